@@ -41,7 +41,7 @@ func createUser(_ *cobra.Command, args []string) {
 			return
 		}
 
-		if _, err = users.UpdatePassword(ctx, u.ID, pass); err != nil {
+		if _, err = users.UpdatePassword(ctx, u.ID, pass, 0); err != nil {
 			logger.Error().Err(err).Msg("User already exists. Unable to update password")
 			return
 		}
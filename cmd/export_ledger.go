@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"context"
+	"io"
+	"os"
+	"time"
+
+	"github.com/cryptolink/cryptolink/internal/app"
+	"github.com/cryptolink/cryptolink/internal/service/ledger"
+	"github.com/cryptolink/cryptolink/internal/util"
+	"github.com/spf13/cobra"
+)
+
+// export-ledger writes merchant's ledger journals as an accounting software
+// import file, same as the "ledger/export" merchant API endpoint.
+var exportLedgerCommand = &cobra.Command{
+	Use:   "export-ledger",
+	Short: "Export merchant's ledger for accounting software (xero, quickbooks, journal)",
+	Run:   exportLedger,
+}
+
+var exportLedgerArgs = struct {
+	MerchantID *int64
+	Format     *string
+	From       *string
+	To         *string
+	IsTest     *bool
+	Output     *string
+}{
+	MerchantID: util.Ptr(int64(0)),
+	Format:     util.Ptr(""),
+	From:       util.Ptr(""),
+	To:         util.Ptr(""),
+	IsTest:     util.Ptr(false),
+	Output:     util.Ptr(""),
+}
+
+func exportLedger(_ *cobra.Command, _ []string) {
+	const dateLayout = "2006-01-02"
+
+	var (
+		ctx             = context.Background()
+		cfg             = resolveConfig()
+		service         = app.New(ctx, cfg)
+		merchantService = service.Locator().MerchantService()
+		ledgerService   = service.Locator().LedgerService()
+		logger          = service.Logger()
+		exit            = func(err error, message string) { logger.Fatal().Err(err).Msg(message) }
+	)
+
+	format, err := ledger.ParseExportFormat(*exportLedgerArgs.Format)
+	if err != nil {
+		exit(err, "invalid format")
+	}
+
+	from, err := time.Parse(dateLayout, *exportLedgerArgs.From)
+	if err != nil {
+		exit(err, "invalid from date")
+	}
+
+	to, err := time.Parse(dateLayout, *exportLedgerArgs.To)
+	if err != nil {
+		exit(err, "invalid to date")
+	}
+
+	mt, err := merchantService.GetByID(ctx, *exportLedgerArgs.MerchantID, true)
+	if err != nil {
+		exit(err, "unable to get merchant")
+	}
+
+	var out io.Writer = os.Stdout
+	if *exportLedgerArgs.Output != "" {
+		f, err := os.Create(*exportLedgerArgs.Output)
+		if err != nil {
+			exit(err, "unable to create output file")
+		}
+		defer f.Close()
+
+		out = f
+	}
+
+	r := ledger.Range{
+		MerchantID: mt.ID,
+		IsTest:     *exportLedgerArgs.IsTest,
+		From:       from,
+		To:         to.Add(24 * time.Hour), // inclusive
+	}
+
+	names := ledger.NewAccountNames(mt.Settings().AccountingAccounts())
+
+	if err := ledgerService.Export(ctx, out, r, format, names); err != nil {
+		exit(err, "unable to export ledger")
+	}
+}
+
+func exportLedgerSetup(cmd *cobra.Command) {
+	f := cmd.Flags()
+
+	f.Int64Var(exportLedgerArgs.MerchantID, "merchant-id", 0, "Merchant ID")
+	f.StringVar(exportLedgerArgs.Format, "format", string(ledger.ExportJournal), "Export format: xero, quickbooks or journal")
+	f.StringVar(exportLedgerArgs.From, "from", "", "First day, YYYY-MM-DD (UTC)")
+	f.StringVar(exportLedgerArgs.To, "to", "", "Last day inclusive, YYYY-MM-DD (UTC)")
+	f.BoolVar(exportLedgerArgs.IsTest, "is-test", false, "Export test network journals")
+	f.StringVar(exportLedgerArgs.Output, "output", "", "Output file, stdout if empty")
+
+	for _, name := range []string{"merchant-id", "from", "to"} {
+		if err := cmd.MarkFlagRequired(name); err != nil {
+			panic(name + ": " + err.Error())
+		}
+	}
+}
@@ -86,4 +86,7 @@ func init() {
 
 	recoverPaymentSetup(recoverPaymentCommand)
 	rootCmd.AddCommand(recoverPaymentCommand)
+
+	exportLedgerSetup(exportLedgerCommand)
+	rootCmd.AddCommand(exportLedgerCommand)
 }
@@ -5,10 +5,12 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"sync"
 	"time"
 
 	"github.com/cryptolink/cryptolink/internal/bus"
 	"github.com/cryptolink/cryptolink/internal/config"
+	"github.com/cryptolink/cryptolink/internal/event/billingevents"
 	"github.com/cryptolink/cryptolink/internal/event/paymentevents"
 	"github.com/cryptolink/cryptolink/internal/event/userevents"
 	"github.com/cryptolink/cryptolink/internal/locator"
@@ -40,6 +42,9 @@ type App struct {
 	logger    *zerolog.Logger
 	services  *locator.Locator
 	beforeRun []BeforeRun
+
+	// both RunServer and RunScheduler start consumers, e.g. in all-in-one mode
+	eventHandlersOnce sync.Once
 }
 
 type BeforeRun func(ctx context.Context, app *App) error
@@ -79,6 +84,11 @@ func (app *App) RunServer() {
 		app.services.XpubService(),
 		app.services.EvmCollectorService(),
 		app.services.SubscriptionService(),
+		app.services.BillingService(),
+		app.services.LedgerService(),
+		app.services.WebhookService(),
+		app.services.EmailService(),
+		app.services.AuditService(),
 		app.services.BlockchainService(),
 		app.services.EventBus(),
 		app.Logger(),
@@ -135,6 +145,12 @@ func (app *App) RunServer() {
 		app.services.ProcessingService(),
 		app.services.TransactionService(),
 		app.services.WatcherService(),
+		app.services.BillingService(),
+		app.services.EvmCollectorService(),
+		app.services.LedgerService(),
+		app.services.ReportService(),
+		app.services.WebhookService(),
+		app.services.EventBus(),
 		app.services.JobLogger(),
 	)
 
@@ -175,7 +191,8 @@ func (app *App) RunServer() {
 		httpServer.When(withInternalAPI, httpServer.WithAuthDebug(web.AuthDebugFiles())),
 	)
 
-	app.registerEventHandlers()
+	// wakes up merchant event streams served by this process
+	go app.services.WebhookService().WatchStream(app.ctx)
 
 	// Start marketing queue processor (background goroutine)
 	go app.services.MarketingService().StartQueueProcessor(app.ctx)
@@ -214,6 +231,15 @@ func (app *App) RunServer() {
 		}
 	}
 
+	// payment event streams are served by every replica
+	if err := app.services.EventBus().RegisterBroadcastHandler(paymentAPIHandler.Stream()); err != nil {
+		app.logger.Fatal().Err(err).Msg("unable to register payment stream handler")
+		return
+	}
+
+	// after migrations
+	app.registerEventHandlers()
+
 	go func() {
 		app.logger.Info().Str("address", srv.Address()).Msg("starting http server")
 		if err := srv.Run(); err != nil && err != http.ErrServerClosed {
@@ -226,8 +252,6 @@ func (app *App) RunServer() {
 		app.logger.Info().Msg("shutting down http server")
 		return srv.Shutdown(app.ctx)
 	})
-
-	graceful.AddCallback(app.services.EventBus().Shutdown)
 }
 
 func (app *App) RunScheduler() {
@@ -240,6 +264,12 @@ func (app *App) RunScheduler() {
 		app.services.ProcessingService(),
 		app.services.TransactionService(),
 		app.services.WatcherService(),
+		app.services.BillingService(),
+		app.services.EvmCollectorService(),
+		app.services.LedgerService(),
+		app.services.ReportService(),
+		app.services.WebhookService(),
+		app.services.EventBus(),
 		app.services.JobLogger(),
 	)
 
@@ -247,14 +277,38 @@ func (app *App) RunScheduler() {
 
 	register("@every 15s", "watchPendingAddresses", jobs.WatchPendingAddresses, false)
 
+	register("@every 10s", "deliverWebhooks", jobs.DeliverWebhooks, false)
+
+	register("@every 1h", "purgeWebhookDeliveries", jobs.PurgeWebhookDeliveries, false)
+
+	register("@every 1m", "reportEventBusLag", jobs.ReportEventBusLag, false)
+
+	register("@every 1h", "purgeEventBusMessages", jobs.PurgeEventBusMessages, false)
+
 	register("@every 30s", "checkIncomingTransactionsProgress", jobs.CheckIncomingTransactionsProgress, false)
 
 	register("@every 2m", "cancelExpiredPayments", jobs.CancelExpiredPayments, false)
 
 	register("@every 5m", "recheckPartialFills", jobs.RecheckPartialFills, false)
+
+	register("@every 1m", "processCustomerSubscriptions", jobs.ProcessCustomerSubscriptions, false)
+
+	register("@every 6h", "reverifyEvmCollectors", jobs.ReverifyEvmCollectors, false)
+
+	register("@every 30s", "processEvmCollectorDeployments", jobs.ProcessEvmCollectorDeployments, false)
+
+	register("@every 2m", "indexEvmCollectorWithdrawals", jobs.IndexEvmCollectorWithdrawals, false)
+
+	register("@every 5m", "postLedgerCollectorWithdrawals", jobs.PostLedgerCollectorWithdrawals, false)
+
+	register("@every 15m", "sendSettlementReports", jobs.SendSettlementReports, false)
 }
 
 func (app *App) registerEventHandlers() {
+	app.eventHandlersOnce.Do(app.startEventBus)
+}
+
+func (app *App) startEventBus() {
 	handlers := []bus.Handler{
 		paymentevents.New(
 			app.services.MerchantService(),
@@ -264,6 +318,11 @@ func (app *App) registerEventHandlers() {
 			app.config.Notifications.SlackWebhookURL,
 			app.logger,
 		),
+		billingevents.New(
+			app.services.BillingService(),
+			app.services.WebhookService(),
+			app.logger,
+		),
 		userevents.New(
 			app.config.Env,
 			app.config.Notifications.SlackWebhookURL,
@@ -277,6 +336,12 @@ func (app *App) registerEventHandlers() {
 			panic(errors.Wrapf(err, "unable to register handler %T", h))
 		}
 	}
+
+	if err := app.services.EventBus().Start(); err != nil {
+		panic(errors.Wrap(err, "unable to start event bus"))
+	}
+
+	graceful.AddCallback(app.services.EventBus().Shutdown)
 }
 
 type registerFunc func(cronSpec, name string, job jobFunc, enableTableLogging bool)
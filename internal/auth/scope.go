@@ -0,0 +1,183 @@
+package auth
+
+import (
+	"net"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Scope is a permission granted to a merchant API token. Scopes have the form
+// "resource:action"; "resource:*" grants every action on the resource and "*"
+// grants everything.
+type Scope = string
+
+const (
+	ScopeAll Scope = "*"
+
+	ScopePaymentsRead    Scope = "payments:read"
+	ScopePaymentsWrite   Scope = "payments:write"
+	ScopePaymentsResolve Scope = "payments:resolve"
+
+	ScopePaymentLinksRead  Scope = "payment_links:read"
+	ScopePaymentLinksWrite Scope = "payment_links:write"
+
+	ScopeCustomersRead Scope = "customers:read"
+
+	ScopeBalancesRead Scope = "balances:read"
+	ScopeRefundsWrite Scope = "refunds:write"
+
+	ScopeWebhooksRead  Scope = "webhooks:read"
+	ScopeWebhooksWrite Scope = "webhooks:write"
+
+	ScopeSubscriptionsRead  Scope = "subscriptions:read"
+	ScopeSubscriptionsWrite Scope = "subscriptions:write"
+
+	scopeWildcard = "*"
+)
+
+var (
+	ErrInvalidScope      = errors.New("invalid scope")
+	ErrInvalidCIDR       = errors.New("invalid CIDR")
+	ErrInvalidExpiration = errors.New("token expiration should be in the future")
+)
+
+// Scopes lists all known scopes except wildcards.
+var Scopes = []Scope{
+	ScopePaymentsRead,
+	ScopePaymentsWrite,
+	ScopePaymentsResolve,
+	ScopePaymentLinksRead,
+	ScopePaymentLinksWrite,
+	ScopeCustomersRead,
+	ScopeBalancesRead,
+	ScopeRefundsWrite,
+	ScopeWebhooksRead,
+	ScopeWebhooksWrite,
+	ScopeSubscriptionsRead,
+	ScopeSubscriptionsWrite,
+}
+
+// ValidateScopes checks that every scope is known, "*" or "resource:*" of a
+// known resource. Returns deduplicated scopes.
+func ValidateScopes(scopes []string) ([]string, error) {
+	if len(scopes) == 0 {
+		return nil, errors.Wrap(ErrInvalidScope, "at least one scope is required")
+	}
+
+	seen := make(map[string]struct{}, len(scopes))
+	result := make([]string, 0, len(scopes))
+
+	for _, scope := range scopes {
+		scope = strings.TrimSpace(scope)
+		if !isKnownScope(scope) {
+			return nil, errors.Wrapf(ErrInvalidScope, "unknown scope %q", scope)
+		}
+
+		if _, ok := seen[scope]; ok {
+			continue
+		}
+
+		seen[scope] = struct{}{}
+		result = append(result, scope)
+	}
+
+	return result, nil
+}
+
+func isKnownScope(scope string) bool {
+	if scope == ScopeAll {
+		return true
+	}
+
+	for _, known := range Scopes {
+		if scope == known || scope == scopeResource(known)+":"+scopeWildcard {
+			return true
+		}
+	}
+
+	return false
+}
+
+func scopeResource(scope string) string {
+	resource, _, _ := strings.Cut(scope, ":")
+	return resource
+}
+
+// ParseCIDRs validates an allow-list. Plain IP addresses are treated as
+// single-host networks. Returns normalized CIDRs.
+func ParseCIDRs(cidrs []string) ([]string, error) {
+	result := make([]string, 0, len(cidrs))
+
+	for _, raw := range cidrs {
+		network, err := parseCIDR(strings.TrimSpace(raw))
+		if err != nil {
+			return nil, errors.Wrapf(ErrInvalidCIDR, "%q", raw)
+		}
+
+		result = append(result, network.String())
+	}
+
+	return result, nil
+}
+
+func parseCIDR(raw string) (*net.IPNet, error) {
+	if !strings.Contains(raw, "/") {
+		ip := net.ParseIP(raw)
+		if ip == nil {
+			return nil, ErrInvalidCIDR
+		}
+
+		bits := 128
+		if ip4 := ip.To4(); ip4 != nil {
+			ip, bits = ip4, 32
+		}
+
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+	}
+
+	_, network, err := net.ParseCIDR(raw)
+
+	return network, err
+}
+
+// HasScope checks whether the token grants the scope.
+func (t *APIToken) HasScope(scope Scope) bool {
+	for _, granted := range t.Scopes {
+		switch {
+		case granted == ScopeAll, granted == scope:
+			return true
+		case strings.HasSuffix(granted, ":"+scopeWildcard) && scopeResource(granted) == scopeResource(scope):
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsExpired checks whether the token is expired at the given time.
+func (t *APIToken) IsExpired(now time.Time) bool {
+	return t.ExpiresAt != nil && !now.Before(*t.ExpiresAt)
+}
+
+// AllowsIP checks client's IP against token's allow-list. Empty list allows any IP.
+func (t *APIToken) AllowsIP(ip string) bool {
+	if len(t.AllowedCIDRs) == 0 {
+		return true
+	}
+
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+
+	for _, raw := range t.AllowedCIDRs {
+		network, err := parseCIDR(raw)
+		if err == nil && network.Contains(parsed) {
+			return true
+		}
+	}
+
+	return false
+}
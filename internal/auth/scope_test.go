@@ -0,0 +1,90 @@
+package auth_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cryptolink/cryptolink/internal/auth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateScopes(t *testing.T) {
+	for _, tt := range []struct {
+		scopes    []string
+		expected  []string
+		expectErr bool
+	}{
+		{scopes: []string{"*"}, expected: []string{"*"}},
+		{scopes: []string{"payments:write", "payments:write"}, expected: []string{"payments:write"}},
+		{scopes: []string{"payment_links:*", " customers:read"}, expected: []string{"payment_links:*", "customers:read"}},
+		{scopes: nil, expectErr: true},
+		{scopes: []string{"payments:delete"}, expectErr: true},
+		{scopes: []string{"unknown:*"}, expectErr: true},
+		{scopes: []string{"payments"}, expectErr: true},
+	} {
+		t.Run("", func(t *testing.T) {
+			actual, err := auth.ValidateScopes(tt.scopes)
+			if tt.expectErr {
+				assert.ErrorIs(t, err, auth.ErrInvalidScope)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, actual)
+		})
+	}
+}
+
+func TestAPIToken_HasScope(t *testing.T) {
+	for _, tt := range []struct {
+		granted  []string
+		scope    string
+		expected bool
+	}{
+		{granted: []string{"*"}, scope: auth.ScopeCustomersRead, expected: true},
+		{granted: []string{"payments:write"}, scope: auth.ScopePaymentsWrite, expected: true},
+		{granted: []string{"payments:write"}, scope: auth.ScopePaymentsRead, expected: false},
+		{granted: []string{"payments:write"}, scope: auth.ScopePaymentsResolve, expected: false},
+		{granted: []string{"webhooks:*"}, scope: auth.ScopeWebhooksWrite, expected: true},
+		{granted: []string{"webhooks:*"}, scope: auth.ScopePaymentsRead, expected: false},
+		{granted: nil, scope: auth.ScopePaymentsRead, expected: false},
+	} {
+		t.Run(tt.scope, func(t *testing.T) {
+			token := &auth.APIToken{Scopes: tt.granted}
+			assert.Equal(t, tt.expected, token.HasScope(tt.scope))
+		})
+	}
+}
+
+func TestParseCIDRs(t *testing.T) {
+	actual, err := auth.ParseCIDRs([]string{"203.0.113.7", "10.1.2.3/8", "2001:db8::1"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"203.0.113.7/32", "10.0.0.0/8", "2001:db8::1/128"}, actual)
+
+	_, err = auth.ParseCIDRs([]string{"10.0.0.0/33"})
+	assert.ErrorIs(t, err, auth.ErrInvalidCIDR)
+
+	_, err = auth.ParseCIDRs([]string{"localhost"})
+	assert.ErrorIs(t, err, auth.ErrInvalidCIDR)
+}
+
+func TestAPIToken_AllowsIP(t *testing.T) {
+	token := &auth.APIToken{AllowedCIDRs: []string{"203.0.113.7/32", "10.0.0.0/8"}}
+
+	assert.True(t, token.AllowsIP("203.0.113.7"))
+	assert.True(t, token.AllowsIP("10.20.30.40"))
+	assert.False(t, token.AllowsIP("203.0.113.8"))
+	assert.False(t, token.AllowsIP(""))
+
+	assert.True(t, (&auth.APIToken{}).AllowsIP("198.51.100.1"))
+}
+
+func TestAPIToken_IsExpired(t *testing.T) {
+	now := time.Now()
+	past, future := now.Add(-time.Minute), now.Add(time.Minute)
+
+	assert.False(t, (&auth.APIToken{}).IsExpired(now))
+	assert.False(t, (&auth.APIToken{ExpiresAt: &future}).IsExpired(now))
+	assert.True(t, (&auth.APIToken{ExpiresAt: &past}).IsExpired(now))
+}
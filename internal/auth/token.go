@@ -24,14 +24,28 @@ type TokenAuthManager struct {
 type TokenType string
 
 type APIToken struct {
-	ID         int64
-	EntityType TokenType
-	EntityID   int64
-	CreatedAt  time.Time
-	Token      string
-	Name       *string
-	UUID       uuid.UUID
-	Settings   []byte
+	ID           int64
+	EntityType   TokenType
+	EntityID     int64
+	CreatedAt    time.Time
+	Token        string
+	Name         *string
+	UUID         uuid.UUID
+	Settings     []byte
+	Scopes       []string
+	ExpiresAt    *time.Time
+	LastUsedAt   *time.Time
+	LastUsedIP   *string
+	AllowedCIDRs []string
+}
+
+type CreateTokenParams struct {
+	Name string
+	// Scopes granted to the token, see Scopes. Empty means full access.
+	Scopes    []string
+	ExpiresAt *time.Time
+	// AllowedCIDRs client IP allow-list. Empty means any IP.
+	AllowedCIDRs []string
 }
 
 var (
@@ -43,6 +57,9 @@ const (
 	TokenTypeMerchant TokenType = "merchant"
 
 	tokenRuneSource = "abcdefghijklmnopqrstuvwxyz" + "ABCDEFGHIJKLMNOPQRSTUVWXYZ" + "0123456789" + ".$;"
+
+	// tokenUsageThrottle skips last-used updates of a token used recently from the same IP.
+	tokenUsageThrottle = time.Minute
 )
 
 func NewTokenAuth(repo *repository.Queries, logger *zerolog.Logger) *TokenAuthManager {
@@ -93,18 +110,43 @@ func (m *TokenAuthManager) GetMerchantTokenByUUID(ctx context.Context, merchantI
 }
 
 func (m *TokenAuthManager) CreateUserToken(ctx context.Context, merchantID int64, name string) (*APIToken, error) {
-	return m.createToken(ctx, TokenTypeUser, merchantID, name)
+	return m.createToken(ctx, TokenTypeUser, merchantID, CreateTokenParams{Name: name})
 }
 
-func (m *TokenAuthManager) CreateMerchantToken(ctx context.Context, merchantID int64, name string) (*APIToken, error) {
-	return m.createToken(ctx, TokenTypeMerchant, merchantID, name)
+// CreateMerchantToken creates merchant API token. Returns ErrInvalidScope,
+// ErrInvalidCIDR or ErrInvalidExpiration on invalid params.
+func (m *TokenAuthManager) CreateMerchantToken(
+	ctx context.Context,
+	merchantID int64,
+	params CreateTokenParams,
+) (*APIToken, error) {
+	return m.createToken(ctx, TokenTypeMerchant, merchantID, params)
 }
 
 func (m *TokenAuthManager) createToken(
 	ctx context.Context,
 	tokenType TokenType,
-	entityID int64, name string,
+	entityID int64,
+	params CreateTokenParams,
 ) (*APIToken, error) {
+	scopes := []string{ScopeAll}
+	if len(params.Scopes) > 0 {
+		var err error
+		if scopes, err = ValidateScopes(params.Scopes); err != nil {
+			return nil, err
+		}
+	}
+
+	cidrs, err := ParseCIDRs(params.AllowedCIDRs)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if params.ExpiresAt != nil && !params.ExpiresAt.After(now) {
+		return nil, ErrInvalidExpiration
+	}
+
 	token, err := generateToken(64)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate token: %w", err)
@@ -113,16 +155,19 @@ func (m *TokenAuthManager) createToken(
 	entry, err := m.repo.CreateAPIToken(ctx, repository.CreateAPITokenParams{
 		EntityType: string(tokenType),
 		EntityID:   entityID,
-		CreatedAt:  time.Now(),
+		CreatedAt:  now,
 		Token:      token,
 		Uuid:       uuid.New(),
 		Name: sql.NullString{
 			Valid:  true,
-			String: name,
+			String: params.Name,
 		},
 		Settings: pgtype.JSONB{
 			Status: pgtype.Null,
 		},
+		Scopes:       scopes,
+		ExpiresAt:    repository.PointerTimeToNullable(params.ExpiresAt),
+		AllowedCidrs: cidrs,
 	})
 
 	if err != nil {
@@ -162,16 +207,47 @@ func (m *TokenAuthManager) DeleteToken(ctx context.Context, id int64) error {
 	return m.repo.DeleteAPITokenByID(ctx, id)
 }
 
+// MarkUsed records token's last usage time and client IP. Updates are
+// throttled for tokens that were recently used from the same IP.
+func (m *TokenAuthManager) MarkUsed(ctx context.Context, token *APIToken, ip string) error {
+	now := time.Now()
+
+	recent := token.LastUsedAt != nil && now.Sub(*token.LastUsedAt) < tokenUsageThrottle
+	sameIP := token.LastUsedIP != nil && *token.LastUsedIP == ip
+	if recent && sameIP {
+		return nil
+	}
+
+	err := m.repo.UpdateAPITokenLastUsed(ctx, repository.UpdateAPITokenLastUsedParams{
+		ID:         token.ID,
+		LastUsedAt: repository.TimeToNullable(now),
+		LastUsedIp: repository.StringToNullable(ip),
+	})
+	if err != nil {
+		return err
+	}
+
+	token.LastUsedAt = &now
+	token.LastUsedIP = &ip
+
+	return nil
+}
+
 func entryToAPIToken(entry repository.ApiToken) *APIToken {
 	return &APIToken{
-		ID:         entry.ID,
-		EntityType: TokenType(entry.EntityType),
-		EntityID:   entry.EntityID,
-		CreatedAt:  entry.CreatedAt,
-		Token:      entry.Token,
-		Name:       repository.NullableStringToPointer(entry.Name),
-		UUID:       entry.Uuid,
-		Settings:   []byte{}, // todo
+		ID:           entry.ID,
+		EntityType:   TokenType(entry.EntityType),
+		EntityID:     entry.EntityID,
+		CreatedAt:    entry.CreatedAt,
+		Token:        entry.Token,
+		Name:         repository.NullableStringToPointer(entry.Name),
+		UUID:         entry.Uuid,
+		Settings:     []byte{}, // todo
+		Scopes:       entry.Scopes,
+		ExpiresAt:    repository.NullTimeToPointer(entry.ExpiresAt),
+		LastUsedAt:   repository.NullTimeToPointer(entry.LastUsedAt),
+		LastUsedIP:   repository.NullableStringToPointer(entry.LastUsedIp),
+		AllowedCIDRs: entry.AllowedCidrs,
 	}
 }
 
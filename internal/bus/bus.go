@@ -5,12 +5,14 @@ import (
 	"encoding/json"
 
 	evbus "github.com/asaskevich/EventBus"
+	"github.com/cryptolink/cryptolink/internal/db/repository"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
 )
 
 // PubSub simple pub-sub implementation. Works locally using in-memory store.
-// No persistence, no semaphores. Should do the job until we switch to a real bus (e.g. Kafka).
+// No persistence, no semaphores. Consumers registered in other processes
+// don't receive messages, see Durable for the persistent bus.
 type PubSub struct {
 	ctx    context.Context
 	bus    evbus.Bus
@@ -28,6 +30,9 @@ type Handler interface {
 
 type Publisher interface {
 	Publish(topic Topic, message any) error
+
+	// PublishTx publishes message as a part of q's transaction.
+	PublishTx(ctx context.Context, q repository.Querier, topic Topic, message any) error
 }
 
 func NewPubSub(ctx context.Context, async bool, logger *zerolog.Logger) *PubSub {
@@ -97,6 +102,11 @@ func (p *PubSub) Publish(topic Topic, message any) error {
 	return nil
 }
 
+// PublishTx publishes message immediately, PubSub is not transactional.
+func (p *PubSub) PublishTx(_ context.Context, _ repository.Querier, topic Topic, message any) error {
+	return p.Publish(topic, message)
+}
+
 func (p *PubSub) Shutdown() error {
 	p.logger.Info().Msg("Shutting down event listener")
 	p.bus.WaitAsync()
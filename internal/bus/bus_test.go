@@ -107,3 +107,18 @@ func TestPubSub_Publish(t *testing.T) {
 		assert.NoError(t, bus.Shutdown())
 	})
 }
+
+func (s sampleMessage) consume(_ context.Context, _ Message) error {
+	return nil
+}
+
+func TestConsumerName(t *testing.T) {
+	assert.Equal(t, "bus.sampleMessage.consume", consumerName(sampleMessage{}.consume))
+	assert.Equal(t, "bus.TestConsumerName.func1", consumerName(func(context.Context, Message) error { return nil }))
+}
+
+func TestRetryDelay(t *testing.T) {
+	assert.Equal(t, time.Second, retryDelay(1))
+	assert.Equal(t, 8*time.Second, retryDelay(4))
+	assert.Equal(t, retryMaxDelay, retryDelay(10))
+}
@@ -0,0 +1,640 @@
+package bus
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cryptolink/cryptolink/internal/db/repository"
+	"github.com/google/uuid"
+	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+const (
+	// notifyChannel is the Postgres channel publishers notify on commit,
+	// notification payload is the topic.
+	notifyChannel = "event_bus"
+
+	consumeBatchSize = 100
+
+	// pollInterval is how often consumers check for messages when no
+	// notification arrives, e.g. while the listener reconnects.
+	pollInterval = 5 * time.Second
+
+	// MaxAttempts how many times a consumer is called with the same message
+	// before the message is moved to dead letters.
+	MaxAttempts = 5
+
+	retryBaseDelay = time.Second
+	retryMaxDelay  = 30 * time.Second
+
+	// consumeLease how long a process holds a consumer without saving its
+	// offset. Should exceed handling of a single message with all retries.
+	consumeLease = 2 * time.Minute
+
+	// Retention of processed messages.
+	Retention = 7 * 24 * time.Hour
+
+	// LagWarningThreshold is consumer lag reported as a warning.
+	LagWarningThreshold = time.Minute
+)
+
+// Durable is Postgres-backed transactional outbox bus. Messages are written
+// to event_bus_messages, within publisher's transaction when PublishTx is
+// used, and consumed at least once by every consumer in publishing order.
+// Each consumer keeps its own offset, so consumers registered in any process
+// receive all messages; a consumer runs in one process at a time.
+// A message that fails MaxAttempts times is moved to event_bus_dead_letters
+// and the consumer proceeds.
+// Broadcast consumers, see SubscribeBroadcast, run in every process instead.
+type Durable struct {
+	ctx    context.Context
+	store  *repository.Store
+	pool   *pgxpool.Pool
+	logger *zerolog.Logger
+
+	// owner identifies this process in consumer leases.
+	owner string
+
+	mu        sync.Mutex
+	consumers []*durableConsumer
+	started   bool
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+type durableConsumer struct {
+	name  string
+	topic Topic
+	fn    Consumer
+	wake  chan struct{}
+
+	// broadcast consumer keeps its offset in memory
+	broadcast     bool
+	lastTxID      int64
+	lastMessageID int64
+}
+
+// ConsumerStats is consumer position. Lag is age of the oldest message
+// the consumer hasn't processed yet.
+type ConsumerStats struct {
+	Consumer    string
+	Topic       Topic
+	Pending     int64
+	Lag         time.Duration
+	DeadLetters int64
+	UpdatedAt   time.Time
+}
+
+func NewDurable(ctx context.Context, store *repository.Store, pool *pgxpool.Pool, logger *zerolog.Logger) *Durable {
+	log := logger.With().Str("channel", "event_bus").Logger()
+
+	return &Durable{
+		ctx:    ctx,
+		store:  store,
+		pool:   pool,
+		logger: &log,
+		owner:  uuid.NewString(),
+	}
+}
+
+func (p *Durable) RegisterHandler(h Handler) error {
+	for topic, consumers := range h.Consumers() {
+		for _, c := range consumers {
+			if err := p.Subscribe(topic, c); err != nil {
+				return errors.Wrapf(err, "unable to subscibe to topic %q", topic)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Subscribe registers consumer of the topic. Consumer is identified by its
+// function name, e.g. "paymentevents.(*Handler).ProcessPaymentStatusUpdate";
+// renaming the function starts a new consumer from the end of the topic.
+// Consumers are run by Start.
+func (p *Durable) Subscribe(topic Topic, fn Consumer) error {
+	return p.subscribe(topic, fn, false)
+}
+
+// RegisterBroadcastHandler subscribes all handler's consumers with
+// SubscribeBroadcast.
+func (p *Durable) RegisterBroadcastHandler(h Handler) error {
+	for topic, consumers := range h.Consumers() {
+		for _, c := range consumers {
+			if err := p.SubscribeBroadcast(topic, c); err != nil {
+				return errors.Wrapf(err, "unable to subscibe to topic %q", topic)
+			}
+		}
+	}
+
+	return nil
+}
+
+// SubscribeBroadcast registers consumer of the topic that runs in every
+// process, e.g. to fan out messages to clients connected to this process.
+// Broadcast consumer receives messages published after Start at most once:
+// its offset is kept in memory, failed messages are logged and skipped.
+func (p *Durable) SubscribeBroadcast(topic Topic, fn Consumer) error {
+	return p.subscribe(topic, fn, true)
+}
+
+func (p *Durable) subscribe(topic Topic, fn Consumer, broadcast bool) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.started {
+		return errors.New("bus is already started")
+	}
+
+	name := consumerName(fn)
+
+	for _, c := range p.consumers {
+		if c.topic == topic && c.name == name {
+			return errors.Errorf("consumer %q is already subscribed", name)
+		}
+	}
+
+	p.consumers = append(p.consumers, &durableConsumer{
+		name:      name,
+		topic:     topic,
+		fn:        fn,
+		wake:      make(chan struct{}, 1),
+		broadcast: broadcast,
+	})
+
+	return nil
+}
+
+// Publish writes message to the bus outside any transaction.
+func (p *Durable) Publish(topic Topic, message any) error {
+	return p.PublishTx(p.ctx, p.store, topic, message)
+}
+
+// PublishTx writes message within transaction of q: consumers receive it
+// only if the transaction commits.
+func (p *Durable) PublishTx(ctx context.Context, q repository.Querier, topic Topic, message any) error {
+	raw, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	payload := pgtype.JSONB{Bytes: raw, Status: pgtype.Present}
+
+	if _, err := q.CreateEventBusMessage(ctx, string(topic), payload, time.Now().UTC()); err != nil {
+		return errors.Wrapf(err, "unable to publish %q message", topic)
+	}
+
+	return errors.Wrap(q.NotifyEventBus(ctx, string(topic)), "unable to notify consumers")
+}
+
+// Start runs registered consumers in background until Shutdown.
+func (p *Durable) Start() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.started {
+		return errors.New("bus is already started")
+	}
+
+	now := time.Now().UTC()
+	for _, c := range p.consumers {
+		if c.broadcast {
+			txID, id, err := p.store.GetEventBusHead(p.ctx, string(c.topic))
+			if err != nil {
+				return errors.Wrapf(err, "unable to get head of topic %q", c.topic)
+			}
+
+			c.lastTxID, c.lastMessageID = txID, id
+
+			continue
+		}
+
+		if err := p.store.CreateEventBusOffset(p.ctx, c.name, string(c.topic), now); err != nil {
+			return errors.Wrapf(err, "unable to register consumer %q", c.name)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(p.ctx)
+	p.cancel = cancel
+	p.started = true
+
+	p.wg.Add(1 + len(p.consumers))
+
+	go p.listen(ctx)
+
+	for _, c := range p.consumers {
+		go p.run(ctx, c)
+	}
+
+	p.logger.Info().Int("consumers", len(p.consumers)).Msg("started event bus consumers")
+
+	return nil
+}
+
+func (p *Durable) Shutdown() error {
+	p.logger.Info().Msg("Shutting down event listener")
+
+	p.mu.Lock()
+	cancel := p.cancel
+	p.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+	}
+
+	p.wg.Wait()
+
+	return nil
+}
+
+// Stats returns positions of all consumers known to the database, including
+// ones registered by other processes.
+func (p *Durable) Stats(ctx context.Context) ([]ConsumerStats, error) {
+	rows, err := p.store.ListEventBusConsumerStats(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to list consumer stats")
+	}
+
+	now := time.Now().UTC()
+	stats := make([]ConsumerStats, len(rows))
+
+	for i, row := range rows {
+		stats[i] = ConsumerStats{
+			Consumer:    row.Consumer,
+			Topic:       Topic(row.Topic),
+			Pending:     row.Pending,
+			DeadLetters: row.DeadLetters,
+			UpdatedAt:   row.UpdatedAt,
+		}
+
+		if row.OldestPendingAt.Valid {
+			stats[i].Lag = now.Sub(row.OldestPendingAt.Time)
+		}
+	}
+
+	return stats, nil
+}
+
+// ReportLag logs lag of every consumer, as a warning when it exceeds
+// LagWarningThreshold.
+func (p *Durable) ReportLag(ctx context.Context) error {
+	stats, err := p.Stats(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, s := range stats {
+		event := p.logger.Info()
+		if s.Lag > LagWarningThreshold {
+			event = p.logger.Warn()
+		}
+
+		event.
+			Str("consumer", s.Consumer).
+			Str("topic", string(s.Topic)).
+			Int64("pending", s.Pending).
+			Float64("lag_seconds", s.Lag.Seconds()).
+			Int64("dead_letters", s.DeadLetters).
+			Msg("event bus consumer lag")
+	}
+
+	return nil
+}
+
+// PurgeConsumed deletes messages older than Retention that all consumers
+// have processed.
+func (p *Durable) PurgeConsumed(ctx context.Context) error {
+	const batch = 1000
+
+	before := time.Now().UTC().Add(-Retention)
+
+	var total int64
+	for {
+		deleted, err := p.store.DeleteEventBusMessagesBefore(ctx, before, batch)
+		if err != nil {
+			return errors.Wrap(err, "unable to delete event bus messages")
+		}
+
+		total += deleted
+		if deleted < batch {
+			break
+		}
+	}
+
+	p.logger.Info().Int64("deleted", total).Msg("purged event bus messages")
+
+	return nil
+}
+
+// listen wakes consumers up on notifications. Connection is dedicated since
+// LISTEN is bound to it.
+func (p *Durable) listen(ctx context.Context) {
+	defer p.wg.Done()
+
+	for {
+		err := p.listenOnce(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+
+		p.logger.Warn().Err(err).Msg("event bus listener disconnected")
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+func (p *Durable) listenOnce(ctx context.Context) error {
+	conn, err := pgx.ConnectConfig(ctx, p.pool.Config().ConnConfig)
+	if err != nil {
+		return errors.Wrap(err, "unable to connect")
+	}
+
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+notifyChannel); err != nil {
+		return errors.Wrap(err, "unable to listen")
+	}
+
+	// messages published while listener was down
+	p.wakeAll()
+
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, c := range p.consumers {
+			if string(c.topic) == n.Payload {
+				c.notify()
+			}
+		}
+	}
+}
+
+func (p *Durable) wakeAll() {
+	for _, c := range p.consumers {
+		c.notify()
+	}
+}
+
+func (c *durableConsumer) notify() {
+	select {
+	case c.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (p *Durable) run(ctx context.Context, c *durableConsumer) {
+	defer p.wg.Done()
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	consume := p.consume
+	if c.broadcast {
+		consume = p.consumeBroadcast
+	}
+
+	for {
+		n, err := consume(ctx, c)
+		if err != nil && ctx.Err() == nil {
+			p.logger.Error().Err(err).
+				Str("consumer", c.name).Str("topic", string(c.topic)).
+				Msg("unable to consume messages")
+		}
+
+		if n == consumeBatchSize && err == nil {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			p.release(c)
+			return
+		case <-c.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// consume processes next batch of messages. The consumer is leased to this
+// process, so it runs in one process at a time. The offset is saved after
+// every message in a short transaction: no transaction is open while the
+// consumer runs. On shutdown the batch stops after the message being processed.
+func (p *Durable) consume(ctx context.Context, c *durableConsumer) (int, error) {
+	// offset is saved even if ctx is cancelled in the middle of a batch
+	dbCtx := context.WithoutCancel(ctx)
+
+	now := time.Now().UTC()
+
+	offset, err := p.store.ClaimEventBusOffset(dbCtx, repository.ClaimEventBusOffsetParams{
+		Consumer:    c.name,
+		Topic:       string(c.topic),
+		LockedBy:    p.owner,
+		Now:         now,
+		LockedUntil: now.Add(consumeLease),
+	})
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		// consumed by another process
+		return 0, nil
+	case err != nil:
+		return 0, errors.Wrap(err, "unable to claim offset")
+	}
+
+	messages, err := p.store.ListEventBusMessages(dbCtx, repository.ListEventBusMessagesParams{
+		Topic:     string(c.topic),
+		AfterTxID: offset.LastTxID,
+		AfterID:   offset.LastMessageID,
+		Limit:     consumeBatchSize,
+	})
+	if err != nil {
+		return 0, errors.Wrap(err, "unable to list messages")
+	}
+
+	var processed int
+
+	for _, m := range messages {
+		attempts, errConsume := p.handle(ctx, c, m)
+		if errConsume != nil && ctx.Err() != nil {
+			// shutdown: the message will be consumed again on start
+			break
+		}
+
+		if err := p.commit(dbCtx, c, m, attempts, errConsume); err != nil {
+			return processed, err
+		}
+
+		processed++
+	}
+
+	return processed, nil
+}
+
+// commit advances consumer's offset past the message and extends the lease.
+// Message the consumer failed on is moved to dead letters within the same
+// transaction.
+func (p *Durable) commit(ctx context.Context, c *durableConsumer, m repository.EventBusMessage, attempts int, errConsume error) error {
+	return p.store.RunTransaction(ctx, func(ctx context.Context, q repository.Querier) error {
+		now := time.Now().UTC()
+
+		if errConsume != nil {
+			p.logger.Error().Err(errConsume).
+				Str("consumer", c.name).Str("topic", string(c.topic)).
+				Int64("message_id", m.ID).Bytes("message", m.Payload.Bytes).
+				Msg("consumer failed, moving message to dead letters")
+
+			err := q.CreateEventBusDeadLetter(ctx, repository.CreateEventBusDeadLetterParams{
+				Consumer:  c.name,
+				Topic:     string(c.topic),
+				MessageID: m.ID,
+				Payload:   m.Payload,
+				Attempts:  int32(attempts),
+				LastError: errConsume.Error(),
+				CreatedAt: now,
+			})
+			if err != nil {
+				return errors.Wrap(err, "unable to create dead letter")
+			}
+		}
+
+		updated, err := q.UpdateEventBusOffset(ctx, repository.UpdateEventBusOffsetParams{
+			Consumer:      c.name,
+			Topic:         string(c.topic),
+			LastTxID:      m.TxID,
+			LastMessageID: m.ID,
+			LockedBy:      p.owner,
+			LockedUntil:   now.Add(consumeLease),
+			UpdatedAt:     now,
+		})
+		switch {
+		case err != nil:
+			return errors.Wrap(err, "unable to update offset")
+		case updated == 0:
+			// the message will be consumed again by the current owner
+			return errors.New("consumer lease expired")
+		}
+
+		return nil
+	})
+}
+
+// release ends the lease of the consumer on shutdown, so that another process
+// takes it over without waiting for the lease to expire.
+func (p *Durable) release(c *durableConsumer) {
+	if c.broadcast {
+		return
+	}
+
+	err := p.store.ReleaseEventBusOffset(context.WithoutCancel(p.ctx), c.name, string(c.topic), p.owner)
+	if err != nil {
+		p.logger.Warn().Err(err).
+			Str("consumer", c.name).Str("topic", string(c.topic)).
+			Msg("unable to release consumer")
+	}
+}
+
+// consumeBroadcast processes next batch of messages of a broadcast consumer.
+// No locks are taken: every process reads the topic on its own.
+func (p *Durable) consumeBroadcast(ctx context.Context, c *durableConsumer) (int, error) {
+	messages, err := p.store.ListEventBusMessages(ctx, repository.ListEventBusMessagesParams{
+		Topic:     string(c.topic),
+		AfterTxID: c.lastTxID,
+		AfterID:   c.lastMessageID,
+		Limit:     consumeBatchSize,
+	})
+	if err != nil {
+		return 0, errors.Wrap(err, "unable to list messages")
+	}
+
+	for _, m := range messages {
+		if err := p.call(ctx, c, m); err != nil {
+			p.logger.Error().Err(err).
+				Str("consumer", c.name).Str("topic", string(c.topic)).
+				Int64("message_id", m.ID).Bytes("message", m.Payload.Bytes).
+				Msg("broadcast consumer failed, skipping message")
+		}
+
+		c.lastTxID, c.lastMessageID = m.TxID, m.ID
+	}
+
+	return len(messages), nil
+}
+
+// handle calls consumer with the message up to MaxAttempts times with
+// exponential backoff. Returns the number of attempts and the last error.
+func (p *Durable) handle(ctx context.Context, c *durableConsumer, m repository.EventBusMessage) (int, error) {
+	var err error
+
+	for attempt := 1; attempt <= MaxAttempts; attempt++ {
+		if err = p.call(ctx, c, m); err == nil {
+			return attempt, nil
+		}
+
+		if attempt == MaxAttempts {
+			return attempt, err
+		}
+
+		p.logger.Warn().Err(err).
+			Str("consumer", c.name).Str("topic", string(c.topic)).
+			Int64("message_id", m.ID).Int("attempt", attempt).
+			Msg("consumer failed, will retry")
+
+		select {
+		case <-ctx.Done():
+			return attempt, ctx.Err()
+		case <-time.After(retryDelay(attempt)):
+		}
+	}
+
+	return MaxAttempts, err
+}
+
+func (p *Durable) call(ctx context.Context, c *durableConsumer, m repository.EventBusMessage) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("consumer panic: %v", r)
+		}
+	}()
+
+	return c.fn(ctx, m.Payload.Bytes)
+}
+
+func retryDelay(attempt int) time.Duration {
+	delay := retryBaseDelay << (attempt - 1)
+	if delay > retryMaxDelay {
+		return retryMaxDelay
+	}
+
+	return delay
+}
+
+// consumerName returns package-qualified function name, e.g.
+// "paymentevents.(*Handler).ProcessPaymentStatusUpdate".
+func consumerName(fn Consumer) string {
+	name := runtime.FuncForPC(reflect.ValueOf(fn).Pointer()).Name()
+	name = strings.TrimSuffix(name, "-fm")
+
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+
+	return name
+}
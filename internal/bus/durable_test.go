@@ -0,0 +1,150 @@
+package bus_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cryptolink/cryptolink/internal/bus"
+	"github.com/cryptolink/cryptolink/internal/db/repository"
+	"github.com/cryptolink/cryptolink/internal/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type sampleMessage struct {
+	PaymentID int64
+}
+
+type recorder struct {
+	mu       sync.Mutex
+	received []int64
+}
+
+func (r *recorder) Consume(_ context.Context, message bus.Message) error {
+	sample, err := bus.Bind[sampleMessage](message)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.received = append(r.received, sample.PaymentID)
+
+	return nil
+}
+
+func (r *recorder) Received() []int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]int64(nil), r.received...)
+}
+
+func TestDurable(t *testing.T) {
+	const topic = bus.Topic("test")
+
+	t.Run("Delivers committed messages in order", func(t *testing.T) {
+		// ARRANGE
+		tc := test.NewIntegrationTest(t)
+
+		// Given a bus with a consumer
+		b := bus.NewDurable(tc.Context, tc.Storage, tc.Database.Conn().Pool, tc.Logger)
+
+		r := &recorder{}
+		require.NoError(t, b.Subscribe(topic, r.Consume))
+		require.NoError(t, b.Start())
+		t.Cleanup(func() { _ = b.Shutdown() })
+
+		// ACT
+		// Publish messages: outside of tx, within committed tx and within rolled back tx
+		require.NoError(t, b.Publish(topic, sampleMessage{PaymentID: 1}))
+
+		err := tc.Storage.RunTransaction(tc.Context, func(ctx context.Context, q repository.Querier) error {
+			return b.PublishTx(ctx, q, topic, sampleMessage{PaymentID: 2})
+		})
+		require.NoError(t, err)
+
+		errRollback := errors.New("rollback")
+		err = tc.Storage.RunTransaction(tc.Context, func(ctx context.Context, q repository.Querier) error {
+			require.NoError(t, b.PublishTx(ctx, q, topic, sampleMessage{PaymentID: 3}))
+			return errRollback
+		})
+		require.ErrorIs(t, err, errRollback)
+
+		require.NoError(t, b.Publish(topic, sampleMessage{PaymentID: 4}))
+
+		// ASSERT
+		assert.Eventually(t, func() bool { return len(r.Received()) == 3 }, 5*time.Second, 50*time.Millisecond)
+		assert.Equal(t, []int64{1, 2, 4}, r.Received())
+
+		stats, err := b.Stats(tc.Context)
+		require.NoError(t, err)
+		require.Len(t, stats, 1)
+		assert.Equal(t, int64(0), stats[0].Pending)
+	})
+
+	t.Run("Resumes from the offset after restart", func(t *testing.T) {
+		// ARRANGE
+		tc := test.NewIntegrationTest(t)
+
+		// Given a consumer that processed a message and was stopped
+		r := &recorder{}
+
+		b1 := bus.NewDurable(tc.Context, tc.Storage, tc.Database.Conn().Pool, tc.Logger)
+		require.NoError(t, b1.Subscribe(topic, r.Consume))
+		require.NoError(t, b1.Start())
+
+		require.NoError(t, b1.Publish(topic, sampleMessage{PaymentID: 1}))
+		assert.Eventually(t, func() bool { return len(r.Received()) == 1 }, 5*time.Second, 50*time.Millisecond)
+		require.NoError(t, b1.Shutdown())
+
+		// And a message published while it was down, e.g. by another process
+		publisher := bus.NewDurable(tc.Context, tc.Storage, tc.Database.Conn().Pool, tc.Logger)
+		require.NoError(t, publisher.Publish(topic, sampleMessage{PaymentID: 2}))
+
+		// ACT
+		b2 := bus.NewDurable(tc.Context, tc.Storage, tc.Database.Conn().Pool, tc.Logger)
+		require.NoError(t, b2.Subscribe(topic, r.Consume))
+		require.NoError(t, b2.Start())
+		t.Cleanup(func() { _ = b2.Shutdown() })
+
+		// ASSERT
+		assert.Eventually(t, func() bool { return len(r.Received()) == 2 }, 5*time.Second, 50*time.Millisecond)
+		assert.Equal(t, []int64{1, 2}, r.Received())
+	})
+	t.Run("Consumer runs in one process at a time", func(t *testing.T) {
+		// ARRANGE
+		tc := test.NewIntegrationTest(t)
+
+		// Given the same consumer started by two processes
+		r1, r2 := &recorder{}, &recorder{}
+
+		b1 := bus.NewDurable(tc.Context, tc.Storage, tc.Database.Conn().Pool, tc.Logger)
+		require.NoError(t, b1.Subscribe(topic, r1.Consume))
+		require.NoError(t, b1.Start())
+		t.Cleanup(func() { _ = b1.Shutdown() })
+
+		b2 := bus.NewDurable(tc.Context, tc.Storage, tc.Database.Conn().Pool, tc.Logger)
+		require.NoError(t, b2.Subscribe(topic, r2.Consume))
+		require.NoError(t, b2.Start())
+		t.Cleanup(func() { _ = b2.Shutdown() })
+
+		// ACT
+		for i := int64(1); i <= 10; i++ {
+			require.NoError(t, b1.Publish(topic, sampleMessage{PaymentID: i}))
+		}
+
+		// ASSERT
+		// every message is consumed once by the process holding the lease
+		received := func() int { return len(r1.Received()) + len(r2.Received()) }
+		assert.Eventually(t, func() bool { return received() == 10 }, 5*time.Second, 50*time.Millisecond)
+
+		time.Sleep(200 * time.Millisecond)
+		assert.Equal(t, 10, received())
+		assert.True(t, len(r1.Received()) == 0 || len(r2.Received()) == 0)
+	})
+}
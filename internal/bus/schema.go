@@ -2,8 +2,15 @@ package bus
 
 const (
 	TopicPaymentStatusUpdate Topic = "payment.status"
-	TopicFormSubmissions     Topic = "form.submitted"
-	TopicUserRegistered      Topic = "user.registered"
+
+	// TopicPaymentProgress is published on changes of payment that don't
+	// change its status, e.g. confirmations of incoming transaction.
+	TopicPaymentProgress Topic = "payment.progress"
+
+	TopicFormSubmissions Topic = "form.submitted"
+	TopicUserRegistered  Topic = "user.registered"
+
+	TopicCustomerSubscriptionUpdate Topic = "customer_subscription.status"
 )
 
 type PaymentStatusUpdateEvent struct {
@@ -11,6 +18,11 @@ type PaymentStatusUpdateEvent struct {
 	PaymentID  int64
 }
 
+type PaymentProgressEvent struct {
+	MerchantID int64
+	PaymentID  int64
+}
+
 type FormSubmittedEvent struct {
 	RequestType string
 	Message     string
@@ -20,3 +32,13 @@ type FormSubmittedEvent struct {
 type UserRegisteredEvent struct {
 	UserID int64
 }
+
+// CustomerSubscriptionUpdateEvent is published on every status transition of
+// merchant customer's subscription. Status == PreviousStatus == "active"
+// means a successful renewal.
+type CustomerSubscriptionUpdateEvent struct {
+	MerchantID     int64
+	SubscriptionID int64
+	Status         string
+	PreviousStatus string
+}
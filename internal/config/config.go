@@ -16,9 +16,12 @@ import (
 	"github.com/cryptolink/cryptolink/internal/provider/rpc"
 	"github.com/cryptolink/cryptolink/internal/provider/trongrid"
 	"github.com/cryptolink/cryptolink/internal/server/http"
+	"github.com/cryptolink/cryptolink/internal/service/billing"
 	"github.com/cryptolink/cryptolink/internal/service/evmcollector"
+	"github.com/cryptolink/cryptolink/internal/service/merchant"
 	"github.com/cryptolink/cryptolink/internal/service/processing"
 	"github.com/cryptolink/cryptolink/internal/service/watcher"
+	"github.com/cryptolink/cryptolink/internal/service/webhooks"
 	"github.com/cryptolink/cryptolink/internal/util"
 	"github.com/samber/lo"
 )
@@ -49,6 +52,9 @@ type Oxygen struct {
 	Processing   processing.Config `yaml:"processing"`
 	Watcher      watcher.Config    `yaml:"watcher"`
 	Subscription Subscription      `yaml:"subscription"`
+	Billing      billing.Config    `yaml:"billing"`
+	Webhooks     webhooks.Config   `yaml:"webhooks"`
+	Branding     merchant.BrandingConfig `yaml:"branding"`
 }
 
 type Subscription struct {
@@ -85,10 +91,13 @@ func New(gitCommit, gitVersion, configPath string, skipConfig, embedFrontend boo
 
 		if skipConfig {
 			errCfg = cleanenv.ReadEnv(cfg)
-			return
+		} else {
+			errCfg = cleanenv.ReadConfig(configPath, cfg)
 		}
 
-		errCfg = cleanenv.ReadConfig(configPath, cfg)
+		if errCfg == nil {
+			errCfg = cfg.Oxygen.Server.Validate()
+		}
 	})
 
 	return cfg, errCfg
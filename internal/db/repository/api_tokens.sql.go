@@ -22,19 +22,25 @@ INSERT INTO api_tokens (
     token,
     uuid,
     name,
-    settings
-) VALUES ($1, $2, $3, $4, $5, $6, $7)
-RETURNING id, entity_type, entity_id, created_at, token, name, settings, uuid
+    settings,
+    scopes,
+    expires_at,
+    allowed_cidrs
+) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+RETURNING id, entity_type, entity_id, created_at, token, name, settings, uuid, scopes, expires_at, last_used_at, last_used_ip, allowed_cidrs
 `
 
 type CreateAPITokenParams struct {
-	EntityType string
-	EntityID   int64
-	CreatedAt  time.Time
-	Token      string
-	Uuid       uuid.UUID
-	Name       sql.NullString
-	Settings   pgtype.JSONB
+	EntityType   string
+	EntityID     int64
+	CreatedAt    time.Time
+	Token        string
+	Uuid         uuid.UUID
+	Name         sql.NullString
+	Settings     pgtype.JSONB
+	Scopes       []string
+	ExpiresAt    sql.NullTime
+	AllowedCidrs []string
 }
 
 func (q *Queries) CreateAPIToken(ctx context.Context, arg CreateAPITokenParams) (ApiToken, error) {
@@ -46,6 +52,9 @@ func (q *Queries) CreateAPIToken(ctx context.Context, arg CreateAPITokenParams)
 		arg.Uuid,
 		arg.Name,
 		arg.Settings,
+		arg.Scopes,
+		arg.ExpiresAt,
+		arg.AllowedCidrs,
 	)
 	var i ApiToken
 	err := row.Scan(
@@ -57,6 +66,11 @@ func (q *Queries) CreateAPIToken(ctx context.Context, arg CreateAPITokenParams)
 		&i.Name,
 		&i.Settings,
 		&i.Uuid,
+		&i.Scopes,
+		&i.ExpiresAt,
+		&i.LastUsedAt,
+		&i.LastUsedIp,
+		&i.AllowedCidrs,
 	)
 	return i, err
 }
@@ -81,8 +95,23 @@ func (q *Queries) DeleteAPITokenByToken(ctx context.Context, token string) error
 	return err
 }
 
+const deleteAPITokensByUser = `-- name: DeleteAPITokensByUser :execrows
+DELETE from api_tokens
+WHERE (entity_type = 'user' AND entity_id = $1)
+   OR (entity_type = 'merchant' AND entity_id IN (SELECT id FROM merchants WHERE creator_id = $1))
+`
+
+// DeleteAPITokensByUser deletes user's tokens and tokens of merchants created by the user.
+func (q *Queries) DeleteAPITokensByUser(ctx context.Context, userID int64) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteAPITokensByUser, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const getAPIToken = `-- name: GetAPIToken :one
-SELECT id, entity_type, entity_id, created_at, token, name, settings, uuid FROM api_tokens
+SELECT id, entity_type, entity_id, created_at, token, name, settings, uuid, scopes, expires_at, last_used_at, last_used_ip, allowed_cidrs FROM api_tokens
 WHERE entity_type = $1 and token = $2 LIMIT 1
 `
 
@@ -103,12 +132,17 @@ func (q *Queries) GetAPIToken(ctx context.Context, arg GetAPITokenParams) (ApiTo
 		&i.Name,
 		&i.Settings,
 		&i.Uuid,
+		&i.Scopes,
+		&i.ExpiresAt,
+		&i.LastUsedAt,
+		&i.LastUsedIp,
+		&i.AllowedCidrs,
 	)
 	return i, err
 }
 
 const getAPITokenByUUID = `-- name: GetAPITokenByUUID :one
-SELECT id, entity_type, entity_id, created_at, token, name, settings, uuid FROM api_tokens
+SELECT id, entity_type, entity_id, created_at, token, name, settings, uuid, scopes, expires_at, last_used_at, last_used_ip, allowed_cidrs FROM api_tokens
 WHERE uuid = $1 LIMIT 1
 `
 
@@ -124,12 +158,17 @@ func (q *Queries) GetAPITokenByUUID(ctx context.Context, argUuid uuid.UUID) (Api
 		&i.Name,
 		&i.Settings,
 		&i.Uuid,
+		&i.Scopes,
+		&i.ExpiresAt,
+		&i.LastUsedAt,
+		&i.LastUsedIp,
+		&i.AllowedCidrs,
 	)
 	return i, err
 }
 
 const listAPITokensByEntity = `-- name: ListAPITokensByEntity :many
-SELECT id, entity_type, entity_id, created_at, token, name, settings, uuid FROM api_tokens
+SELECT id, entity_type, entity_id, created_at, token, name, settings, uuid, scopes, expires_at, last_used_at, last_used_ip, allowed_cidrs FROM api_tokens
 WHERE entity_id = $1 and entity_type = $2
 ORDER BY id DESC
 `
@@ -157,6 +196,11 @@ func (q *Queries) ListAPITokensByEntity(ctx context.Context, arg ListAPITokensBy
 			&i.Name,
 			&i.Settings,
 			&i.Uuid,
+			&i.Scopes,
+			&i.ExpiresAt,
+			&i.LastUsedAt,
+			&i.LastUsedIp,
+			&i.AllowedCidrs,
 		); err != nil {
 			return nil, err
 		}
@@ -167,3 +211,20 @@ func (q *Queries) ListAPITokensByEntity(ctx context.Context, arg ListAPITokensBy
 	}
 	return items, nil
 }
+
+const updateAPITokenLastUsed = `-- name: UpdateAPITokenLastUsed :exec
+UPDATE api_tokens
+SET last_used_at = $2, last_used_ip = $3
+WHERE id = $1
+`
+
+type UpdateAPITokenLastUsedParams struct {
+	ID         int64
+	LastUsedAt sql.NullTime
+	LastUsedIp sql.NullString
+}
+
+func (q *Queries) UpdateAPITokenLastUsed(ctx context.Context, arg UpdateAPITokenLastUsedParams) error {
+	_, err := q.db.Exec(ctx, updateAPITokenLastUsed, arg.ID, arg.LastUsedAt, arg.LastUsedIp)
+	return err
+}
@@ -0,0 +1,293 @@
+// Hand-written repository methods for event_bus_* tables.
+// NOT generated by sqlc — keep this file out of `sqlc generate`'s blast radius.
+// event_bus_messages is the outbox of bus.Durable: messages are inserted in the
+// publisher's transaction, every consumer keeps its own offset in
+// event_bus_offsets and gives up messages to event_bus_dead_letters.
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v4"
+)
+
+type EventBusMessage struct {
+	ID        int64
+	Topic     string
+	Payload   pgtype.JSONB
+	TxID      int64
+	CreatedAt time.Time
+}
+
+type EventBusOffset struct {
+	Consumer      string
+	Topic         string
+	LastTxID      int64
+	LastMessageID int64
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// EventBusConsumerStats is a consumer position. Pending is the number of
+// committed messages after the offset, OldestPendingAt is creation time of
+// the first of them.
+type EventBusConsumerStats struct {
+	Consumer        string
+	Topic           string
+	Pending         int64
+	OldestPendingAt sql.NullTime
+	DeadLetters     int64
+	UpdatedAt       time.Time
+}
+
+const createEventBusMessage = `
+INSERT INTO event_bus_messages (topic, payload, created_at)
+VALUES ($1, $2, $3)
+RETURNING id
+`
+
+func (q *Queries) CreateEventBusMessage(ctx context.Context, topic string, payload pgtype.JSONB, createdAt time.Time) (int64, error) {
+	var id int64
+	err := q.db.QueryRow(ctx, createEventBusMessage, topic, payload, createdAt).Scan(&id)
+	return id, err
+}
+
+// NotifyEventBus wakes up listening consumers of the topic. Within a
+// transaction the notification is sent on commit.
+const notifyEventBus = `SELECT pg_notify('event_bus', $1)`
+
+func (q *Queries) NotifyEventBus(ctx context.Context, topic string) error {
+	_, err := q.db.Exec(ctx, notifyEventBus, topic)
+	return err
+}
+
+// CreateEventBusOffset registers a consumer positioned at the end of the
+// topic, so that a new consumer doesn't receive retained history.
+// Does nothing for known consumers.
+const createEventBusOffset = `
+INSERT INTO event_bus_offsets (consumer, topic, last_tx_id, last_message_id, created_at, updated_at)
+SELECT $1, $2, COALESCE(max(m.tx_id), 0), COALESCE(max(m.id), 0), $3, $3
+FROM event_bus_messages m
+WHERE m.topic = $2
+ON CONFLICT (consumer, topic) DO NOTHING
+`
+
+func (q *Queries) CreateEventBusOffset(ctx context.Context, consumer, topic string, createdAt time.Time) error {
+	_, err := q.db.Exec(ctx, createEventBusOffset, consumer, topic, createdAt)
+	return err
+}
+
+// GetEventBusHead returns position of the last message of the topic that
+// ListEventBusMessages can return, zero position for an empty topic.
+const getEventBusHead = `
+SELECT tx_id, id
+FROM event_bus_messages
+WHERE topic = $1
+  AND tx_id < txid_snapshot_xmin(txid_current_snapshot())
+ORDER BY tx_id DESC, id DESC
+LIMIT 1
+`
+
+func (q *Queries) GetEventBusHead(ctx context.Context, topic string) (txID, id int64, err error) {
+	err = q.db.QueryRow(ctx, getEventBusHead, topic).Scan(&txID, &id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, 0, nil
+	}
+
+	return txID, id, err
+}
+
+// ClaimEventBusOffset leases consumer's offset to the owner until given
+// time. Returns pgx.ErrNoRows when the lease is held by another owner.
+// A lease is used instead of a lock held by a transaction: a transaction
+// open while the consumer runs would hold a connection and, once it gets a
+// txid, hold back messages of all consumers, see ListEventBusMessages.
+const claimEventBusOffset = `
+UPDATE event_bus_offsets
+SET locked_by = $3, locked_until = $5
+WHERE consumer = $1 AND topic = $2
+  AND (locked_by IS NULL OR locked_by = $3 OR locked_until < $4)
+RETURNING consumer, topic, last_tx_id, last_message_id, created_at, updated_at
+`
+
+type ClaimEventBusOffsetParams struct {
+	Consumer    string
+	Topic       string
+	LockedBy    string
+	Now         time.Time
+	LockedUntil time.Time
+}
+
+func (q *Queries) ClaimEventBusOffset(ctx context.Context, arg ClaimEventBusOffsetParams) (EventBusOffset, error) {
+	var o EventBusOffset
+	err := q.db.QueryRow(ctx, claimEventBusOffset,
+		arg.Consumer, arg.Topic, arg.LockedBy, arg.Now, arg.LockedUntil,
+	).Scan(&o.Consumer, &o.Topic, &o.LastTxID, &o.LastMessageID, &o.CreatedAt, &o.UpdatedAt)
+	return o, err
+}
+
+// ReleaseEventBusOffset ends owner's lease so that any process can claim the
+// consumer right away.
+const releaseEventBusOffset = `
+UPDATE event_bus_offsets
+SET locked_by = NULL, locked_until = NULL
+WHERE consumer = $1 AND topic = $2 AND locked_by = $3
+`
+
+func (q *Queries) ReleaseEventBusOffset(ctx context.Context, consumer, topic, lockedBy string) error {
+	_, err := q.db.Exec(ctx, releaseEventBusOffset, consumer, topic, lockedBy)
+	return err
+}
+
+// ListEventBusMessages returns messages of the topic after the offset that
+// were written by transactions older than any running one. Messages of
+// running transactions can't be skipped this way: they will be listed after
+// commit since their tx_id is greater than tx_id of every listed message.
+const listEventBusMessages = `
+SELECT id, topic, payload, tx_id, created_at
+FROM event_bus_messages
+WHERE topic = $1
+  AND (tx_id, id) > ($2, $3)
+  AND tx_id < txid_snapshot_xmin(txid_current_snapshot())
+ORDER BY tx_id, id
+LIMIT $4
+`
+
+type ListEventBusMessagesParams struct {
+	Topic     string
+	AfterTxID int64
+	AfterID   int64
+	Limit     int32
+}
+
+func (q *Queries) ListEventBusMessages(ctx context.Context, arg ListEventBusMessagesParams) ([]EventBusMessage, error) {
+	rows, err := q.db.Query(ctx, listEventBusMessages, arg.Topic, arg.AfterTxID, arg.AfterID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []EventBusMessage
+	for rows.Next() {
+		var m EventBusMessage
+		if err := rows.Scan(&m.ID, &m.Topic, &m.Payload, &m.TxID, &m.CreatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, m)
+	}
+
+	return items, rows.Err()
+}
+
+// UpdateEventBusOffset advances consumer's offset and extends the lease if
+// it is still held by the owner. Returns number of updated offsets, zero
+// when the lease was lost.
+const updateEventBusOffset = `
+UPDATE event_bus_offsets
+SET last_tx_id = $3, last_message_id = $4, locked_until = $6, updated_at = $7
+WHERE consumer = $1 AND topic = $2 AND locked_by = $5
+`
+
+type UpdateEventBusOffsetParams struct {
+	Consumer      string
+	Topic         string
+	LastTxID      int64
+	LastMessageID int64
+	LockedBy      string
+	LockedUntil   time.Time
+	UpdatedAt     time.Time
+}
+
+func (q *Queries) UpdateEventBusOffset(ctx context.Context, arg UpdateEventBusOffsetParams) (int64, error) {
+	res, err := q.db.Exec(ctx, updateEventBusOffset,
+		arg.Consumer, arg.Topic, arg.LastTxID, arg.LastMessageID, arg.LockedBy, arg.LockedUntil, arg.UpdatedAt,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected(), nil
+}
+
+const createEventBusDeadLetter = `
+INSERT INTO event_bus_dead_letters (consumer, topic, message_id, payload, attempts, last_error, created_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7)
+`
+
+type CreateEventBusDeadLetterParams struct {
+	Consumer  string
+	Topic     string
+	MessageID int64
+	Payload   pgtype.JSONB
+	Attempts  int32
+	LastError string
+	CreatedAt time.Time
+}
+
+func (q *Queries) CreateEventBusDeadLetter(ctx context.Context, arg CreateEventBusDeadLetterParams) error {
+	_, err := q.db.Exec(ctx, createEventBusDeadLetter,
+		arg.Consumer, arg.Topic, arg.MessageID, arg.Payload, arg.Attempts, arg.LastError, arg.CreatedAt,
+	)
+	return err
+}
+
+const listEventBusConsumerStats = `
+SELECT o.consumer, o.topic, p.pending, p.oldest_pending_at,
+       (SELECT count(*) FROM event_bus_dead_letters d WHERE d.consumer = o.consumer AND d.topic = o.topic),
+       o.updated_at
+FROM event_bus_offsets o
+CROSS JOIN LATERAL (
+    SELECT count(*) AS pending, min(m.created_at) AS oldest_pending_at
+    FROM event_bus_messages m
+    WHERE m.topic = o.topic AND (m.tx_id, m.id) > (o.last_tx_id, o.last_message_id)
+) p
+ORDER BY o.topic, o.consumer
+`
+
+func (q *Queries) ListEventBusConsumerStats(ctx context.Context) ([]EventBusConsumerStats, error) {
+	rows, err := q.db.Query(ctx, listEventBusConsumerStats)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []EventBusConsumerStats
+	for rows.Next() {
+		var s EventBusConsumerStats
+		if err := rows.Scan(&s.Consumer, &s.Topic, &s.Pending, &s.OldestPendingAt, &s.DeadLetters, &s.UpdatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, s)
+	}
+
+	return items, rows.Err()
+}
+
+// DeleteEventBusMessagesBefore deletes up to limit messages created before
+// given time that every consumer of their topic has processed.
+const deleteEventBusMessagesBefore = `
+DELETE FROM event_bus_messages
+WHERE id IN (
+    SELECT m.id FROM event_bus_messages m
+    WHERE m.created_at < $1
+      AND NOT EXISTS (
+          SELECT 1 FROM event_bus_offsets o
+          WHERE o.topic = m.topic AND (o.last_tx_id, o.last_message_id) < (m.tx_id, m.id)
+      )
+    ORDER BY m.id
+    LIMIT $2
+)
+`
+
+func (q *Queries) DeleteEventBusMessagesBefore(ctx context.Context, before time.Time, limit int32) (int64, error) {
+	res, err := q.db.Exec(ctx, deleteEventBusMessagesBefore, before, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected(), nil
+}
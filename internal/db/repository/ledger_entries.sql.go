@@ -0,0 +1,358 @@
+// Hand-written repository methods for ledger_entries.
+// NOT generated by sqlc — keep this file out of `sqlc generate`'s blast radius.
+// Rows are append-only journal lines of the merchant double-entry ledger;
+// there are deliberately no update / delete queries here.
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgtype"
+)
+
+type LedgerEntry struct {
+	ID             int64
+	MerchantID     int64
+	EventKey       string
+	EventType      string
+	Line           int32
+	Account        string
+	Side           string
+	Ticker         string
+	Decimals       int32
+	Amount         pgtype.Numeric
+	FiatCurrency   string
+	FiatAmount     sql.NullString
+	Rate           sql.NullString
+	RateSource     sql.NullString
+	RateSnapshotID sql.NullInt64
+	TransactionID  sql.NullInt64
+	PaymentID      sql.NullInt64
+	ReversesKey    sql.NullString
+	Description    string
+	IsTest         bool
+	OccurredAt     time.Time
+	CreatedAt      time.Time
+	TxHash         sql.NullString
+
+	// PaymentUUID and OrderID are payments.merchant_order_uuid and
+	// payments.merchant_order_id of PaymentID.
+	PaymentUUID uuid.NullUUID
+	OrderID     sql.NullString
+}
+
+const ledgerEntryColumns = `
+id, merchant_id, event_key, event_type, line, account, side, ticker, decimals, amount,
+fiat_currency, fiat_amount::text, rate::text, rate_source, rate_snapshot_id,
+transaction_id, payment_id, reverses_key, description, is_test, occurred_at, created_at, tx_hash,
+(SELECT p.merchant_order_uuid FROM payments p WHERE p.id = ledger_entries.payment_id),
+(SELECT p.merchant_order_id FROM payments p WHERE p.id = ledger_entries.payment_id)
+`
+
+func scanLedgerEntry(row interface{ Scan(dest ...any) error }) (LedgerEntry, error) {
+	var e LedgerEntry
+	err := row.Scan(
+		&e.ID, &e.MerchantID, &e.EventKey, &e.EventType, &e.Line, &e.Account, &e.Side, &e.Ticker, &e.Decimals, &e.Amount,
+		&e.FiatCurrency, &e.FiatAmount, &e.Rate, &e.RateSource, &e.RateSnapshotID,
+		&e.TransactionID, &e.PaymentID, &e.ReversesKey, &e.Description, &e.IsTest, &e.OccurredAt, &e.CreatedAt, &e.TxHash,
+		&e.PaymentUUID, &e.OrderID,
+	)
+	return e, err
+}
+
+const insertLedgerEntry = `
+INSERT INTO ledger_entries (
+    merchant_id, event_key, event_type, line, account, side, ticker, decimals, amount,
+    fiat_currency, fiat_amount, rate, rate_source, rate_snapshot_id,
+    transaction_id, payment_id, reverses_key, description, is_test, occurred_at, created_at, tx_hash
+) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11::numeric, $12::numeric, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22)
+RETURNING ` + ledgerEntryColumns
+
+type InsertLedgerEntryParams struct {
+	MerchantID     int64
+	EventKey       string
+	EventType      string
+	Line           int32
+	Account        string
+	Side           string
+	Ticker         string
+	Decimals       int32
+	Amount         pgtype.Numeric
+	FiatCurrency   string
+	FiatAmount     sql.NullString
+	Rate           sql.NullString
+	RateSource     sql.NullString
+	RateSnapshotID sql.NullInt64
+	TransactionID  sql.NullInt64
+	PaymentID      sql.NullInt64
+	ReversesKey    sql.NullString
+	Description    string
+	IsTest         bool
+	OccurredAt     time.Time
+	CreatedAt      time.Time
+	TxHash         sql.NullString
+}
+
+func (q *Queries) InsertLedgerEntry(ctx context.Context, arg InsertLedgerEntryParams) (LedgerEntry, error) {
+	row := q.db.QueryRow(ctx, insertLedgerEntry,
+		arg.MerchantID, arg.EventKey, arg.EventType, arg.Line, arg.Account, arg.Side, arg.Ticker, arg.Decimals, arg.Amount,
+		arg.FiatCurrency, arg.FiatAmount, arg.Rate, arg.RateSource, arg.RateSnapshotID,
+		arg.TransactionID, arg.PaymentID, arg.ReversesKey, arg.Description, arg.IsTest, arg.OccurredAt, arg.CreatedAt, arg.TxHash,
+	)
+	return scanLedgerEntry(row)
+}
+
+// ListLedgerEntriesByEventKey returns lines of a single journal in line order.
+const listLedgerEntriesByEventKey = `
+SELECT ` + ledgerEntryColumns + `
+FROM ledger_entries
+WHERE merchant_id = $1 AND event_key = $2
+ORDER BY line ASC
+`
+
+func (q *Queries) ListLedgerEntriesByEventKey(ctx context.Context, merchantID int64, eventKey string) ([]LedgerEntry, error) {
+	return q.listLedgerEntries(ctx, listLedgerEntriesByEventKey, merchantID, eventKey)
+}
+
+// SumLedgerAccountByTransaction returns the net debit (debits minus credits)
+// of the account across all journals linked to the transaction.
+const sumLedgerAccountByTransaction = `
+SELECT COALESCE(SUM(CASE WHEN side = 'debit' THEN amount ELSE -amount END), 0)::numeric
+FROM ledger_entries
+WHERE merchant_id = $1 AND transaction_id = $2 AND account = $3
+`
+
+func (q *Queries) SumLedgerAccountByTransaction(ctx context.Context, merchantID, transactionID int64, account string) (pgtype.Numeric, error) {
+	row := q.db.QueryRow(ctx, sumLedgerAccountByTransaction, merchantID, transactionID, account)
+	var sum pgtype.Numeric
+	err := row.Scan(&sum)
+	return sum, err
+}
+
+// ListLedgerEntries returns journal lines that occurred within [from, to)
+// in chronological order. AfterID is the keyset pagination cursor.
+const listLedgerEntries = `
+SELECT ` + ledgerEntryColumns + `
+FROM ledger_entries
+WHERE merchant_id = $1 AND is_test = $2 AND occurred_at >= $3 AND occurred_at < $4 AND id > $5
+ORDER BY id ASC
+LIMIT $6
+`
+
+type ListLedgerEntriesParams struct {
+	MerchantID int64
+	IsTest     bool
+	From       time.Time
+	To         time.Time
+	AfterID    int64
+	Limit      int32
+}
+
+func (q *Queries) ListLedgerEntries(ctx context.Context, arg ListLedgerEntriesParams) ([]LedgerEntry, error) {
+	return q.listLedgerEntries(ctx, listLedgerEntries,
+		arg.MerchantID, arg.IsTest, arg.From, arg.To, arg.AfterID, arg.Limit,
+	)
+}
+
+// ListLedgerJournals returns lines of whole journals whose first line occurred
+// within [from, to), grouped by journal in the order they were posted.
+// AfterID is the id of the first line of the last journal of previous page,
+// Limit is the number of journals.
+const listLedgerJournals = `
+WITH journals AS (
+    SELECT event_key AS journal_key, MIN(id) AS journal_id
+    FROM ledger_entries
+    WHERE merchant_id = $1 AND is_test = $2 AND occurred_at >= $3 AND occurred_at < $4
+    GROUP BY event_key
+    HAVING MIN(id) > $5
+    ORDER BY journal_id ASC
+    LIMIT $6
+)
+SELECT ` + ledgerEntryColumns + `
+FROM ledger_entries
+JOIN journals ON journals.journal_key = ledger_entries.event_key
+WHERE merchant_id = $1
+ORDER BY journals.journal_id ASC, line ASC
+`
+
+func (q *Queries) ListLedgerJournals(ctx context.Context, arg ListLedgerEntriesParams) ([]LedgerEntry, error) {
+	return q.listLedgerEntries(ctx, listLedgerJournals,
+		arg.MerchantID, arg.IsTest, arg.From, arg.To, arg.AfterID, arg.Limit,
+	)
+}
+
+func (q *Queries) listLedgerEntries(ctx context.Context, query string, args ...any) ([]LedgerEntry, error) {
+	rows, err := q.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []LedgerEntry
+	for rows.Next() {
+		e, err := scanLedgerEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, e)
+	}
+	return items, rows.Err()
+}
+
+type LedgerRangeParams struct {
+	MerchantID int64
+	IsTest     bool
+	From       time.Time
+	To         time.Time
+}
+
+type LedgerAccountBalance struct {
+	Account      string
+	Ticker       string
+	Decimals     int32
+	FiatCurrency string
+
+	// Opening* are net debits before From, Debit* / Credit* are movements within [From, To).
+	OpeningAmount pgtype.Numeric
+	DebitAmount   pgtype.Numeric
+	CreditAmount  pgtype.Numeric
+	OpeningFiat   string
+	DebitFiat     string
+	CreditFiat    string
+}
+
+// LedgerAccountBalances returns per-account opening balance and movements of
+// a date range. Lines without fiat value are counted as zero fiat.
+const ledgerAccountBalances = `
+SELECT account, ticker, decimals, fiat_currency,
+    COALESCE(SUM(CASE WHEN side = 'debit' THEN amount ELSE -amount END) FILTER (WHERE occurred_at < $3), 0)::numeric,
+    COALESCE(SUM(amount) FILTER (WHERE side = 'debit' AND occurred_at >= $3), 0)::numeric,
+    COALESCE(SUM(amount) FILTER (WHERE side = 'credit' AND occurred_at >= $3), 0)::numeric,
+    COALESCE(SUM(CASE WHEN side = 'debit' THEN fiat_amount ELSE -fiat_amount END) FILTER (WHERE occurred_at < $3), 0)::text,
+    COALESCE(SUM(fiat_amount) FILTER (WHERE side = 'debit' AND occurred_at >= $3), 0)::text,
+    COALESCE(SUM(fiat_amount) FILTER (WHERE side = 'credit' AND occurred_at >= $3), 0)::text
+FROM ledger_entries
+WHERE merchant_id = $1 AND is_test = $2 AND occurred_at < $4
+GROUP BY account, ticker, decimals, fiat_currency
+ORDER BY account, ticker
+`
+
+func (q *Queries) LedgerAccountBalances(ctx context.Context, arg LedgerRangeParams) ([]LedgerAccountBalance, error) {
+	rows, err := q.db.Query(ctx, ledgerAccountBalances, arg.MerchantID, arg.IsTest, arg.From, arg.To)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []LedgerAccountBalance
+	for rows.Next() {
+		var b LedgerAccountBalance
+		if err := rows.Scan(
+			&b.Account, &b.Ticker, &b.Decimals, &b.FiatCurrency,
+			&b.OpeningAmount, &b.DebitAmount, &b.CreditAmount,
+			&b.OpeningFiat, &b.DebitFiat, &b.CreditFiat,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, b)
+	}
+	return items, rows.Err()
+}
+
+type LedgerEventTotal struct {
+	EventType    string
+	Ticker       string
+	Decimals     int32
+	FiatCurrency string
+	Events       int64
+	Amount       pgtype.Numeric
+	FiatAmount   string
+	Unvalued     int64
+}
+
+// LedgerEventTotals returns totals per event type of journals that occurred
+// within [From, To). A journal's amount is the sum of its debit lines.
+const ledgerEventTotals = `
+SELECT event_type, ticker, decimals, fiat_currency,
+    COUNT(DISTINCT event_key),
+    COALESCE(SUM(amount), 0)::numeric,
+    COALESCE(SUM(fiat_amount), 0)::text,
+    COUNT(*) FILTER (WHERE fiat_amount IS NULL)
+FROM ledger_entries
+WHERE merchant_id = $1 AND is_test = $2 AND occurred_at >= $3 AND occurred_at < $4 AND side = 'debit'
+GROUP BY event_type, ticker, decimals, fiat_currency
+ORDER BY event_type, ticker
+`
+
+func (q *Queries) LedgerEventTotals(ctx context.Context, arg LedgerRangeParams) ([]LedgerEventTotal, error) {
+	rows, err := q.db.Query(ctx, ledgerEventTotals, arg.MerchantID, arg.IsTest, arg.From, arg.To)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []LedgerEventTotal
+	for rows.Next() {
+		var t LedgerEventTotal
+		if err := rows.Scan(
+			&t.EventType, &t.Ticker, &t.Decimals, &t.FiatCurrency,
+			&t.Events, &t.Amount, &t.FiatAmount, &t.Unvalued,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, t)
+	}
+	return items, rows.Err()
+}
+
+type UnpostedCollectorWithdrawal struct {
+	ID            int64
+	MerchantID    int64
+	Blockchain    string
+	ChainID       int32
+	TokenContract sql.NullString
+	Ticker        string
+	Decimals      int32
+	Amount        pgtype.Numeric
+	TxHash        string
+	CreatedAt     time.Time
+}
+
+// ListUnpostedCollectorWithdrawals returns indexed collector withdrawals that
+// have no ledger journal yet. Journal key is "collector_withdrawal:<id>".
+const listUnpostedCollectorWithdrawals = `
+SELECT w.id, w.merchant_id, w.blockchain, c.chain_id, w.token_contract, w.ticker, w.decimals,
+       w.amount, w.tx_hash, w.created_at
+FROM evm_collector_withdrawals w
+JOIN evm_collector_wallets c ON c.id = w.collector_id
+WHERE NOT EXISTS (
+    SELECT 1 FROM ledger_entries l
+    WHERE l.merchant_id = w.merchant_id AND l.event_key = 'collector_withdrawal:' || w.id
+)
+ORDER BY w.id ASC
+LIMIT $1
+`
+
+func (q *Queries) ListUnpostedCollectorWithdrawals(ctx context.Context, limit int32) ([]UnpostedCollectorWithdrawal, error) {
+	rows, err := q.db.Query(ctx, listUnpostedCollectorWithdrawals, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []UnpostedCollectorWithdrawal
+	for rows.Next() {
+		var w UnpostedCollectorWithdrawal
+		if err := rows.Scan(
+			&w.ID, &w.MerchantID, &w.Blockchain, &w.ChainID, &w.TokenContract, &w.Ticker, &w.Decimals,
+			&w.Amount, &w.TxHash, &w.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, w)
+	}
+	return items, rows.Err()
+}
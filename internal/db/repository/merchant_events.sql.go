@@ -0,0 +1,205 @@
+// Hand-written repository methods for merchant_events.
+// NOT generated by sqlc — keep this file out of `sqlc generate`'s blast radius.
+// merchant_events is the log of typed merchant events streamed over WebSocket.
+// Rows are inserted together with webhook deliveries of the event and read in
+// (tx_id, id) order, see ListEventBusMessages.
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v4"
+)
+
+type MerchantEvent struct {
+	ID            int64
+	MerchantID    int64
+	EventID       uuid.UUID
+	EventType     string
+	PaymentID     int64
+	PaymentStatus string
+	IsTest        bool
+	Payload       pgtype.JSONB
+	TxID          int64
+	CreatedAt     time.Time
+}
+
+// MerchantEventPosition is position of an event in the log.
+type MerchantEventPosition struct {
+	MerchantID int64
+	TxID       int64
+	ID         int64
+}
+
+const merchantEventColumns = `
+id, merchant_id, event_id, event_type, COALESCE(payment_id, 0), payment_status, is_test, payload, tx_id, created_at
+`
+
+// CreateMerchantEvent logs merchant's event. Events of test payments are
+// marked as test regardless of IsTest. PaymentID = 0 for events not related
+// to a payment.
+const createMerchantEvent = `
+INSERT INTO merchant_events (merchant_id, event_id, event_type, payment_id, payment_status, is_test, payload, created_at)
+VALUES ($1, $2, $3, NULLIF($4::bigint, 0), $5,
+        COALESCE((SELECT p.is_test FROM payments p WHERE p.id = $4), $6), $7, $8)
+`
+
+type CreateMerchantEventParams struct {
+	MerchantID    int64
+	EventID       uuid.UUID
+	EventType     string
+	PaymentID     int64
+	PaymentStatus string
+	IsTest        bool
+	Payload       pgtype.JSONB
+	CreatedAt     time.Time
+}
+
+func (q *Queries) CreateMerchantEvent(ctx context.Context, arg CreateMerchantEventParams) error {
+	_, err := q.db.Exec(ctx, createMerchantEvent,
+		arg.MerchantID, arg.EventID, arg.EventType, arg.PaymentID, arg.PaymentStatus, arg.IsTest, arg.Payload, arg.CreatedAt,
+	)
+	return err
+}
+
+// ListMerchantEvents returns merchant's events after the position that were
+// written by transactions older than any running one. Empty EventTypes
+// matches all events.
+const listMerchantEvents = `
+SELECT ` + merchantEventColumns + `
+FROM merchant_events
+WHERE merchant_id = $1
+  AND (cardinality($2::text[]) = 0 OR event_type = ANY($2::text[]))
+  AND (tx_id, id) > ($3, $4)
+  AND tx_id < txid_snapshot_xmin(txid_current_snapshot())
+ORDER BY tx_id, id
+LIMIT $5
+`
+
+type ListMerchantEventsParams struct {
+	MerchantID int64
+	EventTypes []string
+	AfterTxID  int64
+	AfterID    int64
+	Limit      int32
+}
+
+func (q *Queries) ListMerchantEvents(ctx context.Context, arg ListMerchantEventsParams) ([]MerchantEvent, error) {
+	eventTypes := arg.EventTypes
+	if eventTypes == nil {
+		eventTypes = []string{}
+	}
+
+	rows, err := q.db.Query(ctx, listMerchantEvents, arg.MerchantID, eventTypes, arg.AfterTxID, arg.AfterID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []MerchantEvent
+	for rows.Next() {
+		var e MerchantEvent
+		err := rows.Scan(
+			&e.ID, &e.MerchantID, &e.EventID, &e.EventType, &e.PaymentID, &e.PaymentStatus,
+			&e.IsTest, &e.Payload, &e.TxID, &e.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, e)
+	}
+
+	return items, rows.Err()
+}
+
+// ListMerchantEventPositions returns positions of events of all merchants
+// after the given one, see ListMerchantEvents.
+const listMerchantEventPositions = `
+SELECT merchant_id, tx_id, id
+FROM merchant_events
+WHERE (tx_id, id) > ($1, $2)
+  AND tx_id < txid_snapshot_xmin(txid_current_snapshot())
+ORDER BY tx_id, id
+LIMIT $3
+`
+
+func (q *Queries) ListMerchantEventPositions(ctx context.Context, afterTxID, afterID int64, limit int32) ([]MerchantEventPosition, error) {
+	rows, err := q.db.Query(ctx, listMerchantEventPositions, afterTxID, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []MerchantEventPosition
+	for rows.Next() {
+		var p MerchantEventPosition
+		if err := rows.Scan(&p.MerchantID, &p.TxID, &p.ID); err != nil {
+			return nil, err
+		}
+		items = append(items, p)
+	}
+
+	return items, rows.Err()
+}
+
+// GetMerchantEventsHead returns position of the last event of the merchant
+// that ListMerchantEvents can return, of all merchants if merchantID = 0.
+// Zero position if there are no events.
+const getMerchantEventsHead = `
+SELECT tx_id, id
+FROM merchant_events
+WHERE ($1::bigint = 0 OR merchant_id = $1)
+  AND tx_id < txid_snapshot_xmin(txid_current_snapshot())
+ORDER BY tx_id DESC, id DESC
+LIMIT 1
+`
+
+func (q *Queries) GetMerchantEventsHead(ctx context.Context, merchantID int64) (txID, id int64, err error) {
+	err = q.db.QueryRow(ctx, getMerchantEventsHead, merchantID).Scan(&txID, &id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, 0, nil
+	}
+
+	return txID, id, err
+}
+
+// UpdateMerchantEventPayload stores rendered payload unless it's already set.
+// Returns stored payload.
+const updateMerchantEventPayload = `
+WITH updated AS (
+    UPDATE merchant_events SET payload = $2
+    WHERE id = $1 AND payload IS NULL
+    RETURNING payload
+)
+SELECT payload FROM updated
+UNION ALL
+SELECT payload FROM merchant_events WHERE id = $1 AND payload IS NOT NULL
+`
+
+func (q *Queries) UpdateMerchantEventPayload(ctx context.Context, id int64, payload pgtype.JSONB) (pgtype.JSONB, error) {
+	var stored pgtype.JSONB
+	err := q.db.QueryRow(ctx, updateMerchantEventPayload, id, payload).Scan(&stored)
+	return stored, err
+}
+
+// DeleteMerchantEventsBefore deletes up to limit events created before given
+// time. Returns number of deleted events.
+const deleteMerchantEventsBefore = `
+DELETE FROM merchant_events
+WHERE id IN (
+    SELECT id FROM merchant_events WHERE created_at < $1 ORDER BY id LIMIT $2
+)
+`
+
+func (q *Queries) DeleteMerchantEventsBefore(ctx context.Context, before time.Time, limit int32) (int64, error) {
+	res, err := q.db.Exec(ctx, deleteMerchantEventsBefore, before, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected(), nil
+}
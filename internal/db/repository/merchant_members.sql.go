@@ -0,0 +1,344 @@
+// Hand-written repository methods for merchant_members and merchant_invitations.
+// NOT generated by sqlc — keep this file out of `sqlc generate`'s blast radius.
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type MerchantMember struct {
+	ID         int64
+	MerchantID int64
+	UserID     int64
+	Role       string
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// MerchantMemberWithUser member joined with its user.
+type MerchantMemberWithUser struct {
+	MerchantMember
+	UserUuid  uuid.UUID
+	UserEmail string
+	UserName  string
+}
+
+// MerchantWithRole merchant joined with the role of a member.
+type MerchantWithRole struct {
+	Merchant
+	Role string
+}
+
+type MerchantInvitation struct {
+	ID         int64
+	Uuid       uuid.UUID
+	MerchantID int64
+	Email      string
+	Role       string
+	TokenHash  string
+	InvitedBy  int64
+	ExpiresAt  time.Time
+	AcceptedAt *time.Time
+	CreatedAt  time.Time
+}
+
+const merchantMemberColumns = `mm.id, mm.merchant_id, mm.user_id, mm.role, mm.created_at, mm.updated_at`
+
+const merchantInvitationColumns = `id, uuid, merchant_id, email, role, token_hash, invited_by, expires_at, accepted_at, created_at`
+
+func scanMerchantMember(row interface{ Scan(...interface{}) error }, i *MerchantMember, extra ...interface{}) error {
+	return row.Scan(append([]interface{}{
+		&i.ID,
+		&i.MerchantID,
+		&i.UserID,
+		&i.Role,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	}, extra...)...)
+}
+
+func scanMerchantInvitation(row interface{ Scan(...interface{}) error }, i *MerchantInvitation) error {
+	return row.Scan(
+		&i.ID,
+		&i.Uuid,
+		&i.MerchantID,
+		&i.Email,
+		&i.Role,
+		&i.TokenHash,
+		&i.InvitedBy,
+		&i.ExpiresAt,
+		&i.AcceptedAt,
+		&i.CreatedAt,
+	)
+}
+
+const createMerchantMember = `
+INSERT INTO merchant_members AS mm (merchant_id, user_id, role, created_at, updated_at)
+VALUES ($1, $2, $3, $4, $4)
+RETURNING ` + merchantMemberColumns
+
+type CreateMerchantMemberParams struct {
+	MerchantID int64
+	UserID     int64
+	Role       string
+	CreatedAt  time.Time
+}
+
+func (q *Queries) CreateMerchantMember(ctx context.Context, arg CreateMerchantMemberParams) (MerchantMember, error) {
+	row := q.db.QueryRow(ctx, createMerchantMember, arg.MerchantID, arg.UserID, arg.Role, arg.CreatedAt)
+	var i MerchantMember
+	err := scanMerchantMember(row, &i)
+	return i, err
+}
+
+const getMerchantByUUIDAndMemberID = `
+SELECT m.id, m.uuid, m.created_at, m.updated_at, m.deleted_at, m.name, m.website, m.creator_id, m.settings, mm.role
+FROM merchants m
+JOIN merchant_members mm ON mm.merchant_id = m.id
+WHERE m.uuid = $1 AND mm.user_id = $2 AND m.deleted_at IS NULL
+LIMIT 1
+`
+
+func (q *Queries) GetMerchantByUUIDAndMemberID(ctx context.Context, merchantUUID uuid.UUID, userID int64) (MerchantWithRole, error) {
+	row := q.db.QueryRow(ctx, getMerchantByUUIDAndMemberID, merchantUUID, userID)
+	var i MerchantWithRole
+	err := row.Scan(
+		&i.ID,
+		&i.Uuid,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.DeletedAt,
+		&i.Name,
+		&i.Website,
+		&i.CreatorID,
+		&i.Settings,
+		&i.Role,
+	)
+	return i, err
+}
+
+const listMerchantsByMemberID = `
+SELECT m.id, m.uuid, m.created_at, m.updated_at, m.deleted_at, m.name, m.website, m.creator_id, m.settings, mm.role
+FROM merchants m
+JOIN merchant_members mm ON mm.merchant_id = m.id
+WHERE mm.user_id = $1 AND m.deleted_at IS NULL
+ORDER BY m.id
+`
+
+func (q *Queries) ListMerchantsByMemberID(ctx context.Context, userID int64) ([]MerchantWithRole, error) {
+	rows, err := q.db.Query(ctx, listMerchantsByMemberID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []MerchantWithRole
+	for rows.Next() {
+		var i MerchantWithRole
+		if err := rows.Scan(
+			&i.ID,
+			&i.Uuid,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.DeletedAt,
+			&i.Name,
+			&i.Website,
+			&i.CreatorID,
+			&i.Settings,
+			&i.Role,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listMerchantMembers = `
+SELECT ` + merchantMemberColumns + `, u.uuid, u.email, u.name
+FROM merchant_members mm
+JOIN users u ON u.id = mm.user_id
+WHERE mm.merchant_id = $1
+ORDER BY mm.id
+`
+
+func (q *Queries) ListMerchantMembers(ctx context.Context, merchantID int64) ([]MerchantMemberWithUser, error) {
+	rows, err := q.db.Query(ctx, listMerchantMembers, merchantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []MerchantMemberWithUser
+	for rows.Next() {
+		var i MerchantMemberWithUser
+		if err := scanMerchantMember(rows, &i.MerchantMember, &i.UserUuid, &i.UserEmail, &i.UserName); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getMerchantMemberByUserUUID = `
+SELECT ` + merchantMemberColumns + `, u.uuid, u.email, u.name
+FROM merchant_members mm
+JOIN users u ON u.id = mm.user_id
+WHERE mm.merchant_id = $1 AND u.uuid = $2
+LIMIT 1
+`
+
+func (q *Queries) GetMerchantMemberByUserUUID(ctx context.Context, merchantID int64, userUUID uuid.UUID) (MerchantMemberWithUser, error) {
+	row := q.db.QueryRow(ctx, getMerchantMemberByUserUUID, merchantID, userUUID)
+	var i MerchantMemberWithUser
+	err := scanMerchantMember(row, &i.MerchantMember, &i.UserUuid, &i.UserEmail, &i.UserName)
+	return i, err
+}
+
+const updateMerchantMemberRole = `
+UPDATE merchant_members AS mm SET role = $3, updated_at = $4
+WHERE merchant_id = $1 AND user_id = $2
+RETURNING ` + merchantMemberColumns
+
+func (q *Queries) UpdateMerchantMemberRole(ctx context.Context, merchantID, userID int64, role string, updatedAt time.Time) (MerchantMember, error) {
+	row := q.db.QueryRow(ctx, updateMerchantMemberRole, merchantID, userID, role, updatedAt)
+	var i MerchantMember
+	err := scanMerchantMember(row, &i)
+	return i, err
+}
+
+const deleteMerchantMember = `
+DELETE FROM merchant_members WHERE merchant_id = $1 AND user_id = $2
+`
+
+func (q *Queries) DeleteMerchantMember(ctx context.Context, merchantID, userID int64) error {
+	_, err := q.db.Exec(ctx, deleteMerchantMember, merchantID, userID)
+	return err
+}
+
+const countMerchantMembersByRole = `
+SELECT count(*) FROM merchant_members WHERE merchant_id = $1 AND role = $2
+`
+
+func (q *Queries) CountMerchantMembersByRole(ctx context.Context, merchantID int64, role string) (int64, error) {
+	var count int64
+	err := q.db.QueryRow(ctx, countMerchantMembersByRole, merchantID, role).Scan(&count)
+	return count, err
+}
+
+const createMerchantInvitation = `
+INSERT INTO merchant_invitations (uuid, merchant_id, email, role, token_hash, invited_by, expires_at, created_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING ` + merchantInvitationColumns
+
+type CreateMerchantInvitationParams struct {
+	Uuid       uuid.UUID
+	MerchantID int64
+	Email      string
+	Role       string
+	TokenHash  string
+	InvitedBy  int64
+	ExpiresAt  time.Time
+	CreatedAt  time.Time
+}
+
+func (q *Queries) CreateMerchantInvitation(ctx context.Context, arg CreateMerchantInvitationParams) (MerchantInvitation, error) {
+	row := q.db.QueryRow(ctx, createMerchantInvitation,
+		arg.Uuid,
+		arg.MerchantID,
+		arg.Email,
+		arg.Role,
+		arg.TokenHash,
+		arg.InvitedBy,
+		arg.ExpiresAt,
+		arg.CreatedAt,
+	)
+	var i MerchantInvitation
+	err := scanMerchantInvitation(row, &i)
+	return i, err
+}
+
+const listPendingMerchantInvitations = `
+SELECT ` + merchantInvitationColumns + ` FROM merchant_invitations
+WHERE merchant_id = $1 AND accepted_at IS NULL AND expires_at > $2
+ORDER BY id
+`
+
+func (q *Queries) ListPendingMerchantInvitations(ctx context.Context, merchantID int64, now time.Time) ([]MerchantInvitation, error) {
+	rows, err := q.db.Query(ctx, listPendingMerchantInvitations, merchantID, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []MerchantInvitation
+	for rows.Next() {
+		var i MerchantInvitation
+		if err := scanMerchantInvitation(rows, &i); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// DeletePendingMerchantInvitations deletes not accepted invitations of the
+// email, e.g. before inviting it again.
+const deletePendingMerchantInvitations = `
+DELETE FROM merchant_invitations
+WHERE merchant_id = $1 AND lower(email) = lower($2) AND accepted_at IS NULL
+`
+
+func (q *Queries) DeletePendingMerchantInvitations(ctx context.Context, merchantID int64, email string) error {
+	_, err := q.db.Exec(ctx, deletePendingMerchantInvitations, merchantID, email)
+	return err
+}
+
+const deleteMerchantInvitation = `
+DELETE FROM merchant_invitations
+WHERE merchant_id = $1 AND uuid = $2 AND accepted_at IS NULL
+`
+
+func (q *Queries) DeleteMerchantInvitation(ctx context.Context, merchantID int64, invitationUUID uuid.UUID) (int64, error) {
+	res, err := q.db.Exec(ctx, deleteMerchantInvitation, merchantID, invitationUUID)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected(), nil
+}
+
+// AcceptMerchantInvitation marks pending invitation sent to the email as
+// accepted and adds the user to the merchant. Existing member keeps its role.
+// Returns pgx.ErrNoRows if there is no such invitation.
+const acceptMerchantInvitation = `
+WITH invitation AS (
+    UPDATE merchant_invitations SET accepted_at = $4
+    WHERE token_hash = $1 AND lower(email) = lower($3) AND accepted_at IS NULL AND expires_at > $4
+    RETURNING merchant_id, role
+)
+INSERT INTO merchant_members AS mm (merchant_id, user_id, role, created_at, updated_at)
+SELECT merchant_id, $2, role, $4, $4 FROM invitation
+ON CONFLICT (merchant_id, user_id) DO UPDATE SET updated_at = mm.updated_at
+RETURNING ` + merchantMemberColumns
+
+func (q *Queries) AcceptMerchantInvitation(
+	ctx context.Context,
+	tokenHash string,
+	userID int64,
+	email string,
+	now time.Time,
+) (MerchantMember, error) {
+	row := q.db.QueryRow(ctx, acceptMerchantInvitation, tokenHash, userID, email, now)
+	var i MerchantMember
+	err := scanMerchantMember(row, &i)
+	return i, err
+}
@@ -13,14 +13,19 @@ import (
 )
 
 type ApiToken struct {
-	ID         int64
-	EntityType string
-	EntityID   int64
-	CreatedAt  time.Time
-	Token      string
-	Name       sql.NullString
-	Settings   pgtype.JSONB
-	Uuid       uuid.UUID
+	ID           int64
+	EntityType   string
+	EntityID     int64
+	CreatedAt    time.Time
+	Token        string
+	Name         sql.NullString
+	Settings     pgtype.JSONB
+	Uuid         uuid.UUID
+	Scopes       []string
+	ExpiresAt    sql.NullTime
+	LastUsedAt   sql.NullTime
+	LastUsedIp   sql.NullString
+	AllowedCidrs []string
 }
 
 type Balance struct {
@@ -146,6 +151,8 @@ type PaymentLink struct {
 	RedirectUrl    sql.NullString
 	SuccessMessage sql.NullString
 	IsTest         bool
+	MaxUses        sql.NullInt32
+	Uses           int32
 }
 
 type Registry struct {
@@ -0,0 +1,61 @@
+// Hand-written repository methods for password_reset_tokens.
+// NOT generated by sqlc — keep this file out of `sqlc generate`'s blast radius.
+package repository
+
+import (
+	"context"
+	"time"
+)
+
+const createPasswordResetToken = `
+INSERT INTO password_reset_tokens (user_id, token_hash, ip_address, expires_at, created_at)
+VALUES ($1, $2, $3, $4, $5)
+`
+
+type CreatePasswordResetTokenParams struct {
+	UserID    int64
+	TokenHash string
+	IPAddress string
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
+
+func (q *Queries) CreatePasswordResetToken(ctx context.Context, arg CreatePasswordResetTokenParams) error {
+	_, err := q.db.Exec(ctx, createPasswordResetToken, arg.UserID, arg.TokenHash, arg.IPAddress, arg.ExpiresAt, arg.CreatedAt)
+	return err
+}
+
+const countPasswordResetTokensSince = `
+SELECT count(*) FROM password_reset_tokens WHERE user_id = $1 AND created_at >= $2
+`
+
+func (q *Queries) CountPasswordResetTokensSince(ctx context.Context, userID int64, since time.Time) (int64, error) {
+	var count int64
+	err := q.db.QueryRow(ctx, countPasswordResetTokensSince, userID, since).Scan(&count)
+	return count, err
+}
+
+// UsePasswordResetToken marks unused and not expired token as used.
+// Returns user id of the token or pgx.ErrNoRows.
+const usePasswordResetToken = `
+UPDATE password_reset_tokens SET used_at = $2
+WHERE token_hash = $1 AND used_at IS NULL AND expires_at > $2
+RETURNING user_id
+`
+
+func (q *Queries) UsePasswordResetToken(ctx context.Context, tokenHash string, now time.Time) (int64, error) {
+	var userID int64
+	err := q.db.QueryRow(ctx, usePasswordResetToken, tokenHash, now).Scan(&userID)
+	return userID, err
+}
+
+// ExpirePasswordResetTokens marks all unused tokens of the user as used.
+const expirePasswordResetTokens = `
+UPDATE password_reset_tokens SET used_at = $2
+WHERE user_id = $1 AND used_at IS NULL
+`
+
+func (q *Queries) ExpirePasswordResetTokens(ctx context.Context, userID int64, now time.Time) error {
+	_, err := q.db.Exec(ctx, expirePasswordResetTokens, userID, now)
+	return err
+}
@@ -29,9 +29,10 @@ INSERT INTO payment_links (
   success_action,
   redirect_url,
   success_message,
-  is_test
-) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
-RETURNING id, uuid, slug, created_at, updated_at, merchant_id, name, description, price, decimals, currency, success_action, redirect_url, success_message, is_test
+  is_test,
+  max_uses
+) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+RETURNING id, uuid, slug, created_at, updated_at, merchant_id, name, description, price, decimals, currency, success_action, redirect_url, success_message, is_test, max_uses, uses
 `
 
 type CreatePaymentLinkParams struct {
@@ -49,6 +50,7 @@ type CreatePaymentLinkParams struct {
 	RedirectUrl    sql.NullString
 	SuccessMessage sql.NullString
 	IsTest         bool
+	MaxUses        sql.NullInt32
 }
 
 func (q *Queries) CreatePaymentLink(ctx context.Context, arg CreatePaymentLinkParams) (PaymentLink, error) {
@@ -67,6 +69,7 @@ func (q *Queries) CreatePaymentLink(ctx context.Context, arg CreatePaymentLinkPa
 		arg.RedirectUrl,
 		arg.SuccessMessage,
 		arg.IsTest,
+		arg.MaxUses,
 	)
 	var i PaymentLink
 	err := row.Scan(
@@ -85,6 +88,8 @@ func (q *Queries) CreatePaymentLink(ctx context.Context, arg CreatePaymentLinkPa
 		&i.RedirectUrl,
 		&i.SuccessMessage,
 		&i.IsTest,
+		&i.MaxUses,
+		&i.Uses,
 	)
 	return i, err
 }
@@ -104,7 +109,7 @@ func (q *Queries) DeletePaymentLinkByPublicID(ctx context.Context, arg DeletePay
 }
 
 const getPaymentLinkByID = `-- name: GetPaymentLinkByID :one
-select id, uuid, slug, created_at, updated_at, merchant_id, name, description, price, decimals, currency, success_action, redirect_url, success_message, is_test from payment_links where merchant_id = $1 and id = $2 limit 1
+select id, uuid, slug, created_at, updated_at, merchant_id, name, description, price, decimals, currency, success_action, redirect_url, success_message, is_test, max_uses, uses from payment_links where merchant_id = $1 and id = $2 limit 1
 `
 
 type GetPaymentLinkByIDParams struct {
@@ -131,12 +136,14 @@ func (q *Queries) GetPaymentLinkByID(ctx context.Context, arg GetPaymentLinkByID
 		&i.RedirectUrl,
 		&i.SuccessMessage,
 		&i.IsTest,
+		&i.MaxUses,
+		&i.Uses,
 	)
 	return i, err
 }
 
 const getPaymentLinkByPublicID = `-- name: GetPaymentLinkByPublicID :one
-select id, uuid, slug, created_at, updated_at, merchant_id, name, description, price, decimals, currency, success_action, redirect_url, success_message, is_test from payment_links where merchant_id = $1 and uuid = $2 limit 1
+select id, uuid, slug, created_at, updated_at, merchant_id, name, description, price, decimals, currency, success_action, redirect_url, success_message, is_test, max_uses, uses from payment_links where merchant_id = $1 and uuid = $2 limit 1
 `
 
 type GetPaymentLinkByPublicIDParams struct {
@@ -163,12 +170,14 @@ func (q *Queries) GetPaymentLinkByPublicID(ctx context.Context, arg GetPaymentLi
 		&i.RedirectUrl,
 		&i.SuccessMessage,
 		&i.IsTest,
+		&i.MaxUses,
+		&i.Uses,
 	)
 	return i, err
 }
 
 const getPaymentLinkBySlug = `-- name: GetPaymentLinkBySlug :one
-select id, uuid, slug, created_at, updated_at, merchant_id, name, description, price, decimals, currency, success_action, redirect_url, success_message, is_test from payment_links where slug = $1 limit 1
+select id, uuid, slug, created_at, updated_at, merchant_id, name, description, price, decimals, currency, success_action, redirect_url, success_message, is_test, max_uses, uses from payment_links where slug = $1 limit 1
 `
 
 func (q *Queries) GetPaymentLinkBySlug(ctx context.Context, slug string) (PaymentLink, error) {
@@ -190,12 +199,49 @@ func (q *Queries) GetPaymentLinkBySlug(ctx context.Context, slug string) (Paymen
 		&i.RedirectUrl,
 		&i.SuccessMessage,
 		&i.IsTest,
+		&i.MaxUses,
+		&i.Uses,
+	)
+	return i, err
+}
+
+const incrementPaymentLinkUses = `-- name: IncrementPaymentLinkUses :one
+UPDATE payment_links SET uses = uses + 1, updated_at = $2 WHERE id = $1
+RETURNING id, uuid, slug, created_at, updated_at, merchant_id, name, description, price, decimals, currency, success_action, redirect_url, success_message, is_test, max_uses, uses
+`
+
+type IncrementPaymentLinkUsesParams struct {
+	ID        int64
+	UpdatedAt time.Time
+}
+
+func (q *Queries) IncrementPaymentLinkUses(ctx context.Context, arg IncrementPaymentLinkUsesParams) (PaymentLink, error) {
+	row := q.db.QueryRow(ctx, incrementPaymentLinkUses, arg.ID, arg.UpdatedAt)
+	var i PaymentLink
+	err := row.Scan(
+		&i.ID,
+		&i.Uuid,
+		&i.Slug,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.MerchantID,
+		&i.Name,
+		&i.Description,
+		&i.Price,
+		&i.Decimals,
+		&i.Currency,
+		&i.SuccessAction,
+		&i.RedirectUrl,
+		&i.SuccessMessage,
+		&i.IsTest,
+		&i.MaxUses,
+		&i.Uses,
 	)
 	return i, err
 }
 
 const listPaymentLinks = `-- name: ListPaymentLinks :many
-select id, uuid, slug, created_at, updated_at, merchant_id, name, description, price, decimals, currency, success_action, redirect_url, success_message, is_test from payment_links where merchant_id = $1 order by id desc limit $2
+select id, uuid, slug, created_at, updated_at, merchant_id, name, description, price, decimals, currency, success_action, redirect_url, success_message, is_test, max_uses, uses from payment_links where merchant_id = $1 order by id desc limit $2
 `
 
 type ListPaymentLinksParams struct {
@@ -228,6 +274,8 @@ func (q *Queries) ListPaymentLinks(ctx context.Context, arg ListPaymentLinksPara
 			&i.RedirectUrl,
 			&i.SuccessMessage,
 			&i.IsTest,
+			&i.MaxUses,
+			&i.Uses,
 		); err != nil {
 			return nil, err
 		}
@@ -99,6 +99,51 @@ func (q *Queries) CreatePayment(ctx context.Context, arg CreatePaymentParams) (P
 	return i, err
 }
 
+const extendPaymentExpiry = `-- name: ExtendPaymentExpiry :one
+UPDATE payments
+SET status = $1,
+    updated_at = $2,
+    expires_at = LEAST(
+        $3::timestamp,
+        COALESCE(original_expires_at, expires_at) + make_interval(mins => $4::int)
+    )
+WHERE id = $5 AND merchant_id = $6
+RETURNING id, expires_at
+`
+
+type ExtendPaymentExpiryParams struct {
+	Status              string
+	UpdatedAt           time.Time
+	RequestedExpiresAt  time.Time
+	MaxExtensionMinutes int32
+	ID                  int64
+	MerchantID          int64
+}
+
+type ExtendPaymentExpiryRow struct {
+	ID        int64
+	ExpiresAt sql.NullTime
+}
+
+// ExtendPaymentExpiry sets a new expires_at for a payment, hard-capped at
+// original_expires_at + MaxExtensionMinutes (merchant's top-up window) to
+// prevent infinite extension via dust top-ups.
+// Also updates the status (typically to "partial"). Returns the actual
+// expires_at applied (may be earlier than requested due to the cap).
+func (q *Queries) ExtendPaymentExpiry(ctx context.Context, arg ExtendPaymentExpiryParams) (ExtendPaymentExpiryRow, error) {
+	row := q.db.QueryRow(ctx, extendPaymentExpiry,
+		arg.Status,
+		arg.UpdatedAt,
+		arg.RequestedExpiresAt,
+		arg.MaxExtensionMinutes,
+		arg.ID,
+		arg.MerchantID,
+	)
+	var i ExtendPaymentExpiryRow
+	err := row.Scan(&i.ID, &i.ExpiresAt)
+	return i, err
+}
+
 const getBatchExpiredPayments = `-- name: GetBatchExpiredPayments :many
 SELECT id, public_id, created_at, updated_at, type, status, merchant_id, merchant_order_uuid, merchant_order_id, expires_at, price, decimals, currency, description, redirect_url, customer_id, is_test, webhook_sent_at, metadata from payments
 where (
@@ -494,6 +539,34 @@ func (q *Queries) PaginatePaymentsDesc(ctx context.Context, arg PaginatePayments
 	return items, nil
 }
 
+const setPaymentMetadataValue = `-- name: SetPaymentMetadataValue :exec
+UPDATE payments
+SET metadata = COALESCE(metadata, '{}'::jsonb) || jsonb_build_object($1::text, $2::text),
+    updated_at = $3
+WHERE id = $4 AND merchant_id = $5
+`
+
+type SetPaymentMetadataValueParams struct {
+	Key        string
+	Value      string
+	UpdatedAt  time.Time
+	ID         int64
+	MerchantID int64
+}
+
+// SetPaymentMetadataValue sets a single key of payment's metadata leaving
+// the rest intact.
+func (q *Queries) SetPaymentMetadataValue(ctx context.Context, arg SetPaymentMetadataValueParams) error {
+	_, err := q.db.Exec(ctx, setPaymentMetadataValue,
+		arg.Key,
+		arg.Value,
+		arg.UpdatedAt,
+		arg.ID,
+		arg.MerchantID,
+	)
+	return err
+}
+
 const updatePayment = `-- name: UpdatePayment :one
 UPDATE payments
 set status = $3,
@@ -546,48 +619,6 @@ func (q *Queries) UpdatePayment(ctx context.Context, arg UpdatePaymentParams) (P
 	return i, err
 }
 
-// ExtendPaymentExpiry sets a new expires_at for a payment, hard-capped at
-// original_expires_at + 24h to prevent infinite extension via dust top-ups.
-// Also updates the status (typically to "partial"). Returns the actual
-// expires_at applied (may be earlier than requested due to the cap).
-const extendPaymentExpiry = `-- name: ExtendPaymentExpiry :one
-UPDATE payments
-SET status = $3,
-    updated_at = $4,
-    expires_at = LEAST(
-        $5::timestamp,
-        COALESCE(original_expires_at, expires_at) + INTERVAL '24 hours'
-    )
-WHERE id = $1 AND merchant_id = $2
-RETURNING id, expires_at
-`
-
-type ExtendPaymentExpiryParams struct {
-	ID                int64
-	MerchantID        int64
-	Status            string
-	UpdatedAt         time.Time
-	RequestedExpiresAt time.Time
-}
-
-type ExtendPaymentExpiryRow struct {
-	ID        int64
-	ExpiresAt sql.NullTime
-}
-
-func (q *Queries) ExtendPaymentExpiry(ctx context.Context, arg ExtendPaymentExpiryParams) (ExtendPaymentExpiryRow, error) {
-	row := q.db.QueryRow(ctx, extendPaymentExpiry,
-		arg.ID,
-		arg.MerchantID,
-		arg.Status,
-		arg.UpdatedAt,
-		arg.RequestedExpiresAt,
-	)
-	var r ExtendPaymentExpiryRow
-	err := row.Scan(&r.ID, &r.ExpiresAt)
-	return r, err
-}
-
 const updatePaymentCustomerID = `-- name: UpdatePaymentCustomerID :exec
 UPDATE payments set customer_id = $1 where id = $2
 `
@@ -601,25 +632,3 @@ func (q *Queries) UpdatePaymentCustomerID(ctx context.Context, arg UpdatePayment
 	_, err := q.db.Exec(ctx, updatePaymentCustomerID, arg.CustomerID, arg.ID)
 	return err
 }
-
-const updatePaymentWebhookInfo = `-- name: UpdatePaymentWebhookInfo :exec
-UPDATE payments set webhook_sent_at = $3, updated_at = $4
-WHERE id = $1 and merchant_id = $2
-`
-
-type UpdatePaymentWebhookInfoParams struct {
-	ID            int64
-	MerchantID    int64
-	WebhookSentAt sql.NullTime
-	UpdatedAt     time.Time
-}
-
-func (q *Queries) UpdatePaymentWebhookInfo(ctx context.Context, arg UpdatePaymentWebhookInfoParams) error {
-	_, err := q.db.Exec(ctx, updatePaymentWebhookInfo,
-		arg.ID,
-		arg.MerchantID,
-		arg.WebhookSentAt,
-		arg.UpdatedAt,
-	)
-	return err
-}
@@ -38,9 +38,11 @@ type Querier interface {
 	HardDeleteXpubWallet(ctx context.Context, id int64) error
 	DeleteAPITokenByID(ctx context.Context, id int64) error
 	DeleteAPITokenByToken(ctx context.Context, token string) error
+	DeleteAPITokensByUser(ctx context.Context, userID int64) (int64, error)
 	DeletePaymentLinkByPublicID(ctx context.Context, arg DeletePaymentLinkByPublicIDParams) error
 	DeleteUser(ctx context.Context, id int64) error
 	EagerLoadTransactionsByPaymentID(ctx context.Context, arg EagerLoadTransactionsByPaymentIDParams) ([]Transaction, error)
+	ExtendPaymentExpiry(ctx context.Context, arg ExtendPaymentExpiryParams) (ExtendPaymentExpiryRow, error)
 	GetAPIToken(ctx context.Context, arg GetAPITokenParams) (ApiToken, error)
 	GetAPITokenByUUID(ctx context.Context, argUuid uuid.UUID) (ApiToken, error)
 	GetActiveSubscriptionByMerchantID(ctx context.Context, merchantID int64) (MerchantSubscription, error)
@@ -92,6 +94,7 @@ type Querier interface {
 	GetXpubWalletByMerchantAndBlockchain(ctx context.Context, arg GetXpubWalletByMerchantAndBlockchainParams) (XpubWallet, error)
 	GetXpubWalletByUUID(ctx context.Context, argUuid uuid.UUID) (XpubWallet, error)
 	IncrementAPIUsage(ctx context.Context, arg IncrementAPIUsageParams) error
+	IncrementPaymentLinkUses(ctx context.Context, arg IncrementPaymentLinkUsesParams) (PaymentLink, error)
 	IncrementPaymentUsage(ctx context.Context, arg IncrementPaymentUsageParams) error
 	InsertBalanceAuditLog(ctx context.Context, arg InsertBalanceAuditLogParams) error
 	LinkPaymentToSubscription(ctx context.Context, arg LinkPaymentToSubscriptionParams) error
@@ -117,16 +120,17 @@ type Querier interface {
 	PaginateCustomersDesc(ctx context.Context, arg PaginateCustomersDescParams) ([]Customer, error)
 	PaginatePaymentsAsc(ctx context.Context, arg PaginatePaymentsAscParams) ([]Payment, error)
 	PaginatePaymentsDesc(ctx context.Context, arg PaginatePaymentsDescParams) ([]Payment, error)
+	MergeTransactionMetadata(ctx context.Context, arg MergeTransactionMetadataParams) error
+	SetPaymentMetadataValue(ctx context.Context, arg SetPaymentMetadataValueParams) error
 	SetTransactionHash(ctx context.Context, arg SetTransactionHashParams) error
 	SoftDeleteMerchantByUUID(ctx context.Context, argUuid uuid.UUID) error
+	UpdateAPITokenLastUsed(ctx context.Context, arg UpdateAPITokenLastUsedParams) error
 	UpdateBalanceByID(ctx context.Context, arg UpdateBalanceByIDParams) (Balance, error)
 	UpdateMerchant(ctx context.Context, arg UpdateMerchantParams) (Merchant, error)
 	UpdateMerchantSettings(ctx context.Context, arg UpdateMerchantSettingsParams) error
 	UpdateMerchantSubscription(ctx context.Context, arg UpdateMerchantSubscriptionParams) (MerchantSubscription, error)
 	UpdatePayment(ctx context.Context, arg UpdatePaymentParams) (Payment, error)
-	ExtendPaymentExpiry(ctx context.Context, arg ExtendPaymentExpiryParams) (ExtendPaymentExpiryRow, error)
 	UpdatePaymentCustomerID(ctx context.Context, arg UpdatePaymentCustomerIDParams) error
-	UpdatePaymentWebhookInfo(ctx context.Context, arg UpdatePaymentWebhookInfoParams) error
 	InsertTransactionFill(ctx context.Context, arg InsertTransactionFillParams) (TransactionFill, error)
 	ListTransactionFills(ctx context.Context, transactionID int64) ([]TransactionFill, error)
 	SumConfirmedFillsForTx(ctx context.Context, transactionID int64) (pgtype.Numeric, error)
@@ -134,6 +138,55 @@ type Querier interface {
 	FillExistsByHashAndRecipient(ctx context.Context, networkID, transactionHash, recipient string) (bool, error)
 	MarkFillReorged(ctx context.Context, id int64) error
 	ListPartialPaymentTxIDs(ctx context.Context) ([]int64, error)
+	InsertRateSnapshot(ctx context.Context, arg InsertRateSnapshotParams) (RateSnapshot, error)
+	ListRateSnapshotsByPayment(ctx context.Context, merchantID, paymentID int64) ([]RateSnapshot, error)
+	ListRateSnapshotsByMerchant(ctx context.Context, arg ListRateSnapshotsByMerchantParams) ([]RateSnapshot, error)
+	ListRateSnapshotsByTransaction(ctx context.Context, transactionID int64) ([]RateSnapshot, error)
+	InsertLedgerEntry(ctx context.Context, arg InsertLedgerEntryParams) (LedgerEntry, error)
+	ListLedgerEntriesByEventKey(ctx context.Context, merchantID int64, eventKey string) ([]LedgerEntry, error)
+	SumLedgerAccountByTransaction(ctx context.Context, merchantID, transactionID int64, account string) (pgtype.Numeric, error)
+	ListLedgerEntries(ctx context.Context, arg ListLedgerEntriesParams) ([]LedgerEntry, error)
+	ListLedgerJournals(ctx context.Context, arg ListLedgerEntriesParams) ([]LedgerEntry, error)
+	LedgerAccountBalances(ctx context.Context, arg LedgerRangeParams) ([]LedgerAccountBalance, error)
+	LedgerEventTotals(ctx context.Context, arg LedgerRangeParams) ([]LedgerEventTotal, error)
+	ListUnpostedCollectorWithdrawals(ctx context.Context, limit int32) ([]UnpostedCollectorWithdrawal, error)
+	CreateWebhookDeliveries(ctx context.Context, arg CreateWebhookDeliveriesParams) (int64, error)
+	CreateWebhookEventDeliveries(ctx context.Context, arg CreateWebhookEventDeliveriesParams) (int64, error)
+	ClaimWebhookDeliveries(ctx context.Context, arg ClaimWebhookDeliveriesParams) ([]WebhookDelivery, error)
+	UpdateWebhookDeliveryAttempt(ctx context.Context, arg UpdateWebhookDeliveryAttemptParams) error
+	UpdateWebhookDeliveryStatus(ctx context.Context, id int64, status string, lastError sql.NullString, updatedAt time.Time) error
+	ListWebhookDeliveries(ctx context.Context, arg ListWebhookDeliveriesParams) ([]WebhookDelivery, error)
+	GetWebhookDeliveryByUUID(ctx context.Context, merchantID int64, id uuid.UUID) (WebhookDelivery, error)
+	CreateWebhookDeliveryReplay(ctx context.Context, id int64, createdAt time.Time) (WebhookDelivery, error)
+	DeleteWebhookDeliveriesBefore(ctx context.Context, before time.Time, limit int32) (int64, error)
+	InsertWebhookDeliveryAttempt(ctx context.Context, arg InsertWebhookDeliveryAttemptParams) error
+	ListWebhookDeliveryAttempts(ctx context.Context, deliveryID int64) ([]WebhookDeliveryAttempt, error)
+	CreateWebhookEndpoint(ctx context.Context, arg CreateWebhookEndpointParams) (WebhookEndpoint, error)
+	CreateMerchantEvent(ctx context.Context, arg CreateMerchantEventParams) error
+	ListMerchantEvents(ctx context.Context, arg ListMerchantEventsParams) ([]MerchantEvent, error)
+	ListMerchantEventPositions(ctx context.Context, afterTxID, afterID int64, limit int32) ([]MerchantEventPosition, error)
+	GetMerchantEventsHead(ctx context.Context, merchantID int64) (txID, id int64, err error)
+	UpdateMerchantEventPayload(ctx context.Context, id int64, payload pgtype.JSONB) (pgtype.JSONB, error)
+	DeleteMerchantEventsBefore(ctx context.Context, before time.Time, limit int32) (int64, error)
+	CreateEventBusMessage(ctx context.Context, topic string, payload pgtype.JSONB, createdAt time.Time) (int64, error)
+	NotifyEventBus(ctx context.Context, topic string) error
+	CreateEventBusOffset(ctx context.Context, consumer, topic string, createdAt time.Time) error
+	GetEventBusHead(ctx context.Context, topic string) (txID, id int64, err error)
+	ClaimEventBusOffset(ctx context.Context, arg ClaimEventBusOffsetParams) (EventBusOffset, error)
+	ReleaseEventBusOffset(ctx context.Context, consumer, topic, lockedBy string) error
+	ListEventBusMessages(ctx context.Context, arg ListEventBusMessagesParams) ([]EventBusMessage, error)
+	UpdateEventBusOffset(ctx context.Context, arg UpdateEventBusOffsetParams) (int64, error)
+	CreateEventBusDeadLetter(ctx context.Context, arg CreateEventBusDeadLetterParams) error
+	ListEventBusConsumerStats(ctx context.Context) ([]EventBusConsumerStats, error)
+	DeleteEventBusMessagesBefore(ctx context.Context, before time.Time, limit int32) (int64, error)
+	UpdateWebhookEndpoint(ctx context.Context, arg UpdateWebhookEndpointParams) (WebhookEndpoint, error)
+	RotateWebhookEndpointSecret(ctx context.Context, arg RotateWebhookEndpointSecretParams) (WebhookEndpoint, error)
+	DisableWebhookEndpoint(ctx context.Context, id int64, disabledAt time.Time) (bool, error)
+	DeleteWebhookEndpoint(ctx context.Context, id int64, deletedAt time.Time) error
+	GetWebhookEndpointByID(ctx context.Context, id int64) (WebhookEndpoint, error)
+	GetWebhookEndpointByUUID(ctx context.Context, merchantID int64, id uuid.UUID) (WebhookEndpoint, error)
+	ListWebhookEndpoints(ctx context.Context, merchantID int64) ([]WebhookEndpoint, error)
+	ListMatchingWebhookEndpoints(ctx context.Context, merchantID int64, eventType string, isTest bool) ([]WebhookEndpoint, error)
 	UpdateRegistryItem(ctx context.Context, arg UpdateRegistryItemParams) (Registry, error)
 	UpdateSubscriptionPlan(ctx context.Context, arg UpdateSubscriptionPlanParams) error
 	UpdateTransaction(ctx context.Context, arg UpdateTransactionParams) (Transaction, error)
@@ -146,6 +199,42 @@ type Querier interface {
 	UpdateEmailVerified(ctx context.Context, id int64) error
 	GetXpubWalletByMerchantAndBlockchainAny(ctx context.Context, arg GetXpubWalletByMerchantAndBlockchainParams) (XpubWallet, error)
 	ReactivateXpubWallet(ctx context.Context, arg ReactivateXpubWalletParams) (XpubWallet, error)
+	GetUserTotp(ctx context.Context, userID int64) (UserTotp, error)
+	UpsertPendingUserTotp(ctx context.Context, userID int64, secret string, now time.Time) (UserTotp, error)
+	EnableUserTotp(ctx context.Context, userID, step int64, now time.Time) (bool, error)
+	UseUserTotpStep(ctx context.Context, userID, step int64, now time.Time) (bool, error)
+	FailUserTotp(ctx context.Context, userID int64, maxAttempts int32, lockedUntil time.Time) (UserTotp, error)
+	ResetUserTotpFailures(ctx context.Context, userID int64) error
+	DeleteUserTotp(ctx context.Context, userID int64) error
+	CreateUserRecoveryCodes(ctx context.Context, userID int64, hashes []string, now time.Time) error
+	UseUserRecoveryCode(ctx context.Context, userID int64, hash string, now time.Time) (bool, error)
+	CountUnusedUserRecoveryCodes(ctx context.Context, userID int64) (int64, error)
+	DeleteUserRecoveryCodes(ctx context.Context, userID int64) error
+	CreatePasswordResetToken(ctx context.Context, arg CreatePasswordResetTokenParams) error
+	CountPasswordResetTokensSince(ctx context.Context, userID int64, since time.Time) (int64, error)
+	UsePasswordResetToken(ctx context.Context, tokenHash string, now time.Time) (int64, error)
+	ExpirePasswordResetTokens(ctx context.Context, userID int64, now time.Time) error
+	CreateMerchantMember(ctx context.Context, arg CreateMerchantMemberParams) (MerchantMember, error)
+	GetMerchantByUUIDAndMemberID(ctx context.Context, merchantUUID uuid.UUID, userID int64) (MerchantWithRole, error)
+	ListMerchantsByMemberID(ctx context.Context, userID int64) ([]MerchantWithRole, error)
+	ListMerchantMembers(ctx context.Context, merchantID int64) ([]MerchantMemberWithUser, error)
+	GetMerchantMemberByUserUUID(ctx context.Context, merchantID int64, userUUID uuid.UUID) (MerchantMemberWithUser, error)
+	UpdateMerchantMemberRole(ctx context.Context, merchantID, userID int64, role string, updatedAt time.Time) (MerchantMember, error)
+	DeleteMerchantMember(ctx context.Context, merchantID, userID int64) error
+	CountMerchantMembersByRole(ctx context.Context, merchantID int64, role string) (int64, error)
+	CreateMerchantInvitation(ctx context.Context, arg CreateMerchantInvitationParams) (MerchantInvitation, error)
+	ListPendingMerchantInvitations(ctx context.Context, merchantID int64, now time.Time) ([]MerchantInvitation, error)
+	DeletePendingMerchantInvitations(ctx context.Context, merchantID int64, email string) error
+	DeleteMerchantInvitation(ctx context.Context, merchantID int64, invitationUUID uuid.UUID) (int64, error)
+	AcceptMerchantInvitation(ctx context.Context, tokenHash string, userID int64, email string, now time.Time) (MerchantMember, error)
+	CreateAuditLog(ctx context.Context, arg CreateAuditLogParams) error
+	ListAuditLog(ctx context.Context, arg ListAuditLogParams) ([]AuditLog, error)
+	CreateUserSession(ctx context.Context, arg CreateUserSessionParams) (UserSession, error)
+	GetActiveUserSession(ctx context.Context, sessionUUID uuid.UUID, now time.Time) (UserSession, error)
+	ListActiveUserSessions(ctx context.Context, userID int64, now time.Time) ([]UserSession, error)
+	TouchUserSession(ctx context.Context, id int64, now time.Time, ipAddress string) error
+	RevokeUserSession(ctx context.Context, userID int64, sessionUUID uuid.UUID, now time.Time) (int64, error)
+	RevokeUserSessions(ctx context.Context, userID, exceptID int64, now time.Time) (int64, error)
 }
 
 var _ Querier = (*Queries)(nil)
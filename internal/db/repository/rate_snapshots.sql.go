@@ -0,0 +1,151 @@
+// Hand-written repository methods for rate_snapshots.
+// NOT generated by sqlc — keep this file out of `sqlc generate`'s blast radius.
+// One row per exchange-rate conversion that priced a transaction; rows are
+// append-only and form the audit trail behind usd_amount and crypto amounts.
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type RateSnapshot struct {
+	ID            int64
+	MerchantID    int64
+	PaymentID     int64
+	TransactionID int64
+	Kind          string
+	FromCurrency  string
+	ToCurrency    string
+	FromAmount    string
+	ToAmount      string
+	Rate          string
+	Source        string
+	FeePercent    sql.NullString
+	ChargedAmount sql.NullString
+	FetchedAt     time.Time
+	CreatedAt     time.Time
+
+	// PaymentUUID is payments.merchant_order_uuid; populated by list queries only.
+	PaymentUUID uuid.NullUUID
+}
+
+const rateSnapshotColumns = `
+rs.id, rs.merchant_id, rs.payment_id, rs.transaction_id, rs.kind, rs.from_currency, rs.to_currency,
+rs.from_amount, rs.to_amount, rs.rate::text, rs.source, rs.fee_percent::text, rs.charged_amount,
+rs.fetched_at, rs.created_at
+`
+
+func scanRateSnapshot(row interface{ Scan(dest ...any) error }, withPaymentUUID bool) (RateSnapshot, error) {
+	var r RateSnapshot
+	dest := []any{
+		&r.ID, &r.MerchantID, &r.PaymentID, &r.TransactionID, &r.Kind, &r.FromCurrency, &r.ToCurrency,
+		&r.FromAmount, &r.ToAmount, &r.Rate, &r.Source, &r.FeePercent, &r.ChargedAmount,
+		&r.FetchedAt, &r.CreatedAt,
+	}
+	if withPaymentUUID {
+		dest = append(dest, &r.PaymentUUID)
+	}
+	err := row.Scan(dest...)
+	return r, err
+}
+
+const insertRateSnapshot = `
+INSERT INTO rate_snapshots AS rs (
+    merchant_id, payment_id, transaction_id, kind, from_currency, to_currency,
+    from_amount, to_amount, rate, source, fee_percent, charged_amount, fetched_at, created_at
+) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9::numeric, $10, $11::numeric, $12, $13, $14)
+RETURNING ` + rateSnapshotColumns
+
+type InsertRateSnapshotParams struct {
+	MerchantID    int64
+	PaymentID     int64
+	TransactionID int64
+	Kind          string
+	FromCurrency  string
+	ToCurrency    string
+	FromAmount    string
+	ToAmount      string
+	Rate          string
+	Source        string
+	FeePercent    sql.NullString
+	ChargedAmount sql.NullString
+	FetchedAt     time.Time
+	CreatedAt     time.Time
+}
+
+func (q *Queries) InsertRateSnapshot(ctx context.Context, arg InsertRateSnapshotParams) (RateSnapshot, error) {
+	row := q.db.QueryRow(ctx, insertRateSnapshot,
+		arg.MerchantID, arg.PaymentID, arg.TransactionID, arg.Kind, arg.FromCurrency, arg.ToCurrency,
+		arg.FromAmount, arg.ToAmount, arg.Rate, arg.Source, arg.FeePercent, arg.ChargedAmount,
+		arg.FetchedAt, arg.CreatedAt,
+	)
+	return scanRateSnapshot(row, false)
+}
+
+const listRateSnapshotsByPayment = `
+SELECT ` + rateSnapshotColumns + `, p.merchant_order_uuid
+FROM rate_snapshots rs
+LEFT JOIN payments p ON p.id = rs.payment_id
+WHERE rs.merchant_id = $1 AND rs.payment_id = $2
+ORDER BY rs.id ASC
+`
+
+func (q *Queries) ListRateSnapshotsByPayment(ctx context.Context, merchantID, paymentID int64) ([]RateSnapshot, error) {
+	return q.listRateSnapshots(ctx, listRateSnapshotsByPayment, merchantID, paymentID)
+}
+
+// ListRateSnapshotsByTransaction returns snapshots that priced the transaction.
+// Used by the ledger to value entries at the rate the transaction was quoted at.
+const listRateSnapshotsByTransaction = `
+SELECT ` + rateSnapshotColumns + `, p.merchant_order_uuid
+FROM rate_snapshots rs
+LEFT JOIN payments p ON p.id = rs.payment_id
+WHERE rs.transaction_id = $1
+ORDER BY rs.id ASC
+`
+
+func (q *Queries) ListRateSnapshotsByTransaction(ctx context.Context, transactionID int64) ([]RateSnapshot, error) {
+	return q.listRateSnapshots(ctx, listRateSnapshotsByTransaction, transactionID)
+}
+
+// ListRateSnapshotsByMerchant returns snapshots created within [from, to).
+// Used by exports; ordered chronologically so the output is stable.
+const listRateSnapshotsByMerchant = `
+SELECT ` + rateSnapshotColumns + `, p.merchant_order_uuid
+FROM rate_snapshots rs
+LEFT JOIN payments p ON p.id = rs.payment_id
+WHERE rs.merchant_id = $1 AND rs.created_at >= $2 AND rs.created_at < $3
+ORDER BY rs.created_at ASC, rs.id ASC
+`
+
+type ListRateSnapshotsByMerchantParams struct {
+	MerchantID int64
+	From       time.Time
+	To         time.Time
+}
+
+func (q *Queries) ListRateSnapshotsByMerchant(ctx context.Context, arg ListRateSnapshotsByMerchantParams) ([]RateSnapshot, error) {
+	return q.listRateSnapshots(ctx, listRateSnapshotsByMerchant, arg.MerchantID, arg.From, arg.To)
+}
+
+func (q *Queries) listRateSnapshots(ctx context.Context, query string, args ...any) ([]RateSnapshot, error) {
+	rows, err := q.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []RateSnapshot
+	for rows.Next() {
+		r, err := scanRateSnapshot(rows, true)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, r)
+	}
+	return items, rows.Err()
+}
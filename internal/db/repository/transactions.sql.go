@@ -473,6 +473,22 @@ func (q *Queries) GetTransactionsByFilter(ctx context.Context, arg GetTransactio
 	return items, nil
 }
 
+const mergeTransactionMetadata = `-- name: MergeTransactionMetadata :exec
+update transactions set metadata = coalesce(metadata, '{}'::jsonb) || $1::jsonb
+where id = $2 and merchant_id = $3
+`
+
+type MergeTransactionMetadataParams struct {
+	Metadata   pgtype.JSONB
+	ID         int64
+	MerchantID int64
+}
+
+func (q *Queries) MergeTransactionMetadata(ctx context.Context, arg MergeTransactionMetadataParams) error {
+	_, err := q.db.Exec(ctx, mergeTransactionMetadata, arg.Metadata, arg.ID, arg.MerchantID)
+	return err
+}
+
 const setTransactionHash = `-- name: SetTransactionHash :exec
 update transactions set transaction_hash = $1, updated_at = $2 where id = $3 and merchant_id = $4
 `
@@ -0,0 +1,166 @@
+// Hand-written repository methods for user_totp and user_recovery_codes.
+// NOT generated by sqlc — keep this file out of `sqlc generate`'s blast radius.
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+type UserTotp struct {
+	UserID         int64
+	Secret         string
+	EnabledAt      sql.NullTime
+	LastUsedStep   int64
+	FailedAttempts int32
+	LockedUntil    sql.NullTime
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+const userTotpColumns = `user_id, secret, enabled_at, last_used_step, failed_attempts, locked_until, created_at, updated_at`
+
+func scanUserTotp(row interface{ Scan(...interface{}) error }, i *UserTotp) error {
+	return row.Scan(
+		&i.UserID,
+		&i.Secret,
+		&i.EnabledAt,
+		&i.LastUsedStep,
+		&i.FailedAttempts,
+		&i.LockedUntil,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+}
+
+const getUserTotp = `SELECT ` + userTotpColumns + ` FROM user_totp WHERE user_id = $1`
+
+func (q *Queries) GetUserTotp(ctx context.Context, userID int64) (UserTotp, error) {
+	var i UserTotp
+	err := scanUserTotp(q.db.QueryRow(ctx, getUserTotp, userID), &i)
+	return i, err
+}
+
+// UpsertPendingUserTotp stores a new secret of not yet enabled TOTP.
+// Returns pgx.ErrNoRows if TOTP is already enabled.
+const upsertPendingUserTotp = `
+INSERT INTO user_totp (user_id, secret, enabled_at, last_used_step, created_at, updated_at)
+VALUES ($1, $2, NULL, 0, $3, $3)
+ON CONFLICT (user_id) DO UPDATE
+    SET secret = excluded.secret, last_used_step = 0, updated_at = excluded.updated_at
+    WHERE user_totp.enabled_at IS NULL
+RETURNING ` + userTotpColumns
+
+func (q *Queries) UpsertPendingUserTotp(ctx context.Context, userID int64, secret string, now time.Time) (UserTotp, error) {
+	var i UserTotp
+	err := scanUserTotp(q.db.QueryRow(ctx, upsertPendingUserTotp, userID, secret, now), &i)
+	return i, err
+}
+
+// EnableUserTotp enables pending TOTP verified by a code of the given step.
+// Returns false if TOTP is missing or already enabled.
+const enableUserTotp = `
+UPDATE user_totp SET enabled_at = $3, last_used_step = $2, updated_at = $3
+WHERE user_id = $1 AND enabled_at IS NULL
+`
+
+func (q *Queries) EnableUserTotp(ctx context.Context, userID, step int64, now time.Time) (bool, error) {
+	res, err := q.db.Exec(ctx, enableUserTotp, userID, step, now)
+	if err != nil {
+		return false, err
+	}
+
+	return res.RowsAffected() == 1, nil
+}
+
+// UseUserTotpStep marks time step as used. Returns false if this or a later
+// step was already used.
+const useUserTotpStep = `
+UPDATE user_totp SET last_used_step = $2, updated_at = $3
+WHERE user_id = $1 AND enabled_at IS NOT NULL AND last_used_step < $2
+`
+
+func (q *Queries) UseUserTotpStep(ctx context.Context, userID, step int64, now time.Time) (bool, error) {
+	res, err := q.db.Exec(ctx, useUserTotpStep, userID, step, now)
+	if err != nil {
+		return false, err
+	}
+
+	return res.RowsAffected() == 1, nil
+}
+
+// FailUserTotp counts failed second factor attempt. The attempt that reaches
+// maxAttempts locks the second factor until lockedUntil and resets the counter.
+const failUserTotp = `
+UPDATE user_totp SET
+    failed_attempts = CASE WHEN failed_attempts + 1 >= $2 THEN 0 ELSE failed_attempts + 1 END,
+    locked_until    = CASE WHEN failed_attempts + 1 >= $2 THEN $3 ELSE locked_until END
+WHERE user_id = $1
+RETURNING ` + userTotpColumns
+
+func (q *Queries) FailUserTotp(ctx context.Context, userID int64, maxAttempts int32, lockedUntil time.Time) (UserTotp, error) {
+	var i UserTotp
+	err := scanUserTotp(q.db.QueryRow(ctx, failUserTotp, userID, maxAttempts, lockedUntil), &i)
+	return i, err
+}
+
+const resetUserTotpFailures = `
+UPDATE user_totp SET failed_attempts = 0, locked_until = NULL
+WHERE user_id = $1
+`
+
+func (q *Queries) ResetUserTotpFailures(ctx context.Context, userID int64) error {
+	_, err := q.db.Exec(ctx, resetUserTotpFailures, userID)
+	return err
+}
+
+const deleteUserTotp = `DELETE FROM user_totp WHERE user_id = $1`
+
+func (q *Queries) DeleteUserTotp(ctx context.Context, userID int64) error {
+	_, err := q.db.Exec(ctx, deleteUserTotp, userID)
+	return err
+}
+
+const createUserRecoveryCodes = `
+INSERT INTO user_recovery_codes (user_id, code_hash, created_at)
+SELECT $1, h, $3 FROM unnest($2::text[]) AS h
+`
+
+func (q *Queries) CreateUserRecoveryCodes(ctx context.Context, userID int64, hashes []string, now time.Time) error {
+	_, err := q.db.Exec(ctx, createUserRecoveryCodes, userID, hashes, now)
+	return err
+}
+
+// UseUserRecoveryCode marks unused code as used. Returns false if there is no
+// such unused code.
+const useUserRecoveryCode = `
+UPDATE user_recovery_codes SET used_at = $3
+WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL
+`
+
+func (q *Queries) UseUserRecoveryCode(ctx context.Context, userID int64, hash string, now time.Time) (bool, error) {
+	res, err := q.db.Exec(ctx, useUserRecoveryCode, userID, hash, now)
+	if err != nil {
+		return false, err
+	}
+
+	return res.RowsAffected() == 1, nil
+}
+
+const countUnusedUserRecoveryCodes = `
+SELECT count(*) FROM user_recovery_codes WHERE user_id = $1 AND used_at IS NULL
+`
+
+func (q *Queries) CountUnusedUserRecoveryCodes(ctx context.Context, userID int64) (int64, error) {
+	var count int64
+	err := q.db.QueryRow(ctx, countUnusedUserRecoveryCodes, userID).Scan(&count)
+	return count, err
+}
+
+const deleteUserRecoveryCodes = `DELETE FROM user_recovery_codes WHERE user_id = $1`
+
+func (q *Queries) DeleteUserRecoveryCodes(ctx context.Context, userID int64) error {
+	_, err := q.db.Exec(ctx, deleteUserRecoveryCodes, userID)
+	return err
+}
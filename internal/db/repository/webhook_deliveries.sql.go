@@ -0,0 +1,406 @@
+// Hand-written repository methods for webhook_deliveries.
+// NOT generated by sqlc — keep this file out of `sqlc generate`'s blast radius.
+// Rows are the merchant webhook outbox: one row per matching webhook endpoint
+// is inserted together with the change that caused the event and delivered by
+// the scheduler.
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgtype"
+)
+
+type WebhookDelivery struct {
+	ID            int64
+	MerchantID    int64
+	PaymentID     int64
+	EventType     string
+	PaymentStatus string
+	Status        string
+	Attempts      int32
+	Payload       pgtype.JSONB
+	URL           sql.NullString
+	ResponseCode  sql.NullInt32
+	LastError     sql.NullString
+	NextAttemptAt time.Time
+	LastAttemptAt sql.NullTime
+	DeliveredAt   sql.NullTime
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	UUID          uuid.UUID
+	ReplayOf      sql.NullInt64
+	EndpointID    sql.NullInt64
+
+	// EventID is the id of typed event envelope, shared by deliveries of the
+	// same event to different endpoints. Not set for legacy payment.status.
+	EventID uuid.NullUUID
+
+	// PaymentUUID is payments.merchant_order_uuid of PaymentID,
+	// EndpointUUID is webhook_endpoints.uuid of EndpointID.
+	// PaymentID is zero for events not related to a payment.
+	PaymentUUID  uuid.NullUUID
+	EndpointUUID uuid.NullUUID
+
+	// FirstAttemptAt retries are given up counting from it, not from CreatedAt.
+	FirstAttemptAt sql.NullTime
+}
+
+const webhookDeliveryColumns = `
+id, merchant_id, COALESCE(payment_id, 0), event_type, payment_status, status, attempts, payload, url,
+response_code, last_error, next_attempt_at, last_attempt_at, delivered_at, created_at, updated_at,
+uuid, replay_of, endpoint_id, event_id, first_attempt_at,
+(SELECT p.merchant_order_uuid FROM payments p WHERE p.id = webhook_deliveries.payment_id),
+(SELECT e.uuid FROM webhook_endpoints e WHERE e.id = webhook_deliveries.endpoint_id)
+`
+
+func scanWebhookDelivery(row interface{ Scan(dest ...any) error }) (WebhookDelivery, error) {
+	var d WebhookDelivery
+	err := row.Scan(
+		&d.ID, &d.MerchantID, &d.PaymentID, &d.EventType, &d.PaymentStatus, &d.Status, &d.Attempts, &d.Payload, &d.URL,
+		&d.ResponseCode, &d.LastError, &d.NextAttemptAt, &d.LastAttemptAt, &d.DeliveredAt, &d.CreatedAt, &d.UpdatedAt,
+		&d.UUID, &d.ReplayOf, &d.EndpointID, &d.EventID, &d.FirstAttemptAt,
+		&d.PaymentUUID, &d.EndpointUUID,
+	)
+	return d, err
+}
+
+// CreateWebhookDeliveries enqueues payment event to every enabled endpoint of
+// the merchant subscribed to it. Test-only endpoints receive events of test
+// payments only. Returns number of enqueued deliveries.
+const createWebhookDeliveries = `
+INSERT INTO webhook_deliveries (
+    merchant_id, payment_id, endpoint_id, url, event_type, event_id, payment_status, status, attempts,
+    next_attempt_at, created_at, updated_at
+)
+SELECT e.merchant_id, p.id, e.id, e.url, $3, $4, $5, 'pending', 0, $6, $6, $6
+FROM webhook_endpoints e
+JOIN payments p ON p.id = $2 AND p.merchant_id = e.merchant_id
+WHERE e.merchant_id = $1 AND e.deleted_at IS NULL AND e.enabled = true
+  AND (cardinality(e.event_types) = 0 OR $3::text = ANY(e.event_types))
+  AND (e.test_only = false OR p.is_test = true)
+ORDER BY e.id
+`
+
+type CreateWebhookDeliveriesParams struct {
+	MerchantID    int64
+	PaymentID     int64
+	EventType     string
+	EventID       uuid.NullUUID
+	PaymentStatus string
+	CreatedAt     time.Time
+}
+
+func (q *Queries) CreateWebhookDeliveries(ctx context.Context, arg CreateWebhookDeliveriesParams) (int64, error) {
+	res, err := q.db.Exec(ctx, createWebhookDeliveries,
+		arg.MerchantID, arg.PaymentID, arg.EventType, arg.EventID, arg.PaymentStatus, arg.CreatedAt,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected(), nil
+}
+
+// CreateWebhookEventDeliveries enqueues event not related to a payment with
+// already rendered payload to every enabled endpoint of the merchant subscribed to it.
+const createWebhookEventDeliveries = `
+INSERT INTO webhook_deliveries (
+    merchant_id, endpoint_id, url, event_type, event_id, status, attempts, payload,
+    next_attempt_at, created_at, updated_at
+)
+SELECT e.merchant_id, e.id, e.url, $2, $3, 'pending', 0, $5, $6, $6, $6
+FROM webhook_endpoints e
+WHERE e.merchant_id = $1 AND e.deleted_at IS NULL AND e.enabled = true
+  AND (cardinality(e.event_types) = 0 OR $2::text = ANY(e.event_types))
+  AND (e.test_only = false OR $4::boolean = true)
+ORDER BY e.id
+`
+
+type CreateWebhookEventDeliveriesParams struct {
+	MerchantID int64
+	EventType  string
+	EventID    uuid.UUID
+	IsTest     bool
+	Payload    pgtype.JSONB
+	CreatedAt  time.Time
+}
+
+func (q *Queries) CreateWebhookEventDeliveries(ctx context.Context, arg CreateWebhookEventDeliveriesParams) (int64, error) {
+	res, err := q.db.Exec(ctx, createWebhookEventDeliveries,
+		arg.MerchantID, arg.EventType, arg.EventID, arg.IsTest, arg.Payload, arg.CreatedAt,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected(), nil
+}
+
+// ClaimWebhookDeliveries leases due pending deliveries by moving their
+// next_attempt_at to LeaseUntil. Only the oldest pending delivery of each
+// payment per endpoint is claimable, so deliveries of a payment are sent to
+// every endpoint in order and a failing endpoint doesn't hold back others.
+// Events not related to a payment are not ordered.
+const claimWebhookDeliveries = `
+UPDATE webhook_deliveries SET next_attempt_at = $2, updated_at = $1
+WHERE id IN (
+    SELECT d.id FROM webhook_deliveries d
+    WHERE d.status = 'pending' AND d.next_attempt_at <= $1
+      AND NOT EXISTS (
+          SELECT 1 FROM webhook_deliveries e
+          WHERE e.payment_id = d.payment_id AND e.endpoint_id IS NOT DISTINCT FROM d.endpoint_id
+            AND e.id < d.id AND e.status = 'pending'
+      )
+    ORDER BY d.next_attempt_at, d.id
+    LIMIT $3
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING ` + webhookDeliveryColumns
+
+type ClaimWebhookDeliveriesParams struct {
+	Now        time.Time
+	LeaseUntil time.Time
+	Limit      int32
+}
+
+func (q *Queries) ClaimWebhookDeliveries(ctx context.Context, arg ClaimWebhookDeliveriesParams) ([]WebhookDelivery, error) {
+	return q.listWebhookDeliveries(ctx, claimWebhookDeliveries, arg.Now, arg.LeaseUntil, arg.Limit)
+}
+
+// ListWebhookDeliveries returns merchant's deliveries, newest first.
+// Zero / empty filters are ignored; BeforeID is the pagination cursor.
+const listWebhookDeliveries = `
+SELECT ` + webhookDeliveryColumns + `
+FROM webhook_deliveries
+WHERE merchant_id = $1
+  AND ($2::bigint = 0 OR payment_id = $2)
+  AND ($3::bigint = 0 OR endpoint_id = $3)
+  AND ($4::text = '' OR status = $4)
+  AND ($5::bigint = 0 OR id < $5)
+ORDER BY id DESC
+LIMIT $6
+`
+
+type ListWebhookDeliveriesParams struct {
+	MerchantID int64
+	PaymentID  int64
+	EndpointID int64
+	Status     string
+	BeforeID   int64
+	Limit      int32
+}
+
+func (q *Queries) ListWebhookDeliveries(ctx context.Context, arg ListWebhookDeliveriesParams) ([]WebhookDelivery, error) {
+	return q.listWebhookDeliveries(ctx, listWebhookDeliveries,
+		arg.MerchantID, arg.PaymentID, arg.EndpointID, arg.Status, arg.BeforeID, arg.Limit,
+	)
+}
+
+const getWebhookDeliveryByUUID = `
+SELECT ` + webhookDeliveryColumns + `
+FROM webhook_deliveries
+WHERE merchant_id = $1 AND uuid = $2
+`
+
+func (q *Queries) GetWebhookDeliveryByUUID(ctx context.Context, merchantID int64, id uuid.UUID) (WebhookDelivery, error) {
+	return scanWebhookDelivery(q.db.QueryRow(ctx, getWebhookDeliveryByUUID, merchantID, id))
+}
+
+// CreateWebhookDeliveryReplay enqueues a copy of the delivery with the same
+// payload to the same endpoint.
+const createWebhookDeliveryReplay = `
+INSERT INTO webhook_deliveries (
+    merchant_id, payment_id, endpoint_id, url, event_type, event_id, payment_status, status, attempts, payload, replay_of,
+    next_attempt_at, created_at, updated_at
+)
+SELECT merchant_id, payment_id, endpoint_id, url, event_type, event_id, payment_status, 'pending', 0, payload, id, $2, $2, $2
+FROM webhook_deliveries
+WHERE id = $1
+RETURNING ` + webhookDeliveryColumns
+
+func (q *Queries) CreateWebhookDeliveryReplay(ctx context.Context, id int64, createdAt time.Time) (WebhookDelivery, error) {
+	return scanWebhookDelivery(q.db.QueryRow(ctx, createWebhookDeliveryReplay, id, createdAt))
+}
+
+// DeleteWebhookDeliveriesBefore deletes up to limit finished deliveries created
+// before given time along with their attempts.
+const deleteWebhookDeliveriesBefore = `
+DELETE FROM webhook_deliveries
+WHERE id IN (
+    SELECT id FROM webhook_deliveries
+    WHERE created_at < $1 AND status <> 'pending'
+    ORDER BY id
+    LIMIT $2
+)
+`
+
+func (q *Queries) DeleteWebhookDeliveriesBefore(ctx context.Context, before time.Time, limit int32) (int64, error) {
+	res, err := q.db.Exec(ctx, deleteWebhookDeliveriesBefore, before, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected(), nil
+}
+
+func (q *Queries) listWebhookDeliveries(ctx context.Context, query string, args ...any) ([]WebhookDelivery, error) {
+	rows, err := q.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []WebhookDelivery
+	for rows.Next() {
+		d, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, d)
+	}
+
+	return items, rows.Err()
+}
+
+// UpdateWebhookDeliveryAttempt records delivery attempt result and bumps
+// payments.webhook_attempts. Successful attempt also sets payments.webhook_sent_at.
+const updateWebhookDeliveryAttempt = `
+WITH d AS (
+    UPDATE webhook_deliveries SET
+        status = $2,
+        attempts = attempts + 1,
+        payload = COALESCE(payload, $3),
+        url = $4,
+        response_code = $5,
+        last_error = $6,
+        next_attempt_at = $7,
+        last_attempt_at = $8,
+        first_attempt_at = COALESCE(first_attempt_at, $8),
+        delivered_at = $9,
+        updated_at = $8
+    WHERE id = $1
+    RETURNING payment_id
+)
+UPDATE payments SET
+    webhook_attempts = webhook_attempts + 1,
+    webhook_sent_at = COALESCE($9, webhook_sent_at)
+FROM d
+WHERE payments.id = d.payment_id
+`
+
+type UpdateWebhookDeliveryAttemptParams struct {
+	ID            int64
+	Status        string
+	Payload       pgtype.JSONB
+	URL           string
+	ResponseCode  sql.NullInt32
+	LastError     sql.NullString
+	NextAttemptAt time.Time
+	AttemptedAt   time.Time
+	DeliveredAt   sql.NullTime
+}
+
+func (q *Queries) UpdateWebhookDeliveryAttempt(ctx context.Context, arg UpdateWebhookDeliveryAttemptParams) error {
+	_, err := q.db.Exec(ctx, updateWebhookDeliveryAttempt,
+		arg.ID, arg.Status, arg.Payload, arg.URL, arg.ResponseCode, arg.LastError,
+		arg.NextAttemptAt, arg.AttemptedAt, arg.DeliveredAt,
+	)
+	return err
+}
+
+// UpdateWebhookDeliveryStatus changes delivery status without attempting it,
+// e.g. when merchant has no webhook endpoint.
+const updateWebhookDeliveryStatus = `
+UPDATE webhook_deliveries SET status = $2, last_error = $3, updated_at = $4
+WHERE id = $1
+`
+
+func (q *Queries) UpdateWebhookDeliveryStatus(ctx context.Context, id int64, status string, lastError sql.NullString, updatedAt time.Time) error {
+	_, err := q.db.Exec(ctx, updateWebhookDeliveryStatus, id, status, lastError, updatedAt)
+	return err
+}
+
+type WebhookDeliveryAttempt struct {
+	ID             int64
+	DeliveryID     int64
+	MerchantID     int64
+	Attempt        int32
+	URL            string
+	RequestHeaders pgtype.JSONB
+	RequestBody    string
+	ResponseStatus sql.NullInt32
+	ResponseBody   sql.NullString
+	LatencyMS      int32
+	ErrorClass     sql.NullString
+	Error          sql.NullString
+	CreatedAt      time.Time
+}
+
+const webhookDeliveryAttemptColumns = `
+id, delivery_id, merchant_id, attempt, url, request_headers, request_body,
+response_status, response_body, latency_ms, error_class, error, created_at
+`
+
+const insertWebhookDeliveryAttempt = `
+INSERT INTO webhook_delivery_attempts (
+    delivery_id, merchant_id, attempt, url, request_headers, request_body,
+    response_status, response_body, latency_ms, error_class, error, created_at
+) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+`
+
+type InsertWebhookDeliveryAttemptParams struct {
+	DeliveryID     int64
+	MerchantID     int64
+	Attempt        int32
+	URL            string
+	RequestHeaders pgtype.JSONB
+	RequestBody    string
+	ResponseStatus sql.NullInt32
+	ResponseBody   sql.NullString
+	LatencyMS      int32
+	ErrorClass     sql.NullString
+	Error          sql.NullString
+	CreatedAt      time.Time
+}
+
+func (q *Queries) InsertWebhookDeliveryAttempt(ctx context.Context, arg InsertWebhookDeliveryAttemptParams) error {
+	_, err := q.db.Exec(ctx, insertWebhookDeliveryAttempt,
+		arg.DeliveryID, arg.MerchantID, arg.Attempt, arg.URL, arg.RequestHeaders, arg.RequestBody,
+		arg.ResponseStatus, arg.ResponseBody, arg.LatencyMS, arg.ErrorClass, arg.Error, arg.CreatedAt,
+	)
+	return err
+}
+
+const listWebhookDeliveryAttempts = `
+SELECT ` + webhookDeliveryAttemptColumns + `
+FROM webhook_delivery_attempts
+WHERE delivery_id = $1
+ORDER BY id ASC
+`
+
+func (q *Queries) ListWebhookDeliveryAttempts(ctx context.Context, deliveryID int64) ([]WebhookDeliveryAttempt, error) {
+	rows, err := q.db.Query(ctx, listWebhookDeliveryAttempts, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []WebhookDeliveryAttempt
+	for rows.Next() {
+		var a WebhookDeliveryAttempt
+		err := rows.Scan(
+			&a.ID, &a.DeliveryID, &a.MerchantID, &a.Attempt, &a.URL, &a.RequestHeaders, &a.RequestBody,
+			&a.ResponseStatus, &a.ResponseBody, &a.LatencyMS, &a.ErrorClass, &a.Error, &a.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, a)
+	}
+
+	return items, rows.Err()
+}
@@ -0,0 +1,213 @@
+// Hand-written repository methods for webhook_endpoints.
+// NOT generated by sqlc — keep this file out of `sqlc generate`'s blast radius.
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type WebhookEndpoint struct {
+	ID          int64
+	UUID        uuid.UUID
+	MerchantID  int64
+	URL         string
+	Secret      string
+	Description string
+	Enabled     bool
+	TestOnly    bool
+	EventTypes  []string
+	DisabledAt  sql.NullTime
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+
+	PreviousSecret          sql.NullString
+	PreviousSecretExpiresAt sql.NullTime
+}
+
+const webhookEndpointColumns = `
+id, uuid, merchant_id, url, secret, description, enabled, test_only, event_types, disabled_at, created_at, updated_at,
+previous_secret, previous_secret_expires_at
+`
+
+func scanWebhookEndpoint(row interface{ Scan(dest ...any) error }) (WebhookEndpoint, error) {
+	var e WebhookEndpoint
+	err := row.Scan(
+		&e.ID, &e.UUID, &e.MerchantID, &e.URL, &e.Secret, &e.Description,
+		&e.Enabled, &e.TestOnly, &e.EventTypes, &e.DisabledAt, &e.CreatedAt, &e.UpdatedAt,
+		&e.PreviousSecret, &e.PreviousSecretExpiresAt,
+	)
+	return e, err
+}
+
+const createWebhookEndpoint = `
+INSERT INTO webhook_endpoints (
+    merchant_id, url, secret, description, enabled, test_only, event_types, created_at, updated_at
+) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $8)
+RETURNING ` + webhookEndpointColumns
+
+type CreateWebhookEndpointParams struct {
+	MerchantID  int64
+	URL         string
+	Secret      string
+	Description string
+	Enabled     bool
+	TestOnly    bool
+	EventTypes  []string
+	CreatedAt   time.Time
+}
+
+func (q *Queries) CreateWebhookEndpoint(ctx context.Context, arg CreateWebhookEndpointParams) (WebhookEndpoint, error) {
+	row := q.db.QueryRow(ctx, createWebhookEndpoint,
+		arg.MerchantID, arg.URL, arg.Secret, arg.Description, arg.Enabled, arg.TestOnly, arg.EventTypes, arg.CreatedAt,
+	)
+	return scanWebhookEndpoint(row)
+}
+
+// UpdateWebhookEndpoint updates endpoint settings except the secret, see
+// RotateWebhookEndpointSecret. Enabling the endpoint clears disabled_at set
+// after persistent delivery failures.
+const updateWebhookEndpoint = `
+UPDATE webhook_endpoints SET
+    url = $2,
+    description = $3,
+    enabled = $4,
+    test_only = $5,
+    event_types = $6,
+    disabled_at = CASE WHEN $4 THEN NULL ELSE disabled_at END,
+    updated_at = $7
+WHERE id = $1 AND deleted_at IS NULL
+RETURNING ` + webhookEndpointColumns
+
+type UpdateWebhookEndpointParams struct {
+	ID          int64
+	URL         string
+	Description string
+	Enabled     bool
+	TestOnly    bool
+	EventTypes  []string
+	UpdatedAt   time.Time
+}
+
+func (q *Queries) UpdateWebhookEndpoint(ctx context.Context, arg UpdateWebhookEndpointParams) (WebhookEndpoint, error) {
+	row := q.db.QueryRow(ctx, updateWebhookEndpoint,
+		arg.ID, arg.URL, arg.Description, arg.Enabled, arg.TestOnly, arg.EventTypes, arg.UpdatedAt,
+	)
+	return scanWebhookEndpoint(row)
+}
+
+// RotateWebhookEndpointSecret replaces the secret and keeps the current one
+// valid until PreviousExpiresAt.
+const rotateWebhookEndpointSecret = `
+UPDATE webhook_endpoints SET
+    previous_secret = secret,
+    previous_secret_expires_at = $3,
+    secret = $2,
+    updated_at = $4
+WHERE id = $1 AND deleted_at IS NULL
+RETURNING ` + webhookEndpointColumns
+
+type RotateWebhookEndpointSecretParams struct {
+	ID                int64
+	Secret            string
+	PreviousExpiresAt time.Time
+	UpdatedAt         time.Time
+}
+
+func (q *Queries) RotateWebhookEndpointSecret(ctx context.Context, arg RotateWebhookEndpointSecretParams) (WebhookEndpoint, error) {
+	row := q.db.QueryRow(ctx, rotateWebhookEndpointSecret, arg.ID, arg.Secret, arg.PreviousExpiresAt, arg.UpdatedAt)
+	return scanWebhookEndpoint(row)
+}
+
+// DisableWebhookEndpoint disables endpoint after persistent delivery failures.
+// Returns false if the endpoint was already disabled.
+const disableWebhookEndpoint = `
+UPDATE webhook_endpoints SET enabled = false, disabled_at = $2, updated_at = $2
+WHERE id = $1 AND enabled = true
+`
+
+func (q *Queries) DisableWebhookEndpoint(ctx context.Context, id int64, disabledAt time.Time) (bool, error) {
+	res, err := q.db.Exec(ctx, disableWebhookEndpoint, id, disabledAt)
+	if err != nil {
+		return false, err
+	}
+
+	return res.RowsAffected() > 0, nil
+}
+
+const deleteWebhookEndpoint = `
+UPDATE webhook_endpoints SET enabled = false, deleted_at = $2, updated_at = $2
+WHERE id = $1 AND deleted_at IS NULL
+`
+
+func (q *Queries) DeleteWebhookEndpoint(ctx context.Context, id int64, deletedAt time.Time) error {
+	_, err := q.db.Exec(ctx, deleteWebhookEndpoint, id, deletedAt)
+	return err
+}
+
+const getWebhookEndpointByID = `
+SELECT ` + webhookEndpointColumns + `
+FROM webhook_endpoints
+WHERE id = $1 AND deleted_at IS NULL
+`
+
+func (q *Queries) GetWebhookEndpointByID(ctx context.Context, id int64) (WebhookEndpoint, error) {
+	return scanWebhookEndpoint(q.db.QueryRow(ctx, getWebhookEndpointByID, id))
+}
+
+const getWebhookEndpointByUUID = `
+SELECT ` + webhookEndpointColumns + `
+FROM webhook_endpoints
+WHERE merchant_id = $1 AND uuid = $2 AND deleted_at IS NULL
+`
+
+func (q *Queries) GetWebhookEndpointByUUID(ctx context.Context, merchantID int64, id uuid.UUID) (WebhookEndpoint, error) {
+	return scanWebhookEndpoint(q.db.QueryRow(ctx, getWebhookEndpointByUUID, merchantID, id))
+}
+
+const listWebhookEndpoints = `
+SELECT ` + webhookEndpointColumns + `
+FROM webhook_endpoints
+WHERE merchant_id = $1 AND deleted_at IS NULL
+ORDER BY id
+`
+
+func (q *Queries) ListWebhookEndpoints(ctx context.Context, merchantID int64) ([]WebhookEndpoint, error) {
+	return q.listWebhookEndpoints(ctx, listWebhookEndpoints, merchantID)
+}
+
+// ListMatchingWebhookEndpoints returns enabled endpoints subscribed to the event.
+const listMatchingWebhookEndpoints = `
+SELECT ` + webhookEndpointColumns + `
+FROM webhook_endpoints
+WHERE merchant_id = $1 AND deleted_at IS NULL AND enabled = true
+  AND (cardinality(event_types) = 0 OR $2::text = ANY(event_types))
+  AND (test_only = false OR $3::boolean = true)
+ORDER BY id
+`
+
+func (q *Queries) ListMatchingWebhookEndpoints(ctx context.Context, merchantID int64, eventType string, isTest bool) ([]WebhookEndpoint, error) {
+	return q.listWebhookEndpoints(ctx, listMatchingWebhookEndpoints, merchantID, eventType, isTest)
+}
+
+func (q *Queries) listWebhookEndpoints(ctx context.Context, query string, args ...any) ([]WebhookEndpoint, error) {
+	rows, err := q.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []WebhookEndpoint
+	for rows.Next() {
+		e, err := scanWebhookEndpoint(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, e)
+	}
+
+	return items, rows.Err()
+}
@@ -0,0 +1,106 @@
+package billingevents
+
+import (
+	"context"
+
+	"github.com/cryptolink/cryptolink/internal/bus"
+	"github.com/cryptolink/cryptolink/internal/service/billing"
+	"github.com/cryptolink/cryptolink/internal/service/webhooks"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+type Handler struct {
+	billing  *billing.Service
+	webhooks *webhooks.Service
+	logger   *zerolog.Logger
+}
+
+func New(billingService *billing.Service, webhookService *webhooks.Service, logger *zerolog.Logger) *Handler {
+	log := logger.With().Str("channel", "billing_events_consumer").Logger()
+
+	return &Handler{
+		billing:  billingService,
+		webhooks: webhookService,
+		logger:   &log,
+	}
+}
+
+func (h *Handler) Consumers() map[bus.Topic][]bus.Consumer {
+	return map[bus.Topic][]bus.Consumer{
+		bus.TopicPaymentStatusUpdate: {
+			h.ProcessPaymentStatusUpdate,
+		},
+		bus.TopicCustomerSubscriptionUpdate: {
+			h.SendSubscriptionWebhook,
+		},
+	}
+}
+
+// Subscription webhook events.
+const (
+	EventActivated = webhooks.EventSubscriptionActivated
+	EventRenewed   = webhooks.EventSubscriptionRenewed
+	EventPastDue   = webhooks.EventSubscriptionPastDue
+	EventCancelled = webhooks.EventSubscriptionCancelled
+)
+
+type SubscriptionWebhook = webhooks.SubscriptionWebhook
+
+func (h *Handler) ProcessPaymentStatusUpdate(ctx context.Context, message bus.Message) error {
+	req, err := bus.Bind[bus.PaymentStatusUpdateEvent](message)
+	if err != nil {
+		return err
+	}
+
+	return h.billing.HandlePaymentStatus(ctx, req.MerchantID, req.PaymentID)
+}
+
+func (h *Handler) SendSubscriptionWebhook(ctx context.Context, message bus.Message) error {
+	req, err := bus.Bind[bus.CustomerSubscriptionUpdateEvent](message)
+	if err != nil {
+		return err
+	}
+
+	event := resolveEvent(req.Status, req.PreviousStatus)
+	if event == "" {
+		return nil
+	}
+
+	sub, err := h.billing.GetSubscriptionByID(ctx, req.MerchantID, req.SubscriptionID)
+	if err != nil {
+		return errors.Wrap(err, "unable to get subscription")
+	}
+
+	wh := SubscriptionWebhook{
+		Event:              event,
+		ID:                 sub.UUID.String(),
+		Status:             req.Status,
+		PreviousStatus:     req.PreviousStatus,
+		PlanID:             sub.PlanUUID.String(),
+		CustomerID:         sub.CustomerUUID.String(),
+		CustomerEmail:      sub.CustomerEmail,
+		CurrentPeriodStart: sub.CurrentPeriodStart,
+		CurrentPeriodEnd:   sub.CurrentPeriodEnd,
+		CancelAtPeriodEnd:  sub.CancelAtPeriodEnd,
+		IsTest:             sub.IsTest,
+	}
+
+	return h.webhooks.SendEvent(ctx, req.MerchantID, event, sub.IsTest, wh)
+}
+
+func resolveEvent(status, previous string) string {
+	switch billing.Status(status) {
+	case billing.StatusActive:
+		if billing.Status(previous) == billing.StatusActive {
+			return EventRenewed
+		}
+		return EventActivated
+	case billing.StatusPastDue:
+		return EventPastDue
+	case billing.StatusCancelled:
+		return EventCancelled
+	}
+
+	return ""
+}
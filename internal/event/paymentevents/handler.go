@@ -4,16 +4,14 @@ import (
 	"context"
 	"fmt"
 	"net/url"
-	"time"
 
 	"github.com/cryptolink/cryptolink/internal/bus"
 	"github.com/cryptolink/cryptolink/internal/service/merchant"
 	"github.com/cryptolink/cryptolink/internal/service/payment"
 	"github.com/cryptolink/cryptolink/internal/service/processing"
 	"github.com/cryptolink/cryptolink/internal/service/subscription"
+	"github.com/cryptolink/cryptolink/internal/service/webhooks"
 	"github.com/cryptolink/cryptolink/internal/slack"
-	"github.com/cryptolink/cryptolink/internal/util"
-	"github.com/cryptolink/cryptolink/internal/webhook"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
 )
@@ -56,121 +54,30 @@ func (h *Handler) Consumers() map[bus.Topic][]bus.Consumer {
 	}
 }
 
-type PaymentWebhook struct {
-	ID     string `json:"id"`
-	Status string `json:"status"`
-
-	CustomerEmail string `json:"customerEmail"`
-
-	SelectedBlockchain string `json:"selectedBlockchain"`
-	SelectedCurrency   string `json:"selectedCurrency"`
-
-	IsTest bool `json:"isTest"`
-
-	LinkID *string `json:"paymentLinkId"`
-
-	// Partial-fill payload — populated only when Status == "partial".
-	// Merchants should treat the `idempotencyKey` as a dedup token: it's
-	// "<network_id>:<tx_hash>:<vout_or_logidx>" of the most recent
-	// confirmed fill, so retried webhook deliveries for the same fill
-	// arrive with the same key.
-	ReceivedAmount  string `json:"receivedAmount,omitempty"`
-	RemainingAmount string `json:"remainingAmount,omitempty"`
-	IdempotencyKey  string `json:"idempotencyKey,omitempty"`
-}
+// PaymentWebhook payload of payment status webhook.
+type PaymentWebhook = webhooks.PaymentWebhook
 
+// ProcessPaymentStatusUpdate handles subscription payments. Status webhooks are
+// written to the outbox by payment service along with the status change and
+// delivered by the scheduler.
 func (h *Handler) ProcessPaymentStatusUpdate(ctx context.Context, message bus.Message) error {
 	req, err := bus.Bind[bus.PaymentStatusUpdateEvent](message)
 	if err != nil {
 		return err
 	}
 
-	mt, err := h.merchants.GetByID(ctx, req.MerchantID, false)
+	pt, err := h.payments.GetByID(ctx, req.MerchantID, req.PaymentID)
 	if err != nil {
-		return errors.Wrap(err, "unable to get merchant")
-	}
-
-	webhookURL := mt.Settings().WebhookURL()
-	signatureSecret := mt.Settings().WebhookSignatureSecret()
-
-	if webhookURL == "" {
-		h.logger.Warn().
-			Int64("merchant_id", req.MerchantID).Int64("payment_id", req.PaymentID).
-			Msg("webhook not set; skipping sending")
-
-		return nil
-	}
-
-	p, err := h.processing.GetDetailedPayment(ctx, req.MerchantID, req.PaymentID)
-	if err != nil {
-		return errors.Wrap(err, "unable to get detailed payment")
-	}
-
-	// omit "locked" event
-	if p.Payment.Status == payment.StatusLocked {
-		return nil
+		return errors.Wrap(err, "unable to get payment")
 	}
 
-	wh := PaymentWebhook{
-		ID:     p.Payment.MerchantOrderUUID.String(),
-		Status: p.Payment.Status.String(),
-		IsTest: p.Payment.IsTest,
-	}
-	if p.Customer != nil {
-		wh.CustomerEmail = p.Customer.Email
-	}
-	if p.PaymentMethod != nil {
-		wh.SelectedBlockchain = p.PaymentMethod.Currency.Blockchain.String()
-		wh.SelectedCurrency = p.PaymentMethod.Currency.Ticker
-	}
-	// Partial-fill enrichment: include received/remaining amounts and a
-	// per-fill idempotency key so merchants can dedup retried deliveries.
-	if p.Payment.Status == payment.StatusPartial && p.PaymentInfo != nil {
-		wh.ReceivedAmount = p.PaymentInfo.ReceivedAmount
-		wh.RemainingAmount = p.PaymentInfo.RemainingAmount
-		if key, keyErr := h.processing.LatestFillIdempotencyKey(ctx, req.MerchantID, req.PaymentID); keyErr == nil {
-			wh.IdempotencyKey = key
-		}
-	}
-	if p.Payment.LinkID() != 0 {
-		link, err := h.payments.GetPaymentLinkByID(ctx, mt.ID, p.Payment.LinkID())
-		if err != nil {
-			return errors.Wrap(err, "unable to get payment link")
-		}
-
-		wh.LinkID = util.Ptr(link.PublicID.String())
-	}
-
-	if err := webhook.Send(ctx, webhookURL, signatureSecret, wh); err != nil {
-		h.logger.Warn().Err(err).
-			Int64("merchant_id", req.MerchantID).
-			Int64("payment_id", req.PaymentID).
-			Interface("webhook", wh).
-			Str("webhook_url", webhookURL).
-			Msg("unable to send webhook")
-
-		// todo some visual alert for merchant
-
-		return nil
-	}
-
-	if err := h.payments.SetWebhookTimestamp(ctx, req.MerchantID, req.PaymentID, time.Now()); err != nil {
-		return errors.Wrap(err, "unable to set webhook timestamp")
-	}
-
-	h.logger.Info().
-		Int64("merchant_id", req.MerchantID).
-		Int64("payment_id", req.PaymentID).
-		Str("webhook_url", webhookURL).
-		Msg("sent webhook to merchant")
-
 	// Handle subscription activation if payment is for a subscription
-	if p.Payment.Status == payment.StatusSuccess {
-		if err := h.handleSubscriptionPayment(ctx, p.Payment.ID); err != nil {
+	if pt.Status == payment.StatusSuccess {
+		if err := h.handleSubscriptionPayment(ctx, pt.ID); err != nil {
 			h.logger.Error().Err(err).
-				Int64("payment_id", p.Payment.ID).
+				Int64("payment_id", pt.ID).
 				Msg("failed to handle subscription payment")
-			// Don't fail the whole webhook processing if subscription activation fails
+			// Don't fail the whole event processing if subscription activation fails
 		}
 	}
 
@@ -6,16 +6,14 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
-	"time"
 
 	"github.com/cryptolink/cryptolink/internal/bus"
-	"github.com/cryptolink/cryptolink/internal/db/repository"
 	"github.com/cryptolink/cryptolink/internal/event/paymentevents"
 	"github.com/cryptolink/cryptolink/internal/money"
-	"github.com/cryptolink/cryptolink/internal/service/merchant"
 	"github.com/cryptolink/cryptolink/internal/service/payment"
 	"github.com/cryptolink/cryptolink/internal/service/transaction"
 	"github.com/cryptolink/cryptolink/internal/service/wallet"
+	"github.com/cryptolink/cryptolink/internal/service/webhooks"
 	"github.com/cryptolink/cryptolink/internal/test"
 	"github.com/cryptolink/cryptolink/internal/util"
 	"github.com/samber/lo"
@@ -39,6 +37,7 @@ func setup(t *testing.T) (*test.IntegrationTest, *paymentevents.Handler, *[]stri
 		tc.Services.Merchants,
 		tc.Services.Processing,
 		tc.Services.Payment,
+		tc.Services.Subscriptions,
 		httptest.NewServer(http.HandlerFunc(okResponder)).URL,
 		tc.Logger,
 	)
@@ -60,15 +59,12 @@ func TestHandler_ProcessPaymentStatusUpdate(t *testing.T) {
 		assertBind(t, request, &actualWebhook)
 	})
 
-	// And a merchant
-	mt, err := tc.Services.Merchants.Create(tc.Context, merchantID, "my-site", "my-site.com", merchant.Settings{
-		merchant.PropertySignatureSecret: "abc",
-		merchant.PropertyWebhookURL:      srv.URL,
-	})
+	// And a merchant with webhook endpoint
+	mt, err := tc.Services.Merchants.Create(tc.Context, merchantID, "my-site", "my-site.com", nil)
+	require.NoError(t, err)
 
+	_, err = tc.Services.Webhooks.SetPrimaryEndpoint(tc.Context, mt.ID, srv.URL, "abc")
 	require.NoError(t, err)
-	require.Equal(t, "abc", mt.Settings().WebhookSignatureSecret())
-	require.Equal(t, srv.URL, mt.Settings().WebhookURL())
 
 	// ... and a payment make from a link
 	// This payment represents the most extended webhook case with payment link
@@ -92,14 +88,19 @@ func TestHandler_ProcessPaymentStatusUpdate(t *testing.T) {
 	person, err := tc.Services.Payment.AssignCustomerByEmail(tc.Context, p, "test@me.com")
 	require.NoError(t, err)
 
-	// And the payment mocked as "successful"
-	_, err = tc.Repository.UpdatePayment(tc.Context, repository.UpdatePaymentParams{
-		ID:         p.ID,
-		MerchantID: p.MerchantID,
-		Status:     payment.StatusSuccess.String(),
-		UpdatedAt:  time.Now(),
+	// And the payment marked as "successful"
+	_, err = tc.Services.Payment.Update(tc.Context, p.MerchantID, p.ID, payment.UpdateProps{Status: payment.StatusSuccess})
+	require.NoError(t, err)
+
+	// And status webhook enqueued to the outbox along with the status change
+	deliveries, err := tc.Services.Webhooks.ListDeliveries(tc.Context, mt.ID, webhooks.ListDeliveriesParams{
+		PaymentID: p.ID,
+		Limit:     10,
 	})
 	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, payment.WebhookEventStatusUpdate, deliveries[0].EventType)
+	assert.Equal(t, webhooks.DeliveryPending, deliveries[0].Status)
 
 	// ACT
 	msg := marshal(bus.PaymentStatusUpdateEvent{
@@ -110,6 +111,17 @@ func TestHandler_ProcessPaymentStatusUpdate(t *testing.T) {
 	assert.NoError(t, handler.ProcessPaymentStatusUpdate(tc.Context, msg))
 	assert.NoError(t, handler.SendSuccessfulPaymentNotification(tc.Context, msg))
 
+	// the consumer doesn't deliver webhooks, the scheduler does
+	deliveries, err = tc.Services.Webhooks.ListDeliveries(tc.Context, mt.ID, webhooks.ListDeliveriesParams{
+		PaymentID: p.ID,
+		Limit:     10,
+	})
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, webhooks.DeliveryPending, deliveries[0].Status)
+
+	require.NoError(t, tc.Services.Webhooks.DeliverDue(tc.Context))
+
 	// ASSERT
 	expectedWebhook := paymentevents.PaymentWebhook{
 		ID:                 p.MerchantOrderUUID.String(),
@@ -123,6 +135,15 @@ func TestHandler_ProcessPaymentStatusUpdate(t *testing.T) {
 
 	assert.Equal(t, expectedWebhook, actualWebhook)
 
+	// Check that outbox delivery was sent
+	deliveries, err = tc.Services.Webhooks.ListDeliveries(tc.Context, mt.ID, webhooks.ListDeliveriesParams{
+		PaymentID: p.ID,
+		Limit:     10,
+	})
+	require.NoError(t, err)
+	require.Len(t, deliveries, 1)
+	assert.Equal(t, webhooks.DeliveryDelivered, deliveries[0].Status)
+
 	// Check that webhook timestamp was updated
 	freshPayment, err := tc.Services.Payment.GetByID(tc.Context, merchantID, p.ID)
 	assert.NoError(t, err)
@@ -16,13 +16,17 @@ import (
 	"github.com/cryptolink/cryptolink/internal/provider/pricefeed"
 	"github.com/cryptolink/cryptolink/internal/provider/rpc"
 	"github.com/cryptolink/cryptolink/internal/provider/trongrid"
+	"github.com/cryptolink/cryptolink/internal/service/audit"
+	"github.com/cryptolink/cryptolink/internal/service/billing"
 	"github.com/cryptolink/cryptolink/internal/service/blockchain"
 	"github.com/cryptolink/cryptolink/internal/service/merchant"
 	"github.com/cryptolink/cryptolink/internal/service/payment"
 	"github.com/cryptolink/cryptolink/internal/service/processing"
 	"github.com/cryptolink/cryptolink/internal/service/email"
 	"github.com/cryptolink/cryptolink/internal/service/evmcollector"
+	"github.com/cryptolink/cryptolink/internal/service/ledger"
 	"github.com/cryptolink/cryptolink/internal/service/registry"
+	"github.com/cryptolink/cryptolink/internal/service/report"
 	"github.com/cryptolink/cryptolink/internal/service/contact"
 	"github.com/cryptolink/cryptolink/internal/service/marketing"
 	"github.com/cryptolink/cryptolink/internal/service/subscription"
@@ -30,6 +34,7 @@ import (
 	"github.com/cryptolink/cryptolink/internal/service/user"
 	"github.com/cryptolink/cryptolink/internal/service/wallet"
 	"github.com/cryptolink/cryptolink/internal/service/watcher"
+	"github.com/cryptolink/cryptolink/internal/service/webhooks"
 	"github.com/cryptolink/cryptolink/internal/service/xpub"
 	"github.com/cryptolink/cryptolink/pkg/graceful"
 	"github.com/rs/zerolog"
@@ -48,7 +53,7 @@ type Locator struct {
 	store *repository.Store
 
 	// Event
-	eventBus *bus.PubSub
+	eventBus *bus.Durable
 
 	// Providers
 	rpcProvider       *rpc.Provider
@@ -65,6 +70,7 @@ type Locator struct {
 	tokenManager         *auth.TokenAuthManager
 	googleAuth           *auth.GoogleOAuthManager
 	transactionService   *transaction.Service
+	ledgerService        *ledger.Service
 	paymentService       *payment.Service
 	walletService        *wallet.Service
 	xpubService          *xpub.Service
@@ -72,7 +78,11 @@ type Locator struct {
 	processingService    *processing.Service
 	watcherService       *watcher.Service
 	subscriptionService  *subscription.Service
+	billingService       *billing.Service
+	reportService        *report.Service
+	webhookService       *webhooks.Service
 	emailService         *email.Service
+	auditService         *audit.Service
 	contactService       *contact.Service
 	marketingService     *marketing.Service
 	jobLogger            *log.JobLogger
@@ -124,9 +134,9 @@ func (loc *Locator) Store() *repository.Store {
 	return loc.store
 }
 
-func (loc *Locator) EventBus() *bus.PubSub {
+func (loc *Locator) EventBus() *bus.Durable {
 	loc.init("event.bus", func() {
-		loc.eventBus = bus.NewPubSub(loc.ctx, true, loc.logger)
+		loc.eventBus = bus.NewDurable(loc.ctx, loc.Store(), loc.DB().Pool, loc.logger)
 	})
 
 	return loc.eventBus
@@ -212,7 +222,12 @@ func (loc *Locator) UserService() *user.Service {
 
 func (loc *Locator) MerchantService() *merchant.Service {
 	loc.init("service.merchant", func() {
-		loc.merchantService = merchant.New(loc.Repository(), loc.BlockchainService(), loc.logger)
+		branding := loc.config.Oxygen.Branding
+		if branding.PublicBaseURL == "" {
+			branding.PublicBaseURL = loc.config.Oxygen.Processing.PaymentFrontendBasePath
+		}
+
+		loc.merchantService = merchant.New(loc.Repository(), loc.Store(), loc.BlockchainService(), branding, loc.logger)
 	})
 
 	return loc.merchantService
@@ -240,6 +255,7 @@ func (loc *Locator) TransactionService() *transaction.Service {
 			loc.Store(),
 			loc.BlockchainService(),
 			loc.WalletService(),
+			loc.LedgerService(),
 			loc.logger,
 		)
 	})
@@ -247,10 +263,18 @@ func (loc *Locator) TransactionService() *transaction.Service {
 	return loc.transactionService
 }
 
+func (loc *Locator) LedgerService() *ledger.Service {
+	loc.init("service.ledger", func() {
+		loc.ledgerService = ledger.New(loc.Store(), loc.BlockchainService(), loc.logger)
+	})
+
+	return loc.ledgerService
+}
+
 func (loc *Locator) PaymentService() *payment.Service {
 	loc.init("service.payment", func() {
 		loc.paymentService = payment.New(
-			loc.Repository(),
+			loc.Store(),
 			loc.config.Oxygen.Processing.PaymentFrontendPath(),
 			loc.TransactionService(),
 			loc.MerchantService(),
@@ -296,6 +320,53 @@ func (loc *Locator) SubscriptionService() *subscription.Service {
 	return loc.subscriptionService
 }
 
+func (loc *Locator) BillingService() *billing.Service {
+	loc.init("service.billing", func() {
+		loc.billingService = billing.New(
+			loc.DB().Pool,
+			loc.config.Oxygen.Billing,
+			loc.PaymentService(),
+			loc.MerchantService(),
+			loc.EmailService(),
+			loc.EventBus(),
+			loc.logger,
+		)
+	})
+
+	return loc.billingService
+}
+
+func (loc *Locator) ReportService() *report.Service {
+	loc.init("service.report", func() {
+		loc.reportService = report.New(
+			loc.DB().Pool,
+			loc.MerchantService(),
+			loc.LedgerService(),
+			loc.EvmCollectorService(),
+			loc.EmailService(),
+			loc.logger,
+		)
+	})
+
+	return loc.reportService
+}
+
+func (loc *Locator) WebhookService() *webhooks.Service {
+	loc.init("service.webhooks", func() {
+		loc.webhookService = webhooks.New(
+			loc.config.Oxygen.Webhooks,
+			loc.Store(),
+			loc.MerchantService(),
+			loc.PaymentService(),
+			loc.ProcessingService(),
+			loc.EmailService(),
+			loc.logger,
+		)
+	})
+
+	return loc.webhookService
+}
+
 func (loc *Locator) EmailService() *email.Service {
 	loc.init("service.email", func() {
 		loc.emailService = email.New(loc.DB().Pool, loc.logger)
@@ -304,6 +375,14 @@ func (loc *Locator) EmailService() *email.Service {
 	return loc.emailService
 }
 
+func (loc *Locator) AuditService() *audit.Service {
+	loc.init("service.audit", func() {
+		loc.auditService = audit.New(loc.Repository(), loc.MerchantService(), loc.EmailService(), loc.logger)
+	})
+
+	return loc.auditService
+}
+
 func (loc *Locator) ContactService() *contact.Service {
 	loc.init("service.contact", func() {
 		loc.contactService = contact.New(loc.DB().Pool, loc.logger)
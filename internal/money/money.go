@@ -9,6 +9,7 @@ import (
 
 	"github.com/cryptolink/cryptolink/internal/util"
 	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
 )
 
 // FIAT ------------------
@@ -394,6 +395,18 @@ func (m Money) MultiplyInt64(by int64) (Money, error) {
 	return NewFromBigInt(m.moneyType, m.ticker, bigInt, m.decimals)
 }
 
+// Percent returns percent of the amount rounded down to base units. Unlike
+// MultiplyFloat64 the result is exact.
+func (m Money) Percent(percent decimal.Decimal) (Money, error) {
+	if percent.IsNegative() {
+		return Money{}, errors.New("percent should not be negative")
+	}
+
+	amount := decimal.NewFromBigInt(m.val(), 0).Mul(percent).Shift(-2).Floor()
+
+	return NewFromBigInt(m.moneyType, m.ticker, amount.BigInt(), m.decimals)
+}
+
 func (m Money) Equals(b Money) bool {
 	return m.CompatibleTo(b) && m.val().Cmp(b.val()) == 0
 }
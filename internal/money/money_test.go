@@ -5,6 +5,7 @@ import (
 	"strconv"
 	"testing"
 
+	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -263,6 +264,58 @@ func TestMoney_MultiplyInt64(t *testing.T) {
 	}
 }
 
+func TestMoney_Percent(t *testing.T) {
+	testcases := []struct {
+		from     Money
+		percent  string
+		expected Money
+		error    bool
+	}{
+		{
+			from:     mustCreateCrypto("1000", 2),
+			percent:  "0",
+			expected: mustCreateCrypto("0", 2),
+		},
+		{
+			from:     mustCreateCrypto("1000", 2),
+			percent:  "1.5",
+			expected: mustCreateCrypto("15", 2),
+		},
+		{
+			// rounded down to base units
+			from:     mustCreateCrypto("999", 2),
+			percent:  "1",
+			expected: mustCreateCrypto("9", 2),
+		},
+		{
+			// float64 can't represent 0.1 exactly
+			from:     mustCreateCrypto("1_000_000_000_000_000_000_000", 18),
+			percent:  "0.1",
+			expected: mustCreateCrypto("1_000_000_000_000_000_000", 18),
+		},
+		{
+			from:    mustCreateCrypto("1000", 2),
+			percent: "-1",
+			error:   true,
+		},
+	}
+
+	for _, tc := range testcases {
+		name := fmt.Sprintf("%s/%s/%s", tc.from.Ticker(), tc.from.String(), tc.percent)
+
+		t.Run(name, func(t *testing.T) {
+			actual, err := tc.from.Percent(decimal.RequireFromString(tc.percent))
+
+			if tc.error {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.Equal(t, tc.expected.StringRaw(), actual.StringRaw())
+		})
+	}
+}
+
 func TestMoney_AddSub(t *testing.T) {
 	for i, tc := range []struct {
 		a, b, sum, sub Money
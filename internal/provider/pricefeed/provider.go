@@ -27,8 +27,22 @@ import (
 type ExchangeRate struct {
 	Value     string  // string float, e.g. "1823.45"
 	Timestamp float64 // unix millis
+	Source    Source  // where the quote came from; persisted with rate snapshots
 }
 
+// Source identifies how a quote was obtained. Stored verbatim in the
+// rate_snapshots audit trail, so values must stay stable.
+type Source string
+
+const (
+	SourceBinance     Source = "binance"
+	SourceCoinGecko   Source = "coingecko"
+	SourceComposition Source = "stablecoin_composition"
+	SourceIdentity    Source = "identity"
+	SourceFixedFiat   Source = "fixed_fiat"
+	SourcePegFallback Source = "peg_fallback"
+)
+
 // Config for the price feed provider.
 type Config struct {
 	BinanceBaseURL   string `yaml:"binance_base_url" env:"PRICEFEED_BINANCE_URL" env-default:"https://api.binance.com" env-description:"Binance API base URL"`
@@ -149,7 +163,7 @@ func (p *Provider) GetExchangeRate(ctx context.Context, desired, selected string
 	// and in cross-currency payment matching. A peg fallback is applied at the
 	// end only if every market source is unreachable.
 	if isStablecoin(selected) && stableBase(selected) == stableBase(desired) {
-		rate := ExchangeRate{Value: "1.0", Timestamp: float64(time.Now().UnixMilli())}
+		rate := ExchangeRate{Value: "1.0", Timestamp: float64(time.Now().UnixMilli()), Source: SourceIdentity}
 		p.cache.set(cacheKey, rate)
 		return rate, nil
 	}
@@ -170,7 +184,7 @@ func (p *Provider) GetExchangeRate(ctx context.Context, desired, selected string
 	// Handle fiat-to-fiat (USD->EUR etc.) - use fixed rates for now
 	// CryptoLink primarily uses USD, so this is rarely hit
 	if isFiat(selected) && isFiat(desired) {
-		rate := ExchangeRate{Value: "1.0", Timestamp: float64(time.Now().UnixMilli())}
+		rate := ExchangeRate{Value: "1.0", Timestamp: float64(time.Now().UnixMilli()), Source: SourceFixedFiat}
 		p.cache.set(cacheKey, rate)
 		return rate, nil
 	}
@@ -238,7 +252,7 @@ func (p *Provider) GetExchangeRate(ctx context.Context, desired, selected string
 	// De-peg detection above still applies whenever any source responds, so this
 	// only degrades to 1.0 during a full pricing outage.
 	if isStablecoin(selected) && (desired == "USD" || desired == "USDT" || desired == "USDC") {
-		rate := ExchangeRate{Value: "1.0", Timestamp: float64(time.Now().UnixMilli())}
+		rate := ExchangeRate{Value: "1.0", Timestamp: float64(time.Now().UnixMilli()), Source: SourcePegFallback}
 		p.cache.set(cacheKey, rate)
 		return rate, nil
 	}
@@ -284,6 +298,7 @@ func (p *Provider) composeStablecoinFiatRate(ctx context.Context, selected, desi
 	return ExchangeRate{
 		Value:     strconv.FormatFloat(a*b, 'f', -1, 64),
 		Timestamp: float64(time.Now().UnixMilli()),
+		Source:    SourceComposition,
 	}, true
 }
 
@@ -342,6 +357,7 @@ func (p *Provider) getBinanceRate(ctx context.Context, selected, desired string)
 	return ExchangeRate{
 		Value:     value,
 		Timestamp: float64(time.Now().UnixMilli()),
+		Source:    SourceBinance,
 	}, nil
 }
 
@@ -391,6 +407,7 @@ func (p *Provider) getCoinGeckoRate(ctx context.Context, selected, desired strin
 	return ExchangeRate{
 		Value:     strconv.FormatFloat(price, 'f', -1, 64),
 		Timestamp: float64(time.Now().UnixMilli()),
+		Source:    SourceCoinGecko,
 	}, nil
 }
 
@@ -1,6 +1,11 @@
 package pricefeed
 
-import "testing"
+import (
+	"context"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
 
 func TestStableBase(t *testing.T) {
 	cases := map[string]string{
@@ -46,3 +51,26 @@ func TestIsNonUSDFiat(t *testing.T) {
 		}
 	}
 }
+
+func TestGetExchangeRateSource(t *testing.T) {
+	logger := zerolog.Nop()
+	p := New(Config{CacheTTLSeconds: 0}, &logger)
+
+	cases := []struct {
+		desired, selected string
+		want              Source
+	}{
+		{"USDT", "ETH_USDT", SourceIdentity},
+		{"USDC", "USDC", SourceIdentity},
+		{"EUR", "USD", SourceFixedFiat},
+	}
+	for _, c := range cases {
+		rate, err := p.GetExchangeRate(context.Background(), c.desired, c.selected)
+		if err != nil {
+			t.Fatalf("GetExchangeRate(%q, %q): %v", c.desired, c.selected, err)
+		}
+		if rate.Source != c.want {
+			t.Errorf("GetExchangeRate(%q, %q).Source = %q, want %q", c.desired, c.selected, rate.Source, c.want)
+		}
+	}
+}
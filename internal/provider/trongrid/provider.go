@@ -259,6 +259,8 @@ type TransactionReceipt struct {
 	Confirmations int64
 	IsConfirmed   bool
 	Success       bool
+
+	RequiredConfirmations int64
 }
 
 func (p *Provider) GetTransactionReceipt(
@@ -353,6 +355,8 @@ func (p *Provider) GetTransactionReceipt(
 		Confirmations: confirmations,
 		IsConfirmed:   confirmations >= confirmationBlocks,
 		Success:       success,
+
+		RequiredConfirmations: confirmationBlocks,
 	}, nil
 }
 
@@ -19,6 +19,12 @@ type Handler struct {
 	processing   ProcessingService
 	transactions *transaction.Service
 	watcher      *watcher.Service
+	billing      BillingService
+	collectors   EvmCollectorService
+	ledger       LedgerService
+	reports      ReportService
+	webhooks     WebhookService
+	eventBus     EventBus
 	tableLogger  *log.JobLogger
 }
 
@@ -38,11 +44,45 @@ type ProcessingService interface {
 	ResolveUnmatchedCollectorPayment(ctx context.Context, p processing.UnmatchedCollectorPayment) error
 }
 
+type BillingService interface {
+	ProcessDueSubscriptions(ctx context.Context) error
+}
+
+type EvmCollectorService interface {
+	ReverifyCollectors(ctx context.Context) error
+	ProcessPendingDeployments(ctx context.Context) error
+	IndexWithdrawals(ctx context.Context) error
+}
+
+type LedgerService interface {
+	PostCollectorWithdrawals(ctx context.Context) error
+}
+
+type ReportService interface {
+	SendDueReports(ctx context.Context) error
+}
+
+type WebhookService interface {
+	DeliverDue(ctx context.Context) error
+	PurgeExpired(ctx context.Context) error
+}
+
+type EventBus interface {
+	ReportLag(ctx context.Context) error
+	PurgeConsumed(ctx context.Context) error
+}
+
 func New(
 	payments *payment.Service,
 	processingService ProcessingService,
 	transactions *transaction.Service,
 	watcherService *watcher.Service,
+	billingService BillingService,
+	evmCollectors EvmCollectorService,
+	ledgerService LedgerService,
+	reportService ReportService,
+	webhookService WebhookService,
+	eventBus EventBus,
 	jobLogger *log.JobLogger,
 ) *Handler {
 	return &Handler{
@@ -50,6 +90,12 @@ func New(
 		processing:   processingService,
 		transactions: transactions,
 		watcher:      watcherService,
+		billing:      billingService,
+		collectors:   evmCollectors,
+		ledger:       ledgerService,
+		reports:      reportService,
+		webhooks:     webhookService,
+		eventBus:     eventBus,
 		tableLogger:  jobLogger,
 	}
 }
@@ -141,3 +187,100 @@ func (h *Handler) WatchPendingAddresses(ctx context.Context) error {
 		return h.processing.ProcessInboundTransaction(ctx, d.PendingTx, d.Wallet, input)
 	})
 }
+
+// ProcessCustomerSubscriptions issues renewal invoices, sends payment reminders
+// and moves merchants' customer subscriptions to past_due / cancelled.
+func (h *Handler) ProcessCustomerSubscriptions(ctx context.Context) error {
+	if h.billing == nil {
+		return nil
+	}
+
+	return h.billing.ProcessDueSubscriptions(ctx)
+}
+
+// ReverifyEvmCollectors re-checks on-chain ownership of active EVM collectors
+// and deactivates those that no longer pass verification.
+func (h *Handler) ReverifyEvmCollectors(ctx context.Context) error {
+	if h.collectors == nil {
+		return nil
+	}
+
+	return h.collectors.ReverifyCollectors(ctx)
+}
+
+// ProcessEvmCollectorDeployments registers collectors whose server-assisted
+// deployment got confirmed on-chain.
+func (h *Handler) ProcessEvmCollectorDeployments(ctx context.Context) error {
+	if h.collectors == nil {
+		return nil
+	}
+
+	return h.collectors.ProcessPendingDeployments(ctx)
+}
+
+// IndexEvmCollectorWithdrawals indexes owner withdrawals from collector
+// contracts into the settlement table.
+func (h *Handler) IndexEvmCollectorWithdrawals(ctx context.Context) error {
+	if h.collectors == nil {
+		return nil
+	}
+
+	return h.collectors.IndexWithdrawals(ctx)
+}
+
+// PostLedgerCollectorWithdrawals books indexed collector withdrawals
+// into merchants' ledgers.
+func (h *Handler) PostLedgerCollectorWithdrawals(ctx context.Context) error {
+	if h.ledger == nil {
+		return nil
+	}
+
+	return h.ledger.PostCollectorWithdrawals(ctx)
+}
+
+// SendSettlementReports emails daily and weekly settlement reports to
+// merchants that opted in.
+func (h *Handler) SendSettlementReports(ctx context.Context) error {
+	if h.reports == nil {
+		return nil
+	}
+
+	return h.reports.SendDueReports(ctx)
+}
+
+// DeliverWebhooks sends due merchant webhooks from the outbox and retries
+// failed ones.
+func (h *Handler) DeliverWebhooks(ctx context.Context) error {
+	if h.webhooks == nil {
+		return nil
+	}
+
+	return h.webhooks.DeliverDue(ctx)
+}
+
+// PurgeWebhookDeliveries deletes webhook delivery log past retention period.
+func (h *Handler) PurgeWebhookDeliveries(ctx context.Context) error {
+	if h.webhooks == nil {
+		return nil
+	}
+
+	return h.webhooks.PurgeExpired(ctx)
+}
+
+// ReportEventBusLag logs lag of event bus consumers.
+func (h *Handler) ReportEventBusLag(ctx context.Context) error {
+	if h.eventBus == nil {
+		return nil
+	}
+
+	return h.eventBus.ReportLag(ctx)
+}
+
+// PurgeEventBusMessages deletes processed event bus messages past retention period.
+func (h *Handler) PurgeEventBusMessages(ctx context.Context) error {
+	if h.eventBus == nil {
+		return nil
+	}
+
+	return h.eventBus.PurgeConsumed(ctx)
+}
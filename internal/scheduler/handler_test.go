@@ -43,6 +43,12 @@ func setup(t *testing.T) *TestContext {
 			processingMock,
 			tc.Services.Transaction,
 			nil, // watcher (not needed in tests)
+			nil, // billing (not needed in tests)
+			nil, // evm collectors (not needed in tests)
+			nil, // ledger (not needed in tests)
+			nil, // reports (not needed in tests)
+			nil, // webhooks (not needed in tests)
+			nil, // event bus (not needed in tests)
 			tc.Services.JobLogger,
 		),
 	}
@@ -0,0 +1,202 @@
+package merchantapi
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/cryptolink/cryptolink/internal/server/http/common"
+	"github.com/cryptolink/cryptolink/internal/server/http/middleware"
+	"github.com/cryptolink/cryptolink/internal/service/audit"
+	"github.com/cryptolink/cryptolink/internal/util"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	auditLogLimitDefault = 50
+	auditLogLimitMax     = 200
+)
+
+type auditLogEntryResponse struct {
+	ID          string       `json:"id"`
+	MerchantID  *string      `json:"merchantId"`
+	Action      string       `json:"action"`
+	Description string       `json:"description"`
+	ActorType   string       `json:"actorType"`
+	ActorEmail  string       `json:"actorEmail"`
+	IP          string       `json:"ip"`
+	UserAgent   string       `json:"userAgent"`
+	ResourceID  string       `json:"resourceId"`
+	Before      audit.Values `json:"before"`
+	After       audit.Values `json:"after"`
+	CreatedAt   string       `json:"createdAt"`
+}
+
+type auditLogPagination struct {
+	Cursor  string                   `json:"cursor"`
+	Limit   int64                    `json:"limit"`
+	Results []*auditLogEntryResponse `json:"results"`
+}
+
+type securityAlertsBody struct {
+	Enabled bool `json:"enabled"`
+}
+
+// ListAuditLog lists merchant's configuration changes, newest first.
+// Filters: action, actorType.
+func (h *Handler) ListAuditLog(c echo.Context) error {
+	params, valid := auditLogListParams(c)
+	if !valid {
+		return nil
+	}
+
+	mt := middleware.ResolveMerchant(c)
+	params.MerchantID = mt.ID
+
+	return h.listAuditLog(c, params)
+}
+
+// AdminListAuditLog lists configuration changes of all merchants and super
+// admins, newest first. Filters: merchantId, userId, action, actorType.
+func (h *Handler) AdminListAuditLog(c echo.Context) error {
+	params, valid := auditLogListParams(c)
+	if !valid {
+		return nil
+	}
+
+	var err error
+	if raw := c.QueryParam("merchantId"); raw != "" {
+		params.MerchantID, err = strconv.ParseInt(raw, 10, 64)
+		if err != nil || params.MerchantID < 1 {
+			return common.ValidationErrorItemResponse(c, "merchantId", "invalid merchant id")
+		}
+	}
+
+	if raw := c.QueryParam("userId"); raw != "" {
+		params.ActorUserID, err = strconv.ParseInt(raw, 10, 64)
+		if err != nil || params.ActorUserID < 1 {
+			return common.ValidationErrorItemResponse(c, "userId", "invalid user id")
+		}
+	}
+
+	return h.listAuditLog(c, params)
+}
+
+// GetSecurityAlerts returns whether owners are emailed about high-risk changes.
+func (h *Handler) GetSecurityAlerts(c echo.Context) error {
+	mt := middleware.ResolveMerchant(c)
+
+	return c.JSON(http.StatusOK, &securityAlertsBody{Enabled: mt.Settings().SecurityAlertsEnabled()})
+}
+
+// UpdateSecurityAlerts turns emails about high-risk changes on or off.
+func (h *Handler) UpdateSecurityAlerts(c echo.Context) error {
+	var req securityAlertsBody
+	if err := c.Bind(&req); err != nil {
+		return common.ValidationErrorResponse(c, "invalid request body")
+	}
+
+	mt := middleware.ResolveMerchant(c)
+	before := mt.Settings().SecurityAlertsEnabled()
+
+	if err := h.merchants.UpdateSecurityAlerts(c.Request().Context(), mt, req.Enabled); err != nil {
+		h.logger.Error().Err(err).Int64("merchant_id", mt.ID).Msg("failed to update security alerts")
+		return common.ErrorResponse(c, "internal_error")
+	}
+
+	h.recordChange(c, audit.Change{
+		MerchantID: mt.ID,
+		Action:     audit.ActionSecurityAlertsUpdate,
+		Before:     audit.Values{"enabled": before},
+		After:      audit.Values{"enabled": req.Enabled},
+	})
+
+	return c.JSON(http.StatusOK, &securityAlertsBody{Enabled: req.Enabled})
+}
+
+func (h *Handler) listAuditLog(c echo.Context, params audit.ListParams) error {
+	entries, err := h.audit.List(c.Request().Context(), params)
+	if err != nil {
+		h.logger.Error().Err(err).Int64("merchant_id", params.MerchantID).Msg("unable to list audit log")
+		return common.ErrorResponse(c, "internal_error")
+	}
+
+	var cursor string
+	if len(entries) == params.Limit {
+		cursor = strconv.FormatInt(entries[len(entries)-1].ID, 10)
+	}
+
+	return c.JSON(http.StatusOK, &auditLogPagination{
+		Cursor:  cursor,
+		Limit:   int64(params.Limit),
+		Results: util.MapSlice(entries, auditLogEntryToResponse),
+	})
+}
+
+// recordChange appends the change made by current request to the audit log.
+func (h *Handler) recordChange(c echo.Context, change audit.Change) {
+	h.audit.Record(c.Request().Context(), middleware.ResolveAuditActor(c), change)
+}
+
+// auditLogListParams parses common filters. Writes validation error response
+// and returns false for invalid ones.
+func auditLogListParams(c echo.Context) (audit.ListParams, bool) {
+	params := audit.ListParams{
+		Action:    audit.Action(c.QueryParam("action")),
+		ActorType: audit.ActorType(c.QueryParam("actorType")),
+		Limit:     auditLogLimitDefault,
+	}
+
+	if params.Action != "" && !params.Action.Valid() {
+		_ = common.ValidationErrorItemResponse(c, "action", "unknown action")
+		return params, false
+	}
+
+	if params.ActorType != "" && !params.ActorType.Valid() {
+		_ = common.ValidationErrorItemResponse(c, "actorType", "unknown actor type")
+		return params, false
+	}
+
+	if raw := c.QueryParam(common.ParamQueryLimit); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > auditLogLimitMax {
+			_ = common.ValidationErrorItemResponse(c, common.ParamQueryLimit, "limit should be between 1 and %d", auditLogLimitMax)
+			return params, false
+		}
+		params.Limit = limit
+	}
+
+	if raw := c.QueryParam(common.ParamQueryCursor); raw != "" {
+		beforeID, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || beforeID < 1 {
+			_ = common.ValidationErrorItemResponse(c, common.ParamQueryCursor, "invalid cursor")
+			return params, false
+		}
+		params.BeforeID = beforeID
+	}
+
+	return params, true
+}
+
+func auditLogEntryToResponse(e *audit.Entry) *auditLogEntryResponse {
+	var merchantID *string
+	if e.MerchantUUID != nil {
+		id := e.MerchantUUID.String()
+		merchantID = &id
+	}
+
+	return &auditLogEntryResponse{
+		ID:          e.UUID.String(),
+		MerchantID:  merchantID,
+		Action:      string(e.Action),
+		Description: e.Action.Description(),
+		ActorType:   string(e.Actor.Type),
+		ActorEmail:  e.Actor.Email,
+		IP:          e.Actor.IP,
+		UserAgent:   e.Actor.UserAgent,
+		ResourceID:  e.ResourceID,
+		Before:      e.Before,
+		After:       e.After,
+		CreatedAt:   e.CreatedAt.Format(time.RFC3339),
+	}
+}
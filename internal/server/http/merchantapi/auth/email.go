@@ -3,8 +3,11 @@ package auth
 import (
 	"context"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/labstack/echo/v4"
+	mw "github.com/labstack/echo/v4/middleware"
 	"github.com/cryptolink/cryptolink/internal/server/http/common"
 	"github.com/cryptolink/cryptolink/internal/server/http/middleware"
 	"github.com/cryptolink/cryptolink/internal/service/user"
@@ -25,6 +28,10 @@ func (h *Handler) PostLogin(c echo.Context) error {
 		return c.NoContent(http.StatusNoContent)
 	}
 
+	if !h.allowLogin(c, req.Email.String()) {
+		return tooManyAttemptsResponse()
+	}
+
 	person, err := h.users.GetByEmailWithPasswordCheck(ctx, req.Email.String(), req.Password)
 	switch {
 	case errors.Is(err, user.ErrNotFound), errors.Is(err, user.ErrWrongPassword):
@@ -33,14 +40,39 @@ func (h *Handler) PostLogin(c echo.Context) error {
 		return errors.Wrap(err, "unable to resolve user")
 	}
 
-	setSession := map[string]any{middleware.UserIDContextKey: person.ID}
-	if err := h.persistSession(c, "email", setSession); err != nil {
+	twoFactorRequired, err := h.startLogin(c, "email", person.ID, user.AuthMethodPassword)
+	if err != nil {
 		return common.ErrorResponse(c, "internal error")
 	}
 
+	// the second step is PostLoginTwoFactor
+	if twoFactorRequired {
+		return c.JSON(http.StatusAccepted, &twoFactorLoginResponse{TwoFactorRequired: true})
+	}
+
 	return c.NoContent(http.StatusNoContent)
 }
 
+// allowLogin limits password attempts per email regardless of the client IP,
+// see newLoginLimiter.
+func (h *Handler) allowLogin(c echo.Context, email string) bool {
+	if allowed, _ := h.logins.Allow(strings.ToLower(email)); !allowed {
+		h.logger.Warn().Str("ip", c.RealIP()).Msg("login attempts limit of email reached")
+		return false
+	}
+
+	return true
+}
+
+// newLoginLimiter at most 10 password attempts per email and then one per minute.
+func newLoginLimiter() *mw.RateLimiterMemoryStore {
+	return mw.NewRateLimiterMemoryStoreWithConfig(mw.RateLimiterMemoryStoreConfig{
+		Rate:      1.0 / 60,
+		Burst:     10,
+		ExpiresIn: time.Hour,
+	})
+}
+
 func (h *Handler) PostRegister(c echo.Context) error {
 	ctx := c.Request().Context()
 
@@ -87,8 +119,7 @@ func (h *Handler) PostRegister(c echo.Context) error {
 	}
 
 	// Auto-login after registration
-	setSession := map[string]any{middleware.UserIDContextKey: person.ID}
-	if err := h.persistSession(c, "email", setSession); err != nil {
+	if err := h.login(c, "email", person.ID, user.AuthMethodPassword, false); err != nil {
 		return common.ErrorResponse(c, "internal error")
 	}
 
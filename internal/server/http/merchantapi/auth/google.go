@@ -2,22 +2,38 @@ package auth
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/labstack/echo/v4"
+	"github.com/cryptolink/cryptolink/internal/auth"
 	"github.com/cryptolink/cryptolink/internal/server/http/common"
 	"github.com/cryptolink/cryptolink/internal/server/http/middleware"
 	"github.com/cryptolink/cryptolink/internal/service/user"
 	"github.com/pkg/errors"
 )
 
+// googleReauthSessionKey marks OAuth flow started by a logged-in user to
+// re-authenticate, see PostReauth.
+const googleReauthSessionKey = "google_reauth"
+
+// twoFactorLoginURL dashboard page of the second login step.
+const twoFactorLoginURL = "/merchants/login?step=2fa"
+
+// GetRedirect starts Google OAuth. Logged-in users can pass "?reauth=true"
+// to re-authenticate before sensitive operations.
 func (h *Handler) GetRedirect(c echo.Context) error {
+	reauth := false
 	if person := middleware.ResolveUser(c); person != nil {
-		return c.Redirect(http.StatusTemporaryRedirect, h.googleAuth.GetAuthenticatedRedirectURL())
+		if c.QueryParam("reauth") != "true" {
+			return c.Redirect(http.StatusTemporaryRedirect, h.googleAuth.GetAuthenticatedRedirectURL())
+		}
+
+		reauth = true
 	}
 
 	redirect, state := h.googleAuth.RedirectURLWithState()
 
-	setSession := map[string]any{middleware.SessionStateKey: state}
+	setSession := map[string]any{middleware.SessionStateKey: state, googleReauthSessionKey: reauth}
 	if err := h.persistSession(c, "google", setSession); err != nil {
 		return common.ErrorResponse(c, "internal error")
 	}
@@ -28,7 +44,10 @@ func (h *Handler) GetRedirect(c echo.Context) error {
 func (h *Handler) GetCallback(c echo.Context) error {
 	ctx := c.Request().Context()
 
-	if person := middleware.ResolveUser(c); person != nil {
+	person := middleware.ResolveUser(c)
+	reauth, _ := middleware.ResolveSession(c).Values[googleReauthSessionKey].(bool)
+
+	if person != nil && !reauth {
 		return c.Redirect(http.StatusTemporaryRedirect, h.googleAuth.GetAuthenticatedRedirectURL())
 	}
 
@@ -49,8 +68,12 @@ func (h *Handler) GetCallback(c echo.Context) error {
 		return c.JSON(http.StatusInternalServerError, msg)
 	}
 
+	if person != nil {
+		return h.reauthWithGoogle(c, person, googleUser)
+	}
+
 	// check that user exists
-	person, err := h.users.ResolveWithGoogle(ctx, googleUser)
+	person, err = h.users.ResolveWithGoogle(ctx, googleUser)
 
 	switch {
 	case errors.Is(err, user.ErrRestricted):
@@ -59,7 +82,27 @@ func (h *Handler) GetCallback(c echo.Context) error {
 		return errors.Wrap(err, "unable to resolve google user")
 	}
 
-	setSession := map[string]any{middleware.UserIDContextKey: person.ID}
+	twoFactorRequired, err := h.startLogin(c, "google", person.ID, user.AuthMethodGoogle)
+	if err != nil {
+		return common.ErrorResponse(c, "internal error")
+	}
+
+	if twoFactorRequired {
+		return c.Redirect(http.StatusTemporaryRedirect, twoFactorLoginURL)
+	}
+
+	return c.Redirect(http.StatusTemporaryRedirect, h.googleAuth.GetAuthenticatedRedirectURL())
+}
+
+func (h *Handler) reauthWithGoogle(c echo.Context, person *user.User, googleUser *auth.GoogleUser) error {
+	if googleUser.Email != person.Email {
+		return common.ValidationErrorResponse(c, "Google account does not match current user")
+	}
+
+	setSession := map[string]any{
+		middleware.ReauthAtSessionKey: time.Now().Unix(),
+		googleReauthSessionKey:        false,
+	}
 	if err := h.persistSession(c, "google", setSession); err != nil {
 		return common.ErrorResponse(c, "internal error")
 	}
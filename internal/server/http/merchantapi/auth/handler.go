@@ -5,6 +5,7 @@ import (
 	"net/http"
 
 	"github.com/labstack/echo/v4"
+	mw "github.com/labstack/echo/v4/middleware"
 	"github.com/cryptolink/cryptolink/internal/auth"
 	"github.com/cryptolink/cryptolink/internal/server/http/common"
 	"github.com/cryptolink/cryptolink/internal/server/http/middleware"
@@ -22,6 +23,15 @@ type Handler struct {
 	emailService     *email.Service
 	enabledProviders []auth.ProviderType
 	logger           *zerolog.Logger
+
+	// passwordResets limits reset requests per email, see newPasswordResetLimiter.
+	passwordResets *mw.RateLimiterMemoryStore
+
+	// logins limits password attempts per email, see newLoginLimiter.
+	logins *mw.RateLimiterMemoryStore
+
+	// secondFactors limits 2FA code attempts per user, see newSecondFactorLimiter.
+	secondFactors *mw.RateLimiterMemoryStore
 }
 
 func NewHandler(
@@ -39,6 +49,9 @@ func NewHandler(
 		emailService:     emailService,
 		enabledProviders: enabledProviders,
 		logger:           &log,
+		passwordResets:   newPasswordResetLimiter(),
+		logins:           newLoginLimiter(),
+		secondFactors:    newSecondFactorLimiter(),
 	}
 }
 
@@ -67,24 +80,25 @@ func (h *Handler) ListAvailableProviders(c echo.Context) error {
 func (h *Handler) GetMe(c echo.Context) error {
 	person := middleware.ResolveUser(c)
 
-	return c.JSON(http.StatusOK, &model.User{
-		UUID:             person.UUID.String(),
-		Email:            person.Email,
-		Name:             person.Name,
-		ProfileImageURL:  person.ProfileImageURL,
-		IsSuperAdmin:     person.IsSuperAdmin,
-		CompanyName:      person.CompanyName,
-		Address:          person.Address,
-		Website:          person.Website,
-		Phone:            person.Phone,
-		EmailVerified:    person.EmailVerified,
-		MarketingConsent: person.MarketingConsent,
-	})
+	res, err := h.userResponse(c.Request().Context(), person)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, res)
 }
 
 func (h *Handler) PostLogout(c echo.Context) error {
+	if record := middleware.ResolveUserSession(c); record != nil {
+		if err := h.users.RevokeSession(c.Request().Context(), record.UserID, record.UUID); err != nil {
+			h.logger.Error().Err(err).Msg("unable to revoke user session")
+		}
+	}
+
 	userSession := middleware.ResolveSession(c)
 	userSession.Values["user_id"] = nil
+	userSession.Values[middleware.SessionIDSessionKey] = nil
+	userSession.Values[middleware.ReauthAtSessionKey] = nil
 	if err := userSession.Save(c.Request(), c.Response()); err != nil {
 		h.logger.Error().Err(err).Msg("unable to persist user session")
 	}
@@ -121,19 +135,12 @@ func (h *Handler) UpdateProfile(c echo.Context) error {
 		return common.ErrorResponse(c, err.Error())
 	}
 
-	return c.JSON(http.StatusOK, &model.User{
-		UUID:             updated.UUID.String(),
-		Email:            updated.Email,
-		Name:             updated.Name,
-		ProfileImageURL:  updated.ProfileImageURL,
-		IsSuperAdmin:     updated.IsSuperAdmin,
-		CompanyName:      updated.CompanyName,
-		Address:          updated.Address,
-		Website:          updated.Website,
-		Phone:            updated.Phone,
-		EmailVerified:    updated.EmailVerified,
-		MarketingConsent: updated.MarketingConsent,
-	})
+	res, err := h.userResponse(c.Request().Context(), updated)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, res)
 }
 
 // UpdatePassword handles PUT /auth/password
@@ -161,8 +168,13 @@ func (h *Handler) UpdatePassword(c echo.Context) error {
 		})
 	}
 
-	// Update password
-	_, err = h.users.UpdatePassword(c.Request().Context(), person.ID, req.NewPassword)
+	// Update password, other sessions are revoked
+	var keepSessionID int64
+	if record := middleware.ResolveUserSession(c); record != nil {
+		keepSessionID = record.ID
+	}
+
+	_, err = h.users.UpdatePassword(c.Request().Context(), person.ID, req.NewPassword, keepSessionID)
 	if err != nil {
 		h.logger.Error().Err(err).Msg("failed to update password")
 		return common.ErrorResponse(c, err.Error())
@@ -213,6 +225,29 @@ func (h *Handler) ResendVerification(c echo.Context) error {
 	return c.JSON(http.StatusOK, map[string]string{"message": "Verification email sent"})
 }
 
+func (h *Handler) userResponse(ctx context.Context, person *user.User) (*model.User, error) {
+	twoFactorEnabled, err := h.users.IsTwoFactorEnabled(ctx, person.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.User{
+		UUID:              person.UUID.String(),
+		Email:             person.Email,
+		Name:              person.Name,
+		ProfileImageURL:   person.ProfileImageURL,
+		IsSuperAdmin:      person.IsSuperAdmin,
+		CompanyName:       person.CompanyName,
+		Address:           person.Address,
+		Website:           person.Website,
+		Phone:             person.Phone,
+		EmailVerified:     person.EmailVerified,
+		MarketingConsent:  person.MarketingConsent,
+		TwoFactorEnabled:  twoFactorEnabled,
+		TwoFactorRequired: h.users.IsTwoFactorRequired(ctx),
+	}, nil
+}
+
 func (h *Handler) persistSession(c echo.Context, source string, values map[string]any) error {
 	s := middleware.ResolveSession(c)
 
@@ -0,0 +1,108 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cryptolink/cryptolink/internal/server/http/common"
+	"github.com/cryptolink/cryptolink/internal/service/user"
+	"github.com/labstack/echo/v4"
+	mw "github.com/labstack/echo/v4/middleware"
+	"github.com/pkg/errors"
+)
+
+type passwordResetRequest struct {
+	Email string `json:"email"`
+}
+
+type passwordResetConfirmRequest struct {
+	Token           string `json:"token"`
+	Password        string `json:"password"`
+	RevokeAPITokens bool   `json:"revokeApiTokens"`
+}
+
+// PostPasswordReset handles POST /auth/password-reset. Emails a reset link if
+// the account exists; the response is the same either way.
+func (h *Handler) PostPasswordReset(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	var req passwordResetRequest
+	if err := c.Bind(&req); err != nil {
+		return common.ValidationErrorResponse(c, "invalid request")
+	}
+
+	email := strings.TrimSpace(req.Email)
+	if email == "" {
+		return common.ValidationErrorItemResponse(c, "email", "email is required")
+	}
+
+	// limited for any email, so the response doesn't reveal whether the account exists
+	if allowed, _ := h.passwordResets.Allow(strings.ToLower(email)); !allowed {
+		h.logger.Warn().Str("ip", c.RealIP()).Msg("password reset requests limit of email reached")
+		return echo.NewHTTPError(http.StatusTooManyRequests, "too many password reset requests")
+	}
+
+	if h.emailService == nil {
+		return common.ErrorResponse(c, "email service not available")
+	}
+
+	person, token, err := h.users.RequestPasswordReset(ctx, email, c.RealIP())
+	switch {
+	case errors.Is(err, user.ErrNotFound):
+		// do nothing
+	case errors.Is(err, user.ErrTooManyResetRequests):
+		h.logger.Warn().Str("ip", c.RealIP()).Msg("password reset requests limit reached")
+	case err != nil:
+		h.logger.Error().Err(err).Msg("unable to request password reset")
+	default:
+		go h.emailService.SendPasswordResetEmail(context.Background(), person.Email, person.Name, token, user.PasswordResetTTL)
+	}
+
+	return c.JSON(http.StatusAccepted, map[string]string{
+		"message": "If an account with this email exists, a password reset link has been sent",
+	})
+}
+
+// PostPasswordResetConfirm handles POST /auth/password-reset/confirm. Sets a
+// new password and signs the user out everywhere.
+func (h *Handler) PostPasswordResetConfirm(c echo.Context) error {
+	var req passwordResetConfirmRequest
+	if err := c.Bind(&req); err != nil {
+		return common.ValidationErrorResponse(c, "invalid request")
+	}
+
+	if req.Token == "" {
+		return common.ValidationErrorItemResponse(c, "token", "token is required")
+	}
+
+	if len(req.Password) < 8 {
+		return common.ValidationErrorItemResponse(c, "password", "password should have minimum length of 8")
+	}
+
+	_, err := h.users.ResetPassword(c.Request().Context(), user.ResetPasswordParams{
+		Token:           req.Token,
+		Password:        req.Password,
+		RevokeAPITokens: req.RevokeAPITokens,
+	})
+	switch {
+	case errors.Is(err, user.ErrInvalidResetToken):
+		return common.ValidationErrorItemResponse(c, "token", "Password reset link is invalid or expired")
+	case err != nil:
+		return errors.Wrap(err, "unable to reset password")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// newPasswordResetLimiter limits reset requests per email regardless of the
+// client IP: at most 3 requests and then one per 20 minutes. Unlike the limit
+// of the user service it also covers emails without an account.
+func newPasswordResetLimiter() *mw.RateLimiterMemoryStore {
+	return mw.NewRateLimiterMemoryStoreWithConfig(mw.RateLimiterMemoryStoreConfig{
+		Rate:      1.0 / (20 * 60),
+		Burst:     3,
+		ExpiresIn: time.Hour,
+	})
+}
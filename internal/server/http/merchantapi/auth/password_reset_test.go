@@ -0,0 +1,42 @@
+package auth_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/cryptolink/cryptolink/internal/test"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	authPasswordResetRoute = "/api/dashboard/v1/auth/password-reset"
+)
+
+func TestHandler_PostPasswordReset(t *testing.T) {
+	tc := test.NewIntegrationTest(t)
+
+	request := func(email string) *test.Response {
+		return tc.Client.
+			POST().
+			WithCSRF().
+			Path(authPasswordResetRoute).
+			Body([]byte(fmt.Sprintf(`{"email":%q}`, email))).
+			Do()
+	}
+
+	t.Run("Limits requests per email", func(t *testing.T) {
+		// ACT
+		// Request resets of the same email, in different case
+		for _, email := range []string{"reset@me.com", "Reset@me.com", "RESET@me.com"} {
+			assert.NotEqual(t, http.StatusTooManyRequests, request(email).StatusCode())
+		}
+
+		limited := request("reset@me.com")
+		other := request("other@me.com")
+
+		// ASSERT
+		assert.Equal(t, http.StatusTooManyRequests, limited.StatusCode())
+		assert.NotEqual(t, http.StatusTooManyRequests, other.StatusCode())
+	})
+}
@@ -0,0 +1,161 @@
+package auth
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/cryptolink/cryptolink/internal/server/http/common"
+	"github.com/cryptolink/cryptolink/internal/server/http/middleware"
+	"github.com/cryptolink/cryptolink/internal/service/user"
+	"github.com/cryptolink/cryptolink/internal/util"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+)
+
+type sessionResponse struct {
+	ID         string `json:"id"`
+	AuthMethod string `json:"authMethod"`
+	TwoFactor  bool   `json:"twoFactor"`
+	IP         string `json:"ip"`
+	UserAgent  string `json:"userAgent"`
+	CreatedAt  string `json:"createdAt"`
+	LastSeenAt string `json:"lastSeenAt"`
+	ExpiresAt  string `json:"expiresAt"`
+	// Current whether the request is made with this session.
+	Current bool `json:"current"`
+}
+
+type sessionListResponse struct {
+	Results []*sessionResponse `json:"results"`
+}
+
+type revokedSessionsResponse struct {
+	Revoked int64 `json:"revoked"`
+}
+
+// ListSessions handles GET /auth/sessions
+func (h *Handler) ListSessions(c echo.Context) error {
+	person := middleware.ResolveUser(c)
+
+	return h.listSessions(c, person.ID, middleware.ResolveUserSession(c))
+}
+
+// RevokeSession handles DELETE /auth/sessions/:sessionId
+func (h *Handler) RevokeSession(c echo.Context) error {
+	person := middleware.ResolveUser(c)
+
+	sessionID, err := common.UUID(c, "sessionId")
+	if err != nil {
+		return nil
+	}
+
+	err = h.users.RevokeSession(c.Request().Context(), person.ID, sessionID)
+	switch {
+	case errors.Is(err, user.ErrSessionNotFound):
+		return common.NotFoundResponse(c, "session not found")
+	case err != nil:
+		return errors.Wrap(err, "unable to revoke session")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// RevokeOtherSessions handles DELETE /auth/sessions. Revokes all sessions of
+// the user except the current one.
+func (h *Handler) RevokeOtherSessions(c echo.Context) error {
+	person := middleware.ResolveUser(c)
+
+	var keepSessionID int64
+	if record := middleware.ResolveUserSession(c); record != nil {
+		keepSessionID = record.ID
+	}
+
+	revoked, err := h.users.RevokeSessions(c.Request().Context(), person.ID, keepSessionID)
+	if err != nil {
+		return errors.Wrap(err, "unable to revoke sessions")
+	}
+
+	return c.JSON(http.StatusOK, &revokedSessionsResponse{Revoked: revoked})
+}
+
+// AdminListUserSessions handles GET /admin/users/:userId/sessions
+func (h *Handler) AdminListUserSessions(c echo.Context) error {
+	userID, ok := h.adminUserID(c)
+	if !ok {
+		return nil
+	}
+
+	return h.listSessions(c, userID, middleware.ResolveUserSession(c))
+}
+
+// AdminRevokeUserSessions handles DELETE /admin/users/:userId/sessions,
+// logs the user out everywhere.
+func (h *Handler) AdminRevokeUserSessions(c echo.Context) error {
+	userID, ok := h.adminUserID(c)
+	if !ok {
+		return nil
+	}
+
+	revoked, err := h.users.RevokeSessions(c.Request().Context(), userID, 0)
+	if err != nil {
+		return errors.Wrap(err, "unable to revoke sessions")
+	}
+
+	h.logger.Info().
+		Int64("user_id", userID).
+		Int64("admin_id", middleware.ResolveUser(c).ID).
+		Msg("user was logged out by super admin")
+
+	return c.JSON(http.StatusOK, &revokedSessionsResponse{Revoked: revoked})
+}
+
+func (h *Handler) listSessions(c echo.Context, userID int64, current *user.Session) error {
+	sessions, err := h.users.ListSessions(c.Request().Context(), userID)
+	if err != nil {
+		return errors.Wrap(err, "unable to list sessions")
+	}
+
+	results := util.MapSlice(sessions, func(s *user.Session) *sessionResponse {
+		return sessionToResponse(s, current != nil && current.ID == s.ID)
+	})
+
+	return c.JSON(http.StatusOK, &sessionListResponse{Results: results})
+}
+
+// adminUserID resolves :userId of existing user. Writes error response and
+// returns false otherwise.
+func (h *Handler) adminUserID(c echo.Context) (int64, bool) {
+	userID, err := strconv.ParseInt(c.Param("userId"), 10, 64)
+	if err != nil {
+		_ = common.ValidationErrorResponse(c, "invalid user ID")
+		return 0, false
+	}
+
+	_, err = h.users.GetByID(c.Request().Context(), userID)
+	switch {
+	case errors.Is(err, user.ErrNotFound):
+		_ = common.NotFoundResponse(c, "user not found")
+		return 0, false
+	case err != nil:
+		h.logger.Error().Err(err).Int64("user_id", userID).Msg("unable to get user")
+		_ = common.ErrorResponse(c, "internal error")
+		return 0, false
+	}
+
+	return userID, true
+}
+
+func sessionToResponse(s *user.Session, current bool) *sessionResponse {
+	return &sessionResponse{
+		ID:         s.UUID.String(),
+		AuthMethod: string(s.AuthMethod),
+		TwoFactor:  s.TwoFactor,
+		IP:         s.IP,
+		UserAgent:  s.UserAgent,
+		CreatedAt:  s.CreatedAt.Format(time.RFC3339),
+		LastSeenAt: s.LastSeenAt.Format(time.RFC3339),
+		ExpiresAt:  s.ExpiresAt.Format(time.RFC3339),
+		Current:    current,
+	}
+}
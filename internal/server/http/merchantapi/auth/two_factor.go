@@ -0,0 +1,377 @@
+package auth
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/cryptolink/cryptolink/internal/server/http/common"
+	"github.com/cryptolink/cryptolink/internal/server/http/middleware"
+	"github.com/cryptolink/cryptolink/internal/service/user"
+	"github.com/cryptolink/cryptolink/pkg/api-dashboard/v1/model"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	mw "github.com/labstack/echo/v4/middleware"
+	"github.com/pkg/errors"
+)
+
+// pendingAuthMethodSessionKey first factor of the pending login.
+const pendingAuthMethodSessionKey = "pending_auth_method"
+
+type twoFactorLoginResponse struct {
+	TwoFactorRequired bool `json:"twoFactorRequired"`
+}
+
+type twoFactorStatusResponse struct {
+	Enabled           bool  `json:"enabled"`
+	Required          bool  `json:"required"`
+	RecoveryCodesLeft int64 `json:"recoveryCodesLeft"`
+}
+
+type totpEnrollmentResponse struct {
+	Secret string `json:"secret"`
+	// ProvisioningURI otpauth:// URI to render as a QR code.
+	ProvisioningURI string `json:"provisioningUri"`
+}
+
+type recoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+type twoFactorCodeRequest struct {
+	Code string `json:"code"`
+}
+
+type reauthRequest struct {
+	Password string `json:"password"`
+	Code     string `json:"code"`
+}
+
+type twoFactorSettings struct {
+	Required bool `json:"required"`
+}
+
+// PostLoginTwoFactor handles POST /auth/login/2fa, the second step of login
+// for users with 2FA. Accepts TOTP or recovery code.
+func (h *Handler) PostLoginTwoFactor(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	var req twoFactorCodeRequest
+	if err := c.Bind(&req); err != nil {
+		return common.ValidationErrorResponse(c, "invalid request")
+	}
+
+	if req.Code == "" {
+		return common.ValidationErrorItemResponse(c, "code", "code is required")
+	}
+
+	userID, ok := middleware.ResolvePendingUserID(c)
+	if !ok {
+		return c.JSON(http.StatusUnauthorized, &model.ErrorResponse{
+			Message: "Login expired, please sign in again",
+			Status:  "unauthorized",
+		})
+	}
+
+	if !h.allowSecondFactor(c, userID) {
+		return tooManyAttemptsResponse()
+	}
+
+	err := h.users.VerifySecondFactor(ctx, userID, req.Code)
+	switch {
+	case errors.Is(err, user.ErrInvalidCode):
+		return common.ValidationErrorItemResponse(c, "code", "Invalid code")
+	case errors.Is(err, user.ErrTwoFactorLocked):
+		// the password step is required again once the lockout ends
+		if err := h.persistSession(c, "2fa", pendingLoginSession(nil, "")); err != nil {
+			return common.ErrorResponse(c, "internal error")
+		}
+
+		return tooManyAttemptsResponse()
+	case err != nil:
+		return errors.Wrap(err, "unable to verify second factor")
+	}
+
+	method := user.AuthMethodPassword
+	if raw, ok := middleware.ResolveSession(c).Values[pendingAuthMethodSessionKey].(string); ok {
+		method = user.AuthMethod(raw)
+	}
+
+	if err := h.login(c, "2fa", userID, method, true); err != nil {
+		return common.ErrorResponse(c, "internal error")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// allowSecondFactor limits second factor attempts per user regardless of the
+// client IP, see newSecondFactorLimiter.
+func (h *Handler) allowSecondFactor(c echo.Context, userID int64) bool {
+	if allowed, _ := h.secondFactors.Allow(strconv.FormatInt(userID, 10)); !allowed {
+		h.logger.Warn().Int64("user_id", userID).Str("ip", c.RealIP()).Msg("second factor attempts limit reached")
+		return false
+	}
+
+	return true
+}
+
+// newSecondFactorLimiter at most 5 attempts per user and then one per minute.
+func newSecondFactorLimiter() *mw.RateLimiterMemoryStore {
+	return mw.NewRateLimiterMemoryStoreWithConfig(mw.RateLimiterMemoryStoreConfig{
+		Rate:      1.0 / 60,
+		Burst:     5,
+		ExpiresIn: time.Hour,
+	})
+}
+
+func tooManyAttemptsResponse() error {
+	return echo.NewHTTPError(http.StatusTooManyRequests, "Too many attempts, please try again later")
+}
+
+// GetTwoFactor handles GET /auth/2fa
+func (h *Handler) GetTwoFactor(c echo.Context) error {
+	person := middleware.ResolveUser(c)
+
+	status, err := h.users.GetTwoFactorStatus(c.Request().Context(), person.ID)
+	if err != nil {
+		return errors.Wrap(err, "unable to get two-factor status")
+	}
+
+	return c.JSON(http.StatusOK, &twoFactorStatusResponse{
+		Enabled:           status.Enabled,
+		Required:          status.Required,
+		RecoveryCodesLeft: status.RecoveryCodesLeft,
+	})
+}
+
+// PostTwoFactorEnroll handles POST /auth/2fa/enroll. Returns a new TOTP
+// secret that should be confirmed with PostTwoFactorConfirm.
+func (h *Handler) PostTwoFactorEnroll(c echo.Context) error {
+	person := middleware.ResolveUser(c)
+
+	enrollment, err := h.users.BeginTOTPEnrollment(c.Request().Context(), person)
+	switch {
+	case errors.Is(err, user.ErrTwoFactorEnabled):
+		return common.ValidationErrorResponse(c, "Two-factor authentication is already enabled")
+	case err != nil:
+		return errors.Wrap(err, "unable to begin totp enrollment")
+	}
+
+	return c.JSON(http.StatusOK, &totpEnrollmentResponse{
+		Secret:          enrollment.Secret,
+		ProvisioningURI: enrollment.URI,
+	})
+}
+
+// PostTwoFactorConfirm handles POST /auth/2fa/confirm. Enables 2FA and returns
+// recovery codes.
+func (h *Handler) PostTwoFactorConfirm(c echo.Context) error {
+	person := middleware.ResolveUser(c)
+
+	var req twoFactorCodeRequest
+	if err := c.Bind(&req); err != nil {
+		return common.ValidationErrorResponse(c, "invalid request")
+	}
+
+	codes, err := h.users.ConfirmTOTPEnrollment(c.Request().Context(), person.ID, req.Code)
+	switch {
+	case errors.Is(err, user.ErrInvalidCode):
+		return common.ValidationErrorItemResponse(c, "code", "Invalid code")
+	case errors.Is(err, user.ErrTwoFactorNotEnrolled):
+		return common.ValidationErrorResponse(c, "Two-factor enrollment is not started")
+	case errors.Is(err, user.ErrTwoFactorEnabled):
+		return common.ValidationErrorResponse(c, "Two-factor authentication is already enabled")
+	case err != nil:
+		return errors.Wrap(err, "unable to confirm totp enrollment")
+	}
+
+	return c.JSON(http.StatusOK, &recoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// PostRecoveryCodes handles POST /auth/2fa/recovery-codes. Replaces recovery
+// codes of the user.
+func (h *Handler) PostRecoveryCodes(c echo.Context) error {
+	person := middleware.ResolveUser(c)
+
+	codes, err := h.users.RegenerateRecoveryCodes(c.Request().Context(), person.ID)
+	switch {
+	case errors.Is(err, user.ErrTwoFactorNotEnabled):
+		return common.ValidationErrorResponse(c, "Two-factor authentication is not enabled")
+	case err != nil:
+		return errors.Wrap(err, "unable to regenerate recovery codes")
+	}
+
+	return c.JSON(http.StatusOK, &recoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// DeleteTwoFactor handles DELETE /auth/2fa
+func (h *Handler) DeleteTwoFactor(c echo.Context) error {
+	person := middleware.ResolveUser(c)
+
+	err := h.users.DisableTwoFactor(c.Request().Context(), person.ID)
+	switch {
+	case errors.Is(err, user.ErrTwoFactorRequired):
+		return common.ValidationErrorResponse(c, "Two-factor authentication is required for all users")
+	case err != nil:
+		return errors.Wrap(err, "unable to disable two-factor authentication")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// PostReauth handles POST /auth/reauth. Confirms password or second factor of
+// the logged-in user and allows sensitive operations for middleware.ReauthTTL.
+// Users without password and 2FA re-authenticate with Google, see GetRedirect.
+func (h *Handler) PostReauth(c echo.Context) error {
+	ctx := c.Request().Context()
+	person := middleware.ResolveUser(c)
+
+	var req reauthRequest
+	if err := c.Bind(&req); err != nil {
+		return common.ValidationErrorResponse(c, "invalid request")
+	}
+
+	switch {
+	case req.Code != "":
+		if !h.allowSecondFactor(c, person.ID) {
+			return tooManyAttemptsResponse()
+		}
+
+		err := h.users.VerifySecondFactor(ctx, person.ID, req.Code)
+		switch {
+		case errors.Is(err, user.ErrInvalidCode), errors.Is(err, user.ErrTwoFactorNotEnabled):
+			return common.ValidationErrorItemResponse(c, "code", "Invalid code")
+		case errors.Is(err, user.ErrTwoFactorLocked):
+			return tooManyAttemptsResponse()
+		case err != nil:
+			return errors.Wrap(err, "unable to verify second factor")
+		}
+	case req.Password != "":
+		if !h.allowLogin(c, person.Email) {
+			return tooManyAttemptsResponse()
+		}
+
+		_, err := h.users.GetByEmailWithPasswordCheck(ctx, person.Email, req.Password)
+		switch {
+		case errors.Is(err, user.ErrWrongPassword):
+			return common.ValidationErrorItemResponse(c, "password", "Password is incorrect")
+		case err != nil:
+			return errors.Wrap(err, "unable to check password")
+		}
+	default:
+		return common.ValidationErrorResponse(c, "password or code is required")
+	}
+
+	setSession := map[string]any{middleware.ReauthAtSessionKey: time.Now().Unix()}
+	if err := h.persistSession(c, "reauth", setSession); err != nil {
+		return common.ErrorResponse(c, "internal error")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// GetTwoFactorSettings handles GET /admin/auth/2fa
+func (h *Handler) GetTwoFactorSettings(c echo.Context) error {
+	return c.JSON(http.StatusOK, &twoFactorSettings{
+		Required: h.users.IsTwoFactorRequired(c.Request().Context()),
+	})
+}
+
+// UpdateTwoFactorSettings handles PUT /admin/auth/2fa. Requires 2FA for all
+// users: those without it can only enroll until they enable it.
+func (h *Handler) UpdateTwoFactorSettings(c echo.Context) error {
+	var req twoFactorSettings
+	if err := c.Bind(&req); err != nil {
+		return common.ValidationErrorResponse(c, "invalid request")
+	}
+
+	if err := h.users.SetTwoFactorRequired(c.Request().Context(), req.Required); err != nil {
+		return errors.Wrap(err, "unable to update two-factor settings")
+	}
+
+	return c.JSON(http.StatusOK, &req)
+}
+
+// ResetUserTwoFactor handles DELETE /admin/users/:userId/2fa for users that
+// lost their authenticator and recovery codes.
+func (h *Handler) ResetUserTwoFactor(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	userID, err := strconv.ParseInt(c.Param("userId"), 10, 64)
+	if err != nil {
+		return common.ValidationErrorResponse(c, "invalid user ID")
+	}
+
+	if _, err := h.users.GetByID(ctx, userID); errors.Is(err, user.ErrNotFound) {
+		return common.NotFoundResponse(c, "user not found")
+	} else if err != nil {
+		return errors.Wrap(err, "unable to get user")
+	}
+
+	if err := h.users.ResetTwoFactor(ctx, userID); err != nil {
+		return errors.Wrap(err, "unable to reset two-factor authentication")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// startLogin logs the user in or, if the user has 2FA, starts pending login
+// that PostLoginTwoFactor completes. Returns true if the second factor is
+// required.
+func (h *Handler) startLogin(c echo.Context, source string, userID int64, method user.AuthMethod) (bool, error) {
+	enabled, err := h.users.IsTwoFactorEnabled(c.Request().Context(), userID)
+	if err != nil {
+		h.logger.Error().Err(err).Str("source", source).Msg("unable to check two-factor authentication")
+		return false, err
+	}
+
+	if enabled {
+		return true, h.persistSession(c, source, pendingLoginSession(&userID, method))
+	}
+
+	return false, h.login(c, source, userID, method, false)
+}
+
+// login creates server-side session record and logs the user in.
+func (h *Handler) login(c echo.Context, source string, userID int64, method user.AuthMethod, twoFactor bool) error {
+	record, err := h.users.CreateSession(c.Request().Context(), user.CreateSessionParams{
+		UserID:     userID,
+		AuthMethod: method,
+		TwoFactor:  twoFactor,
+		IP:         c.RealIP(),
+		UserAgent:  c.Request().UserAgent(),
+		TTL:        middleware.ResolveSessionTTL(c),
+	})
+	if err != nil {
+		h.logger.Error().Err(err).Str("source", source).Msg("unable to create session")
+		return err
+	}
+
+	return h.persistSession(c, source, loginSession(userID, record.UUID))
+}
+
+func loginSession(userID int64, sessionID uuid.UUID) map[string]any {
+	values := pendingLoginSession(nil, "")
+	values[middleware.UserIDContextKey] = userID
+	values[middleware.SessionIDSessionKey] = sessionID.String()
+	values[middleware.ReauthAtSessionKey] = time.Now().Unix()
+
+	return values
+}
+
+// pendingLoginSession sets or clears (userID = nil) pending login.
+func pendingLoginSession(userID *int64, method user.AuthMethod) map[string]any {
+	if userID == nil {
+		return map[string]any{
+			middleware.PendingUserIDSessionKey: nil,
+			middleware.PendingUserAtSessionKey: nil,
+			pendingAuthMethodSessionKey:        nil,
+		}
+	}
+
+	return map[string]any{
+		middleware.PendingUserIDSessionKey: *userID,
+		middleware.PendingUserAtSessionKey: time.Now().Unix(),
+		pendingAuthMethodSessionKey:        string(method),
+	}
+}
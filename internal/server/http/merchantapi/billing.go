@@ -0,0 +1,367 @@
+package merchantapi
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/cryptolink/cryptolink/internal/money"
+	"github.com/cryptolink/cryptolink/internal/server/http/common"
+	"github.com/cryptolink/cryptolink/internal/server/http/middleware"
+	"github.com/cryptolink/cryptolink/internal/service/billing"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+)
+
+const (
+	paramBillingPlanID  = "planId"
+	paramSubscriptionID = "subscriptionId"
+)
+
+// ────────────────────────────────────────────────────────────────────────────
+// Request / Response types
+// ────────────────────────────────────────────────────────────────────────────
+
+type createBillingPlanRequest struct {
+	Name          string  `json:"name"`
+	Description   *string `json:"description"`
+	Price         float64 `json:"price"`
+	Currency      string  `json:"currency"`
+	Interval      string  `json:"interval"`
+	IntervalCount int     `json:"intervalCount"`
+}
+
+type billingPlanResponse struct {
+	ID            string  `json:"id"`
+	Name          string  `json:"name"`
+	Description   *string `json:"description"`
+	Price         string  `json:"price"`
+	Currency      string  `json:"currency"`
+	Interval      string  `json:"interval"`
+	IntervalCount int     `json:"intervalCount"`
+	IsActive      bool    `json:"isActive"`
+	CreatedAt     string  `json:"createdAt"`
+}
+
+type createCustomerSubscriptionRequest struct {
+	PlanID        string `json:"planId"`
+	CustomerEmail string `json:"customerEmail"`
+	IsTest        bool   `json:"isTest"`
+}
+
+type cancelCustomerSubscriptionRequest struct {
+	AtPeriodEnd bool `json:"atPeriodEnd"`
+}
+
+type customerSubscriptionResponse struct {
+	ID                 string  `json:"id"`
+	PlanID             string  `json:"planId"`
+	CustomerID         string  `json:"customerId"`
+	CustomerEmail      string  `json:"customerEmail"`
+	Status             string  `json:"status"`
+	CurrentPeriodStart string  `json:"currentPeriodStart"`
+	CurrentPeriodEnd   string  `json:"currentPeriodEnd"`
+	CancelAtPeriodEnd  bool    `json:"cancelAtPeriodEnd"`
+	PastDueSince       *string `json:"pastDueSince"`
+	CancelledAt        *string `json:"cancelledAt"`
+	IsTest             bool    `json:"isTest"`
+	CreatedAt          string  `json:"createdAt"`
+}
+
+type subscriptionInvoiceResponse struct {
+	PaymentID     string  `json:"paymentId"`
+	PeriodStart   string  `json:"periodStart"`
+	PeriodEnd     string  `json:"periodEnd"`
+	Status        string  `json:"status"`
+	RemindersSent int     `json:"remindersSent"`
+	PaidAt        *string `json:"paidAt"`
+	CreatedAt     string  `json:"createdAt"`
+}
+
+// ────────────────────────────────────────────────────────────────────────────
+// Plans
+// ────────────────────────────────────────────────────────────────────────────
+
+// ListBillingPlans returns merchant's recurring billing plans.
+func (h *Handler) ListBillingPlans(c echo.Context) error {
+	ctx := c.Request().Context()
+	mt := middleware.ResolveMerchant(c)
+
+	plans, err := h.billing.ListPlans(ctx, mt.ID)
+	if err != nil {
+		return err
+	}
+
+	results := make([]billingPlanResponse, 0, len(plans))
+	for _, plan := range plans {
+		results = append(results, billingPlanToResponse(plan))
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{"results": results})
+}
+
+// GetBillingPlan returns a single billing plan.
+func (h *Handler) GetBillingPlan(c echo.Context) error {
+	ctx := c.Request().Context()
+	mt := middleware.ResolveMerchant(c)
+
+	planID, err := uuid.Parse(c.Param(paramBillingPlanID))
+	if err != nil {
+		return common.ValidationErrorResponse(c, "invalid plan id")
+	}
+
+	plan, err := h.billing.GetPlanByUUID(ctx, mt.ID, planID)
+	switch {
+	case errors.Is(err, billing.ErrPlanNotFound):
+		return common.NotFoundResponse(c, "billing plan not found")
+	case err != nil:
+		return err
+	}
+
+	return c.JSON(http.StatusOK, billingPlanToResponse(plan))
+}
+
+// CreateBillingPlan creates recurring price (e.g. 9.99 USD every month).
+func (h *Handler) CreateBillingPlan(c echo.Context) error {
+	var req createBillingPlanRequest
+	if err := c.Bind(&req); err != nil {
+		return common.ValidationErrorResponse(c, "invalid request body")
+	}
+
+	ctx := c.Request().Context()
+	mt := middleware.ResolveMerchant(c)
+
+	currency, err := money.MakeFiatCurrency(req.Currency)
+	if err != nil {
+		return common.ValidationErrorItemResponse(c, "currency", "unsupported currency %q", req.Currency)
+	}
+
+	price, err := money.FiatFromFloat64(currency, req.Price)
+	if err != nil {
+		return common.ValidationErrorItemResponse(c, "price", "%s", err.Error())
+	}
+
+	if req.IntervalCount == 0 {
+		req.IntervalCount = 1
+	}
+
+	plan, err := h.billing.CreatePlan(ctx, mt.ID, billing.CreatePlanProps{
+		Name:          req.Name,
+		Description:   req.Description,
+		Price:         price,
+		Interval:      billing.Interval(req.Interval),
+		IntervalCount: req.IntervalCount,
+	})
+	switch {
+	case errors.Is(err, billing.ErrValidation):
+		return common.ValidationErrorResponse(c, err.Error())
+	case err != nil:
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, billingPlanToResponse(plan))
+}
+
+// DeactivateBillingPlan disables new subscriptions to the plan. Existing
+// subscriptions are not affected.
+func (h *Handler) DeactivateBillingPlan(c echo.Context) error {
+	ctx := c.Request().Context()
+	mt := middleware.ResolveMerchant(c)
+
+	planID, err := uuid.Parse(c.Param(paramBillingPlanID))
+	if err != nil {
+		return common.ValidationErrorResponse(c, "invalid plan id")
+	}
+
+	err = h.billing.DeactivatePlan(ctx, mt.ID, planID)
+	switch {
+	case errors.Is(err, billing.ErrPlanNotFound):
+		return common.NotFoundResponse(c, "billing plan not found")
+	case err != nil:
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// ────────────────────────────────────────────────────────────────────────────
+// Subscriptions
+// ────────────────────────────────────────────────────────────────────────────
+
+// ListCustomerSubscriptions returns subscriptions of merchant's customers.
+func (h *Handler) ListCustomerSubscriptions(c echo.Context) error {
+	ctx := c.Request().Context()
+	mt := middleware.ResolveMerchant(c)
+
+	subs, err := h.billing.ListSubscriptions(ctx, mt.ID)
+	if err != nil {
+		return err
+	}
+
+	results := make([]customerSubscriptionResponse, 0, len(subs))
+	for _, sub := range subs {
+		results = append(results, customerSubscriptionToResponse(sub))
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{"results": results})
+}
+
+// GetCustomerSubscription returns a single subscription.
+func (h *Handler) GetCustomerSubscription(c echo.Context) error {
+	ctx := c.Request().Context()
+	mt := middleware.ResolveMerchant(c)
+
+	subID, err := uuid.Parse(c.Param(paramSubscriptionID))
+	if err != nil {
+		return common.ValidationErrorResponse(c, "invalid subscription id")
+	}
+
+	sub, err := h.billing.GetSubscriptionByUUID(ctx, mt.ID, subID)
+	switch {
+	case errors.Is(err, billing.ErrSubscriptionNotFound):
+		return common.NotFoundResponse(c, "subscription not found")
+	case err != nil:
+		return err
+	}
+
+	return c.JSON(http.StatusOK, customerSubscriptionToResponse(sub))
+}
+
+// CreateCustomerSubscription subscribes customer to the plan and emails the
+// first invoice. Subscription becomes active once the invoice is paid.
+func (h *Handler) CreateCustomerSubscription(c echo.Context) error {
+	var req createCustomerSubscriptionRequest
+	if err := c.Bind(&req); err != nil {
+		return common.ValidationErrorResponse(c, "invalid request body")
+	}
+
+	planID, err := uuid.Parse(req.PlanID)
+	if err != nil {
+		return common.ValidationErrorItemResponse(c, "planId", "invalid plan id")
+	}
+
+	ctx := c.Request().Context()
+	mt := middleware.ResolveMerchant(c)
+
+	sub, err := h.billing.Subscribe(ctx, mt.ID, billing.SubscribeProps{
+		PlanUUID:      planID,
+		CustomerEmail: req.CustomerEmail,
+		IsTest:        req.IsTest,
+	})
+	switch {
+	case errors.Is(err, billing.ErrValidation):
+		return common.ValidationErrorItemResponse(c, "customerEmail", "invalid email")
+	case errors.Is(err, billing.ErrPlanNotFound):
+		return common.ValidationErrorItemResponse(c, "planId", "billing plan not found")
+	case errors.Is(err, billing.ErrPlanInactive):
+		return common.ValidationErrorItemResponse(c, "planId", "billing plan is not active")
+	case err != nil:
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, customerSubscriptionToResponse(sub))
+}
+
+// CancelCustomerSubscription cancels subscription immediately or at the end
+// of the current period ({"atPeriodEnd": true}).
+func (h *Handler) CancelCustomerSubscription(c echo.Context) error {
+	var req cancelCustomerSubscriptionRequest
+	if err := c.Bind(&req); err != nil {
+		return common.ValidationErrorResponse(c, "invalid request body")
+	}
+
+	subID, err := uuid.Parse(c.Param(paramSubscriptionID))
+	if err != nil {
+		return common.ValidationErrorResponse(c, "invalid subscription id")
+	}
+
+	ctx := c.Request().Context()
+	mt := middleware.ResolveMerchant(c)
+
+	sub, err := h.billing.Cancel(ctx, mt.ID, subID, req.AtPeriodEnd)
+	switch {
+	case errors.Is(err, billing.ErrSubscriptionNotFound):
+		return common.NotFoundResponse(c, "subscription not found")
+	case errors.Is(err, billing.ErrAlreadyCancelled):
+		return common.ValidationErrorResponse(c, "subscription is already cancelled")
+	case err != nil:
+		return err
+	}
+
+	return c.JSON(http.StatusOK, customerSubscriptionToResponse(sub))
+}
+
+// ListCustomerSubscriptionInvoices returns invoices of the subscription, newest first.
+func (h *Handler) ListCustomerSubscriptionInvoices(c echo.Context) error {
+	ctx := c.Request().Context()
+	mt := middleware.ResolveMerchant(c)
+
+	subID, err := uuid.Parse(c.Param(paramSubscriptionID))
+	if err != nil {
+		return common.ValidationErrorResponse(c, "invalid subscription id")
+	}
+
+	invoices, err := h.billing.ListInvoices(ctx, mt.ID, subID)
+	switch {
+	case errors.Is(err, billing.ErrSubscriptionNotFound):
+		return common.NotFoundResponse(c, "subscription not found")
+	case err != nil:
+		return err
+	}
+
+	results := make([]subscriptionInvoiceResponse, 0, len(invoices))
+	for _, inv := range invoices {
+		results = append(results, subscriptionInvoiceResponse{
+			PaymentID:     inv.PaymentUUID.String(),
+			PeriodStart:   inv.PeriodStart.Format(time.RFC3339),
+			PeriodEnd:     inv.PeriodEnd.Format(time.RFC3339),
+			Status:        string(inv.Status),
+			RemindersSent: inv.RemindersSent,
+			PaidAt:        formatOptionalTime(inv.PaidAt),
+			CreatedAt:     inv.CreatedAt.Format(time.RFC3339),
+		})
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{"results": results})
+}
+
+func billingPlanToResponse(plan *billing.Plan) billingPlanResponse {
+	return billingPlanResponse{
+		ID:            plan.UUID.String(),
+		Name:          plan.Name,
+		Description:   plan.Description,
+		Price:         plan.Price.String(),
+		Currency:      plan.Price.Ticker(),
+		Interval:      string(plan.Interval),
+		IntervalCount: plan.IntervalCount,
+		IsActive:      plan.IsActive,
+		CreatedAt:     plan.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+func customerSubscriptionToResponse(sub *billing.Subscription) customerSubscriptionResponse {
+	return customerSubscriptionResponse{
+		ID:                 sub.UUID.String(),
+		PlanID:             sub.PlanUUID.String(),
+		CustomerID:         sub.CustomerUUID.String(),
+		CustomerEmail:      sub.CustomerEmail,
+		Status:             sub.Status.String(),
+		CurrentPeriodStart: sub.CurrentPeriodStart.Format(time.RFC3339),
+		CurrentPeriodEnd:   sub.CurrentPeriodEnd.Format(time.RFC3339),
+		CancelAtPeriodEnd:  sub.CancelAtPeriodEnd,
+		PastDueSince:       formatOptionalTime(sub.PastDueSince),
+		CancelledAt:        formatOptionalTime(sub.CancelledAt),
+		IsTest:             sub.IsTest,
+		CreatedAt:          sub.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+func formatOptionalTime(t *time.Time) *string {
+	if t == nil {
+		return nil
+	}
+
+	s := t.Format(time.RFC3339)
+
+	return &s
+}
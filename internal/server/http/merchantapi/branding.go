@@ -0,0 +1,126 @@
+package merchantapi
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/cryptolink/cryptolink/internal/server/http/common"
+	"github.com/cryptolink/cryptolink/internal/server/http/middleware"
+	"github.com/cryptolink/cryptolink/internal/service/merchant"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+)
+
+// logoReadLimit guards memory while reading the upload; the actual size
+// limit is enforced by merchant.Service.
+const logoReadLimit = 5 << 20
+
+type brandingRequest struct {
+	AccentColor  string `json:"accentColor"`
+	SupportEmail string `json:"supportEmail"`
+	TermsURL     string `json:"termsUrl"`
+	FooterText   string `json:"footerText"`
+}
+
+type brandingResponse struct {
+	LogoURL      string `json:"logoUrl"`
+	AccentColor  string `json:"accentColor"`
+	SupportEmail string `json:"supportEmail"`
+	TermsURL     string `json:"termsUrl"`
+	FooterText   string `json:"footerText"`
+}
+
+// GetBranding returns merchant's checkout branding.
+func (h *Handler) GetBranding(c echo.Context) error {
+	mt := middleware.ResolveMerchant(c)
+
+	return c.JSON(http.StatusOK, brandingToResponse(h.merchants.Branding(mt)))
+}
+
+// UpdateBranding updates accent color, support email, terms url and footer
+// text. Empty values reset the setting to default.
+func (h *Handler) UpdateBranding(c echo.Context) error {
+	var req brandingRequest
+	if err := c.Bind(&req); err != nil {
+		return common.ValidationErrorResponse(c, "invalid request body")
+	}
+
+	ctx := c.Request().Context()
+	mt := middleware.ResolveMerchant(c)
+
+	err := h.merchants.UpdateBranding(ctx, mt, merchant.BrandingProps{
+		AccentColor:  req.AccentColor,
+		SupportEmail: req.SupportEmail,
+		TermsURL:     req.TermsURL,
+		FooterText:   req.FooterText,
+	})
+	switch {
+	case errors.Is(err, merchant.ErrInvalidBranding):
+		return common.ValidationErrorResponse(c, err.Error())
+	case err != nil:
+		h.logger.Error().Err(err).Int64("merchant_id", mt.ID).Msg("failed to update branding")
+		return common.ErrorResponse(c, "internal_error")
+	}
+
+	return c.JSON(http.StatusOK, brandingToResponse(h.merchants.Branding(mt)))
+}
+
+// UploadBrandingLogo accepts multipart/form-data with "logo" file field.
+func (h *Handler) UploadBrandingLogo(c echo.Context) error {
+	file, err := c.FormFile("logo")
+	if err != nil {
+		return common.ValidationErrorItemResponse(c, "logo", "logo file is required")
+	}
+
+	if file.Size > logoReadLimit {
+		return common.ValidationErrorItemResponse(c, "logo", "logo is too large")
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return common.ValidationErrorItemResponse(c, "logo", "unable to read logo")
+	}
+	defer src.Close()
+
+	data, err := io.ReadAll(io.LimitReader(src, logoReadLimit))
+	if err != nil {
+		return common.ValidationErrorItemResponse(c, "logo", "unable to read logo")
+	}
+
+	ctx := c.Request().Context()
+	mt := middleware.ResolveMerchant(c)
+
+	err = h.merchants.UploadLogo(ctx, mt, data)
+	switch {
+	case errors.Is(err, merchant.ErrInvalidBranding):
+		return common.ValidationErrorItemResponse(c, "logo", "%s", err.Error())
+	case err != nil:
+		h.logger.Error().Err(err).Int64("merchant_id", mt.ID).Msg("failed to upload logo")
+		return common.ErrorResponse(c, "internal_error")
+	}
+
+	return c.JSON(http.StatusOK, brandingToResponse(h.merchants.Branding(mt)))
+}
+
+// DeleteBrandingLogo removes merchant's logo.
+func (h *Handler) DeleteBrandingLogo(c echo.Context) error {
+	ctx := c.Request().Context()
+	mt := middleware.ResolveMerchant(c)
+
+	if err := h.merchants.DeleteLogo(ctx, mt); err != nil {
+		h.logger.Error().Err(err).Int64("merchant_id", mt.ID).Msg("failed to delete logo")
+		return common.ErrorResponse(c, "internal_error")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func brandingToResponse(b merchant.Branding) brandingResponse {
+	return brandingResponse{
+		LogoURL:      b.LogoURL,
+		AccentColor:  b.AccentColor,
+		SupportEmail: b.SupportEmail,
+		TermsURL:     b.TermsURL,
+		FooterText:   b.FooterText,
+	}
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/cryptolink/cryptolink/internal/server/http/common"
 	"github.com/cryptolink/cryptolink/internal/server/http/middleware"
+	"github.com/cryptolink/cryptolink/internal/service/audit"
 	"github.com/cryptolink/cryptolink/internal/service/evmcollector"
 	"github.com/labstack/echo/v4"
 	"github.com/pkg/errors"
@@ -87,6 +88,15 @@ func (h *Handler) UpsertCollectorFactory(c echo.Context) error {
 
 	ctx := c.Request().Context()
 
+	var before audit.Values
+	prev, err := h.evmCollector.GetFactoryByBlockchain(ctx, req.Blockchain)
+	switch {
+	case err == nil:
+		before = factoryAuditValues(prev)
+	case !errors.Is(err, evmcollector.ErrFactoryNotFound):
+		return err
+	}
+
 	factory := &evmcollector.CollectorFactory{
 		Blockchain:            req.Blockchain,
 		ImplementationAddress: req.ImplementationAddress,
@@ -99,6 +109,14 @@ func (h *Handler) UpsertCollectorFactory(c echo.Context) error {
 		return err
 	}
 
+	// factories are shared by all merchants
+	h.recordChange(c, audit.Change{
+		Action:     audit.ActionCollectorFactoryUpdate,
+		ResourceID: result.Blockchain,
+		Before:     before,
+		After:      factoryAuditValues(result),
+	})
+
 	return c.JSON(http.StatusOK, toFactoryResponse(result))
 }
 
@@ -138,3 +156,11 @@ func toFactoryResponse(f *evmcollector.CollectorFactory) factoryResponse {
 		UpdatedAt:             f.UpdatedAt.Format("2006-01-02T15:04:05Z"),
 	}
 }
+
+func factoryAuditValues(f *evmcollector.CollectorFactory) audit.Values {
+	return audit.Values{
+		"blockchain":            f.Blockchain,
+		"implementationAddress": f.ImplementationAddress,
+		"factoryAddress":        f.FactoryAddress,
+	}
+}
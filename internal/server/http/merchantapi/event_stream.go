@@ -0,0 +1,194 @@
+package merchantapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cryptolink/cryptolink/internal/server/http/common"
+	"github.com/cryptolink/cryptolink/internal/server/http/middleware"
+	"github.com/cryptolink/cryptolink/internal/service/webhooks"
+	"github.com/gorilla/websocket"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+)
+
+const (
+	queryParamEventTypes = "types"
+	queryParamCursor     = "cursor"
+
+	eventStreamBatch = 100
+
+	eventStreamWriteTimeout = 10 * time.Second
+	eventStreamPingInterval = 30 * time.Second
+	eventStreamPongTimeout  = 2 * eventStreamPingInterval
+
+	// eventStreamPollInterval re-reads the log without a wake up, e.g. when
+	// events were skipped by the watcher after a restart.
+	eventStreamPollInterval = 30 * time.Second
+)
+
+// The stream is authorized by API token header, not by cookies, so requests
+// from any origin are fine.
+var eventStreamUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 4096,
+	CheckOrigin:     func(*http.Request) bool { return true },
+}
+
+// eventStreamMessage is a message sent over the stream. "ready" is sent once
+// after connect with the cursor the stream starts from, "event" carries the
+// webhook event envelope.
+type eventStreamMessage struct {
+	Type   string          `json:"type"`
+	Cursor string          `json:"cursor"`
+	Event  json.RawMessage `json:"event,omitempty"`
+}
+
+// StreamEvents streams merchant's typed webhook events over WebSocket in the
+// order they happened. Stream starts after the cursor or, without one, from
+// the current end of the log. Like webhooks, delivery is at least once:
+// clients persist cursor of the processed event, resume from it after
+// reconnect and deduplicate by envelope id.
+func (h *Handler) StreamEvents(c echo.Context) error {
+	mt := middleware.ResolveMerchant(c)
+
+	params := webhooks.StreamParams{
+		EventTypes: parseEventTypes(c.QueryParams()[queryParamEventTypes]),
+		Cursor:     c.QueryParam(queryParamCursor),
+		Limit:      eventStreamBatch,
+	}
+
+	switch err := webhooks.ValidateStreamParams(params); {
+	case errors.Is(err, webhooks.ErrUnknownEventType):
+		return common.ValidationErrorItemResponse(c, queryParamEventTypes, "%s", err.Error())
+	case errors.Is(err, webhooks.ErrInvalidCursor):
+		return common.ValidationErrorItemResponse(c, queryParamCursor, "invalid cursor")
+	case err != nil:
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(c.Request().Context())
+	defer cancel()
+
+	if params.Cursor == "" {
+		cursor, err := h.webhooks.StreamHead(ctx, mt.ID)
+		if err != nil {
+			h.logger.Error().Err(err).Int64("merchant_id", mt.ID).Msg("unable to get event stream head")
+			return common.ErrorResponse(c, common.StatusInternalError)
+		}
+
+		params.Cursor = cursor
+	}
+
+	wake, unsubscribe, err := h.webhooks.SubscribeStream(mt.ID)
+	switch {
+	case errors.Is(err, webhooks.ErrTooManyStreams):
+		return echo.NewHTTPError(http.StatusTooManyRequests, err.Error())
+	case err != nil:
+		return err
+	}
+
+	defer unsubscribe()
+
+	conn, err := eventStreamUpgrader.Upgrade(c.Response(), c.Request(), nil)
+	if err != nil {
+		// upgrader has already responded
+		return nil
+	}
+
+	defer conn.Close()
+
+	// client messages are not expected, reading handles pings and close
+	conn.SetReadLimit(512)
+	_ = conn.SetReadDeadline(time.Now().Add(eventStreamPongTimeout))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(eventStreamPongTimeout))
+	})
+
+	go func() {
+		defer cancel()
+
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	write := func(v any) error {
+		_ = conn.SetWriteDeadline(time.Now().Add(eventStreamWriteTimeout))
+		return conn.WriteJSON(v)
+	}
+
+	if err := write(eventStreamMessage{Type: "ready", Cursor: params.Cursor}); err != nil {
+		return nil
+	}
+
+	ping := time.NewTicker(eventStreamPingInterval)
+	defer ping.Stop()
+
+	poll := time.NewTicker(eventStreamPollInterval)
+	defer poll.Stop()
+
+	for {
+		events, next, err := h.webhooks.ListStreamEvents(ctx, mt, params)
+		if err != nil {
+			if ctx.Err() == nil {
+				h.logger.Error().Err(err).Int64("merchant_id", mt.ID).Msg("unable to list stream events")
+				_ = conn.WriteControl(
+					websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseInternalServerErr, "internal error"),
+					time.Now().Add(eventStreamWriteTimeout),
+				)
+			}
+
+			return nil
+		}
+
+		for _, e := range events {
+			if err := write(eventStreamMessage{Type: "event", Cursor: e.Cursor, Event: e.Payload}); err != nil {
+				return nil
+			}
+		}
+
+		if next != params.Cursor {
+			params.Cursor = next
+			continue
+		}
+
+	wait:
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-wake:
+				break wait
+			case <-poll.C:
+				break wait
+			case <-ping.C:
+				err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(eventStreamWriteTimeout))
+				if err != nil {
+					return nil
+				}
+			}
+		}
+	}
+}
+
+// parseEventTypes accepts both repeated and comma separated query values.
+func parseEventTypes(values []string) []string {
+	var types []string
+
+	for _, v := range values {
+		for _, t := range strings.Split(v, ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				types = append(types, t)
+			}
+		}
+	}
+
+	return types
+}
@@ -4,9 +4,13 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/cryptolink/cryptolink/internal/money"
 	"github.com/cryptolink/cryptolink/internal/server/http/common"
 	"github.com/cryptolink/cryptolink/internal/server/http/middleware"
+	"github.com/cryptolink/cryptolink/internal/service/audit"
+	"github.com/cryptolink/cryptolink/internal/service/blockchain"
 	"github.com/cryptolink/cryptolink/internal/service/evmcollector"
+	"github.com/cryptolink/cryptolink/pkg/api-dashboard/v1/model"
 	"github.com/labstack/echo/v4"
 	"github.com/pkg/errors"
 )
@@ -20,7 +24,9 @@ type setupCollectorRequest struct {
 	ChainID         int    `json:"chainId"`
 	OwnerAddress    string `json:"ownerAddress"`
 	ContractAddress string `json:"contractAddress"`
-	FactoryAddress  string `json:"factoryAddress"`
+
+	// DeploymentTxHash is the factory deploy() transaction hash, optional.
+	DeploymentTxHash string `json:"deploymentTxHash"`
 }
 
 type collectorResponse struct {
@@ -31,6 +37,9 @@ type collectorResponse struct {
 	FactoryAddress  string `json:"factoryAddress"`
 	IsActive        bool   `json:"isActive"`
 	CreatedAt       string `json:"createdAt"`
+
+	VerificationStatus string  `json:"verificationStatus"`
+	VerifiedAt         *string `json:"verifiedAt"`
 }
 
 type nativeBalanceResponse struct {
@@ -49,6 +58,34 @@ type tokenBalanceResponse struct {
 type collectorBalanceResponse struct {
 	Native nativeBalanceResponse  `json:"native"`
 	Tokens []tokenBalanceResponse `json:"tokens"`
+
+	// Reconciliation cross-checks balances against received minus withdrawn.
+	Reconciliation []reconciliationResponse `json:"reconciliation"`
+	Discrepancy    bool                     `json:"discrepancy"`
+}
+
+type reconciliationResponse struct {
+	Contract    string  `json:"contract,omitempty"`
+	Ticker      string  `json:"ticker"`
+	Received    string  `json:"received"`
+	Withdrawn   string  `json:"withdrawn"`
+	Expected    string  `json:"expected"`
+	OnChain     *string `json:"onChain"`
+	Discrepancy string  `json:"discrepancy"`
+	Status      string  `json:"status"`
+}
+
+type withdrawalResponse struct {
+	ID            int64  `json:"id"`
+	TokenContract string `json:"tokenContract,omitempty"`
+	Ticker        string `json:"ticker"`
+	Amount        string `json:"amount"`
+	RawAmount     string `json:"rawAmount"`
+	ToAddress     string `json:"toAddress"`
+	TxHash        string `json:"txHash"`
+	TxLink        string `json:"txLink,omitempty"`
+	BlockNumber   int64  `json:"blockNumber"`
+	CreatedAt     string `json:"createdAt"`
 }
 
 // ────────────────────────────────────────────────────────────────────────────
@@ -91,8 +128,8 @@ func (h *Handler) GetEvmCollector(c echo.Context) error {
 }
 
 // SetupEvmCollector registers a new EVM smart contract collector for the merchant.
-// The contract_address should be pre-computed by the frontend using CREATE2 prediction
-// (via wagmi/viem calling factory.predictAddress) or set to the merchant's wallet address.
+// The contract must be deployed by the configured factory via deploy(ownerAddress);
+// ownership is verified on-chain before the collector is stored.
 func (h *Handler) SetupEvmCollector(c echo.Context) error {
 	var req setupCollectorRequest
 	if err := c.Bind(&req); err != nil {
@@ -115,14 +152,8 @@ func (h *Handler) SetupEvmCollector(c echo.Context) error {
 	// Get chain config from app config
 	chainCfg, ok := h.evmCollector.GetChainConfig(req.Blockchain)
 	chainID := req.ChainID
-	factoryAddress := req.FactoryAddress
-	if ok {
-		if chainCfg.ChainID != 0 {
-			chainID = chainCfg.ChainID
-		}
-		if chainCfg.FactoryAddress != "" {
-			factoryAddress = chainCfg.FactoryAddress
-		}
+	if ok && chainCfg.ChainID != 0 {
+		chainID = chainCfg.ChainID
 	}
 
 	col, err := h.evmCollector.RegisterCollector(
@@ -132,12 +163,17 @@ func (h *Handler) SetupEvmCollector(c echo.Context) error {
 		chainID,
 		req.ContractAddress,
 		req.OwnerAddress,
-		factoryAddress,
+		req.DeploymentTxHash,
 	)
 
 	switch {
 	case errors.Is(err, evmcollector.ErrAlreadyExists):
 		return common.ValidationErrorResponse(c, "collector already exists for this blockchain")
+	case errors.Is(err, evmcollector.ErrVerificationFailed):
+		return common.ValidationErrorResponse(c, err.Error())
+	case errors.Is(err, evmcollector.ErrVerificationUnavailable):
+		h.logger.Warn().Err(err).Str("blockchain", req.Blockchain).Msg("unable to verify evm collector")
+		return verificationUnavailableResponse(c)
 	case err != nil:
 		h.logger.Error().Err(err).Msg("unable to create evm collector")
 		return err
@@ -146,6 +182,13 @@ func (h *Handler) SetupEvmCollector(c echo.Context) error {
 	// No external webhook subscription needed — the internal address watcher
 	// polls EVM collector contract addresses for incoming payments.
 
+	h.recordChange(c, audit.Change{
+		MerchantID: mt.ID,
+		Action:     audit.ActionCollectorCreate,
+		ResourceID: col.UUID.String(),
+		After:      collectorAuditValues(col),
+	})
+
 	return c.JSON(http.StatusCreated, toCollectorResponse(col))
 }
 
@@ -155,7 +198,15 @@ func (h *Handler) DeleteEvmCollector(c echo.Context) error {
 	mt := middleware.ResolveMerchant(c)
 	blockchain := strings.ToUpper(c.Param("blockchain"))
 
-	err := h.evmCollector.Delete(ctx, mt.ID, blockchain)
+	col, err := h.evmCollector.GetByMerchantAndBlockchain(ctx, mt.ID, blockchain)
+	switch {
+	case errors.Is(err, evmcollector.ErrNotFound):
+		return common.NotFoundResponse(c, "evm collector not found")
+	case err != nil:
+		return err
+	}
+
+	err = h.evmCollector.Delete(ctx, mt.ID, blockchain)
 	switch {
 	case errors.Is(err, evmcollector.ErrNotFound):
 		return common.NotFoundResponse(c, "evm collector not found")
@@ -163,12 +214,20 @@ func (h *Handler) DeleteEvmCollector(c echo.Context) error {
 		return err
 	}
 
+	h.recordChange(c, audit.Change{
+		MerchantID: mt.ID,
+		Action:     audit.ActionCollectorDelete,
+		ResourceID: col.UUID.String(),
+		Before:     collectorAuditValues(col),
+	})
+
 	return c.JSON(http.StatusOK, map[string]string{"status": "deleted"})
 }
 
 // GetEvmCollectorBalance returns the collector's on-chain balance info.
 // The actual balance query requires an RPC endpoint — currently returns the contract address
 // so the frontend can query it directly via wagmi/viem.
+// Balances are cross-checked against received payments minus indexed withdrawals.
 func (h *Handler) GetEvmCollectorBalance(c echo.Context) error {
 	ctx := c.Request().Context()
 	mt := middleware.ResolveMerchant(c)
@@ -198,13 +257,74 @@ func (h *Handler) GetEvmCollectorBalance(c echo.Context) error {
 		})
 	}
 
-	return c.JSON(http.StatusOK, collectorBalanceResponse{
+	response := collectorBalanceResponse{
 		Native: nativeBalanceResponse{
 			Amount:    bal.NativeAmount,
 			Ticker:    bal.NativeTicker,
 			UsdAmount: "0",
 		},
-		Tokens: tokens,
+		Tokens:         tokens,
+		Reconciliation: []reconciliationResponse{},
+	}
+
+	// zero balances returned on RPC failure can't be reconciled
+	if err == nil {
+		recs, errRec := h.evmCollector.Reconcile(ctx, col, bal, h.blockchain)
+		if errRec != nil {
+			h.logger.Warn().Err(errRec).Int64("collector_id", col.ID).Msg("unable to reconcile evm collector balance")
+		}
+
+		for _, r := range recs {
+			response.Reconciliation = append(response.Reconciliation, toReconciliationResponse(r))
+			if r.Status == evmcollector.ReconciliationSurplus || r.Status == evmcollector.ReconciliationDeficit {
+				response.Discrepancy = true
+			}
+		}
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// ListEvmCollectorWithdrawals returns owner withdrawals from the collector
+// contract indexed from chain events, newest first.
+func (h *Handler) ListEvmCollectorWithdrawals(c echo.Context) error {
+	ctx := c.Request().Context()
+	mt := middleware.ResolveMerchant(c)
+	blockchain := strings.ToUpper(c.Param("blockchain"))
+
+	pagination, err := common.QueryPagination(c)
+	if err != nil {
+		return common.ValidationErrorResponse(c, err)
+	}
+
+	col, err := h.evmCollector.GetByMerchantAndBlockchain(ctx, mt.ID, blockchain)
+	switch {
+	case errors.Is(err, evmcollector.ErrNotFound):
+		return common.NotFoundResponse(c, "evm collector not found")
+	case err != nil:
+		return err
+	}
+
+	withdrawals, nextCursor, err := h.evmCollector.ListWithdrawals(ctx, col.ID, pagination.Limit, pagination.Cursor)
+	if err != nil {
+		return err
+	}
+
+	// collectors are mainnet-only
+	var networkID string
+	if coin, errCoin := h.blockchain.GetNativeCoin(money.Blockchain(col.Blockchain)); errCoin == nil {
+		networkID = coin.NetworkID
+	}
+
+	results := make([]withdrawalResponse, 0, len(withdrawals))
+	for _, w := range withdrawals {
+		results = append(results, toWithdrawalResponse(w, networkID))
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{
+		"results": results,
+		"cursor":  nextCursor,
+		"limit":   pagination.Limit,
 	})
 }
 
@@ -212,7 +332,57 @@ func (h *Handler) GetEvmCollectorBalance(c echo.Context) error {
 // Helpers
 // ────────────────────────────────────────────────────────────────────────────
 
+func toReconciliationResponse(r evmcollector.AssetReconciliation) reconciliationResponse {
+	var onChain *string
+	if r.OnChain != nil {
+		v := r.OnChain.String()
+		onChain = &v
+	}
+
+	return reconciliationResponse{
+		Contract:    r.TokenContract,
+		Ticker:      r.Ticker,
+		Received:    r.Received.String(),
+		Withdrawn:   r.Withdrawn.String(),
+		Expected:    r.Expected.String(),
+		OnChain:     onChain,
+		Discrepancy: r.Discrepancy.String(),
+		Status:      string(r.Status),
+	}
+}
+
+func toWithdrawalResponse(w *evmcollector.Withdrawal, networkID string) withdrawalResponse {
+	link, _ := blockchain.CreateExplorerTXLink(money.Blockchain(w.Blockchain), networkID, w.TxHash)
+
+	return withdrawalResponse{
+		ID:            w.ID,
+		TokenContract: w.TokenContract,
+		Ticker:        w.Ticker,
+		Amount:        w.Amount(),
+		RawAmount:     w.RawAmount.String(),
+		ToAddress:     w.ToAddress,
+		TxHash:        w.TxHash,
+		TxLink:        link,
+		BlockNumber:   w.BlockNumber,
+		CreatedAt:     w.CreatedAt.Format("2006-01-02T15:04:05Z"),
+	}
+}
+
+// verificationUnavailableResponse chain couldn't be queried, client should retry later.
+func verificationUnavailableResponse(c echo.Context) error {
+	return c.JSON(http.StatusServiceUnavailable, &model.ErrorResponse{
+		Message: "collector verification is unavailable, try again later",
+		Status:  "verification_unavailable",
+	})
+}
+
 func toCollectorResponse(col *evmcollector.Collector) collectorResponse {
+	var verifiedAt *string
+	if col.VerifiedAt != nil {
+		v := col.VerifiedAt.Format("2006-01-02T15:04:05Z")
+		verifiedAt = &v
+	}
+
 	return collectorResponse{
 		Blockchain:      col.Blockchain,
 		ChainID:         col.ChainID,
@@ -221,6 +391,19 @@ func toCollectorResponse(col *evmcollector.Collector) collectorResponse {
 		FactoryAddress:  col.FactoryAddress,
 		IsActive:        col.IsActive,
 		CreatedAt:       col.CreatedAt.Format("2006-01-02T15:04:05Z"),
+
+		VerificationStatus: string(col.VerificationStatus),
+		VerifiedAt:         verifiedAt,
 	}
 }
 
+
+func collectorAuditValues(col *evmcollector.Collector) audit.Values {
+	return audit.Values{
+		"blockchain":      col.Blockchain,
+		"chainId":         col.ChainID,
+		"contractAddress": col.ContractAddress,
+		"ownerAddress":    col.OwnerAddress,
+		"factoryAddress":  col.FactoryAddress,
+	}
+}
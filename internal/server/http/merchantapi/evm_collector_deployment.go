@@ -0,0 +1,188 @@
+package merchantapi
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/cryptolink/cryptolink/internal/server/http/common"
+	"github.com/cryptolink/cryptolink/internal/server/http/middleware"
+	"github.com/cryptolink/cryptolink/internal/service/audit"
+	"github.com/cryptolink/cryptolink/internal/service/evmcollector"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+)
+
+// ────────────────────────────────────────────────────────────────────────────
+// Request / Response types
+// ────────────────────────────────────────────────────────────────────────────
+
+type prepareDeploymentRequest struct {
+	Blockchain   string `json:"blockchain"`
+	ChainID      int    `json:"chainId"`
+	OwnerAddress string `json:"ownerAddress"`
+}
+
+type deploymentTxHashRequest struct {
+	TxHash string `json:"txHash"`
+}
+
+type deploymentTransactionResponse struct {
+	To               string `json:"to"`
+	Data             string `json:"data"`
+	Value            string `json:"value"`
+	FunctionSelector string `json:"functionSelector"`
+	Parameter        string `json:"parameter"`
+}
+
+type deploymentResponse struct {
+	ID              string                        `json:"id"`
+	Blockchain      string                        `json:"blockchain"`
+	ChainID         int                           `json:"chainId"`
+	Status          string                        `json:"status"`
+	OwnerAddress    string                        `json:"ownerAddress"`
+	FactoryAddress  string                        `json:"factoryAddress"`
+	Transaction     deploymentTransactionResponse `json:"transaction"`
+	TxHash          string                        `json:"txHash,omitempty"`
+	ContractAddress string                        `json:"contractAddress,omitempty"`
+	Error           string                        `json:"error,omitempty"`
+	CreatedAt       string                        `json:"createdAt"`
+	ExpiresAt       string                        `json:"expiresAt"`
+}
+
+// ────────────────────────────────────────────────────────────────────────────
+// Handlers
+// ────────────────────────────────────────────────────────────────────────────
+
+// PrepareEvmCollectorDeployment returns unsigned factory deploy(owner) transaction
+// for the merchant's wallet to sign. Once CloneCreated is observed on-chain,
+// the collector is registered automatically.
+func (h *Handler) PrepareEvmCollectorDeployment(c echo.Context) error {
+	var req prepareDeploymentRequest
+	if err := c.Bind(&req); err != nil {
+		return common.ValidationErrorResponse(c, "invalid request body")
+	}
+
+	if req.Blockchain == "" {
+		return common.ValidationErrorItemResponse(c, "blockchain", "blockchain is required")
+	}
+	if req.OwnerAddress == "" {
+		return common.ValidationErrorItemResponse(c, "owner_address", "owner_address is required")
+	}
+
+	ctx := c.Request().Context()
+	mt := middleware.ResolveMerchant(c)
+
+	chainID := req.ChainID
+	if chainCfg, ok := h.evmCollector.GetChainConfig(req.Blockchain); ok && chainCfg.ChainID != 0 {
+		chainID = chainCfg.ChainID
+	}
+
+	d, err := h.evmCollector.PrepareDeployment(ctx, mt.ID, req.Blockchain, chainID, req.OwnerAddress)
+
+	switch {
+	case errors.Is(err, evmcollector.ErrAlreadyExists):
+		return common.ValidationErrorResponse(c, "collector already exists for this blockchain")
+	case errors.Is(err, evmcollector.ErrInvalidAddress):
+		return common.ValidationErrorItemResponse(c, "owner_address", "invalid address")
+	case errors.Is(err, evmcollector.ErrVerificationUnavailable):
+		h.logger.Warn().Err(err).Str("blockchain", req.Blockchain).Msg("unable to prepare evm collector deployment")
+		return verificationUnavailableResponse(c)
+	case err != nil:
+		h.logger.Error().Err(err).Msg("unable to prepare evm collector deployment")
+		return err
+	}
+
+	h.recordChange(c, audit.Change{
+		MerchantID: mt.ID,
+		Action:     audit.ActionCollectorDeploy,
+		ResourceID: d.UUID.String(),
+		After: audit.Values{
+			"blockchain":     d.Blockchain,
+			"chainId":        d.ChainID,
+			"ownerAddress":   d.OwnerAddress,
+			"factoryAddress": d.FactoryAddress,
+		},
+	})
+
+	return c.JSON(http.StatusCreated, toDeploymentResponse(d))
+}
+
+// GetEvmCollectorDeployment returns the latest collector deployment for the chain.
+func (h *Handler) GetEvmCollectorDeployment(c echo.Context) error {
+	ctx := c.Request().Context()
+	mt := middleware.ResolveMerchant(c)
+	blockchain := strings.ToUpper(c.Param("blockchain"))
+
+	d, err := h.evmCollector.GetDeployment(ctx, mt.ID, blockchain)
+	switch {
+	case errors.Is(err, evmcollector.ErrDeploymentNotFound):
+		return common.NotFoundResponse(c, "evm collector deployment not found")
+	case err != nil:
+		return err
+	}
+
+	return c.JSON(http.StatusOK, toDeploymentResponse(d))
+}
+
+// SetEvmCollectorDeploymentTx attaches the signed transaction hash to a pending
+// deployment which speeds up CloneCreated lookup.
+func (h *Handler) SetEvmCollectorDeploymentTx(c echo.Context) error {
+	var req deploymentTxHashRequest
+	if err := c.Bind(&req); err != nil {
+		return common.ValidationErrorResponse(c, "invalid request body")
+	}
+
+	if req.TxHash == "" {
+		return common.ValidationErrorItemResponse(c, "tx_hash", "tx_hash is required")
+	}
+
+	ctx := c.Request().Context()
+	mt := middleware.ResolveMerchant(c)
+	blockchain := strings.ToUpper(c.Param("blockchain"))
+
+	d, err := h.evmCollector.SetDeploymentTxHash(ctx, mt.ID, blockchain, req.TxHash)
+	switch {
+	case errors.Is(err, evmcollector.ErrDeploymentNotFound):
+		return common.NotFoundResponse(c, "pending evm collector deployment not found")
+	case err != nil:
+		return err
+	}
+
+	h.recordChange(c, audit.Change{
+		MerchantID: mt.ID,
+		Action:     audit.ActionCollectorDeployTx,
+		ResourceID: d.UUID.String(),
+		After:      audit.Values{"blockchain": d.Blockchain, "txHash": d.TxHash},
+	})
+
+	return c.JSON(http.StatusOK, toDeploymentResponse(d))
+}
+
+// ────────────────────────────────────────────────────────────────────────────
+// Helpers
+// ────────────────────────────────────────────────────────────────────────────
+
+func toDeploymentResponse(d *evmcollector.Deployment) deploymentResponse {
+	tx := d.Transaction()
+
+	return deploymentResponse{
+		ID:             d.UUID.String(),
+		Blockchain:     d.Blockchain,
+		ChainID:        d.ChainID,
+		Status:         string(d.Status),
+		OwnerAddress:   d.OwnerAddress,
+		FactoryAddress: d.FactoryAddress,
+		Transaction: deploymentTransactionResponse{
+			To:               tx.To,
+			Data:             tx.Data,
+			Value:            tx.Value,
+			FunctionSelector: tx.FunctionSelector,
+			Parameter:        tx.Parameter,
+		},
+		TxHash:          d.TxHash,
+		ContractAddress: d.ContractAddress,
+		Error:           d.Error,
+		CreatedAt:       d.CreatedAt.Format("2006-01-02T15:04:05Z"),
+		ExpiresAt:       d.ExpiresAt.Format("2006-01-02T15:04:05Z"),
+	}
+}
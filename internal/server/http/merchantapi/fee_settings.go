@@ -7,6 +7,7 @@ import (
 	"github.com/cryptolink/cryptolink/internal/money"
 	"github.com/cryptolink/cryptolink/internal/server/http/common"
 	"github.com/cryptolink/cryptolink/internal/server/http/middleware"
+	"github.com/cryptolink/cryptolink/internal/service/audit"
 	"github.com/cryptolink/cryptolink/internal/service/merchant"
 )
 
@@ -40,6 +41,7 @@ func (h *Handler) UpdateFeeSettings(c echo.Context) error {
 
 	ctx := c.Request().Context()
 	mt := middleware.ResolveMerchant(c)
+	before := feeSettingsAuditValues(mt.Settings())
 
 	settings := merchant.Settings{}
 	if req.PreferredCurrency != "" {
@@ -56,9 +58,23 @@ func (h *Handler) UpdateFeeSettings(c echo.Context) error {
 		return common.ErrorResponse(c, "internal_error")
 	}
 
+	h.recordChange(c, audit.Change{
+		MerchantID: mt.ID,
+		Action:     audit.ActionFeeSettingsUpdate,
+		Before:     before,
+		After:      feeSettingsAuditValues(mt.Settings()),
+	})
+
 	return c.NoContent(http.StatusNoContent)
 }
 
+func feeSettingsAuditValues(s merchant.Settings) audit.Values {
+	return audit.Values{
+		"preferredCurrency":   s.FiatCurrency(),
+		"globalFeePercentage": s[merchant.Property("fee.global")],
+	}
+}
+
 // ListFiatCurrencies returns all supported fiat currencies with their symbols.
 func (h *Handler) ListFiatCurrencies(c echo.Context) error {
 	currencies := money.SupportedFiatCurrencies()
@@ -3,13 +3,18 @@ package merchantapi
 import (
 	"github.com/cryptolink/cryptolink/internal/auth"
 	"github.com/cryptolink/cryptolink/internal/bus"
+	"github.com/cryptolink/cryptolink/internal/service/audit"
+	"github.com/cryptolink/cryptolink/internal/service/billing"
 	"github.com/cryptolink/cryptolink/internal/service/blockchain"
+	"github.com/cryptolink/cryptolink/internal/service/email"
 	"github.com/cryptolink/cryptolink/internal/service/evmcollector"
+	"github.com/cryptolink/cryptolink/internal/service/ledger"
 	"github.com/cryptolink/cryptolink/internal/service/merchant"
 	"github.com/cryptolink/cryptolink/internal/service/payment"
 	"github.com/cryptolink/cryptolink/internal/service/processing"
 	"github.com/cryptolink/cryptolink/internal/service/subscription"
 	"github.com/cryptolink/cryptolink/internal/service/wallet"
+	"github.com/cryptolink/cryptolink/internal/service/webhooks"
 	"github.com/cryptolink/cryptolink/internal/service/xpub"
 	"github.com/rs/zerolog"
 )
@@ -28,6 +33,11 @@ type Handler struct {
 	xpubService     *xpub.Service
 	evmCollector    *evmcollector.Service
 	subscriptions   *subscription.Service
+	billing         *billing.Service
+	ledger          *ledger.Service
+	webhooks        *webhooks.Service
+	emails          *email.Service
+	audit           *audit.Service
 	blockchain      BlockchainService
 	publisher       bus.Publisher
 	logger          *zerolog.Logger
@@ -42,6 +52,11 @@ func NewHandler(
 	xpubService *xpub.Service,
 	evmCollectorService *evmcollector.Service,
 	subscriptionService *subscription.Service,
+	billingService *billing.Service,
+	ledgerService *ledger.Service,
+	webhookService *webhooks.Service,
+	emailService *email.Service,
+	auditService *audit.Service,
 	blockchainService BlockchainService,
 	publisher bus.Publisher,
 	logger *zerolog.Logger,
@@ -57,6 +72,11 @@ func NewHandler(
 		xpubService:     xpubService,
 		evmCollector:    evmCollectorService,
 		subscriptions:   subscriptionService,
+		billing:         billingService,
+		ledger:          ledgerService,
+		webhooks:        webhookService,
+		emails:          emailService,
+		audit:           auditService,
 		blockchain:      blockchainService,
 		publisher:       publisher,
 		logger:          &log,
@@ -0,0 +1,409 @@
+package merchantapi
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/cryptolink/cryptolink/internal/server/http/common"
+	"github.com/cryptolink/cryptolink/internal/server/http/middleware"
+	"github.com/cryptolink/cryptolink/internal/service/ledger"
+	"github.com/cryptolink/cryptolink/internal/service/merchant"
+	"github.com/cryptolink/cryptolink/internal/service/payment"
+	"github.com/cryptolink/cryptolink/internal/util"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	queryParamTest = "test"
+
+	ledgerEntriesLimitDefault = 100
+	ledgerEntriesLimitMax     = 500
+)
+
+type ledgerBalanceResponse struct {
+	Account      string `json:"account"`
+	Ticker       string `json:"ticker"`
+	FiatCurrency string `json:"fiatCurrency"`
+	Opening      string `json:"opening"`
+	Debits       string `json:"debits"`
+	Credits      string `json:"credits"`
+	Closing      string `json:"closing"`
+	OpeningFiat  string `json:"openingFiat"`
+	DebitsFiat   string `json:"debitsFiat"`
+	CreditsFiat  string `json:"creditsFiat"`
+	ClosingFiat  string `json:"closingFiat"`
+}
+
+type ledgerTotalResponse struct {
+	EventType    string `json:"eventType"`
+	Ticker       string `json:"ticker"`
+	FiatCurrency string `json:"fiatCurrency"`
+	Events       int64  `json:"events"`
+	Amount       string `json:"amount"`
+	FiatAmount   string `json:"fiatAmount"`
+	Unvalued     int64  `json:"unvalued"`
+}
+
+type ledgerEntryResponse struct {
+	ID             int64   `json:"id"`
+	EventKey       string  `json:"eventKey"`
+	EventType      string  `json:"eventType"`
+	Line           int     `json:"line"`
+	Account        string  `json:"account"`
+	Side           string  `json:"side"`
+	Ticker         string  `json:"ticker"`
+	Amount         string  `json:"amount"`
+	FiatCurrency   string  `json:"fiatCurrency"`
+	FiatAmount     *string `json:"fiatAmount"`
+	Rate           *string `json:"rate,omitempty"`
+	RateSource     string  `json:"rateSource,omitempty"`
+	RateSnapshotID *int64  `json:"rateSnapshotId,omitempty"`
+	TransactionID  *int64  `json:"transactionId,omitempty"`
+	PaymentID      string  `json:"paymentId,omitempty"`
+	OrderID        string  `json:"orderId,omitempty"`
+	TxHash         string  `json:"txHash,omitempty"`
+	ReversesKey    string  `json:"reversesKey,omitempty"`
+	Description    string  `json:"description"`
+	IsTest         bool    `json:"isTest"`
+	OccurredAt     string  `json:"occurredAt"`
+}
+
+type ledgerEntriesPagination struct {
+	Cursor  string                 `json:"cursor"`
+	Limit   int64                  `json:"limit"`
+	Results []*ledgerEntryResponse `json:"results"`
+}
+
+type ledgerRefundRequest struct {
+	PaymentID string `json:"paymentId"`
+	Amount    string `json:"amount"`
+	TxHash    string `json:"txHash"`
+	Reason    string `json:"reason"`
+}
+
+// GetLedgerBalances returns merchant's ledger account balances for a date range.
+func (h *Handler) GetLedgerBalances(c echo.Context) error {
+	r, err := h.ledgerRange(c)
+	if err != nil || r == nil {
+		return err
+	}
+
+	balances, err := h.ledger.Balances(c.Request().Context(), *r)
+	if err != nil {
+		h.logger.Error().Err(err).Int64("merchant_id", r.MerchantID).Msg("unable to get ledger balances")
+		return common.ErrorResponse(c, "internal_error")
+	}
+
+	return c.JSON(http.StatusOK, map[string]any{"results": util.MapSlice(balances, ledgerBalanceToResponse)})
+}
+
+// GetLedgerTotals returns merchant's realized totals per event type for a date range.
+func (h *Handler) GetLedgerTotals(c echo.Context) error {
+	r, err := h.ledgerRange(c)
+	if err != nil || r == nil {
+		return err
+	}
+
+	totals, err := h.ledger.Totals(c.Request().Context(), *r)
+	if err != nil {
+		h.logger.Error().Err(err).Int64("merchant_id", r.MerchantID).Msg("unable to get ledger totals")
+		return common.ErrorResponse(c, "internal_error")
+	}
+
+	return c.JSON(http.StatusOK, map[string]any{"results": util.MapSlice(totals, ledgerTotalToResponse)})
+}
+
+// ListLedgerEntries paginates merchant's journal lines for a date range.
+func (h *Handler) ListLedgerEntries(c echo.Context) error {
+	r, err := h.ledgerRange(c)
+	if err != nil || r == nil {
+		return err
+	}
+
+	limit := ledgerEntriesLimitDefault
+	if raw := c.QueryParam(common.ParamQueryLimit); raw != "" {
+		limit, err = strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > ledgerEntriesLimitMax {
+			return common.ValidationErrorItemResponse(c, common.ParamQueryLimit, "limit should be between 1 and %d", ledgerEntriesLimitMax)
+		}
+	}
+
+	var afterID int64
+	if raw := c.QueryParam(common.ParamQueryCursor); raw != "" {
+		afterID, err = strconv.ParseInt(raw, 10, 64)
+		if err != nil || afterID < 0 {
+			return common.ValidationErrorItemResponse(c, common.ParamQueryCursor, "invalid cursor")
+		}
+	}
+
+	entries, err := h.ledger.ListEntries(c.Request().Context(), *r, afterID, limit)
+	if err != nil {
+		h.logger.Error().Err(err).Int64("merchant_id", r.MerchantID).Msg("unable to list ledger entries")
+		return common.ErrorResponse(c, "internal_error")
+	}
+
+	var cursor string
+	if len(entries) == limit {
+		cursor = strconv.FormatInt(entries[len(entries)-1].ID, 10)
+	}
+
+	return c.JSON(http.StatusOK, &ledgerEntriesPagination{
+		Cursor:  cursor,
+		Limit:   int64(limit),
+		Results: util.MapSlice(entries, ledgerEntryToResponse),
+	})
+}
+
+// CreateLedgerRefund records refund sent to the customer outside of the platform.
+func (h *Handler) CreateLedgerRefund(c echo.Context) error {
+	var req ledgerRefundRequest
+	if err := c.Bind(&req); err != nil {
+		return common.ValidationErrorResponse(c, "invalid request body")
+	}
+
+	paymentUUID, err := uuid.Parse(req.PaymentID)
+	if err != nil {
+		return common.ValidationErrorItemResponse(c, "paymentId", "invalid payment id")
+	}
+
+	if len(req.TxHash) > 128 {
+		return common.ValidationErrorItemResponse(c, "txHash", "transaction hash is too long")
+	}
+
+	if len(req.Reason) > 256 {
+		return common.ValidationErrorItemResponse(c, "reason", "reason should be at most 256 characters")
+	}
+
+	ctx := c.Request().Context()
+	mt := middleware.ResolveMerchant(c)
+
+	pt, err := h.payments.GetByMerchantOrderID(ctx, mt.ID, paymentUUID)
+	switch {
+	case errors.Is(err, payment.ErrNotFound):
+		return common.NotFoundResponse(c, "payment not found")
+	case err != nil:
+		return err
+	}
+
+	entries, err := h.ledger.RecordRefund(ctx, ledger.RefundParams{
+		MerchantID: mt.ID,
+		PaymentID:  pt.ID,
+		Amount:     req.Amount,
+		TxHash:     req.TxHash,
+		Reason:     req.Reason,
+	})
+
+	switch {
+	case errors.Is(err, ledger.ErrNotFound):
+		return common.NotFoundResponse(c, "payment has no transaction")
+	case errors.Is(err, ledger.ErrInvalidRefund):
+		return common.ValidationErrorItemResponse(c, "amount", "%s", err.Error())
+	case errors.Is(err, ledger.ErrAlreadyRecorded):
+		return common.ValidationErrorItemResponse(c, "txHash", "refund with this transaction hash is already recorded")
+	case err != nil:
+		h.logger.Error().Err(err).Int64("merchant_id", mt.ID).Int64("payment_id", pt.ID).Msg("unable to record refund")
+		return common.ErrorResponse(c, "internal_error")
+	}
+
+	return c.JSON(http.StatusCreated, map[string]any{"results": util.MapSlice(entries, ledgerEntryToResponse)})
+}
+
+// ExportLedger exports merchant's ledger journals for a date range as CSV
+// in accounting software format (?format=xero|quickbooks|journal).
+func (h *Handler) ExportLedger(c echo.Context) error {
+	format, err := ledger.ParseExportFormat(c.QueryParam("format"))
+	if err != nil {
+		return common.ValidationErrorItemResponse(c, "format", "format should be one of xero, quickbooks, journal")
+	}
+
+	r, err := h.ledgerRange(c)
+	if err != nil || r == nil {
+		return err
+	}
+
+	mt := middleware.ResolveMerchant(c)
+	names := ledger.NewAccountNames(mt.Settings().AccountingAccounts())
+
+	filename := fmt.Sprintf(
+		"ledger-%s-%s-%s.csv",
+		format,
+		r.From.Format(rateExportDateLayout),
+		r.To.Add(-24*time.Hour).Format(rateExportDateLayout),
+	)
+
+	c.Response().Header().Set(echo.HeaderContentType, "text/csv")
+	c.Response().Header().Set(echo.HeaderContentDisposition, "attachment; filename="+filename)
+	c.Response().WriteHeader(http.StatusOK)
+
+	// headers are already sent, so failure can only be logged
+	if err := h.ledger.Export(c.Request().Context(), c.Response().Writer, *r, format, names); err != nil {
+		h.logger.Error().Err(err).Int64("merchant_id", mt.ID).Str("format", string(format)).Msg("unable to export ledger")
+	}
+
+	return nil
+}
+
+type accountingAccountResponse struct {
+	Account     string `json:"account"`
+	Name        string `json:"name"`
+	DefaultName string `json:"defaultName"`
+}
+
+type updateAccountingAccountsRequest struct {
+	// Accounts ledger account => name, empty name resets to default.
+	Accounts map[string]string `json:"accounts"`
+}
+
+// GetAccountingAccounts returns names of ledger accounts used in accounting exports.
+func (h *Handler) GetAccountingAccounts(c echo.Context) error {
+	mt := middleware.ResolveMerchant(c)
+
+	return c.JSON(http.StatusOK, map[string]any{"results": accountingAccountsToResponse(mt)})
+}
+
+// UpdateAccountingAccounts renames ledger accounts in accounting exports.
+func (h *Handler) UpdateAccountingAccounts(c echo.Context) error {
+	var req updateAccountingAccountsRequest
+	if err := c.Bind(&req); err != nil {
+		return common.ValidationErrorResponse(c, "invalid request body")
+	}
+
+	for account := range req.Accounts {
+		if _, ok := ledger.DefaultAccountNames[ledger.Account(account)]; !ok {
+			return common.ValidationErrorItemResponse(c, "accounts", "unknown account %q", account)
+		}
+	}
+
+	ctx := c.Request().Context()
+	mt := middleware.ResolveMerchant(c)
+
+	err := h.merchants.UpdateAccountingAccounts(ctx, mt, req.Accounts)
+	switch {
+	case errors.Is(err, merchant.ErrInvalidAccountingAccounts):
+		return common.ValidationErrorItemResponse(c, "accounts", "%s", err.Error())
+	case err != nil:
+		h.logger.Error().Err(err).Int64("merchant_id", mt.ID).Msg("failed to update accounting accounts")
+		return common.ErrorResponse(c, "internal_error")
+	}
+
+	return c.JSON(http.StatusOK, map[string]any{"results": accountingAccountsToResponse(mt)})
+}
+
+func accountingAccountsToResponse(mt *merchant.Merchant) []accountingAccountResponse {
+	names := ledger.NewAccountNames(mt.Settings().AccountingAccounts())
+
+	results := make([]accountingAccountResponse, 0, len(ledger.Accounts))
+	for _, account := range ledger.Accounts {
+		results = append(results, accountingAccountResponse{
+			Account:     string(account),
+			Name:        names[account],
+			DefaultName: ledger.DefaultAccountNames[account],
+		})
+	}
+
+	return results
+}
+
+// ledgerRange resolves date range query. Returns nil range when validation
+// response has been written.
+func (h *Handler) ledgerRange(c echo.Context) (*ledger.Range, error) {
+	from, to, rangeErr := queryDateRange(c)
+	if rangeErr != nil {
+		return nil, common.ValidationErrorItemResponse(c, rangeErr.field, "%s", rangeErr.message)
+	}
+
+	var isTest bool
+	if raw := c.QueryParam(queryParamTest); raw != "" {
+		v, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, common.ValidationErrorItemResponse(c, queryParamTest, "should be a boolean")
+		}
+
+		isTest = v
+	}
+
+	return &ledger.Range{
+		MerchantID: middleware.ResolveMerchant(c).ID,
+		IsTest:     isTest,
+		From:       from,
+		To:         to,
+	}, nil
+}
+
+func ledgerBalanceToResponse(b *ledger.AccountBalance) *ledgerBalanceResponse {
+	return &ledgerBalanceResponse{
+		Account:      string(b.Account),
+		Ticker:       b.Ticker,
+		FiatCurrency: b.FiatCurrency,
+		Opening:      b.Opening.String(),
+		Debits:       b.Debits.String(),
+		Credits:      b.Credits.String(),
+		Closing:      b.Closing.String(),
+		OpeningFiat:  b.OpeningFiat.StringFixed(2),
+		DebitsFiat:   b.DebitsFiat.StringFixed(2),
+		CreditsFiat:  b.CreditsFiat.StringFixed(2),
+		ClosingFiat:  b.ClosingFiat.StringFixed(2),
+	}
+}
+
+func ledgerTotalToResponse(t *ledger.EventTotal) *ledgerTotalResponse {
+	return &ledgerTotalResponse{
+		EventType:    string(t.EventType),
+		Ticker:       t.Ticker,
+		FiatCurrency: t.FiatCurrency,
+		Events:       t.Events,
+		Amount:       t.Amount.String(),
+		FiatAmount:   t.FiatAmount.StringFixed(2),
+		Unvalued:     t.Unvalued,
+	}
+}
+
+func ledgerEntryToResponse(e *ledger.Entry) *ledgerEntryResponse {
+	res := &ledgerEntryResponse{
+		ID:             e.ID,
+		EventKey:       e.EventKey,
+		EventType:      string(e.EventType),
+		Line:           e.Line,
+		Account:        string(e.Account),
+		Side:           string(e.Side),
+		Ticker:         e.Ticker,
+		Amount:         e.Amount.String(),
+		FiatCurrency:   e.FiatCurrency,
+		FiatAmount:     decimalToStringPtr(e.FiatAmount, 2),
+		Rate:           decimalToStringPtr(e.Rate, -1),
+		RateSource:     e.RateSource,
+		RateSnapshotID: e.RateSnapshotID,
+		TransactionID:  e.TransactionID,
+		OrderID:        e.OrderID,
+		TxHash:         e.TxHash,
+		ReversesKey:    e.ReversesKey,
+		Description:    e.Description,
+		IsTest:         e.IsTest,
+		OccurredAt:     e.OccurredAt.UTC().Format(time.RFC3339),
+	}
+
+	if e.PaymentUUID != nil {
+		res.PaymentID = e.PaymentUUID.String()
+	}
+
+	return res
+}
+
+// decimalToStringPtr formats decimal with fixed places; places < 0 keeps full precision.
+func decimalToStringPtr(d *decimal.Decimal, places int32) *string {
+	if d == nil {
+		return nil
+	}
+
+	s := d.String()
+	if places >= 0 {
+		s = d.StringFixed(places)
+	}
+
+	return &s
+}
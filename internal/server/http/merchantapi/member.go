@@ -0,0 +1,325 @@
+package merchantapi
+
+import (
+	"context"
+	"net/http"
+	"net/mail"
+	"time"
+
+	"github.com/cryptolink/cryptolink/internal/server/http/common"
+	"github.com/cryptolink/cryptolink/internal/server/http/middleware"
+	"github.com/cryptolink/cryptolink/internal/service/audit"
+	"github.com/cryptolink/cryptolink/internal/service/merchant"
+	"github.com/cryptolink/cryptolink/pkg/api-dashboard/v1/model"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+)
+
+const (
+	paramMemberID     = "userId"
+	paramInvitationID = "invitationId"
+)
+
+type memberResponse struct {
+	ID          string                `json:"id"`
+	Email       string                `json:"email"`
+	Name        string                `json:"name"`
+	Role        merchant.Role         `json:"role"`
+	Permissions []merchant.Permission `json:"permissions"`
+	CreatedAt   string                `json:"createdAt"`
+}
+
+type invitationResponse struct {
+	ID        string        `json:"id"`
+	Email     string        `json:"email"`
+	Role      merchant.Role `json:"role"`
+	ExpiresAt string        `json:"expiresAt"`
+	CreatedAt string        `json:"createdAt"`
+}
+
+type updateMemberRequest struct {
+	Role string `json:"role"`
+}
+
+type inviteMemberRequest struct {
+	Email string `json:"email"`
+	Role  string `json:"role"`
+}
+
+type acceptInvitationRequest struct {
+	Token string `json:"token"`
+}
+
+// ListMembers handles GET /merchant/:merchantId/member.
+func (h *Handler) ListMembers(c echo.Context) error {
+	mt := middleware.ResolveMerchant(c)
+
+	members, err := h.merchants.ListMembers(c.Request().Context(), mt.ID)
+	if err != nil {
+		h.logger.Error().Err(err).Int64("merchant_id", mt.ID).Msg("unable to list members")
+		return common.ErrorResponse(c, "internal_error")
+	}
+
+	results := make([]*memberResponse, len(members))
+	for i, m := range members {
+		results[i] = memberToResponse(m)
+	}
+
+	return c.JSON(http.StatusOK, map[string]any{"results": results})
+}
+
+// UpdateMember handles PUT /merchant/:merchantId/member/:userId. Changes member's role.
+func (h *Handler) UpdateMember(c echo.Context) error {
+	var req updateMemberRequest
+	if err := c.Bind(&req); err != nil {
+		return common.ValidationErrorResponse(c, "invalid request")
+	}
+
+	role, err := merchant.ParseRole(req.Role)
+	if err != nil {
+		return common.ValidationErrorItemResponse(c, "role", "%s", err.Error())
+	}
+
+	member, err := h.resolveMember(c)
+	if member == nil {
+		return err
+	}
+
+	actorRole, _ := middleware.ResolveMemberRole(c)
+
+	updated, err := h.merchants.UpdateMemberRole(c.Request().Context(), actorRole, member, role)
+	if err != nil {
+		return h.memberErrorResponse(c, err)
+	}
+
+	h.recordChange(c, audit.Change{
+		MerchantID: member.MerchantID,
+		Action:     audit.ActionMemberUpdate,
+		ResourceID: member.UserUUID.String(),
+		Before:     memberAuditValues(member),
+		After:      memberAuditValues(updated),
+	})
+
+	return c.JSON(http.StatusOK, memberToResponse(updated))
+}
+
+// DeleteMember handles DELETE /merchant/:merchantId/member/:userId. Members with
+// PermissionMembers remove others, any member can leave the merchant.
+func (h *Handler) DeleteMember(c echo.Context) error {
+	member, err := h.resolveMember(c)
+	if member == nil {
+		return err
+	}
+
+	actorRole, _ := middleware.ResolveMemberRole(c)
+	isSelf := middleware.ResolveUser(c).ID == member.UserID
+
+	if !isSelf && !actorRole.Can(merchant.PermissionMembers) {
+		return c.JSON(http.StatusForbidden, &model.ErrorResponse{
+			Message: "Your role doesn't allow this action",
+			Status:  "insufficient_role",
+		})
+	}
+
+	// leaving member acts with own role
+	if isSelf {
+		actorRole = member.Role
+	}
+
+	if err := h.merchants.RemoveMember(c.Request().Context(), actorRole, member); err != nil {
+		return h.memberErrorResponse(c, err)
+	}
+
+	h.recordChange(c, audit.Change{
+		MerchantID: member.MerchantID,
+		Action:     audit.ActionMemberRemove,
+		ResourceID: member.UserUUID.String(),
+		Before:     memberAuditValues(member),
+	})
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// ListInvitations handles GET /merchant/:merchantId/invitation. Lists pending invitations.
+func (h *Handler) ListInvitations(c echo.Context) error {
+	mt := middleware.ResolveMerchant(c)
+
+	invitations, err := h.merchants.ListInvitations(c.Request().Context(), mt.ID)
+	if err != nil {
+		h.logger.Error().Err(err).Int64("merchant_id", mt.ID).Msg("unable to list invitations")
+		return common.ErrorResponse(c, "internal_error")
+	}
+
+	results := make([]*invitationResponse, len(invitations))
+	for i, inv := range invitations {
+		results[i] = invitationToResponse(inv)
+	}
+
+	return c.JSON(http.StatusOK, map[string]any{"results": results})
+}
+
+// CreateInvitation handles POST /merchant/:merchantId/invitation. Emails an
+// invitation link to join the merchant.
+func (h *Handler) CreateInvitation(c echo.Context) error {
+	var req inviteMemberRequest
+	if err := c.Bind(&req); err != nil {
+		return common.ValidationErrorResponse(c, "invalid request")
+	}
+
+	if _, err := mail.ParseAddress(req.Email); err != nil {
+		return common.ValidationErrorItemResponse(c, "email", "invalid email")
+	}
+
+	role, err := merchant.ParseRole(req.Role)
+	if err != nil {
+		return common.ValidationErrorItemResponse(c, "role", "%s", err.Error())
+	}
+
+	if h.emails == nil {
+		return common.ErrorResponse(c, "email service not available")
+	}
+
+	mt := middleware.ResolveMerchant(c)
+	person := middleware.ResolveUser(c)
+	actorRole, _ := middleware.ResolveMemberRole(c)
+
+	invitation, token, err := h.merchants.InviteMember(c.Request().Context(), merchant.InviteMemberParams{
+		MerchantID:    mt.ID,
+		Email:         req.Email,
+		Role:          role,
+		InvitedBy:     person.ID,
+		InvitedByRole: actorRole,
+	})
+	if err != nil {
+		return h.memberErrorResponse(c, err)
+	}
+
+	h.recordChange(c, audit.Change{
+		MerchantID: mt.ID,
+		Action:     audit.ActionMemberInvite,
+		ResourceID: invitation.UUID.String(),
+		After:      audit.Values{"email": invitation.Email, "role": string(invitation.Role)},
+	})
+
+	go h.emails.SendMerchantInvitationEmail(
+		context.Background(),
+		invitation.Email,
+		mt.Name,
+		person.Name,
+		string(invitation.Role),
+		token,
+		merchant.InvitationTTL,
+	)
+
+	return c.JSON(http.StatusCreated, invitationToResponse(invitation))
+}
+
+// DeleteInvitation handles DELETE /merchant/:merchantId/invitation/:invitationId.
+func (h *Handler) DeleteInvitation(c echo.Context) error {
+	id, err := common.UUID(c, paramInvitationID)
+	if err != nil {
+		return nil
+	}
+
+	mt := middleware.ResolveMerchant(c)
+
+	switch err := h.merchants.RevokeInvitation(c.Request().Context(), mt.ID, id); {
+	case errors.Is(err, merchant.ErrInvitationNotFound):
+		return common.NotFoundResponse(c, err.Error())
+	case err != nil:
+		return errors.Wrap(err, "unable to revoke invitation")
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// AcceptInvitation handles POST /invitation/accept. Adds current user to the
+// merchant if the invitation was sent to user's email.
+func (h *Handler) AcceptInvitation(c echo.Context) error {
+	var req acceptInvitationRequest
+	if err := c.Bind(&req); err != nil {
+		return common.ValidationErrorResponse(c, "invalid request")
+	}
+
+	if req.Token == "" {
+		return common.ValidationErrorItemResponse(c, "token", "token is required")
+	}
+
+	person := middleware.ResolveUser(c)
+
+	mt, err := h.merchants.AcceptInvitation(c.Request().Context(), req.Token, person.ID, person.Email)
+	switch {
+	case errors.Is(err, merchant.ErrInvitationNotFound):
+		return common.ValidationErrorItemResponse(c, "token", "Invitation is invalid, expired or sent to another email")
+	case err != nil:
+		return errors.Wrap(err, "unable to accept invitation")
+	}
+
+	return c.JSON(http.StatusOK, &model.MerchantListItem{
+		ID:      mt.UUID.String(),
+		Name:    mt.Name,
+		Website: mt.Website,
+	})
+}
+
+// resolveMember returns member from the path. Returns nil member if the
+// response is already written.
+func (h *Handler) resolveMember(c echo.Context) (*merchant.Member, error) {
+	userUUID, err := common.UUID(c, paramMemberID)
+	if err != nil {
+		return nil, nil
+	}
+
+	mt := middleware.ResolveMerchant(c)
+
+	member, err := h.merchants.GetMember(c.Request().Context(), mt.ID, userUUID)
+	switch {
+	case errors.Is(err, merchant.ErrMemberNotFound):
+		return nil, common.NotFoundResponse(c, err.Error())
+	case err != nil:
+		return nil, errors.Wrap(err, "unable to get member")
+	}
+
+	return member, nil
+}
+
+func (h *Handler) memberErrorResponse(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, merchant.ErrOwnerRequired):
+		return c.JSON(http.StatusForbidden, &model.ErrorResponse{
+			Message: err.Error(),
+			Status:  "insufficient_role",
+		})
+	case errors.Is(err, merchant.ErrInvalidRole):
+		return common.ValidationErrorItemResponse(c, "role", "%s", err.Error())
+	case errors.Is(err, merchant.ErrLastOwner), errors.Is(err, merchant.ErrAlreadyMember):
+		return common.ValidationErrorResponse(c, err.Error())
+	default:
+		return err
+	}
+}
+
+func memberAuditValues(m *merchant.Member) audit.Values {
+	return audit.Values{"email": m.Email, "role": string(m.Role)}
+}
+
+func memberToResponse(m *merchant.Member) *memberResponse {
+	return &memberResponse{
+		ID:          m.UserUUID.String(),
+		Email:       m.Email,
+		Name:        m.Name,
+		Role:        m.Role,
+		Permissions: m.Role.Permissions(),
+		CreatedAt:   m.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+func invitationToResponse(inv *merchant.Invitation) *invitationResponse {
+	return &invitationResponse{
+		ID:        inv.UUID.String(),
+		Email:     inv.Email,
+		Role:      inv.Role,
+		ExpiresAt: inv.ExpiresAt.Format(time.RFC3339),
+		CreatedAt: inv.CreatedAt.Format(time.RFC3339),
+	}
+}
@@ -3,14 +3,17 @@ package merchantapi
 import (
 	"net/http"
 	"net/url"
+	"slices"
 	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/cryptolink/cryptolink/internal/money"
 	"github.com/cryptolink/cryptolink/internal/server/http/common"
 	"github.com/cryptolink/cryptolink/internal/server/http/middleware"
+	"github.com/cryptolink/cryptolink/internal/service/audit"
 	"github.com/cryptolink/cryptolink/internal/service/merchant"
 	"github.com/cryptolink/cryptolink/internal/service/subscription"
+	"github.com/cryptolink/cryptolink/internal/service/webhooks"
 	"github.com/cryptolink/cryptolink/internal/util"
 	"github.com/cryptolink/cryptolink/pkg/api-dashboard/v1/model"
 	"github.com/pkg/errors"
@@ -20,19 +23,20 @@ func (h *Handler) ListMerchants(c echo.Context) error {
 	ctx := c.Request().Context()
 	user := middleware.ResolveUser(c)
 
-	merchants, err := h.merchants.ListByCreatorID(ctx, user.ID)
+	memberships, err := h.merchants.ListByMemberID(ctx, user.ID)
 
 	if err != nil {
 		h.logger.Error().Err(err).Msg("unable to list merchants")
 		return common.ErrorResponse(c, "internal_error")
 	}
 
-	var merchantList = make([]*model.MerchantListItem, len(merchants))
-	for i, mt := range merchants {
+	var merchantList = make([]*model.MerchantListItem, len(memberships))
+	for i, membership := range memberships {
 		merchantList[i] = &model.MerchantListItem{
-			ID:      mt.UUID.String(),
-			Name:    mt.Name,
-			Website: mt.Website,
+			ID:      membership.Merchant.UUID.String(),
+			Name:    membership.Merchant.Name,
+			Website: membership.Merchant.Website,
+			Role:    string(membership.Role),
 		}
 	}
 
@@ -88,6 +92,7 @@ func (h *Handler) CreateMerchant(c echo.Context) error {
 	return c.JSON(http.StatusCreated, &model.Merchant{
 		ID:      mt.UUID.String(),
 		Name:    mt.Name,
+		Role:    string(merchant.RoleOwner),
 		Website: mt.Website,
 	})
 }
@@ -101,17 +106,31 @@ func (h *Handler) GetMerchant(c echo.Context) error {
 		return err
 	}
 
+	// legacy single webhook settings are backed by the primary endpoint
+	webhookSettings := &model.WebhookSettings{}
+
+	endpoint, err := h.webhooks.PrimaryEndpoint(ctx, mt.ID)
+	switch {
+	case err == nil:
+		webhookSettings.URL = endpoint.URL
+		if role, ok := middleware.ResolveMemberRole(c); !ok || role.Can(merchant.PermissionWebhooks) {
+			webhookSettings.Secret = endpoint.Secret
+		}
+	case !errors.Is(err, webhooks.ErrEndpointNotFound):
+		return err
+	}
+
 	fiatCurrency := mt.Settings().FiatCurrency()
 	fiatSymbol := money.FiatSymbol(money.FiatCurrency(fiatCurrency))
 
+	role, _ := middleware.ResolveMemberRole(c)
+
 	return c.JSON(http.StatusOK, &model.Merchant{
-		ID:      mt.UUID.String(),
-		Name:    mt.Name,
-		Website: mt.Website,
-		WebhookSettings: &model.WebhookSettings{
-			Secret: mt.Settings().WebhookSignatureSecret(),
-			URL:    mt.Settings().WebhookURL(),
-		},
+		ID:              mt.UUID.String(),
+		Name:            mt.Name,
+		Role:            string(role),
+		Website:         mt.Website,
+		WebhookSettings: webhookSettings,
 		FiatCurrency:       fiatCurrency,
 		FiatCurrencySymbol: fiatSymbol,
 		SupportedPaymentMethods: util.MapSlice(methods, func(sc merchant.SupportedCurrency) *model.SupportedPaymentMethod {
@@ -173,15 +192,28 @@ func (h *Handler) UpdateMerchantWebhook(c echo.Context) error {
 	ctx := c.Request().Context()
 	mt := middleware.ResolveMerchant(c)
 
-	upsert := merchant.Settings{
-		merchant.PropertyWebhookURL:      req.URL,
-		merchant.PropertySignatureSecret: req.Secret,
+	prev, err := h.webhooks.PrimaryEndpoint(ctx, mt.ID)
+	if err != nil && !errors.Is(err, webhooks.ErrEndpointNotFound) {
+		return err
 	}
 
-	if err := h.merchants.UpsertSettings(ctx, mt, upsert); err != nil {
+	// re-enables endpoint that was disabled after persistent failures
+	endpoint, err := h.webhooks.SetPrimaryEndpoint(ctx, mt.ID, req.URL, req.Secret)
+	switch {
+	case errors.Is(err, webhooks.ErrInvalidEndpoint):
+		return common.ValidationErrorResponse(c, "url is invalid")
+	case err != nil:
 		return err
 	}
 
+	h.recordChange(c, audit.Change{
+		MerchantID: mt.ID,
+		Action:     audit.ActionWebhookUpdate,
+		ResourceID: endpoint.UUID.String(),
+		Before:     webhookEndpointAuditValues(prev),
+		After:      webhookEndpointAuditValues(endpoint),
+	})
+
 	return c.NoContent(http.StatusNoContent)
 }
 
@@ -199,10 +231,18 @@ func (h *Handler) UpdateMerchantSupportedMethods(c echo.Context) error {
 
 	ctx := c.Request().Context()
 	mt := middleware.ResolveMerchant(c)
+	before := slices.Sorted(slices.Values(mt.Settings().PaymentMethods()))
 
 	if err := h.merchants.UpdateSupportedMethods(ctx, mt, tickers); err != nil {
 		return err
 	}
 
+	h.recordChange(c, audit.Change{
+		MerchantID: mt.ID,
+		Action:     audit.ActionPaymentMethodsUpdate,
+		Before:     audit.Values{"paymentMethods": before},
+		After:      audit.Values{"paymentMethods": slices.Sorted(slices.Values(mt.Settings().PaymentMethods()))},
+	})
+
 	return c.NoContent(http.StatusNoContent)
 }
@@ -372,6 +372,10 @@ func paymentToResponse(pr payment.PaymentWithRelations, feePercent float64) *mod
 			info.CustomerEmail = &customer.Email
 		}
 
+		if decision := pt.UnderpaymentDecision(); decision != "" {
+			info.UnderpaymentDecision = util.Ptr(decision.String())
+		}
+
 		res.AdditionalInfo = &model.PaymentAdditionalInfo{Payment: info}
 	}
 
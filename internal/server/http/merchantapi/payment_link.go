@@ -89,6 +89,11 @@ func (h *Handler) CreatePaymentLink(c echo.Context) error {
 		return common.ValidationErrorItemResponse(c, "price", "price should be between %.2f and %.0f", money.FiatMin, money.FiatMax)
 	}
 
+	var maxUses *int
+	if req.MaxUses != nil {
+		maxUses = util.Ptr(int(*req.MaxUses))
+	}
+
 	mt := middleware.ResolveMerchant(c)
 
 	link, err := h.payments.CreatePaymentLink(ctx, mt.ID, payment.CreateLinkProps{
@@ -98,6 +103,7 @@ func (h *Handler) CreatePaymentLink(c echo.Context) error {
 		SuccessAction:  payment.SuccessAction(req.SuccessAction),
 		RedirectURL:    req.RedirectURL,
 		SuccessMessage: req.SuccessMessage,
+		MaxUses:        maxUses,
 		IsTest:         false,
 	})
 
@@ -126,5 +132,16 @@ func linkToResponse(link *payment.Link) *model.PaymentLink {
 		SuccessAction:  string(link.SuccessAction),
 		RedirectURL:    link.RedirectURL,
 		SuccessMessage: link.SuccessMessage,
+
+		MaxUses: maxUsesToResponse(link.MaxUses),
+		Uses:    int64(link.Uses),
+	}
+}
+
+func maxUsesToResponse(maxUses *int) *int64 {
+	if maxUses == nil {
+		return nil
 	}
+
+	return util.Ptr(int64(*maxUses))
 }
@@ -0,0 +1,189 @@
+package merchantapi
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/cryptolink/cryptolink/internal/server/http/common"
+	"github.com/cryptolink/cryptolink/internal/server/http/middleware"
+	"github.com/cryptolink/cryptolink/internal/service/payment"
+	"github.com/cryptolink/cryptolink/internal/service/transaction"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+)
+
+const (
+	rateExportDateLayout = "2006-01-02"
+	rateExportMaxRange   = 366 * 24 * time.Hour
+)
+
+type rateSnapshotResponse struct {
+	PaymentID     string   `json:"paymentId,omitempty"`
+	TransactionID int64    `json:"transactionId"`
+	Kind          string   `json:"kind"`
+	FromCurrency  string   `json:"fromCurrency"`
+	ToCurrency    string   `json:"toCurrency"`
+	FromAmount    string   `json:"fromAmount"`
+	ToAmount      string   `json:"toAmount"`
+	Rate          float64  `json:"rate"`
+	Source        string   `json:"source"`
+	FeePercent    *float64 `json:"feePercent,omitempty"`
+	ChargedAmount *string  `json:"chargedAmount,omitempty"`
+	FetchedAt     string   `json:"fetchedAt"`
+	CreatedAt     string   `json:"createdAt"`
+}
+
+// ListPaymentRateSnapshots returns exchange-rate audit trail of the payment.
+func (h *Handler) ListPaymentRateSnapshots(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	paymentUUID, err := uuid.Parse(c.Param(paramPaymentID))
+	if err != nil {
+		return common.ValidationErrorResponse(c, "invalid payment id")
+	}
+
+	mt := middleware.ResolveMerchant(c)
+
+	pt, err := h.payments.GetByMerchantOrderID(ctx, mt.ID, paymentUUID)
+	switch {
+	case errors.Is(err, payment.ErrNotFound):
+		return common.NotFoundResponse(c, "payment not found")
+	case err != nil:
+		return err
+	}
+
+	snapshots, err := h.processing.ListRateSnapshots(ctx, mt.ID, pt.ID)
+	if err != nil {
+		h.logger.Error().Err(err).
+			Int64("merchant_id", mt.ID).Int64("payment_id", pt.ID).
+			Msg("unable to list rate snapshots")
+
+		return common.ErrorResponse(c, "internal_error")
+	}
+
+	results := make([]rateSnapshotResponse, len(snapshots))
+	for i := range snapshots {
+		results[i] = rateSnapshotToResponse(snapshots[i])
+	}
+
+	return c.JSON(http.StatusOK, map[string]interface{}{"results": results})
+}
+
+// ExportRateSnapshots exports merchant's exchange-rate audit trail for a date
+// range as CSV (default) or JSON (?format=json). Dates are inclusive, UTC.
+func (h *Handler) ExportRateSnapshots(c echo.Context) error {
+	ctx := c.Request().Context()
+	mt := middleware.ResolveMerchant(c)
+
+	from, to, rangeErr := queryDateRange(c)
+	if rangeErr != nil {
+		return common.ValidationErrorItemResponse(c, rangeErr.field, "%s", rangeErr.message)
+	}
+
+	snapshots, err := h.processing.ListRateSnapshotsInRange(ctx, mt.ID, from, to)
+	if err != nil {
+		h.logger.Error().Err(err).Int64("merchant_id", mt.ID).Msg("unable to export rate snapshots")
+		return common.ErrorResponse(c, "internal_error")
+	}
+
+	results := make([]rateSnapshotResponse, len(snapshots))
+	for i := range snapshots {
+		results[i] = rateSnapshotToResponse(snapshots[i])
+	}
+
+	if c.QueryParam("format") == "json" {
+		return c.JSON(http.StatusOK, map[string]interface{}{"results": results})
+	}
+
+	filename := fmt.Sprintf(
+		"rate-snapshots-%s-%s.csv",
+		from.Format(rateExportDateLayout),
+		to.Add(-24*time.Hour).Format(rateExportDateLayout),
+	)
+
+	c.Response().Header().Set(echo.HeaderContentType, "text/csv")
+	c.Response().Header().Set(echo.HeaderContentDisposition, "attachment; filename="+filename)
+	c.Response().WriteHeader(http.StatusOK)
+
+	writer := csv.NewWriter(c.Response().Writer)
+	defer writer.Flush()
+
+	_ = writer.Write([]string{
+		"Created At", "Payment ID", "Transaction ID", "Kind",
+		"From Currency", "From Amount", "To Currency", "To Amount",
+		"Rate", "Source", "Fetched At", "Fee Percent", "Charged Amount",
+	})
+
+	for _, r := range results {
+		feePercent, charged := "", ""
+		if r.FeePercent != nil {
+			feePercent = strconv.FormatFloat(*r.FeePercent, 'f', -1, 64)
+		}
+		if r.ChargedAmount != nil {
+			charged = *r.ChargedAmount
+		}
+
+		_ = writer.Write([]string{
+			r.CreatedAt, r.PaymentID, strconv.FormatInt(r.TransactionID, 10), r.Kind,
+			r.FromCurrency, r.FromAmount, r.ToCurrency, r.ToAmount,
+			strconv.FormatFloat(r.Rate, 'f', -1, 64), r.Source, r.FetchedAt, feePercent, charged,
+		})
+	}
+
+	return nil
+}
+
+type dateRangeError struct {
+	field   string
+	message string
+}
+
+// queryDateRange parses inclusive "?from=YYYY-MM-DD&to=YYYY-MM-DD" dates (UTC)
+// into [from, to) range of at most one year.
+func queryDateRange(c echo.Context) (time.Time, time.Time, *dateRangeError) {
+	from, err := time.Parse(rateExportDateLayout, c.QueryParam(queryParamFrom))
+	if err != nil {
+		return time.Time{}, time.Time{}, &dateRangeError{queryParamFrom, "date should be in YYYY-MM-DD format"}
+	}
+
+	to, err := time.Parse(rateExportDateLayout, c.QueryParam(queryParamTo))
+	if err != nil {
+		return time.Time{}, time.Time{}, &dateRangeError{queryParamTo, "date should be in YYYY-MM-DD format"}
+	}
+
+	// make "to" inclusive
+	to = to.Add(24 * time.Hour)
+
+	if !from.Before(to) || to.Sub(from) > rateExportMaxRange {
+		return time.Time{}, time.Time{}, &dateRangeError{queryParamTo, "date range should be positive and at most one year"}
+	}
+
+	return from, to, nil
+}
+
+func rateSnapshotToResponse(snap transaction.RateSnapshot) rateSnapshotResponse {
+	res := rateSnapshotResponse{
+		TransactionID: snap.TransactionID,
+		Kind:          string(snap.Kind),
+		FromCurrency:  snap.FromCurrency,
+		ToCurrency:    snap.ToCurrency,
+		FromAmount:    snap.FromAmount,
+		ToAmount:      snap.ToAmount,
+		Rate:          snap.Rate,
+		Source:        snap.Source,
+		FeePercent:    snap.FeePercent,
+		ChargedAmount: snap.ChargedAmount,
+		FetchedAt:     snap.FetchedAt.UTC().Format(time.RFC3339),
+		CreatedAt:     snap.CreatedAt.UTC().Format(time.RFC3339),
+	}
+
+	if snap.PaymentUUID != nil {
+		res.PaymentID = snap.PaymentUUID.String()
+	}
+
+	return res
+}
@@ -0,0 +1,56 @@
+package merchantapi
+
+import (
+	"net/http"
+
+	"github.com/cryptolink/cryptolink/internal/server/http/common"
+	"github.com/cryptolink/cryptolink/internal/server/http/middleware"
+	"github.com/cryptolink/cryptolink/internal/service/merchant"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+)
+
+// reportSettingsBody is used both for request and response.
+// Timezone is an IANA name, e.g. "Europe/Madrid".
+type reportSettingsBody struct {
+	Frequency string `json:"frequency"`
+	Timezone  string `json:"timezone"`
+}
+
+// GetReportSettings returns merchant's settlement report settings.
+func (h *Handler) GetReportSettings(c echo.Context) error {
+	mt := middleware.ResolveMerchant(c)
+
+	return c.JSON(http.StatusOK, reportSettingsToResponse(mt.Settings()))
+}
+
+// UpdateReportSettings opts merchant in or out of daily/weekly settlement reports.
+func (h *Handler) UpdateReportSettings(c echo.Context) error {
+	var req reportSettingsBody
+	if err := c.Bind(&req); err != nil {
+		return common.ValidationErrorResponse(c, "invalid request body")
+	}
+
+	ctx := c.Request().Context()
+	mt := middleware.ResolveMerchant(c)
+
+	frequency := merchant.ReportFrequency(defaultString(req.Frequency, string(merchant.ReportOff)))
+
+	err := h.merchants.UpdateReportSettings(ctx, mt, frequency, req.Timezone)
+	switch {
+	case errors.Is(err, merchant.ErrInvalidReportSettings):
+		return common.ValidationErrorResponse(c, err.Error())
+	case err != nil:
+		h.logger.Error().Err(err).Int64("merchant_id", mt.ID).Msg("failed to update report settings")
+		return common.ErrorResponse(c, "internal_error")
+	}
+
+	return c.JSON(http.StatusOK, reportSettingsToResponse(mt.Settings()))
+}
+
+func reportSettingsToResponse(s merchant.Settings) reportSettingsBody {
+	return reportSettingsBody{
+		Frequency: string(s.ReportFrequency()),
+		Timezone:  s.Timezone(),
+	}
+}
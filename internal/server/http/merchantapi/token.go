@@ -2,12 +2,14 @@ package merchantapi
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/go-openapi/strfmt"
 	"github.com/labstack/echo/v4"
 	"github.com/cryptolink/cryptolink/internal/auth"
 	"github.com/cryptolink/cryptolink/internal/server/http/common"
 	"github.com/cryptolink/cryptolink/internal/server/http/middleware"
+	"github.com/cryptolink/cryptolink/internal/service/audit"
 	"github.com/cryptolink/cryptolink/pkg/api-dashboard/v1/model"
 	"github.com/pkg/errors"
 )
@@ -28,17 +30,7 @@ func (h *Handler) ListMerchantTokens(c echo.Context) error {
 
 	var tokensFormatted = make([]*model.APIToken, len(tokens))
 	for i, token := range tokens {
-		var name string
-		if token.Name != nil {
-			name = *token.Name
-		}
-
-		tokensFormatted[i] = &model.APIToken{
-			ID:        token.UUID.String(),
-			CreatedAt: strfmt.DateTime(token.CreatedAt),
-			Name:      name,
-			Token:     &token.Token,
-		}
+		tokensFormatted[i] = tokenToResponse(token)
 	}
 
 	return c.JSON(http.StatusOK, &model.TokenList{Results: tokensFormatted})
@@ -53,21 +45,38 @@ func (h *Handler) CreateMerchantToken(c echo.Context) error {
 	mt := middleware.ResolveMerchant(c)
 	ctx := c.Request().Context()
 
-	token, err := h.tokens.CreateMerchantToken(ctx, mt.ID, req.Name)
-	if err != nil {
-		return errors.Wrap(err, "unable to create merchant token")
+	var expiresAt *time.Time
+	if req.ExpiresAt != nil {
+		t := time.Time(*req.ExpiresAt)
+		expiresAt = &t
 	}
 
-	var name string
-	if token.Name != nil {
-		name = *token.Name
+	token, err := h.tokens.CreateMerchantToken(ctx, mt.ID, auth.CreateTokenParams{
+		Name:         req.Name,
+		Scopes:       req.Scopes,
+		ExpiresAt:    expiresAt,
+		AllowedCIDRs: req.AllowedCidrs,
+	})
+
+	switch {
+	case errors.Is(err, auth.ErrInvalidScope):
+		return common.ValidationErrorItemResponse(c, "scopes", "%s", err.Error())
+	case errors.Is(err, auth.ErrInvalidCIDR):
+		return common.ValidationErrorItemResponse(c, "allowedCidrs", "%s", err.Error())
+	case errors.Is(err, auth.ErrInvalidExpiration):
+		return common.ValidationErrorItemResponse(c, "expiresAt", "%s", err.Error())
+	case err != nil:
+		return errors.Wrap(err, "unable to create merchant token")
 	}
 
-	return c.JSON(http.StatusCreated, &model.APIToken{
-		Name:  name,
-		Token: &token.Token,
-		ID:    token.UUID.String(),
+	h.recordChange(c, audit.Change{
+		MerchantID: mt.ID,
+		Action:     audit.ActionAPITokenCreate,
+		ResourceID: token.UUID.String(),
+		After:      tokenAuditValues(token),
 	})
+
+	return c.JSON(http.StatusCreated, tokenToResponse(token))
 }
 
 func (h *Handler) DeleteMerchantTokens(c echo.Context) error {
@@ -93,5 +102,61 @@ func (h *Handler) DeleteMerchantTokens(c echo.Context) error {
 		return errors.Wrap(err, "unable to delete token")
 	}
 
+	h.recordChange(c, audit.Change{
+		MerchantID: mt.ID,
+		Action:     audit.ActionAPITokenDelete,
+		ResourceID: token.UUID.String(),
+		Before:     tokenAuditValues(token),
+	})
+
 	return c.NoContent(http.StatusNoContent)
 }
+
+func tokenToResponse(token *auth.APIToken) *model.APIToken {
+	var name string
+	if token.Name != nil {
+		name = *token.Name
+	}
+
+	return &model.APIToken{
+		ID:           token.UUID.String(),
+		CreatedAt:    strfmt.DateTime(token.CreatedAt),
+		Name:         name,
+		Token:        &token.Token,
+		Scopes:       token.Scopes,
+		ExpiresAt:    optionalDateTime(token.ExpiresAt),
+		LastUsedAt:   optionalDateTime(token.LastUsedAt),
+		LastUsedIP:   token.LastUsedIP,
+		AllowedCidrs: token.AllowedCIDRs,
+	}
+}
+
+// tokenAuditValues token restrictions for the audit log, the token itself is omitted.
+func tokenAuditValues(token *auth.APIToken) audit.Values {
+	var name string
+	if token.Name != nil {
+		name = *token.Name
+	}
+
+	var expiresAt string
+	if token.ExpiresAt != nil {
+		expiresAt = token.ExpiresAt.UTC().Format(time.RFC3339)
+	}
+
+	return audit.Values{
+		"name":         name,
+		"scopes":       token.Scopes,
+		"expiresAt":    expiresAt,
+		"allowedCidrs": token.AllowedCIDRs,
+	}
+}
+
+func optionalDateTime(t *time.Time) *strfmt.DateTime {
+	if t == nil {
+		return nil
+	}
+
+	dt := strfmt.DateTime(*t)
+
+	return &dt
+}
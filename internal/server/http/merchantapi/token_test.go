@@ -0,0 +1,70 @@
+package merchantapi_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/cryptolink/cryptolink/internal/auth"
+	"github.com/cryptolink/cryptolink/internal/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPITokenAllowedCIDRs(t *testing.T) {
+	const balancesRoute = "/api/merchant/v1/merchant/:merchantId/balance"
+
+	tc := test.NewIntegrationTest(t)
+
+	// Given a merchant
+	user, _ := tc.Must.CreateSampleUser(t)
+	mt, _ := tc.Must.CreateMerchant(t, user.ID)
+
+	createToken := func(t *testing.T, cidrs ...string) string {
+		token, err := tc.Services.AuthTokenManager.CreateMerchantToken(tc.Context, mt.ID, auth.CreateTokenParams{
+			Name:         "restricted",
+			AllowedCIDRs: cidrs,
+		})
+		require.NoError(t, err)
+
+		return token.Token
+	}
+
+	t.Run("Forged X-Forwarded-For is ignored", func(t *testing.T) {
+		// ARRANGE
+		// Given a token allowed from an office IP only
+		token := createToken(t, "203.0.113.0/24")
+
+		// ACT
+		// Request from another IP that claims to be the office
+		res := tc.Client.
+			GET().
+			Path(balancesRoute).
+			Param(paramMerchantID, mt.UUID.String()).
+			WithToken(token).
+			Header("X-Forwarded-For", "203.0.113.7").
+			Header("X-Real-IP", "203.0.113.7").
+			Do()
+
+		// ASSERT
+		assert.Equal(t, http.StatusForbidden, res.StatusCode())
+		assert.Contains(t, res.String(), "ip_not_allowed")
+	})
+
+	t.Run("Peer address is allowed", func(t *testing.T) {
+		// ARRANGE
+		// Given a token allowed from the test client's address
+		token := createToken(t, "192.0.2.1/32")
+
+		// ACT
+		res := tc.Client.
+			GET().
+			Path(balancesRoute).
+			Param(paramMerchantID, mt.UUID.String()).
+			WithToken(token).
+			Header("X-Forwarded-For", "203.0.113.7").
+			Do()
+
+		// ASSERT
+		assert.Equal(t, http.StatusOK, res.StatusCode())
+	})
+}
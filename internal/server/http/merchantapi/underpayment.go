@@ -0,0 +1,83 @@
+package merchantapi
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/cryptolink/cryptolink/internal/server/http/common"
+	"github.com/cryptolink/cryptolink/internal/server/http/middleware"
+	"github.com/cryptolink/cryptolink/internal/service/merchant"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+// underpaymentPolicyBody is used both for request and response.
+// Tolerance amount is in merchant's fiat currency.
+type underpaymentPolicyBody struct {
+	TolerancePercent   string `json:"tolerancePercent"`
+	ToleranceAmount    string `json:"toleranceAmount"`
+	BelowTolerance     string `json:"belowTolerance"`
+	TopUpWindowMinutes int    `json:"topUpWindowMinutes"`
+}
+
+// GetUnderpaymentPolicy returns merchant's underpayment policy.
+func (h *Handler) GetUnderpaymentPolicy(c echo.Context) error {
+	mt := middleware.ResolveMerchant(c)
+
+	return c.JSON(http.StatusOK, underpaymentPolicyToResponse(mt.Settings().UnderpaymentPolicy()))
+}
+
+// UpdateUnderpaymentPolicy updates merchant's underpayment policy.
+func (h *Handler) UpdateUnderpaymentPolicy(c echo.Context) error {
+	var req underpaymentPolicyBody
+	if err := c.Bind(&req); err != nil {
+		return common.ValidationErrorResponse(c, "invalid request body")
+	}
+
+	percent, err := decimal.NewFromString(defaultString(req.TolerancePercent, "0"))
+	if err != nil {
+		return common.ValidationErrorItemResponse(c, "tolerancePercent", "invalid number")
+	}
+
+	amount, err := decimal.NewFromString(defaultString(req.ToleranceAmount, "0"))
+	if err != nil {
+		return common.ValidationErrorItemResponse(c, "toleranceAmount", "invalid number")
+	}
+
+	ctx := c.Request().Context()
+	mt := middleware.ResolveMerchant(c)
+
+	err = h.merchants.UpdateUnderpaymentPolicy(ctx, mt, merchant.UnderpaymentPolicy{
+		TolerancePercent: percent,
+		ToleranceFiat:    amount,
+		BelowTolerance:   merchant.UnderpaymentAction(req.BelowTolerance),
+		TopUpWindow:      time.Duration(req.TopUpWindowMinutes) * time.Minute,
+	})
+	switch {
+	case errors.Is(err, merchant.ErrInvalidUnderpaymentPolicy):
+		return common.ValidationErrorResponse(c, err.Error())
+	case err != nil:
+		h.logger.Error().Err(err).Int64("merchant_id", mt.ID).Msg("failed to update underpayment policy")
+		return common.ErrorResponse(c, "internal_error")
+	}
+
+	return c.JSON(http.StatusOK, underpaymentPolicyToResponse(mt.Settings().UnderpaymentPolicy()))
+}
+
+func underpaymentPolicyToResponse(p merchant.UnderpaymentPolicy) underpaymentPolicyBody {
+	return underpaymentPolicyBody{
+		TolerancePercent:   p.TolerancePercent.String(),
+		ToleranceAmount:    p.ToleranceFiat.StringFixed(2),
+		BelowTolerance:     string(p.BelowTolerance),
+		TopUpWindowMinutes: int(p.TopUpWindow / time.Minute),
+	}
+}
+
+func defaultString(s, def string) string {
+	if s == "" {
+		return def
+	}
+
+	return s
+}
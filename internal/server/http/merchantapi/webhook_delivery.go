@@ -0,0 +1,239 @@
+package merchantapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/cryptolink/cryptolink/internal/server/http/common"
+	"github.com/cryptolink/cryptolink/internal/server/http/middleware"
+	"github.com/cryptolink/cryptolink/internal/service/payment"
+	"github.com/cryptolink/cryptolink/internal/service/webhooks"
+	"github.com/cryptolink/cryptolink/internal/util"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+)
+
+const (
+	paramDeliveryID = "deliveryId"
+
+	webhookDeliveriesLimitDefault = 50
+	webhookDeliveriesLimitMax     = 200
+)
+
+type webhookDeliveryResponse struct {
+	ID            string                            `json:"id"`
+	PaymentID     *string                           `json:"paymentId"`
+	EndpointID    *string                           `json:"endpointId"`
+	EventID       *string                           `json:"eventId"`
+	EventType     string                            `json:"eventType"`
+	PaymentStatus string                            `json:"paymentStatus"`
+	Status        string                            `json:"status"`
+	Attempts      int                               `json:"attempts"`
+	URL           string                            `json:"url"`
+	ResponseCode  int                               `json:"responseCode,omitempty"`
+	LastError     string                            `json:"lastError,omitempty"`
+	IsReplay      bool                              `json:"isReplay"`
+	Payload       json.RawMessage                   `json:"payload,omitempty"`
+	NextAttemptAt *string                           `json:"nextAttemptAt"`
+	LastAttemptAt *string                           `json:"lastAttemptAt"`
+	DeliveredAt   *string                           `json:"deliveredAt"`
+	CreatedAt     string                            `json:"createdAt"`
+	AttemptLog    []*webhookDeliveryAttemptResponse `json:"attemptLog,omitempty"`
+}
+
+type webhookDeliveryAttemptResponse struct {
+	Attempt        int               `json:"attempt"`
+	URL            string            `json:"url"`
+	RequestHeaders map[string]string `json:"requestHeaders"`
+	RequestBody    string            `json:"requestBody"`
+	ResponseStatus int               `json:"responseStatus,omitempty"`
+	ResponseBody   string            `json:"responseBody,omitempty"`
+	LatencyMS      int64             `json:"latencyMs"`
+	ErrorClass     string            `json:"errorClass,omitempty"`
+	Error          string            `json:"error,omitempty"`
+	CreatedAt      string            `json:"createdAt"`
+}
+
+type webhookDeliveriesPagination struct {
+	Cursor  string                     `json:"cursor"`
+	Limit   int64                      `json:"limit"`
+	Results []*webhookDeliveryResponse `json:"results"`
+}
+
+// ListWebhookDeliveries lists merchant's webhook deliveries, newest first.
+// Filters: paymentId, endpointId, status.
+func (h *Handler) ListWebhookDeliveries(c echo.Context) error {
+	ctx := c.Request().Context()
+	mt := middleware.ResolveMerchant(c)
+
+	params := webhooks.ListDeliveriesParams{
+		Status: webhooks.DeliveryStatus(c.QueryParam("status")),
+		Limit:  webhookDeliveriesLimitDefault,
+	}
+
+	switch params.Status {
+	case "", webhooks.DeliveryPending, webhooks.DeliveryDelivered, webhooks.DeliveryFailed, webhooks.DeliverySkipped:
+	default:
+		return common.ValidationErrorItemResponse(c, "status", "unknown delivery status")
+	}
+
+	if raw := c.QueryParam(common.ParamQueryLimit); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > webhookDeliveriesLimitMax {
+			return common.ValidationErrorItemResponse(c, common.ParamQueryLimit, "limit should be between 1 and %d", webhookDeliveriesLimitMax)
+		}
+		params.Limit = limit
+	}
+
+	if raw := c.QueryParam(common.ParamQueryCursor); raw != "" {
+		beforeID, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || beforeID < 1 {
+			return common.ValidationErrorItemResponse(c, common.ParamQueryCursor, "invalid cursor")
+		}
+		params.BeforeID = beforeID
+	}
+
+	if raw := c.QueryParam(paramPaymentID); raw != "" {
+		paymentUUID, err := uuid.Parse(raw)
+		if err != nil {
+			return common.ValidationErrorItemResponse(c, paramPaymentID, "invalid payment id")
+		}
+
+		pt, err := h.payments.GetByMerchantOrderID(ctx, mt.ID, paymentUUID)
+		switch {
+		case errors.Is(err, payment.ErrNotFound):
+			return common.NotFoundResponse(c, "payment not found")
+		case err != nil:
+			return err
+		}
+
+		params.PaymentID = pt.ID
+	}
+
+	if raw := c.QueryParam(paramEndpointID); raw != "" {
+		endpointUUID, err := uuid.Parse(raw)
+		if err != nil {
+			return common.ValidationErrorItemResponse(c, paramEndpointID, "invalid endpoint id")
+		}
+
+		endpoint, err := h.webhooks.GetEndpoint(ctx, mt.ID, endpointUUID)
+		switch {
+		case errors.Is(err, webhooks.ErrEndpointNotFound):
+			return common.NotFoundResponse(c, "webhook endpoint not found")
+		case err != nil:
+			return err
+		}
+
+		params.EndpointID = endpoint.ID
+	}
+
+	deliveries, err := h.webhooks.ListDeliveries(ctx, mt.ID, params)
+	if err != nil {
+		h.logger.Error().Err(err).Int64("merchant_id", mt.ID).Msg("unable to list webhook deliveries")
+		return common.ErrorResponse(c, "internal_error")
+	}
+
+	var cursor string
+	if len(deliveries) == params.Limit {
+		cursor = strconv.FormatInt(deliveries[len(deliveries)-1].ID, 10)
+	}
+
+	return c.JSON(http.StatusOK, &webhookDeliveriesPagination{
+		Cursor:  cursor,
+		Limit:   int64(params.Limit),
+		Results: util.MapSlice(deliveries, webhookDeliveryToResponse),
+	})
+}
+
+// GetWebhookDelivery returns delivery with its payload and attempt log.
+func (h *Handler) GetWebhookDelivery(c echo.Context) error {
+	deliveryID, err := uuid.Parse(c.Param(paramDeliveryID))
+	if err != nil {
+		return common.ValidationErrorItemResponse(c, paramDeliveryID, "invalid delivery id")
+	}
+
+	mt := middleware.ResolveMerchant(c)
+
+	d, err := h.webhooks.GetDelivery(c.Request().Context(), mt.ID, deliveryID)
+	switch {
+	case errors.Is(err, webhooks.ErrDeliveryNotFound):
+		return common.NotFoundResponse(c, "webhook delivery not found")
+	case err != nil:
+		h.logger.Error().Err(err).Int64("merchant_id", mt.ID).Msg("unable to get webhook delivery")
+		return common.ErrorResponse(c, "internal_error")
+	}
+
+	return c.JSON(http.StatusOK, webhookDeliveryToResponse(d))
+}
+
+// ReplayWebhookDelivery sends past event again as a new delivery.
+func (h *Handler) ReplayWebhookDelivery(c echo.Context) error {
+	deliveryID, err := uuid.Parse(c.Param(paramDeliveryID))
+	if err != nil {
+		return common.ValidationErrorItemResponse(c, paramDeliveryID, "invalid delivery id")
+	}
+
+	mt := middleware.ResolveMerchant(c)
+
+	d, err := h.webhooks.Replay(c.Request().Context(), mt, deliveryID)
+	switch {
+	case errors.Is(err, webhooks.ErrDeliveryNotFound):
+		return common.NotFoundResponse(c, "webhook delivery not found")
+	case errors.Is(err, webhooks.ErrReplayDisabled):
+		return common.ValidationErrorResponse(c, "webhook endpoint is deleted or disabled")
+	case err != nil:
+		h.logger.Error().Err(err).Int64("merchant_id", mt.ID).Msg("unable to replay webhook delivery")
+		return common.ErrorResponse(c, "internal_error")
+	}
+
+	return c.JSON(http.StatusCreated, webhookDeliveryToResponse(d))
+}
+
+func webhookDeliveryToResponse(d *webhooks.Delivery) *webhookDeliveryResponse {
+	return &webhookDeliveryResponse{
+		ID:            d.UUID.String(),
+		PaymentID:     uuidToOptionalString(d.PaymentUUID),
+		EndpointID:    uuidToOptionalString(d.EndpointUUID),
+		EventID:       uuidToOptionalString(d.EventID),
+		EventType:     d.EventType,
+		PaymentStatus: d.PaymentStatus,
+		Status:        string(d.Status),
+		Attempts:      d.Attempts,
+		URL:           d.URL,
+		ResponseCode:  d.ResponseCode,
+		LastError:     d.LastError,
+		IsReplay:      d.ReplayOf != nil,
+		Payload:       d.Payload,
+		NextAttemptAt: formatOptionalTime(d.NextAttemptAt),
+		LastAttemptAt: formatOptionalTime(d.LastAttemptAt),
+		DeliveredAt:   formatOptionalTime(d.DeliveredAt),
+		CreatedAt:     d.CreatedAt.UTC().Format(time.RFC3339),
+		AttemptLog:    util.MapSlice(d.AttemptLog, webhookDeliveryAttemptToResponse),
+	}
+}
+
+func webhookDeliveryAttemptToResponse(a *webhooks.Attempt) *webhookDeliveryAttemptResponse {
+	return &webhookDeliveryAttemptResponse{
+		Attempt:        a.Attempt,
+		URL:            a.URL,
+		RequestHeaders: a.RequestHeaders,
+		RequestBody:    a.RequestBody,
+		ResponseStatus: a.ResponseStatus,
+		ResponseBody:   a.ResponseBody,
+		LatencyMS:      a.Latency.Milliseconds(),
+		ErrorClass:     a.ErrorClass,
+		Error:          a.Error,
+		CreatedAt:      a.CreatedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+func uuidToOptionalString(id *uuid.UUID) *string {
+	if id == nil {
+		return nil
+	}
+
+	return util.Ptr(id.String())
+}
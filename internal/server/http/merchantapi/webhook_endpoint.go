@@ -0,0 +1,360 @@
+package merchantapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/cryptolink/cryptolink/internal/server/http/common"
+	"github.com/cryptolink/cryptolink/internal/server/http/middleware"
+	"github.com/cryptolink/cryptolink/internal/service/audit"
+	"github.com/cryptolink/cryptolink/internal/service/payment"
+	"github.com/cryptolink/cryptolink/internal/service/webhooks"
+	"github.com/cryptolink/cryptolink/internal/util"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+)
+
+const paramEndpointID = "endpointId"
+
+type webhookEndpointRequest struct {
+	URL         string   `json:"url"`
+	Secret      string   `json:"secret"`
+	Description string   `json:"description"`
+	Enabled     *bool    `json:"enabled"`
+	TestOnly    bool     `json:"testOnly"`
+	EventTypes  []string `json:"eventTypes"`
+}
+
+type rotateWebhookSecretRequest struct {
+	// OverlapHours how long the current secret stays valid; configured default when omitted.
+	OverlapHours *int `json:"overlapHours"`
+}
+
+type testWebhookEndpointRequest struct {
+	EventType string `json:"eventType"`
+}
+
+// testWebhookEndpointResponse is the exchange of a test event. Success is true
+// when endpoint responded with 2xx.
+type testWebhookEndpointResponse struct {
+	EventType string `json:"eventType"`
+	Success   bool   `json:"success"`
+	*webhookDeliveryAttemptResponse
+}
+
+type webhookPreviewResponse struct {
+	EventType string          `json:"eventType"`
+	Payload   json.RawMessage `json:"payload"`
+}
+
+type webhookEndpointResponse struct {
+	ID                      string   `json:"id"`
+	URL                     string   `json:"url"`
+	Secret                  string   `json:"secret"`
+	Description             string   `json:"description"`
+	Enabled                 bool     `json:"enabled"`
+	TestOnly                bool     `json:"testOnly"`
+	EventTypes              []string `json:"eventTypes"`
+	DisabledAt              *string  `json:"disabledAt"`
+	PreviousSecretExpiresAt *string  `json:"previousSecretExpiresAt"`
+	CreatedAt               string   `json:"createdAt"`
+	UpdatedAt               string   `json:"updatedAt"`
+}
+
+func (h *Handler) ListWebhookEndpoints(c echo.Context) error {
+	mt := middleware.ResolveMerchant(c)
+
+	endpoints, err := h.webhooks.ListEndpoints(c.Request().Context(), mt.ID)
+	if err != nil {
+		h.logger.Error().Err(err).Int64("merchant_id", mt.ID).Msg("unable to list webhook endpoints")
+		return common.ErrorResponse(c, "internal_error")
+	}
+
+	return c.JSON(http.StatusOK, util.MapSlice(endpoints, webhookEndpointToResponse))
+}
+
+func (h *Handler) GetWebhookEndpoint(c echo.Context) error {
+	endpointID, err := uuid.Parse(c.Param(paramEndpointID))
+	if err != nil {
+		return common.ValidationErrorItemResponse(c, paramEndpointID, "invalid endpoint id")
+	}
+
+	mt := middleware.ResolveMerchant(c)
+
+	endpoint, err := h.webhooks.GetEndpoint(c.Request().Context(), mt.ID, endpointID)
+	if err != nil {
+		return h.webhookEndpointErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, webhookEndpointToResponse(endpoint))
+}
+
+// CreateWebhookEndpoint creates an endpoint. Secret is generated unless provided.
+func (h *Handler) CreateWebhookEndpoint(c echo.Context) error {
+	var req webhookEndpointRequest
+	if err := c.Bind(&req); err != nil {
+		return common.ValidationErrorResponse(c, "invalid request body")
+	}
+
+	mt := middleware.ResolveMerchant(c)
+
+	endpoint, err := h.webhooks.CreateEndpoint(c.Request().Context(), mt.ID, req.toParams())
+	if err != nil {
+		return h.webhookEndpointErrorResponse(c, err)
+	}
+
+	h.recordChange(c, audit.Change{
+		MerchantID: mt.ID,
+		Action:     audit.ActionWebhookEndpointCreate,
+		ResourceID: endpoint.UUID.String(),
+		After:      webhookEndpointAuditValues(endpoint),
+	})
+
+	return c.JSON(http.StatusCreated, webhookEndpointToResponse(endpoint))
+}
+
+// UpdateWebhookEndpoint replaces endpoint settings. Empty secret keeps the current one.
+func (h *Handler) UpdateWebhookEndpoint(c echo.Context) error {
+	endpointID, err := uuid.Parse(c.Param(paramEndpointID))
+	if err != nil {
+		return common.ValidationErrorItemResponse(c, paramEndpointID, "invalid endpoint id")
+	}
+
+	var req webhookEndpointRequest
+	if err := c.Bind(&req); err != nil {
+		return common.ValidationErrorResponse(c, "invalid request body")
+	}
+
+	ctx := c.Request().Context()
+	mt := middleware.ResolveMerchant(c)
+
+	prev, err := h.webhooks.GetEndpoint(ctx, mt.ID, endpointID)
+	if err != nil {
+		return h.webhookEndpointErrorResponse(c, err)
+	}
+
+	endpoint, err := h.webhooks.UpdateEndpoint(ctx, mt.ID, endpointID, req.toParams())
+	if err != nil {
+		return h.webhookEndpointErrorResponse(c, err)
+	}
+
+	h.recordChange(c, audit.Change{
+		MerchantID: mt.ID,
+		Action:     audit.ActionWebhookEndpointUpdate,
+		ResourceID: endpoint.UUID.String(),
+		Before:     webhookEndpointAuditValues(prev),
+		After:      webhookEndpointAuditValues(endpoint),
+	})
+
+	return c.JSON(http.StatusOK, webhookEndpointToResponse(endpoint))
+}
+
+// RotateWebhookEndpointSecret generates new endpoint secret. Deliveries are
+// signed with both secrets until the overlap ends.
+func (h *Handler) RotateWebhookEndpointSecret(c echo.Context) error {
+	endpointID, err := uuid.Parse(c.Param(paramEndpointID))
+	if err != nil {
+		return common.ValidationErrorItemResponse(c, paramEndpointID, "invalid endpoint id")
+	}
+
+	var req rotateWebhookSecretRequest
+	if err := c.Bind(&req); err != nil {
+		return common.ValidationErrorResponse(c, "invalid request body")
+	}
+
+	overlap := time.Duration(-1)
+	if req.OverlapHours != nil {
+		maxHours := int(webhooks.MaxSecretRotationOverlap / time.Hour)
+		if *req.OverlapHours < 0 || *req.OverlapHours > maxHours {
+			return common.ValidationErrorItemResponse(c, "overlapHours", "overlap should be between 0 and %d hours", maxHours)
+		}
+
+		overlap = time.Duration(*req.OverlapHours) * time.Hour
+	}
+
+	ctx := c.Request().Context()
+	mt := middleware.ResolveMerchant(c)
+
+	prev, err := h.webhooks.GetEndpoint(ctx, mt.ID, endpointID)
+	if err != nil {
+		return h.webhookEndpointErrorResponse(c, err)
+	}
+
+	endpoint, err := h.webhooks.RotateEndpointSecret(ctx, mt.ID, endpointID, overlap)
+	if err != nil {
+		return h.webhookEndpointErrorResponse(c, err)
+	}
+
+	h.recordChange(c, audit.Change{
+		MerchantID: mt.ID,
+		Action:     audit.ActionWebhookEndpointRotateSecret,
+		ResourceID: endpoint.UUID.String(),
+		Before:     audit.Values{"secret": prev.Secret},
+		After: audit.Values{
+			"secret":                  endpoint.Secret,
+			"previousSecretExpiresAt": formatOptionalTime(endpoint.PreviousSecretExpiresAt),
+		},
+	})
+
+	return c.JSON(http.StatusOK, webhookEndpointToResponse(endpoint))
+}
+
+func (h *Handler) DeleteWebhookEndpoint(c echo.Context) error {
+	endpointID, err := uuid.Parse(c.Param(paramEndpointID))
+	if err != nil {
+		return common.ValidationErrorItemResponse(c, paramEndpointID, "invalid endpoint id")
+	}
+
+	ctx := c.Request().Context()
+	mt := middleware.ResolveMerchant(c)
+
+	prev, err := h.webhooks.GetEndpoint(ctx, mt.ID, endpointID)
+	if err != nil {
+		return h.webhookEndpointErrorResponse(c, err)
+	}
+
+	if err := h.webhooks.DeleteEndpoint(ctx, mt.ID, endpointID); err != nil {
+		return h.webhookEndpointErrorResponse(c, err)
+	}
+
+	h.recordChange(c, audit.Change{
+		MerchantID: mt.ID,
+		Action:     audit.ActionWebhookEndpointDelete,
+		ResourceID: prev.UUID.String(),
+		Before:     webhookEndpointAuditValues(prev),
+	})
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// TestWebhookEndpoint sends synthetic event of given type to the endpoint
+// and returns request and response. Endpoint errors are not API errors:
+// they are returned in the attempt.
+func (h *Handler) TestWebhookEndpoint(c echo.Context) error {
+	endpointID, err := uuid.Parse(c.Param(paramEndpointID))
+	if err != nil {
+		return common.ValidationErrorItemResponse(c, paramEndpointID, "invalid endpoint id")
+	}
+
+	var req testWebhookEndpointRequest
+	if err := c.Bind(&req); err != nil {
+		return common.ValidationErrorResponse(c, "invalid request body")
+	}
+
+	mt := middleware.ResolveMerchant(c)
+
+	attempt, err := h.webhooks.SendTestEvent(c.Request().Context(), mt.ID, endpointID, req.EventType)
+	switch {
+	case errors.Is(err, webhooks.ErrUnknownEventType):
+		return common.ValidationErrorItemResponse(c, "eventType", "unknown event type %q", req.EventType)
+	case err != nil:
+		return h.webhookEndpointErrorResponse(c, err)
+	}
+
+	return c.JSON(http.StatusOK, &testWebhookEndpointResponse{
+		EventType:                      req.EventType,
+		Success:                        attempt.Error == "",
+		webhookDeliveryAttemptResponse: webhookDeliveryAttemptToResponse(attempt),
+	})
+}
+
+// PreviewPaymentWebhook renders payload the payment would be delivered with
+// for given event type ("payment.status" by default) without sending it.
+func (h *Handler) PreviewPaymentWebhook(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	paymentUUID, err := uuid.Parse(c.Param(paramPaymentID))
+	if err != nil {
+		return common.ValidationErrorResponse(c, "invalid payment id")
+	}
+
+	eventType := c.QueryParam("eventType")
+	if eventType == "" {
+		eventType = webhooks.EventPaymentStatus
+	}
+
+	mt := middleware.ResolveMerchant(c)
+
+	pt, err := h.payments.GetByMerchantOrderID(ctx, mt.ID, paymentUUID)
+	switch {
+	case errors.Is(err, payment.ErrNotFound):
+		return common.NotFoundResponse(c, "payment not found")
+	case err != nil:
+		return err
+	}
+
+	payload, err := h.webhooks.PreviewPaymentEvent(ctx, mt, pt, eventType)
+	switch {
+	case errors.Is(err, webhooks.ErrUnknownEventType), errors.Is(err, webhooks.ErrNotPaymentEvent):
+		return common.ValidationErrorItemResponse(c, "eventType", "%q is not a payment event type", eventType)
+	case err != nil:
+		h.logger.Error().Err(err).
+			Int64("merchant_id", mt.ID).Int64("payment_id", pt.ID).
+			Msg("unable to render webhook preview")
+
+		return common.ErrorResponse(c, "internal_error")
+	}
+
+	return c.JSON(http.StatusOK, &webhookPreviewResponse{EventType: eventType, Payload: payload})
+}
+
+func (h *Handler) webhookEndpointErrorResponse(c echo.Context, err error) error {
+	switch {
+	case errors.Is(err, webhooks.ErrEndpointNotFound):
+		return common.NotFoundResponse(c, "webhook endpoint not found")
+	case errors.Is(err, webhooks.ErrEndpointLimit):
+		return common.ValidationErrorResponse(c, "webhook endpoints limit reached")
+	case errors.Is(err, webhooks.ErrInvalidEndpoint):
+		return common.ValidationErrorResponse(c, err.Error())
+	}
+
+	h.logger.Error().Err(err).Msg("unable to process webhook endpoint request")
+
+	return common.ErrorResponse(c, "internal_error")
+}
+
+func (r *webhookEndpointRequest) toParams() webhooks.EndpointParams {
+	return webhooks.EndpointParams{
+		URL:         r.URL,
+		Secret:      r.Secret,
+		Description: r.Description,
+		Enabled:     r.Enabled == nil || *r.Enabled,
+		TestOnly:    r.TestOnly,
+		EventTypes:  r.EventTypes,
+	}
+}
+
+func webhookEndpointToResponse(e *webhooks.Endpoint) *webhookEndpointResponse {
+	return &webhookEndpointResponse{
+		ID:                      e.UUID.String(),
+		URL:                     e.URL,
+		Secret:                  e.Secret,
+		Description:             e.Description,
+		Enabled:                 e.Enabled,
+		TestOnly:                e.TestOnly,
+		EventTypes:              e.EventTypes,
+		DisabledAt:              formatOptionalTime(e.DisabledAt),
+		PreviousSecretExpiresAt: formatOptionalTime(e.PreviousSecretExpiresAt),
+		CreatedAt:               e.CreatedAt.UTC().Format(time.RFC3339),
+		UpdatedAt:               e.UpdatedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+// webhookEndpointAuditValues endpoint settings for the audit log, nil for
+// missing endpoint.
+func webhookEndpointAuditValues(e *webhooks.Endpoint) audit.Values {
+	if e == nil {
+		return nil
+	}
+
+	return audit.Values{
+		"url":         e.URL,
+		"secret":      e.Secret,
+		"description": e.Description,
+		"enabled":     e.Enabled,
+		"testOnly":    e.TestOnly,
+		"eventTypes":  e.EventTypes,
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/labstack/echo/v4"
 	"github.com/cryptolink/cryptolink/internal/server/http/common"
 	"github.com/cryptolink/cryptolink/internal/server/http/middleware"
+	"github.com/cryptolink/cryptolink/internal/service/audit"
 	"github.com/cryptolink/cryptolink/internal/service/xpub"
 	"github.com/pkg/errors"
 )
@@ -63,6 +64,13 @@ func (h *Handler) CreateXpubWallet(c echo.Context) error {
 		return errors.Wrap(err, "unable to create xpub wallet")
 	}
 
+	h.recordChange(c, audit.Change{
+		MerchantID: merchant.ID,
+		Action:     audit.ActionXpubWalletCreate,
+		ResourceID: wallet.UUID.String(),
+		After:      xpubWalletAuditValues(wallet),
+	})
+
 	return c.JSON(http.StatusCreated, &XpubWalletResponse{
 		UUID:             wallet.UUID.String(),
 		Blockchain:       wallet.Blockchain,
@@ -132,6 +140,17 @@ func (h *Handler) DeleteXpubWallet(c echo.Context) error {
 		return common.ValidationErrorItemResponse(c, "walletId", "Invalid wallet UUID")
 	}
 
+	// wallet settings for the audit log
+	wallet, err := h.xpubService.GetByUUID(ctx, walletUUID)
+	switch {
+	case errors.Is(err, xpub.ErrNotFound):
+		return common.ErrorResponse(c, "Xpub wallet not found")
+	case err != nil:
+		return errors.Wrap(err, "unable to get xpub wallet")
+	case wallet.MerchantID != merchant.ID:
+		return common.ErrorResponse(c, "Xpub wallet not found")
+	}
+
 	err = h.xpubService.DeactivateWallet(ctx, walletUUID, merchant.ID)
 	switch {
 	case errors.Is(err, xpub.ErrNotFound):
@@ -140,6 +159,13 @@ func (h *Handler) DeleteXpubWallet(c echo.Context) error {
 		return errors.Wrap(err, "unable to delete xpub wallet")
 	}
 
+	h.recordChange(c, audit.Change{
+		MerchantID: merchant.ID,
+		Action:     audit.ActionXpubWalletDelete,
+		ResourceID: wallet.UUID.String(),
+		Before:     xpubWalletAuditValues(wallet),
+	})
+
 	return c.NoContent(http.StatusNoContent)
 }
 
@@ -243,3 +269,13 @@ func (h *Handler) ListDerivedAddresses(c echo.Context) error {
 
 	return c.JSON(http.StatusOK, response)
 }
+
+// xpubWalletAuditValues wallet settings for the audit log. Extended key is
+// masked: it reveals all addresses of the wallet.
+func xpubWalletAuditValues(wallet *xpub.XpubWallet) audit.Values {
+	return audit.Values{
+		"blockchain":     wallet.Blockchain,
+		"xpub":           audit.Mask(wallet.Xpub),
+		"derivationPath": wallet.DerivationPath,
+	}
+}
@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"github.com/cryptolink/cryptolink/internal/service/audit"
+	"github.com/labstack/echo/v4"
+)
+
+// ResolveAuditActor returns who makes the request for the audit log: merchant
+// API token, merchant member or super admin outside a merchant (admin routes).
+func ResolveAuditActor(c echo.Context) audit.Actor {
+	actor := audit.Actor{
+		IP:        c.RealIP(),
+		UserAgent: c.Request().UserAgent(),
+	}
+
+	if token := ResolveAPIToken(c); token != nil {
+		actor.Type = audit.ActorToken
+		actor.TokenID = token.ID
+
+		return actor
+	}
+
+	actor.Type = audit.ActorUser
+
+	if person := ResolveUser(c); person != nil {
+		actor.UserID = person.ID
+		actor.Email = person.Email
+
+		if _, isMember := ResolveMemberRole(c); !isMember && person.IsSuperAdmin {
+			actor.Type = audit.ActorAdmin
+		}
+	}
+
+	return actor
+}
@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/gorilla/sessions"
@@ -22,13 +23,21 @@ const (
 	UserIDContextKey      = "user_id"
 	IsTokenAuthContextKey = "token_auth"
 	MerchantContextKey    = "merchant"
+	APITokenContextKey    = "api_token"
+	MemberRoleContextKey  = "member_role"
+	UserSessionContextKey = "user_session"
 
 	SessionStateKey = "session_state"
 
+	// SessionIDSessionKey uuid of server-side session record, see user.Session.
+	SessionIDSessionKey = "session_id"
+
 	ParamMerchantID = "merchantId"
 )
 
-// ResolvesUserBySession attaches user to echo.Context if possible
+// ResolvesUserBySession attaches user and its server-side session record to
+// echo.Context if possible. Cookies without an active record (revoked, expired
+// or created before records were introduced) are treated as logged out.
 func ResolvesUserBySession(users *user.Service) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
@@ -38,10 +47,31 @@ func ResolvesUserBySession(users *user.Service) echo.MiddlewareFunc {
 				return next(c)
 			}
 
-			if person, err := users.GetByID(c.Request().Context(), userID); err == nil {
-				c.Set(UserContextKey, person)
+			rawSessionID, _ := userSession.Values[SessionIDSessionKey].(string)
+			sessionID, err := uuid.Parse(rawSessionID)
+			if err != nil {
+				return next(c)
+			}
+
+			ctx := c.Request().Context()
+
+			record, err := users.GetActiveSession(ctx, sessionID)
+			if err != nil || record.UserID != userID {
+				return next(c)
+			}
+
+			person, err := users.GetByID(ctx, userID)
+			if err != nil {
+				return next(c)
+			}
+
+			if err := users.TouchSession(ctx, record, c.RealIP()); err != nil {
+				c.Logger().Errorf("unable to touch session %d: %s", record.ID, err)
 			}
 
+			c.Set(UserContextKey, person)
+			c.Set(UserSessionContextKey, record)
+
 			return next(c)
 		}
 	}
@@ -83,8 +113,9 @@ func ResolvesUserByToken(tokens *auth.TokenAuthManager, users *user.Service) ech
 	}
 }
 
-// ResolvesMerchantByUUID. Middleware tries to bind merchant from request to echo.Context
-// if uuid is invalid or merchant not found, no error occurs.
+// ResolvesMerchantByUUID. Middleware tries to bind merchant from request and user's
+// role in it to echo.Context if the user is a member of the merchant.
+// If uuid is invalid or merchant not found, no error occurs.
 // Warning: user with middleware only after ResolvesUserBySession or ResolvesUserByToken
 func ResolvesMerchantByUUID(merchants *merchant.Service) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
@@ -105,15 +136,10 @@ func ResolvesMerchantByUUID(merchants *merchant.Service) echo.MiddlewareFunc {
 				return next(c)
 			}
 
-			m, err = merchants.GetByUUIDAndCreatorID(
-				c.Request().Context(),
-				merchantUUID,
-				person.ID,
-				false,
-			)
-
+			m, role, err := merchants.GetByUUIDAndMemberID(c.Request().Context(), merchantUUID, person.ID)
 			if err == nil && m != nil {
 				c.Set(MerchantContextKey, m)
+				c.Set(MemberRoleContextKey, role)
 			}
 
 			return next(c)
@@ -121,7 +147,8 @@ func ResolvesMerchantByUUID(merchants *merchant.Service) echo.MiddlewareFunc {
 	}
 }
 
-// ResolvesMerchantByToken attaches merchant to echo.Context. Returns 400 if auth token not provided
+// ResolvesMerchantByToken attaches merchant and its token to echo.Context.
+// Returns 401 if auth token not provided or expired, 403 if client's IP is not allowed.
 func ResolvesMerchantByToken(tokens *auth.TokenAuthManager, merchants *merchant.Service) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
@@ -149,8 +176,28 @@ func ResolvesMerchantByToken(tokens *auth.TokenAuthManager, merchants *merchant.
 				})
 			}
 
+			if token.IsExpired(time.Now()) {
+				return c.JSON(http.StatusUnauthorized, &model.ErrorResponse{
+					Message: "Api token expired",
+					Status:  "token_expired",
+				})
+			}
+
+			ip := c.RealIP()
+			if !token.AllowsIP(ip) {
+				return c.JSON(http.StatusForbidden, &model.ErrorResponse{
+					Message: "Api token is not allowed from this IP",
+					Status:  "ip_not_allowed",
+				})
+			}
+
+			if err := tokens.MarkUsed(ctx, token, ip); err != nil {
+				c.Logger().Errorf("unable to mark api token %d as used: %s", token.ID, err)
+			}
+
 			if mt, err = merchants.GetByID(ctx, token.EntityID, false); err == nil {
 				c.Set(MerchantContextKey, mt)
+				c.Set(APITokenContextKey, token)
 				c.Set(IsTokenAuthContextKey, true)
 			}
 
@@ -176,6 +223,42 @@ func GuardsUsers() echo.MiddlewareFunc {
 	}
 }
 
+// RequiresScope rejects requests authenticated by merchant API token without
+// the scope with '403 Forbidden'. Dashboard sessions pass.
+func RequiresScope(scope auth.Scope) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			token := ResolveAPIToken(c)
+			if token != nil && !token.HasScope(scope) {
+				return c.JSON(http.StatusForbidden, &model.ErrorResponse{
+					Message: "Api token lacks required scope " + scope,
+					Status:  "insufficient_scope",
+				})
+			}
+
+			return next(c)
+		}
+	}
+}
+
+// RequiresPermission rejects dashboard users whose role in the merchant lacks
+// the permission with '403 Forbidden'. Merchant API tokens pass, see RequiresScope.
+func RequiresPermission(permission merchant.Permission) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			role, ok := ResolveMemberRole(c)
+			if ok && !role.Can(permission) {
+				return c.JSON(http.StatusForbidden, &model.ErrorResponse{
+					Message: "Your role doesn't allow this action",
+					Status:  "insufficient_role",
+				})
+			}
+
+			return next(c)
+		}
+	}
+}
+
 // GuardsMerchants validate that user's merchant is attached to echo.Context or returns 400 bad request
 func GuardsMerchants() echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
@@ -218,6 +301,17 @@ func ResolveSession(c echo.Context) *sessions.Session {
 	return userSession
 }
 
+// ResolveSessionTTL returns max age of the session cookie or 24h for browser
+// session cookies (max age isn't set).
+func ResolveSessionTTL(c echo.Context) time.Duration {
+	sessionOptions, _ := c.Get(sessionOptionsKey).(sessions.Options)
+	if sessionOptions.MaxAge <= 0 {
+		return 24 * time.Hour
+	}
+
+	return time.Duration(sessionOptions.MaxAge) * time.Second
+}
+
 func ResolveSessionOAuthState(c echo.Context) (string, bool) {
 	s := ResolveSession(c)
 
@@ -244,6 +338,17 @@ func ResolveUser(c echo.Context) *user.User {
 	return person
 }
 
+// ResolveUserSession returns server-side record of the session the user is
+// authenticated by. Returns nil for token auth.
+func ResolveUserSession(c echo.Context) *user.Session {
+	record, ok := c.Get(UserSessionContextKey).(*user.Session)
+	if !ok {
+		return nil
+	}
+
+	return record
+}
+
 func ResolveMerchant(c echo.Context) *merchant.Merchant {
 	raw := c.Get(MerchantContextKey)
 	m, ok := raw.(*merchant.Merchant)
@@ -253,3 +358,19 @@ func ResolveMerchant(c echo.Context) *merchant.Merchant {
 
 	return m
 }
+
+// ResolveAPIToken returns merchant API token the request is authenticated by.
+func ResolveAPIToken(c echo.Context) *auth.APIToken {
+	token, ok := c.Get(APITokenContextKey).(*auth.APIToken)
+	if !ok {
+		return nil
+	}
+
+	return token
+}
+
+// ResolveMemberRole returns role of the user in the resolved merchant.
+func ResolveMemberRole(c echo.Context) (merchant.Role, bool) {
+	role, ok := c.Get(MemberRoleContextKey).(merchant.Role)
+	return role, ok
+}
@@ -0,0 +1,109 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/cryptolink/cryptolink/internal/service/user"
+	"github.com/cryptolink/cryptolink/pkg/api-dashboard/v1/model"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// PendingUserIDSessionKey user that passed the password step of login
+	// and should provide the second factor.
+	PendingUserIDSessionKey = "pending_user_id"
+	PendingUserAtSessionKey = "pending_user_at"
+
+	// ReauthAtSessionKey unix time when the user last confirmed password
+	// or second factor.
+	ReauthAtSessionKey = "reauth_at"
+
+	// PendingLoginTTL time to provide the second factor after the password.
+	PendingLoginTTL = 5 * time.Minute
+
+	// ReauthTTL time after login or re-authentication when sensitive
+	// operations are allowed.
+	ReauthTTL = 10 * time.Minute
+)
+
+// RequiresRecentAuth protects sensitive operations of session users: returns
+// '403 Forbidden' with "reauth_required" status unless the user confirmed
+// password or second factor within ReauthTTL. Token auth is not affected.
+func RequiresRecentAuth() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if IsTokenAuth(c) {
+				return next(c)
+			}
+
+			if s := ResolveSession(c); s != nil {
+				at, ok := s.Values[ReauthAtSessionKey].(int64)
+				if ok && time.Since(time.Unix(at, 0)) < ReauthTTL {
+					return next(c)
+				}
+			}
+
+			return c.JSON(http.StatusForbidden, &model.ErrorResponse{
+				Errors:  nil,
+				Message: "Re-authentication required",
+				Status:  "reauth_required",
+			})
+		}
+	}
+}
+
+// GuardsTwoFactor returns '403 Forbidden' with "two_factor_required" status
+// to session users without 2FA when super admin requires it for everyone.
+// Use only after GuardsUsers.
+func GuardsTwoFactor(users *user.Service) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			ctx := c.Request().Context()
+
+			if IsTokenAuth(c) || !users.IsTwoFactorRequired(ctx) {
+				return next(c)
+			}
+
+			enabled, err := users.IsTwoFactorEnabled(ctx, ResolveUser(c).ID)
+			if err != nil {
+				return err
+			}
+
+			if !enabled {
+				return c.JSON(http.StatusForbidden, &model.ErrorResponse{
+					Errors:  nil,
+					Message: "Two-factor authentication is required",
+					Status:  "two_factor_required",
+				})
+			}
+
+			return next(c)
+		}
+	}
+}
+
+// ResolvePendingUserID returns user that passed the password step of login.
+func ResolvePendingUserID(c echo.Context) (int64, bool) {
+	s := ResolveSession(c)
+	if s == nil {
+		return 0, false
+	}
+
+	userID, ok := s.Values[PendingUserIDSessionKey].(int64)
+	if !ok {
+		return 0, false
+	}
+
+	at, ok := s.Values[PendingUserAtSessionKey].(int64)
+	if !ok || time.Since(time.Unix(at, 0)) > PendingLoginTTL {
+		return 0, false
+	}
+
+	return userID, true
+}
+
+func IsTokenAuth(c echo.Context) bool {
+	isTokenAuth, ok := c.Get(IsTokenAuthContextKey).(bool)
+	return ok && isTokenAuth
+}
@@ -0,0 +1,44 @@
+package paymentapi
+
+import (
+	"net/http"
+
+	"github.com/cryptolink/cryptolink/internal/server/http/common"
+	"github.com/cryptolink/cryptolink/internal/service/merchant"
+	"github.com/cryptolink/cryptolink/pkg/api-payment/v1/model"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+)
+
+const paramLogoFilename = "filename"
+
+// GetMerchantLogo serves merchant's uploaded logo. Filenames are random, so
+// the content never changes and can be cached forever.
+func (h *Handler) GetMerchantLogo(c echo.Context) error {
+	data, contentType, err := h.merchants.ReadLogo(c.Param(paramLogoFilename))
+	switch {
+	case errors.Is(err, merchant.ErrLogoNotFound):
+		return common.NotFoundResponse(c, "logo not found")
+	case err != nil:
+		return err
+	}
+
+	c.Response().Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	c.Response().Header().Set("X-Content-Type-Options", "nosniff")
+
+	return c.Blob(http.StatusOK, contentType, data)
+}
+
+func brandingToResponse(b merchant.Branding) *model.MerchantBranding {
+	if b.IsEmpty() {
+		return nil
+	}
+
+	return &model.MerchantBranding{
+		LogoURL:      b.LogoURL,
+		AccentColor:  b.AccentColor,
+		SupportEmail: b.SupportEmail,
+		TermsURL:     b.TermsURL,
+		FooterText:   b.FooterText,
+	}
+}
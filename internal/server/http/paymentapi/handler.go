@@ -24,6 +24,7 @@ type Handler struct {
 	blockchain BlockchainService
 	processing *processing.Service
 	contacts   *contact.Service
+	stream     *Stream
 	logger     *zerolog.Logger
 }
 
@@ -43,6 +44,7 @@ func New(
 		blockchain: blockchainService,
 		processing: core,
 		contacts:   contacts,
+		stream:     newStream(),
 		logger:     &log,
 	}
 }
@@ -51,6 +53,11 @@ func (h *Handler) PaymentService() *payment.Service {
 	return h.payments
 }
 
+// Stream returns event bus handler that feeds payment event streams.
+func (h *Handler) Stream() *Stream {
+	return h.stream
+}
+
 // GetCookie sets CSRF cookie for customer's session and attaches
 // X-CSRF-Token to response headers
 func (h *Handler) GetCookie(c echo.Context) error {
@@ -54,6 +54,7 @@ func (h *Handler) GetPayment(c echo.Context) error {
 		MerchantName: detailedPayment.Merchant.Name,
 		Description:  detailedPayment.Payment.Description,
 		FeePercent:   feePercent,
+		Branding:     brandingToResponse(h.merchants.Branding(detailedPayment.Merchant)),
 	}
 
 	if detailedPayment.Customer != nil {
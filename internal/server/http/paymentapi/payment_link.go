@@ -40,6 +40,7 @@ func (h *Handler) GetPaymentLink(c echo.Context) error {
 		Currency:     link.Price.Ticker(),
 		Price:        price,
 		Description:  link.Description,
+		Branding:     brandingToResponse(h.merchants.Branding(mt)),
 	})
 }
 
@@ -57,7 +58,11 @@ func (h *Handler) CreatePaymentFromLink(c echo.Context) error {
 	}
 
 	pt, err := h.payments.CreatePaymentFromLink(ctx, link)
-	if err != nil {
+
+	switch {
+	case errors.Is(err, payment.ErrLinkUsedUp):
+		return common.ValidationErrorResponse(c, "payment link is no longer available")
+	case err != nil:
 		return errors.Wrap(err, "unable to create payment from link")
 	}
 
@@ -0,0 +1,272 @@
+package paymentapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/cryptolink/cryptolink/internal/bus"
+	"github.com/cryptolink/cryptolink/internal/server/http/middleware"
+	"github.com/cryptolink/cryptolink/internal/service/payment"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	streamHeartbeatInterval = 15 * time.Second
+
+	// streamMaxDuration closes the stream so that abandoned checkout pages
+	// don't hold connections forever; EventSource reconnects on its own.
+	streamMaxDuration = 30 * time.Minute
+
+	// streamRetry is reconnection delay suggested to EventSource.
+	streamRetry = 3 * time.Second
+
+	// MaxStreamsPerIP limits concurrent event streams opened from one IP
+	// address to this process. Client IP is resolved by echo's IPExtractor
+	// that trusts X-Forwarded-For of configured proxies only.
+	MaxStreamsPerIP = 10
+)
+
+// Stream fans out payment updates from the event bus to event streams
+// connected to this process. It's registered as a broadcast handler, so that
+// every replica receives all updates.
+type Stream struct {
+	mu          sync.Mutex
+	subscribers map[int64]map[chan struct{}]struct{}
+	connections map[string]int
+}
+
+// paymentEvent is data of "payment" server-sent event.
+type paymentEvent struct {
+	Status                string `json:"status"`
+	AmountFormatted       string `json:"amountFormatted,omitempty"`
+	FactAmountFormatted   string `json:"factAmountFormatted,omitempty"`
+	Confirmations         int64  `json:"confirmations"`
+	RequiredConfirmations int64  `json:"requiredConfirmations"`
+	ReceivedAmount        string `json:"receivedAmount,omitempty"`
+	RemainingAmount       string `json:"remainingAmount,omitempty"`
+	RemainingPaymentLink  string `json:"remainingPaymentLink,omitempty"`
+}
+
+func newStream() *Stream {
+	return &Stream{
+		subscribers: make(map[int64]map[chan struct{}]struct{}),
+		connections: make(map[string]int),
+	}
+}
+
+func (s *Stream) Consumers() map[bus.Topic][]bus.Consumer {
+	return map[bus.Topic][]bus.Consumer{
+		bus.TopicPaymentStatusUpdate: {s.NotifyPaymentStatusUpdate},
+		bus.TopicPaymentProgress:     {s.NotifyPaymentProgress},
+	}
+}
+
+func (s *Stream) NotifyPaymentStatusUpdate(_ context.Context, message bus.Message) error {
+	event, err := bus.Bind[bus.PaymentStatusUpdateEvent](message)
+	if err != nil {
+		return err
+	}
+
+	s.notify(event.PaymentID)
+
+	return nil
+}
+
+func (s *Stream) NotifyPaymentProgress(_ context.Context, message bus.Message) error {
+	event, err := bus.Bind[bus.PaymentProgressEvent](message)
+	if err != nil {
+		return err
+	}
+
+	s.notify(event.PaymentID)
+
+	return nil
+}
+
+// notify wakes up streams of the payment. Streams reload the payment
+// themselves, so pending wake ups are coalesced.
+func (s *Stream) notify(paymentID int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for ch := range s.subscribers[paymentID] {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (s *Stream) subscribe(paymentID int64) (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.subscribers[paymentID] == nil {
+		s.subscribers[paymentID] = make(map[chan struct{}]struct{})
+	}
+
+	s.subscribers[paymentID][ch] = struct{}{}
+
+	unsubscribe := func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		delete(s.subscribers[paymentID], ch)
+		if len(s.subscribers[paymentID]) == 0 {
+			delete(s.subscribers, paymentID)
+		}
+	}
+
+	return ch, unsubscribe
+}
+
+// acquire registers a connection from the ip. Returns false when ip already
+// has MaxStreamsPerIP connections.
+func (s *Stream) acquire(ip string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.connections[ip] >= MaxStreamsPerIP {
+		return false
+	}
+
+	s.connections[ip]++
+
+	return true
+}
+
+func (s *Stream) release(ip string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.connections[ip]--
+	if s.connections[ip] <= 0 {
+		delete(s.connections, ip)
+	}
+}
+
+// StreamPayment streams payment updates as server-sent events: "payment" event
+// with the current state is sent on connect and after each change, comments
+// are sent as heartbeats. Event id identifies the state, so that a client
+// reconnecting with Last-Event-ID doesn't receive the state it already has.
+// The stream ends when payment reaches a final status.
+func (h *Handler) StreamPayment(c echo.Context) error {
+	pt, err := middleware.ResolvePayment(c)
+	if err != nil {
+		return err
+	}
+
+	ip := c.RealIP()
+	if !h.stream.acquire(ip) {
+		return echo.NewHTTPError(http.StatusTooManyRequests, "too many open event streams")
+	}
+
+	defer h.stream.release(ip)
+
+	// subscribe before the first read to not miss updates in between
+	updates, unsubscribe := h.stream.subscribe(pt.ID)
+	defer unsubscribe()
+
+	ctx, cancel := context.WithTimeout(c.Request().Context(), streamMaxDuration)
+	defer cancel()
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set(echo.HeaderCacheControl, "no-cache")
+	res.Header().Set(echo.HeaderConnection, "keep-alive")
+	res.Header().Set("X-Accel-Buffering", "no")
+	res.WriteHeader(http.StatusOK)
+
+	if _, err := fmt.Fprintf(res, "retry: %d\n\n", streamRetry.Milliseconds()); err != nil {
+		return nil
+	}
+
+	res.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	lastEventID := c.Request().Header.Get("Last-Event-ID")
+
+	for {
+		event, status, err := h.paymentEvent(ctx, pt)
+		if err != nil && ctx.Err() != nil {
+			return nil
+		}
+
+		if err != nil {
+			h.logger.Error().Err(err).Int64("payment_id", pt.ID).Msg("unable to get payment for event stream")
+			return nil
+		}
+
+		if data, id := encodePaymentEvent(event); id != lastEventID {
+			if _, err := fmt.Fprintf(res, "id: %s\nevent: payment\ndata: %s\n\n", id, data); err != nil {
+				return nil
+			}
+
+			res.Flush()
+			lastEventID = id
+		}
+
+		if status == payment.StatusSuccess || status == payment.StatusFailed {
+			return nil
+		}
+
+	wait:
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-updates:
+				break wait
+			case <-heartbeat.C:
+				if _, err := fmt.Fprint(res, ": heartbeat\n\n"); err != nil {
+					return nil
+				}
+
+				res.Flush()
+			}
+		}
+	}
+}
+
+func (h *Handler) paymentEvent(ctx context.Context, pt *payment.Payment) (*paymentEvent, payment.Status, error) {
+	details, err := h.processing.GetDetailedPayment(ctx, pt.MerchantID, pt.ID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	info := details.PaymentInfo
+	if info == nil {
+		status := details.Payment.PublicStatus()
+		return &paymentEvent{Status: status.String()}, status, nil
+	}
+
+	return &paymentEvent{
+		Status:                info.Status.String(),
+		AmountFormatted:       info.AmountFormatted,
+		FactAmountFormatted:   info.FactAmountFormatted,
+		Confirmations:         info.Confirmations,
+		RequiredConfirmations: info.RequiredConfirmations,
+		ReceivedAmount:        info.ReceivedAmount,
+		RemainingAmount:       info.RemainingAmount,
+		RemainingPaymentLink:  info.RemainingPaymentLink,
+	}, info.Status, nil
+}
+
+// encodePaymentEvent returns event's data and id derived from the data.
+func encodePaymentEvent(event *paymentEvent) ([]byte, string) {
+	data, _ := json.Marshal(event)
+
+	hash := fnv.New64a()
+	_, _ = hash.Write(data)
+
+	return data, fmt.Sprintf("%x", hash.Sum64())
+}
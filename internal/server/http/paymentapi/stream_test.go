@@ -0,0 +1,57 @@
+package paymentapi
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/cryptolink/cryptolink/internal/bus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStream(t *testing.T) {
+	t.Run("Limits connections per IP", func(t *testing.T) {
+		s := newStream()
+
+		for i := 0; i < MaxStreamsPerIP; i++ {
+			require.True(t, s.acquire("10.0.0.1"))
+		}
+
+		assert.False(t, s.acquire("10.0.0.1"))
+		assert.True(t, s.acquire("10.0.0.2"))
+
+		s.release("10.0.0.1")
+		assert.True(t, s.acquire("10.0.0.1"))
+	})
+
+	t.Run("Notifies payment subscribers", func(t *testing.T) {
+		s := newStream()
+
+		updates, unsubscribe := s.subscribe(1)
+		other, unsubscribeOther := s.subscribe(2)
+		defer unsubscribeOther()
+
+		message, err := json.Marshal(bus.PaymentProgressEvent{MerchantID: 1, PaymentID: 1})
+		require.NoError(t, err)
+
+		// pending wake ups are coalesced
+		require.NoError(t, s.NotifyPaymentProgress(context.Background(), message))
+		require.NoError(t, s.NotifyPaymentStatusUpdate(context.Background(), message))
+
+		assert.Len(t, updates, 1)
+		assert.Len(t, other, 0)
+
+		unsubscribe()
+		assert.NotContains(t, s.subscribers, int64(1))
+	})
+
+	t.Run("Event id depends on data", func(t *testing.T) {
+		_, id1 := encodePaymentEvent(&paymentEvent{Status: "inProgress", Confirmations: 1, RequiredConfirmations: 12})
+		_, id2 := encodePaymentEvent(&paymentEvent{Status: "inProgress", Confirmations: 1, RequiredConfirmations: 12})
+		_, id3 := encodePaymentEvent(&paymentEvent{Status: "inProgress", Confirmations: 2, RequiredConfirmations: 12})
+
+		assert.Equal(t, id1, id2)
+		assert.NotEqual(t, id1, id3)
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"io/fs"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	mw "github.com/labstack/echo/v4/middleware"
@@ -17,6 +18,7 @@ import (
 	"github.com/cryptolink/cryptolink/internal/server/http/paymentapi"
 	"github.com/cryptolink/cryptolink/internal/server/http/subscriptionapi"
 	"github.com/cryptolink/cryptolink/internal/server/http/webhook"
+	"github.com/cryptolink/cryptolink/internal/service/merchant"
 	"github.com/cryptolink/cryptolink/internal/service/user"
 )
 
@@ -37,6 +39,18 @@ func WithDashboardAPI(
 		s.echo.Use(middleware.SecurityHeaders())
 
 		guardsUsersMW := middleware.GuardsUsers()
+		guardsTwoFactorMW := middleware.GuardsTwoFactor(users)
+		requiresReauthMW := middleware.RequiresRecentAuth()
+
+		// merchant members act within permissions of their role
+		var (
+			canManageSettings   = middleware.RequiresPermission(merchant.PermissionSettings)
+			canManageWebhooks   = middleware.RequiresPermission(merchant.PermissionWebhooks)
+			canManageTokens     = middleware.RequiresPermission(merchant.PermissionTokens)
+			canManageCollectors = middleware.RequiresPermission(merchant.PermissionCollectors)
+			canManageMembers    = middleware.RequiresPermission(merchant.PermissionMembers)
+			canManageOwnership  = middleware.RequiresPermission(merchant.PermissionOwnership)
+		)
 
 		dashboardAPI := s.echo.Group(
 			"/api/dashboard/v1",
@@ -58,16 +72,43 @@ func WithDashboardAPI(
 		authGroup.PUT("/password", authHandler.UpdatePassword, guardsUsersMW)
 		authGroup.POST("/logout", authHandler.PostLogout, guardsUsersMW)
 
+		// active sessions
+		authGroup.GET("/sessions", authHandler.ListSessions, guardsUsersMW)
+		authGroup.DELETE("/sessions", authHandler.RevokeOtherSessions, guardsUsersMW)
+		authGroup.DELETE("/sessions/:sessionId", authHandler.RevokeSession, guardsUsersMW)
+
 		// email auth routes
 		if enableEmailAuth {
 			authGroup.POST("/login", authHandler.PostLogin)
 			authGroup.POST("/register", authHandler.PostRegister)
+
+			// password reset, at most 5 requests per IP and then one per minute
+			passwordResetRL := mw.NewRateLimiterMemoryStoreWithConfig(mw.RateLimiterMemoryStoreConfig{
+				Rate:      1.0 / 60,
+				Burst:     5,
+				ExpiresIn: time.Hour,
+			})
+			authGroup.POST("/password-reset", authHandler.PostPasswordReset, mw.RateLimiter(passwordResetRL))
+			authGroup.POST("/password-reset/confirm", authHandler.PostPasswordResetConfirm, mw.RateLimiter(mw.NewRateLimiterMemoryStore(1)))
 		}
 
 		// email verification routes (always available)
 		authGroup.GET("/verify-email", authHandler.VerifyEmail)
 		authGroup.POST("/resend-verification", authHandler.ResendVerification, guardsUsersMW)
 
+		// second login step, also after google auth
+		authGroup.POST("/login/2fa", authHandler.PostLoginTwoFactor, mw.RateLimiter(mw.NewRateLimiterMemoryStore(1)))
+
+		// re-authentication before sensitive operations
+		authGroup.POST("/reauth", authHandler.PostReauth, guardsUsersMW, mw.RateLimiter(mw.NewRateLimiterMemoryStore(1)))
+
+		// two-factor authentication
+		authGroup.GET("/2fa", authHandler.GetTwoFactor, guardsUsersMW)
+		authGroup.DELETE("/2fa", authHandler.DeleteTwoFactor, guardsUsersMW, requiresReauthMW)
+		authGroup.POST("/2fa/enroll", authHandler.PostTwoFactorEnroll, guardsUsersMW, requiresReauthMW)
+		authGroup.POST("/2fa/confirm", authHandler.PostTwoFactorConfirm, guardsUsersMW, requiresReauthMW)
+		authGroup.POST("/2fa/recovery-codes", authHandler.PostRecoveryCodes, guardsUsersMW, requiresReauthMW)
+
 		// google auth routes
 		if enableGoogleAuth {
 			authGroup.GET("/redirect", authHandler.GetRedirect)
@@ -76,47 +117,61 @@ func WithDashboardAPI(
 
 		dashboardAPI.GET("/fiat-currencies", handler.ListFiatCurrencies)
 
-		dashboardAPI.GET("/merchant", handler.ListMerchants, guardsUsersMW)
-		dashboardAPI.POST("/merchant", handler.CreateMerchant, guardsUsersMW)
+		dashboardAPI.GET("/merchant", handler.ListMerchants, guardsUsersMW, guardsTwoFactorMW)
+		dashboardAPI.POST("/merchant", handler.CreateMerchant, guardsUsersMW, guardsTwoFactorMW)
+		dashboardAPI.POST("/invitation/accept", handler.AcceptInvitation, guardsUsersMW, guardsTwoFactorMW)
 
 		// Merchants
 		merchantGroup := dashboardAPI.Group(
 			"/merchant/:merchantId",
 			guardsUsersMW,
+			guardsTwoFactorMW,
 			middleware.ResolvesMerchantByUUID(handler.MerchantService()),
 			middleware.GuardsMerchants(),
 		)
 
 		// Merchant
 		merchantGroup.GET("", handler.GetMerchant)
-		merchantGroup.PUT("", handler.UpdateMerchant)
-		merchantGroup.DELETE("", handler.DeleteMerchant)
+		merchantGroup.PUT("", handler.UpdateMerchant, canManageSettings)
+		merchantGroup.DELETE("", handler.DeleteMerchant, canManageOwnership)
+
+		merchantGroup.PUT("/webhook", handler.UpdateMerchantWebhook, canManageWebhooks, requiresReauthMW)
+		merchantGroup.PUT("/supported-method", handler.UpdateMerchantSupportedMethods, canManageSettings)
 
-		merchantGroup.PUT("/webhook", handler.UpdateMerchantWebhook)
-		merchantGroup.PUT("/supported-method", handler.UpdateMerchantSupportedMethods)
+		// Team members and invitations
+		merchantGroup.GET("/member", handler.ListMembers)
+		merchantGroup.PUT("/member/:userId", handler.UpdateMember, canManageMembers, requiresReauthMW)
+		merchantGroup.DELETE("/member/:userId", handler.DeleteMember, requiresReauthMW)
+		merchantGroup.GET("/invitation", handler.ListInvitations, canManageMembers)
+		merchantGroup.POST("/invitation", handler.CreateInvitation, canManageMembers, requiresReauthMW)
+		merchantGroup.DELETE("/invitation/:invitationId", handler.DeleteInvitation, canManageMembers)
 
 		// Merchant Tokens (rate limited to prevent abuse)
 		tokenRL := mw.NewRateLimiterMemoryStore(20) // 20 requests per second
-		tokenGroup := merchantGroup.Group("/token", mw.RateLimiter(tokenRL))
+		tokenGroup := merchantGroup.Group("/token", canManageTokens, mw.RateLimiter(tokenRL))
 		tokenGroup.GET("", handler.ListMerchantTokens)
-		tokenGroup.POST("", handler.CreateMerchantToken)
+		tokenGroup.POST("", handler.CreateMerchantToken, requiresReauthMW)
 		tokenGroup.DELETE("/:tokenId", handler.DeleteMerchantTokens)
 
 		// Xpub Wallets
 		merchantGroup.GET("/xpub-wallet", handler.ListXpubWallets)
-		merchantGroup.POST("/xpub-wallet", handler.CreateXpubWallet)
+		merchantGroup.POST("/xpub-wallet", handler.CreateXpubWallet, canManageCollectors)
 		merchantGroup.GET("/xpub-wallet/:walletId", handler.GetXpubWallet)
-		merchantGroup.DELETE("/xpub-wallet/:walletId", handler.DeleteXpubWallet)
-		merchantGroup.POST("/xpub-wallet/:walletId/derive", handler.DeriveAddress)
+		merchantGroup.DELETE("/xpub-wallet/:walletId", handler.DeleteXpubWallet, canManageCollectors)
+		merchantGroup.POST("/xpub-wallet/:walletId/derive", handler.DeriveAddress, canManageCollectors)
 		merchantGroup.GET("/xpub-wallet/:walletId/next-address", handler.GetNextAddress)
 		merchantGroup.GET("/xpub-wallet/:walletId/addresses", handler.ListDerivedAddresses)
 
 		// EVM Smart Contract Collector Wallets
 		merchantGroup.GET("/evm-collector", handler.ListEvmCollectors)
-		merchantGroup.POST("/evm-collector", handler.SetupEvmCollector)
+		merchantGroup.POST("/evm-collector", handler.SetupEvmCollector, canManageCollectors, requiresReauthMW)
 		merchantGroup.GET("/evm-collector/:blockchain", handler.GetEvmCollector)
-		merchantGroup.DELETE("/evm-collector/:blockchain", handler.DeleteEvmCollector)
+		merchantGroup.DELETE("/evm-collector/:blockchain", handler.DeleteEvmCollector, canManageCollectors, requiresReauthMW)
 		merchantGroup.GET("/evm-collector/:blockchain/balance", handler.GetEvmCollectorBalance)
+		merchantGroup.GET("/evm-collector/:blockchain/withdrawals", handler.ListEvmCollectorWithdrawals)
+		merchantGroup.POST("/evm-collector/deployments", handler.PrepareEvmCollectorDeployment, canManageCollectors, requiresReauthMW)
+		merchantGroup.GET("/evm-collector/deployments/:blockchain", handler.GetEvmCollectorDeployment)
+		merchantGroup.PUT("/evm-collector/deployments/:blockchain/tx", handler.SetEvmCollectorDeploymentTx, canManageCollectors)
 
 		// Collector factory (for frontend to discover factory address before deploying)
 		merchantGroup.GET("/collector-factory/:blockchain", handler.GetMerchantCollectorFactory)
@@ -129,18 +184,41 @@ func WithDashboardAPI(
 
 		// Fee & fiat currency settings
 		merchantGroup.GET("/fee-settings", handler.GetFeeSettings)
-		merchantGroup.PUT("/fee-settings", handler.UpdateFeeSettings)
+		merchantGroup.PUT("/fee-settings", handler.UpdateFeeSettings, canManageSettings)
+
+		// Checkout branding
+		merchantGroup.GET("/branding", handler.GetBranding)
+		merchantGroup.PUT("/branding", handler.UpdateBranding, canManageSettings)
+		merchantGroup.POST("/branding/logo", handler.UploadBrandingLogo, canManageSettings)
+		merchantGroup.DELETE("/branding/logo", handler.DeleteBrandingLogo, canManageSettings)
+
+		// Underpayment policy
+		merchantGroup.GET("/underpayment-policy", handler.GetUnderpaymentPolicy)
+		merchantGroup.PUT("/underpayment-policy", handler.UpdateUnderpaymentPolicy, canManageSettings)
+
+		// Account names used in accounting exports
+		merchantGroup.GET("/accounting-accounts", handler.GetAccountingAccounts)
+		merchantGroup.PUT("/accounting-accounts", handler.UpdateAccountingAccounts, canManageSettings)
+
+		// Scheduled settlement report emails
+		merchantGroup.GET("/report-settings", handler.GetReportSettings)
+		merchantGroup.PUT("/report-settings", handler.UpdateReportSettings, canManageSettings)
+
+		// Security audit log of configuration changes and owner alerts about high-risk ones
+		merchantGroup.GET("/audit-log", handler.ListAuditLog, canManageSettings)
+		merchantGroup.GET("/security-alerts", handler.GetSecurityAlerts)
+		merchantGroup.PUT("/security-alerts", handler.UpdateSecurityAlerts, canManageOwnership, requiresReauthMW)
 
 		// Subscription routes
 		dashboardAPI.GET("/subscription/plans", subscriptionHandler.ListPlans)
 
 		merchantGroup.GET("/subscription", subscriptionHandler.GetCurrentSubscription)
-		merchantGroup.POST("/subscription/upgrade", subscriptionHandler.UpgradePlan)
-		merchantGroup.POST("/subscription/cancel", subscriptionHandler.CancelSubscription)
+		merchantGroup.POST("/subscription/upgrade", subscriptionHandler.UpgradePlan, canManageSettings)
+		merchantGroup.POST("/subscription/cancel", subscriptionHandler.CancelSubscription, canManageSettings)
 		merchantGroup.GET("/subscription/usage", subscriptionHandler.GetUsageHistory)
 
 		// Admin routes (super admin only)
-		adminGroup := dashboardAPI.Group("/admin", guardsUsersMW, middleware.GuardsSuperAdmin())
+		adminGroup := dashboardAPI.Group("/admin", guardsUsersMW, guardsTwoFactorMW, middleware.GuardsSuperAdmin())
 		adminGroup.GET("/subscription/stats", subscriptionHandler.GetSystemStats)
 		adminGroup.GET("/subscription/list", subscriptionHandler.ListAllSubscriptions)
 
@@ -156,6 +234,13 @@ func WithDashboardAPI(
 		adminGroup.DELETE("/merchants/:merchantId", subscriptionHandler.AdminDeleteMerchant)
 		adminGroup.GET("/users", subscriptionHandler.ListAllUsers)
 		adminGroup.DELETE("/users/:userId", subscriptionHandler.AdminDeleteUser)
+		adminGroup.DELETE("/users/:userId/2fa", authHandler.ResetUserTwoFactor, requiresReauthMW)
+		adminGroup.GET("/users/:userId/sessions", authHandler.AdminListUserSessions)
+		adminGroup.DELETE("/users/:userId/sessions", authHandler.AdminRevokeUserSessions, requiresReauthMW)
+
+		// Admin two-factor policy
+		adminGroup.GET("/auth/2fa", authHandler.GetTwoFactorSettings)
+		adminGroup.PUT("/auth/2fa", authHandler.UpdateTwoFactorSettings, requiresReauthMW)
 
 		// Admin email routes
 		adminGroup.GET("/email/settings", emailHandler.GetSettings)
@@ -202,6 +287,9 @@ func WithDashboardAPI(
 		// to a payment when a customer paid the wrong/stale address.
 		adminGroup.POST("/payments/:paymentId/reconcile", handler.AdminReconcilePayment)
 
+		// Security audit log of all merchants
+		adminGroup.GET("/audit-log", handler.AdminListAuditLog)
+
 		setupCommonMerchantRoutes(merchantGroup, handler)
 	}
 }
@@ -216,6 +304,9 @@ func WithMerchantAPI(handler *merchantapi.Handler, tokensManager *auth.TokenAuth
 		)
 
 		setupCommonMerchantRoutes(merchantAPI, handler)
+
+		// WebSocket stream of webhook events, API tokens only
+		merchantAPI.GET("/event-stream", handler.StreamEvents, middleware.RequiresScope(auth.ScopeWebhooksRead))
 	}
 }
 
@@ -223,29 +314,104 @@ func WithMerchantAPI(handler *merchantapi.Handler, tokensManager *auth.TokenAuth
 // session auth: "/api/dashboard/v1/merchant/{merchant}/*"
 // token auth: "/api/merchant/v1/merchant/{merchant}/*"
 func setupCommonMerchantRoutes(g *echo.Group, handler *merchantapi.Handler) {
+	// session users confirm password or 2FA before changing webhooks
+	requiresReauthMW := middleware.RequiresRecentAuth()
+
+	// merchant API tokens are limited to granted scopes, dashboard users to
+	// permissions of their role
+	var (
+		canManagePayments = middleware.RequiresPermission(merchant.PermissionPayments)
+		canRefund         = middleware.RequiresPermission(merchant.PermissionRefunds)
+		canManageWebhooks = middleware.RequiresPermission(merchant.PermissionWebhooks)
+
+		paymentsRead       = middleware.RequiresScope(auth.ScopePaymentsRead)
+		paymentsWrite      = middleware.RequiresScope(auth.ScopePaymentsWrite)
+		paymentsResolve    = middleware.RequiresScope(auth.ScopePaymentsResolve)
+		paymentLinksRead   = middleware.RequiresScope(auth.ScopePaymentLinksRead)
+		paymentLinksWrite  = middleware.RequiresScope(auth.ScopePaymentLinksWrite)
+		balancesRead       = middleware.RequiresScope(auth.ScopeBalancesRead)
+		refundsWrite       = middleware.RequiresScope(auth.ScopeRefundsWrite)
+		webhooksRead       = middleware.RequiresScope(auth.ScopeWebhooksRead)
+		webhooksWrite      = middleware.RequiresScope(auth.ScopeWebhooksWrite)
+		customersRead      = middleware.RequiresScope(auth.ScopeCustomersRead)
+		subscriptionsRead  = middleware.RequiresScope(auth.ScopeSubscriptionsRead)
+		subscriptionsWrite = middleware.RequiresScope(auth.ScopeSubscriptionsWrite)
+	)
+
 	// Payment routes (rate limited to prevent abuse)
 	paymentRL := mw.NewRateLimiterMemoryStore(100) // 100 requests per second
 	paymentGroup := g.Group("/payment", mw.RateLimiter(paymentRL))
 
-	paymentGroup.GET("", handler.ListPayments)
-	paymentGroup.GET("/:paymentId", handler.GetPayment)
-	paymentGroup.POST("", handler.CreatePayment)
-	paymentGroup.POST("/:paymentId/resolve", handler.ResolvePayment)
-	paymentGroup.POST("/:paymentId/decline", handler.DeclinePayment)
+	paymentGroup.GET("", handler.ListPayments, paymentsRead)
+	paymentGroup.GET("/:paymentId", handler.GetPayment, paymentsRead)
+	paymentGroup.POST("", handler.CreatePayment, paymentsWrite, canManagePayments)
+	paymentGroup.POST("/:paymentId/resolve", handler.ResolvePayment, paymentsResolve, canManagePayments)
+	paymentGroup.POST("/:paymentId/decline", handler.DeclinePayment, paymentsResolve, canManagePayments)
+	paymentGroup.GET("/:paymentId/rate-snapshots", handler.ListPaymentRateSnapshots, paymentsRead)
+	paymentGroup.GET("/:paymentId/webhook-preview", handler.PreviewPaymentWebhook, paymentsRead)
 
 	// Payment link routes (rate limited to prevent abuse)
 	paymentLinkRL := mw.NewRateLimiterMemoryStore(50) // 50 requests per second
 	paymentLinkGroup := g.Group("/payment-link", mw.RateLimiter(paymentLinkRL))
 
-	paymentLinkGroup.GET("", handler.ListPaymentLinks)
-	paymentLinkGroup.GET("/:paymentLinkId", handler.GetPaymentLink)
-	paymentLinkGroup.DELETE("/:paymentLinkId", handler.DeletePaymentLink)
-	paymentLinkGroup.POST("", handler.CreatePaymentLink)
+	paymentLinkGroup.GET("", handler.ListPaymentLinks, paymentLinksRead)
+	paymentLinkGroup.GET("/:paymentLinkId", handler.GetPaymentLink, paymentLinksRead)
+	paymentLinkGroup.DELETE("/:paymentLinkId", handler.DeletePaymentLink, paymentLinksWrite, canManagePayments)
+	paymentLinkGroup.POST("", handler.CreatePaymentLink, paymentLinksWrite, canManagePayments)
+
+	g.GET("/balance", handler.ListBalances, balancesRead)
+
+	g.GET("/rate-snapshot/export", handler.ExportRateSnapshots, paymentsRead, mw.RateLimiter(mw.NewRateLimiterMemoryStore(1)))
+
+	// Merchant ledger
+	ledgerRL := mw.NewRateLimiterMemoryStore(10) // 10 requests per second
+	ledgerGroup := g.Group("/ledger", mw.RateLimiter(ledgerRL))
+
+	ledgerGroup.GET("/balances", handler.GetLedgerBalances, balancesRead)
+	ledgerGroup.GET("/totals", handler.GetLedgerTotals, balancesRead)
+	ledgerGroup.GET("/entries", handler.ListLedgerEntries, balancesRead)
+	ledgerGroup.POST("/refunds", handler.CreateLedgerRefund, refundsWrite, canRefund)
+	ledgerGroup.GET("/export", handler.ExportLedger, balancesRead, mw.RateLimiter(mw.NewRateLimiterMemoryStore(1)))
+
+	// Webhook endpoints
+	webhookEndpointRL := mw.NewRateLimiterMemoryStore(10) // 10 requests per second
+	webhookEndpointGroup := g.Group("/webhook-endpoints", canManageWebhooks, mw.RateLimiter(webhookEndpointRL))
+
+	webhookEndpointGroup.GET("", handler.ListWebhookEndpoints, webhooksRead)
+	webhookEndpointGroup.POST("", handler.CreateWebhookEndpoint, webhooksWrite, requiresReauthMW)
+	webhookEndpointGroup.GET("/:endpointId", handler.GetWebhookEndpoint, webhooksRead)
+	webhookEndpointGroup.PUT("/:endpointId", handler.UpdateWebhookEndpoint, webhooksWrite, requiresReauthMW)
+	webhookEndpointGroup.DELETE("/:endpointId", handler.DeleteWebhookEndpoint, webhooksWrite, requiresReauthMW)
+	webhookEndpointGroup.POST("/:endpointId/rotate-secret", handler.RotateWebhookEndpointSecret, webhooksWrite, requiresReauthMW)
+	webhookEndpointGroup.POST("/:endpointId/test", handler.TestWebhookEndpoint, webhooksWrite, mw.RateLimiter(mw.NewRateLimiterMemoryStore(1)))
+
+	// Webhook delivery log
+	webhookDeliveryRL := mw.NewRateLimiterMemoryStore(10) // 10 requests per second
+	webhookDeliveryGroup := g.Group("/webhook-deliveries", canManageWebhooks, mw.RateLimiter(webhookDeliveryRL))
+
+	webhookDeliveryGroup.GET("", handler.ListWebhookDeliveries, webhooksRead)
+	webhookDeliveryGroup.GET("/:deliveryId", handler.GetWebhookDelivery, webhooksRead)
+	webhookDeliveryGroup.POST("/:deliveryId/replay", handler.ReplayWebhookDelivery, webhooksWrite)
+
+	g.GET("/customer", handler.ListCustomers, customersRead)
+	g.GET("/customer/:customerId", handler.GetCustomerDetails, customersRead)
+
+	// Recurring billing for merchant's customers
+	billingPlanGroup := g.Group("/billing-plan")
+
+	billingPlanGroup.GET("", handler.ListBillingPlans, subscriptionsRead)
+	billingPlanGroup.POST("", handler.CreateBillingPlan, subscriptionsWrite, canManagePayments)
+	billingPlanGroup.GET("/:planId", handler.GetBillingPlan, subscriptionsRead)
+	billingPlanGroup.DELETE("/:planId", handler.DeactivateBillingPlan, subscriptionsWrite, canManagePayments)
 
-	g.GET("/balance", handler.ListBalances)
+	subscriptionRL := mw.NewRateLimiterMemoryStore(10) // 10 requests per second
+	customerSubscriptionGroup := g.Group("/customer-subscription", mw.RateLimiter(subscriptionRL))
 
-	g.GET("/customer", handler.ListCustomers)
-	g.GET("/customer/:customerId", handler.GetCustomerDetails)
+	customerSubscriptionGroup.GET("", handler.ListCustomerSubscriptions, subscriptionsRead)
+	customerSubscriptionGroup.POST("", handler.CreateCustomerSubscription, subscriptionsWrite, canManagePayments)
+	customerSubscriptionGroup.GET("/:subscriptionId", handler.GetCustomerSubscription, subscriptionsRead)
+	customerSubscriptionGroup.POST("/:subscriptionId/cancel", handler.CancelCustomerSubscription, subscriptionsWrite, canManagePayments)
+	customerSubscriptionGroup.GET("/:subscriptionId/invoices", handler.ListCustomerSubscriptionInvoices, subscriptionsRead)
 }
 
 // WithPaymentAPI setups routes public-facing payment api (pay.o2pay.co)
@@ -260,6 +426,7 @@ func WithPaymentAPI(handler *paymentapi.Handler, cfg Config) Opt {
 
 		paymentAPI.GET("/csrf-cookie", handler.GetCookie)
 		paymentAPI.GET("/currency-convert", handler.GetExchangeRate)
+		paymentAPI.GET("/branding/logo/:filename", handler.GetMerchantLogo)
 
 		paymentGroup := paymentAPI.Group(
 			"/payment/:paymentId",
@@ -273,6 +440,7 @@ func WithPaymentAPI(handler *paymentapi.Handler, cfg Config) Opt {
 		paymentGroup.POST("/method", handler.CreatePaymentMethod)
 
 		paymentGroup.GET("/supported-method", handler.GetSupportedMethods)
+		paymentGroup.GET("/events", handler.StreamPayment)
 
 		paymentLinkGroup := paymentAPI.Group("/payment-link")
 
@@ -2,6 +2,8 @@ package http
 
 import (
 	"context"
+	"fmt"
+	"net"
 	"net/http"
 	"strings"
 
@@ -20,9 +22,22 @@ type Config struct {
 	CSRF    middleware.CSRFConfig    `yaml:"csrf"`
 	CORS    middleware.CORSConfig    `yaml:"cors"`
 
+	TrustedProxies []string `yaml:"trusted_proxies" env:"WEB_TRUSTED_PROXIES" env-description:"Comma separated list of reverse proxy CIDRs allowed to set X-Forwarded-For. When empty, client IP is the peer address. Example: 10.0.0.0/8"`
+
 	EnableInternalAPI bool `yaml:"enable_internal_api" env:"WEB_ENABLE_INTERNAL_API" env-default:"false" env-description:"Enables internal API /internal/v1/*. DO NOT EXPOSE TO PUBLIC"`
 }
 
+// Validate checks TrustedProxies, called on config load.
+func (c *Config) Validate() error {
+	for _, raw := range c.TrustedProxies {
+		if _, _, err := net.ParseCIDR(strings.TrimSpace(raw)); err != nil {
+			return fmt.Errorf("invalid trusted proxy CIDR %q", raw)
+		}
+	}
+
+	return nil
+}
+
 type Server struct {
 	echo    *echo.Echo
 	address string
@@ -47,6 +62,15 @@ func New(cfg Config, opts ...Opt) *Server {
 	e := echo.New()
 	e.HideBanner = true
 	e.HidePort = true
+
+	// Client IP is used by API token allow-lists and rate limits, so
+	// X-Forwarded-For is never taken from the client as is.
+	if len(cfg.TrustedProxies) > 0 {
+		e.IPExtractor = echo.ExtractIPFromXFFHeader(trustedProxies(cfg.TrustedProxies)...)
+	} else {
+		e.IPExtractor = echo.ExtractIPDirect()
+	}
+
 	srv := &Server{
 		echo:    e,
 		address: cfg.Address + ":" + cfg.Port,
@@ -61,9 +85,37 @@ func New(cfg Config, opts ...Opt) *Server {
 		option(srv)
 	}
 
+	if len(cfg.TrustedProxies) == 0 && srv.logger != nil {
+		srv.logger.Warn().Msg(
+			"no trusted proxies configured, X-Forwarded-For is ignored and client IP is the peer address. " +
+				"Set WEB_TRUSTED_PROXIES when running behind a load balancer or reverse proxy",
+		)
+	}
+
 	return srv
 }
 
+// trustedProxies only trusts X-Forwarded-For set by listed proxies, so
+// clients can't spoof their IP. CIDRs are checked by Config.Validate.
+func trustedProxies(cidrs []string) []echo.TrustOption {
+	opts := []echo.TrustOption{
+		echo.TrustLoopback(false),
+		echo.TrustLinkLocal(false),
+		echo.TrustPrivateNet(false),
+	}
+
+	for _, raw := range cidrs {
+		_, network, err := net.ParseCIDR(strings.TrimSpace(raw))
+		if err != nil {
+			continue
+		}
+
+		opts = append(opts, echo.TrustIPRange(network))
+	}
+
+	return opts
+}
+
 func WithRecover() Opt {
 	return func(s *Server) {
 		s.echo.Use(middleware.Recover(s.logger))
@@ -0,0 +1,288 @@
+package audit
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ActorType who made a change.
+type ActorType string
+
+const (
+	// ActorUser dashboard user acting as a merchant member.
+	ActorUser ActorType = "user"
+	// ActorToken merchant API token.
+	ActorToken ActorType = "token"
+	// ActorAdmin super admin acting from the admin panel.
+	ActorAdmin ActorType = "admin"
+)
+
+func (t ActorType) Valid() bool {
+	switch t {
+	case ActorUser, ActorToken, ActorAdmin:
+		return true
+	default:
+		return false
+	}
+}
+
+// Actor who made a change and from where.
+type Actor struct {
+	Type      ActorType
+	UserID    int64
+	TokenID   int64
+	Email     string
+	IP        string
+	UserAgent string
+}
+
+func (a Actor) String() string {
+	switch a.Type {
+	case ActorToken:
+		return "API token"
+	case ActorAdmin:
+		return "Super admin " + a.Email
+	default:
+		return a.Email
+	}
+}
+
+// Action is a kind of configuration change.
+type Action string
+
+const (
+	ActionWebhookUpdate               Action = "webhook.update"
+	ActionWebhookEndpointCreate       Action = "webhook_endpoint.create"
+	ActionWebhookEndpointUpdate       Action = "webhook_endpoint.update"
+	ActionWebhookEndpointDelete       Action = "webhook_endpoint.delete"
+	ActionWebhookEndpointRotateSecret Action = "webhook_endpoint.rotate_secret"
+	ActionPaymentMethodsUpdate        Action = "payment_methods.update"
+	ActionFeeSettingsUpdate           Action = "fee_settings.update"
+	ActionAPITokenCreate              Action = "api_token.create"
+	ActionAPITokenDelete              Action = "api_token.delete"
+	ActionXpubWalletCreate            Action = "xpub_wallet.create"
+	ActionXpubWalletDelete            Action = "xpub_wallet.delete"
+	ActionCollectorCreate             Action = "evm_collector.create"
+	ActionCollectorDelete             Action = "evm_collector.delete"
+	ActionCollectorDeploy             Action = "evm_collector.deploy"
+	ActionCollectorDeployTx           Action = "evm_collector.deploy_tx"
+	ActionCollectorFactoryUpdate      Action = "collector_factory.update"
+	ActionMemberInvite                Action = "member.invite"
+	ActionMemberUpdate                Action = "member.update"
+	ActionMemberRemove                Action = "member.remove"
+	ActionSecurityAlertsUpdate        Action = "security_alerts.update"
+)
+
+type actionInfo struct {
+	description string
+	// highRisk changes can redirect funds or data, owners are emailed about them.
+	highRisk bool
+}
+
+var actions = map[Action]actionInfo{
+	ActionWebhookUpdate:               {description: "webhook URL was changed", highRisk: true},
+	ActionWebhookEndpointCreate:       {description: "webhook endpoint was added", highRisk: true},
+	ActionWebhookEndpointUpdate:       {description: "webhook endpoint was changed", highRisk: true},
+	ActionWebhookEndpointDelete:       {description: "webhook endpoint was deleted"},
+	ActionWebhookEndpointRotateSecret: {description: "webhook signature secret was rotated"},
+	ActionPaymentMethodsUpdate:        {description: "payment methods were changed"},
+	ActionFeeSettingsUpdate:           {description: "fee settings were changed"},
+	ActionAPITokenCreate:              {description: "API token was created", highRisk: true},
+	ActionAPITokenDelete:              {description: "API token was deleted"},
+	ActionXpubWalletCreate:            {description: "xpub wallet was added", highRisk: true},
+	ActionXpubWalletDelete:            {description: "xpub wallet was deleted", highRisk: true},
+	ActionCollectorCreate:             {description: "EVM collector was set up", highRisk: true},
+	ActionCollectorDelete:             {description: "EVM collector was deleted", highRisk: true},
+	ActionCollectorDeploy:             {description: "EVM collector deployment was started", highRisk: true},
+	ActionCollectorDeployTx:           {description: "EVM collector deployment transaction was set"},
+	ActionCollectorFactoryUpdate:      {description: "collector factory was changed"},
+	ActionMemberInvite:                {description: "team member was invited"},
+	ActionMemberUpdate:                {description: "team member role was changed"},
+	ActionMemberRemove:                {description: "team member was removed"},
+	ActionSecurityAlertsUpdate:        {description: "security alerts were changed", highRisk: true},
+}
+
+func (a Action) Valid() bool {
+	_, ok := actions[a]
+	return ok
+}
+
+func (a Action) Description() string {
+	if info, ok := actions[a]; ok {
+		return info.description
+	}
+
+	return string(a)
+}
+
+// HighRisk changes can redirect funds or data.
+func (a Action) HighRisk() bool {
+	return actions[a].highRisk
+}
+
+// Values of changed settings, e.g. {"url": "https://..."}.
+type Values map[string]any
+
+// Change to record. Zero MerchantID is a system-wide change made by a super
+// admin. Before is empty for created resources, After for deleted ones.
+type Change struct {
+	MerchantID int64
+	Action     Action
+	ResourceID string
+	Before     Values
+	After      Values
+}
+
+// Entry of the audit log.
+type Entry struct {
+	ID           int64
+	UUID         uuid.UUID
+	MerchantID   int64
+	MerchantUUID *uuid.UUID
+	Actor        Actor
+	Action       Action
+	ResourceID   string
+	Before       Values
+	After        Values
+	CreatedAt    time.Time
+}
+
+const (
+	// Redacted replaces secret values.
+	Redacted = "[redacted]"
+	// RedactedChanged replaces secret value that differs from the previous one.
+	RedactedChanged = "[redacted, changed]"
+)
+
+// secretKeys value keys containing these words are redacted.
+var secretKeys = []string{"secret", "password", "privatekey", "mnemonic"}
+
+func isSecretKey(key string) bool {
+	key = strings.ToLower(key)
+	if key == "token" {
+		return true
+	}
+
+	for _, k := range secretKeys {
+		if strings.Contains(key, k) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Redact returns copies of before and after with secret values replaced. A
+// secret that was changed is marked with RedactedChanged in after, so the log
+// still shows that it was changed.
+func Redact(before, after Values) (Values, Values) {
+	redactedBefore, redactedAfter := redactValues(before), redactValues(after)
+
+	for key, value := range after {
+		if !isSecretKey(key) || isEmpty(value) {
+			continue
+		}
+
+		if prev, ok := before[key]; ok && !reflect.DeepEqual(prev, value) {
+			redactedAfter[key] = RedactedChanged
+		}
+	}
+
+	return redactedBefore, redactedAfter
+}
+
+func redactValues(values Values) Values {
+	if values == nil {
+		return nil
+	}
+
+	result := make(Values, len(values))
+	for key, value := range values {
+		switch {
+		case isSecretKey(key) && !isEmpty(value):
+			result[key] = Redacted
+		case isNested(value):
+			result[key] = redactValues(toValues(value))
+		default:
+			result[key] = value
+		}
+	}
+
+	return result
+}
+
+func isEmpty(value any) bool {
+	return value == nil || value == ""
+}
+
+func isNested(value any) bool {
+	switch value.(type) {
+	case Values, map[string]any:
+		return true
+	default:
+		return false
+	}
+}
+
+func toValues(value any) Values {
+	switch v := value.(type) {
+	case Values:
+		return v
+	case map[string]any:
+		return v
+	default:
+		return nil
+	}
+}
+
+// Mask hides the middle of a long identifier such as xpub leaving enough to
+// recognize it.
+func Mask(value string) string {
+	const keep = 8
+	if len(value) <= keep*2 {
+		return value
+	}
+
+	return value[:keep] + "…" + value[len(value)-keep:]
+}
+
+// Details describes changed values as "key: before → after" lines sorted by key.
+func Details(before, after Values) []string {
+	keys := make(map[string]struct{}, len(before)+len(after))
+	for key := range before {
+		keys[key] = struct{}{}
+	}
+	for key := range after {
+		keys[key] = struct{}{}
+	}
+
+	sorted := make([]string, 0, len(keys))
+	for key := range keys {
+		sorted = append(sorted, key)
+	}
+	sort.Strings(sorted)
+
+	var lines []string
+	for _, key := range sorted {
+		prev, hadPrev := before[key]
+		next, hasNext := after[key]
+
+		switch {
+		case hadPrev && hasNext && reflect.DeepEqual(prev, next):
+			continue
+		case !hadPrev:
+			lines = append(lines, fmt.Sprintf("%s: %v", key, next))
+		case !hasNext:
+			lines = append(lines, fmt.Sprintf("%s: %v → (removed)", key, prev))
+		default:
+			lines = append(lines, fmt.Sprintf("%s: %v → %v", key, prev, next))
+		}
+	}
+
+	return lines
+}
@@ -0,0 +1,77 @@
+package audit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedact(t *testing.T) {
+	t.Run("redacts secrets", func(t *testing.T) {
+		before, after := Redact(
+			Values{"url": "https://a.example", "secret": "s1"},
+			Values{"url": "https://b.example", "secret": "s1", "nested": map[string]any{"password": "p"}},
+		)
+
+		assert.Equal(t, Values{"url": "https://a.example", "secret": Redacted}, before)
+		assert.Equal(t, Values{
+			"url":    "https://b.example",
+			"secret": Redacted,
+			"nested": Values{"password": Redacted},
+		}, after)
+	})
+
+	t.Run("marks changed secret", func(t *testing.T) {
+		before, after := Redact(Values{"secret": "s1"}, Values{"secret": "s2"})
+
+		assert.Equal(t, Values{"secret": Redacted}, before)
+		assert.Equal(t, Values{"secret": RedactedChanged}, after)
+	})
+
+	t.Run("keeps empty secret", func(t *testing.T) {
+		before, after := Redact(Values{"secret": ""}, Values{"previousSecret": "s1"})
+
+		assert.Equal(t, Values{"secret": ""}, before)
+		assert.Equal(t, Values{"previousSecret": Redacted}, after)
+	})
+
+	t.Run("nil values", func(t *testing.T) {
+		before, after := Redact(nil, Values{"token": "t"})
+
+		assert.Nil(t, before)
+		assert.Equal(t, Values{"token": Redacted}, after)
+	})
+
+	t.Run("doesn't modify input", func(t *testing.T) {
+		input := Values{"secret": "s1"}
+		_, _ = Redact(input, nil)
+
+		assert.Equal(t, "s1", input["secret"])
+	})
+}
+
+func TestDetails(t *testing.T) {
+	lines := Details(
+		Values{"url": "https://a.example", "enabled": true, "description": "old"},
+		Values{"url": "https://b.example", "enabled": true, "eventTypes": []string{"payment.success"}},
+	)
+
+	assert.Equal(t, []string{
+		"description: old → (removed)",
+		"eventTypes: [payment.success]",
+		"url: https://a.example → https://b.example",
+	}, lines)
+}
+
+func TestMask(t *testing.T) {
+	assert.Equal(t, "short", Mask("short"))
+	assert.Equal(t, "xpub6CUG…Hb8JgSrh", Mask("xpub6CUGRUonZSQ4TWtTMmzXdrXDtypWKiKrhko4egpiMZbpiaQL2jkwSB1icqYh2cfDfVxdx4df189oLKnC5fSwqPfgyP3hooxujYzAu3fDVmz4TWtTMHb8JgSrh"))
+}
+
+func TestAction(t *testing.T) {
+	assert.True(t, ActionXpubWalletCreate.Valid())
+	assert.True(t, ActionCollectorCreate.HighRisk())
+	assert.False(t, ActionFeeSettingsUpdate.HighRisk())
+	assert.False(t, Action("unknown").Valid())
+	assert.Equal(t, "unknown", Action("unknown").Description())
+}
@@ -0,0 +1,213 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/cryptolink/cryptolink/internal/db/repository"
+	"github.com/cryptolink/cryptolink/internal/service/email"
+	"github.com/cryptolink/cryptolink/internal/service/merchant"
+	"github.com/google/uuid"
+	"github.com/jackc/pgtype"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+// Service keeps append-only log of security-sensitive merchant configuration
+// changes and alerts owners about high-risk ones.
+type Service struct {
+	repo      *repository.Queries
+	merchants *merchant.Service
+	emails    *email.Service
+	logger    *zerolog.Logger
+}
+
+type ListParams struct {
+	MerchantID  int64
+	ActorType   ActorType
+	ActorUserID int64
+	Action      Action
+	BeforeID    int64
+	Limit       int
+}
+
+func New(
+	repo *repository.Queries,
+	merchants *merchant.Service,
+	emails *email.Service,
+	logger *zerolog.Logger,
+) *Service {
+	log := logger.With().Str("channel", "audit_service").Logger()
+
+	return &Service{
+		repo:      repo,
+		merchants: merchants,
+		emails:    emails,
+		logger:    &log,
+	}
+}
+
+// Record appends the change to the audit log with secrets redacted and emails
+// owners about high-risk changes. The change has already happened, so errors
+// are logged rather than returned.
+func (s *Service) Record(ctx context.Context, actor Actor, change Change) {
+	before, after := Redact(change.Before, change.After)
+	now := time.Now()
+
+	err := s.repo.CreateAuditLog(ctx, repository.CreateAuditLogParams{
+		Uuid:         uuid.New(),
+		MerchantID:   change.MerchantID,
+		ActorType:    string(actor.Type),
+		ActorUserID:  actor.UserID,
+		ActorTokenID: actor.TokenID,
+		ActorEmail:   actor.Email,
+		IP:           actor.IP,
+		UserAgent:    truncate(actor.UserAgent, 512),
+		Action:       string(change.Action),
+		ResourceID:   change.ResourceID,
+		Before:       valuesToJSONB(before),
+		After:        valuesToJSONB(after),
+		CreatedAt:    now,
+	})
+	if err != nil {
+		s.logger.Error().Err(err).
+			Int64("merchant_id", change.MerchantID).
+			Str("action", string(change.Action)).
+			Msg("unable to record audit log")
+	}
+
+	if change.MerchantID == 0 || !change.Action.HighRisk() || s.emails == nil {
+		return
+	}
+
+	go s.alertOwners(context.Background(), actor, change.MerchantID, change.Action, before, after, now)
+}
+
+// List returns audit log entries newest first. Zero MerchantID lists entries
+// of all merchants.
+func (s *Service) List(ctx context.Context, params ListParams) ([]*Entry, error) {
+	rows, err := s.repo.ListAuditLog(ctx, repository.ListAuditLogParams{
+		MerchantID:  params.MerchantID,
+		ActorType:   string(params.ActorType),
+		ActorUserID: params.ActorUserID,
+		Action:      string(params.Action),
+		BeforeID:    params.BeforeID,
+		Limit:       int32(params.Limit),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to list audit log")
+	}
+
+	results := make([]*Entry, 0, len(rows))
+	for _, row := range rows {
+		results = append(results, entryFromRepo(row))
+	}
+
+	return results, nil
+}
+
+func (s *Service) alertOwners(
+	ctx context.Context,
+	actor Actor,
+	merchantID int64,
+	action Action,
+	before, after Values,
+	occurredAt time.Time,
+) {
+	mt, err := s.merchants.GetByID(ctx, merchantID, false)
+	if err != nil {
+		s.logger.Error().Err(err).Int64("merchant_id", merchantID).Msg("unable to get merchant for security alert")
+		return
+	}
+
+	// turning alerts off is alerted about as well
+	if !mt.Settings().SecurityAlertsEnabled() && action != ActionSecurityAlertsUpdate {
+		return
+	}
+
+	members, err := s.merchants.ListMembers(ctx, merchantID)
+	if err != nil {
+		s.logger.Error().Err(err).Int64("merchant_id", merchantID).Msg("unable to list owners for security alert")
+		return
+	}
+
+	for _, m := range members {
+		if m.Role != merchant.RoleOwner {
+			continue
+		}
+
+		s.emails.SendSecurityAlert(ctx, email.SecurityAlertParams{
+			OwnerEmail:   m.Email,
+			MerchantName: mt.Name,
+			Change:       action.Description(),
+			Actor:        actor.String(),
+			IP:           actor.IP,
+			UserAgent:    actor.UserAgent,
+			Details:      Details(before, after),
+			OccurredAt:   occurredAt.UTC().Format("2006-01-02 15:04 UTC"),
+		})
+	}
+}
+
+func valuesToJSONB(values Values) pgtype.JSONB {
+	if values == nil {
+		return pgtype.JSONB{Status: pgtype.Null}
+	}
+
+	raw, err := json.Marshal(values)
+	if err != nil {
+		return pgtype.JSONB{Status: pgtype.Null}
+	}
+
+	return pgtype.JSONB{Bytes: raw, Status: pgtype.Present}
+}
+
+func valuesFromJSONB(raw pgtype.JSONB) Values {
+	if raw.Status != pgtype.Present {
+		return nil
+	}
+
+	var values Values
+	if err := json.Unmarshal(raw.Bytes, &values); err != nil {
+		return nil
+	}
+
+	return values
+}
+
+func entryFromRepo(row repository.AuditLog) *Entry {
+	var merchantUUID *uuid.UUID
+	if row.MerchantUuid.Valid {
+		merchantUUID = &row.MerchantUuid.UUID
+	}
+
+	return &Entry{
+		ID:           row.ID,
+		UUID:         row.Uuid,
+		MerchantID:   row.MerchantID,
+		MerchantUUID: merchantUUID,
+		Actor: Actor{
+			Type:      ActorType(row.ActorType),
+			UserID:    row.ActorUserID,
+			TokenID:   row.ActorTokenID,
+			Email:     row.ActorEmail,
+			IP:        row.IP,
+			UserAgent: row.UserAgent,
+		},
+		Action:     Action(row.Action),
+		ResourceID: row.ResourceID,
+		Before:     valuesFromJSONB(row.Before),
+		After:      valuesFromJSONB(row.After),
+		CreatedAt:  row.CreatedAt,
+	}
+}
+
+func truncate(s string, maxLen int) string {
+	if len(s) <= maxLen {
+		return s
+	}
+
+	return strings.ToValidUTF8(s[:maxLen], "")
+}
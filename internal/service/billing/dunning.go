@@ -0,0 +1,355 @@
+package billing
+
+import (
+	"context"
+	"time"
+
+	"github.com/cryptolink/cryptolink/internal/money"
+	"github.com/cryptolink/cryptolink/internal/service/email"
+	"github.com/cryptolink/cryptolink/internal/service/payment"
+	"github.com/jackc/pgx/v4"
+	"github.com/pkg/errors"
+)
+
+const dueBatchLimit = 100
+
+// GetSubscriptionByID returns merchant's subscription by internal id.
+func (s *Service) GetSubscriptionByID(ctx context.Context, merchantID, id int64) (*Subscription, error) {
+	sub, err := s.getSubscriptionByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if sub.MerchantID != merchantID {
+		return nil, ErrSubscriptionNotFound
+	}
+
+	return sub, nil
+}
+
+// HandlePaymentStatus is called on every payment status update. When the
+// payment belongs to an open invoice and is successful, the invoice is marked
+// as paid and subscription is (re)activated for the invoiced period.
+func (s *Service) HandlePaymentStatus(ctx context.Context, merchantID, paymentID int64) error {
+	row := s.db.QueryRow(ctx, `
+		SELECT `+invoiceColumns+`
+		FROM customer_subscription_invoices i
+		JOIN payments p ON p.id = i.payment_id
+		WHERE i.payment_id = $1 AND p.merchant_id = $2 AND i.status = 'open'`, paymentID, merchantID,
+	)
+
+	inv, err := scanInvoice(row)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		// not a subscription payment
+		return nil
+	case err != nil:
+		return errors.Wrap(err, "unable to get invoice by payment")
+	}
+
+	pt, err := s.payments.GetByID(ctx, merchantID, paymentID)
+	if err != nil {
+		return errors.Wrap(err, "unable to get payment")
+	}
+
+	if pt.Status != payment.StatusSuccess {
+		return nil
+	}
+
+	sub, err := s.getSubscriptionByID(ctx, inv.SubscriptionID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+
+	err = s.db.BeginFunc(ctx, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, `
+			UPDATE customer_subscription_invoices SET status = $2, paid_at = $3, updated_at = $3
+			WHERE id = $1`, inv.ID, string(InvoicePaid), now,
+		); err != nil {
+			return err
+		}
+
+		// Customer paid after the subscription was cancelled: keep the payment,
+		// but don't resurrect the subscription.
+		if sub.Status == StatusCancelled {
+			return nil
+		}
+
+		_, err := tx.Exec(ctx, `
+			UPDATE customer_subscriptions
+			SET status = $2, current_period_start = $3, current_period_end = $4,
+			    past_due_since = NULL, updated_at = $5
+			WHERE id = $1`, sub.ID, StatusActive.String(), inv.PeriodStart, inv.PeriodEnd, now,
+		)
+
+		return err
+	})
+	if err != nil {
+		return errors.Wrap(err, "unable to mark invoice as paid")
+	}
+
+	s.logger.Info().
+		Int64("merchant_id", merchantID).Int64("subscription_id", sub.ID).Int64("payment_id", paymentID).
+		Msg("subscription invoice paid")
+
+	if sub.Status != StatusCancelled {
+		s.publishTransition(sub, StatusActive)
+	}
+
+	return nil
+}
+
+// ProcessDueSubscriptions is a scheduler job that drives subscriptions through
+// their lifecycle: issues renewal invoices, moves overdue subscriptions to
+// past_due, sends reminders and cancels subscriptions after the grace period.
+func (s *Service) ProcessDueSubscriptions(ctx context.Context) error {
+	now := time.Now().UTC()
+
+	steps := []struct {
+		name string
+		fn   func(context.Context, time.Time) error
+	}{
+		{"issue renewals", s.issueRenewals},
+		{"end periods", s.endPeriods},
+		{"cancel overdue", s.cancelOverdue},
+		{"send reminders", s.sendReminders},
+	}
+
+	for _, step := range steps {
+		if err := step.fn(ctx, now); err != nil {
+			return errors.Wrap(err, "unable to "+step.name)
+		}
+	}
+
+	return nil
+}
+
+// issueRenewals creates invoices for the next period of subscriptions whose
+// current period ends within RenewalLeadTime. past_due subscriptions are
+// included in case issuing the renewal failed before the period was over.
+func (s *Service) issueRenewals(ctx context.Context, now time.Time) error {
+	subs, err := s.listSubscriptions(ctx, `
+		SELECT `+subscriptionColumns+` FROM `+subscriptionFrom+`
+		WHERE s.status IN ('active', 'past_due') AND NOT s.cancel_at_period_end AND s.current_period_end <= $1
+		  AND NOT EXISTS (
+		      SELECT 1 FROM customer_subscription_invoices i
+		      WHERE i.subscription_id = s.id AND i.period_start = s.current_period_end
+		  )
+		ORDER BY s.current_period_end
+		LIMIT $2`, now.Add(s.config.RenewalLeadTime), dueBatchLimit,
+	)
+	if err != nil {
+		return err
+	}
+
+	for _, sub := range subs {
+		plan, err := s.getPlanByID(ctx, sub.PlanID)
+		if err != nil {
+			return err
+		}
+
+		start := sub.CurrentPeriodEnd
+		end := nextPeriodEnd(start, plan.Interval, plan.IntervalCount)
+
+		if _, err := s.issueInvoice(ctx, sub, plan, start, end); err != nil {
+			s.logger.Error().Err(err).
+				Int64("merchant_id", sub.MerchantID).Int64("subscription_id", sub.ID).
+				Msg("unable to issue renewal invoice")
+		}
+	}
+
+	return nil
+}
+
+// endPeriods handles active subscriptions whose period is over: those marked
+// for cancellation are cancelled, the rest have an unpaid renewal invoice and
+// become past_due.
+func (s *Service) endPeriods(ctx context.Context, now time.Time) error {
+	subs, err := s.listSubscriptions(ctx, `
+		SELECT `+subscriptionColumns+` FROM `+subscriptionFrom+`
+		WHERE s.status = 'active' AND s.current_period_end <= $1
+		ORDER BY s.current_period_end
+		LIMIT $2`, now, dueBatchLimit,
+	)
+	if err != nil {
+		return err
+	}
+
+	for _, sub := range subs {
+		if sub.CancelAtPeriodEnd {
+			if err := s.cancel(ctx, sub); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := s.setStatus(ctx, sub, StatusPastDue); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// cancelOverdue cancels past_due subscriptions after GracePeriod and pending
+// subscriptions whose first invoice was not paid within GracePeriod.
+func (s *Service) cancelOverdue(ctx context.Context, now time.Time) error {
+	deadline := now.Add(-s.config.GracePeriod)
+
+	subs, err := s.listSubscriptions(ctx, `
+		SELECT `+subscriptionColumns+` FROM `+subscriptionFrom+`
+		WHERE (s.status = 'past_due' AND s.past_due_since <= $1)
+		   OR (s.status = 'pending' AND s.created_at <= $1)
+		ORDER BY s.id
+		LIMIT $2`, deadline, dueBatchLimit,
+	)
+	if err != nil {
+		return err
+	}
+
+	for _, sub := range subs {
+		if err := s.cancel(ctx, sub); err != nil {
+			return err
+		}
+
+		s.sendCancelledEmail(ctx, sub)
+	}
+
+	return nil
+}
+
+// sendReminders re-sends the pay link of open invoices every ReminderInterval
+// (up to MaxReminders). Unlocked payments expire quickly, so when the invoice's
+// payment has already failed a fresh payment is issued for the same period.
+func (s *Service) sendReminders(ctx context.Context, now time.Time) error {
+	rows, err := s.db.Query(ctx, `
+		SELECT `+invoiceColumns+`
+		FROM customer_subscription_invoices i
+		JOIN payments p ON p.id = i.payment_id
+		JOIN customer_subscriptions s ON s.id = i.subscription_id
+		WHERE i.status = 'open' AND s.status <> 'cancelled'
+		  AND i.reminders_sent < $1 AND i.last_notified_at <= $2
+		ORDER BY i.last_notified_at
+		LIMIT $3`, s.config.MaxReminders, now.Add(-s.config.ReminderInterval), dueBatchLimit,
+	)
+	if err != nil {
+		return err
+	}
+
+	var invoices []*Invoice
+	for rows.Next() {
+		inv, err := scanInvoice(rows)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		invoices = append(invoices, inv)
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, inv := range invoices {
+		if err := s.remind(ctx, inv); err != nil {
+			s.logger.Error().Err(err).
+				Int64("subscription_id", inv.SubscriptionID).Int64("invoice_id", inv.ID).
+				Msg("unable to send invoice reminder")
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) remind(ctx context.Context, inv *Invoice) error {
+	sub, err := s.getSubscriptionByID(ctx, inv.SubscriptionID)
+	if err != nil {
+		return err
+	}
+
+	plan, err := s.getPlanByID(ctx, sub.PlanID)
+	if err != nil {
+		return err
+	}
+
+	pt, err := s.payments.GetByID(ctx, sub.MerchantID, inv.PaymentID)
+	if err != nil {
+		return errors.Wrap(err, "unable to get invoice payment")
+	}
+
+	switch pt.Status {
+	case payment.StatusFailed:
+		pt, err = s.createInvoicePayment(ctx, sub, plan, inv.PeriodStart, inv.PeriodEnd)
+		if err != nil {
+			return err
+		}
+	case payment.StatusPending:
+		// customer hasn't started the payment yet, remind with the same link
+	default:
+		// customer is paying right now, no need to remind
+		return nil
+	}
+
+	_, err = s.db.Exec(ctx, `
+		UPDATE customer_subscription_invoices
+		SET payment_id = $2, reminders_sent = reminders_sent + 1, last_notified_at = $3, updated_at = $3
+		WHERE id = $1`, inv.ID, pt.ID, time.Now().UTC(),
+	)
+	if err != nil {
+		return errors.Wrap(err, "unable to update invoice")
+	}
+
+	s.sendInvoiceEmail(ctx, sub, plan, inv, pt, true)
+
+	return nil
+}
+
+func (s *Service) sendInvoiceEmail(ctx context.Context, sub *Subscription, plan *Plan, inv *Invoice, pt *payment.Payment, isReminder bool) {
+	if s.emails == nil || sub.CustomerEmail == "" {
+		return
+	}
+
+	mt, err := s.merchants.GetByID(ctx, sub.MerchantID, false)
+	if err != nil {
+		s.logger.Error().Err(err).Int64("merchant_id", sub.MerchantID).Msg("unable to get merchant")
+		return
+	}
+
+	s.emails.SendSubscriptionInvoice(ctx, email.SubscriptionInvoiceParams{
+		CustomerEmail: sub.CustomerEmail,
+		MerchantName:  mt.Name,
+		PlanName:      plan.Name,
+		Amount:        plan.Price.String(),
+		FiatSymbol:    money.FiatSymbol(money.FiatCurrency(plan.Price.Ticker())),
+		FiatCode:      plan.Price.Ticker(),
+		PeriodStart:   inv.PeriodStart,
+		PeriodEnd:     inv.PeriodEnd,
+		PaymentURL:    pt.PaymentURL,
+		IsReminder:    isReminder,
+		IsOverdue:     sub.Status == StatusPastDue,
+		Branding:      email.Branding(s.merchants.Branding(mt)),
+	})
+}
+
+func (s *Service) sendCancelledEmail(ctx context.Context, sub *Subscription) {
+	if s.emails == nil || sub.CustomerEmail == "" {
+		return
+	}
+
+	mt, err := s.merchants.GetByID(ctx, sub.MerchantID, false)
+	if err != nil {
+		s.logger.Error().Err(err).Int64("merchant_id", sub.MerchantID).Msg("unable to get merchant")
+		return
+	}
+
+	plan, err := s.getPlanByID(ctx, sub.PlanID)
+	if err != nil {
+		s.logger.Error().Err(err).Int64("subscription_id", sub.ID).Msg("unable to get plan")
+		return
+	}
+
+	s.emails.SendSubscriptionCancelled(ctx, sub.CustomerEmail, mt.Name, plan.Name, email.Branding(s.merchants.Branding(mt)))
+}
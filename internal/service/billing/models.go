@@ -0,0 +1,112 @@
+package billing
+
+import (
+	"time"
+
+	"github.com/cryptolink/cryptolink/internal/money"
+	"github.com/google/uuid"
+)
+
+// Plan is a merchant-defined recurring price: customer is charged Price
+// every IntervalCount x Interval.
+type Plan struct {
+	ID            int64
+	UUID          uuid.UUID
+	MerchantID    int64
+	Name          string
+	Description   *string
+	Price         money.Money
+	Interval      Interval
+	IntervalCount int
+	IsActive      bool
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+type Interval string
+
+const (
+	IntervalDay   Interval = "day"
+	IntervalWeek  Interval = "week"
+	IntervalMonth Interval = "month"
+	IntervalYear  Interval = "year"
+)
+
+func (i Interval) Valid() bool {
+	switch i {
+	case IntervalDay, IntervalWeek, IntervalMonth, IntervalYear:
+		return true
+	}
+
+	return false
+}
+
+// Subscription binds merchant's customer to a Plan.
+type Subscription struct {
+	ID                 int64
+	UUID               uuid.UUID
+	MerchantID         int64
+	PlanID             int64
+	CustomerID         int64
+	Status             Status
+	CurrentPeriodStart time.Time
+	CurrentPeriodEnd   time.Time
+	CancelAtPeriodEnd  bool
+	PastDueSince       *time.Time
+	CancelledAt        *time.Time
+	IsTest             bool
+	CreatedAt          time.Time
+	UpdatedAt          time.Time
+
+	// Joined fields
+	PlanUUID      uuid.UUID
+	CustomerUUID  uuid.UUID
+	CustomerEmail string
+}
+
+// Status of a customer subscription.
+//
+//	pending  -> active     first invoice paid
+//	active   -> past_due   period ended while renewal invoice is unpaid
+//	past_due -> active     overdue invoice paid
+//	past_due -> cancelled  grace period is over
+//	*        -> cancelled  cancelled by merchant
+type Status string
+
+const (
+	StatusPending   Status = "pending"
+	StatusActive    Status = "active"
+	StatusPastDue   Status = "past_due"
+	StatusCancelled Status = "cancelled"
+)
+
+func (s Status) String() string {
+	return string(s)
+}
+
+// Invoice is a bill for a single subscription period. PaymentID references
+// the payment customer is currently asked to pay.
+type Invoice struct {
+	ID             int64
+	SubscriptionID int64
+	PaymentID      int64
+	PeriodStart    time.Time
+	PeriodEnd      time.Time
+	Status         InvoiceStatus
+	RemindersSent  int
+	LastNotifiedAt time.Time
+	PaidAt         *time.Time
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+
+	// Joined fields
+	PaymentUUID uuid.UUID
+}
+
+type InvoiceStatus string
+
+const (
+	InvoiceOpen InvoiceStatus = "open"
+	InvoicePaid InvoiceStatus = "paid"
+	InvoiceVoid InvoiceStatus = "void"
+)
@@ -0,0 +1,43 @@
+package billing
+
+import "time"
+
+// nextPeriodEnd returns the end of a billing period that starts at start.
+// Monthly and yearly periods are clamped to the end of the target month, so
+// a subscription started on Jan 31 renews on Feb 28 (29), not on Mar 3.
+func nextPeriodEnd(start time.Time, interval Interval, count int) time.Time {
+	if count < 1 {
+		count = 1
+	}
+
+	switch interval {
+	case IntervalDay:
+		return start.AddDate(0, 0, count)
+	case IntervalWeek:
+		return start.AddDate(0, 0, 7*count)
+	case IntervalMonth:
+		return addMonthsClamped(start, count)
+	case IntervalYear:
+		return addMonthsClamped(start, 12*count)
+	}
+
+	return start.AddDate(0, 1, 0)
+}
+
+func addMonthsClamped(t time.Time, months int) time.Time {
+	year, month, day := t.Date()
+	hour, minute, sec := t.Clock()
+
+	// first day of the target month
+	target := time.Date(year, month+time.Month(months), 1, hour, minute, sec, t.Nanosecond(), t.Location())
+
+	if lastDay := daysIn(target.Year(), target.Month()); day > lastDay {
+		day = lastDay
+	}
+
+	return target.AddDate(0, 0, day-1)
+}
+
+func daysIn(year int, month time.Month) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
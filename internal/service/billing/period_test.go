@@ -0,0 +1,35 @@
+package billing
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNextPeriodEnd(t *testing.T) {
+	date := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, 10, 30, 0, 0, time.UTC)
+	}
+
+	for _, tt := range []struct {
+		name     string
+		start    time.Time
+		interval Interval
+		count    int
+		expected time.Time
+	}{
+		{name: "day", start: date(2024, 1, 31), interval: IntervalDay, count: 1, expected: date(2024, 2, 1)},
+		{name: "two weeks", start: date(2024, 1, 25), interval: IntervalWeek, count: 2, expected: date(2024, 2, 8)},
+		{name: "month", start: date(2024, 3, 15), interval: IntervalMonth, count: 1, expected: date(2024, 4, 15)},
+		{name: "month clamped leap year", start: date(2024, 1, 31), interval: IntervalMonth, count: 1, expected: date(2024, 2, 29)},
+		{name: "month clamped", start: date(2023, 1, 31), interval: IntervalMonth, count: 1, expected: date(2023, 2, 28)},
+		{name: "quarter over new year", start: date(2023, 11, 30), interval: IntervalMonth, count: 3, expected: date(2024, 2, 29)},
+		{name: "year from leap day", start: date(2024, 2, 29), interval: IntervalYear, count: 1, expected: date(2025, 2, 28)},
+		{name: "zero count defaults to one", start: date(2024, 5, 1), interval: IntervalMonth, count: 0, expected: date(2024, 6, 1)},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, nextPeriodEnd(tt.start, tt.interval, tt.count))
+		})
+	}
+}
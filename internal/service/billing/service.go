@@ -0,0 +1,552 @@
+package billing
+
+import (
+	"context"
+	"fmt"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/cryptolink/cryptolink/internal/bus"
+	"github.com/cryptolink/cryptolink/internal/money"
+	"github.com/cryptolink/cryptolink/internal/service/email"
+	"github.com/cryptolink/cryptolink/internal/service/merchant"
+	"github.com/cryptolink/cryptolink/internal/service/payment"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+type Config struct {
+	RenewalLeadTime  time.Duration `yaml:"renewal_lead_time" env:"OXYGEN_BILLING_RENEWAL_LEAD_TIME" env-default:"72h" env-description:"How long before period end a renewal invoice is issued"`
+	ReminderInterval time.Duration `yaml:"reminder_interval" env:"OXYGEN_BILLING_REMINDER_INTERVAL" env-default:"24h" env-description:"Interval between payment reminders of an unpaid invoice"`
+	MaxReminders     int           `yaml:"max_reminders" env:"OXYGEN_BILLING_MAX_REMINDERS" env-default:"3" env-description:"Max number of reminders per invoice"`
+	GracePeriod      time.Duration `yaml:"grace_period" env:"OXYGEN_BILLING_GRACE_PERIOD" env-default:"168h" env-description:"How long a subscription stays past_due before it is cancelled"`
+}
+
+type Service struct {
+	db        *pgxpool.Pool
+	config    Config
+	payments  *payment.Service
+	merchants *merchant.Service
+	emails    *email.Service
+	publisher bus.Publisher
+	logger    *zerolog.Logger
+}
+
+var (
+	ErrPlanNotFound         = errors.New("billing plan not found")
+	ErrPlanInactive         = errors.New("billing plan is not active")
+	ErrSubscriptionNotFound = errors.New("customer subscription not found")
+	ErrAlreadyCancelled     = errors.New("customer subscription is already cancelled")
+	ErrValidation           = errors.New("invalid billing input")
+)
+
+func New(
+	db *pgxpool.Pool,
+	config Config,
+	payments *payment.Service,
+	merchants *merchant.Service,
+	emails *email.Service,
+	publisher bus.Publisher,
+	logger *zerolog.Logger,
+) *Service {
+	log := logger.With().Str("channel", "billing_service").Logger()
+
+	return &Service{
+		db:        db,
+		config:    config,
+		payments:  payments,
+		merchants: merchants,
+		emails:    emails,
+		publisher: publisher,
+		logger:    &log,
+	}
+}
+
+// ===== Plans =====
+
+type CreatePlanProps struct {
+	Name          string
+	Description   *string
+	Price         money.Money
+	Interval      Interval
+	IntervalCount int
+}
+
+func (p CreatePlanProps) validate() error {
+	switch {
+	case strings.TrimSpace(p.Name) == "":
+		return errors.Wrap(ErrValidation, "name is required")
+	case p.Price.Type() != money.Fiat || !p.Price.IsPositive():
+		return errors.Wrap(ErrValidation, "price should be a positive fiat amount")
+	case !p.Interval.Valid():
+		return errors.Wrap(ErrValidation, "interval should be one of day, week, month, year")
+	case p.IntervalCount < 1 || p.IntervalCount > 12:
+		return errors.Wrap(ErrValidation, "intervalCount should be between 1 and 12")
+	}
+
+	return nil
+}
+
+const planColumns = `id, uuid, merchant_id, name, description, price::text, currency,
+	billing_interval, interval_count, is_active, created_at, updated_at`
+
+func (s *Service) CreatePlan(ctx context.Context, merchantID int64, props CreatePlanProps) (*Plan, error) {
+	if err := props.validate(); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+
+	row := s.db.QueryRow(ctx, `
+		INSERT INTO billing_plans (uuid, merchant_id, name, description, price, currency,
+		                           billing_interval, interval_count, is_active, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5::numeric, $6, $7, $8, true, $9, $9)
+		RETURNING `+planColumns,
+		uuid.New(), merchantID, strings.TrimSpace(props.Name), props.Description,
+		props.Price.StringRaw(), props.Price.Ticker(), string(props.Interval), props.IntervalCount, now,
+	)
+
+	plan, err := scanPlan(row)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create billing plan")
+	}
+
+	return plan, nil
+}
+
+func (s *Service) ListPlans(ctx context.Context, merchantID int64) ([]*Plan, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT `+planColumns+` FROM billing_plans
+		WHERE merchant_id = $1
+		ORDER BY id DESC`, merchantID,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to list billing plans")
+	}
+	defer rows.Close()
+
+	var plans []*Plan
+	for rows.Next() {
+		plan, err := scanPlan(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to scan billing plan")
+		}
+		plans = append(plans, plan)
+	}
+
+	return plans, rows.Err()
+}
+
+func (s *Service) GetPlanByUUID(ctx context.Context, merchantID int64, id uuid.UUID) (*Plan, error) {
+	row := s.db.QueryRow(ctx, `
+		SELECT `+planColumns+` FROM billing_plans
+		WHERE merchant_id = $1 AND uuid = $2`, merchantID, id,
+	)
+
+	return s.scanPlanRow(row)
+}
+
+func (s *Service) getPlanByID(ctx context.Context, id int64) (*Plan, error) {
+	row := s.db.QueryRow(ctx, `SELECT `+planColumns+` FROM billing_plans WHERE id = $1`, id)
+
+	return s.scanPlanRow(row)
+}
+
+// DeactivatePlan prevents new subscriptions to the plan.
+// Existing subscriptions keep renewing.
+func (s *Service) DeactivatePlan(ctx context.Context, merchantID int64, id uuid.UUID) error {
+	tag, err := s.db.Exec(ctx, `
+		UPDATE billing_plans SET is_active = false, updated_at = $3
+		WHERE merchant_id = $1 AND uuid = $2`, merchantID, id, time.Now().UTC(),
+	)
+	if err != nil {
+		return errors.Wrap(err, "unable to deactivate billing plan")
+	}
+
+	if tag.RowsAffected() == 0 {
+		return ErrPlanNotFound
+	}
+
+	return nil
+}
+
+func (s *Service) scanPlanRow(row pgx.Row) (*Plan, error) {
+	plan, err := scanPlan(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrPlanNotFound
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to get billing plan")
+	}
+
+	return plan, nil
+}
+
+func scanPlan(row pgx.Row) (*Plan, error) {
+	var (
+		plan            Plan
+		price, currency string
+		interval        string
+	)
+
+	err := row.Scan(
+		&plan.ID, &plan.UUID, &plan.MerchantID, &plan.Name, &plan.Description, &price, &currency,
+		&interval, &plan.IntervalCount, &plan.IsActive, &plan.CreatedAt, &plan.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	plan.Interval = Interval(interval)
+	plan.Price, err = money.FiatCurrency(currency).MakeAmount(price)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to parse plan price")
+	}
+
+	return &plan, nil
+}
+
+// ===== Subscriptions =====
+
+type SubscribeProps struct {
+	PlanUUID      uuid.UUID
+	CustomerEmail string
+	IsTest        bool
+}
+
+const subscriptionColumns = `s.id, s.uuid, s.merchant_id, s.plan_id, s.customer_id, s.status,
+	s.current_period_start, s.current_period_end, s.cancel_at_period_end, s.past_due_since,
+	s.cancelled_at, s.is_test, s.created_at, s.updated_at, p.uuid, c.uuid, COALESCE(c.email, '')`
+
+const subscriptionFrom = `customer_subscriptions s
+	JOIN billing_plans p ON p.id = s.plan_id
+	JOIN customers c ON c.id = s.customer_id`
+
+// Subscribe subscribes customer (resolved or created by email) to the plan and
+// issues the first invoice. Subscription stays pending until it is paid.
+func (s *Service) Subscribe(ctx context.Context, merchantID int64, props SubscribeProps) (*Subscription, error) {
+	if _, err := mail.ParseAddress(props.CustomerEmail); err != nil {
+		return nil, errors.Wrap(ErrValidation, "invalid customer email")
+	}
+
+	plan, err := s.GetPlanByUUID(ctx, merchantID, props.PlanUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !plan.IsActive {
+		return nil, ErrPlanInactive
+	}
+
+	person, err := s.payments.ResolveCustomerByEmail(ctx, merchantID, props.CustomerEmail)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to resolve customer")
+	}
+
+	now := time.Now().UTC()
+	periodEnd := nextPeriodEnd(now, plan.Interval, plan.IntervalCount)
+
+	var id int64
+	err = s.db.QueryRow(ctx, `
+		INSERT INTO customer_subscriptions (uuid, merchant_id, plan_id, customer_id, status,
+		                                    current_period_start, current_period_end, is_test, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $6, $6)
+		RETURNING id`,
+		uuid.New(), merchantID, plan.ID, person.ID, StatusPending.String(), now, periodEnd, props.IsTest,
+	).Scan(&id)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create customer subscription")
+	}
+
+	sub, err := s.getSubscriptionByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.issueInvoice(ctx, sub, plan, now, periodEnd); err != nil {
+		return nil, errors.Wrap(err, "unable to issue first invoice")
+	}
+
+	return sub, nil
+}
+
+func (s *Service) ListSubscriptions(ctx context.Context, merchantID int64) ([]*Subscription, error) {
+	return s.listSubscriptions(ctx, `
+		SELECT `+subscriptionColumns+` FROM `+subscriptionFrom+`
+		WHERE s.merchant_id = $1
+		ORDER BY s.id DESC`, merchantID,
+	)
+}
+
+func (s *Service) GetSubscriptionByUUID(ctx context.Context, merchantID int64, id uuid.UUID) (*Subscription, error) {
+	row := s.db.QueryRow(ctx, `
+		SELECT `+subscriptionColumns+` FROM `+subscriptionFrom+`
+		WHERE s.merchant_id = $1 AND s.uuid = $2`, merchantID, id,
+	)
+
+	return s.scanSubscriptionRow(row)
+}
+
+func (s *Service) getSubscriptionByID(ctx context.Context, id int64) (*Subscription, error) {
+	row := s.db.QueryRow(ctx, `SELECT `+subscriptionColumns+` FROM `+subscriptionFrom+` WHERE s.id = $1`, id)
+
+	return s.scanSubscriptionRow(row)
+}
+
+// Cancel cancels the subscription either immediately or at the end of the
+// current period (the already paid period is not refunded either way).
+func (s *Service) Cancel(ctx context.Context, merchantID int64, id uuid.UUID, atPeriodEnd bool) (*Subscription, error) {
+	sub, err := s.GetSubscriptionByUUID(ctx, merchantID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if sub.Status == StatusCancelled {
+		return nil, ErrAlreadyCancelled
+	}
+
+	// Nothing is paid yet for pending subscription, so there is no period to wait for.
+	if atPeriodEnd && sub.Status != StatusPending {
+		_, err = s.db.Exec(ctx, `
+			UPDATE customer_subscriptions SET cancel_at_period_end = true, updated_at = $2
+			WHERE id = $1`, sub.ID, time.Now().UTC(),
+		)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to schedule cancellation")
+		}
+
+		return s.getSubscriptionByID(ctx, sub.ID)
+	}
+
+	if err := s.cancel(ctx, sub); err != nil {
+		return nil, err
+	}
+
+	return s.getSubscriptionByID(ctx, sub.ID)
+}
+
+func (s *Service) listSubscriptions(ctx context.Context, query string, args ...any) ([]*Subscription, error) {
+	rows, err := s.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to list customer subscriptions")
+	}
+	defer rows.Close()
+
+	var subs []*Subscription
+	for rows.Next() {
+		sub, err := scanSubscription(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to scan customer subscription")
+		}
+		subs = append(subs, sub)
+	}
+
+	return subs, rows.Err()
+}
+
+func (s *Service) scanSubscriptionRow(row pgx.Row) (*Subscription, error) {
+	sub, err := scanSubscription(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrSubscriptionNotFound
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to get customer subscription")
+	}
+
+	return sub, nil
+}
+
+func scanSubscription(row pgx.Row) (*Subscription, error) {
+	var (
+		sub    Subscription
+		status string
+	)
+
+	err := row.Scan(
+		&sub.ID, &sub.UUID, &sub.MerchantID, &sub.PlanID, &sub.CustomerID, &status,
+		&sub.CurrentPeriodStart, &sub.CurrentPeriodEnd, &sub.CancelAtPeriodEnd, &sub.PastDueSince,
+		&sub.CancelledAt, &sub.IsTest, &sub.CreatedAt, &sub.UpdatedAt, &sub.PlanUUID, &sub.CustomerUUID,
+		&sub.CustomerEmail,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	sub.Status = Status(status)
+
+	return &sub, nil
+}
+
+// ===== Invoices =====
+
+const invoiceColumns = `i.id, i.subscription_id, i.payment_id, i.period_start, i.period_end, i.status,
+	i.reminders_sent, i.last_notified_at, i.paid_at, i.created_at, i.updated_at, p.public_id`
+
+func (s *Service) ListInvoices(ctx context.Context, merchantID int64, subscriptionID uuid.UUID) ([]*Invoice, error) {
+	sub, err := s.GetSubscriptionByUUID(ctx, merchantID, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.Query(ctx, `
+		SELECT `+invoiceColumns+`
+		FROM customer_subscription_invoices i
+		JOIN payments p ON p.id = i.payment_id
+		WHERE i.subscription_id = $1
+		ORDER BY i.period_start DESC`, sub.ID,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to list invoices")
+	}
+	defer rows.Close()
+
+	var invoices []*Invoice
+	for rows.Next() {
+		inv, err := scanInvoice(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to scan invoice")
+		}
+		invoices = append(invoices, inv)
+	}
+
+	return invoices, rows.Err()
+}
+
+func scanInvoice(row pgx.Row) (*Invoice, error) {
+	var (
+		inv    Invoice
+		status string
+	)
+
+	err := row.Scan(
+		&inv.ID, &inv.SubscriptionID, &inv.PaymentID, &inv.PeriodStart, &inv.PeriodEnd, &status,
+		&inv.RemindersSent, &inv.LastNotifiedAt, &inv.PaidAt, &inv.CreatedAt, &inv.UpdatedAt, &inv.PaymentUUID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	inv.Status = InvoiceStatus(status)
+
+	return &inv, nil
+}
+
+// issueInvoice creates a payment for the period, stores the invoice and emails
+// the pay link to the customer.
+func (s *Service) issueInvoice(ctx context.Context, sub *Subscription, plan *Plan, start, end time.Time) (*Invoice, error) {
+	pt, err := s.createInvoicePayment(ctx, sub, plan, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+
+	row := s.db.QueryRow(ctx, `
+		WITH i AS (
+			INSERT INTO customer_subscription_invoices (subscription_id, payment_id, period_start, period_end,
+			                                            status, last_notified_at, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $6, $6)
+			RETURNING *
+		)
+		SELECT `+invoiceColumns+` FROM i JOIN payments p ON p.id = i.payment_id`,
+		sub.ID, pt.ID, start, end, string(InvoiceOpen), now,
+	)
+
+	inv, err := scanInvoice(row)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create invoice")
+	}
+
+	s.sendInvoiceEmail(ctx, sub, plan, inv, pt, false)
+
+	return inv, nil
+}
+
+func (s *Service) createInvoicePayment(ctx context.Context, sub *Subscription, plan *Plan, start, end time.Time) (*payment.Payment, error) {
+	description := fmt.Sprintf("%s (%s — %s)", plan.Name, start.Format("2006-01-02"), end.Format("2006-01-02"))
+	orderID := "sub_" + sub.UUID.String()
+
+	pt, err := s.payments.CreatePayment(ctx, sub.MerchantID, payment.CreatePaymentProps{
+		MerchantOrderUUID: uuid.New(),
+		MerchantOrderID:   &orderID,
+		Money:             plan.Price,
+		Description:       &description,
+		IsTest:            sub.IsTest,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create invoice payment")
+	}
+
+	if _, err := s.payments.AssignCustomerByEmail(ctx, pt, sub.CustomerEmail); err != nil {
+		return nil, errors.Wrap(err, "unable to assign customer to invoice payment")
+	}
+
+	return pt, nil
+}
+
+// cancel moves subscription to cancelled and voids its open invoices.
+func (s *Service) cancel(ctx context.Context, sub *Subscription) error {
+	now := time.Now().UTC()
+
+	err := s.db.BeginFunc(ctx, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, `
+			UPDATE customer_subscriptions
+			SET status = $2, cancelled_at = $3, updated_at = $3
+			WHERE id = $1`, sub.ID, StatusCancelled.String(), now,
+		); err != nil {
+			return err
+		}
+
+		_, err := tx.Exec(ctx, `
+			UPDATE customer_subscription_invoices SET status = $2, updated_at = $3
+			WHERE subscription_id = $1 AND status = 'open'`, sub.ID, string(InvoiceVoid), now,
+		)
+
+		return err
+	})
+	if err != nil {
+		return errors.Wrap(err, "unable to cancel customer subscription")
+	}
+
+	s.publishTransition(sub, StatusCancelled)
+
+	return nil
+}
+
+func (s *Service) setStatus(ctx context.Context, sub *Subscription, status Status) error {
+	now := time.Now().UTC()
+
+	_, err := s.db.Exec(ctx, `
+		UPDATE customer_subscriptions
+		SET status = $2,
+		    past_due_since = CASE WHEN $2 = 'past_due' THEN $3 ELSE NULL END,
+		    updated_at = $3
+		WHERE id = $1`, sub.ID, status.String(), now,
+	)
+	if err != nil {
+		return errors.Wrapf(err, "unable to set subscription status to %s", status)
+	}
+
+	s.publishTransition(sub, status)
+
+	return nil
+}
+
+func (s *Service) publishTransition(sub *Subscription, status Status) {
+	err := s.publisher.Publish(bus.TopicCustomerSubscriptionUpdate, bus.CustomerSubscriptionUpdateEvent{
+		MerchantID:     sub.MerchantID,
+		SubscriptionID: sub.ID,
+		Status:         status.String(),
+		PreviousStatus: sub.Status.String(),
+	})
+
+	if err != nil {
+		s.logger.Error().Err(err).
+			Int64("merchant_id", sub.MerchantID).Int64("subscription_id", sub.ID).
+			Msg("unable to publish customer subscription event")
+	}
+}
@@ -89,6 +89,9 @@ type TransactionReceipt struct {
 	Success       bool
 	Confirmations int64
 	IsConfirmed   bool
+
+	// RequiredConfirmations is the number of confirmations IsConfirmed requires.
+	RequiredConfirmations int64
 }
 
 func (s *Service) GetTransactionReceipt(
@@ -157,6 +160,8 @@ func (s *Service) getTransactionReceipt(
 			Success:       receipt.Success,
 			Confirmations: receipt.Confirmations,
 			IsConfirmed:   receipt.IsConfirmed,
+
+			RequiredConfirmations: receipt.RequiredConfirmations,
 		}, nil
 	case kms.BTC:
 		return s.getBitcoinReceipt(ctx, nativeCoin, transactionID, btcConfirmations, isTest)
@@ -325,6 +330,8 @@ func (s *Service) getEthReceipt(
 		Success:       receipt.Status == 1,
 		Confirmations: confirmations,
 		IsConfirmed:   confirmations >= requiredConfirmations,
+
+		RequiredConfirmations: requiredConfirmations,
 	}, nil
 }
 
@@ -368,6 +375,8 @@ func (s *Service) getBitcoinReceipt(
 		Success:       txInfo.Confirmed,
 		Confirmations: txInfo.Confirmations,
 		IsConfirmed:   txInfo.Confirmations >= requiredConfirmations,
+
+		RequiredConfirmations: requiredConfirmations,
 	}, nil
 }
 
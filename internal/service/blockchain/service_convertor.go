@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/cryptolink/cryptolink/internal/money"
+	"github.com/cryptolink/cryptolink/internal/provider/pricefeed"
 	"github.com/pkg/errors"
 )
 
@@ -23,6 +24,7 @@ type ExchangeRate struct {
 	To           string
 	Rate         float64
 	CalculatedAt time.Time
+	Source       pricefeed.Source
 }
 
 type ConversionType string
@@ -38,6 +40,11 @@ type Conversion struct {
 	Rate float64
 	From money.Money
 	To   money.Money
+
+	// Source and CalculatedAt describe the quote Rate was taken from.
+	// Both are persisted in the rate snapshot audit trail.
+	Source       pricefeed.Source
+	CalculatedAt time.Time
 }
 
 func (s *Service) GetExchangeRate(ctx context.Context, from, to string) (ExchangeRate, error) {
@@ -52,6 +59,7 @@ func (s *Service) GetExchangeRate(ctx context.Context, from, to string) (Exchang
 			From:         from,
 			To:           to,
 			CalculatedAt: time.Now(),
+			Source:       pricefeed.SourceIdentity,
 		}, nil
 	}
 
@@ -61,16 +69,17 @@ func (s *Service) GetExchangeRate(ctx context.Context, from, to string) (Exchang
 	}
 
 	var (
-		rate float64
-		at   time.Time
+		rate   float64
+		at     time.Time
+		source pricefeed.Source
 	)
 
 	switch convType {
 	case ConversionTypeFiatToFiat, ConversionTypeCryptoToFiat:
-		rate, at, err = s.getExchangeRate(ctx, NormalizeTicker(to), NormalizeTicker(from))
+		rate, at, source, err = s.getExchangeRate(ctx, NormalizeTicker(to), NormalizeTicker(from))
 	case ConversionTypeFiatToCrypto:
 		// Price feeds return crypto->fiat, so we need to calculate ETH->USD and reverse it
-		rate, at, err = s.getExchangeRate(ctx, NormalizeTicker(from), NormalizeTicker(to))
+		rate, at, source, err = s.getExchangeRate(ctx, NormalizeTicker(from), NormalizeTicker(to))
 		if err == nil {
 			rate = 1 / rate
 		}
@@ -87,6 +96,7 @@ func (s *Service) GetExchangeRate(ctx context.Context, from, to string) (Exchang
 		To:           to,
 		Rate:         rate,
 		CalculatedAt: at,
+		Source:       source,
 	}, nil
 }
 
@@ -189,6 +199,9 @@ func (s *Service) FiatToFiat(ctx context.Context, from money.Money, to money.Fia
 		From: from,
 		To:   toMoney,
 		Rate: rate.Rate,
+
+		Source:       rate.Source,
+		CalculatedAt: rate.CalculatedAt,
 	}, nil
 }
 
@@ -238,6 +251,9 @@ func (s *Service) FiatToCrypto(ctx context.Context, from money.Money, to money.C
 		Rate: rate.Rate,
 		From: from,
 		To:   cryptoMoney,
+
+		Source:       rate.Source,
+		CalculatedAt: rate.CalculatedAt,
 	}, nil
 }
 
@@ -261,23 +277,26 @@ func (s *Service) CryptoToFiat(ctx context.Context, from money.Money, to money.F
 		Rate: rate.Rate,
 		From: from,
 		To:   fiatMoney,
+
+		Source:       rate.Source,
+		CalculatedAt: rate.CalculatedAt,
 	}, nil
 }
 
 // getExchangeRate. Example: if 1 ETH = $1500, then semantics are:
-// getExchangeRate(ctx, "USD", "ETH") returns (1500, time.Time, nil)
-func (s *Service) getExchangeRate(ctx context.Context, desired, selected string) (float64, time.Time, error) {
+// getExchangeRate(ctx, "USD", "ETH") returns (1500, time.Time, "binance", nil)
+func (s *Service) getExchangeRate(ctx context.Context, desired, selected string) (float64, time.Time, pricefeed.Source, error) {
 	res, err := s.providers.PriceFeed.GetExchangeRate(ctx, desired, selected)
 	if err != nil {
-		return 0, time.Time{}, errors.Wrapf(err, "unable to get exchange rate of %q / %q", desired, selected)
+		return 0, time.Time{}, "", errors.Wrapf(err, "unable to get exchange rate of %q / %q", desired, selected)
 	}
 
 	rate, err := strconv.ParseFloat(res.Value, 64)
 	if err != nil {
-		return 0, time.Time{}, err
+		return 0, time.Time{}, "", err
 	}
 
-	return rate, time.UnixMilli(int64(res.Timestamp)), nil
+	return rate, time.UnixMilli(int64(res.Timestamp)), res.Source, nil
 }
 
 func determineConversionType(from, to string) (ConversionType, error) {
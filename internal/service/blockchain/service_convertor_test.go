@@ -139,6 +139,11 @@ func TestConvertor(t *testing.T) {
 			}
 
 			assert.NoError(t, err)
+			assert.NotEmpty(t, actual.Source)
+			assert.False(t, actual.CalculatedAt.IsZero())
+
+			// quote metadata is time-dependent, compare the conversion itself
+			actual.Source, actual.CalculatedAt = "", time.Time{}
 			assert.Equal(t, tt.expected, actual)
 		})
 	}
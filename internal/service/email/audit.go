@@ -0,0 +1,75 @@
+package email
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"strings"
+)
+
+// SecurityAlertParams contains data for a high-risk configuration change notification.
+type SecurityAlertParams struct {
+	OwnerEmail   string
+	MerchantName string
+	Change       string // e.g. "EVM collector was set up"
+	Actor        string // e.g. "jane@example.com" or "API token"
+	IP           string
+	UserAgent    string
+	Details      []string // redacted "field: before → after" lines
+	OccurredAt   string   // e.g. "2026-06-01 12:00 UTC"
+}
+
+// SendSecurityAlert notifies merchant's owner about a high-risk change such as
+// a new collector or xpub wallet. Best-effort: errors are logged.
+func (s *Service) SendSecurityAlert(ctx context.Context, params SecurityAlertParams) {
+	subject := fmt.Sprintf("[CryptoLink] Security alert for %s: %s", params.MerchantName, params.Change)
+
+	var details strings.Builder
+	for _, line := range params.Details {
+		fmt.Fprintf(&details, `<p style="margin:4px 0;font-family:monospace;">%s</p>`, html.EscapeString(line))
+	}
+
+	body := fmt.Sprintf(`<!DOCTYPE html>
+<html>
+<head><meta charset="UTF-8"></head>
+<body style="font-family:-apple-system,BlinkMacSystemFont,'Segoe UI',sans-serif;max-width:600px;margin:0 auto;padding:20px;">
+  <div style="background:#0f172a;padding:24px;border-radius:8px 8px 0 0;">
+    <h1 style="color:#fff;margin:0;font-size:20px;">CryptoLink</h1>
+  </div>
+  <div style="border:1px solid #e2e8f0;border-top:none;padding:24px;border-radius:0 0 8px 8px;">
+    <h2 style="color:#ff4d4f;margin-top:0;">Security Alert</h2>
+    <p>A security-sensitive setting of <strong>%s</strong> was changed: <strong>%s</strong>.</p>
+    <div style="background:#fff1f0;border:1px solid #ffa39e;padding:16px;border-radius:8px;margin:16px 0;">
+      <p style="margin:4px 0;"><strong>By:</strong> %s</p>
+      <p style="margin:4px 0;"><strong>IP address:</strong> %s</p>
+      <p style="margin:4px 0;"><strong>Device:</strong> %s</p>
+      <p style="margin:4px 0;"><strong>Time:</strong> %s</p>
+      %s
+    </div>
+    <p>If you don't recognize this change, revert it, revoke API tokens and review team members immediately.</p>
+    <a href="https://cryptolink.cc/merchants/settings/audit-log" style="display:inline-block;background:#10b981;color:#fff;padding:12px 24px;border-radius:6px;text-decoration:none;margin-top:8px;">Open Audit Log</a>
+    <hr style="border:none;border-top:1px solid #e2e8f0;margin:24px 0;">
+    <p style="color:#94a3b8;font-size:12px;">This is an automated notification from CryptoLink. Security alerts can be turned off in merchant settings.</p>
+  </div>
+</body>
+</html>`,
+		html.EscapeString(params.MerchantName),
+		html.EscapeString(params.Change),
+		html.EscapeString(params.Actor),
+		html.EscapeString(params.IP),
+		html.EscapeString(params.UserAgent),
+		html.EscapeString(params.OccurredAt),
+		details.String(),
+	)
+
+	if err := s.SendEmail(ctx, SendEmailParams{
+		To:       params.OwnerEmail,
+		Subject:  subject,
+		Body:     body,
+		Template: "security_alert",
+	}); err != nil {
+		s.logger.Warn().Err(err).
+			Str("owner_email", params.OwnerEmail).
+			Msg("unable to send security alert email")
+	}
+}
@@ -0,0 +1,118 @@
+package email
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// SubscriptionInvoiceParams contains data for a recurring billing invoice sent
+// to merchant's customer.
+type SubscriptionInvoiceParams struct {
+	CustomerEmail string
+	MerchantName  string
+	PlanName      string
+	Amount        string // e.g. "9.99"
+	FiatSymbol    string // e.g. "$"
+	FiatCode      string // e.g. "USD"
+	PeriodStart   time.Time
+	PeriodEnd     time.Time
+	PaymentURL    string
+	IsReminder    bool
+	IsOverdue     bool
+	Branding      Branding // merchant's checkout branding
+}
+
+// SendSubscriptionInvoice sends a pay link for a subscription period to the customer.
+// Best-effort: errors are logged but not propagated.
+func (s *Service) SendSubscriptionInvoice(ctx context.Context, params SubscriptionInvoiceParams) {
+	heading, color := "New Invoice", params.Branding.accent()
+	subject := fmt.Sprintf("[%s] Invoice for %s", params.MerchantName, params.PlanName)
+	tmpl := "subscription_invoice"
+
+	switch {
+	case params.IsOverdue:
+		heading, color = "Payment Overdue", "#ef4444"
+		subject = fmt.Sprintf("[%s] Payment overdue for %s", params.MerchantName, params.PlanName)
+		tmpl = "subscription_reminder"
+	case params.IsReminder:
+		heading, color = "Payment Reminder", "#faad14"
+		subject = fmt.Sprintf("[%s] Reminder: invoice for %s", params.MerchantName, params.PlanName)
+		tmpl = "subscription_reminder"
+	}
+
+	body := fmt.Sprintf(`<!DOCTYPE html>
+<html>
+<head><meta charset="UTF-8"></head>
+<body style="font-family:-apple-system,BlinkMacSystemFont,'Segoe UI',sans-serif;max-width:600px;margin:0 auto;padding:20px;">
+  %s
+  <div style="border:1px solid #e2e8f0;border-top:none;padding:24px;border-radius:0 0 8px 8px;">
+    <h2 style="color:%s;margin-top:0;">%s</h2>
+    <p>Your subscription to <strong>%s</strong> is due for the period below.</p>
+    <div style="background:#f1f5f9;padding:16px;border-radius:8px;margin:16px 0;">
+      <p style="margin:4px 0;font-size:24px;font-weight:700;">%s%s %s</p>
+      <p style="margin:4px 0;color:#64748b;">%s — %s</p>
+    </div>
+    <div style="text-align:center;margin:24px 0;">
+      <a href="%s" style="display:inline-block;background:%s;color:#fff;padding:14px 32px;border-radius:8px;text-decoration:none;font-weight:600;font-size:16px;">Pay with Crypto</a>
+    </div>
+    <p style="color:#64748b;font-size:14px;">If the link has expired, a fresh one will be sent with the next reminder.</p>
+    <hr style="border:none;border-top:1px solid #e2e8f0;margin:24px 0;">
+    %s
+    <p style="color:#94a3b8;font-size:12px;">This is an automated message from CryptoLink on behalf of %s.</p>
+  </div>
+</body>
+</html>`,
+		params.Branding.header(params.MerchantName),
+		color, heading,
+		params.PlanName,
+		params.FiatSymbol, params.Amount, params.FiatCode,
+		params.PeriodStart.Format("2006-01-02"), params.PeriodEnd.Format("2006-01-02"),
+		params.PaymentURL, params.Branding.accent(),
+		params.Branding.footer(),
+		params.MerchantName,
+	)
+
+	if err := s.SendEmail(ctx, SendEmailParams{
+		To:       params.CustomerEmail,
+		Subject:  subject,
+		Body:     body,
+		Template: tmpl,
+	}); err != nil {
+		s.logger.Warn().Err(err).
+			Str("customer_email", params.CustomerEmail).
+			Str("template", tmpl).
+			Msg("unable to send subscription invoice email")
+	}
+}
+
+// SendSubscriptionCancelled notifies the customer that the subscription was
+// cancelled because of non-payment.
+func (s *Service) SendSubscriptionCancelled(ctx context.Context, customerEmail, merchantName, planName string, branding Branding) {
+	body := fmt.Sprintf(`<!DOCTYPE html>
+<html>
+<head><meta charset="UTF-8"></head>
+<body style="font-family:-apple-system,BlinkMacSystemFont,'Segoe UI',sans-serif;max-width:600px;margin:0 auto;padding:20px;">
+  %s
+  <div style="border:1px solid #e2e8f0;border-top:none;padding:24px;border-radius:0 0 8px 8px;">
+    <h2 style="color:#ef4444;margin-top:0;">Subscription Cancelled</h2>
+    <p>Your subscription to <strong>%s</strong> was cancelled because the invoice was not paid in time.</p>
+    <p>Please contact <strong>%s</strong> if you would like to subscribe again.</p>
+    <hr style="border:none;border-top:1px solid #e2e8f0;margin:24px 0;">
+    %s
+    <p style="color:#94a3b8;font-size:12px;">This is an automated message from CryptoLink on behalf of %s.</p>
+  </div>
+</body>
+</html>`, branding.header(merchantName), planName, merchantName, branding.footer(), merchantName)
+
+	if err := s.SendEmail(ctx, SendEmailParams{
+		To:       customerEmail,
+		Subject:  fmt.Sprintf("[%s] Subscription to %s cancelled", merchantName, planName),
+		Body:     body,
+		Template: "subscription_cancelled",
+	}); err != nil {
+		s.logger.Warn().Err(err).
+			Str("customer_email", customerEmail).
+			Msg("unable to send subscription cancelled email")
+	}
+}
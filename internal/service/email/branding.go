@@ -0,0 +1,69 @@
+package email
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+const defaultAccentColor = "#10b981"
+
+// Branding is merchant's look applied to customer-facing emails.
+// Zero value renders the default CryptoLink layout.
+type Branding struct {
+	LogoURL      string
+	AccentColor  string
+	SupportEmail string
+	TermsURL     string
+	FooterText   string
+}
+
+func (b Branding) accent() string {
+	if b.AccentColor == "" {
+		return defaultAccentColor
+	}
+
+	return html.EscapeString(b.AccentColor)
+}
+
+// header renders the top bar: merchant's logo if uploaded, otherwise the title.
+func (b Branding) header(title string) string {
+	if b.LogoURL != "" {
+		return fmt.Sprintf(
+			`<div style="background:#0f172a;padding:24px;border-radius:8px 8px 0 0;"><img src="%s" alt="%s" style="max-height:48px;max-width:240px;"></div>`,
+			html.EscapeString(b.LogoURL), html.EscapeString(title),
+		)
+	}
+
+	return fmt.Sprintf(
+		`<div style="background:#0f172a;padding:24px;border-radius:8px 8px 0 0;"><h1 style="color:#fff;margin:0;font-size:20px;">%s</h1></div>`,
+		html.EscapeString(title),
+	)
+}
+
+// footer renders merchant's footer text, support email and terms link.
+func (b Branding) footer() string {
+	var parts []string
+
+	if b.FooterText != "" {
+		parts = append(parts, fmt.Sprintf(
+			`<p style="color:#64748b;font-size:12px;white-space:pre-line;">%s</p>`,
+			html.EscapeString(b.FooterText),
+		))
+	}
+
+	var links []string
+	if b.SupportEmail != "" {
+		email := html.EscapeString(b.SupportEmail)
+		links = append(links, fmt.Sprintf(`Support: <a href="mailto:%s" style="color:%s;">%s</a>`, email, b.accent(), email))
+	}
+	if b.TermsURL != "" {
+		links = append(links, fmt.Sprintf(`<a href="%s" style="color:%s;">Terms of Service</a>`, html.EscapeString(b.TermsURL), b.accent()))
+	}
+
+	if len(links) > 0 {
+		parts = append(parts, `<p style="color:#64748b;font-size:12px;">`+strings.Join(links, " &middot; ")+`</p>`)
+	}
+
+	return strings.Join(parts, "\n    ")
+}
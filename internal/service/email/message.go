@@ -0,0 +1,71 @@
+package email
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+)
+
+// buildMessage renders RFC 5322 message. Emails with attachments are sent as
+// multipart/mixed with HTML body as the first part.
+func buildMessage(from string, params SendEmailParams) []byte {
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", params.To)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", params.Subject)
+	buf.WriteString("MIME-Version: 1.0\r\n")
+
+	if len(params.Attachments) == 0 {
+		buf.WriteString("Content-Type: text/html; charset=\"UTF-8\"\r\n\r\n")
+		buf.WriteString(params.Body)
+
+		return buf.Bytes()
+	}
+
+	mw := multipart.NewWriter(&buf)
+
+	fmt.Fprintf(&buf, "Content-Type: multipart/mixed; boundary=%q\r\n\r\n", mw.Boundary())
+
+	body, _ := mw.CreatePart(textproto.MIMEHeader{
+		"Content-Type": {`text/html; charset="UTF-8"`},
+	})
+	_, _ = body.Write([]byte(params.Body))
+
+	for _, a := range params.Attachments {
+		contentType := a.ContentType
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+
+		part, _ := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {contentType},
+			"Content-Transfer-Encoding": {"base64"},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": a.Filename})},
+		})
+
+		writeBase64Lines(part, a.Data)
+	}
+
+	_ = mw.Close()
+
+	return buf.Bytes()
+}
+
+// writeBase64Lines encodes data wrapped at 76 characters per line (RFC 2045).
+func writeBase64Lines(w io.Writer, data []byte) {
+	const lineLength = 76
+
+	encoded := base64.StdEncoding.EncodeToString(data)
+
+	for len(encoded) > lineLength {
+		_, _ = w.Write([]byte(encoded[:lineLength] + "\r\n"))
+		encoded = encoded[lineLength:]
+	}
+
+	_, _ = w.Write([]byte(encoded + "\r\n"))
+}
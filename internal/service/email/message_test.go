@@ -0,0 +1,72 @@
+package email
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildMessage(t *testing.T) {
+	t.Run("html only", func(t *testing.T) {
+		raw := buildMessage("CryptoLink <noreply@example.com>", SendEmailParams{
+			To: "merchant@example.com", Subject: "Hello", Body: "<p>hi</p>",
+		})
+
+		msg, err := mail.ReadMessage(bytes.NewReader(raw))
+		require.NoError(t, err)
+
+		assert.Equal(t, `text/html; charset="UTF-8"`, msg.Header.Get("Content-Type"))
+
+		body, _ := io.ReadAll(msg.Body)
+		assert.Equal(t, "<p>hi</p>", string(body))
+	})
+
+	t.Run("with attachment", func(t *testing.T) {
+		csv := bytes.Repeat([]byte("Date,Journal,Event\n"), 20)
+
+		raw := buildMessage("CryptoLink <noreply@example.com>", SendEmailParams{
+			To:          "merchant@example.com",
+			Subject:     "Report",
+			Body:        "<p>report</p>",
+			Attachments: []Attachment{{Filename: "journal.csv", ContentType: "text/csv", Data: csv}},
+		})
+
+		msg, err := mail.ReadMessage(bytes.NewReader(raw))
+		require.NoError(t, err)
+
+		mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+		require.NoError(t, err)
+		assert.Equal(t, "multipart/mixed", mediaType)
+
+		mr := multipart.NewReader(msg.Body, params["boundary"])
+
+		html, err := mr.NextPart()
+		require.NoError(t, err)
+		body, _ := io.ReadAll(html)
+		assert.Equal(t, "<p>report</p>", string(body))
+
+		attachment, err := mr.NextPart()
+		require.NoError(t, err)
+		assert.Equal(t, "journal.csv", attachment.FileName())
+
+		// multipart reader decodes quoted-printable only, decode base64 manually
+		encoded, _ := io.ReadAll(attachment)
+		for _, line := range bytes.Split(bytes.TrimSpace(encoded), []byte("\r\n")) {
+			assert.LessOrEqual(t, len(line), 76)
+		}
+
+		decoded, err := io.ReadAll(base64.NewDecoder(base64.StdEncoding, bytes.NewReader(encoded)))
+		require.NoError(t, err)
+		assert.Equal(t, csv, decoded)
+
+		_, err = mr.NextPart()
+		assert.ErrorIs(t, err, io.EOF)
+	})
+}
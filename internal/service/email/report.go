@@ -0,0 +1,133 @@
+package email
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"strings"
+)
+
+// SettlementReportParams contains data of merchant's periodic settlement report.
+// Amounts are pre-formatted, fiat values are in merchant's fiat currency.
+type SettlementReportParams struct {
+	MerchantEmail string
+	MerchantName  string
+	Frequency     string // "daily" or "weekly"
+	Period        string // e.g. "2026-06-01" or "2026-06-01 – 2026-06-07"
+	Timezone      string // IANA name, e.g. "Europe/Madrid"
+	FiatCode      string // e.g. "EUR"
+
+	Payments  int64 // created within the period
+	Succeeded int64
+	Partial   int64
+	Underpaid int64
+	Expired   int64
+
+	GrossFiat   string
+	Volumes     []ReportVolume
+	TopLinks    []ReportLink
+	Collectors  []ReportCollectorBalance
+	Attachments []Attachment
+}
+
+type ReportVolume struct {
+	Ticker string
+	Amount string
+	Fiat   string
+}
+
+type ReportLink struct {
+	Name     string
+	Payments int64
+	Volume   string // e.g. "120.00 USD"
+}
+
+type ReportCollectorBalance struct {
+	Blockchain string
+	Address    string
+	Balances   string // e.g. "0.5 ETH, 120 USDT"
+}
+
+// SendSettlementReport sends merchant's daily or weekly settlement report.
+func (s *Service) SendSettlementReport(ctx context.Context, params SettlementReportParams) error {
+	title := "Daily"
+	if params.Frequency == "weekly" {
+		title = "Weekly"
+	}
+
+	body, err := renderSettlementReportTemplate(title, params)
+	if err != nil {
+		return err
+	}
+
+	return s.SendEmail(ctx, SendEmailParams{
+		To:          params.MerchantEmail,
+		Subject:     fmt.Sprintf("[CryptoLink] %s report for %s: %s", title, params.MerchantName, params.Period),
+		Body:        body,
+		Template:    "settlement_report_" + params.Frequency,
+		Attachments: params.Attachments,
+	})
+}
+
+const settlementReportTemplate = `<!DOCTYPE html>
+<html>
+<head><meta charset="UTF-8"></head>
+<body style="font-family:-apple-system,BlinkMacSystemFont,'Segoe UI',sans-serif;max-width:600px;margin:0 auto;padding:20px;">
+  <div style="background:#0f172a;padding:24px;border-radius:8px 8px 0 0;">
+    <h1 style="color:#fff;margin:0;font-size:20px;">CryptoLink</h1>
+  </div>
+  <div style="border:1px solid #e2e8f0;border-top:none;padding:24px;border-radius:0 0 8px 8px;">
+    <h2 style="color:#10b981;margin-top:0;">{{.Title}} report</h2>
+    <p><strong>{{.MerchantName}}</strong> &middot; {{.Period}} ({{.Timezone}})</p>
+    <div style="background:#f0fdf4;border:1px solid #bbf7d0;padding:16px;border-radius:8px;margin:16px 0;">
+      <p style="margin:4px 0;font-size:24px;font-weight:700;color:#059669;">{{.GrossFiat}} {{.FiatCode}}</p>
+      <p style="margin:4px 0;color:#64748b;">gross volume, {{.Succeeded}} of {{.Payments}} payments succeeded</p>
+    </div>
+    <table style="width:100%;border-collapse:collapse;font-size:14px;">
+      <tr><td style="padding:6px 0;color:#64748b;width:40%;">Partially paid</td><td style="padding:6px 0;">{{.Partial}}</td></tr>
+      <tr><td style="padding:6px 0;color:#64748b;">Underpaid</td><td style="padding:6px 0;">{{.Underpaid}}</td></tr>
+      <tr><td style="padding:6px 0;color:#64748b;">Expired</td><td style="padding:6px 0;">{{.Expired}}</td></tr>
+    </table>
+    {{if .Volumes}}
+    <h3 style="margin-bottom:8px;">Volume by currency</h3>
+    <table style="width:100%;border-collapse:collapse;font-size:14px;">
+      {{range .Volumes}}<tr><td style="padding:6px 0;">{{.Ticker}}</td><td style="padding:6px 0;">{{.Amount}}</td><td style="padding:6px 0;color:#64748b;">{{.Fiat}} {{$.FiatCode}}</td></tr>
+      {{end}}
+    </table>
+    {{end}}
+    {{if .TopLinks}}
+    <h3 style="margin-bottom:8px;">Top payment links</h3>
+    <table style="width:100%;border-collapse:collapse;font-size:14px;">
+      {{range .TopLinks}}<tr><td style="padding:6px 0;">{{.Name}}</td><td style="padding:6px 0;">{{.Payments}} payments</td><td style="padding:6px 0;color:#64748b;">{{.Volume}}</td></tr>
+      {{end}}
+    </table>
+    {{end}}
+    {{if .Collectors}}
+    <h3 style="margin-bottom:8px;">Collector balances</h3>
+    <table style="width:100%;border-collapse:collapse;font-size:14px;">
+      {{range .Collectors}}<tr><td style="padding:6px 0;">{{.Blockchain}}</td><td style="padding:6px 0;font-family:monospace;font-size:12px;">{{.Address}}</td><td style="padding:6px 0;">{{.Balances}}</td></tr>
+      {{end}}
+    </table>
+    {{end}}
+    <p style="color:#64748b;">The journal of the period is attached as CSV.</p>
+    <hr style="border:none;border-top:1px solid #e2e8f0;margin:24px 0;">
+    <p style="color:#94a3b8;font-size:12px;">This is an automated report from CryptoLink. Change report frequency in your dashboard settings.</p>
+  </div>
+</body>
+</html>`
+
+var settlementReportTmpl = template.Must(template.New("settlement_report").Parse(settlementReportTemplate))
+
+func renderSettlementReportTemplate(title string, params SettlementReportParams) (string, error) {
+	data := struct {
+		SettlementReportParams
+		Title string
+	}{params, title}
+
+	var buf strings.Builder
+	if err := settlementReportTmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/tls"
 	"fmt"
+	"html"
 	"html/template"
 	"net/smtp"
 	"strings"
@@ -43,10 +44,18 @@ type EmailLog struct {
 }
 
 type SendEmailParams struct {
-	To       string
-	Subject  string
-	Body     string
-	Template string
+	To          string
+	Subject     string
+	Body        string
+	Template    string
+	Attachments []Attachment
+}
+
+// Attachment file attached to the email.
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
 }
 
 func New(db *pgxpool.Pool, logger *zerolog.Logger) *Service {
@@ -116,13 +125,7 @@ func (s *Service) SendEmail(ctx context.Context, params SendEmailParams) error {
 
 	from := fmt.Sprintf("%s <%s>", settings.FromName, settings.FromEmail)
 
-	msg := fmt.Sprintf("From: %s\r\n"+
-		"To: %s\r\n"+
-		"Subject: %s\r\n"+
-		"MIME-Version: 1.0\r\n"+
-		"Content-Type: text/html; charset=\"UTF-8\"\r\n"+
-		"\r\n"+
-		"%s", from, params.To, params.Subject, params.Body)
+	msg := buildMessage(from, params)
 
 	addr := fmt.Sprintf("%s:%d", settings.SMTPHost, settings.SMTPPort)
 
@@ -135,7 +138,7 @@ func (s *Service) SendEmail(ctx context.Context, params SendEmailParams) error {
 	conn, err := tls.Dial("tcp", addr, tlsConfig)
 	if err != nil {
 		// Try STARTTLS fallback
-		sendErr := smtp.SendMail(addr, auth, settings.FromEmail, []string{params.To}, []byte(msg))
+		sendErr := smtp.SendMail(addr, auth, settings.FromEmail, []string{params.To}, msg)
 		if sendErr != nil {
 			s.logEmail(ctx, params.To, params.Subject, params.Template, "failed", sendErr.Error())
 			return errors.Wrap(sendErr, "failed to send email")
@@ -172,7 +175,7 @@ func (s *Service) SendEmail(ctx context.Context, params SendEmailParams) error {
 		return err
 	}
 
-	_, err = w.Write([]byte(msg))
+	_, err = w.Write(msg)
 	if err != nil {
 		s.logEmail(ctx, params.To, params.Subject, params.Template, "failed", err.Error())
 		return err
@@ -417,6 +420,7 @@ type CustomerPaymentConfirmParams struct {
 	ExplorerLink     string
 	Network          string
 	ReceivedAt       time.Time
+	Branding         Branding // merchant's checkout branding
 }
 
 // SendCustomerPaymentConfirmation sends a payment confirmation email to the customer.
@@ -477,26 +481,120 @@ func (s *Service) SendVerificationEmail(ctx context.Context, toEmail, name, toke
 	}
 }
 
+// SendPasswordResetEmail sends a link to set a new password.
+func (s *Service) SendPasswordResetEmail(ctx context.Context, toEmail, name, token string, ttl time.Duration) {
+	resetURL := "https://cryptolink.cc/merchants/reset-password?token=" + token
+
+	body := fmt.Sprintf(`<!DOCTYPE html>
+<html>
+<head><meta charset="UTF-8"></head>
+<body style="font-family:-apple-system,BlinkMacSystemFont,'Segoe UI',sans-serif;max-width:600px;margin:0 auto;padding:20px;">
+  <div style="background:#0f172a;padding:24px;border-radius:8px 8px 0 0;">
+    <h1 style="color:#fff;margin:0;font-size:20px;">CryptoLink</h1>
+  </div>
+  <div style="border:1px solid #e2e8f0;border-top:none;padding:24px;border-radius:0 0 8px 8px;">
+    <h2 style="color:#10b981;margin-top:0;">Reset Your Password</h2>
+    <p>Hello <strong>%s</strong>,</p>
+    <p>We received a request to reset the password of your CryptoLink account.</p>
+    <div style="text-align:center;margin:24px 0;">
+      <a href="%s" style="display:inline-block;background:#10b981;color:#fff;padding:14px 32px;border-radius:8px;text-decoration:none;font-weight:600;font-size:16px;">Reset Password</a>
+    </div>
+    <p style="color:#64748b;font-size:14px;">Or copy and paste this link into your browser:</p>
+    <p style="word-break:break-all;color:#10b981;font-size:13px;">%s</p>
+    <p style="color:#64748b;font-size:14px;">This link can be used once and expires in %d minutes. After the reset you will be signed out on all devices.</p>
+    <hr style="border:none;border-top:1px solid #e2e8f0;margin:24px 0;">
+    <p style="color:#94a3b8;font-size:12px;">If you did not request a password reset, you can safely ignore this email, your password will not change.</p>
+  </div>
+</body>
+</html>`, html.EscapeString(name), resetURL, resetURL, int(ttl.Minutes()))
+
+	if err := s.SendEmail(ctx, SendEmailParams{
+		To:       toEmail,
+		Subject:  "[CryptoLink] Reset your password",
+		Body:     body,
+		Template: "password_reset",
+	}); err != nil {
+		s.logger.Warn().Err(err).
+			Str("to", toEmail).
+			Msg("unable to send password reset email")
+	}
+}
+
+// SendMerchantInvitationEmail sends a link to join merchant's team.
+func (s *Service) SendMerchantInvitationEmail(
+	ctx context.Context,
+	toEmail, merchantName, inviterName, role, token string,
+	ttl time.Duration,
+) {
+	acceptURL := "https://cryptolink.cc/merchants/invitations/accept?token=" + token
+
+	body := fmt.Sprintf(`<!DOCTYPE html>
+<html>
+<head><meta charset="UTF-8"></head>
+<body style="font-family:-apple-system,BlinkMacSystemFont,'Segoe UI',sans-serif;max-width:600px;margin:0 auto;padding:20px;">
+  <div style="background:#0f172a;padding:24px;border-radius:8px 8px 0 0;">
+    <h1 style="color:#fff;margin:0;font-size:20px;">CryptoLink</h1>
+  </div>
+  <div style="border:1px solid #e2e8f0;border-top:none;padding:24px;border-radius:0 0 8px 8px;">
+    <h2 style="color:#10b981;margin-top:0;">Join %s</h2>
+    <p><strong>%s</strong> invited you to join <strong>%s</strong> on CryptoLink as <strong>%s</strong>.</p>
+    <div style="text-align:center;margin:24px 0;">
+      <a href="%s" style="display:inline-block;background:#10b981;color:#fff;padding:14px 32px;border-radius:8px;text-decoration:none;font-weight:600;font-size:16px;">Accept Invitation</a>
+    </div>
+    <p style="color:#64748b;font-size:14px;">Or copy and paste this link into your browser:</p>
+    <p style="word-break:break-all;color:#10b981;font-size:13px;">%s</p>
+    <p style="color:#64748b;font-size:14px;">Sign in or register with this email address to accept. The invitation expires in %d days.</p>
+    <hr style="border:none;border-top:1px solid #e2e8f0;margin:24px 0;">
+    <p style="color:#94a3b8;font-size:12px;">If you don't know the sender, you can safely ignore this email.</p>
+  </div>
+</body>
+</html>`,
+		html.EscapeString(merchantName),
+		html.EscapeString(inviterName),
+		html.EscapeString(merchantName),
+		html.EscapeString(role),
+		acceptURL,
+		acceptURL,
+		int(ttl.Hours()/24),
+	)
+
+	if err := s.SendEmail(ctx, SendEmailParams{
+		To:       toEmail,
+		Subject:  "[CryptoLink] You are invited to join " + merchantName,
+		Body:     body,
+		Template: "merchant_invitation",
+	}); err != nil {
+		s.logger.Warn().Err(err).
+			Str("to", toEmail).
+			Msg("unable to send merchant invitation email")
+	}
+}
+
 func renderCustomerPaymentConfirmTemplate(params CustomerPaymentConfirmParams) string {
 	shortTx := params.TxHash
 	if len(shortTx) > 20 {
 		shortTx = shortTx[:10] + "..." + shortTx[len(shortTx)-10:]
 	}
 
+	accent := params.Branding.accent()
+
 	explorerBtn := ""
 	if params.ExplorerLink != "" {
-		explorerBtn = fmt.Sprintf(`<a href="%s" style="display:inline-block;background:#10b981;color:#fff;padding:10px 20px;border-radius:6px;text-decoration:none;margin-top:8px;">View Transaction</a>`, params.ExplorerLink)
+		explorerBtn = fmt.Sprintf(`<a href="%s" style="display:inline-block;background:%s;color:#fff;padding:10px 20px;border-radius:6px;text-decoration:none;margin-top:8px;">View Transaction</a>`, params.ExplorerLink, accent)
+	}
+
+	title := "CryptoLink"
+	if params.Branding != (Branding{}) {
+		title = params.MerchantName
 	}
 
 	return fmt.Sprintf(`<!DOCTYPE html>
 <html>
 <head><meta charset="UTF-8"></head>
 <body style="font-family:-apple-system,BlinkMacSystemFont,'Segoe UI',sans-serif;max-width:600px;margin:0 auto;padding:20px;">
-  <div style="background:#0f172a;padding:24px;border-radius:8px 8px 0 0;">
-    <h1 style="color:#fff;margin:0;font-size:20px;">CryptoLink</h1>
-  </div>
+  %s
   <div style="border:1px solid #e2e8f0;border-top:none;padding:24px;border-radius:0 0 8px 8px;">
-    <h2 style="color:#10b981;margin-top:0;">Payment Confirmed</h2>
+    <h2 style="color:%s;margin-top:0;">Payment Confirmed</h2>
     <p>Your payment to <strong>%s</strong> has been confirmed on the <strong>%s</strong> network.</p>
     <div style="background:#f0fdf4;border:1px solid #bbf7d0;padding:16px;border-radius:8px;margin:16px 0;">
       <p style="margin:4px 0;font-size:24px;font-weight:700;color:#059669;">%s %s</p>
@@ -508,10 +606,13 @@ func renderCustomerPaymentConfirmTemplate(params CustomerPaymentConfirmParams) s
     </table>
     %s
     <hr style="border:none;border-top:1px solid #e2e8f0;margin:24px 0;">
+    %s
     <p style="color:#94a3b8;font-size:12px;">This is an automated receipt from CryptoLink on behalf of %s.</p>
   </div>
 </body>
 </html>`,
+		params.Branding.header(title),
+		accent,
 		params.MerchantName,
 		params.Network,
 		params.Amount, params.Ticker,
@@ -519,6 +620,7 @@ func renderCustomerPaymentConfirmTemplate(params CustomerPaymentConfirmParams) s
 		shortTx,
 		params.ReceivedAt.Format("2006-01-02 15:04:05 UTC"),
 		explorerBtn,
+		params.Branding.footer(),
 		params.MerchantName,
 	)
 }
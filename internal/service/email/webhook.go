@@ -0,0 +1,60 @@
+package email
+
+import (
+	"context"
+	"fmt"
+	"html"
+)
+
+// WebhookDisabledParams contains data for a disabled webhook endpoint notification.
+type WebhookDisabledParams struct {
+	MerchantEmail string
+	MerchantName  string
+	WebhookURL    string
+	LastError     string
+	FailingSince  string // e.g. "2026-06-01 12:00 UTC"
+}
+
+// SendWebhookDisabled notifies the merchant that webhook endpoint was disabled
+// after persistent delivery failures. Best-effort: errors are logged.
+func (s *Service) SendWebhookDisabled(ctx context.Context, params WebhookDisabledParams) {
+	subject := fmt.Sprintf("[CryptoLink] Webhook endpoint disabled for %s", params.MerchantName)
+
+	body := fmt.Sprintf(`<!DOCTYPE html>
+<html>
+<head><meta charset="UTF-8"></head>
+<body style="font-family:-apple-system,BlinkMacSystemFont,'Segoe UI',sans-serif;max-width:600px;margin:0 auto;padding:20px;">
+  <div style="background:#0f172a;padding:24px;border-radius:8px 8px 0 0;">
+    <h1 style="color:#fff;margin:0;font-size:20px;">CryptoLink</h1>
+  </div>
+  <div style="border:1px solid #e2e8f0;border-top:none;padding:24px;border-radius:0 0 8px 8px;">
+    <h2 style="color:#ff4d4f;margin-top:0;">Webhook Endpoint Disabled</h2>
+    <p>Hello <strong>%s</strong>, we could not deliver webhooks to your endpoint since %s and have stopped sending them.</p>
+    <div style="background:#fff1f0;border:1px solid #ffa39e;padding:16px;border-radius:8px;margin:16px 0;">
+      <p style="margin:4px 0;"><strong>Endpoint:</strong> <span style="font-family:monospace;">%s</span></p>
+      <p style="margin:4px 0;"><strong>Last error:</strong> %s</p>
+    </div>
+    <p>Fix the endpoint and enable it again in your dashboard webhook settings to resume deliveries.</p>
+    <a href="https://cryptolink.cc/merchants/settings" style="display:inline-block;background:#10b981;color:#fff;padding:12px 24px;border-radius:6px;text-decoration:none;margin-top:8px;">Open Settings</a>
+    <hr style="border:none;border-top:1px solid #e2e8f0;margin:24px 0;">
+    <p style="color:#94a3b8;font-size:12px;">This is an automated notification from CryptoLink.</p>
+  </div>
+</body>
+</html>`,
+		html.EscapeString(params.MerchantName),
+		html.EscapeString(params.FailingSince),
+		html.EscapeString(params.WebhookURL),
+		html.EscapeString(params.LastError),
+	)
+
+	if err := s.SendEmail(ctx, SendEmailParams{
+		To:       params.MerchantEmail,
+		Subject:  subject,
+		Body:     body,
+		Template: "webhook_disabled",
+	}); err != nil {
+		s.logger.Warn().Err(err).
+			Str("merchant_email", params.MerchantEmail).
+			Msg("unable to send webhook disabled email")
+	}
+}
@@ -0,0 +1,327 @@
+package evmcollector
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+// DeploymentStatus status of a server-assisted collector deployment.
+type DeploymentStatus string
+
+const (
+	// DeploymentPending calldata was issued, waiting for CloneCreated event.
+	DeploymentPending   DeploymentStatus = "pending"
+	DeploymentConfirmed DeploymentStatus = "confirmed"
+	DeploymentFailed    DeploymentStatus = "failed"
+	DeploymentExpired   DeploymentStatus = "expired"
+)
+
+// deploymentTTL how long a pending deployment is watched for CloneCreated event.
+const deploymentTTL = 24 * time.Hour
+
+const (
+	// signatureDeploy CryptoLinkCloneFactory's clone creation entry point.
+	signatureDeploy = "deploy(address)"
+
+	// keccak256("deploy(address)")[:4]
+	selectorDeploy = "0x4c96a389"
+)
+
+var (
+	ErrDeploymentNotFound = errors.New("evm collector deployment not found")
+	ErrInvalidAddress     = errors.New("invalid address")
+)
+
+// Deployment collector deployment prepared by the backend and signed by the merchant.
+type Deployment struct {
+	ID              int64
+	UUID            uuid.UUID
+	MerchantID      int64
+	Blockchain      string
+	ChainID         int
+	FactoryAddress  string
+	OwnerAddress    string
+	Status          DeploymentStatus
+	SearchFrom      int64
+	TxHash          string
+	ContractAddress string
+	Error           string
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	ExpiresAt       time.Time
+}
+
+// DeploymentTx unsigned factory call the merchant signs in their wallet.
+type DeploymentTx struct {
+	To    string
+	Data  string
+	Value string
+
+	// FunctionSelector and Parameter are used by TRON wallets that trigger
+	// contracts by function signature and ABI-encoded arguments.
+	FunctionSelector string
+	Parameter        string
+}
+
+// Transaction returns factory deploy(owner) call for the deployment.
+func (d *Deployment) Transaction() DeploymentTx {
+	ownerHex, _ := addressToHex(d.Blockchain, d.OwnerAddress)
+	parameter := strings.Repeat("0", 24) + ownerHex
+
+	return DeploymentTx{
+		To:               d.FactoryAddress,
+		Data:             selectorDeploy + parameter,
+		Value:            "0",
+		FunctionSelector: signatureDeploy,
+		Parameter:        parameter,
+	}
+}
+
+// PrepareDeployment creates (or restarts) a pending deployment of merchant's
+// collector on the chain. The factory is taken from app config or collector_factories,
+// never from the client.
+func (s *Service) PrepareDeployment(
+	ctx context.Context,
+	merchantID int64,
+	blockchain string,
+	chainID int,
+	ownerAddress string,
+) (*Deployment, error) {
+	blockchain = strings.ToUpper(blockchain)
+
+	if _, err := addressToHex(blockchain, ownerAddress); err != nil {
+		return nil, errors.Wrap(ErrInvalidAddress, "owner address")
+	}
+
+	if blockchain != "TRON" {
+		ownerAddress = strings.ToLower(ownerAddress)
+	}
+
+	_, err := s.GetByMerchantAndBlockchain(ctx, merchantID, blockchain)
+	switch {
+	case err == nil:
+		return nil, ErrAlreadyExists
+	case !errors.Is(err, ErrNotFound):
+		return nil, err
+	}
+
+	factory, err := s.trustedFactoryAddress(ctx, blockchain)
+	if err != nil {
+		return nil, err
+	}
+
+	reader := s.chainReader(blockchain)
+	if reader == nil {
+		return nil, errors.Wrapf(ErrVerificationUnavailable, "no rpc endpoint for %s", blockchain)
+	}
+
+	// CloneCreated events emitted before this point belong to earlier deploys
+	searchFrom, err := reader.cursor(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(ErrVerificationUnavailable, "unable to get chain position: %s", err)
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+
+	_, err = s.db.Exec(ctx, `
+		INSERT INTO evm_collector_deployments
+			(uuid, merchant_id, blockchain, chain_id, factory_address, owner_address, status, search_from,
+			 tx_hash, contract_address, error, created_at, updated_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NULL, NULL, NULL, $9, $9, $10)
+		ON CONFLICT (merchant_id, blockchain)
+		DO UPDATE SET
+			uuid             = EXCLUDED.uuid,
+			chain_id         = EXCLUDED.chain_id,
+			factory_address  = EXCLUDED.factory_address,
+			owner_address    = EXCLUDED.owner_address,
+			status           = EXCLUDED.status,
+			search_from      = EXCLUDED.search_from,
+			tx_hash          = NULL,
+			contract_address = NULL,
+			error            = NULL,
+			created_at       = EXCLUDED.created_at,
+			updated_at       = EXCLUDED.updated_at,
+			expires_at       = EXCLUDED.expires_at
+	`, uuid.New(), merchantID, blockchain, chainID, factory, ownerAddress,
+		string(DeploymentPending), searchFrom, now, now.Add(deploymentTTL))
+
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to upsert evm collector deployment")
+	}
+
+	return s.GetDeployment(ctx, merchantID, blockchain)
+}
+
+// GetDeployment returns the latest deployment of merchant's collector on the chain.
+func (s *Service) GetDeployment(ctx context.Context, merchantID int64, blockchain string) (*Deployment, error) {
+	return s.scanDeployment(s.db.QueryRow(ctx, `
+		SELECT `+deploymentColumns+`
+		FROM evm_collector_deployments
+		WHERE merchant_id = $1 AND blockchain = $2
+	`, merchantID, strings.ToUpper(blockchain)))
+}
+
+// SetDeploymentTxHash attaches the signed deploy transaction to a pending deployment
+// so CloneCreated is looked up in its receipt instead of scanning factory logs.
+func (s *Service) SetDeploymentTxHash(ctx context.Context, merchantID int64, blockchain, txHash string) (*Deployment, error) {
+	result, err := s.db.Exec(ctx, `
+		UPDATE evm_collector_deployments SET tx_hash = $3, updated_at = $4
+		WHERE merchant_id = $1 AND blockchain = $2 AND status = $5
+	`, merchantID, strings.ToUpper(blockchain), txHash, time.Now().UTC().Truncate(time.Second), string(DeploymentPending))
+
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to update evm collector deployment")
+	}
+	if result.RowsAffected() == 0 {
+		return nil, ErrDeploymentNotFound
+	}
+
+	return s.GetDeployment(ctx, merchantID, blockchain)
+}
+
+// ProcessPendingDeployments looks for CloneCreated events of pending deployments
+// and registers found clones as merchants' collectors. Stale deployments are expired.
+func (s *Service) ProcessPendingDeployments(ctx context.Context) error {
+	now := time.Now().UTC().Truncate(time.Second)
+
+	_, err := s.db.Exec(ctx, `
+		UPDATE evm_collector_deployments SET status = $1, updated_at = $2
+		WHERE status = $3 AND expires_at < $2
+	`, string(DeploymentExpired), now, string(DeploymentPending))
+	if err != nil {
+		return errors.Wrap(err, "unable to expire evm collector deployments")
+	}
+
+	rows, err := s.db.Query(ctx, `
+		SELECT `+deploymentColumns+`
+		FROM evm_collector_deployments
+		WHERE status = $1
+		ORDER BY id
+	`, string(DeploymentPending))
+	if err != nil {
+		return errors.Wrap(err, "unable to list evm collector deployments")
+	}
+
+	var deployments []*Deployment
+	for rows.Next() {
+		d, err := s.scanDeployment(rows)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		deployments = append(deployments, d)
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return errors.Wrap(err, "unable to list evm collector deployments")
+	}
+
+	for _, d := range deployments {
+		if err := s.resolveDeployment(ctx, d); err != nil {
+			s.logger.Error().Err(err).
+				Int64("merchant_id", d.MerchantID).
+				Str("blockchain", d.Blockchain).
+				Msg("unable to resolve evm collector deployment")
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) resolveDeployment(ctx context.Context, d *Deployment) error {
+	reader := s.chainReader(d.Blockchain)
+	if reader == nil {
+		return nil
+	}
+
+	ownerHex, err := addressToHex(d.Blockchain, d.OwnerAddress)
+	if err != nil {
+		return s.markDeployment(ctx, d.ID, DeploymentFailed, "", d.TxHash, "invalid owner address")
+	}
+
+	clones, err := reader.clonesCreated(ctx, d.FactoryAddress, ownerHex, d.TxHash, d.SearchFrom)
+	if err != nil {
+		s.logger.Warn().Err(err).Int64("deployment_id", d.ID).Msg("unable to get CloneCreated events")
+		return nil
+	}
+
+	if len(clones) == 0 {
+		return nil
+	}
+
+	// the most recent clone wins when merchant deployed more than once
+	clone := clones[len(clones)-1]
+
+	txHash := clone.TxHash
+	if txHash == "" {
+		txHash = d.TxHash
+	}
+
+	col, err := s.RegisterCollector(
+		ctx,
+		d.MerchantID,
+		d.Blockchain,
+		d.ChainID,
+		hexToAddress(d.Blockchain, clone.Hex),
+		d.OwnerAddress,
+		txHash,
+	)
+
+	switch {
+	case errors.Is(err, ErrVerificationFailed):
+		return s.markDeployment(ctx, d.ID, DeploymentFailed, "", txHash, err.Error())
+	case errors.Is(err, ErrVerificationUnavailable):
+		return nil
+	case err != nil:
+		return err
+	}
+
+	s.logger.Info().
+		Int64("merchant_id", d.MerchantID).
+		Str("blockchain", d.Blockchain).
+		Str("contract_address", col.ContractAddress).
+		Msg("evm collector deployment confirmed")
+
+	return s.markDeployment(ctx, d.ID, DeploymentConfirmed, col.ContractAddress, txHash, "")
+}
+
+func (s *Service) markDeployment(
+	ctx context.Context,
+	id int64,
+	status DeploymentStatus,
+	contractAddress, txHash, reason string,
+) error {
+	_, err := s.db.Exec(ctx, `
+		UPDATE evm_collector_deployments
+		SET status = $2, contract_address = NULLIF($3, ''), tx_hash = NULLIF($4, ''),
+		    error = NULLIF($5, ''), updated_at = $6
+		WHERE id = $1
+	`, id, string(status), contractAddress, txHash, reason, time.Now().UTC().Truncate(time.Second))
+
+	return errors.Wrap(err, "unable to update evm collector deployment")
+}
+
+const deploymentColumns = `id, uuid, merchant_id, blockchain, chain_id, factory_address, owner_address,
+		       status, search_from, COALESCE(tx_hash, ''), COALESCE(contract_address, ''), COALESCE(error, ''),
+		       created_at, updated_at, expires_at`
+
+func (s *Service) scanDeployment(row scanner) (*Deployment, error) {
+	d := &Deployment{}
+	err := row.Scan(
+		&d.ID, &d.UUID, &d.MerchantID, &d.Blockchain, &d.ChainID, &d.FactoryAddress, &d.OwnerAddress,
+		&d.Status, &d.SearchFrom, &d.TxHash, &d.ContractAddress, &d.Error,
+		&d.CreatedAt, &d.UpdatedAt, &d.ExpiresAt,
+	)
+	if err != nil {
+		if strings.Contains(err.Error(), "no rows") {
+			return nil, ErrDeploymentNotFound
+		}
+		return nil, errors.Wrap(err, "unable to scan evm collector deployment")
+	}
+	return d, nil
+}
@@ -0,0 +1,34 @@
+package evmcollector
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeployment_Transaction(t *testing.T) {
+	d := &Deployment{
+		Blockchain:     "ETH",
+		FactoryAddress: testFactory,
+		OwnerAddress:   "0xAbCdEf0123456789aBcDeF0123456789AbCdEf01",
+	}
+
+	tx := d.Transaction()
+
+	assert.Equal(t, testFactory, tx.To)
+	assert.Equal(t, "0", tx.Value)
+	assert.Equal(t, "deploy(address)", tx.FunctionSelector)
+	assert.Equal(t, "000000000000000000000000abcdef0123456789abcdef0123456789abcdef01", tx.Parameter)
+	assert.Equal(t, "0x4c96a389"+tx.Parameter, tx.Data)
+}
+
+func TestHexToAddress(t *testing.T) {
+	const h = "a614f803b6fd780986a42c78ec9c7f77e6ded13c"
+
+	assert.Equal(t, "0x"+h, hexToAddress("ETH", h))
+	assert.Equal(t, "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t", hexToAddress("TRON", h))
+
+	back, err := addressToHex("TRON", "TR7NHqjeKQxGTCi8q8ZY4pL8otSzgjLj6t")
+	assert.NoError(t, err)
+	assert.Equal(t, h, back)
+}
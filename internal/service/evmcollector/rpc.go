@@ -0,0 +1,444 @@
+package evmcollector
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ────────────────────────────────────────────────────────────────────────────
+// EVM JSON-RPC reader
+// ────────────────────────────────────────────────────────────────────────────
+
+type evmReader struct {
+	rpcURL string
+}
+
+type rpcRawResponse struct {
+	Result json.RawMessage `json:"result"`
+	Error  *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+type rpcLog struct {
+	Address         string   `json:"address"`
+	Topics          []string `json:"topics"`
+	Data            string   `json:"data"`
+	TransactionHash string   `json:"transactionHash"`
+	LogIndex        string   `json:"logIndex"`
+	BlockNumber     string   `json:"blockNumber"`
+}
+
+func (r evmReader) code(ctx context.Context, address string) (string, error) {
+	var code string
+	if err := r.call(ctx, "eth_getCode", []interface{}{address, "latest"}, &code); err != nil {
+		return "", err
+	}
+
+	return strings.TrimPrefix(strings.ToLower(code), "0x"), nil
+}
+
+func (r evmReader) callAddress(ctx context.Context, contract, signature string) (string, error) {
+	selector, ok := evmSelectors[signature]
+	if !ok {
+		return "", fmt.Errorf("unknown function %q", signature)
+	}
+
+	var result string
+	params := []interface{}{
+		map[string]string{"to": contract, "data": selector},
+		"latest",
+	}
+
+	if err := r.call(ctx, "eth_call", params, &result); err != nil {
+		return "", err
+	}
+
+	return wordToAddress(result)
+}
+
+func (r evmReader) clonesCreated(ctx context.Context, factory, ownerHex, txHash string, from int64) ([]createdClone, error) {
+	var logs []rpcLog
+
+	if txHash != "" {
+		var receipt *struct {
+			Logs []rpcLog `json:"logs"`
+		}
+		if err := r.call(ctx, "eth_getTransactionReceipt", []interface{}{txHash}, &receipt); err != nil {
+			return nil, err
+		}
+		if receipt == nil {
+			return nil, nil
+		}
+		logs = receipt.Logs
+	} else {
+		fromBlock := "earliest"
+		if from > 0 {
+			fromBlock = fmt.Sprintf("0x%x", from)
+		}
+
+		filter := map[string]interface{}{
+			"address":   factory,
+			"fromBlock": fromBlock,
+			"toBlock":   "latest",
+			"topics":    []interface{}{cloneCreatedTopic, "0x" + strings.Repeat("0", 24) + ownerHex},
+		}
+		if err := r.call(ctx, "eth_getLogs", []interface{}{filter}, &logs); err != nil {
+			return nil, err
+		}
+	}
+
+	factoryHex := strings.TrimPrefix(strings.ToLower(factory), "0x")
+
+	var clones []createdClone
+	for _, l := range logs {
+		cloneHex, ok := cloneFromLog(l, factoryHex, ownerHex)
+		if !ok {
+			continue
+		}
+		clones = append(clones, createdClone{Hex: cloneHex, TxHash: l.TransactionHash})
+	}
+
+	return clones, nil
+}
+
+func (r evmReader) cursor(ctx context.Context) (int64, error) {
+	var blockNumber string
+	if err := r.call(ctx, "eth_blockNumber", []interface{}{}, &blockNumber); err != nil {
+		return 0, err
+	}
+
+	n, err := strconv.ParseInt(strings.TrimPrefix(blockNumber, "0x"), 16, 64)
+	if err != nil {
+		return 0, fmt.Errorf("unexpected block number %q", blockNumber)
+	}
+
+	return n, nil
+}
+
+// cloneFromLog matches CloneCreated(address indexed owner, address clone)
+// and returns clone address.
+func cloneFromLog(l rpcLog, factoryHex, ownerHex string) (string, bool) {
+	if strings.TrimPrefix(strings.ToLower(l.Address), "0x") != factoryHex {
+		return "", false
+	}
+
+	if len(l.Topics) != 2 || !strings.EqualFold(l.Topics[0], cloneCreatedTopic) {
+		return "", false
+	}
+
+	owner, err := wordToAddress(l.Topics[1])
+	if err != nil || owner != ownerHex {
+		return "", false
+	}
+
+	clone, err := wordToAddress(l.Data)
+	if err != nil {
+		return "", false
+	}
+
+	return clone, true
+}
+
+func (r evmReader) withdrawals(ctx context.Context, collector string, cursor int64) ([]withdrawalEvent, int64, error) {
+	head, err := r.cursor(ctx)
+	if err != nil {
+		return nil, cursor, err
+	}
+
+	// eth_blockNumber can report a block before eth_getLogs can serve it
+	const headBlockBuffer = 3
+	safeHead := head - headBlockBuffer
+
+	from := cursor + 1
+	if cursor == 0 {
+		from = safeHead - withdrawalsColdStartBlocks
+	}
+	if from < 0 {
+		from = 0
+	}
+
+	if from > safeHead {
+		return nil, cursor, nil
+	}
+
+	to := safeHead
+	if to-from >= withdrawalsMaxBlocksPerRun {
+		to = from + withdrawalsMaxBlocksPerRun - 1
+	}
+
+	var events []withdrawalEvent
+	for chunkFrom := from; chunkFrom <= to; chunkFrom += withdrawalsLogsChunk {
+		chunkTo := chunkFrom + withdrawalsLogsChunk - 1
+		if chunkTo > to {
+			chunkTo = to
+		}
+
+		filter := map[string]interface{}{
+			"address":   collector,
+			"fromBlock": fmt.Sprintf("0x%x", chunkFrom),
+			"toBlock":   fmt.Sprintf("0x%x", chunkTo),
+			"topics":    []interface{}{[]string{withdrewNativeTopic, withdrewTokenTopic}},
+		}
+
+		var logs []rpcLog
+		if err := r.call(ctx, "eth_getLogs", []interface{}{filter}, &logs); err != nil {
+			return nil, cursor, err
+		}
+
+		for _, l := range logs {
+			if e, ok := withdrawalFromLog(l); ok {
+				events = append(events, e)
+			}
+		}
+	}
+
+	return events, to, nil
+}
+
+// withdrawalFromLog parses WithdrewNative(address indexed to, uint256 amount)
+// and WithdrewToken(address indexed token, address indexed to, uint256 amount).
+func withdrawalFromLog(l rpcLog) (withdrawalEvent, bool) {
+	data := strings.TrimPrefix(l.Data, "0x")
+	if len(l.Topics) < 2 || len(data) < 64 {
+		return withdrawalEvent{}, false
+	}
+
+	amount, ok := new(big.Int).SetString(data[len(data)-64:], 16)
+	if !ok {
+		return withdrawalEvent{}, false
+	}
+
+	logIndex, _ := strconv.ParseInt(strings.TrimPrefix(l.LogIndex, "0x"), 16, 64)
+	blockNumber, _ := strconv.ParseInt(strings.TrimPrefix(l.BlockNumber, "0x"), 16, 64)
+
+	e := withdrawalEvent{
+		Amount:      amount,
+		TxHash:      l.TransactionHash,
+		LogIndex:    int(logIndex),
+		BlockNumber: blockNumber,
+	}
+
+	var err error
+	switch {
+	case strings.EqualFold(l.Topics[0], withdrewNativeTopic):
+		e.ToHex, err = wordToAddress(l.Topics[1])
+	case strings.EqualFold(l.Topics[0], withdrewTokenTopic) && len(l.Topics) == 3:
+		if e.TokenHex, err = wordToAddress(l.Topics[1]); err == nil {
+			e.ToHex, err = wordToAddress(l.Topics[2])
+		}
+	default:
+		return withdrawalEvent{}, false
+	}
+
+	return e, err == nil
+}
+
+func (r evmReader) call(ctx context.Context, method string, params []interface{}, out interface{}) error {
+	payload, _ := json.Marshal(rpcRequest{
+		JSONRPC: "2.0",
+		Method:  method,
+		Params:  params,
+		ID:      1,
+	})
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.rpcURL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var result rpcRawResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return err
+	}
+	if result.Error != nil {
+		return fmt.Errorf("rpc: %s", result.Error.Message)
+	}
+
+	return json.Unmarshal(result.Result, out)
+}
+
+// ────────────────────────────────────────────────────────────────────────────
+// TRON reader via TronGrid REST API
+// ────────────────────────────────────────────────────────────────────────────
+
+const tronGridBaseURL = "https://api.trongrid.io"
+
+type tronReader struct{}
+
+type tronContractInfoResponse struct {
+	RuntimeCode string `json:"runtimecode"`
+}
+
+type tronEventsResponse struct {
+	Data []struct {
+		EventName      string            `json:"event_name"`
+		EventIndex     int               `json:"event_index"`
+		Contract       string            `json:"contract_address"`
+		TransactionID  string            `json:"transaction_id"`
+		BlockNumber    int64             `json:"block_number"`
+		BlockTimestamp int64             `json:"block_timestamp"`
+		Result         map[string]string `json:"result"`
+	} `json:"data"`
+}
+
+func (tronReader) code(ctx context.Context, address string) (string, error) {
+	var result tronContractInfoResponse
+	body := map[string]interface{}{"value": address, "visible": true}
+
+	if err := tronRequest(ctx, http.MethodPost, "/wallet/getcontractinfo", body, &result); err != nil {
+		return "", err
+	}
+
+	return strings.ToLower(result.RuntimeCode), nil
+}
+
+func (tronReader) callAddress(ctx context.Context, contract, signature string) (string, error) {
+	var result tronTriggerResponse
+	body := map[string]interface{}{
+		"owner_address":     contract,
+		"contract_address":  contract,
+		"function_selector": signature,
+		"visible":           true,
+	}
+
+	if err := tronRequest(ctx, http.MethodPost, "/wallet/triggerconstantcontract", body, &result); err != nil {
+		return "", err
+	}
+
+	if !result.Result.Result || len(result.ConstantResult) == 0 {
+		return "", fmt.Errorf("%s call failed", signature)
+	}
+
+	return wordToAddress(result.ConstantResult[0])
+}
+
+func (tronReader) clonesCreated(ctx context.Context, factory, ownerHex, txHash string, from int64) ([]createdClone, error) {
+	path := fmt.Sprintf(
+		"/v1/contracts/%s/events?event_name=CloneCreated&order_by=block_timestamp,asc&limit=200&min_block_timestamp=%d",
+		url.PathEscape(factory), from,
+	)
+	if txHash != "" {
+		path = fmt.Sprintf("/v1/transactions/%s/events", url.PathEscape(txHash))
+	}
+
+	var result tronEventsResponse
+	if err := tronRequest(ctx, http.MethodGet, path, nil, &result); err != nil {
+		return nil, err
+	}
+
+	var clones []createdClone
+	for _, e := range result.Data {
+		if e.EventName != "CloneCreated" || e.Contract != factory {
+			continue
+		}
+
+		// TronGrid returns event addresses as 0x-prefixed 20-byte hex
+		owner := strings.TrimPrefix(strings.ToLower(e.Result["owner"]), "0x")
+		clone := strings.TrimPrefix(strings.ToLower(e.Result["clone"]), "0x")
+
+		if owner == ownerHex && len(clone) == 40 {
+			clones = append(clones, createdClone{Hex: clone, TxHash: e.TransactionID})
+		}
+	}
+
+	return clones, nil
+}
+
+// cursor returns current time in ms: TRON events are searched by block timestamp
+// and TRON produces a block every 3 seconds.
+func (tronReader) cursor(_ context.Context) (int64, error) {
+	return time.Now().UnixMilli(), nil
+}
+
+func (tronReader) withdrawals(ctx context.Context, collector string, cursor int64) ([]withdrawalEvent, int64, error) {
+	path := fmt.Sprintf(
+		"/v1/contracts/%s/events?only_confirmed=true&order_by=block_timestamp,asc&limit=200&min_block_timestamp=%d",
+		url.PathEscape(collector), cursor+1,
+	)
+
+	var result tronEventsResponse
+	if err := tronRequest(ctx, http.MethodGet, path, nil, &result); err != nil {
+		return nil, cursor, err
+	}
+
+	var events []withdrawalEvent
+	for _, e := range result.Data {
+		if e.BlockTimestamp > cursor {
+			cursor = e.BlockTimestamp
+		}
+
+		if e.EventName != "WithdrewNative" && e.EventName != "WithdrewToken" {
+			continue
+		}
+
+		amount, ok := new(big.Int).SetString(e.Result["amount"], 10)
+		if !ok {
+			continue
+		}
+
+		// TronGrid returns event addresses as 0x-prefixed 20-byte hex
+		w := withdrawalEvent{
+			ToHex:       strings.TrimPrefix(strings.ToLower(e.Result["to"]), "0x"),
+			Amount:      amount,
+			TxHash:      e.TransactionID,
+			LogIndex:    e.EventIndex,
+			BlockNumber: e.BlockNumber,
+		}
+		if e.EventName == "WithdrewToken" {
+			w.TokenHex = strings.TrimPrefix(strings.ToLower(e.Result["token"]), "0x")
+		}
+
+		events = append(events, w)
+	}
+
+	return events, cursor, nil
+}
+
+func tronRequest(ctx context.Context, method, path string, body, out interface{}) error {
+	var reader *bytes.Reader
+	if body != nil {
+		payload, _ := json.Marshal(body)
+		reader = bytes.NewReader(payload)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, tronGridBaseURL+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("trongrid: unexpected status %d", resp.StatusCode)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -41,6 +41,15 @@ type Collector struct {
 	IsActive        bool
 	CreatedAt       time.Time
 	UpdatedAt       time.Time
+
+	// On-chain ownership verification, see VerifyCollector.
+	DeploymentTxHash   string
+	VerificationStatus VerificationStatus
+	VerificationError  string
+	VerifiedAt         *time.Time
+
+	// WithdrawalsCursor position withdrawals were indexed up to, see IndexWithdrawals.
+	WithdrawalsCursor int64
 }
 
 // TokenBalance holds an on-chain token balance.
@@ -77,8 +86,8 @@ func New(db *pgxpool.Pool, config Config, logger *zerolog.Logger) *Service {
 }
 
 // RegisterCollector creates a new collector record.
-// The contract_address must be pre-computed by the frontend using CREATE2 prediction,
-// or simply the merchant's wallet address for a simpler flow.
+// The contract must be deployed by the trusted factory and owned by ownerAddress,
+// this is verified on-chain before the record is stored (see VerifyCollector).
 func (s *Service) RegisterCollector(
 	ctx context.Context,
 	merchantID int64,
@@ -86,10 +95,20 @@ func (s *Service) RegisterCollector(
 	chainID int,
 	contractAddress string,
 	ownerAddress string,
-	factoryAddress string,
+	deploymentTxHash string,
 ) (*Collector, error) {
 	blockchain = strings.ToUpper(blockchain)
 
+	factoryAddress, err := s.VerifyCollector(ctx, VerifyCollectorParams{
+		Blockchain:       blockchain,
+		ContractAddress:  contractAddress,
+		OwnerAddress:     ownerAddress,
+		DeploymentTxHash: deploymentTxHash,
+	})
+	if err != nil {
+		return nil, err
+	}
+
 	// Only lowercase EVM addresses (hex). TRON uses Base58Check where case matters.
 	if blockchain != "TRON" {
 		contractAddress = strings.ToLower(contractAddress)
@@ -99,19 +118,25 @@ func (s *Service) RegisterCollector(
 	now := time.Now().UTC().Truncate(time.Second)
 	id := uuid.New()
 
-	_, err := s.db.Exec(ctx, `
+	_, err = s.db.Exec(ctx, `
 		INSERT INTO evm_collector_wallets
-			(uuid, merchant_id, blockchain, chain_id, contract_address, owner_address, factory_address, is_active, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, true, $8, $8)
+			(uuid, merchant_id, blockchain, chain_id, contract_address, owner_address, factory_address, is_active, created_at, updated_at,
+			 deployment_tx_hash, verification_status, verification_error, verified_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, true, $8, $8, NULLIF($9, ''), $10, NULL, $8)
 		ON CONFLICT (merchant_id, blockchain)
 		DO UPDATE SET
-			contract_address = EXCLUDED.contract_address,
-			owner_address    = EXCLUDED.owner_address,
-			factory_address  = EXCLUDED.factory_address,
-			chain_id         = EXCLUDED.chain_id,
-			is_active        = true,
-			updated_at       = EXCLUDED.updated_at
-	`, id, merchantID, blockchain, chainID, contractAddress, ownerAddress, factoryAddress, now)
+			contract_address    = EXCLUDED.contract_address,
+			owner_address       = EXCLUDED.owner_address,
+			factory_address     = EXCLUDED.factory_address,
+			chain_id            = EXCLUDED.chain_id,
+			is_active           = true,
+			updated_at          = EXCLUDED.updated_at,
+			deployment_tx_hash  = EXCLUDED.deployment_tx_hash,
+			verification_status = EXCLUDED.verification_status,
+			verification_error  = NULL,
+			verified_at         = EXCLUDED.verified_at
+	`, id, merchantID, blockchain, chainID, contractAddress, ownerAddress, factoryAddress, now,
+		deploymentTxHash, string(VerificationVerified))
 
 	if err != nil {
 		return nil, errors.Wrap(err, "unable to upsert evm collector")
@@ -123,8 +148,7 @@ func (s *Service) RegisterCollector(
 // GetByUUID retrieves a collector by its UUID.
 func (s *Service) GetByUUID(ctx context.Context, id uuid.UUID) (*Collector, error) {
 	return s.scanCollector(s.db.QueryRow(ctx, `
-		SELECT id, uuid, merchant_id, blockchain, chain_id, contract_address, owner_address,
-		       factory_address, is_active, created_at, updated_at
+		SELECT `+collectorColumns+`
 		FROM evm_collector_wallets
 		WHERE uuid = $1 AND is_active = true
 	`, id))
@@ -133,8 +157,7 @@ func (s *Service) GetByUUID(ctx context.Context, id uuid.UUID) (*Collector, erro
 // GetByMerchantAndBlockchain retrieves a collector for a specific merchant and chain.
 func (s *Service) GetByMerchantAndBlockchain(ctx context.Context, merchantID int64, blockchain string) (*Collector, error) {
 	return s.scanCollector(s.db.QueryRow(ctx, `
-		SELECT id, uuid, merchant_id, blockchain, chain_id, contract_address, owner_address,
-		       factory_address, is_active, created_at, updated_at
+		SELECT `+collectorColumns+`
 		FROM evm_collector_wallets
 		WHERE merchant_id = $1 AND blockchain = $2 AND is_active = true
 	`, merchantID, strings.ToUpper(blockchain)))
@@ -143,8 +166,7 @@ func (s *Service) GetByMerchantAndBlockchain(ctx context.Context, merchantID int
 // GetByContractAddress retrieves a collector by its contract address.
 func (s *Service) GetByContractAddress(ctx context.Context, contractAddress string) (*Collector, error) {
 	return s.scanCollector(s.db.QueryRow(ctx, `
-		SELECT id, uuid, merchant_id, blockchain, chain_id, contract_address, owner_address,
-		       factory_address, is_active, created_at, updated_at
+		SELECT `+collectorColumns+`
 		FROM evm_collector_wallets
 		WHERE contract_address = $1 AND is_active = true
 	`, strings.ToLower(contractAddress)))
@@ -153,8 +175,7 @@ func (s *Service) GetByContractAddress(ctx context.Context, contractAddress stri
 // ListByMerchantID returns all active collectors for a merchant.
 func (s *Service) ListByMerchantID(ctx context.Context, merchantID int64) ([]*Collector, error) {
 	rows, err := s.db.Query(ctx, `
-		SELECT id, uuid, merchant_id, blockchain, chain_id, contract_address, owner_address,
-		       factory_address, is_active, created_at, updated_at
+		SELECT `+collectorColumns+`
 		FROM evm_collector_wallets
 		WHERE merchant_id = $1 AND is_active = true
 		ORDER BY blockchain
@@ -166,17 +187,13 @@ func (s *Service) ListByMerchantID(ctx context.Context, merchantID int64) ([]*Co
 
 	var collectors []*Collector
 	for rows.Next() {
-		c := &Collector{}
-		if err := rows.Scan(
-			&c.ID, &c.UUID, &c.MerchantID, &c.Blockchain, &c.ChainID,
-			&c.ContractAddress, &c.OwnerAddress, &c.FactoryAddress,
-			&c.IsActive, &c.CreatedAt, &c.UpdatedAt,
-		); err != nil {
-			return nil, errors.Wrap(err, "unable to scan evm collector")
+		c, err := s.scanCollector(rows)
+		if err != nil {
+			return nil, err
 		}
 		collectors = append(collectors, c)
 	}
-	return collectors, nil
+	return collectors, rows.Err()
 }
 
 // Delete soft-deletes a collector (sets is_active=false).
@@ -221,6 +238,11 @@ func (s *Service) GetMerchantEmail(ctx context.Context, merchantID int64) (strin
 // Helpers
 // ────────────────────────────────────────────────────────────────────────────
 
+const collectorColumns = `id, uuid, merchant_id, blockchain, chain_id, contract_address, owner_address,
+		       factory_address, is_active, created_at, updated_at,
+		       COALESCE(deployment_tx_hash, ''), verification_status, COALESCE(verification_error, ''), verified_at,
+		       withdrawals_cursor`
+
 type scanner interface {
 	Scan(dest ...interface{}) error
 }
@@ -231,6 +253,8 @@ func (s *Service) scanCollector(row scanner) (*Collector, error) {
 		&c.ID, &c.UUID, &c.MerchantID, &c.Blockchain, &c.ChainID,
 		&c.ContractAddress, &c.OwnerAddress, &c.FactoryAddress,
 		&c.IsActive, &c.CreatedAt, &c.UpdatedAt,
+		&c.DeploymentTxHash, &c.VerificationStatus, &c.VerificationError, &c.VerifiedAt,
+		&c.WithdrawalsCursor,
 	)
 	if err != nil {
 		if strings.Contains(err.Error(), "no rows") {
@@ -0,0 +1,322 @@
+package evmcollector
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cryptolink/cryptolink/internal/util"
+	"github.com/pkg/errors"
+)
+
+// VerificationStatus on-chain ownership verification status of a collector.
+type VerificationStatus string
+
+const (
+	// VerificationPending collector was registered before verification was
+	// introduced and wasn't checked by the re-verify job yet.
+	VerificationPending  VerificationStatus = "pending"
+	VerificationVerified VerificationStatus = "verified"
+	VerificationFailed   VerificationStatus = "failed"
+)
+
+var (
+	// ErrVerificationFailed the chain proves that the collector is not what was declared.
+	ErrVerificationFailed = errors.New("collector verification failed")
+
+	// ErrVerificationUnavailable the chain couldn't be queried, verification should be retried.
+	ErrVerificationUnavailable = errors.New("collector verification is unavailable")
+)
+
+const (
+	// EIP-1167 minimal proxy runtime code: prefix + implementation + suffix.
+	eip1167Prefix = "363d3d373d3d3d363d73"
+	eip1167Suffix = "5af43d82803e903d91602b57fd5bf3"
+
+	// keccak256("CloneCreated(address,address)")
+	cloneCreatedTopic = "0x3d2b821286d97aa9d35330d87a82f7b548b9737b10e2d1ba59994edfd50e9d2b"
+
+	signatureOwner          = "owner()"
+	signatureImplementation = "implementation()"
+)
+
+// evmSelectors 4-byte selectors of view functions used for verification.
+var evmSelectors = map[string]string{
+	signatureOwner:          "0x8da5cb5b",
+	signatureImplementation: "0x5c60da1b",
+}
+
+// chainReader reads contract state required for collector verification.
+// Addresses are passed in chain-native format; returned addresses are
+// 40-char lowercase hex without 0x prefix.
+type chainReader interface {
+	// code returns lowercase hex runtime bytecode at address without 0x prefix.
+	code(ctx context.Context, address string) (string, error)
+
+	// callAddress calls a no-argument view function that returns an address.
+	callAddress(ctx context.Context, contract, signature string) (string, error)
+
+	// clonesCreated returns clones from factory's CloneCreated(owner, clone) events
+	// in chronological order. When txHash is provided, only logs of that transaction
+	// are checked; otherwise the search starts at position from (see cursor).
+	clonesCreated(ctx context.Context, factory, ownerHex, txHash string, from int64) ([]createdClone, error)
+
+	// cursor returns current chain position usable as clonesCreated's from:
+	// block number for EVM chains, block timestamp in ms for TRON.
+	cursor(ctx context.Context) (int64, error)
+}
+
+// createdClone clone found in factory's CloneCreated event.
+type createdClone struct {
+	Hex    string
+	TxHash string
+}
+
+// VerifyCollectorParams collector declared by the merchant.
+type VerifyCollectorParams struct {
+	Blockchain      string
+	ContractAddress string
+	OwnerAddress    string
+
+	// DeploymentTxHash is the factory deploy() transaction. Optional, but
+	// without it CloneCreated event is looked up through eth_getLogs which
+	// some RPC providers limit.
+	DeploymentTxHash string
+}
+
+// VerifyCollector checks on-chain that the contract is an EIP-1167 clone of the
+// configured factory's implementation, that its owner() equals the declared owner
+// and that the factory emitted a matching CloneCreated event.
+// Returns trusted factory address the collector was created by.
+func (s *Service) VerifyCollector(ctx context.Context, p VerifyCollectorParams) (string, error) {
+	chain := strings.ToUpper(p.Blockchain)
+
+	factory, err := s.trustedFactoryAddress(ctx, chain)
+	if err != nil {
+		return "", err
+	}
+
+	reader := s.chainReader(chain)
+	if reader == nil {
+		return "", errors.Wrapf(ErrVerificationUnavailable, "no rpc endpoint for %s", chain)
+	}
+
+	if err := verifyClone(ctx, reader, chain, factory, p); err != nil {
+		return "", err
+	}
+
+	return factory, nil
+}
+
+func verifyClone(ctx context.Context, r chainReader, chain, factory string, p VerifyCollectorParams) error {
+	contractHex, err := addressToHex(chain, p.ContractAddress)
+	if err != nil {
+		return errors.Wrap(ErrVerificationFailed, "invalid contract address")
+	}
+
+	ownerHex, err := addressToHex(chain, p.OwnerAddress)
+	if err != nil {
+		return errors.Wrap(ErrVerificationFailed, "invalid owner address")
+	}
+
+	implementation, err := r.callAddress(ctx, factory, signatureImplementation)
+	if err != nil {
+		return errors.Wrapf(ErrVerificationUnavailable, "unable to get factory implementation: %s", err)
+	}
+
+	code, err := r.code(ctx, p.ContractAddress)
+	switch {
+	case err != nil:
+		return errors.Wrapf(ErrVerificationUnavailable, "unable to get contract code: %s", err)
+	case code == "":
+		return errors.Wrap(ErrVerificationFailed, "no contract is deployed at the address")
+	case code != eip1167Prefix+implementation+eip1167Suffix:
+		return errors.Wrap(ErrVerificationFailed, "contract is not a clone of the factory implementation")
+	}
+
+	owner, err := r.callAddress(ctx, p.ContractAddress, signatureOwner)
+	switch {
+	case err != nil:
+		return errors.Wrapf(ErrVerificationUnavailable, "unable to get contract owner: %s", err)
+	case owner != ownerHex:
+		return errors.Wrap(ErrVerificationFailed, "contract owner doesn't match declared owner")
+	}
+
+	clones, err := r.clonesCreated(ctx, factory, ownerHex, p.DeploymentTxHash, 0)
+	if err != nil {
+		return errors.Wrapf(ErrVerificationUnavailable, "unable to get CloneCreated event: %s", err)
+	}
+
+	for _, c := range clones {
+		if c.Hex == contractHex {
+			return nil
+		}
+	}
+
+	return errors.Wrap(ErrVerificationFailed, "factory CloneCreated event for the contract is not found")
+}
+
+// trustedFactoryAddress returns factory address from app config or admin-managed
+// collector_factories. Client-provided factory address is never trusted.
+func (s *Service) trustedFactoryAddress(ctx context.Context, chain string) (string, error) {
+	if cfg, ok := s.config.Chains[chain]; ok && cfg.FactoryAddress != "" {
+		return cfg.FactoryAddress, nil
+	}
+
+	f, err := s.GetFactoryByBlockchain(ctx, chain)
+	switch {
+	case errors.Is(err, ErrFactoryNotFound):
+		return "", errors.Wrapf(ErrVerificationUnavailable, "no collector factory is configured for %s", chain)
+	case err != nil:
+		return "", err
+	}
+
+	return f.FactoryAddress, nil
+}
+
+func (s *Service) chainReader(chain string) chainReader {
+	if chain == "TRON" {
+		return tronReader{}
+	}
+
+	rpcURL := ""
+	if cfg, ok := s.config.Chains[chain]; ok && cfg.RPCEndpoint != "" {
+		rpcURL = cfg.RPCEndpoint
+	}
+	if rpcURL == "" {
+		rpcURL = publicRPCFallbacks[chain]
+	}
+	if rpcURL == "" {
+		return nil
+	}
+
+	return evmReader{rpcURL: rpcURL}
+}
+
+// ReverifyCollectors re-checks every active collector on-chain. Collectors that
+// definitively fail verification are deactivated so no new invoices are routed
+// to them; RPC errors leave the collector untouched until the next run.
+func (s *Service) ReverifyCollectors(ctx context.Context) error {
+	rows, err := s.db.Query(ctx, `
+		SELECT `+collectorColumns+`
+		FROM evm_collector_wallets
+		WHERE is_active = true
+		ORDER BY verified_at NULLS FIRST, id
+	`)
+	if err != nil {
+		return errors.Wrap(err, "unable to list evm collectors")
+	}
+
+	var collectors []*Collector
+	for rows.Next() {
+		c, err := s.scanCollector(rows)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		collectors = append(collectors, c)
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return errors.Wrap(err, "unable to list evm collectors")
+	}
+
+	var verified, failed, unavailable int
+
+	for _, c := range collectors {
+		_, err := s.VerifyCollector(ctx, VerifyCollectorParams{
+			Blockchain:       c.Blockchain,
+			ContractAddress:  c.ContractAddress,
+			OwnerAddress:     c.OwnerAddress,
+			DeploymentTxHash: c.DeploymentTxHash,
+		})
+
+		switch {
+		case err == nil:
+			verified++
+			if errMark := s.markVerification(ctx, c.ID, VerificationVerified, "", true); errMark != nil {
+				return errMark
+			}
+		case errors.Is(err, ErrVerificationFailed):
+			failed++
+			s.logger.Error().Err(err).
+				Int64("merchant_id", c.MerchantID).
+				Str("blockchain", c.Blockchain).
+				Str("contract_address", c.ContractAddress).
+				Msg("evm collector failed on-chain verification, deactivating")
+
+			if errMark := s.markVerification(ctx, c.ID, VerificationFailed, err.Error(), false); errMark != nil {
+				return errMark
+			}
+		default:
+			unavailable++
+			s.logger.Warn().Err(err).
+				Int64("collector_id", c.ID).
+				Str("blockchain", c.Blockchain).
+				Msg("unable to verify evm collector")
+		}
+	}
+
+	s.logger.Info().
+		Int("verified", verified).
+		Int("failed", failed).
+		Int("unavailable", unavailable).
+		Msg("evm collectors re-verification completed")
+
+	return nil
+}
+
+func (s *Service) markVerification(ctx context.Context, id int64, status VerificationStatus, reason string, isActive bool) error {
+	now := time.Now().UTC().Truncate(time.Second)
+
+	_, err := s.db.Exec(ctx, `
+		UPDATE evm_collector_wallets
+		SET verification_status = $2, verification_error = NULLIF($3, ''),
+		    verified_at = $4, is_active = $5, updated_at = $4
+		WHERE id = $1
+	`, id, string(status), reason, now, isActive)
+
+	return errors.Wrap(err, "unable to update evm collector verification")
+}
+
+// addressToHex normalizes chain-native address to 40-char lowercase hex.
+func addressToHex(chain, address string) (string, error) {
+	var h string
+	if chain == "TRON" {
+		h = strings.TrimPrefix(tronBase58ToHex(address), "41")
+	} else {
+		h = strings.TrimPrefix(strings.ToLower(address), "0x")
+	}
+
+	if len(h) != 40 {
+		return "", errors.New("invalid address length")
+	}
+
+	if _, err := hex.DecodeString(h); err != nil {
+		return "", errors.New("invalid address")
+	}
+
+	return strings.ToLower(h), nil
+}
+
+// hexToAddress converts 40-char hex address to chain-native format.
+func hexToAddress(chain, h string) string {
+	if chain == "TRON" {
+		return util.TronHexToBase58("41" + h)
+	}
+
+	return "0x" + h
+}
+
+// wordToAddress extracts address from an ABI-encoded 32-byte word.
+func wordToAddress(word string) (string, error) {
+	word = strings.TrimPrefix(strings.ToLower(word), "0x")
+	if len(word) < 64 {
+		return "", fmt.Errorf("unexpected result %q", word)
+	}
+
+	return word[len(word)-40:], nil
+}
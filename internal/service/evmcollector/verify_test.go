@@ -0,0 +1,144 @@
+package evmcollector
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testFactory        = "0x1111111111111111111111111111111111111111"
+	testImplementation = "2222222222222222222222222222222222222222"
+	testContract       = "0x3333333333333333333333333333333333333333"
+	testOwner          = "0x4444444444444444444444444444444444444444"
+)
+
+type fakeReader struct {
+	bytecode  string
+	owner     string
+	created   bool
+	failEvent bool
+}
+
+func (r *fakeReader) code(_ context.Context, _ string) (string, error) {
+	return r.bytecode, nil
+}
+
+func (r *fakeReader) callAddress(_ context.Context, _, signature string) (string, error) {
+	if signature == signatureImplementation {
+		return testImplementation, nil
+	}
+
+	return r.owner, nil
+}
+
+func (r *fakeReader) clonesCreated(_ context.Context, _, _, _ string, _ int64) ([]createdClone, error) {
+	if r.failEvent {
+		return nil, errors.New("rpc timeout")
+	}
+
+	if !r.created {
+		return []createdClone{{Hex: strings.Repeat("7", 40)}}, nil
+	}
+
+	return []createdClone{{Hex: strings.TrimPrefix(testContract, "0x")}}, nil
+}
+
+func (r *fakeReader) cursor(_ context.Context) (int64, error) {
+	return 0, nil
+}
+
+func TestVerifyClone(t *testing.T) {
+	validCode := eip1167Prefix + testImplementation + eip1167Suffix
+	ownerHex := strings.TrimPrefix(testOwner, "0x")
+
+	for _, tt := range []struct {
+		name    string
+		reader  *fakeReader
+		owner   string
+		wantErr error
+	}{
+		{
+			name:   "valid clone",
+			reader: &fakeReader{bytecode: validCode, owner: ownerHex, created: true},
+			owner:  testOwner,
+		},
+		{
+			name:    "no contract",
+			reader:  &fakeReader{bytecode: "", owner: ownerHex, created: true},
+			owner:   testOwner,
+			wantErr: ErrVerificationFailed,
+		},
+		{
+			name:    "foreign implementation",
+			reader:  &fakeReader{bytecode: eip1167Prefix + strings.Repeat("5", 40) + eip1167Suffix, owner: ownerHex, created: true},
+			owner:   testOwner,
+			wantErr: ErrVerificationFailed,
+		},
+		{
+			name:    "owner mismatch",
+			reader:  &fakeReader{bytecode: validCode, owner: strings.Repeat("6", 40), created: true},
+			owner:   testOwner,
+			wantErr: ErrVerificationFailed,
+		},
+		{
+			name:    "not created by factory",
+			reader:  &fakeReader{bytecode: validCode, owner: ownerHex, created: false},
+			owner:   testOwner,
+			wantErr: ErrVerificationFailed,
+		},
+		{
+			name:    "rpc error",
+			reader:  &fakeReader{bytecode: validCode, owner: ownerHex, failEvent: true},
+			owner:   testOwner,
+			wantErr: ErrVerificationUnavailable,
+		},
+		{
+			name:    "invalid owner address",
+			reader:  &fakeReader{bytecode: validCode, owner: ownerHex, created: true},
+			owner:   "0x123",
+			wantErr: ErrVerificationFailed,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verifyClone(context.Background(), tt.reader, "ETH", testFactory, VerifyCollectorParams{
+				Blockchain:      "ETH",
+				ContractAddress: testContract,
+				OwnerAddress:    tt.owner,
+			})
+
+			if tt.wantErr == nil {
+				require.NoError(t, err)
+				return
+			}
+
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestCloneFromLog(t *testing.T) {
+	ownerHex := strings.TrimPrefix(testOwner, "0x")
+	cloneHex := strings.TrimPrefix(testContract, "0x")
+	factoryHex := strings.TrimPrefix(testFactory, "0x")
+
+	log := rpcLog{
+		Address: testFactory,
+		Topics:  []string{cloneCreatedTopic, "0x" + strings.Repeat("0", 24) + ownerHex},
+		Data:    "0x" + strings.Repeat("0", 24) + cloneHex,
+	}
+
+	clone, ok := cloneFromLog(log, factoryHex, ownerHex)
+	assert.True(t, ok)
+	assert.Equal(t, cloneHex, clone)
+
+	_, ok = cloneFromLog(log, strings.Repeat("9", 40), ownerHex)
+	assert.False(t, ok)
+
+	_, ok = cloneFromLog(log, factoryHex, strings.Repeat("9", 40))
+	assert.False(t, ok)
+}
@@ -0,0 +1,528 @@
+package evmcollector
+
+import (
+	"context"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cryptolink/cryptolink/internal/db/repository"
+	"github.com/cryptolink/cryptolink/internal/money"
+	"github.com/cryptolink/cryptolink/internal/webhook"
+	"github.com/jackc/pgx/v4"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	// keccak256("WithdrewNative(address,uint256)")
+	withdrewNativeTopic = "0xb61d08cd869b50160c9a861deb23c095afc1eb91637ed814db897be30bcc53d2"
+
+	// keccak256("WithdrewToken(address,address,uint256)")
+	withdrewTokenTopic = "0x56e93dc9a8ed3ca4fa5e0477c0ddff18d1c15bf42effb39e071a886827683432"
+)
+
+const (
+	// withdrawalsColdStartBlocks how far back the first EVM scan of a collector
+	// looks. Older withdrawals are not indexed.
+	withdrawalsColdStartBlocks int64 = 50_000
+
+	// withdrawalsMaxBlocksPerRun caps blocks scanned per collector per run;
+	// the indexer catches up over subsequent runs.
+	withdrawalsMaxBlocksPerRun int64 = 5_000
+
+	// withdrawalsLogsChunk block range of a single eth_getLogs call; public
+	// RPC endpoints reject wide ranges.
+	withdrawalsLogsChunk int64 = 1_000
+)
+
+// reconciliationTolerance on-chain balances are formatted with 6 decimal places.
+var reconciliationTolerance = decimal.New(1, -6)
+
+// Withdrawal owner's withdrawal from the collector contract indexed from
+// WithdrewNative / WithdrewToken events.
+type Withdrawal struct {
+	ID          int64
+	CollectorID int64
+	MerchantID  int64
+	Blockchain  string
+
+	// TokenContract is empty for native coin withdrawals.
+	TokenContract string
+	Ticker        string
+	Decimals      int
+
+	ToAddress   string
+	RawAmount   *big.Int
+	TxHash      string
+	LogIndex    int
+	BlockNumber int64
+	CreatedAt   time.Time
+}
+
+// Amount returns human-readable withdrawn amount.
+func (w *Withdrawal) Amount() string {
+	return decimal.NewFromBigInt(w.RawAmount, -int32(w.Decimals)).String()
+}
+
+// withdrawalEvent WithdrewNative / WithdrewToken event read from the chain.
+// Addresses are 40-char lowercase hex; TokenHex is empty for native coin.
+type withdrawalEvent struct {
+	TokenHex    string
+	ToHex       string
+	Amount      *big.Int
+	TxHash      string
+	LogIndex    int
+	BlockNumber int64
+}
+
+// withdrawalReader reads collector withdrawal events.
+type withdrawalReader interface {
+	// withdrawals returns collector's withdrawal events after cursor and the
+	// cursor to continue from: block number for EVM chains, block timestamp
+	// in ms for TRON. Zero cursor means the collector wasn't scanned yet.
+	withdrawals(ctx context.Context, collector string, cursor int64) ([]withdrawalEvent, int64, error)
+}
+
+// IndexWithdrawals indexes new withdrawals of every active collector into
+// the settlement table.
+func (s *Service) IndexWithdrawals(ctx context.Context) error {
+	rows, err := s.db.Query(ctx, `
+		SELECT `+collectorColumns+`
+		FROM evm_collector_wallets
+		WHERE is_active = true
+		ORDER BY id
+	`)
+	if err != nil {
+		return errors.Wrap(err, "unable to list evm collectors")
+	}
+
+	var collectors []*Collector
+	for rows.Next() {
+		c, err := s.scanCollector(rows)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		collectors = append(collectors, c)
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return errors.Wrap(err, "unable to list evm collectors")
+	}
+
+	var indexed int
+	for _, c := range collectors {
+		n, err := s.indexCollectorWithdrawals(ctx, c)
+		if err != nil {
+			s.logger.Warn().Err(err).
+				Int64("collector_id", c.ID).
+				Str("blockchain", c.Blockchain).
+				Msg("unable to index evm collector withdrawals")
+			continue
+		}
+		indexed += n
+	}
+
+	if indexed > 0 {
+		s.logger.Info().Int("indexed", indexed).Msg("evm collector withdrawals indexed")
+	}
+
+	return nil
+}
+
+func (s *Service) indexCollectorWithdrawals(ctx context.Context, c *Collector) (int, error) {
+	reader, ok := s.chainReader(c.Blockchain).(withdrawalReader)
+	if !ok {
+		return 0, nil
+	}
+
+	events, cursor, err := reader.withdrawals(ctx, c.ContractAddress, c.WithdrawalsCursor)
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	inserted := 0
+
+	err = s.db.BeginFunc(ctx, func(tx pgx.Tx) error {
+		for _, e := range events {
+			contract, ticker, decimals := assetInfo(c.Blockchain, e.TokenHex)
+
+			res, err := tx.Exec(ctx, `
+				INSERT INTO evm_collector_withdrawals
+					(collector_id, merchant_id, blockchain, token_contract, ticker, decimals,
+					 to_address, amount, tx_hash, log_index, block_number, created_at)
+				VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6, $7, $8::numeric, $9, $10, $11, $12)
+				ON CONFLICT (blockchain, tx_hash, log_index) DO NOTHING
+			`, c.ID, c.MerchantID, c.Blockchain, contract, ticker, decimals,
+				hexToAddress(c.Blockchain, e.ToHex), e.Amount.String(), e.TxHash, e.LogIndex, e.BlockNumber, now)
+			if err != nil {
+				return errors.Wrap(err, "unable to insert evm collector withdrawal")
+			}
+
+			inserted += int(res.RowsAffected())
+
+			// The first scan indexes past withdrawals, merchant isn't notified of them.
+			if res.RowsAffected() == 0 || c.WithdrawalsCursor == 0 {
+				continue
+			}
+
+			if err := enqueueWithdrawn(ctx, tx, c, e, contract, ticker, decimals); err != nil {
+				return err
+			}
+		}
+
+		_, err := tx.Exec(ctx, `
+			UPDATE evm_collector_wallets SET withdrawals_cursor = $2 WHERE id = $1
+		`, c.ID, cursor)
+
+		return errors.Wrap(err, "unable to update withdrawals cursor")
+	})
+
+	return inserted, err
+}
+
+// enqueueWithdrawn writes collector.withdrawn webhook of indexed withdrawal
+// to the outbox within the indexing transaction.
+func enqueueWithdrawn(ctx context.Context, tx pgx.Tx, c *Collector, e withdrawalEvent, contract, ticker string, decimals int) error {
+	data := webhook.CollectorWithdrawnData{
+		CollectorID:     c.UUID.String(),
+		Blockchain:      c.Blockchain,
+		ContractAddress: c.ContractAddress,
+		Ticker:          ticker,
+		ToAddress:       hexToAddress(c.Blockchain, e.ToHex),
+		Amount:          decimal.NewFromBigInt(e.Amount, -int32(decimals)).String(),
+		TxHash:          e.TxHash,
+		BlockNumber:     e.BlockNumber,
+	}
+
+	if contract != "" {
+		data.TokenContract = &contract
+	}
+
+	return webhook.EnqueueEvent(ctx, repository.New(tx), c.MerchantID, webhook.EventCollectorWithdrawn, false, data)
+}
+
+// ListWithdrawals returns collector's withdrawals, newest first. Cursor is the
+// id of the last withdrawal of the previous page.
+func (s *Service) ListWithdrawals(ctx context.Context, collectorID int64, limit int, cursor string) ([]*Withdrawal, string, error) {
+	var before int64
+	if cursor != "" {
+		v, err := strconv.ParseInt(cursor, 10, 64)
+		if err != nil {
+			return nil, "", errors.New("invalid cursor")
+		}
+		before = v
+	}
+
+	rows, err := s.db.Query(ctx, `
+		SELECT id, collector_id, merchant_id, blockchain, COALESCE(token_contract, ''), ticker, decimals,
+		       to_address, amount::text, tx_hash, log_index, block_number, created_at
+		FROM evm_collector_withdrawals
+		WHERE collector_id = $1 AND ($2 = 0 OR id < $2)
+		ORDER BY id DESC
+		LIMIT $3
+	`, collectorID, before, limit+1)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "unable to list evm collector withdrawals")
+	}
+	defer rows.Close()
+
+	var withdrawals []*Withdrawal
+	for rows.Next() {
+		w := &Withdrawal{}
+		var amount string
+
+		if err := rows.Scan(
+			&w.ID, &w.CollectorID, &w.MerchantID, &w.Blockchain, &w.TokenContract, &w.Ticker, &w.Decimals,
+			&w.ToAddress, &amount, &w.TxHash, &w.LogIndex, &w.BlockNumber, &w.CreatedAt,
+		); err != nil {
+			return nil, "", errors.Wrap(err, "unable to scan evm collector withdrawal")
+		}
+
+		w.RawAmount, _ = new(big.Int).SetString(amount, 10)
+		withdrawals = append(withdrawals, w)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, "", errors.Wrap(err, "unable to list evm collector withdrawals")
+	}
+
+	var next string
+	if len(withdrawals) > limit {
+		withdrawals = withdrawals[:limit]
+		next = strconv.FormatInt(withdrawals[limit-1].ID, 10)
+	}
+
+	return withdrawals, next, nil
+}
+
+// ────────────────────────────────────────────────────────────────────────────
+// Reconciliation
+// ────────────────────────────────────────────────────────────────────────────
+
+// ReconciliationStatus result of comparing on-chain balance with the ledger.
+type ReconciliationStatus string
+
+const (
+	ReconciliationOK ReconciliationStatus = "ok"
+
+	// ReconciliationSurplus contract holds more than received minus withdrawn,
+	// e.g. direct transfers that didn't belong to any invoice.
+	ReconciliationSurplus ReconciliationStatus = "surplus"
+
+	// ReconciliationDeficit contract holds less than expected: a withdrawal
+	// wasn't indexed or a payment was credited without funds.
+	ReconciliationDeficit ReconciliationStatus = "deficit"
+
+	// ReconciliationUnknown on-chain balance of the asset isn't tracked.
+	ReconciliationUnknown ReconciliationStatus = "unknown"
+)
+
+// AssetReconciliation cross-check of a single collector asset.
+type AssetReconciliation struct {
+	// TokenContract is empty for native coin.
+	TokenContract string
+	Ticker        string
+	Received      decimal.Decimal
+	Withdrawn     decimal.Decimal
+	Expected      decimal.Decimal
+	OnChain       *decimal.Decimal
+	Discrepancy   decimal.Decimal
+	Status        ReconciliationStatus
+}
+
+type currencyResolver interface {
+	GetCurrencyByTicker(ticker string) (money.CryptoCurrency, error)
+}
+
+// assetTotal amount of a collector asset keyed by normalized token contract.
+type assetTotal struct {
+	Ticker string
+	Amount decimal.Decimal
+}
+
+// Reconcile cross-checks collector's on-chain balance against confirmed
+// incoming payments minus indexed withdrawals, per asset.
+func (s *Service) Reconcile(
+	ctx context.Context,
+	col *Collector,
+	onChain *OnChainBalance,
+	currencies currencyResolver,
+) ([]AssetReconciliation, error) {
+	received, err := s.receivedTotals(ctx, col, currencies)
+	if err != nil {
+		return nil, err
+	}
+
+	withdrawn, err := s.withdrawnTotals(ctx, col)
+	if err != nil {
+		return nil, err
+	}
+
+	return reconcile(col.Blockchain, received, withdrawn, onChain), nil
+}
+
+func (s *Service) receivedTotals(ctx context.Context, col *Collector, currencies currencyResolver) (map[string]assetTotal, error) {
+	// EVM addresses may be stored checksummed; TRON base58 is case-sensitive
+	recipientClause := "lower(recipient_address) = lower($3)"
+	if col.Blockchain == "TRON" {
+		recipientClause = "recipient_address = $3"
+	}
+
+	rows, err := s.db.Query(ctx, `
+		SELECT currency_type, currency, decimals, SUM(COALESCE(fact_amount, amount))::text
+		FROM transactions
+		WHERE merchant_id = $1 AND blockchain = $2 AND `+recipientClause+`
+		  AND type = 'incoming' AND is_test = false
+		  AND status IN ('completed', 'completedInv')
+		GROUP BY currency_type, currency, decimals
+	`, col.MerchantID, col.Blockchain, col.ContractAddress)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to sum collector payments")
+	}
+	defer rows.Close()
+
+	totals := make(map[string]assetTotal)
+	for rows.Next() {
+		var (
+			currencyType, ticker, sum string
+			decimals                  int32
+		)
+		if err := rows.Scan(&currencyType, &ticker, &decimals, &sum); err != nil {
+			return nil, errors.Wrap(err, "unable to scan collector payments")
+		}
+
+		amount, err := decimal.NewFromString(sum)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to parse %s sum", ticker)
+		}
+
+		key := ""
+		if currencyType != string(money.Coin) {
+			cur, err := currencies.GetCurrencyByTicker(ticker)
+			if err != nil {
+				continue
+			}
+			key = normalizeContract(col.Blockchain, cur.TokenContractAddress)
+		}
+
+		t := totals[key]
+		t.Ticker = ticker
+		t.Amount = t.Amount.Add(amount.Shift(-decimals))
+		totals[key] = t
+	}
+
+	return totals, rows.Err()
+}
+
+func (s *Service) withdrawnTotals(ctx context.Context, col *Collector) (map[string]assetTotal, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT COALESCE(token_contract, ''), ticker, decimals, SUM(amount)::text
+		FROM evm_collector_withdrawals
+		WHERE collector_id = $1
+		GROUP BY token_contract, ticker, decimals
+	`, col.ID)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to sum collector withdrawals")
+	}
+	defer rows.Close()
+
+	totals := make(map[string]assetTotal)
+	for rows.Next() {
+		var (
+			contract, ticker, sum string
+			decimals              int32
+		)
+		if err := rows.Scan(&contract, &ticker, &decimals, &sum); err != nil {
+			return nil, errors.Wrap(err, "unable to scan collector withdrawals")
+		}
+
+		amount, err := decimal.NewFromString(sum)
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to parse %s sum", ticker)
+		}
+
+		key := normalizeContract(col.Blockchain, contract)
+		t := totals[key]
+		t.Ticker = ticker
+		t.Amount = t.Amount.Add(amount.Shift(-decimals))
+		totals[key] = t
+	}
+
+	return totals, rows.Err()
+}
+
+func reconcile(chain string, received, withdrawn map[string]assetTotal, onChain *OnChainBalance) []AssetReconciliation {
+	balances := make(map[string]assetTotal)
+	if onChain != nil {
+		if v, err := decimal.NewFromString(onChain.NativeAmount); err == nil {
+			balances[""] = assetTotal{Ticker: onChain.NativeTicker, Amount: v}
+		}
+		for _, t := range onChain.Tokens {
+			if v, err := decimal.NewFromString(t.Amount); err == nil {
+				balances[normalizeContract(chain, t.ContractAddress)] = assetTotal{Ticker: t.Ticker, Amount: v}
+			}
+		}
+	}
+
+	keys := make(map[string]struct{})
+	for k := range received {
+		keys[k] = struct{}{}
+	}
+	for k := range withdrawn {
+		keys[k] = struct{}{}
+	}
+	for k, b := range balances {
+		if b.Amount.IsPositive() {
+			keys[k] = struct{}{}
+		}
+	}
+
+	results := make([]AssetReconciliation, 0, len(keys))
+	for key := range keys {
+		r := AssetReconciliation{
+			TokenContract: key,
+			Received:      received[key].Amount,
+			Withdrawn:     withdrawn[key].Amount,
+			Status:        ReconciliationUnknown,
+		}
+		r.Expected = r.Received.Sub(r.Withdrawn)
+
+		for _, src := range []map[string]assetTotal{balances, withdrawn, received} {
+			if t, ok := src[key]; ok && t.Ticker != "" {
+				r.Ticker = t.Ticker
+				break
+			}
+		}
+
+		if b, ok := balances[key]; ok {
+			onChainAmount := b.Amount
+			r.OnChain = &onChainAmount
+			r.Discrepancy = onChainAmount.Sub(r.Expected)
+
+			switch {
+			case r.Discrepancy.Abs().LessThanOrEqual(reconciliationTolerance):
+				r.Status = ReconciliationOK
+			case r.Discrepancy.IsPositive():
+				r.Status = ReconciliationSurplus
+			default:
+				r.Status = ReconciliationDeficit
+			}
+		}
+
+		results = append(results, r)
+	}
+
+	// native coin first, then tokens by ticker
+	sort.Slice(results, func(i, j int) bool {
+		if (results[i].TokenContract == "") != (results[j].TokenContract == "") {
+			return results[i].TokenContract == ""
+		}
+		return results[i].Ticker < results[j].Ticker
+	})
+
+	return results
+}
+
+// assetInfo resolves chain-native token contract address, ticker and decimals
+// of a withdrawn asset. Unknown tokens get empty ticker and zero decimals.
+func assetInfo(chain, tokenHex string) (string, string, int) {
+	if tokenHex == "" {
+		if chain == "TRON" {
+			return "", "TRX", 6
+		}
+		return "", chainNativeTickers[chain], 18
+	}
+
+	if chain == "TRON" {
+		for _, t := range tronKnownTokensList {
+			if t.Hex == "41"+tokenHex {
+				return t.Base58, t.Ticker, t.Decimals
+			}
+		}
+		return hexToAddress(chain, tokenHex), "", 0
+	}
+
+	for _, t := range knownERC20Tokens[chain] {
+		if strings.EqualFold(strings.TrimPrefix(t.Address, "0x"), tokenHex) {
+			return "0x" + tokenHex, t.Ticker, t.Decimals
+		}
+	}
+
+	return "0x" + tokenHex, "", 0
+}
+
+// normalizeContract makes contract address comparable: EVM addresses are
+// case-insensitive, TRON base58 is not.
+func normalizeContract(chain, address string) string {
+	if chain == "TRON" {
+		return address
+	}
+
+	return strings.ToLower(address)
+}
@@ -0,0 +1,106 @@
+package evmcollector
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithdrawalFromLog(t *testing.T) {
+	const (
+		ownerHex = "4444444444444444444444444444444444444444"
+		usdtHex  = "dac17f958d2ee523a2206206994597c13d831ec7"
+	)
+
+	word := func(h string) string { return "0x" + strings.Repeat("0", 64-len(h)) + h }
+
+	t.Run("native", func(t *testing.T) {
+		e, ok := withdrawalFromLog(rpcLog{
+			Topics:          []string{withdrewNativeTopic, word(ownerHex)},
+			Data:            word("de0b6b3a7640000"), // 1e18
+			TransactionHash: "0xabc",
+			LogIndex:        "0x2",
+			BlockNumber:     "0x10",
+		})
+
+		require.True(t, ok)
+		assert.Equal(t, "", e.TokenHex)
+		assert.Equal(t, ownerHex, e.ToHex)
+		assert.Equal(t, "1000000000000000000", e.Amount.String())
+		assert.Equal(t, 2, e.LogIndex)
+		assert.Equal(t, int64(16), e.BlockNumber)
+	})
+
+	t.Run("token", func(t *testing.T) {
+		e, ok := withdrawalFromLog(rpcLog{
+			Topics: []string{withdrewTokenTopic, word(usdtHex), word(ownerHex)},
+			Data:   word("f4240"), // 1e6
+		})
+
+		require.True(t, ok)
+		assert.Equal(t, usdtHex, e.TokenHex)
+		assert.Equal(t, ownerHex, e.ToHex)
+		assert.Equal(t, "1000000", e.Amount.String())
+
+		contract, ticker, decimals := assetInfo("ETH", e.TokenHex)
+		assert.Equal(t, "0x"+usdtHex, contract)
+		assert.Equal(t, "USDT", ticker)
+		assert.Equal(t, 6, decimals)
+	})
+
+	t.Run("other event", func(t *testing.T) {
+		_, ok := withdrawalFromLog(rpcLog{
+			Topics: []string{cloneCreatedTopic, word(ownerHex)},
+			Data:   word("1"),
+		})
+
+		assert.False(t, ok)
+	})
+}
+
+func TestReconcile(t *testing.T) {
+	const usdt = "0xdAC17F958D2ee523a2206206994597C13D831ec7"
+
+	d := decimal.RequireFromString
+
+	received := map[string]assetTotal{
+		"":                    {Ticker: "ETH", Amount: d("2")},
+		strings.ToLower(usdt): {Ticker: "ETH_USDT", Amount: d("150")},
+	}
+	withdrawn := map[string]assetTotal{
+		"":                    {Ticker: "ETH", Amount: d("1.5")},
+		strings.ToLower(usdt): {Ticker: "USDT", Amount: d("100")},
+	}
+	onChain := &OnChainBalance{
+		NativeAmount: "0.50000000",
+		NativeTicker: "ETH",
+		Tokens:       []TokenBalance{{ContractAddress: usdt, Ticker: "USDT", Amount: "40.000000"}},
+	}
+
+	results := reconcile("ETH", received, withdrawn, onChain)
+	require.Len(t, results, 2)
+
+	native := results[0]
+	assert.Equal(t, "", native.TokenContract)
+	assert.Equal(t, "0.5", native.Expected.String())
+	assert.Equal(t, ReconciliationOK, native.Status)
+
+	token := results[1]
+	assert.Equal(t, "USDT", token.Ticker)
+	assert.Equal(t, "50", token.Expected.String())
+	assert.Equal(t, "-10", token.Discrepancy.String())
+	assert.Equal(t, ReconciliationDeficit, token.Status)
+
+	// unmatched direct transfer shows up as surplus
+	onChain.NativeAmount = "0.7"
+	results = reconcile("ETH", received, withdrawn, onChain)
+	assert.Equal(t, ReconciliationSurplus, results[0].Status)
+
+	// without on-chain data the status is unknown
+	results = reconcile("ETH", received, withdrawn, nil)
+	assert.Equal(t, ReconciliationUnknown, results[0].Status)
+	assert.Nil(t, results[0].OnChain)
+}
@@ -0,0 +1,99 @@
+package ledger
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/cryptolink/cryptolink/internal/db/repository"
+	"github.com/cryptolink/cryptolink/internal/money"
+	"github.com/pkg/errors"
+)
+
+// collectorWithdrawalsBatch max withdrawals posted per run.
+const collectorWithdrawalsBatch = 500
+
+// PostCollectorWithdrawals records indexed EVM collector withdrawals that have
+// no ledger journal yet. Withdrawals of assets unknown to the currency resolver
+// are skipped because they were never booked as received.
+func (s *Service) PostCollectorWithdrawals(ctx context.Context) error {
+	withdrawals, err := s.store.ListUnpostedCollectorWithdrawals(ctx, collectorWithdrawalsBatch)
+	if err != nil {
+		return errors.Wrap(err, "unable to list collector withdrawals")
+	}
+
+	for i := range withdrawals {
+		w := withdrawals[i]
+
+		ticker, isTest, ok := s.collectorAsset(w)
+		if !ok {
+			continue
+		}
+
+		bigInt, err := repository.NumericToBigInt(w.Amount)
+		if err != nil {
+			continue
+		}
+
+		amount, err := money.NewFromBigInt(money.Crypto, ticker, bigInt, int64(w.Decimals))
+		if err != nil {
+			continue
+		}
+
+		o := Origin{
+			MerchantID:   w.MerchantID,
+			IsTest:       isTest,
+			NonCustodial: true,
+			TxHash:       w.TxHash,
+			OccurredAt:   w.CreatedAt,
+		}
+
+		err = s.store.RunTransaction(ctx, func(ctx context.Context, q repository.Querier) error {
+			_, err := s.post(ctx, q, collectorWithdrawalJournal(o, w.ID, amount, w.TxHash), valueAtSpot)
+			return err
+		})
+
+		if err != nil {
+			s.logger.Error().Err(err).Int64("withdrawal_id", w.ID).Msg("unable to post collector withdrawal")
+		}
+	}
+
+	return nil
+}
+
+// collectorAsset maps withdrawn asset to the ticker incoming transactions are
+// booked in and tells whether it belongs to a test network.
+func (s *Service) collectorAsset(w repository.UnpostedCollectorWithdrawal) (string, bool, bool) {
+	if s.blockchain == nil {
+		return "", false, false
+	}
+
+	bc := money.Blockchain(w.Blockchain)
+
+	if !w.TokenContract.Valid || w.TokenContract.String == "" {
+		coin, err := s.blockchain.GetNativeCoin(bc)
+		if err != nil {
+			return "", false, false
+		}
+
+		chainID := strconv.Itoa(int(w.ChainID))
+		isTest := chainID != coin.NetworkID && chainID == coin.TestNetworkID
+
+		return coin.Ticker, isTest, true
+	}
+
+	for _, c := range s.blockchain.ListBlockchainCurrencies(bc) {
+		if c.Type != money.Token {
+			continue
+		}
+
+		switch {
+		case strings.EqualFold(c.TokenContractAddress, w.TokenContract.String):
+			return c.Ticker, false, true
+		case strings.EqualFold(c.TestTokenContractAddress, w.TokenContract.String):
+			return c.Ticker, true, true
+		}
+	}
+
+	return "", false, false
+}
@@ -0,0 +1,324 @@
+package ledger
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/cryptolink/cryptolink/internal/db/repository"
+	"github.com/pkg/errors"
+)
+
+// ExportFormat accounting software import profile.
+type ExportFormat string
+
+const (
+	// ExportXero Xero precoded bank statement CSV: one row per movement of
+	// crypto asset accounts, signed fiat amount.
+	ExportXero ExportFormat = "xero"
+
+	// ExportQuickBooks QuickBooks Online journal entries CSV valued in fiat.
+	ExportQuickBooks ExportFormat = "quickbooks"
+
+	// ExportJournal generic double-entry journal with crypto amounts and fiat values.
+	ExportJournal ExportFormat = "journal"
+)
+
+var ErrUnknownExportFormat = errors.New("unknown export format")
+
+func ParseExportFormat(raw string) (ExportFormat, error) {
+	switch f := ExportFormat(raw); f {
+	case ExportXero, ExportQuickBooks, ExportJournal:
+		return f, nil
+	default:
+		return "", errors.Wrapf(ErrUnknownExportFormat, "%q", raw)
+	}
+}
+
+const (
+	exportDateLayout    = "2006-01-02"
+	exportJournalsBatch = 500
+)
+
+// AccountNames names of ledger accounts in merchant's chart of accounts.
+type AccountNames map[Account]string
+
+// DefaultAccountNames names used for accounts merchant hasn't renamed.
+var DefaultAccountNames = AccountNames{
+	AccountCustody:          "Crypto Custody",
+	AccountNonCustodial:     "Crypto Wallets",
+	AccountPayouts:          "Crypto Payouts",
+	AccountCustomerDeposits: "Customer Deposits",
+	AccountOverpayments:     "Customer Overpayments",
+	AccountFeesPayable:      "Processing Fees Payable",
+	AccountTopups:           "Balance Top-ups",
+	AccountSales:            "Sales",
+	AccountRefunds:          "Sales Refunds",
+	AccountFees:             "Processing Fees",
+}
+
+// NewAccountNames merges merchant's custom names (see merchant.Settings.AccountingAccounts)
+// with the defaults. Unknown accounts are ignored.
+func NewAccountNames(custom map[string]string) AccountNames {
+	names := make(AccountNames, len(DefaultAccountNames))
+	for account, name := range DefaultAccountNames {
+		names[account] = name
+	}
+
+	for account, name := range custom {
+		if _, ok := names[Account(account)]; ok && name != "" {
+			names[Account(account)] = name
+		}
+	}
+
+	return names
+}
+
+func (n AccountNames) name(a Account) string {
+	if name, ok := n[a]; ok {
+		return name
+	}
+
+	return string(a)
+}
+
+// Export writes merchant's ledger journals posted within the range as CSV in
+// the given format. Xero and QuickBooks formats are valued in fiat, so
+// journals recorded without exchange rate are left out of them.
+func (s *Service) Export(ctx context.Context, w io.Writer, r Range, format ExportFormat, names AccountNames) error {
+	if !r.From.Before(r.To) {
+		return errors.New("invalid date range")
+	}
+
+	var write func(lines []*Entry) error
+
+	cw := csv.NewWriter(w)
+
+	switch format {
+	case ExportXero:
+		_ = cw.Write([]string{"Date", "Amount", "Payee", "Description", "Reference", "Account Code"})
+		write = func(lines []*Entry) error { return writeXeroRows(cw, lines, names) }
+	case ExportQuickBooks:
+		_ = cw.Write([]string{
+			"Journal No", "Journal Date", "Currency", "Memo", "Account Name", "Debits", "Credits", "Description", "Name",
+		})
+		write = func(lines []*Entry) error { return writeQuickBooksRows(cw, lines, names) }
+	case ExportJournal:
+		_ = cw.Write([]string{
+			"Date", "Journal", "Event", "Account", "Debit", "Credit", "Currency",
+			"Fiat Currency", "Fiat Value", "Reference", "Description",
+		})
+		write = func(lines []*Entry) error { return writeJournalRows(cw, lines, names) }
+	default:
+		return errors.Wrapf(ErrUnknownExportFormat, "%q", format)
+	}
+
+	if err := s.eachJournal(ctx, r, write); err != nil {
+		return err
+	}
+
+	cw.Flush()
+
+	return cw.Error()
+}
+
+// eachJournal iterates over journals posted within the range in posting order.
+func (s *Service) eachJournal(ctx context.Context, r Range, fn func(lines []*Entry) error) error {
+	var afterID int64
+
+	for {
+		rows, err := s.store.ListLedgerJournals(ctx, repository.ListLedgerEntriesParams{
+			MerchantID: r.MerchantID,
+			IsTest:     r.IsTest,
+			From:       r.From,
+			To:         r.To,
+			AfterID:    afterID,
+			Limit:      exportJournalsBatch,
+		})
+		if err != nil {
+			return errors.Wrap(err, "unable to list ledger journals")
+		}
+
+		journals := groupJournals(entriesFromRepo(rows))
+
+		for _, lines := range journals {
+			if err := fn(lines); err != nil {
+				return err
+			}
+		}
+
+		if len(journals) < exportJournalsBatch {
+			return nil
+		}
+
+		afterID = journals[len(journals)-1][0].ID
+	}
+}
+
+// groupJournals splits lines ordered by journal into journals.
+func groupJournals(entries []*Entry) [][]*Entry {
+	var journals [][]*Entry
+
+	for i, e := range entries {
+		if i == 0 || e.EventKey != entries[i-1].EventKey {
+			journals = append(journals, nil)
+		}
+
+		journals[len(journals)-1] = append(journals[len(journals)-1], e)
+	}
+
+	return journals
+}
+
+func writeXeroRows(cw *csv.Writer, lines []*Entry, names AccountNames) error {
+	if !valued(lines) {
+		return nil
+	}
+
+	for _, e := range lines {
+		if e.Account != AccountCustody && e.Account != AccountNonCustodial {
+			continue
+		}
+
+		amount := *e.FiatAmount
+		if e.Side == Credit {
+			amount = amount.Neg()
+		}
+
+		err := cw.Write([]string{
+			e.OccurredAt.UTC().Format(exportDateLayout),
+			amount.StringFixed(2),
+			string(e.EventType),
+			csvText(fmt.Sprintf("%s (%s %s)", e.Description, e.Amount.String(), e.Ticker)),
+			csvText(reference(e)),
+			csvText(names.name(counterAccount(lines, e))),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writeQuickBooksRows(cw *csv.Writer, lines []*Entry, names AccountNames) error {
+	if !valued(lines) {
+		return nil
+	}
+
+	first := lines[0]
+
+	memo := first.Description
+	if ref := reference(first); ref != "" {
+		memo += ", " + ref
+	}
+
+	for _, e := range lines {
+		debit, credit := "", ""
+		if e.Side == Debit {
+			debit = e.FiatAmount.StringFixed(2)
+		} else {
+			credit = e.FiatAmount.StringFixed(2)
+		}
+
+		err := cw.Write([]string{
+			fmt.Sprintf("CL-%d", first.ID),
+			e.OccurredAt.UTC().Format(exportDateLayout),
+			e.FiatCurrency,
+			csvText(memo),
+			csvText(names.name(e.Account)),
+			debit,
+			credit,
+			fmt.Sprintf("%s %s", e.Amount.String(), e.Ticker),
+			csvText(first.OrderID),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func writeJournalRows(cw *csv.Writer, lines []*Entry, names AccountNames) error {
+	for _, e := range lines {
+		debit, credit := "", ""
+		if e.Side == Debit {
+			debit = e.Amount.String()
+		} else {
+			credit = e.Amount.String()
+		}
+
+		var fiatValue string
+		if e.FiatAmount != nil {
+			fiatValue = e.FiatAmount.StringFixed(2)
+		}
+
+		err := cw.Write([]string{
+			e.OccurredAt.UTC().Format(exportDateLayout),
+			e.EventKey,
+			string(e.EventType),
+			csvText(names.name(e.Account)),
+			debit,
+			credit,
+			e.Ticker,
+			e.FiatCurrency,
+			fiatValue,
+			csvText(reference(e)),
+			csvText(e.Description),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func valued(lines []*Entry) bool {
+	for _, e := range lines {
+		if e.FiatAmount == nil {
+			return false
+		}
+	}
+
+	return len(lines) > 0
+}
+
+// counterAccount returns the first account on the opposite side of the journal
+// that is not a crypto asset account.
+func counterAccount(lines []*Entry, e *Entry) Account {
+	for _, l := range lines {
+		if l.Side != e.Side && l.Account != AccountCustody && l.Account != AccountNonCustodial {
+			return l.Account
+		}
+	}
+
+	return e.Account
+}
+
+// reference is merchant's order id and on-chain hash of the event.
+func reference(e *Entry) string {
+	parts := make([]string, 0, 2)
+
+	if e.OrderID != "" {
+		parts = append(parts, e.OrderID)
+	}
+
+	if e.TxHash != "" {
+		parts = append(parts, e.TxHash)
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// csvText escapes merchant-provided text so spreadsheets don't treat it as a formula.
+func csvText(s string) string {
+	if s != "" && strings.ContainsAny(s[:1], "=+-@\t\r") {
+		return "'" + s
+	}
+
+	return s
+}
@@ -0,0 +1,133 @@
+package ledger
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func exportReceipt() []*Entry {
+	at := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	fiat := func(v string) *decimal.Decimal {
+		d := decimal.RequireFromString(v)
+		return &d
+	}
+
+	entry := func(id int64, account Account, side Side, amount, value string) *Entry {
+		return &Entry{
+			ID:           id,
+			EventKey:     "receipt:tx:10",
+			EventType:    EventReceipt,
+			Account:      account,
+			Side:         side,
+			Ticker:       "ETH_USDT",
+			Amount:       decimal.RequireFromString(amount),
+			FiatCurrency: "EUR",
+			FiatAmount:   fiat(value),
+			OrderID:      "=ORDER-1",
+			TxHash:       "0xabc",
+			Description:  "payment received, tx #10",
+			OccurredAt:   at,
+		}
+	}
+
+	return []*Entry{
+		entry(1, AccountCustody, Debit, "100", "92.00"),
+		entry(2, AccountSales, Credit, "100", "92.00"),
+		entry(3, AccountFees, Debit, "1", "0.92"),
+		entry(4, AccountCustody, Credit, "1", "0.92"),
+	}
+}
+
+func readCSV(t *testing.T, buf *bytes.Buffer) [][]string {
+	records, err := csv.NewReader(buf).ReadAll()
+	require.NoError(t, err)
+
+	return records
+}
+
+func TestExportRows(t *testing.T) {
+	names := NewAccountNames(map[string]string{
+		string(AccountSales): "4000 Sales",
+		"unknown":            "ignored",
+	})
+
+	t.Run("xero", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		cw := csv.NewWriter(buf)
+
+		require.NoError(t, writeXeroRows(cw, exportReceipt(), names))
+		cw.Flush()
+
+		assert.Equal(t, [][]string{
+			{"2026-06-01", "92.00", "receipt", "payment received, tx #10 (100 ETH_USDT)", "'=ORDER-1 0xabc", "4000 Sales"},
+			{"2026-06-01", "-0.92", "receipt", "payment received, tx #10 (1 ETH_USDT)", "'=ORDER-1 0xabc", "Processing Fees"},
+		}, readCSV(t, buf))
+	})
+
+	t.Run("quickbooks", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		cw := csv.NewWriter(buf)
+
+		require.NoError(t, writeQuickBooksRows(cw, exportReceipt(), names))
+		cw.Flush()
+
+		records := readCSV(t, buf)
+		require.Len(t, records, 4)
+
+		assert.Equal(t, []string{
+			"CL-1", "2026-06-01", "EUR", "payment received, tx #10, =ORDER-1 0xabc",
+			"Crypto Custody", "92.00", "", "100 ETH_USDT", "'=ORDER-1",
+		}, records[0])
+		assert.Equal(t, "4000 Sales", records[1][4])
+		assert.Equal(t, "92.00", records[1][6])
+	})
+
+	t.Run("journal", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		cw := csv.NewWriter(buf)
+
+		require.NoError(t, writeJournalRows(cw, exportReceipt(), names))
+		cw.Flush()
+
+		records := readCSV(t, buf)
+		require.Len(t, records, 4)
+
+		assert.Equal(t, []string{
+			"2026-06-01", "receipt:tx:10", "receipt", "4000 Sales", "", "100", "ETH_USDT",
+			"EUR", "92.00", "'=ORDER-1 0xabc", "payment received, tx #10",
+		}, records[1])
+	})
+
+	t.Run("unvalued journals are skipped in fiat formats", func(t *testing.T) {
+		lines := exportReceipt()
+		for _, e := range lines {
+			e.FiatAmount = nil
+		}
+
+		buf := &bytes.Buffer{}
+		cw := csv.NewWriter(buf)
+
+		require.NoError(t, writeXeroRows(cw, lines, names))
+		require.NoError(t, writeQuickBooksRows(cw, lines, names))
+		cw.Flush()
+
+		assert.Empty(t, buf.String())
+	})
+}
+
+func TestGroupJournals(t *testing.T) {
+	entries := []*Entry{
+		{ID: 1, EventKey: "a"}, {ID: 2, EventKey: "a"}, {ID: 3, EventKey: "b"}, {ID: 4, EventKey: "b"},
+	}
+
+	journals := groupJournals(entries)
+	require.Len(t, journals, 2)
+	assert.Len(t, journals[0], 2)
+	assert.Equal(t, "b", journals[1][0].EventKey)
+}
@@ -0,0 +1,381 @@
+package ledger
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/cryptolink/cryptolink/internal/db/repository"
+	"github.com/cryptolink/cryptolink/internal/money"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+// Origin identifies merchant's transaction a ledger event belongs to.
+type Origin struct {
+	MerchantID    int64
+	TransactionID int64
+	PaymentID     int64
+	IsTest        bool
+
+	// NonCustodial is set when funds were received to merchant-controlled
+	// address (collector contract, xpub wallet) instead of a hot wallet.
+	NonCustodial bool
+
+	// TxHash on-chain hash of the transfer behind the event, if any.
+	TxHash string
+
+	OccurredAt time.Time
+}
+
+func (o Origin) asset() Account {
+	if o.NonCustodial {
+		return AccountNonCustodial
+	}
+
+	return AccountCustody
+}
+
+// feeAccount custodial fees are withheld from the hot wallet, non-custodial
+// fees are owed to the processing.
+func (o Origin) feeAccount() Account {
+	if o.NonCustodial {
+		return AccountFeesPayable
+	}
+
+	return AccountCustody
+}
+
+// Incoming confirmed incoming transaction of merchant's payment.
+type Incoming struct {
+	Origin
+	Expected   money.Money
+	Fact       money.Money
+	ServiceFee money.Money
+
+	// Underpaid funds are held as customer deposits until merchant resolves the payment.
+	Underpaid bool
+}
+
+type line struct {
+	Account Account
+	Side    Side
+	Amount  *big.Int
+
+	// fiat preset value, used by reversals only.
+	fiat *decimal.Decimal
+}
+
+// journal balanced set of lines recorded for a single event in a single asset.
+type journal struct {
+	Key         string
+	Type        EventType
+	Origin      Origin
+	Ticker      string
+	Decimals    int64
+	ReversesKey string
+	Description string
+	Lines       []line
+
+	// FiatCurrency and preset valuation are set by reversals only,
+	// other journals are valued in merchant's current fiat currency.
+	FiatCurrency string
+	preset       *valuation
+}
+
+func newJournal(eventType EventType, key string, o Origin, ticker string, decimals int64, description string) *journal {
+	return &journal{
+		Key:         key,
+		Type:        eventType,
+		Origin:      o,
+		Ticker:      ticker,
+		Decimals:    decimals,
+		Description: description,
+	}
+}
+
+// add appends a line; zero and negative amounts are skipped.
+func (j *journal) add(account Account, side Side, amount *big.Int) *journal {
+	if amount == nil || amount.Sign() <= 0 {
+		return j
+	}
+
+	j.Lines = append(j.Lines, line{Account: account, Side: side, Amount: new(big.Int).Set(amount)})
+
+	return j
+}
+
+func (j *journal) empty() bool {
+	return len(j.Lines) == 0
+}
+
+func (j *journal) total() *big.Int {
+	sum := new(big.Int)
+	for _, l := range j.Lines {
+		if l.Side == Debit {
+			sum.Add(sum, l.Amount)
+		}
+	}
+
+	return sum
+}
+
+func (j *journal) validate() error {
+	if j.Key == "" || j.Origin.MerchantID == 0 {
+		return errors.New("journal key and merchant are required")
+	}
+
+	if len(j.Lines) < 2 {
+		return errors.Errorf("journal %s should have at least two lines", j.Key)
+	}
+
+	debits, credits := new(big.Int), new(big.Int)
+	for _, l := range j.Lines {
+		switch l.Side {
+		case Debit:
+			debits.Add(debits, l.Amount)
+		case Credit:
+			credits.Add(credits, l.Amount)
+		default:
+			return errors.Errorf("journal %s has invalid side %q", j.Key, l.Side)
+		}
+	}
+
+	if debits.Cmp(credits) != 0 {
+		return errors.Errorf("journal %s is not balanced: debits %s, credits %s", j.Key, debits, credits)
+	}
+
+	return nil
+}
+
+// fiatValues values each line at the rate. Rounding difference is put on the
+// last credit line so that journal stays balanced in fiat as well.
+func (j *journal) fiatValues(rate decimal.Decimal) []decimal.Decimal {
+	values := make([]decimal.Decimal, len(j.Lines))
+	debits, credits := decimal.Zero, decimal.Zero
+	lastCredit := -1
+
+	for i, l := range j.Lines {
+		if l.fiat != nil {
+			values[i] = *l.fiat
+		} else {
+			values[i] = decimal.NewFromBigInt(l.Amount, -int32(j.Decimals)).Mul(rate).Round(2)
+		}
+
+		if l.Side == Debit {
+			debits = debits.Add(values[i])
+		} else {
+			credits = credits.Add(values[i])
+			lastCredit = i
+		}
+	}
+
+	if diff := debits.Sub(credits); !diff.IsZero() && lastCredit >= 0 {
+		values[lastCredit] = values[lastCredit].Add(diff)
+	}
+
+	return values
+}
+
+func minInt(a, b *big.Int) *big.Int {
+	if a.Cmp(b) < 0 {
+		return new(big.Int).Set(a)
+	}
+
+	return new(big.Int).Set(b)
+}
+
+func sub(a, b *big.Int) *big.Int {
+	return new(big.Int).Sub(a, b)
+}
+
+func raw(m money.Money) *big.Int {
+	v, _ := m.BigInt()
+	return v
+}
+
+// incomingJournals splits confirmed incoming transaction into receipt and
+// overpayment journals. deposited is the amount already booked as customer
+// deposits for the transaction (partial fills).
+func incomingJournals(in Incoming, deposited *big.Int) []*journal {
+	var (
+		o        = in.Origin
+		ticker   = in.Fact.Ticker()
+		decimals = in.Fact.Decimals()
+		fact     = raw(in.Fact)
+		ref      = fmt.Sprintf("tx #%d", o.TransactionID)
+	)
+
+	if deposited.Sign() < 0 {
+		deposited = new(big.Int)
+	}
+
+	if in.Underpaid {
+		j := newJournal(EventUnderpayment, fmt.Sprintf("underpayment:tx:%d", o.TransactionID), o, ticker, decimals,
+			"underpaid payment held until resolved, "+ref).
+			add(o.asset(), Debit, sub(fact, deposited)).
+			add(AccountCustomerDeposits, Credit, sub(fact, deposited))
+
+		return []*journal{j}
+	}
+
+	gained := minInt(fact, raw(in.Expected))
+	excess := sub(fact, gained)
+
+	fromDeposits := minInt(deposited, gained)
+	restDeposits := minInt(sub(deposited, fromDeposits), excess)
+
+	receipt := newJournal(EventReceipt, fmt.Sprintf("receipt:tx:%d", o.TransactionID), o, ticker, decimals,
+		"payment received, "+ref).
+		add(AccountCustomerDeposits, Debit, fromDeposits).
+		add(o.asset(), Debit, sub(gained, fromDeposits)).
+		add(AccountSales, Credit, gained)
+
+	if fee := raw(in.ServiceFee); fee.Sign() > 0 {
+		receipt.
+			add(AccountFees, Debit, fee).
+			add(o.feeAccount(), Credit, fee)
+	}
+
+	journals := []*journal{receipt}
+
+	if excess.Sign() > 0 {
+		overpayment := newJournal(EventOverpayment, fmt.Sprintf("overpayment:tx:%d", o.TransactionID), o, ticker, decimals,
+			"amount paid above invoice price, "+ref).
+			add(AccountCustomerDeposits, Debit, restDeposits).
+			add(o.asset(), Debit, sub(excess, restDeposits)).
+			add(AccountOverpayments, Credit, excess)
+
+		journals = append(journals, overpayment)
+	}
+
+	return journals
+}
+
+func fillJournal(o Origin, fillID int64, amount money.Money) *journal {
+	return newJournal(EventFill, fillKey(fillID), o, amount.Ticker(), amount.Decimals(),
+		fmt.Sprintf("partial payment fill #%d, tx #%d", fillID, o.TransactionID)).
+		add(o.asset(), Debit, raw(amount)).
+		add(AccountCustomerDeposits, Credit, raw(amount))
+}
+
+func fillKey(fillID int64) string {
+	return fmt.Sprintf("fill:%d", fillID)
+}
+
+// resolvedJournal recognizes underpaid payment resolved by merchant as revenue.
+func resolvedJournal(o Origin, fact money.Money, deposited *big.Int) *journal {
+	amount := raw(fact)
+	if deposited.Sign() < 0 {
+		deposited = new(big.Int)
+	}
+
+	fromDeposits := minInt(deposited, amount)
+
+	return newJournal(EventReceipt, fmt.Sprintf("resolve:tx:%d", o.TransactionID), o, fact.Ticker(), fact.Decimals(),
+		fmt.Sprintf("underpaid payment resolved by merchant, tx #%d", o.TransactionID)).
+		add(AccountCustomerDeposits, Debit, fromDeposits).
+		add(o.asset(), Debit, sub(amount, fromDeposits)).
+		add(AccountSales, Credit, amount)
+}
+
+func withdrawalJournal(o Origin, amount, fee money.Money) *journal {
+	total := new(big.Int).Add(raw(amount), raw(fee))
+
+	return newJournal(EventWithdrawal, fmt.Sprintf("withdrawal:tx:%d", o.TransactionID), o, amount.Ticker(), amount.Decimals(),
+		fmt.Sprintf("withdrawal to merchant's wallet, tx #%d", o.TransactionID)).
+		add(AccountPayouts, Debit, raw(amount)).
+		add(AccountFees, Debit, raw(fee)).
+		add(o.asset(), Credit, total)
+}
+
+func topupJournal(o Origin, amount money.Money) *journal {
+	return newJournal(EventTopup, fmt.Sprintf("topup:tx:%d", o.TransactionID), o, amount.Ticker(), amount.Decimals(),
+		fmt.Sprintf("system top-up, tx #%d", o.TransactionID)).
+		add(AccountCustody, Debit, raw(amount)).
+		add(AccountTopups, Credit, raw(amount))
+}
+
+// refundJournal settles outstanding overpayment first, then unapplied deposits,
+// the rest is booked as contra-revenue.
+func refundJournal(o Origin, key string, amount money.Money, overpaid, deposited *big.Int, description string) *journal {
+	rest := raw(amount)
+
+	fromOverpaid := minInt(rest, nonNegative(overpaid))
+	rest = sub(rest, fromOverpaid)
+
+	fromDeposits := minInt(rest, nonNegative(deposited))
+	rest = sub(rest, fromDeposits)
+
+	return newJournal(EventRefund, key, o, amount.Ticker(), amount.Decimals(), description).
+		add(AccountOverpayments, Debit, fromOverpaid).
+		add(AccountCustomerDeposits, Debit, fromDeposits).
+		add(AccountRefunds, Debit, rest).
+		add(o.asset(), Credit, raw(amount))
+}
+
+func collectorWithdrawalJournal(o Origin, withdrawalID int64, amount money.Money, txHash string) *journal {
+	return newJournal(EventWithdrawal, fmt.Sprintf("collector_withdrawal:%d", withdrawalID), o, amount.Ticker(), amount.Decimals(),
+		"collector withdrawal "+txHash).
+		add(AccountPayouts, Debit, raw(amount)).
+		add(AccountNonCustodial, Credit, raw(amount))
+}
+
+func nonNegative(v *big.Int) *big.Int {
+	if v == nil || v.Sign() < 0 {
+		return new(big.Int)
+	}
+
+	return v
+}
+
+// reversalJournal mirrors original journal's lines keeping their fiat value.
+func reversalJournal(original []repository.LedgerEntry, key string, eventType EventType, at time.Time) *journal {
+	first := original[0]
+
+	o := Origin{
+		MerchantID:    first.MerchantID,
+		TransactionID: first.TransactionID.Int64,
+		PaymentID:     first.PaymentID.Int64,
+		IsTest:        first.IsTest,
+		TxHash:        first.TxHash.String,
+		OccurredAt:    at,
+	}
+
+	j := newJournal(eventType, key, o, first.Ticker, int64(first.Decimals), "reversal of "+first.EventKey)
+	j.ReversesKey = first.EventKey
+	j.FiatCurrency = first.FiatCurrency
+
+	if first.Rate.Valid {
+		if rate, err := decimal.NewFromString(first.Rate.String); err == nil {
+			j.preset = &valuation{
+				Rate:       rate,
+				Source:     first.RateSource.String,
+				SnapshotID: repository.NullableInt64ToPointer(first.RateSnapshotID),
+			}
+		}
+	}
+
+	for _, e := range original {
+		amount, err := repository.NumericToBigInt(e.Amount)
+		if err != nil {
+			continue
+		}
+
+		side := Credit
+		if Side(e.Side) == Credit {
+			side = Debit
+		}
+
+		l := line{Account: Account(e.Account), Side: side, Amount: amount}
+		if e.FiatAmount.Valid {
+			if fiat, err := decimal.NewFromString(e.FiatAmount.String); err == nil {
+				l.fiat = &fiat
+			}
+		}
+
+		j.Lines = append(j.Lines, l)
+	}
+
+	return j
+}
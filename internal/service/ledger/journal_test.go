@@ -0,0 +1,219 @@
+package ledger
+
+import (
+	"database/sql"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/cryptolink/cryptolink/internal/db/repository"
+	"github.com/cryptolink/cryptolink/internal/money"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func usdt(raw string) money.Money {
+	return money.MustCryptoFromRaw("ETH_USDT", raw, 6)
+}
+
+type flatLine struct {
+	Account Account
+	Side    Side
+	Amount  string
+}
+
+func flatten(j *journal) []flatLine {
+	lines := make([]flatLine, len(j.Lines))
+	for i, l := range j.Lines {
+		lines[i] = flatLine{Account: l.Account, Side: l.Side, Amount: l.Amount.String()}
+	}
+
+	return lines
+}
+
+func TestIncomingJournals(t *testing.T) {
+	o := Origin{MerchantID: 1, TransactionID: 10, PaymentID: 100}
+
+	for _, tt := range []struct {
+		name      string
+		in        Incoming
+		deposited string
+		expected  map[string][]flatLine
+	}{
+		{
+			name: "exact payment with fee",
+			in:   Incoming{Origin: o, Expected: usdt("100000000"), Fact: usdt("100000000"), ServiceFee: usdt("1000000")},
+			expected: map[string][]flatLine{
+				"receipt:tx:10": {
+					{AccountCustody, Debit, "100000000"},
+					{AccountSales, Credit, "100000000"},
+					{AccountFees, Debit, "1000000"},
+					{AccountCustody, Credit, "1000000"},
+				},
+			},
+		},
+		{
+			name: "overpayment",
+			in:   Incoming{Origin: o, Expected: usdt("100000000"), Fact: usdt("120000000")},
+			expected: map[string][]flatLine{
+				"receipt:tx:10": {
+					{AccountCustody, Debit, "100000000"},
+					{AccountSales, Credit, "100000000"},
+				},
+				"overpayment:tx:10": {
+					{AccountCustody, Debit, "20000000"},
+					{AccountOverpayments, Credit, "20000000"},
+				},
+			},
+		},
+		{
+			name:      "partial fills are applied from deposits",
+			in:        Incoming{Origin: o, Expected: usdt("100000000"), Fact: usdt("110000000")},
+			deposited: "105000000",
+			expected: map[string][]flatLine{
+				"receipt:tx:10": {
+					{AccountCustomerDeposits, Debit, "100000000"},
+					{AccountSales, Credit, "100000000"},
+				},
+				"overpayment:tx:10": {
+					{AccountCustomerDeposits, Debit, "5000000"},
+					{AccountCustody, Debit, "5000000"},
+					{AccountOverpayments, Credit, "10000000"},
+				},
+			},
+		},
+		{
+			name: "non-custodial fee is payable",
+			in: Incoming{
+				Origin:     Origin{MerchantID: 1, TransactionID: 10, NonCustodial: true},
+				Expected:   usdt("100000000"),
+				Fact:       usdt("100000000"),
+				ServiceFee: usdt("500000"),
+			},
+			expected: map[string][]flatLine{
+				"receipt:tx:10": {
+					{AccountNonCustodial, Debit, "100000000"},
+					{AccountSales, Credit, "100000000"},
+					{AccountFees, Debit, "500000"},
+					{AccountFeesPayable, Credit, "500000"},
+				},
+			},
+		},
+		{
+			name:      "underpaid is held as deposit",
+			in:        Incoming{Origin: o, Expected: usdt("100000000"), Fact: usdt("60000000"), Underpaid: true},
+			deposited: "40000000",
+			expected: map[string][]flatLine{
+				"underpayment:tx:10": {
+					{AccountCustody, Debit, "20000000"},
+					{AccountCustomerDeposits, Credit, "20000000"},
+				},
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			deposited := new(big.Int)
+			if tt.deposited != "" {
+				deposited.SetString(tt.deposited, 10)
+			}
+
+			journals := incomingJournals(tt.in, deposited)
+			require.Len(t, journals, len(tt.expected))
+
+			for _, j := range journals {
+				require.NoError(t, j.validate())
+				assert.Equal(t, tt.expected[j.Key], flatten(j), j.Key)
+			}
+		})
+	}
+}
+
+func TestRefundJournal(t *testing.T) {
+	o := Origin{MerchantID: 1, TransactionID: 10}
+
+	j := refundJournal(o, "refund:1", usdt("30000000"), big.NewInt(10000000), big.NewInt(5000000), "refund")
+	require.NoError(t, j.validate())
+
+	assert.Equal(t, []flatLine{
+		{AccountOverpayments, Debit, "10000000"},
+		{AccountCustomerDeposits, Debit, "5000000"},
+		{AccountRefunds, Debit, "15000000"},
+		{AccountCustody, Credit, "30000000"},
+	}, flatten(j))
+}
+
+func TestJournal_FiatValues(t *testing.T) {
+	j := newJournal(EventReceipt, "receipt:tx:1", Origin{MerchantID: 1}, "ETH_USDT", 6, "").
+		add(AccountCustody, Debit, big.NewInt(333333)).
+		add(AccountCustody, Debit, big.NewInt(333333)).
+		add(AccountCustody, Debit, big.NewInt(333334)).
+		add(AccountSales, Credit, big.NewInt(1000000))
+
+	values := j.fiatValues(decimal.RequireFromString("0.92"))
+
+	// each debit rounds to 0.31, credit absorbs the difference
+	assert.Equal(t, []string{"0.31", "0.31", "0.31", "0.93"}, []string{
+		values[0].StringFixed(2), values[1].StringFixed(2), values[2].StringFixed(2), values[3].StringFixed(2),
+	})
+}
+
+func TestReversalJournal(t *testing.T) {
+	numeric := func(v int64) repository.LedgerEntry {
+		e := repository.LedgerEntry{}
+		_ = e.Amount.Set(v)
+		return e
+	}
+
+	debit := numeric(5000000)
+	debit.MerchantID, debit.EventKey, debit.Account, debit.Side = 1, "fill:7", string(AccountCustody), string(Debit)
+	debit.Ticker, debit.Decimals, debit.FiatCurrency = "ETH_USDT", 6, "EUR"
+	debit.FiatAmount = sql.NullString{String: "4.60", Valid: true}
+	debit.Rate = sql.NullString{String: "0.92", Valid: true}
+
+	credit := debit
+	credit.Account, credit.Side = string(AccountCustomerDeposits), string(Credit)
+
+	j := reversalJournal([]repository.LedgerEntry{debit, credit}, "reorg:fill:7", EventReorgReversal, time.Now())
+	require.NoError(t, j.validate())
+
+	assert.Equal(t, "fill:7", j.ReversesKey)
+	assert.Equal(t, "EUR", j.FiatCurrency)
+	require.NotNil(t, j.preset)
+	assert.Equal(t, "0.92", j.preset.Rate.String())
+
+	assert.Equal(t, []flatLine{
+		{AccountCustody, Credit, "5000000"},
+		{AccountCustomerDeposits, Debit, "5000000"},
+	}, flatten(j))
+
+	values := j.fiatValues(decimal.RequireFromString("100"))
+	assert.Equal(t, "4.6", values[0].String())
+	assert.Equal(t, "4.6", values[1].String())
+}
+
+func TestRateFromSnapshots(t *testing.T) {
+	snapshots := []repository.RateSnapshot{
+		{ID: 1, FromCurrency: "EUR", ToCurrency: "ETH", Rate: "0.0005", Source: "pricefeed"},
+		{ID: 2, FromCurrency: "EUR", ToCurrency: "USD", Rate: "1.1", Source: "pricefeed"},
+	}
+
+	t.Run("direct", func(t *testing.T) {
+		v, ok := rateFromSnapshots("ETH", "EUR", snapshots)
+		require.True(t, ok)
+		assert.Equal(t, "2000", v.Rate.String())
+		assert.Equal(t, int64(1), *v.SnapshotID)
+	})
+
+	t.Run("one hop", func(t *testing.T) {
+		v, ok := rateFromSnapshots("ETH", "USD", snapshots)
+		require.True(t, ok)
+		assert.Equal(t, "2200", v.Rate.String())
+		assert.Equal(t, int64(1), *v.SnapshotID)
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		_, ok := rateFromSnapshots("TRON", "USD", snapshots)
+		assert.False(t, ok)
+	})
+}
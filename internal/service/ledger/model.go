@@ -0,0 +1,160 @@
+package ledger
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// Account ledger account of a merchant. Prefix defines account's class.
+type Account string
+
+const (
+	// AccountCustody crypto held in processing-managed hot wallets.
+	AccountCustody Account = "assets:custody"
+
+	// AccountNonCustodial crypto received directly to merchant-controlled
+	// addresses (EVM collector contracts, xpub-derived addresses).
+	AccountNonCustodial Account = "assets:non_custodial"
+
+	// AccountPayouts funds moved out to merchant's own wallets.
+	AccountPayouts Account = "assets:payouts"
+
+	// AccountCustomerDeposits received funds not yet applied to an invoice:
+	// partial fills and underpaid payments awaiting merchant's resolution.
+	AccountCustomerDeposits Account = "liabilities:customer_deposits"
+
+	// AccountOverpayments amount customers paid above the invoice price.
+	AccountOverpayments Account = "liabilities:overpayments"
+
+	// AccountFeesPayable processing fees of non-custodial payments which are
+	// not withheld on-chain.
+	AccountFeesPayable Account = "liabilities:fees_payable"
+
+	// AccountTopups virtual top-ups of merchant's balance by the system.
+	AccountTopups Account = "equity:topups"
+
+	AccountSales Account = "revenue:sales"
+
+	// AccountRefunds contra-revenue: refunds above outstanding overpayments and deposits.
+	AccountRefunds Account = "revenue:refunds"
+
+	AccountFees Account = "expenses:processing_fees"
+)
+
+// Accounts merchant's chart of accounts in presentation order.
+var Accounts = []Account{
+	AccountCustody,
+	AccountNonCustodial,
+	AccountPayouts,
+	AccountCustomerDeposits,
+	AccountOverpayments,
+	AccountFeesPayable,
+	AccountTopups,
+	AccountSales,
+	AccountRefunds,
+	AccountFees,
+}
+
+// DebitNormal reports whether account's balance increases with debits
+// (assets and expenses).
+func (a Account) DebitNormal() bool {
+	return a.class() == "assets" || a.class() == "expenses"
+}
+
+func (a Account) class() string {
+	class, _, _ := strings.Cut(string(a), ":")
+	return class
+}
+
+type Side string
+
+const (
+	Debit  Side = "debit"
+	Credit Side = "credit"
+)
+
+type EventType string
+
+const (
+	EventReceipt       EventType = "receipt"
+	EventOverpayment   EventType = "overpayment"
+	EventUnderpayment  EventType = "underpayment"
+	EventFill          EventType = "fill"
+	EventReorgReversal EventType = "reorg_reversal"
+	EventRefund        EventType = "refund"
+	EventWithdrawal    EventType = "withdrawal"
+	EventTopup         EventType = "topup"
+)
+
+// Entry journal line of the ledger.
+type Entry struct {
+	ID        int64
+	EventKey  string
+	EventType EventType
+	Line      int
+	Account   Account
+	Side      Side
+	Ticker    string
+	Amount    decimal.Decimal
+
+	// FiatAmount is nil when no exchange rate was available at event time.
+	FiatCurrency   string
+	FiatAmount     *decimal.Decimal
+	Rate           *decimal.Decimal
+	RateSource     string
+	RateSnapshotID *int64
+
+	TransactionID *int64
+	PaymentID     *int64
+	PaymentUUID   *uuid.UUID
+	OrderID       string
+	TxHash        string
+	ReversesKey   string
+	Description   string
+	IsTest        bool
+	OccurredAt    time.Time
+	CreatedAt     time.Time
+}
+
+// AccountBalance balance of an account in one asset over a date range.
+// Balances are signed by account's normal side: positive asset balance
+// means funds held, positive revenue balance means income earned.
+type AccountBalance struct {
+	Account      Account
+	Ticker       string
+	FiatCurrency string
+
+	Opening decimal.Decimal
+	Debits  decimal.Decimal
+	Credits decimal.Decimal
+	Closing decimal.Decimal
+
+	OpeningFiat decimal.Decimal
+	DebitsFiat  decimal.Decimal
+	CreditsFiat decimal.Decimal
+	ClosingFiat decimal.Decimal
+}
+
+// EventTotal realized total of an event type in one asset over a date range.
+type EventTotal struct {
+	EventType    EventType
+	Ticker       string
+	FiatCurrency string
+	Events       int64
+	Amount       decimal.Decimal
+	FiatAmount   decimal.Decimal
+
+	// Unvalued number of lines without fiat value, FiatAmount excludes them.
+	Unvalued int64
+}
+
+// Range date range [From, To) of ledger queries.
+type Range struct {
+	MerchantID int64
+	IsTest     bool
+	From       time.Time
+	To         time.Time
+}
@@ -0,0 +1,182 @@
+package ledger
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/cryptolink/cryptolink/internal/db/repository"
+	"github.com/jackc/pgtype"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+// Balances returns opening balance, movements and closing balance of each
+// merchant's account within the range.
+func (s *Service) Balances(ctx context.Context, r Range) ([]*AccountBalance, error) {
+	if !r.From.Before(r.To) {
+		return nil, errors.New("invalid date range")
+	}
+
+	rows, err := s.store.LedgerAccountBalances(ctx, repository.LedgerRangeParams{
+		MerchantID: r.MerchantID,
+		IsTest:     r.IsTest,
+		From:       r.From,
+		To:         r.To,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to get ledger balances")
+	}
+
+	results := make([]*AccountBalance, 0, len(rows))
+	for _, row := range rows {
+		b := &AccountBalance{
+			Account:      Account(row.Account),
+			Ticker:       row.Ticker,
+			FiatCurrency: row.FiatCurrency,
+			Opening:      numericToDecimal(row.OpeningAmount, row.Decimals),
+			Debits:       numericToDecimal(row.DebitAmount, row.Decimals),
+			Credits:      numericToDecimal(row.CreditAmount, row.Decimals),
+			OpeningFiat:  stringToDecimal(row.OpeningFiat),
+			DebitsFiat:   stringToDecimal(row.DebitFiat),
+			CreditsFiat:  stringToDecimal(row.CreditFiat),
+		}
+
+		// opening is stored as net debit; flip credit-normal accounts
+		if !b.Account.DebitNormal() {
+			b.Opening = b.Opening.Neg()
+			b.OpeningFiat = b.OpeningFiat.Neg()
+			b.Closing = b.Opening.Add(b.Credits).Sub(b.Debits)
+			b.ClosingFiat = b.OpeningFiat.Add(b.CreditsFiat).Sub(b.DebitsFiat)
+		} else {
+			b.Closing = b.Opening.Add(b.Debits).Sub(b.Credits)
+			b.ClosingFiat = b.OpeningFiat.Add(b.DebitsFiat).Sub(b.CreditsFiat)
+		}
+
+		results = append(results, b)
+	}
+
+	return results, nil
+}
+
+// Totals returns realized totals per event type within the range.
+func (s *Service) Totals(ctx context.Context, r Range) ([]*EventTotal, error) {
+	if !r.From.Before(r.To) {
+		return nil, errors.New("invalid date range")
+	}
+
+	rows, err := s.store.LedgerEventTotals(ctx, repository.LedgerRangeParams{
+		MerchantID: r.MerchantID,
+		IsTest:     r.IsTest,
+		From:       r.From,
+		To:         r.To,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to get ledger totals")
+	}
+
+	results := make([]*EventTotal, 0, len(rows))
+	for _, row := range rows {
+		results = append(results, &EventTotal{
+			EventType:    EventType(row.EventType),
+			Ticker:       row.Ticker,
+			FiatCurrency: row.FiatCurrency,
+			Events:       row.Events,
+			Amount:       numericToDecimal(row.Amount, row.Decimals),
+			FiatAmount:   stringToDecimal(row.FiatAmount),
+			Unvalued:     row.Unvalued,
+		})
+	}
+
+	return results, nil
+}
+
+// ListEntries returns journal lines within the range in chronological order.
+// afterID is the pagination cursor: id of the last entry of previous page.
+func (s *Service) ListEntries(ctx context.Context, r Range, afterID int64, limit int) ([]*Entry, error) {
+	if !r.From.Before(r.To) {
+		return nil, errors.New("invalid date range")
+	}
+
+	rows, err := s.store.ListLedgerEntries(ctx, repository.ListLedgerEntriesParams{
+		MerchantID: r.MerchantID,
+		IsTest:     r.IsTest,
+		From:       r.From,
+		To:         r.To,
+		AfterID:    afterID,
+		Limit:      int32(limit),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to list ledger entries")
+	}
+
+	return entriesFromRepo(rows), nil
+}
+
+func entriesFromRepo(rows []repository.LedgerEntry) []*Entry {
+	entries := make([]*Entry, 0, len(rows))
+
+	for _, row := range rows {
+		e := &Entry{
+			ID:             row.ID,
+			EventKey:       row.EventKey,
+			EventType:      EventType(row.EventType),
+			Line:           int(row.Line),
+			Account:        Account(row.Account),
+			Side:           Side(row.Side),
+			Ticker:         row.Ticker,
+			Amount:         numericToDecimal(row.Amount, row.Decimals),
+			FiatCurrency:   row.FiatCurrency,
+			RateSource:     row.RateSource.String,
+			RateSnapshotID: repository.NullableInt64ToPointer(row.RateSnapshotID),
+			TransactionID:  repository.NullableInt64ToPointer(row.TransactionID),
+			PaymentID:      repository.NullableInt64ToPointer(row.PaymentID),
+			OrderID:        row.OrderID.String,
+			TxHash:         row.TxHash.String,
+			ReversesKey:    row.ReversesKey.String,
+			Description:    row.Description,
+			IsTest:         row.IsTest,
+			OccurredAt:     row.OccurredAt,
+			CreatedAt:      row.CreatedAt,
+		}
+
+		if row.PaymentUUID.Valid {
+			e.PaymentUUID = &row.PaymentUUID.UUID
+		}
+
+		if row.FiatAmount.Valid {
+			v := stringToDecimal(row.FiatAmount.String)
+			e.FiatAmount = &v
+		}
+
+		if row.Rate.Valid {
+			v := stringToDecimal(row.Rate.String)
+			e.Rate = &v
+		}
+
+		entries = append(entries, e)
+	}
+
+	return entries
+}
+
+func numericToDecimal(num pgtype.Numeric, decimals int32) decimal.Decimal {
+	v, err := repository.NumericToBigInt(num)
+	if err != nil {
+		return decimal.Zero
+	}
+
+	return decimal.NewFromBigInt(v, -decimals)
+}
+
+func stringToDecimal(s string) decimal.Decimal {
+	v, err := decimal.NewFromString(s)
+	if err != nil {
+		return decimal.Zero
+	}
+
+	return v
+}
+
+func formatRaw(v *big.Int, decimals int32) string {
+	return decimal.NewFromBigInt(v, -decimals).String()
+}
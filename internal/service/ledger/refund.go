@@ -0,0 +1,120 @@
+package ledger
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cryptolink/cryptolink/internal/db/repository"
+	"github.com/cryptolink/cryptolink/internal/money"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/pkg/errors"
+)
+
+// RefundParams refund sent to the customer by the merchant.
+type RefundParams struct {
+	MerchantID int64
+	PaymentID  int64
+
+	// Amount in payment's crypto currency, e.g. "0.015"
+	Amount string
+
+	// TxHash of the refund transfer. When set, the refund is idempotent per hash.
+	TxHash string
+	Reason string
+}
+
+// RecordRefund records refund of the payment's transaction. The refund settles
+// outstanding overpayment first, then unapplied deposits, the rest is booked
+// as contra-revenue. Merchant can't refund more than ledger holds for the payment.
+func (s *Service) RecordRefund(ctx context.Context, params RefundParams) ([]*Entry, error) {
+	var entries []*Entry
+
+	err := s.store.RunTransaction(ctx, func(ctx context.Context, q repository.Querier) error {
+		tx, err := q.GetLatestTransactionByPaymentID(ctx, repository.Int64ToNullable(params.PaymentID))
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			return ErrNotFound
+		case err != nil:
+			return errors.Wrap(err, "unable to get transaction")
+		case tx.MerchantID != params.MerchantID:
+			return ErrNotFound
+		}
+
+		amount, err := money.CryptoFromStringFloat(tx.Currency, params.Amount, int64(tx.Decimals))
+		if err != nil || !amount.IsPositive() {
+			return errors.Wrap(ErrInvalidRefund, "invalid amount")
+		}
+
+		o := Origin{
+			MerchantID:    tx.MerchantID,
+			TransactionID: tx.ID,
+			PaymentID:     params.PaymentID,
+			IsTest:        tx.IsTest,
+			NonCustodial:  !tx.RecipientWalletID.Valid,
+			TxHash:        params.TxHash,
+			OccurredAt:    time.Now(),
+		}
+
+		held, err := s.netCredit(ctx, q, o, o.asset())
+		if err != nil {
+			return err
+		}
+
+		// asset accounts are debit-normal
+		held.Neg(held)
+
+		if raw(amount).Cmp(held) > 0 {
+			return errors.Wrapf(ErrInvalidRefund, "amount exceeds %s held for the payment", formatRaw(held, tx.Decimals))
+		}
+
+		overpaid, err := s.netCredit(ctx, q, o, AccountOverpayments)
+		if err != nil {
+			return err
+		}
+
+		deposited, err := s.deposited(ctx, q, o)
+		if err != nil {
+			return err
+		}
+
+		key := "refund:" + uuid.New().String()
+		if params.TxHash != "" {
+			key = fmt.Sprintf("refund:tx:%d:%s", tx.ID, params.TxHash)
+		}
+
+		description := "refund to customer"
+		if params.Reason != "" {
+			description += ": " + params.Reason
+		}
+		if params.TxHash != "" {
+			description += " (" + params.TxHash + ")"
+		}
+
+		j := refundJournal(o, key, amount, overpaid, deposited, description)
+
+		posted, err := s.post(ctx, q, j, valueAtSpot)
+		switch {
+		case err != nil:
+			return err
+		case !posted:
+			return ErrAlreadyRecorded
+		}
+
+		rows, err := q.ListLedgerEntriesByEventKey(ctx, o.MerchantID, key)
+		if err != nil {
+			return errors.Wrap(err, "unable to get refund journal")
+		}
+
+		entries = entriesFromRepo(rows)
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,234 @@
+package ledger
+
+import (
+	"context"
+	"database/sql"
+	"math/big"
+	"time"
+
+	"github.com/cryptolink/cryptolink/internal/db/repository"
+	"github.com/cryptolink/cryptolink/internal/money"
+	"github.com/cryptolink/cryptolink/internal/service/blockchain"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"github.com/shopspring/decimal"
+)
+
+// BlockchainService currency resolver and convertor used to value ledger entries.
+type BlockchainService interface {
+	blockchain.Resolver
+	blockchain.Convertor
+}
+
+// Service append-only double-entry ledger of merchants. Each balance-affecting
+// event is recorded as a balanced journal in the same DB transaction as the
+// balance change itself, so Record* methods accept repository.Querier.
+type Service struct {
+	store      *repository.Store
+	blockchain blockchain.Resolver
+	convertor  blockchain.Convertor
+	logger     *zerolog.Logger
+}
+
+var (
+	ErrNotFound        = errors.New("not found")
+	ErrInvalidRefund   = errors.New("invalid refund")
+	ErrAlreadyRecorded = errors.New("event is already recorded")
+)
+
+func New(store *repository.Store, blockchainService BlockchainService, logger *zerolog.Logger) *Service {
+	log := logger.With().Str("channel", "ledger_service").Logger()
+
+	s := &Service{store: store, logger: &log}
+
+	// avoid typed-nil interfaces
+	if blockchainService != nil {
+		s.blockchain = blockchainService
+		s.convertor = blockchainService
+	}
+
+	return s
+}
+
+// RecordIncoming records receipt and overpayment (or underpayment) journals of
+// confirmed incoming transaction. Amounts already booked as partial fills are
+// moved out of customer deposits.
+//
+// Warning: this function is meant to be used only in db transactions
+func (s *Service) RecordIncoming(ctx context.Context, q repository.Querier, in Incoming) error {
+	deposited, err := s.deposited(ctx, q, in.Origin)
+	if err != nil {
+		return err
+	}
+
+	for _, j := range incomingJournals(in, deposited) {
+		if _, err := s.post(ctx, q, j, valueAtQuote); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RecordFill records a confirmed partial-payment fill as customer deposit.
+//
+// Warning: this function is meant to be used only in db transactions
+func (s *Service) RecordFill(ctx context.Context, q repository.Querier, o Origin, fillID int64, amount money.Money) error {
+	_, err := s.post(ctx, q, fillJournal(o, fillID, amount), valueAtQuote)
+	return err
+}
+
+// ReverseFill records a reversing journal of reorged fill at the original
+// fill's value. Noop if the fill was never recorded or is already reversed.
+//
+// Warning: this function is meant to be used only in db transactions
+func (s *Service) ReverseFill(ctx context.Context, q repository.Querier, merchantID, fillID int64, at time.Time) error {
+	original, err := q.ListLedgerEntriesByEventKey(ctx, merchantID, fillKey(fillID))
+	if err != nil {
+		return errors.Wrap(err, "unable to get fill journal")
+	}
+
+	if len(original) == 0 {
+		return nil
+	}
+
+	_, err = s.post(ctx, q, reversalJournal(original, "reorg:"+fillKey(fillID), EventReorgReversal, at), valueAtQuote)
+
+	return err
+}
+
+// RecordResolvedReceipt recognizes underpaid payment resolved by merchant as revenue.
+//
+// Warning: this function is meant to be used only in db transactions
+func (s *Service) RecordResolvedReceipt(ctx context.Context, q repository.Querier, o Origin, fact money.Money) error {
+	deposited, err := s.deposited(ctx, q, o)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.post(ctx, q, resolvedJournal(o, fact, deposited), valueAtQuote)
+
+	return err
+}
+
+// RecordWithdrawal records withdrawal of merchant's funds to their own wallet.
+//
+// Warning: this function is meant to be used only in db transactions
+func (s *Service) RecordWithdrawal(ctx context.Context, q repository.Querier, o Origin, amount, fee money.Money) error {
+	_, err := s.post(ctx, q, withdrawalJournal(o, amount, fee), valueAtSpot)
+	return err
+}
+
+// RecordTopup records virtual system top-up of merchant's balance.
+//
+// Warning: this function is meant to be used only in db transactions
+func (s *Service) RecordTopup(ctx context.Context, q repository.Querier, o Origin, amount money.Money) error {
+	_, err := s.post(ctx, q, topupJournal(o, amount), valueAtSpot)
+	return err
+}
+
+// deposited returns amount booked as customer deposits for the transaction.
+func (s *Service) deposited(ctx context.Context, q repository.Querier, o Origin) (*big.Int, error) {
+	return s.netCredit(ctx, q, o, AccountCustomerDeposits)
+}
+
+// netCredit returns credits minus debits of the account for the transaction.
+func (s *Service) netCredit(ctx context.Context, q repository.Querier, o Origin, account Account) (*big.Int, error) {
+	if o.TransactionID == 0 {
+		return new(big.Int), nil
+	}
+
+	num, err := q.SumLedgerAccountByTransaction(ctx, o.MerchantID, o.TransactionID, string(account))
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to sum %s", account)
+	}
+
+	netDebit, err := repository.NumericToBigInt(num)
+	if err != nil {
+		return nil, err
+	}
+
+	return netDebit.Neg(netDebit), nil
+}
+
+// post validates, values and inserts the journal. Returns false if the journal
+// is empty or was already recorded.
+func (s *Service) post(ctx context.Context, q repository.Querier, j *journal, mode valuationMode) (bool, error) {
+	if j.empty() {
+		return false, nil
+	}
+
+	if err := j.validate(); err != nil {
+		return false, err
+	}
+
+	existing, err := q.ListLedgerEntriesByEventKey(ctx, j.Origin.MerchantID, j.Key)
+	if err != nil {
+		return false, errors.Wrap(err, "unable to check ledger journal")
+	}
+
+	if len(existing) > 0 {
+		return false, nil
+	}
+
+	fiat := j.FiatCurrency
+	if fiat == "" {
+		if fiat, err = merchantFiatCurrency(ctx, q, j.Origin.MerchantID); err != nil {
+			return false, err
+		}
+	}
+
+	v := s.value(ctx, q, j, fiat, mode)
+
+	var fiatValues []decimal.Decimal
+	if v != nil {
+		fiatValues = j.fiatValues(v.Rate)
+	}
+
+	occurredAt := j.Origin.OccurredAt
+	if occurredAt.IsZero() {
+		occurredAt = time.Now()
+	}
+
+	now := time.Now()
+
+	for i, l := range j.Lines {
+		params := repository.InsertLedgerEntryParams{
+			MerchantID:    j.Origin.MerchantID,
+			EventKey:      j.Key,
+			EventType:     string(j.Type),
+			Line:          int32(i + 1),
+			Account:       string(l.Account),
+			Side:          string(l.Side),
+			Ticker:        j.Ticker,
+			Decimals:      int32(j.Decimals),
+			Amount:        repository.BigIntToNumeric(l.Amount),
+			FiatCurrency:  fiat,
+			TransactionID: nullInt64(j.Origin.TransactionID),
+			PaymentID:     nullInt64(j.Origin.PaymentID),
+			ReversesKey:   repository.StringToNullable(j.ReversesKey),
+			Description:   j.Description,
+			IsTest:        j.Origin.IsTest,
+			OccurredAt:    occurredAt.UTC(),
+			CreatedAt:     now.UTC(),
+			TxHash:        repository.StringToNullable(j.Origin.TxHash),
+		}
+
+		if v != nil {
+			params.FiatAmount = repository.StringToNullable(fiatValues[i].StringFixed(2))
+			params.Rate = repository.StringToNullable(v.Rate.String())
+			params.RateSource = repository.StringToNullable(v.Source)
+			params.RateSnapshotID = repository.PointerInt64ToNullable(v.SnapshotID)
+		}
+
+		if _, err := q.InsertLedgerEntry(ctx, params); err != nil {
+			return false, errors.Wrapf(err, "unable to insert ledger entry %s/%d", j.Key, i+1)
+		}
+	}
+
+	return true, nil
+}
+
+func nullInt64(v int64) sql.NullInt64 {
+	return sql.NullInt64{Int64: v, Valid: v != 0}
+}
@@ -0,0 +1,211 @@
+package ledger
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cryptolink/cryptolink/internal/db/repository"
+	"github.com/cryptolink/cryptolink/internal/money"
+	"github.com/cryptolink/cryptolink/internal/service/merchant"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+// rateKindLedgerValuation rate snapshot kind of spot rates taken by the ledger
+// (see transaction.RateKindLedgerValuation).
+const rateKindLedgerValuation = "ledger_valuation"
+
+// valuation fiat units per one whole unit of the asset.
+type valuation struct {
+	Rate       decimal.Decimal
+	Source     string
+	SnapshotID *int64
+}
+
+// valuationMode defines which rate an event is valued at.
+type valuationMode int
+
+const (
+	// valueAtQuote uses the rate the transaction was quoted at (stored rate
+	// snapshots), falling back to the spot rate.
+	valueAtQuote valuationMode = iota
+
+	// valueAtSpot uses the spot rate at event time.
+	valueAtSpot
+)
+
+// merchantFiatCurrency returns merchant's FiatCurrency setting.
+func merchantFiatCurrency(ctx context.Context, q repository.Querier, merchantID int64) (string, error) {
+	mt, err := q.GetMerchantByID(ctx, repository.GetMerchantByIDParams{ID: merchantID, WithTrashed: true})
+	if err != nil {
+		return "", errors.Wrap(err, "unable to get merchant")
+	}
+
+	settings := merchant.Settings{}
+	if len(mt.Settings.Bytes) > 0 {
+		if err := json.Unmarshal(mt.Settings.Bytes, &settings); err != nil {
+			return "", errors.Wrap(err, "unable to decode merchant settings")
+		}
+	}
+
+	return settings.FiatCurrency(), nil
+}
+
+// rateFromSnapshots derives fiat rate of the ticker from transaction's rate
+// snapshots either directly (e.g. EUR -> ETH) or through one intermediate
+// currency (e.g. EUR -> ETH and EUR -> USD for USD valuation). Later
+// snapshots take precedence.
+func rateFromSnapshots(ticker, fiat string, snapshots []repository.RateSnapshot) (valuation, bool) {
+	type edge struct {
+		rate       decimal.Decimal
+		source     string
+		snapshotID int64
+	}
+
+	// edges[from][to]: to = from * rate
+	edges := map[string]map[string]edge{}
+	put := func(from, to string, e edge) {
+		if edges[from] == nil {
+			edges[from] = map[string]edge{}
+		}
+		edges[from][to] = e
+	}
+
+	for _, snap := range snapshots {
+		rate, err := decimal.NewFromString(snap.Rate)
+		if err != nil || !rate.IsPositive() {
+			continue
+		}
+
+		from, to := strings.ToUpper(snap.FromCurrency), strings.ToUpper(snap.ToCurrency)
+
+		put(from, to, edge{rate: rate, source: snap.Source, snapshotID: snap.ID})
+		put(to, from, edge{rate: decimal.NewFromInt(1).DivRound(rate, 18), source: snap.Source, snapshotID: snap.ID})
+	}
+
+	ticker, fiat = strings.ToUpper(ticker), strings.ToUpper(fiat)
+
+	if e, ok := edges[ticker][fiat]; ok {
+		id := e.snapshotID
+		return valuation{Rate: e.rate, Source: e.source, SnapshotID: &id}, true
+	}
+
+	vias := make([]string, 0, len(edges[ticker]))
+	for via := range edges[ticker] {
+		vias = append(vias, via)
+	}
+	sort.Strings(vias)
+
+	for _, via := range vias {
+		first := edges[ticker][via]
+		second, ok := edges[via][fiat]
+		if !ok {
+			continue
+		}
+
+		id := first.snapshotID
+
+		return valuation{Rate: first.rate.Mul(second.rate), Source: first.source, SnapshotID: &id}, true
+	}
+
+	return valuation{}, false
+}
+
+// value resolves journal's valuation in merchant's fiat currency. When no rate
+// is available the journal is recorded without fiat value.
+func (s *Service) value(ctx context.Context, q repository.Querier, j *journal, fiat string, mode valuationMode) *valuation {
+	// reversals are recorded at the value of the original journal
+	if j.ReversesKey != "" {
+		return j.preset
+	}
+
+	if mode == valueAtQuote && j.Origin.TransactionID != 0 {
+		snapshots, err := q.ListRateSnapshotsByTransaction(ctx, j.Origin.TransactionID)
+		if err != nil {
+			s.logger.Warn().Err(err).Int64("transaction_id", j.Origin.TransactionID).Msg("unable to list rate snapshots")
+		}
+
+		if v, ok := rateFromSnapshots(j.Ticker, fiat, snapshots); ok {
+			return &v
+		}
+	}
+
+	v, err := s.spotValuation(ctx, q, j, fiat)
+	if err != nil {
+		s.logger.Warn().Err(err).
+			Int64("merchant_id", j.Origin.MerchantID).
+			Str("event_key", j.Key).
+			Msg("unable to value ledger journal, recording without fiat value")
+
+		return nil
+	}
+
+	return v
+}
+
+// spotValuation converts journal's total at the current rate. The rate is
+// persisted as a rate snapshot when the journal belongs to a transaction.
+func (s *Service) spotValuation(ctx context.Context, q repository.Querier, j *journal, fiat string) (*valuation, error) {
+	if s.convertor == nil {
+		return nil, errors.New("convertor is not set")
+	}
+
+	fiatCurrency, err := money.MakeFiatCurrency(fiat)
+	if err != nil {
+		return nil, err
+	}
+
+	amount, err := money.NewFromBigInt(money.Crypto, j.Ticker, j.total(), j.Decimals)
+	if err != nil {
+		return nil, err
+	}
+
+	conv, err := s.convertor.CryptoToFiat(ctx, amount, fiatCurrency)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to convert %s to %s", j.Ticker, fiat)
+	}
+
+	rate := decimal.NewFromFloat(conv.Rate)
+	if !rate.IsPositive() {
+		return nil, errors.Errorf("invalid %s/%s rate", j.Ticker, fiat)
+	}
+
+	v := &valuation{Rate: rate, Source: string(conv.Source)}
+
+	if j.Origin.TransactionID == 0 {
+		return v, nil
+	}
+
+	fetchedAt := conv.CalculatedAt
+	if fetchedAt.IsZero() {
+		fetchedAt = time.Now()
+	}
+
+	snap, err := q.InsertRateSnapshot(ctx, repository.InsertRateSnapshotParams{
+		MerchantID:    j.Origin.MerchantID,
+		PaymentID:     j.Origin.PaymentID,
+		TransactionID: j.Origin.TransactionID,
+		Kind:          rateKindLedgerValuation,
+		FromCurrency:  conv.From.Ticker(),
+		ToCurrency:    conv.To.Ticker(),
+		FromAmount:    conv.From.String(),
+		ToAmount:      conv.To.String(),
+		Rate:          rate.String(),
+		Source:        string(conv.Source),
+		FeePercent:    sql.NullString{},
+		ChargedAmount: sql.NullString{},
+		FetchedAt:     fetchedAt,
+		CreatedAt:     time.Now(),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to insert rate snapshot")
+	}
+
+	v.SnapshotID = &snap.ID
+
+	return v, nil
+}
@@ -0,0 +1,76 @@
+package merchant
+
+import (
+	"context"
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+// PropertyAccountingAccountPrefix prefixes merchant's names of ledger accounts
+// used in accounting exports, e.g. "accounting.account.revenue:sales" = "4000 Sales".
+const PropertyAccountingAccountPrefix = "accounting.account."
+
+const maxAccountingAccountNameLength = 100
+
+var ErrInvalidAccountingAccounts = errors.New("invalid accounting account names")
+
+// AccountingAccounts returns merchant's custom names of ledger accounts keyed by
+// ledger account, e.g. "revenue:sales". Accounts without custom name are omitted.
+func (s Settings) AccountingAccounts() map[string]string {
+	names := make(map[string]string)
+
+	for prop, value := range s {
+		account, ok := strings.CutPrefix(string(prop), PropertyAccountingAccountPrefix)
+		if !ok || account == "" || value == "" {
+			continue
+		}
+
+		names[account] = value
+	}
+
+	return names
+}
+
+// UpdateAccountingAccounts sets custom names of ledger accounts. Empty name
+// resets the account to its default name. Caller is responsible for checking
+// that accounts exist.
+func (s *Service) UpdateAccountingAccounts(ctx context.Context, mt *Merchant, names map[string]string) error {
+	settings := make(Settings, len(names))
+
+	for account, name := range names {
+		name = strings.TrimSpace(name)
+
+		if err := validateAccountingAccountName(name); err != nil {
+			return errors.Wrapf(err, "account %q", account)
+		}
+
+		settings[Property(PropertyAccountingAccountPrefix+account)] = name
+	}
+
+	if len(settings) == 0 {
+		return nil
+	}
+
+	return s.UpsertSettings(ctx, mt, settings)
+}
+
+func validateAccountingAccountName(name string) error {
+	if len(name) > maxAccountingAccountNameLength {
+		return errors.Wrapf(ErrInvalidAccountingAccounts, "name should be at most %d characters", maxAccountingAccountNameLength)
+	}
+
+	// names end up in CSV files opened by spreadsheets
+	if name != "" && strings.ContainsAny(name[:1], "=+-@") {
+		return errors.Wrap(ErrInvalidAccountingAccounts, "name should not start with =, +, - or @")
+	}
+
+	for _, r := range name {
+		if unicode.IsControl(r) {
+			return errors.Wrap(ErrInvalidAccountingAccounts, "name should not contain control characters")
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,30 @@
+package merchant
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSettings_AccountingAccounts(t *testing.T) {
+	settings := Settings{
+		PropertyFiatCurrency:                               "EUR",
+		PropertyAccountingAccountPrefix + "revenue:sales":  "4000 Sales",
+		PropertyAccountingAccountPrefix + "assets:custody": "",
+	}
+
+	assert.Equal(t, map[string]string{"revenue:sales": "4000 Sales"}, settings.AccountingAccounts())
+}
+
+func TestValidateAccountingAccountName(t *testing.T) {
+	for name, valid := range map[string]bool{
+		"":                  true,
+		"1010 Crypto (EUR)": true,
+		"=HYPERLINK()":      false,
+		"-1000":             false,
+		"Sales\nRefunds":    false,
+	} {
+		err := validateAccountingAccountName(name)
+		assert.Equal(t, valid, err == nil, name)
+	}
+}
@@ -0,0 +1,221 @@
+package merchant
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	PropertyBrandingLogo         = "branding.logo"
+	PropertyBrandingAccentColor  = "branding.accent_color"
+	PropertyBrandingSupportEmail = "branding.support_email"
+	PropertyBrandingTermsURL     = "branding.terms_url"
+	PropertyBrandingFooterText   = "branding.footer_text"
+)
+
+// BrandingConfig configures local storage of merchant logos.
+type BrandingConfig struct {
+	LogoDir     string `yaml:"logo_dir" env:"OXYGEN_BRANDING_LOGO_DIR" env-default:"./data/logos" env-description:"Directory where uploaded merchant logos are stored"`
+	MaxLogoSize int64  `yaml:"max_logo_size" env:"OXYGEN_BRANDING_MAX_LOGO_SIZE" env-default:"524288" env-description:"Max logo size in bytes"`
+
+	// PublicBaseURL is used to build absolute logo URLs for emails.
+	// Defaults to processing.payment_frontend_base_path.
+	PublicBaseURL string `yaml:"public_base_url" env:"OXYGEN_BRANDING_PUBLIC_BASE_URL" env-description:"Base URL of the payment API host. Example: https://pay.site.com"`
+}
+
+// LogoPath is the payment API route that serves uploaded logos.
+const LogoPath = "/api/payment/v1/branding/logo/"
+
+const maxFooterTextLength = 500
+
+var (
+	ErrInvalidBranding = errors.New("invalid branding")
+	ErrLogoNotFound    = errors.New("logo not found")
+	ErrLogoStorage     = errors.New("logo storage is not configured")
+
+	accentColorRegexp  = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+	logoFilenameRegexp = regexp.MustCompile(`^[0-9a-f-]{36}-[0-9a-f]{16}\.(png|jpg|gif|webp)$`)
+
+	// SVG is not allowed on purpose: it can carry scripts.
+	logoExtensions = map[string]string{
+		"image/png":  "png",
+		"image/jpeg": "jpg",
+		"image/gif":  "gif",
+		"image/webp": "webp",
+	}
+)
+
+// Branding merchant's checkout & customer emails look.
+type Branding struct {
+	LogoURL      string
+	AccentColor  string
+	SupportEmail string
+	TermsURL     string
+	FooterText   string
+}
+
+func (b Branding) IsEmpty() bool {
+	return b == Branding{}
+}
+
+// BrandingProps are user-provided branding settings. Empty value removes the setting.
+type BrandingProps struct {
+	AccentColor  string
+	SupportEmail string
+	TermsURL     string
+	FooterText   string
+}
+
+func (p BrandingProps) validate() error {
+	if p.AccentColor != "" && !accentColorRegexp.MatchString(p.AccentColor) {
+		return errors.Wrap(ErrInvalidBranding, "accent color should be in #RRGGBB format")
+	}
+
+	if p.SupportEmail != "" {
+		if _, err := mail.ParseAddress(p.SupportEmail); err != nil {
+			return errors.Wrap(ErrInvalidBranding, "invalid support email")
+		}
+	}
+
+	if p.TermsURL != "" {
+		u, err := url.ParseRequestURI(p.TermsURL)
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return errors.Wrap(ErrInvalidBranding, "terms url should be a valid http(s) url")
+		}
+	}
+
+	if len([]rune(p.FooterText)) > maxFooterTextLength {
+		return errors.Wrapf(ErrInvalidBranding, "footer text should be at most %d characters", maxFooterTextLength)
+	}
+
+	return nil
+}
+
+// Branding returns merchant's branding with absolute logo url.
+func (s *Service) Branding(mt *Merchant) Branding {
+	settings := mt.Settings()
+
+	b := Branding{
+		AccentColor:  settings[PropertyBrandingAccentColor],
+		SupportEmail: settings[PropertyBrandingSupportEmail],
+		TermsURL:     settings[PropertyBrandingTermsURL],
+		FooterText:   settings[PropertyBrandingFooterText],
+	}
+
+	if logo := settings[PropertyBrandingLogo]; logo != "" {
+		b.LogoURL = strings.TrimSuffix(s.branding.PublicBaseURL, "/") + LogoPath + logo
+	}
+
+	return b
+}
+
+func (s *Service) UpdateBranding(ctx context.Context, mt *Merchant, props BrandingProps) error {
+	if err := props.validate(); err != nil {
+		return err
+	}
+
+	return s.UpsertSettings(ctx, mt, Settings{
+		PropertyBrandingAccentColor:  strings.ToLower(props.AccentColor),
+		PropertyBrandingSupportEmail: props.SupportEmail,
+		PropertyBrandingTermsURL:     props.TermsURL,
+		PropertyBrandingFooterText:   strings.TrimSpace(props.FooterText),
+	})
+}
+
+// UploadLogo validates the image by its content (not by user-provided
+// content-type), stores it in LogoDir and replaces the previous logo.
+func (s *Service) UploadLogo(ctx context.Context, mt *Merchant, data []byte) error {
+	if s.branding.LogoDir == "" {
+		return ErrLogoStorage
+	}
+
+	if len(data) == 0 {
+		return errors.Wrap(ErrInvalidBranding, "logo is empty")
+	}
+
+	if int64(len(data)) > s.branding.MaxLogoSize {
+		return errors.Wrapf(ErrInvalidBranding, "logo should be at most %d bytes", s.branding.MaxLogoSize)
+	}
+
+	ext, ok := logoExtensions[http.DetectContentType(data)]
+	if !ok {
+		return errors.Wrap(ErrInvalidBranding, "logo should be png, jpeg, gif or webp image")
+	}
+
+	suffix := make([]byte, 8)
+	if _, err := rand.Read(suffix); err != nil {
+		return errors.Wrap(err, "unable to generate logo name")
+	}
+
+	filename := mt.UUID.String() + "-" + hex.EncodeToString(suffix) + "." + ext
+
+	if err := os.MkdirAll(s.branding.LogoDir, 0o755); err != nil {
+		return errors.Wrap(err, "unable to create logo dir")
+	}
+
+	if err := os.WriteFile(filepath.Join(s.branding.LogoDir, filename), data, 0o644); err != nil {
+		return errors.Wrap(err, "unable to write logo")
+	}
+
+	previous := mt.Settings()[PropertyBrandingLogo]
+
+	if err := s.UpsertSettings(ctx, mt, Settings{PropertyBrandingLogo: filename}); err != nil {
+		return err
+	}
+
+	s.removeLogo(previous)
+
+	return nil
+}
+
+func (s *Service) DeleteLogo(ctx context.Context, mt *Merchant) error {
+	previous := mt.Settings()[PropertyBrandingLogo]
+	if previous == "" {
+		return nil
+	}
+
+	if err := s.UpsertSettings(ctx, mt, Settings{PropertyBrandingLogo: ""}); err != nil {
+		return err
+	}
+
+	s.removeLogo(previous)
+
+	return nil
+}
+
+// ReadLogo returns logo's content and content-type by its filename.
+func (s *Service) ReadLogo(filename string) ([]byte, string, error) {
+	if s.branding.LogoDir == "" || !logoFilenameRegexp.MatchString(filename) {
+		return nil, "", ErrLogoNotFound
+	}
+
+	data, err := os.ReadFile(filepath.Join(s.branding.LogoDir, filename))
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return nil, "", ErrLogoNotFound
+	case err != nil:
+		return nil, "", errors.Wrap(err, "unable to read logo")
+	}
+
+	return data, http.DetectContentType(data), nil
+}
+
+func (s *Service) removeLogo(filename string) {
+	if filename == "" || !logoFilenameRegexp.MatchString(filename) || s.branding.LogoDir == "" {
+		return
+	}
+
+	if err := os.Remove(filepath.Join(s.branding.LogoDir, filename)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		s.logger.Warn().Err(err).Str("filename", filename).Msg("unable to remove previous logo")
+	}
+}
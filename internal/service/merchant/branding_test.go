@@ -0,0 +1,65 @@
+package merchant
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBrandingProps_Validate(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		props BrandingProps
+		valid bool
+	}{
+		{name: "empty", props: BrandingProps{}, valid: true},
+		{
+			name: "full",
+			props: BrandingProps{
+				AccentColor:  "#FF5a1f",
+				SupportEmail: "support@acme.com",
+				TermsURL:     "https://acme.com/terms",
+				FooterText:   "Acme Inc",
+			},
+			valid: true,
+		},
+		{name: "short color", props: BrandingProps{AccentColor: "#fff"}, valid: false},
+		{name: "css injection", props: BrandingProps{AccentColor: "red;background:url(x)"}, valid: false},
+		{name: "bad email", props: BrandingProps{SupportEmail: "nope"}, valid: false},
+		{name: "javascript url", props: BrandingProps{TermsURL: "javascript:alert(1)"}, valid: false},
+		{name: "relative url", props: BrandingProps{TermsURL: "/terms"}, valid: false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.props.validate()
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, ErrInvalidBranding)
+			}
+		})
+	}
+}
+
+func TestService_ReadLogo(t *testing.T) {
+	dir := t.TempDir()
+	logger := zerolog.Nop()
+	s := New(nil, nil, nil, BrandingConfig{LogoDir: dir, MaxLogoSize: 1024}, &logger)
+
+	const filename = "8a2d4f60-1c3e-4b5a-9e7f-2d6c8b0a1e34-0123456789abcdef.png"
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, filename), png, 0o644))
+
+	data, contentType, err := s.ReadLogo(filename)
+	require.NoError(t, err)
+	assert.Equal(t, png, data)
+	assert.Equal(t, "image/png", contentType)
+
+	for _, name := range []string{"", "../secret.png", "logo.svg", "8a2d4f60-1c3e-4b5a-9e7f-2d6c8b0a1e34-ffffffffffffffff.png"} {
+		_, _, err := s.ReadLogo(name)
+		assert.ErrorIs(t, err, ErrLogoNotFound, name)
+	}
+}
@@ -0,0 +1,125 @@
+package merchant
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Role of a user within a merchant.
+type Role string
+
+// Permission is an action over merchant's resources. Viewing payments,
+// customers, balances and settings is allowed to every member.
+type Permission string
+
+const (
+	RoleOwner     Role = "owner"
+	RoleAdmin     Role = "admin"
+	RoleDeveloper Role = "developer"
+	RoleFinance   Role = "finance"
+	RoleSupport   Role = "support"
+
+	// PermissionPayments create, resolve and decline payments, payment links and subscriptions.
+	PermissionPayments Permission = "payments"
+	// PermissionRefunds create refunds.
+	PermissionRefunds Permission = "refunds"
+	// PermissionWebhooks view and manage webhook endpoints and deliveries.
+	PermissionWebhooks Permission = "webhooks"
+	// PermissionTokens view and manage API tokens.
+	PermissionTokens Permission = "tokens"
+	// PermissionCollectors manage xpub wallets and EVM collectors.
+	PermissionCollectors Permission = "collectors"
+	// PermissionSettings change merchant settings, branding and plan.
+	PermissionSettings Permission = "settings"
+	// PermissionMembers invite members and change their roles.
+	PermissionMembers Permission = "members"
+	// PermissionOwnership delete the merchant and manage owners.
+	PermissionOwnership Permission = "ownership"
+)
+
+var rolePermissions = map[Role][]Permission{
+	RoleOwner: {
+		PermissionPayments,
+		PermissionRefunds,
+		PermissionWebhooks,
+		PermissionTokens,
+		PermissionCollectors,
+		PermissionSettings,
+		PermissionMembers,
+		PermissionOwnership,
+	},
+	RoleAdmin: {
+		PermissionPayments,
+		PermissionRefunds,
+		PermissionWebhooks,
+		PermissionTokens,
+		PermissionCollectors,
+		PermissionSettings,
+		PermissionMembers,
+	},
+	RoleDeveloper: {
+		PermissionPayments,
+		PermissionWebhooks,
+		PermissionTokens,
+	},
+	RoleFinance: {
+		PermissionPayments,
+		PermissionRefunds,
+	},
+	RoleSupport: {},
+}
+
+// ParseRole returns ErrInvalidRole for unknown roles.
+func ParseRole(raw string) (Role, error) {
+	role := Role(raw)
+	if _, ok := rolePermissions[role]; !ok {
+		return "", ErrInvalidRole
+	}
+
+	return role, nil
+}
+
+// Can checks whether the role has the permission.
+func (r Role) Can(permission Permission) bool {
+	for _, p := range rolePermissions[r] {
+		if p == permission {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (r Role) Permissions() []Permission {
+	return rolePermissions[r]
+}
+
+// Membership is a merchant with the role of the user in it.
+type Membership struct {
+	Merchant *Merchant
+	Role     Role
+}
+
+type Member struct {
+	ID         int64
+	MerchantID int64
+	UserID     int64
+	UserUUID   uuid.UUID
+	Email      string
+	Name       string
+	Role       Role
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+type Invitation struct {
+	ID         int64
+	UUID       uuid.UUID
+	MerchantID int64
+	Email      string
+	Role       Role
+	InvitedBy  int64
+	ExpiresAt  time.Time
+	CreatedAt  time.Time
+}
@@ -0,0 +1,43 @@
+package merchant
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRole(t *testing.T) {
+	for _, raw := range []string{"owner", "admin", "developer", "finance", "support"} {
+		role, err := ParseRole(raw)
+		assert.NoError(t, err)
+		assert.Equal(t, Role(raw), role)
+	}
+
+	for _, raw := range []string{"", "Owner", "root"} {
+		_, err := ParseRole(raw)
+		assert.ErrorIs(t, err, ErrInvalidRole)
+	}
+}
+
+func TestRole_Can(t *testing.T) {
+	for _, tt := range []struct {
+		role       Role
+		permission Permission
+		expected   bool
+	}{
+		{role: RoleOwner, permission: PermissionOwnership, expected: true},
+		{role: RoleAdmin, permission: PermissionMembers, expected: true},
+		{role: RoleAdmin, permission: PermissionOwnership, expected: false},
+		{role: RoleDeveloper, permission: PermissionTokens, expected: true},
+		{role: RoleDeveloper, permission: PermissionRefunds, expected: false},
+		{role: RoleDeveloper, permission: PermissionSettings, expected: false},
+		{role: RoleFinance, permission: PermissionRefunds, expected: true},
+		{role: RoleFinance, permission: PermissionWebhooks, expected: false},
+		{role: RoleSupport, permission: PermissionPayments, expected: false},
+		{role: Role("unknown"), permission: PermissionPayments, expected: false},
+	} {
+		t.Run(string(tt.role)+"/"+string(tt.permission), func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.role.Can(tt.permission))
+		})
+	}
+}
@@ -23,10 +23,8 @@ type Merchant struct {
 }
 
 const (
-	PropertyWebhookURL      = "webhook.url"
-	PropertySignatureSecret = "webhook.secret"
-	PropertyPaymentMethods  = "payment.methods"
-	PropertyFiatCurrency    = "fiat.currency"
+	PropertyPaymentMethods = "payment.methods"
+	PropertyFiatCurrency   = "fiat.currency"
 )
 
 func (m *Merchant) Settings() Settings {
@@ -36,14 +34,6 @@ func (m *Merchant) Settings() Settings {
 type Property string
 type Settings map[Property]string
 
-func (s Settings) WebhookURL() string {
-	return s[PropertyWebhookURL]
-}
-
-func (s Settings) WebhookSignatureSecret() string {
-	return s[PropertySignatureSecret]
-}
-
 func (s Settings) PaymentMethods() []string {
 	raw := s[PropertyPaymentMethods]
 	if raw == "" {
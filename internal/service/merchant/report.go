@@ -0,0 +1,85 @@
+package merchant
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	PropertyTimezone        = "timezone"
+	PropertyReportFrequency = "report.frequency"
+)
+
+// ReportFrequency how often merchant receives settlement report emails.
+type ReportFrequency string
+
+const (
+	ReportOff    ReportFrequency = "off"
+	ReportDaily  ReportFrequency = "daily"
+	ReportWeekly ReportFrequency = "weekly"
+)
+
+func (f ReportFrequency) Valid() bool {
+	switch f {
+	case ReportOff, ReportDaily, ReportWeekly:
+		return true
+	default:
+		return false
+	}
+}
+
+var ErrInvalidReportSettings = errors.New("invalid report settings")
+
+// Timezone returns merchant's IANA timezone name. Defaults to "UTC".
+func (s Settings) Timezone() string {
+	tz := s[PropertyTimezone]
+	if tz == "" {
+		return "UTC"
+	}
+
+	if _, err := time.LoadLocation(tz); err != nil {
+		return "UTC"
+	}
+
+	return tz
+}
+
+// Location returns merchant's timezone location used for reporting periods.
+func (s Settings) Location() *time.Location {
+	loc, err := time.LoadLocation(s.Timezone())
+	if err != nil {
+		return time.UTC
+	}
+
+	return loc
+}
+
+// ReportFrequency returns merchant's settlement report frequency. Reports are opt-in.
+func (s Settings) ReportFrequency() ReportFrequency {
+	if f := ReportFrequency(s[PropertyReportFrequency]); f.Valid() {
+		return f
+	}
+
+	return ReportOff
+}
+
+func (s *Service) UpdateReportSettings(ctx context.Context, mt *Merchant, frequency ReportFrequency, timezone string) error {
+	if !frequency.Valid() {
+		return errors.Wrapf(ErrInvalidReportSettings, "unknown frequency %q", frequency)
+	}
+
+	if timezone == "" {
+		timezone = "UTC"
+	}
+
+	if _, err := time.LoadLocation(timezone); err != nil {
+		return errors.Wrapf(ErrInvalidReportSettings, "unknown timezone %q", timezone)
+	}
+
+	return s.UpsertSettings(ctx, mt, Settings{
+		PropertyReportFrequency: string(frequency),
+		PropertyTimezone:        timezone,
+	})
+}
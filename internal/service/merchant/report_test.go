@@ -0,0 +1,20 @@
+package merchant
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSettings_Report(t *testing.T) {
+	assert.Equal(t, ReportOff, Settings{}.ReportFrequency())
+	assert.Equal(t, "UTC", Settings{}.Timezone())
+
+	settings := Settings{PropertyReportFrequency: "weekly", PropertyTimezone: "Europe/Madrid"}
+	assert.Equal(t, ReportWeekly, settings.ReportFrequency())
+	assert.Equal(t, "Europe/Madrid", settings.Location().String())
+
+	invalid := Settings{PropertyReportFrequency: "hourly", PropertyTimezone: "Mars/Olympus"}
+	assert.Equal(t, ReportOff, invalid.ReportFrequency())
+	assert.Equal(t, "UTC", invalid.Timezone())
+}
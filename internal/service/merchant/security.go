@@ -0,0 +1,27 @@
+package merchant
+
+import (
+	"context"
+	"strconv"
+)
+
+// PropertySecurityAlerts whether owners receive emails about high-risk
+// configuration changes.
+const PropertySecurityAlerts = "security.alerts"
+
+// SecurityAlertsEnabled returns whether owners are emailed about high-risk
+// changes. Alerts are opt-out.
+func (s Settings) SecurityAlertsEnabled() bool {
+	enabled, err := strconv.ParseBool(s[PropertySecurityAlerts])
+	if err != nil {
+		return true
+	}
+
+	return enabled
+}
+
+func (s *Service) UpdateSecurityAlerts(ctx context.Context, mt *Merchant, enabled bool) error {
+	return s.UpsertSettings(ctx, mt, Settings{
+		PropertySecurityAlerts: strconv.FormatBool(enabled),
+	})
+}
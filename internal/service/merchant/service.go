@@ -25,7 +25,9 @@ type BlockchainService interface {
 
 type Service struct {
 	repo       *repository.Queries
+	store      *repository.Store
 	blockchain BlockchainService
+	branding   BrandingConfig
 	logger     *zerolog.Logger
 }
 
@@ -38,14 +40,18 @@ var (
 
 func New(
 	repo *repository.Queries,
+	store *repository.Store,
 	blockchainService BlockchainService,
+	branding BrandingConfig,
 	logger *zerolog.Logger,
 ) *Service {
 	log := logger.With().Str("channel", "merchant_service").Logger()
 
 	return &Service{
 		repo:       repo,
+		store:      store,
 		blockchain: blockchainService,
+		branding:   branding,
 		logger:     &log,
 	}
 }
@@ -89,17 +95,36 @@ func (s *Service) GetByUUIDAndCreatorID(
 }
 
 func (s *Service) Create(ctx context.Context, creatorID int64, name, website string, settings Settings) (*Merchant, error) {
-	entry, err := s.repo.CreateMerchant(ctx, repository.CreateMerchantParams{
-		Uuid:      uuid.New(),
-		Name:      name,
-		Website:   website,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
-		DeletedAt: sql.NullTime{},
-		CreatorID: creatorID,
-		Settings:  settings.toJSONB(),
-	})
+	var entry repository.Merchant
+
+	err := s.store.RunTransaction(ctx, func(ctx context.Context, q repository.Querier) error {
+		var err error
+		entry, err = q.CreateMerchant(ctx, repository.CreateMerchantParams{
+			Uuid:      uuid.New(),
+			Name:      name,
+			Website:   website,
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+			DeletedAt: sql.NullTime{},
+			CreatorID: creatorID,
+			Settings:  settings.toJSONB(),
+		})
+		if err != nil {
+			return err
+		}
+
+		_, err = q.CreateMerchantMember(ctx, repository.CreateMerchantMemberParams{
+			MerchantID: entry.ID,
+			UserID:     creatorID,
+			Role:       string(RoleOwner),
+			CreatedAt:  entry.CreatedAt,
+		})
+		if err != nil {
+			return errors.Wrap(err, "unable to add merchant owner")
+		}
 
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
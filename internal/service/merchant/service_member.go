@@ -0,0 +1,287 @@
+package merchant
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/cryptolink/cryptolink/internal/db/repository"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/pkg/errors"
+)
+
+// InvitationTTL how long an emailed invitation is valid.
+const InvitationTTL = 7 * 24 * time.Hour
+
+var (
+	ErrMemberNotFound     = errors.New("member not found")
+	ErrInvitationNotFound = errors.New("invitation not found or expired")
+	ErrInvalidRole        = errors.New("invalid role")
+	ErrAlreadyMember      = errors.New("user is already a member")
+	ErrLastOwner          = errors.New("merchant should have at least one owner")
+	ErrOwnerRequired      = errors.New("only owners can manage owners")
+)
+
+type InviteMemberParams struct {
+	MerchantID int64
+	Email      string
+	Role       Role
+	// InvitedBy user id and role of the inviting member.
+	InvitedBy     int64
+	InvitedByRole Role
+}
+
+// GetByUUIDAndMemberID returns merchant if the user is its member along with
+// user's role.
+func (s *Service) GetByUUIDAndMemberID(ctx context.Context, merchantUUID uuid.UUID, userID int64) (*Merchant, Role, error) {
+	entry, err := s.repo.GetMerchantByUUIDAndMemberID(ctx, merchantUUID, userID)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return nil, "", ErrMerchantNotFound
+	case err != nil:
+		return nil, "", err
+	}
+
+	return entryToMerchant(entry.Merchant), Role(entry.Role), nil
+}
+
+// ListByMemberID lists merchants the user is a member of.
+func (s *Service) ListByMemberID(ctx context.Context, userID int64) ([]Membership, error) {
+	entries, err := s.repo.ListMerchantsByMemberID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]Membership, len(entries))
+	for i := range entries {
+		results[i] = Membership{
+			Merchant: entryToMerchant(entries[i].Merchant),
+			Role:     Role(entries[i].Role),
+		}
+	}
+
+	return results, nil
+}
+
+func (s *Service) ListMembers(ctx context.Context, merchantID int64) ([]*Member, error) {
+	entries, err := s.repo.ListMerchantMembers(ctx, merchantID)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*Member, len(entries))
+	for i := range entries {
+		results[i] = entryToMember(entries[i])
+	}
+
+	return results, nil
+}
+
+func (s *Service) GetMember(ctx context.Context, merchantID int64, userUUID uuid.UUID) (*Member, error) {
+	entry, err := s.repo.GetMerchantMemberByUserUUID(ctx, merchantID, userUUID)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return nil, ErrMemberNotFound
+	case err != nil:
+		return nil, err
+	}
+
+	return entryToMember(entry), nil
+}
+
+// UpdateMemberRole changes member's role on behalf of a member with actorRole.
+// Only owners can promote to or demote from owner; the last owner can't be demoted.
+func (s *Service) UpdateMemberRole(
+	ctx context.Context,
+	actorRole Role,
+	member *Member,
+	role Role,
+) (*Member, error) {
+	if _, err := ParseRole(string(role)); err != nil {
+		return nil, err
+	}
+
+	if err := s.guardOwnerChange(ctx, actorRole, member, role); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.repo.UpdateMerchantMemberRole(ctx, member.MerchantID, member.UserID, string(role), time.Now()); err != nil {
+		return nil, errors.Wrap(err, "unable to update member role")
+	}
+
+	updated := *member
+	updated.Role = role
+
+	return &updated, nil
+}
+
+// RemoveMember removes member on behalf of a member with actorRole.
+func (s *Service) RemoveMember(ctx context.Context, actorRole Role, member *Member) error {
+	if err := s.guardOwnerChange(ctx, actorRole, member, ""); err != nil {
+		return err
+	}
+
+	return s.repo.DeleteMerchantMember(ctx, member.MerchantID, member.UserID)
+}
+
+// guardOwnerChange checks that only owners change owners and that the last
+// owner stays.
+func (s *Service) guardOwnerChange(ctx context.Context, actorRole Role, member *Member, role Role) error {
+	if member.Role != RoleOwner && role != RoleOwner {
+		return nil
+	}
+
+	if !actorRole.Can(PermissionOwnership) {
+		return ErrOwnerRequired
+	}
+
+	if member.Role != RoleOwner || role == RoleOwner {
+		return nil
+	}
+
+	owners, err := s.repo.CountMerchantMembersByRole(ctx, member.MerchantID, string(RoleOwner))
+	switch {
+	case err != nil:
+		return errors.Wrap(err, "unable to count owners")
+	case owners <= 1:
+		return ErrLastOwner
+	}
+
+	return nil
+}
+
+// InviteMember creates an invitation for the email and returns it with the
+// token to email. Previous pending invitations of the email are replaced.
+func (s *Service) InviteMember(ctx context.Context, params InviteMemberParams) (*Invitation, string, error) {
+	if _, err := ParseRole(string(params.Role)); err != nil {
+		return nil, "", err
+	}
+
+	if params.Role == RoleOwner && !params.InvitedByRole.Can(PermissionOwnership) {
+		return nil, "", ErrOwnerRequired
+	}
+
+	email := strings.ToLower(strings.TrimSpace(params.Email))
+
+	members, err := s.ListMembers(ctx, params.MerchantID)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "unable to list members")
+	}
+
+	for _, m := range members {
+		if strings.EqualFold(m.Email, email) {
+			return nil, "", ErrAlreadyMember
+		}
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return nil, "", errors.Wrap(err, "failed to generate random token")
+	}
+
+	token := hex.EncodeToString(b)
+
+	if err := s.repo.DeletePendingMerchantInvitations(ctx, params.MerchantID, email); err != nil {
+		return nil, "", errors.Wrap(err, "unable to delete previous invitations")
+	}
+
+	now := time.Now()
+
+	entry, err := s.repo.CreateMerchantInvitation(ctx, repository.CreateMerchantInvitationParams{
+		Uuid:       uuid.New(),
+		MerchantID: params.MerchantID,
+		Email:      email,
+		Role:       string(params.Role),
+		TokenHash:  hashInvitationToken(token),
+		InvitedBy:  params.InvitedBy,
+		ExpiresAt:  now.Add(InvitationTTL),
+		CreatedAt:  now,
+	})
+	if err != nil {
+		return nil, "", errors.Wrap(err, "unable to create invitation")
+	}
+
+	return entryToInvitation(entry), token, nil
+}
+
+func (s *Service) ListInvitations(ctx context.Context, merchantID int64) ([]*Invitation, error) {
+	entries, err := s.repo.ListPendingMerchantInvitations(ctx, merchantID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]*Invitation, len(entries))
+	for i := range entries {
+		results[i] = entryToInvitation(entries[i])
+	}
+
+	return results, nil
+}
+
+func (s *Service) RevokeInvitation(ctx context.Context, merchantID int64, id uuid.UUID) error {
+	deleted, err := s.repo.DeleteMerchantInvitation(ctx, merchantID, id)
+	switch {
+	case err != nil:
+		return err
+	case deleted == 0:
+		return ErrInvitationNotFound
+	}
+
+	return nil
+}
+
+// AcceptInvitation adds the user to the merchant by invitation token. The
+// invitation should be sent to user's email.
+func (s *Service) AcceptInvitation(ctx context.Context, token string, userID int64, email string) (*Merchant, error) {
+	entry, err := s.repo.AcceptMerchantInvitation(ctx, hashInvitationToken(token), userID, email, time.Now())
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return nil, ErrInvitationNotFound
+	case err != nil:
+		return nil, errors.Wrap(err, "unable to accept invitation")
+	}
+
+	s.logger.Info().
+		Int64("merchant_id", entry.MerchantID).
+		Int64("user_id", userID).
+		Str("role", entry.Role).
+		Msg("merchant invitation accepted")
+
+	return s.GetByID(ctx, entry.MerchantID, false)
+}
+
+func hashInvitationToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func entryToMember(entry repository.MerchantMemberWithUser) *Member {
+	return &Member{
+		ID:         entry.ID,
+		MerchantID: entry.MerchantID,
+		UserID:     entry.UserID,
+		UserUUID:   entry.UserUuid,
+		Email:      entry.UserEmail,
+		Name:       entry.UserName,
+		Role:       Role(entry.Role),
+		CreatedAt:  entry.CreatedAt,
+		UpdatedAt:  entry.UpdatedAt,
+	}
+}
+
+func entryToInvitation(entry repository.MerchantInvitation) *Invitation {
+	return &Invitation{
+		ID:         entry.ID,
+		UUID:       entry.Uuid,
+		MerchantID: entry.MerchantID,
+		Email:      entry.Email,
+		Role:       Role(entry.Role),
+		InvitedBy:  entry.InvitedBy,
+		ExpiresAt:  entry.ExpiresAt,
+		CreatedAt:  entry.CreatedAt,
+	}
+}
@@ -0,0 +1,141 @@
+package merchant
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	PropertyUnderpaymentTolerancePercent = "underpayment.tolerance_percent"
+	PropertyUnderpaymentToleranceFiat    = "underpayment.tolerance_fiat"
+	PropertyUnderpaymentAction           = "underpayment.action"
+	PropertyUnderpaymentTopUpWindow      = "underpayment.top_up_window_min"
+)
+
+// UnderpaymentAction is what happens with a payment whose received amount is
+// below merchant's tolerance.
+type UnderpaymentAction string
+
+const (
+	// UnderpaymentWait keeps the invoice open so customer can top up
+	// to the same address within TopUpWindow.
+	UnderpaymentWait UnderpaymentAction = "wait"
+
+	// UnderpaymentAcceptPartial completes the payment with the received amount.
+	UnderpaymentAcceptPartial UnderpaymentAction = "accept_partial"
+
+	// UnderpaymentExpire closes the invoice right away as failed. Received
+	// funds are credited only if merchant resolves the payment manually.
+	UnderpaymentExpire UnderpaymentAction = "expire"
+)
+
+func (a UnderpaymentAction) Valid() bool {
+	switch a {
+	case UnderpaymentWait, UnderpaymentAcceptPartial, UnderpaymentExpire:
+		return true
+	default:
+		return false
+	}
+}
+
+const (
+	maxUnderpaymentTolerancePercent = 50
+	maxUnderpaymentTopUpWindow      = 72 * time.Hour
+)
+
+var (
+	ErrInvalidUnderpaymentPolicy = errors.New("invalid underpayment policy")
+
+	// defaultUnderpaymentToleranceFiat mirrors the historical $0.10 rounding allowance.
+	defaultUnderpaymentToleranceFiat = decimal.RequireFromString("0.10")
+)
+
+// DefaultUnderpaymentTopUpWindow is how long after the original expiration
+// the customer can top up a partially paid invoice.
+const DefaultUnderpaymentTopUpWindow = 24 * time.Hour
+
+// UnderpaymentPolicy merchant's rules for payments that received less than expected.
+//
+// The payment is considered fully paid when the shortfall is within the larger
+// of TolerancePercent of the expected amount and ToleranceFiat (in merchant's
+// fiat currency). Otherwise, BelowTolerance action is applied.
+type UnderpaymentPolicy struct {
+	TolerancePercent decimal.Decimal
+	ToleranceFiat    decimal.Decimal
+	BelowTolerance   UnderpaymentAction
+	TopUpWindow      time.Duration
+}
+
+func DefaultUnderpaymentPolicy() UnderpaymentPolicy {
+	return UnderpaymentPolicy{
+		TolerancePercent: decimal.Zero,
+		ToleranceFiat:    defaultUnderpaymentToleranceFiat,
+		BelowTolerance:   UnderpaymentWait,
+		TopUpWindow:      DefaultUnderpaymentTopUpWindow,
+	}
+}
+
+func (p UnderpaymentPolicy) validate() error {
+	if p.TolerancePercent.IsNegative() || p.TolerancePercent.GreaterThan(decimal.NewFromInt(maxUnderpaymentTolerancePercent)) {
+		return errors.Wrapf(ErrInvalidUnderpaymentPolicy, "tolerance percent should be between 0 and %d", maxUnderpaymentTolerancePercent)
+	}
+
+	if p.ToleranceFiat.IsNegative() {
+		return errors.Wrap(ErrInvalidUnderpaymentPolicy, "tolerance amount should not be negative")
+	}
+
+	if !p.BelowTolerance.Valid() {
+		return errors.Wrapf(ErrInvalidUnderpaymentPolicy, "unknown action %q", p.BelowTolerance)
+	}
+
+	if p.TopUpWindow < 0 || p.TopUpWindow > maxUnderpaymentTopUpWindow {
+		return errors.Wrapf(ErrInvalidUnderpaymentPolicy, "top-up window should be between 0 and %d hours", int(maxUnderpaymentTopUpWindow.Hours()))
+	}
+
+	return nil
+}
+
+// UnderpaymentPolicy returns merchant's underpayment policy. Missing or malformed
+// settings fall back to defaults.
+func (s Settings) UnderpaymentPolicy() UnderpaymentPolicy {
+	p := DefaultUnderpaymentPolicy()
+
+	if v, err := decimal.NewFromString(s[PropertyUnderpaymentTolerancePercent]); err == nil {
+		p.TolerancePercent = v
+	}
+
+	if v, err := decimal.NewFromString(s[PropertyUnderpaymentToleranceFiat]); err == nil {
+		p.ToleranceFiat = v
+	}
+
+	if v := UnderpaymentAction(s[PropertyUnderpaymentAction]); v.Valid() {
+		p.BelowTolerance = v
+	}
+
+	if v, err := strconv.Atoi(s[PropertyUnderpaymentTopUpWindow]); err == nil {
+		p.TopUpWindow = time.Duration(v) * time.Minute
+	}
+
+	if p.validate() != nil {
+		return DefaultUnderpaymentPolicy()
+	}
+
+	return p
+}
+
+func (s *Service) UpdateUnderpaymentPolicy(ctx context.Context, mt *Merchant, policy UnderpaymentPolicy) error {
+	if err := policy.validate(); err != nil {
+		return err
+	}
+
+	return s.UpsertSettings(ctx, mt, Settings{
+		PropertyUnderpaymentTolerancePercent: policy.TolerancePercent.String(),
+		PropertyUnderpaymentToleranceFiat:    policy.ToleranceFiat.String(),
+		PropertyUnderpaymentAction:           string(policy.BelowTolerance),
+		PropertyUnderpaymentTopUpWindow:      strconv.Itoa(int(policy.TopUpWindow / time.Minute)),
+	})
+}
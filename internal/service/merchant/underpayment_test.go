@@ -0,0 +1,78 @@
+package merchant
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSettings_UnderpaymentPolicy(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		settings Settings
+		expected UnderpaymentPolicy
+	}{
+		{name: "defaults", settings: Settings{}, expected: DefaultUnderpaymentPolicy()},
+		{
+			name: "custom",
+			settings: Settings{
+				PropertyUnderpaymentTolerancePercent: "1.5",
+				PropertyUnderpaymentToleranceFiat:    "2",
+				PropertyUnderpaymentAction:           "accept_partial",
+				PropertyUnderpaymentTopUpWindow:      "120",
+			},
+			expected: UnderpaymentPolicy{
+				TolerancePercent: decimal.RequireFromString("1.5"),
+				ToleranceFiat:    decimal.RequireFromString("2"),
+				BelowTolerance:   UnderpaymentAcceptPartial,
+				TopUpWindow:      2 * time.Hour,
+			},
+		},
+		{
+			name: "unknown action falls back to wait",
+			settings: Settings{
+				PropertyUnderpaymentAction: "refund",
+			},
+			expected: DefaultUnderpaymentPolicy(),
+		},
+		{
+			name: "out of range values fall back to defaults",
+			settings: Settings{
+				PropertyUnderpaymentTolerancePercent: "90",
+				PropertyUnderpaymentAction:           "expire",
+			},
+			expected: DefaultUnderpaymentPolicy(),
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := tt.settings.UnderpaymentPolicy()
+
+			assert.True(t, tt.expected.TolerancePercent.Equal(actual.TolerancePercent))
+			assert.True(t, tt.expected.ToleranceFiat.Equal(actual.ToleranceFiat))
+			assert.Equal(t, tt.expected.BelowTolerance, actual.BelowTolerance)
+			assert.Equal(t, tt.expected.TopUpWindow, actual.TopUpWindow)
+		})
+	}
+}
+
+func TestUnderpaymentPolicy_Validate(t *testing.T) {
+	valid := DefaultUnderpaymentPolicy()
+	assert.NoError(t, valid.validate())
+
+	for name, mutate := range map[string]func(p *UnderpaymentPolicy){
+		"negative percent": func(p *UnderpaymentPolicy) { p.TolerancePercent = decimal.NewFromInt(-1) },
+		"percent too high": func(p *UnderpaymentPolicy) { p.TolerancePercent = decimal.NewFromInt(51) },
+		"negative amount":  func(p *UnderpaymentPolicy) { p.ToleranceFiat = decimal.NewFromInt(-1) },
+		"unknown action":   func(p *UnderpaymentPolicy) { p.BelowTolerance = "refund" },
+		"window too long":  func(p *UnderpaymentPolicy) { p.TopUpWindow = 73 * time.Hour },
+		"negative window":  func(p *UnderpaymentPolicy) { p.TopUpWindow = -time.Minute },
+	} {
+		t.Run(name, func(t *testing.T) {
+			p := DefaultUnderpaymentPolicy()
+			mutate(&p)
+			assert.ErrorIs(t, p.validate(), ErrInvalidUnderpaymentPolicy)
+		})
+	}
+}
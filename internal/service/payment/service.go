@@ -23,6 +23,7 @@ import (
 	"github.com/cryptolink/cryptolink/internal/service/transaction"
 	"github.com/cryptolink/cryptolink/internal/service/wallet"
 	"github.com/cryptolink/cryptolink/internal/util"
+	"github.com/cryptolink/cryptolink/internal/webhook"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
 )
@@ -36,10 +37,11 @@ type BlockchainService interface {
 type TransactionResolver interface {
 	GetLatestByPaymentID(ctx context.Context, paymentID int64) (*transaction.Transaction, error)
 	EagerLoadByPaymentIDs(ctx context.Context, merchantID int64, paymentIDs []int64) ([]*transaction.Transaction, error)
+	RecordResolvedReceipt(ctx context.Context, tx *transaction.Transaction) error
 }
 
 type Service struct {
-	repo         *repository.Queries
+	store        *repository.Store
 	basePath     string
 	logger       *zerolog.Logger
 	transactions TransactionResolver
@@ -69,6 +71,7 @@ var (
 	ErrAlreadyExists                 = errors.New("payment already exists")
 	ErrValidation                    = errors.New("payment is invalid")
 	ErrLinkValidation                = errors.New("payment link is invalid")
+	ErrLinkUsedUp                    = errors.New("payment link is used up")
 	ErrPaymentMethodNotSet           = errors.New("payment method is not set yet")
 	ErrPaymentLocked                 = errors.New("payment is locked for editing")
 	ErrInvalidLimit                  = errors.New("invalid limit")
@@ -78,7 +81,7 @@ var (
 )
 
 func New(
-	repo *repository.Queries,
+	store *repository.Store,
 	basePath string,
 	transactionService TransactionResolver,
 	merchantService *merchant.Service,
@@ -90,7 +93,7 @@ func New(
 	log := logger.With().Str("channel", "payment_service").Logger()
 
 	return &Service{
-		repo:         repo,
+		store:        store,
 		basePath:     basePath,
 		transactions: transactionService,
 		merchants:    merchantService,
@@ -103,7 +106,7 @@ func New(
 
 func (s *Service) GetByID(ctx context.Context, merchantID, id int64) (*Payment, error) {
 	// merchantID here is for validating that this payment really belongs to the merchant.
-	p, err := s.repo.GetPaymentByID(ctx, repository.GetPaymentByIDParams{
+	p, err := s.store.GetPaymentByID(ctx, repository.GetPaymentByIDParams{
 		ID:                 id,
 		MerchantID:         merchantID,
 		FilterByMerchantID: merchantID != MerchantIDWildcard,
@@ -124,7 +127,7 @@ func (s *Service) GetByMerchantOrderID(
 	merchantID int64,
 	merchantOrderUUID uuid.UUID,
 ) (*Payment, error) {
-	p, err := s.repo.GetPaymentByMerchantIDAndOrderUUID(ctx, repository.GetPaymentByMerchantIDAndOrderUUIDParams{
+	p, err := s.store.GetPaymentByMerchantIDAndOrderUUID(ctx, repository.GetPaymentByMerchantIDAndOrderUUIDParams{
 		MerchantID:        merchantID,
 		MerchantOrderUuid: merchantOrderUUID,
 	})
@@ -159,7 +162,7 @@ func (s *Service) GetByMerchantOrderIDWithRelations(
 }
 
 func (s *Service) GetByPublicID(ctx context.Context, publicID uuid.UUID) (*Payment, error) {
-	p, err := s.repo.GetPaymentByPublicID(ctx, publicID)
+	p, err := s.store.GetPaymentByPublicID(ctx, publicID)
 
 	switch {
 	case errors.Is(err, pgx.ErrNoRows):
@@ -172,7 +175,7 @@ func (s *Service) GetByPublicID(ctx context.Context, publicID uuid.UUID) (*Payme
 }
 
 func (s *Service) GetByMerchantIDs(ctx context.Context, merchantID int64, merchantOrderUUID uuid.UUID) (*Payment, error) {
-	p, err := s.repo.GetPaymentByMerchantIDs(ctx, repository.GetPaymentByMerchantIDsParams{
+	p, err := s.store.GetPaymentByMerchantIDs(ctx, repository.GetPaymentByMerchantIDsParams{
 		MerchantID:        merchantID,
 		MerchantOrderUuid: merchantOrderUUID,
 	})
@@ -234,7 +237,7 @@ func (s *Service) List(ctx context.Context, merchantID int64, opts ListOptions)
 			fromID = cursorPayment.ID
 		}
 
-		results, err = s.repo.PaginatePaymentsDesc(ctx, repository.PaginatePaymentsDescParams{
+		results, err = s.store.PaginatePaymentsDesc(ctx, repository.PaginatePaymentsDescParams{
 			MerchantID:    merchantID,
 			ID:            fromID,
 			Limit:         limit + 1,
@@ -247,7 +250,7 @@ func (s *Service) List(ctx context.Context, merchantID int64, opts ListOptions)
 			fromID = cursorPayment.ID
 		}
 
-		results, err = s.repo.PaginatePaymentsAsc(ctx, repository.PaginatePaymentsAscParams{
+		results, err = s.store.PaginatePaymentsAsc(ctx, repository.PaginatePaymentsAscParams{
 			MerchantID:    merchantID,
 			ID:            fromID,
 			Limit:         limit + 1,
@@ -402,7 +405,7 @@ func (s *Service) GetBatchExpired(ctx context.Context, limit int64) ([]*Payment,
 		lim = limitDefault
 	}
 
-	results, err := s.repo.GetBatchExpiredPayments(ctx, repository.GetBatchExpiredPaymentsParams{
+	results, err := s.store.GetBatchExpiredPayments(ctx, repository.GetBatchExpiredPaymentsParams{
 		ExpiresAt: repository.TimeToNullable(time.Now()),
 		CreatedAt: time.Now().Add(-ExpirationPeriodForNotLocked),
 		Type:      string(TypePayment),
@@ -479,29 +482,40 @@ func (s *Service) CreatePayment(
 		meta = fillPaymentMetaWithLink(meta, props)
 	}
 
-	p, err := s.repo.CreatePayment(ctx, repository.CreatePaymentParams{
-		PublicID: uuid.New(),
+	var p repository.Payment
 
-		CreatedAt: now,
-		UpdatedAt: now,
-		ExpiresAt: sql.NullTime{},
+	err = s.store.RunTransaction(ctx, func(ctx context.Context, q repository.Querier) error {
+		var err error
 
-		Type:   TypePayment.String(),
-		Status: StatusPending.String(),
+		p, err = q.CreatePayment(ctx, repository.CreatePaymentParams{
+			PublicID: uuid.New(),
 
-		MerchantID:        merchantID,
-		MerchantOrderUuid: props.MerchantOrderUUID,
-		MerchantOrderID:   repository.PointerStringToNullable(props.MerchantOrderID),
+			CreatedAt: now,
+			UpdatedAt: now,
+			ExpiresAt: sql.NullTime{},
 
-		Price:    repository.BigIntToNumeric(price),
-		Decimals: int32(decimals),
-		Currency: props.Money.Ticker(),
+			Type:   TypePayment.String(),
+			Status: StatusPending.String(),
 
-		RedirectUrl: redirectURL,
+			MerchantID:        merchantID,
+			MerchantOrderUuid: props.MerchantOrderUUID,
+			MerchantOrderID:   repository.PointerStringToNullable(props.MerchantOrderID),
 
-		Description: repository.PointerStringToNullable(props.Description),
-		IsTest:      props.IsTest,
-		Metadata:    meta.ToJSONB(),
+			Price:    repository.BigIntToNumeric(price),
+			Decimals: int32(decimals),
+			Currency: props.Money.Ticker(),
+
+			RedirectUrl: redirectURL,
+
+			Description: repository.PointerStringToNullable(props.Description),
+			IsTest:      props.IsTest,
+			Metadata:    meta.ToJSONB(),
+		})
+		if err != nil {
+			return err
+		}
+
+		return enqueuePaymentEvent(ctx, q, merchantID, p.ID, webhook.EventPaymentCreated, StatusPending)
 	})
 
 	if err != nil {
@@ -540,7 +554,7 @@ func (s *Service) CreateSystemTopup(ctx context.Context, merchantID int64, props
 		meta            = Metadata{MetaInternalPayment: "system topup"}
 	)
 
-	pt, err := s.repo.CreatePayment(ctx, repository.CreatePaymentParams{
+	pt, err := s.store.CreatePayment(ctx, repository.CreatePaymentParams{
 		PublicID: uuid.New(),
 
 		CreatedAt: now,
@@ -581,6 +595,12 @@ func fillPaymentMetaWithLink(meta Metadata, p CreatePaymentProps) Metadata {
 
 type UpdateProps struct {
 	Status Status
+
+	// Expired marks failure caused by payment expiration.
+	Expired bool
+
+	// Late marks success of a payment paid after its expiration, within the grace period.
+	Late bool
 }
 
 func (s *Service) Update(ctx context.Context, merchantID, id int64, props UpdateProps) (*Payment, error) {
@@ -596,7 +616,26 @@ func (s *Service) Update(ctx context.Context, merchantID, id int64, props Update
 		update.ExpiresAt = repository.TimeToNullable(time.Now().Add(ExpirationPeriodForLocked))
 	}
 
-	pt, err := s.repo.UpdatePayment(ctx, update)
+	var pt repository.Payment
+
+	err := s.store.RunTransaction(ctx, func(ctx context.Context, q repository.Querier) error {
+		var err error
+		if pt, err = q.UpdatePayment(ctx, update); err != nil {
+			return err
+		}
+
+		if err := enqueueWebhook(ctx, q, pt.MerchantID, pt.ID, props); err != nil {
+			return err
+		}
+
+		if props.Status == StatusSuccess {
+			if err := s.useLink(ctx, q, pt); err != nil {
+				return err
+			}
+		}
+
+		return s.publishStatusUpdate(ctx, q, pt.MerchantID, pt.ID)
+	})
 
 	switch {
 	case errors.Is(err, pgx.ErrNoRows):
@@ -605,17 +644,22 @@ func (s *Service) Update(ctx context.Context, merchantID, id int64, props Update
 		return nil, err
 	}
 
-	if pt.MerchantID != 0 {
-		evt := bus.PaymentStatusUpdateEvent{
-			MerchantID: pt.MerchantID,
-			PaymentID:  pt.ID,
-		}
-		if err := s.publisher.Publish(bus.TopicPaymentStatusUpdate, evt); err != nil {
-			return nil, errors.Wrap(err, "unable to publish event")
-		}
+	return s.entryToPayment(pt)
+}
+
+// publishStatusUpdate publishes bus.TopicPaymentStatusUpdate within the
+// transaction of the status change.
+func (s *Service) publishStatusUpdate(ctx context.Context, q repository.Querier, merchantID, paymentID int64) error {
+	if merchantID == 0 {
+		return nil
 	}
 
-	return s.entryToPayment(pt)
+	evt := bus.PaymentStatusUpdateEvent{MerchantID: merchantID, PaymentID: paymentID}
+	if err := s.publisher.PublishTx(ctx, q, bus.TopicPaymentStatusUpdate, evt); err != nil {
+		return errors.Wrap(err, "unable to publish event")
+	}
+
+	return nil
 }
 
 func (s *Service) Fail(ctx context.Context, pt *Payment) error {
@@ -623,46 +667,58 @@ func (s *Service) Fail(ctx context.Context, pt *Payment) error {
 	return err
 }
 
+// Expire fails the payment that wasn't paid in time.
+func (s *Service) Expire(ctx context.Context, pt *Payment) error {
+	_, err := s.Update(ctx, pt.MerchantID, pt.ID, UpdateProps{Status: StatusFailed, Expired: true})
+	return err
+}
+
 // PartialExtensionPerFill is the per-top-up window the customer gets to
 // finish paying. Each detected fill bumps expires_at to now()+this duration,
-// hard-capped at original_expires_at + 24h by the SQL layer so dust spam
-// can't keep an invoice alive forever.
+// hard-capped at original_expires_at + merchant's top-up window by the SQL
+// layer so dust spam can't keep an invoice alive forever.
 const PartialExtensionPerFill = time.Minute * 30
 
 // MarkPartial flips a payment to StatusPartial and extends expires_at by
-// PartialExtensionPerFill from now (capped at original+24h). Idempotent —
-// safe to call on every detected partial fill.
-func (s *Service) MarkPartial(ctx context.Context, merchantID, paymentID int64) (*Payment, error) {
+// PartialExtensionPerFill from now (capped at original+topUpWindow).
+// Idempotent — safe to call on every detected partial fill.
+func (s *Service) MarkPartial(ctx context.Context, merchantID, paymentID int64, topUpWindow time.Duration) (*Payment, error) {
 	requested := time.Now().Add(PartialExtensionPerFill)
 
-	row, err := s.repo.ExtendPaymentExpiry(ctx, repository.ExtendPaymentExpiryParams{
-		ID:                 paymentID,
-		MerchantID:         merchantID,
-		Status:             string(StatusPartial),
-		UpdatedAt:          time.Now(),
-		RequestedExpiresAt: requested,
+	var row repository.ExtendPaymentExpiryRow
+
+	err := s.store.RunTransaction(ctx, func(ctx context.Context, q repository.Querier) error {
+		var err error
+
+		row, err = q.ExtendPaymentExpiry(ctx, repository.ExtendPaymentExpiryParams{
+			ID:                  paymentID,
+			MerchantID:          merchantID,
+			Status:              string(StatusPartial),
+			UpdatedAt:           time.Now(),
+			RequestedExpiresAt:  requested,
+			MaxExtensionMinutes: int32(topUpWindow / time.Minute),
+		})
+		if err != nil {
+			return err
+		}
+
+		if err := enqueueWebhook(ctx, q, merchantID, row.ID, UpdateProps{Status: StatusPartial}); err != nil {
+			return err
+		}
+
+		return s.publishStatusUpdate(ctx, q, merchantID, row.ID)
 	})
 	if err != nil {
 		return nil, errors.Wrap(err, "unable to extend partial payment expiry")
 	}
 
-	pt, err := s.GetByID(ctx, merchantID, row.ID)
-	if err != nil {
-		return nil, err
-	}
-
-	evt := bus.PaymentStatusUpdateEvent{MerchantID: merchantID, PaymentID: pt.ID}
-	if pubErr := s.publisher.Publish(bus.TopicPaymentStatusUpdate, evt); pubErr != nil {
-		s.logger.Warn().Err(pubErr).Int64("payment_id", pt.ID).
-			Msg("unable to publish partial payment event")
-	}
-
-	return pt, nil
+	return s.GetByID(ctx, merchantID, row.ID)
 }
 
 // ResolvePayment allows a merchant to manually mark a failed or underpaid payment as successful.
 // This triggers the standard webhook delivery via bus.TopicPaymentStatusUpdate.
-// For underpaid payments, it also credits the merchant's balance with the actual received amount.
+// For underpaid payments (including the ones expired by merchant's underpayment
+// policy), it also credits the merchant's balance with the actual received amount.
 func (s *Service) ResolvePayment(ctx context.Context, merchantID, paymentID int64, notes, txHash string) (*Payment, error) {
 	pt, err := s.GetByID(ctx, merchantID, paymentID)
 	if err != nil {
@@ -675,6 +731,16 @@ func (s *Service) ResolvePayment(ctx context.Context, merchantID, paymentID int6
 
 	wasUnderpaid := pt.Status == StatusUnderpaid
 
+	// Payment expired by merchant's underpayment policy is failed right away,
+	// but its transaction is credited only once it's confirmed on-chain.
+	expiredByPolicy := pt.Status == StatusFailed && pt.UnderpaymentDecision() == UnderpaymentExpired
+	if expiredByPolicy {
+		tx, txErr := s.transactions.GetLatestByPaymentID(ctx, pt.ID)
+		if txErr == nil && tx.IsInProgress() {
+			return nil, errors.Wrap(ErrValidation, "payment is not confirmed on-chain yet")
+		}
+	}
+
 	pt, err = s.Update(ctx, merchantID, pt.ID, UpdateProps{Status: StatusSuccess})
 	if err != nil {
 		return nil, errors.Wrap(err, "unable to resolve payment")
@@ -683,11 +749,14 @@ func (s *Service) ResolvePayment(ctx context.Context, merchantID, paymentID int6
 	// Credit merchant balance when resolving an underpaid payment.
 	// The automatic balance increment was skipped for completedInv transactions,
 	// so we credit the fact_amount (actual amount received on-chain) here.
-	if wasUnderpaid {
+	if wasUnderpaid || expiredByPolicy {
 		tx, txErr := s.transactions.GetLatestByPaymentID(ctx, pt.ID)
 		if txErr != nil {
 			s.logger.Error().Err(txErr).Int64("payment_id", paymentID).
 				Msg("unable to find transaction for resolved underpaid payment; balance not credited")
+		} else if expiredByPolicy && tx.Status != transaction.StatusCompletedInvalid {
+			s.logger.Warn().Int64("payment_id", paymentID).Str("transaction_status", string(tx.Status)).
+				Msg("transaction of expired underpaid payment was not completed; balance not credited")
 		} else if tx.FactAmount != nil && !tx.FactAmount.IsZero() {
 			balance, ensureErr := s.wallets.EnsureBalance(ctx, wallet.EntityTypeMerchant, merchantID, tx.Currency, tx.IsTest)
 			if ensureErr != nil {
@@ -710,6 +779,11 @@ func (s *Service) ResolvePayment(ctx context.Context, merchantID, paymentID int6
 						Str("amount", tx.FactAmount.String()).
 						Str("currency", tx.Currency.Ticker).
 						Msg("credited merchant balance for resolved underpaid payment")
+
+					if ledgerErr := s.transactions.RecordResolvedReceipt(ctx, tx); ledgerErr != nil {
+						s.logger.Error().Err(ledgerErr).Int64("payment_id", paymentID).
+							Msg("unable to record ledger journal for resolved underpaid payment")
+					}
 				}
 			}
 		}
@@ -751,24 +825,6 @@ func (s *Service) DeclinePayment(ctx context.Context, merchantID, paymentID int6
 	return pt, nil
 }
 
-func (s *Service) SetWebhookTimestamp(ctx context.Context, merchantID, id int64, sentAt time.Time) error {
-	err := s.repo.UpdatePaymentWebhookInfo(ctx, repository.UpdatePaymentWebhookInfoParams{
-		ID:            id,
-		MerchantID:    merchantID,
-		WebhookSentAt: repository.TimeToNullable(sentAt),
-		UpdatedAt:     time.Now(),
-	})
-
-	switch {
-	case errors.Is(err, pgx.ErrNoRows):
-		return ErrNotFound
-	case err != nil:
-		return err
-	}
-
-	return nil
-}
-
 func (s *Service) GetPaymentMethod(ctx context.Context, p *Payment) (*Method, error) {
 	tx, err := s.transactions.GetLatestByPaymentID(ctx, p.ID)
 
@@ -9,11 +9,12 @@ import (
 	pgx "github.com/jackc/pgx/v4"
 	"github.com/cryptolink/cryptolink/internal/db/repository"
 	"github.com/cryptolink/cryptolink/internal/util"
+	"github.com/cryptolink/cryptolink/internal/webhook"
 	"github.com/pkg/errors"
 )
 
 func (s *Service) GetCustomerByEmail(ctx context.Context, merchantID int64, email string) (*Customer, error) {
-	entry, err := s.repo.GetCustomerByEmail(ctx, repository.GetCustomerByEmailParams{
+	entry, err := s.store.GetCustomerByEmail(ctx, repository.GetCustomerByEmailParams{
 		MerchantID: merchantID,
 		Email:      repository.StringToNullable(email),
 	})
@@ -29,7 +30,7 @@ func (s *Service) GetCustomerByEmail(ctx context.Context, merchantID int64, emai
 }
 
 func (s *Service) GetCustomerByID(ctx context.Context, merchantID, id int64) (*Customer, error) {
-	entry, err := s.repo.GetCustomerByID(ctx, repository.GetCustomerByIDParams{
+	entry, err := s.store.GetCustomerByID(ctx, repository.GetCustomerByIDParams{
 		ID:         id,
 		MerchantID: merchantID,
 	})
@@ -45,7 +46,7 @@ func (s *Service) GetCustomerByID(ctx context.Context, merchantID, id int64) (*C
 }
 
 func (s *Service) GetBatchCustomers(ctx context.Context, merchantID int64, ids []int64) ([]*Customer, error) {
-	entries, err := s.repo.GetBatchCustomers(ctx, repository.GetBatchCustomersParams{
+	entries, err := s.store.GetBatchCustomers(ctx, repository.GetBatchCustomersParams{
 		MerchantID: merchantID,
 		Ids:        util.MapSlice(ids, func(i int64) int32 { return int32(i) }),
 	})
@@ -62,7 +63,7 @@ func (s *Service) GetBatchCustomers(ctx context.Context, merchantID int64, ids [
 }
 
 func (s *Service) GetCustomerByUUID(ctx context.Context, merchantID int64, id uuid.UUID) (*Customer, error) {
-	c, err := s.repo.GetCustomerByUUID(ctx, repository.GetCustomerByUUIDParams{
+	c, err := s.store.GetCustomerByUUID(ctx, repository.GetCustomerByUUIDParams{
 		MerchantID: merchantID,
 		Uuid:       id,
 	})
@@ -83,13 +84,13 @@ func (s *Service) GetCustomerDetailsByUUID(ctx context.Context, merchantID int64
 		return nil, err
 	}
 
-	successfulPayments, _ := s.repo.CalculateCustomerPayments(ctx, repository.CalculateCustomerPaymentsParams{
+	successfulPayments, _ := s.store.CalculateCustomerPayments(ctx, repository.CalculateCustomerPaymentsParams{
 		MerchantID: merchantID,
 		CustomerID: repository.Int64ToNullable(c.ID),
 		Status:     StatusSuccess.String(),
 	})
 
-	entries, err := s.repo.GetRecentCustomerPayments(ctx, repository.GetRecentCustomerPaymentsParams{
+	entries, err := s.store.GetRecentCustomerPayments(ctx, repository.GetRecentCustomerPaymentsParams{
 		MerchantID: merchantID,
 		CustomerID: repository.Int64ToNullable(c.ID),
 		Limit:      10,
@@ -146,7 +147,7 @@ func (s *Service) ListCustomers(ctx context.Context, merchantID int64, opts List
 			fromID = cursorCustomer.ID
 		}
 
-		results, err = s.repo.PaginateCustomersDesc(ctx, repository.PaginateCustomersDescParams{
+		results, err = s.store.PaginateCustomersDesc(ctx, repository.PaginateCustomersDescParams{
 			MerchantID: merchantID,
 			ID:         fromID,
 			Limit:      limit + 1,
@@ -157,7 +158,7 @@ func (s *Service) ListCustomers(ctx context.Context, merchantID int64, opts List
 			fromID = cursorCustomer.ID
 		}
 
-		results, err = s.repo.PaginateCustomersAsc(ctx, repository.PaginateCustomersAscParams{
+		results, err = s.store.PaginateCustomersAsc(ctx, repository.PaginateCustomersAscParams{
 			MerchantID: merchantID,
 			ID:         fromID,
 			Limit:      limit + 1,
@@ -186,12 +187,27 @@ func (s *Service) ListCustomers(ctx context.Context, merchantID int64, opts List
 }
 
 func (s *Service) CreateCustomer(ctx context.Context, merchantID int64, email string) (*Customer, error) {
-	entry, err := s.repo.CreateCustomer(ctx, repository.CreateCustomerParams{
-		MerchantID: merchantID,
-		Uuid:       uuid.New(),
-		CreatedAt:  time.Now(),
-		UpdatedAt:  time.Now(),
-		Email:      repository.StringToNullable(email),
+	var entry repository.Customer
+
+	err := s.store.RunTransaction(ctx, func(ctx context.Context, q repository.Querier) error {
+		var err error
+
+		entry, err = q.CreateCustomer(ctx, repository.CreateCustomerParams{
+			MerchantID: merchantID,
+			Uuid:       uuid.New(),
+			CreatedAt:  time.Now(),
+			UpdatedAt:  time.Now(),
+			Email:      repository.StringToNullable(email),
+		})
+		if err != nil {
+			return err
+		}
+
+		return webhook.EnqueueEvent(ctx, q, merchantID, webhook.EventCustomerCreated, false, webhook.CustomerData{
+			ID:        entry.Uuid.String(),
+			Email:     entry.Email.String,
+			CreatedAt: entry.CreatedAt.UTC(),
+		})
 	})
 
 	if err != nil {
@@ -203,7 +219,7 @@ func (s *Service) CreateCustomer(ctx context.Context, merchantID int64, email st
 
 // ResolveCustomerByEmail fetches Customer from DB or creates it on-the-fly.
 func (s *Service) ResolveCustomerByEmail(ctx context.Context, merchantID int64, email string) (*Customer, error) {
-	entry, err := s.repo.GetCustomerByEmail(ctx, repository.GetCustomerByEmailParams{
+	entry, err := s.store.GetCustomerByEmail(ctx, repository.GetCustomerByEmailParams{
 		Email:      repository.StringToNullable(email),
 		MerchantID: merchantID,
 	})
@@ -233,7 +249,7 @@ func (s *Service) AssignCustomerByEmail(ctx context.Context, p *Payment, email s
 		return nil, errors.Wrap(err, "unable to resolve customer by email")
 	}
 
-	err = s.repo.UpdatePaymentCustomerID(ctx, repository.UpdatePaymentCustomerIDParams{
+	err = s.store.UpdatePaymentCustomerID(ctx, repository.UpdatePaymentCustomerIDParams{
 		ID:         p.ID,
 		CustomerID: repository.Int64ToNullable(person.ID),
 	})
@@ -2,14 +2,19 @@ package payment
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgtype"
 	"github.com/jackc/pgx/v4"
 	"github.com/cryptolink/cryptolink/internal/db/repository"
 	"github.com/cryptolink/cryptolink/internal/money"
 	"github.com/cryptolink/cryptolink/internal/util"
+	"github.com/cryptolink/cryptolink/internal/webhook"
 	"github.com/pkg/errors"
 )
 
@@ -32,9 +37,18 @@ type Link struct {
 	RedirectURL    *string
 	SuccessMessage *string
 
+	// MaxUses limits successful payments of the link, nil means unlimited.
+	MaxUses *int
+	Uses    int
+
 	IsTest bool
 }
 
+// UsedUp reports whether link reached its usage limit.
+func (l *Link) UsedUp() bool {
+	return l.MaxUses != nil && l.Uses >= *l.MaxUses
+}
+
 type SuccessAction string
 
 const (
@@ -52,11 +66,13 @@ type CreateLinkProps struct {
 	RedirectURL    *string
 	SuccessMessage *string
 
+	MaxUses *int
+
 	IsTest bool
 }
 
 func (s *Service) ListPaymentLinks(ctx context.Context, merchantID int64) ([]*Link, error) {
-	entries, err := s.repo.ListPaymentLinks(ctx, repository.ListPaymentLinksParams{
+	entries, err := s.store.ListPaymentLinks(ctx, repository.ListPaymentLinksParams{
 		MerchantID: merchantID,
 		Limit:      100,
 	})
@@ -79,7 +95,7 @@ func (s *Service) ListPaymentLinks(ctx context.Context, merchantID int64) ([]*Li
 }
 
 func (s *Service) GetPaymentLinkBySlug(ctx context.Context, slug string) (*Link, error) {
-	link, err := s.repo.GetPaymentLinkBySlug(ctx, slug)
+	link, err := s.store.GetPaymentLinkBySlug(ctx, slug)
 
 	switch {
 	case errors.Is(err, pgx.ErrNoRows):
@@ -92,7 +108,7 @@ func (s *Service) GetPaymentLinkBySlug(ctx context.Context, slug string) (*Link,
 }
 
 func (s *Service) GetPaymentLinkByPublicID(ctx context.Context, merchantID int64, id uuid.UUID) (*Link, error) {
-	link, err := s.repo.GetPaymentLinkByPublicID(ctx, repository.GetPaymentLinkByPublicIDParams{
+	link, err := s.store.GetPaymentLinkByPublicID(ctx, repository.GetPaymentLinkByPublicIDParams{
 		MerchantID: merchantID,
 		Uuid:       id,
 	})
@@ -108,7 +124,7 @@ func (s *Service) GetPaymentLinkByPublicID(ctx context.Context, merchantID int64
 }
 
 func (s *Service) GetPaymentLinkByID(ctx context.Context, merchantID, id int64) (*Link, error) {
-	link, err := s.repo.GetPaymentLinkByID(ctx, repository.GetPaymentLinkByIDParams{
+	link, err := s.store.GetPaymentLinkByID(ctx, repository.GetPaymentLinkByIDParams{
 		MerchantID: merchantID,
 		ID:         id,
 	})
@@ -138,7 +154,7 @@ func (s *Service) CreatePaymentLink(ctx context.Context, merchantID int64, props
 		description = *props.Description
 	}
 
-	link, err := s.repo.CreatePaymentLink(ctx, repository.CreatePaymentLinkParams{
+	link, err := s.store.CreatePaymentLink(ctx, repository.CreatePaymentLinkParams{
 		Uuid:           uuid.New(),
 		Slug:           util.Strings.Random(8),
 		CreatedAt:      time.Now(),
@@ -153,6 +169,7 @@ func (s *Service) CreatePaymentLink(ctx context.Context, merchantID int64, props
 		RedirectUrl:    repository.PointerStringToNullable(props.RedirectURL),
 		SuccessMessage: repository.PointerStringToNullable(props.SuccessMessage),
 		IsTest:         props.IsTest,
+		MaxUses:        maxUsesToNullable(props.MaxUses),
 	})
 
 	if err != nil {
@@ -167,13 +184,17 @@ func (s *Service) DeletePaymentLinkByPublicID(ctx context.Context, merchantID in
 		return err
 	}
 
-	return s.repo.DeletePaymentLinkByPublicID(ctx, repository.DeletePaymentLinkByPublicIDParams{
+	return s.store.DeletePaymentLinkByPublicID(ctx, repository.DeletePaymentLinkByPublicIDParams{
 		MerchantID: merchantID,
 		Uuid:       id,
 	})
 }
 
 func (s *Service) CreatePaymentFromLink(ctx context.Context, link *Link) (*Payment, error) {
+	if link.UsedUp() {
+		return nil, ErrLinkUsedUp
+	}
+
 	props := CreatePaymentProps{
 		MerchantOrderUUID: uuid.New(),
 		Money:             link.Price,
@@ -203,6 +224,10 @@ func (p CreateLinkProps) validate() error {
 		return errors.Wrap(ErrLinkValidation, "price can't be zero or negative")
 	}
 
+	if p.MaxUses != nil && *p.MaxUses < 1 {
+		return errors.Wrap(ErrLinkValidation, "maxUses should be positive")
+	}
+
 	switch p.SuccessAction {
 	case SuccessActionRedirect:
 		if p.RedirectURL == nil {
@@ -228,6 +253,58 @@ func (p CreateLinkProps) validate() error {
 	return nil
 }
 
+// useLink counts successful payment of the payment link it was created from.
+// When the link reaches its usage limit, payment_link.used_up is enqueued
+// within the same transaction.
+func (s *Service) useLink(ctx context.Context, q repository.Querier, pt repository.Payment) error {
+	metadata := make(Metadata)
+	if pt.Metadata.Status == pgtype.Present {
+		if err := json.Unmarshal(pt.Metadata.Bytes, &metadata); err != nil {
+			return errors.Wrap(err, "unable to parse payment metadata")
+		}
+	}
+
+	linkID, _ := strconv.ParseInt(metadata[MetaLinkID], 10, 64)
+	if linkID == 0 {
+		return nil
+	}
+
+	link, err := q.IncrementPaymentLinkUses(ctx, repository.IncrementPaymentLinkUsesParams{
+		ID:        linkID,
+		UpdatedAt: time.Now(),
+	})
+
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		// link was deleted
+		return nil
+	case err != nil:
+		return errors.Wrap(err, "unable to count payment link use")
+	}
+
+	if !link.MaxUses.Valid || link.Uses != link.MaxUses.Int32 {
+		return nil
+	}
+
+	return webhook.EnqueueEvent(ctx, q, link.MerchantID, webhook.EventPaymentLinkUsedUp, link.IsTest, webhook.PaymentLinkUsedUpData{
+		ID:            link.Uuid.String(),
+		Name:          link.Name,
+		URL:           s.linkURL(link.Slug),
+		MaxUses:       int(link.MaxUses.Int32),
+		Uses:          int(link.Uses),
+		LastPaymentID: pt.MerchantOrderUuid.String(),
+		IsTest:        link.IsTest,
+	})
+}
+
+func maxUsesToNullable(maxUses *int) sql.NullInt32 {
+	if maxUses == nil {
+		return sql.NullInt32{}
+	}
+
+	return sql.NullInt32{Int32: int32(*maxUses), Valid: true}
+}
+
 func (s *Service) linkURL(slug string) string {
 	return fmt.Sprintf("%s/link/%s", s.basePath, slug)
 }
@@ -253,6 +330,11 @@ func (s *Service) entryToLink(link repository.PaymentLink) (*Link, error) {
 		desc = &link.Description
 	}
 
+	var maxUses *int
+	if link.MaxUses.Valid {
+		maxUses = util.Ptr(int(link.MaxUses.Int32))
+	}
+
 	return &Link{
 		ID:       link.ID,
 		PublicID: link.Uuid,
@@ -272,6 +354,9 @@ func (s *Service) entryToLink(link repository.PaymentLink) (*Link, error) {
 		RedirectURL:    repository.NullableStringToPointer(link.RedirectUrl),
 		SuccessMessage: repository.NullableStringToPointer(link.SuccessMessage),
 
+		MaxUses: maxUses,
+		Uses:    int(link.Uses),
+
 		IsTest: link.IsTest,
 	}, nil
 }
@@ -0,0 +1,57 @@
+package payment
+
+import (
+	"context"
+	"time"
+
+	"github.com/cryptolink/cryptolink/internal/db/repository"
+	"github.com/cryptolink/cryptolink/internal/service/wallet"
+	"github.com/pkg/errors"
+)
+
+const MetaUnderpaymentDecision wallet.MetaDataKey = "underpaymentDecision"
+
+// UnderpaymentDecision is the outcome of merchant's underpayment policy
+// applied to the payment.
+type UnderpaymentDecision string
+
+const (
+	// UnderpaymentWithinTolerance shortfall is within merchant's tolerance,
+	// payment is treated as fully paid.
+	UnderpaymentWithinTolerance UnderpaymentDecision = "withinTolerance"
+
+	// UnderpaymentAwaitingTopUp invoice is kept open for a top-up.
+	UnderpaymentAwaitingTopUp UnderpaymentDecision = "awaitingTopUp"
+
+	// UnderpaymentAcceptedPartial payment is completed with the received amount.
+	UnderpaymentAcceptedPartial UnderpaymentDecision = "acceptedPartial"
+
+	// UnderpaymentExpired invoice was closed without waiting for a top-up.
+	UnderpaymentExpired UnderpaymentDecision = "expired"
+
+	// UnderpaymentTopUpElapsed top-up window is over, merchant decides
+	// to accept or decline the payment.
+	UnderpaymentTopUpElapsed UnderpaymentDecision = "topUpElapsed"
+)
+
+func (d UnderpaymentDecision) String() string {
+	return string(d)
+}
+
+// UnderpaymentDecision returns applied underpayment decision or empty string.
+func (p *Payment) UnderpaymentDecision() UnderpaymentDecision {
+	return UnderpaymentDecision(p.metadata[MetaUnderpaymentDecision])
+}
+
+// SetUnderpaymentDecision records applied underpayment decision on the payment.
+func (s *Service) SetUnderpaymentDecision(ctx context.Context, merchantID, paymentID int64, decision UnderpaymentDecision) error {
+	err := s.store.SetPaymentMetadataValue(ctx, repository.SetPaymentMetadataValueParams{
+		ID:         paymentID,
+		MerchantID: merchantID,
+		Key:        string(MetaUnderpaymentDecision),
+		Value:      decision.String(),
+		UpdatedAt:  time.Now(),
+	})
+
+	return errors.Wrap(err, "unable to set underpayment decision")
+}
@@ -0,0 +1,106 @@
+package payment
+
+import (
+	"context"
+	"time"
+
+	"github.com/cryptolink/cryptolink/internal/db/repository"
+	"github.com/cryptolink/cryptolink/internal/webhook"
+	"github.com/google/uuid"
+	"github.com/jackc/pgtype"
+	"github.com/pkg/errors"
+)
+
+// WebhookEventStatusUpdate outbox event type of payment status webhooks.
+const WebhookEventStatusUpdate = "payment.status"
+
+// eventTypes returns typed webhook events of the status change.
+func (p UpdateProps) eventTypes() []string {
+	switch p.Status {
+	case StatusLocked:
+		return []string{webhook.EventPaymentMethodSelected}
+	case StatusPartial:
+		return []string{webhook.EventPaymentPartial}
+	case StatusSuccess:
+		if p.Late {
+			return []string{webhook.EventPaymentSucceeded, webhook.EventPaymentLate}
+		}
+
+		return []string{webhook.EventPaymentSucceeded}
+	case StatusUnderpaid:
+		return []string{webhook.EventPaymentUnderpaid}
+	case StatusFailed:
+		if p.Expired {
+			return []string{webhook.EventPaymentExpired}
+		}
+	}
+
+	return nil
+}
+
+// enqueueWebhook writes payment status webhook and typed events of the change
+// to the outbox, one delivery per subscribed webhook endpoint. Must be called
+// within the transaction that changes payment status so that the webhook is
+// never lost. "locked" status is internal and is not sent to merchants as
+// payment status.
+func enqueueWebhook(ctx context.Context, q repository.Querier, merchantID, paymentID int64, props UpdateProps) error {
+	if merchantID == 0 {
+		return nil
+	}
+
+	if props.Status != StatusLocked {
+		if err := enqueuePaymentEvent(ctx, q, merchantID, paymentID, WebhookEventStatusUpdate, props.Status); err != nil {
+			return err
+		}
+	}
+
+	for _, eventType := range props.eventTypes() {
+		if err := enqueuePaymentEvent(ctx, q, merchantID, paymentID, eventType, props.Status); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// enqueuePaymentEvent writes payment event to the outbox and typed events to
+// the merchant event log as well. Payload is rendered by the webhook service
+// on the first attempt.
+func enqueuePaymentEvent(ctx context.Context, q repository.Querier, merchantID, paymentID int64, eventType string, status Status) error {
+	var (
+		eventID uuid.NullUUID
+		now     = time.Now().UTC()
+	)
+
+	if eventType != WebhookEventStatusUpdate {
+		eventID = uuid.NullUUID{UUID: uuid.New(), Valid: true}
+	}
+
+	_, err := q.CreateWebhookDeliveries(ctx, repository.CreateWebhookDeliveriesParams{
+		MerchantID:    merchantID,
+		PaymentID:     paymentID,
+		EventType:     eventType,
+		EventID:       eventID,
+		PaymentStatus: status.String(),
+		CreatedAt:     now,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "unable to enqueue %s webhook", eventType)
+	}
+
+	if !eventID.Valid {
+		return nil
+	}
+
+	err = q.CreateMerchantEvent(ctx, repository.CreateMerchantEventParams{
+		MerchantID:    merchantID,
+		EventID:       eventID.UUID,
+		EventType:     eventType,
+		PaymentID:     paymentID,
+		PaymentStatus: status.String(),
+		Payload:       pgtype.JSONB{Status: pgtype.Null},
+		CreatedAt:     now,
+	})
+
+	return errors.Wrapf(err, "unable to log %s event", eventType)
+}
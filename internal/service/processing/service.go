@@ -143,6 +143,11 @@ type PaymentInfo struct {
 	// for the top-up portion only.
 	RemainingPaymentLink string
 
+	// Confirmations of the incoming transaction observed so far out of
+	// RequiredConfirmations; zero until the transaction is detected.
+	Confirmations         int64
+	RequiredConfirmations int64
+
 	ExpiresAt             time.Time
 	ExpirationDurationMin int64
 
@@ -238,6 +243,8 @@ func (s *Service) GetDetailedPayment(ctx context.Context, merchantID, paymentID
 			}
 		}
 
+		confirmations, requiredConfirmations := tx.Confirmations()
+
 		result.PaymentInfo = &PaymentInfo{
 			Status:           pt.PublicStatus(),
 			PaymentLink:      paymentLink,
@@ -251,6 +258,9 @@ func (s *Service) GetDetailedPayment(ctx context.Context, merchantID, paymentID
 			RemainingAmount:      remainingFormatted,
 			RemainingPaymentLink: remainingLink,
 
+			Confirmations:         confirmations,
+			RequiredConfirmations: requiredConfirmations,
+
 			ExpiresAt:             expiresAt,
 			ExpirationDurationMin: pt.ExpirationDurationMin(),
 
@@ -380,10 +390,12 @@ func (s *Service) createIncomingTransaction(
 	}
 
 	cryptoAmount := conv.To
+	methodConv := conv
 
 	// Apply merchant's volatility buffer (fee markup) to the crypto amount.
 	// This increases the crypto amount the customer must send so the merchant
 	// receives the full invoice value even with minor price swings.
+	var appliedFeePercent float64
 	if mt != nil {
 		feePercent := mt.Settings().GlobalFeePercent()
 		if feePercent > 0 {
@@ -393,6 +405,8 @@ func (s *Service) createIncomingTransaction(
 				s.logger.Warn().Err(err).Float64("fee_percent", feePercent).Msg("unable to apply merchant fee markup")
 				// Fall back to original amount — don't block payment
 				cryptoAmount = conv.To
+			} else {
+				appliedFeePercent = feePercent
 			}
 		}
 	}
@@ -419,6 +433,14 @@ func (s *Service) createIncomingTransaction(
 
 	usdAmount := conv.To
 
+	// Audit trail: persisted with the transaction. Collector flow adds dust to
+	// the amount later on, so ChargedAmount is the pre-dust quote.
+	snapshots := []transaction.RateSnapshot{
+		transaction.SnapshotFromConversion(transaction.RateKindPaymentMethod, methodConv).
+			WithFeeMarkup(appliedFeePercent, cryptoAmount),
+		transaction.SnapshotFromConversion(transaction.RateKindUSDValuation, conv),
+	}
+
 	// 2. Determine recipient address.
 	// Smart contract collector for EVM/TRON chains, xpub for BTC.
 	// No fallback — merchant must have a wallet set up for the blockchain.
@@ -428,7 +450,7 @@ func (s *Service) createIncomingTransaction(
 	if s.evmCollector != nil {
 		collector, collectorErr := s.evmCollector.GetByMerchantAndBlockchain(ctx, pt.MerchantID, blockchain)
 		if collectorErr == nil && collector != nil {
-			return s.createTransactionWithCollectorAddress(ctx, pt, currency, collector, cryptoAmount, cryptoServiceFee, usdAmount)
+			return s.createTransactionWithCollectorAddress(ctx, pt, currency, collector, cryptoAmount, cryptoServiceFee, usdAmount, snapshots)
 		}
 	}
 
@@ -440,7 +462,7 @@ func (s *Service) createIncomingTransaction(
 
 	for _, w := range xpubWallets {
 		if w.Blockchain == blockchain && w.IsActive {
-			return s.createTransactionWithXpubAddress(ctx, pt, currency, w, cryptoAmount, cryptoServiceFee, usdAmount)
+			return s.createTransactionWithXpubAddress(ctx, pt, currency, w, cryptoAmount, cryptoServiceFee, usdAmount, snapshots)
 		}
 	}
 
@@ -457,6 +479,7 @@ func (s *Service) createTransactionWithXpubAddress(
 	cryptoAmount money.Money,
 	cryptoServiceFee money.Money,
 	usdAmount money.Money,
+	snapshots []transaction.RateSnapshot,
 ) (*payment.Method, error) {
 	// Get next unused address from xpub wallet
 	derivedAddr, err := s.xpubService.GetNextUnusedAddress(ctx, xpubWallet.ID)
@@ -481,6 +504,7 @@ func (s *Service) createTransactionWithXpubAddress(
 		ServiceFee:       cryptoServiceFee,
 		USDAmount:        usdAmount,
 		IsTest:           pt.IsTest,
+		RateSnapshots:    snapshots,
 	})
 
 	if err != nil {
@@ -525,6 +549,7 @@ func (s *Service) createTransactionWithCollectorAddress(
 	cryptoAmount money.Money,
 	cryptoServiceFee money.Money,
 	usdAmount money.Money,
+	snapshots []transaction.RateSnapshot,
 ) (*payment.Method, error) {
 	// Add random dust (1-999 base units) to differentiate concurrent invoices
 	// sharing the same collector address. For USDT (6 decimals) this is 0.000001–0.000999,
@@ -557,6 +582,7 @@ func (s *Service) createTransactionWithCollectorAddress(
 		ServiceFee:       cryptoServiceFee,
 		USDAmount:        usdAmount,
 		IsTest:           pt.IsTest,
+		RateSnapshots:    snapshots,
 	})
 
 	if err != nil {
@@ -7,6 +7,7 @@ import (
 	"math/big"
 
 	"github.com/cryptolink/cryptolink/internal/money"
+	"github.com/cryptolink/cryptolink/internal/service/blockchain"
 	"github.com/cryptolink/cryptolink/internal/service/email"
 	"github.com/cryptolink/cryptolink/internal/service/transaction"
 	"github.com/pkg/errors"
@@ -179,7 +180,7 @@ func (s *Service) ResolveUnmatchedCollectorPayment(ctx context.Context, p Unmatc
 	}
 
 	// 5. Value the received crypto in that fiat to drive attribution and gating.
-	detFiat, err := s.cryptoToFiatFloat(ctx, amount, fiatCode)
+	detFiat, valuation, err := s.cryptoToFiatFloat(ctx, amount, fiatCode)
 	if err != nil {
 		// Can't value the payment — fall back to the safety net so it isn't lost.
 		s.logger.Error().Err(err).
@@ -234,7 +235,7 @@ func (s *Service) ResolveUnmatchedCollectorPayment(ctx context.Context, p Unmatc
 
 	// 9. Auto-accept: re-lock the chosen invoice in the received currency and
 	//    drive it through the normal confirm flow.
-	return s.autoAcceptCrossCurrency(ctx, invoices[idx], currency, amount, networkID, p)
+	return s.autoAcceptCrossCurrency(ctx, invoices[idx], currency, amount, networkID, valuation, p)
 }
 
 // resolveUnmatchedCurrency maps the watcher's raw signal to a CryptoCurrency.
@@ -253,16 +254,18 @@ func (s *Service) resolveUnmatchedCurrency(p UnmatchedCollectorPayment) (money.C
 }
 
 // cryptoToFiatFloat values a crypto amount in the given fiat code as a float.
-func (s *Service) cryptoToFiatFloat(ctx context.Context, amount money.Money, fiatCode string) (float64, error) {
+// The underlying conversion is returned as well so it can be snapshotted.
+func (s *Service) cryptoToFiatFloat(ctx context.Context, amount money.Money, fiatCode string) (float64, blockchain.Conversion, error) {
 	fiatCur, err := money.MakeFiatCurrency(fiatCode)
 	if err != nil {
-		return 0, errors.Wrapf(err, "unknown fiat %q", fiatCode)
+		return 0, blockchain.Conversion{}, errors.Wrapf(err, "unknown fiat %q", fiatCode)
 	}
 	conv, err := s.blockchain.CryptoToFiat(ctx, amount, fiatCur)
 	if err != nil {
-		return 0, err
+		return 0, blockchain.Conversion{}, err
 	}
-	return conv.To.FiatToFloat64()
+	value, err := conv.To.FiatToFloat64()
+	return value, conv, err
 }
 
 // autoAcceptCrossCurrency re-locks the invoice in the received currency and
@@ -273,6 +276,7 @@ func (s *Service) autoAcceptCrossCurrency(
 	currency money.CryptoCurrency,
 	amount money.Money,
 	networkID string,
+	valuation blockchain.Conversion,
 	p UnmatchedCollectorPayment,
 ) error {
 	pt, err := s.payments.GetByID(ctx, inv.MerchantID, inv.EntityID)
@@ -289,9 +293,14 @@ func (s *Service) autoAcceptCrossCurrency(
 	// matching the normal lock-creation path (FiatToFiat(price, USD)). For a
 	// non-USD merchant (EUR, GBP, ...) this converts the invoice's fiat price to
 	// USD; falls back to the raw price if conversion is unavailable.
+	snapshots := []transaction.RateSnapshot{
+		transaction.SnapshotFromConversion(transaction.RateKindCrossCurrency, valuation),
+	}
+
 	usdAmount := pt.Price
 	if conv, convErr := s.blockchain.FiatToFiat(ctx, pt.Price, money.USD); convErr == nil {
 		usdAmount = conv.To
+		snapshots = append(snapshots, transaction.SnapshotFromConversion(transaction.RateKindUSDValuation, conv))
 	}
 
 	// New pending lock in the received currency, amount == exactly what arrived
@@ -305,6 +314,7 @@ func (s *Service) autoAcceptCrossCurrency(
 		ServiceFee:       zeroFee,
 		USDAmount:        usdAmount,
 		IsTest:           p.IsTest,
+		RateSnapshots:    snapshots,
 	})
 	if err != nil {
 		return errors.Wrap(err, "cross-currency: unable to create re-locked transaction")
@@ -3,13 +3,16 @@ package processing
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/cryptolink/cryptolink/internal/bus"
 	"github.com/cryptolink/cryptolink/internal/money"
 	"github.com/cryptolink/cryptolink/internal/service/blockchain"
 	"github.com/cryptolink/cryptolink/internal/service/email"
+	"github.com/cryptolink/cryptolink/internal/service/merchant"
 	"github.com/cryptolink/cryptolink/internal/service/payment"
 	"github.com/cryptolink/cryptolink/internal/service/transaction"
 	"github.com/cryptolink/cryptolink/internal/service/wallet"
@@ -73,9 +76,12 @@ func (i Input) validate() error {
 // any wallet to the same address.
 //
 // Promotion to StatusInProgress happens only when sum(confirmed fills) +
-// new transfer ≥ expected (with the standard $0.10 fiat tolerance). At that
+// new transfer ≥ expected (with merchant's underpayment tolerance). At that
 // moment the parent transaction's hash is set to the *triggering* fill's hash
 // so the existing receipt-confirmation poller drives it to completion.
+//
+// Below tolerance, merchant's underpayment policy decides whether to wait for
+// a top-up (default), accept the partial amount or close the invoice right away.
 func (s *Service) ProcessInboundTransaction(
 	ctx context.Context,
 	tx *transaction.Transaction,
@@ -98,42 +104,21 @@ func (s *Service) ProcessInboundTransaction(
 		return errors.Wrap(err, "unable to combine fills")
 	}
 
-	crosses, err := s.crossesExpected(ctx, tx, combined)
+	policy, fiat, err := s.underpaymentPolicy(ctx, tx.MerchantID)
 	if err != nil {
-		return errors.Wrap(err, "unable to evaluate combined amount vs expected")
-	}
-
-	if !crosses {
-		return s.recordPartialFill(ctx, tx, wt, input, prevConfirmed)
-	}
-
-	return s.promoteFromPartial(ctx, tx, wt, input, prevConfirmed, combined)
-}
-
-// crossesExpected returns true when `received` is at or above tx.Amount,
-// applying the standard $0.10 fiat rounding tolerance for underpayments.
-func (s *Service) crossesExpected(ctx context.Context, tx *transaction.Transaction, received money.Money) (bool, error) {
-	if received.GreaterThanOrEqual(tx.Amount) {
-		return true, nil
+		return err
 	}
 
-	// Tolerance: $0.10 in the invoice's crypto currency, mirrors
-	// determineIncomingStatus's existing rounding allowance.
-	tenCents, err := money.USD.MakeAmount("10")
+	decision, err := s.determineIncomingStatusFromCombined(ctx, tx, combined, policy, fiat)
 	if err != nil {
-		return false, err
-	}
-	conv, err := s.blockchain.FiatToCrypto(ctx, tenCents, tx.Currency)
-	if err != nil {
-		return false, err
+		return errors.Wrap(err, "unable to evaluate combined amount vs expected")
 	}
 
-	withTolerance, err := received.Add(conv.To)
-	if err != nil {
-		return false, err
+	if decision == payment.UnderpaymentAwaitingTopUp {
+		return s.recordPartialFill(ctx, tx, wt, input, prevConfirmed, policy.TopUpWindow)
 	}
 
-	return withTolerance.GreaterThanOrEqual(tx.Amount), nil
+	return s.promoteFromPartial(ctx, tx, wt, input, prevConfirmed, combined, decision)
 }
 
 // recordPartialFill stores the new transfer as a fill, flips the payment to
@@ -145,6 +130,7 @@ func (s *Service) recordPartialFill(
 	wt *wallet.Wallet,
 	input Input,
 	prevConfirmed money.Money,
+	topUpWindow time.Duration,
 ) error {
 	walletID := int64(0)
 	if wt != nil {
@@ -170,11 +156,13 @@ func (s *Service) recordPartialFill(
 		return errors.Wrap(err, "unable to record partial fill")
 	}
 
-	pt, err := s.payments.MarkPartial(ctx, tx.MerchantID, tx.EntityID)
+	pt, err := s.payments.MarkPartial(ctx, tx.MerchantID, tx.EntityID, topUpWindow)
 	if err != nil {
 		return errors.Wrap(err, "unable to mark payment partial")
 	}
 
+	s.recordUnderpaymentDecision(ctx, tx, payment.UnderpaymentAwaitingTopUp)
+
 	combined, _ := prevConfirmed.Add(input.Amount)
 	remaining, _ := tx.Amount.SubNegative(combined)
 
@@ -196,6 +184,7 @@ func (s *Service) recordPartialFill(
 // the *cumulative* fact_amount (all prior confirmed fills + this triggering
 // transfer), sets the parent's hash to this transfer's hash, and flips the
 // payment to StatusInProgress so the existing receipt poller takes over.
+// tx.Status is expected to be already set by determineIncomingStatusFromCombined.
 func (s *Service) promoteFromPartial(
 	ctx context.Context,
 	tx *transaction.Transaction,
@@ -203,11 +192,8 @@ func (s *Service) promoteFromPartial(
 	input Input,
 	prevConfirmed money.Money,
 	combined money.Money,
+	decision payment.UnderpaymentDecision,
 ) error {
-	if err := s.determineIncomingStatusFromCombined(ctx, tx, combined); err != nil {
-		return err
-	}
-
 	// Also record the triggering transfer as a fill so the audit trail is
 	// complete (every observed on-chain transfer that funded this invoice
 	// has a row in transaction_fills).
@@ -242,6 +228,27 @@ func (s *Service) promoteFromPartial(
 		walletID = wt.ID
 	}
 
+	s.recordUnderpaymentDecision(ctx, tx, decision)
+
+	// Invoice is closed by merchant's underpayment policy: fail the payment
+	// now, the receipt poller still confirms the transaction so merchant can
+	// resolve the payment later.
+	if decision == payment.UnderpaymentExpired {
+		props := payment.UpdateProps{Status: payment.StatusFailed, Expired: true}
+		if _, err := s.payments.Update(ctx, tx.MerchantID, tx.EntityID, props); err != nil {
+			return errors.Wrap(err, "unable to expire underpaid payment")
+		}
+
+		s.logger.Info().
+			Int64("transaction_id", tx.ID).
+			Int64("payment_id", tx.EntityID).
+			Str("expected_amount", tx.Amount.String()).
+			Str("combined_amount", combined.String()).
+			Msg("underpaid payment expired by merchant's policy")
+
+		return nil
+	}
+
 	if tx.Status != transaction.StatusInProgress {
 		s.logger.Warn().
 			Int64("wallet_id", walletID).
@@ -271,34 +278,55 @@ func (s *Service) promoteFromPartial(
 	return nil
 }
 
-// determineIncomingStatusFromCombined chooses StatusInProgress vs the
-// (legacy, no longer reached in normal flow) StatusInProgressInvalid based on
-// the cumulative combined amount rather than a single transfer. Kept for
-// belt-and-braces — promoteFromPartial only fires when crossesExpected is
-// already true, so this should always pick StatusInProgress.
-func (s *Service) determineIncomingStatusFromCombined(ctx context.Context, tx *transaction.Transaction, combined money.Money) error {
+// determineIncomingStatusFromCombined applies merchant's underpayment policy
+// to the cumulative combined amount rather than a single transfer and sets
+// tx.Status accordingly:
+//   - at or above expected, or within tolerance → StatusInProgress
+//   - below tolerance, accept partial → StatusInProgress with the received amount
+//   - below tolerance, expire → StatusInProgressInvalid (confirmed as completedInvalid)
+//   - below tolerance, wait → status is left intact, caller records a partial fill.
+//
+// Returns applied underpayment decision (empty when nothing is missing).
+func (s *Service) determineIncomingStatusFromCombined(
+	ctx context.Context,
+	tx *transaction.Transaction,
+	combined money.Money,
+	policy merchant.UnderpaymentPolicy,
+	fiat money.FiatCurrency,
+) (payment.UnderpaymentDecision, error) {
 	if combined.GreaterThan(tx.Amount) {
 		tx.Status = transaction.StatusInProgress
 		tx.MetaData[transaction.MetaComment] = "cumulative incoming amount is higher than expected"
-		return nil
+		return "", nil
 	}
 	if combined.Equals(tx.Amount) {
 		tx.Status = transaction.StatusInProgress
-		return nil
+		return "", nil
 	}
 
-	crosses, err := s.crossesExpected(ctx, tx, combined)
+	tolerance, err := s.underpaymentTolerance(ctx, tx, policy, fiat)
 	if err != nil {
-		return err
+		return "", err
+	}
+
+	decision, err := decideUnderpayment(tx.Amount, combined, tolerance, policy.BelowTolerance)
+	if err != nil {
+		return "", err
 	}
-	if crosses {
+
+	switch decision {
+	case payment.UnderpaymentWithinTolerance:
 		tx.Status = transaction.StatusInProgress
-		return nil
+		tx.MetaData[transaction.MetaComment] = "cumulative incoming amount is within underpayment tolerance"
+	case payment.UnderpaymentAcceptedPartial:
+		tx.Status = transaction.StatusInProgress
+		tx.MetaData[transaction.MetaComment] = "underpayment accepted by merchant's policy"
+	case payment.UnderpaymentExpired:
+		tx.Status = transaction.StatusInProgressInvalid
+		tx.MetaData[transaction.MetaErrorReason] = "cumulative incoming amount is less than expected"
 	}
 
-	tx.Status = transaction.StatusInProgressInvalid
-	tx.MetaData[transaction.MetaErrorReason] = "cumulative incoming amount is less than expected"
-	return nil
+	return decision, nil
 }
 
 func (s *Service) createUnexpectedTransaction(ctx context.Context, wt *wallet.Wallet, input Input) error {
@@ -384,7 +412,7 @@ func (s *Service) RecheckPartialFills(ctx context.Context) error {
 			// Transient RPC errors don't get past the grace-period gate
 			// because they'd resolve on the next scheduler tick.
 			if recErr != nil || receipt == nil || !receipt.IsConfirmed {
-				if mErr := s.transactions.MarkFillReorged(ctx, f.ID); mErr != nil {
+				if mErr := s.transactions.MarkFillReorged(ctx, tx, f.ID); mErr != nil {
 					s.logger.Error().Err(mErr).Int64("fill_id", f.ID).
 						Msg("recheck: failed to mark fill reorged")
 					continue
@@ -511,6 +539,8 @@ func (s *Service) checkIncomingTransaction(ctx context.Context, txID int64) erro
 	}
 
 	if !receipt.IsConfirmed {
+		s.trackConfirmations(ctx, tx, receipt)
+
 		// Timeout stuck inProgress transactions after 24h
 		if time.Since(tx.UpdatedAt) > inProgressTimeout {
 			s.logger.Warn().
@@ -530,6 +560,28 @@ func (s *Service) checkIncomingTransaction(ctx context.Context, txID int64) erro
 	return s.confirmIncomingTransaction(ctx, tx, receipt)
 }
 
+// trackConfirmations records confirmations of in-progress payment transaction
+// and notifies payment page about the progress.
+func (s *Service) trackConfirmations(ctx context.Context, tx *transaction.Transaction, receipt *blockchain.TransactionReceipt) {
+	if tx.MerchantID == transaction.SystemMerchantID || tx.EntityID == 0 {
+		return
+	}
+
+	if confirmations, _ := tx.Confirmations(); confirmations == receipt.Confirmations {
+		return
+	}
+
+	if err := s.transactions.SetConfirmations(ctx, tx, receipt.Confirmations, receipt.RequiredConfirmations); err != nil {
+		s.logger.Warn().Err(err).Int64("transaction_id", tx.ID).Msg("unable to track confirmations")
+		return
+	}
+
+	evt := bus.PaymentProgressEvent{MerchantID: tx.MerchantID, PaymentID: tx.EntityID}
+	if err := s.publisher.Publish(bus.TopicPaymentProgress, evt); err != nil {
+		s.logger.Warn().Err(err).Int64("payment_id", tx.EntityID).Msg("unable to publish payment progress")
+	}
+}
+
 func (s *Service) confirmIncomingTransaction(
 	ctx context.Context,
 	tx *transaction.Transaction,
@@ -546,6 +598,13 @@ func (s *Service) confirmIncomingTransaction(
 		setPaymentStatus = payment.StatusUnderpaid
 	}
 
+	if tx.MetaData == nil {
+		tx.MetaData = make(transaction.MetaData)
+	}
+
+	tx.MetaData[transaction.MetaConfirmations] = strconv.FormatInt(receipt.Confirmations, 10)
+	tx.MetaData[transaction.MetaRequiredConfirmations] = strconv.FormatInt(receipt.RequiredConfirmations, 10)
+
 	confirmation := transaction.ConfirmTransaction{
 		Status:          setTXStatus,
 		SenderAddress:   *tx.SenderAddress,
@@ -578,7 +637,21 @@ func (s *Service) confirmIncomingTransaction(
 		return errors.Wrap(err, "unable to get payment")
 	}
 
-	pt, err = s.payments.Update(ctx, tx.MerchantID, pt.ID, payment.UpdateProps{Status: setPaymentStatus})
+	// Payment was already closed by merchant's underpayment policy.
+	if pt.UnderpaymentDecision() == payment.UnderpaymentExpired {
+		s.logger.Info().
+			Int64("transaction_id", tx.ID).
+			Int64("payment_id", paymentID).
+			Str("payment_status", string(pt.Status)).
+			Msg("confirmed transaction of payment expired by underpayment policy")
+
+		return nil
+	}
+
+	// Funds that arrived during the grace period make the payment late.
+	late := setPaymentStatus == payment.StatusSuccess && pt.ExpiresAt != nil && time.Now().After(*pt.ExpiresAt)
+
+	pt, err = s.payments.Update(ctx, tx.MerchantID, pt.ID, payment.UpdateProps{Status: setPaymentStatus, Late: late})
 	if err != nil {
 		return errors.Wrap(err, "unable to update payment")
 	}
@@ -767,6 +840,7 @@ func (s *Service) sendConfirmationEmails(ctx context.Context, tx *transaction.Tr
 		ExplorerLink:  explorerLink,
 		Network:       tx.Currency.BlockchainName,
 		ReceivedAt:    tx.CreatedAt,
+		Branding:      email.Branding(s.merchants.Branding(mt)),
 	})
 }
 
@@ -914,6 +988,7 @@ func (s *Service) expirePayment(ctx context.Context, paymentID int64) error {
 			if _, updErr := s.payments.Update(ctx, pt.MerchantID, pt.ID, payment.UpdateProps{Status: payment.StatusUnderpaid}); updErr != nil {
 				return errors.Wrap(updErr, "unable to mark partial payment as underpaid on expiry")
 			}
+			s.recordUnderpaymentDecision(ctx, tx, payment.UnderpaymentTopUpElapsed)
 			s.logger.Info().Int64("payment_id", paymentID).Str("received", confirmedSum.String()).
 				Msg("partial payment expired with confirmed fills — flipped to underpaid for merchant review")
 			return nil
@@ -925,7 +1000,7 @@ func (s *Service) expirePayment(ctx context.Context, paymentID int64) error {
 				return errors.Wrap(errCancel, "unable to cancel partial-zero transaction")
 			}
 		}
-		if errFail := s.payments.Fail(ctx, pt); errFail != nil {
+		if errFail := s.payments.Expire(ctx, pt); errFail != nil {
 			return errors.Wrap(errFail, "unable to fail partial-zero payment")
 		}
 		s.logger.Info().Int64("payment_id", paymentID).
@@ -942,7 +1017,7 @@ func (s *Service) expirePayment(ctx context.Context, paymentID int64) error {
 	}
 
 	// 5. Cancel payment itself
-	if errFail := s.payments.Fail(ctx, pt); errFail != nil {
+	if errFail := s.payments.Expire(ctx, pt); errFail != nil {
 		return errors.Wrap(errFail, "unable to expire payment")
 	}
 
@@ -132,7 +132,9 @@ func TestService_BatchCheckIncomingTransactions(t *testing.T) {
 	}
 
 	assertUpdateStatusEventSent := func(t *testing.T, sent bool) {
-		calls := tc.Fakes.GetBusCalls()
+		calls := lo.Filter(tc.Fakes.GetBusCalls(), func(call lo.Tuple2[bus.Topic, any], _ int) bool {
+			return call.A == bus.TopicPaymentStatusUpdate
+		})
 		if sent {
 			require.Len(t, calls, 1)
 			assert.Equal(t, bus.TopicPaymentStatusUpdate, calls[0].A)
@@ -411,9 +413,13 @@ func TestService_BatchCheckIncomingTransactions(t *testing.T) {
 				assert.Equal(t, payment.StatusInProgress, pt.Status)
 				assert.Equal(t, transaction.StatusInProgress, tx.Status)
 
+				confirmations, _ := tx.Confirmations()
+				assert.Equal(t, int64(1), confirmations)
+
 				tc.AssertTableRows(t, "wallet_locks", 0)
 
 				assertUpdateStatusEventSent(t, false)
+				assert.Equal(t, bus.TopicPaymentProgress, tc.Fakes.GetBusCalls()[0].A)
 			},
 		},
 		{
@@ -429,9 +435,13 @@ func TestService_BatchCheckIncomingTransactions(t *testing.T) {
 				assert.Equal(t, payment.StatusInProgress, pt.Status)
 				assert.Equal(t, transaction.StatusInProgress, tx.Status)
 
+				confirmations, _ := tx.Confirmations()
+				assert.Equal(t, int64(1), confirmations)
+
 				tc.AssertTableRows(t, "wallet_locks", 0)
 
 				assertUpdateStatusEventSent(t, false)
+				assert.Equal(t, bus.TopicPaymentProgress, tc.Fakes.GetBusCalls()[0].A)
 			},
 		},
 		{
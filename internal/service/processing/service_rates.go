@@ -0,0 +1,19 @@
+package processing
+
+import (
+	"context"
+	"time"
+
+	"github.com/cryptolink/cryptolink/internal/service/transaction"
+)
+
+// ListRateSnapshots returns the exchange-rate audit trail of a payment: every
+// conversion used to price any of its transactions (including superseded locks).
+func (s *Service) ListRateSnapshots(ctx context.Context, merchantID, paymentID int64) ([]transaction.RateSnapshot, error) {
+	return s.transactions.ListRateSnapshots(ctx, merchantID, paymentID)
+}
+
+// ListRateSnapshotsInRange returns merchant's exchange-rate audit trail for [from, to).
+func (s *Service) ListRateSnapshotsInRange(ctx context.Context, merchantID int64, from, to time.Time) ([]transaction.RateSnapshot, error) {
+	return s.transactions.ListRateSnapshotsInRange(ctx, merchantID, from, to)
+}
@@ -0,0 +1,114 @@
+package processing
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/cryptolink/cryptolink/internal/money"
+	"github.com/cryptolink/cryptolink/internal/service/merchant"
+	"github.com/cryptolink/cryptolink/internal/service/payment"
+	"github.com/cryptolink/cryptolink/internal/service/transaction"
+	"github.com/pkg/errors"
+)
+
+// underpaymentPolicy returns merchant's underpayment policy and fiat currency
+// the fiat tolerance is expressed in.
+func (s *Service) underpaymentPolicy(ctx context.Context, merchantID int64) (merchant.UnderpaymentPolicy, money.FiatCurrency, error) {
+	mt, err := s.merchants.GetByID(ctx, merchantID, false)
+	if err != nil {
+		return merchant.UnderpaymentPolicy{}, "", errors.Wrap(err, "unable to get merchant")
+	}
+
+	fiat, err := money.MakeFiatCurrency(mt.Settings().FiatCurrency())
+	if err != nil {
+		fiat = money.USD
+	}
+
+	return mt.Settings().UnderpaymentPolicy(), fiat, nil
+}
+
+// underpaymentTolerance returns the larger of policy's percent and fiat
+// tolerances expressed in invoice's crypto currency.
+func (s *Service) underpaymentTolerance(
+	ctx context.Context,
+	tx *transaction.Transaction,
+	policy merchant.UnderpaymentPolicy,
+	fiat money.FiatCurrency,
+) (money.Money, error) {
+	tolerance, err := money.NewFromBigInt(tx.Amount.Type(), tx.Amount.Ticker(), big.NewInt(0), tx.Amount.Decimals())
+	if err != nil {
+		return money.Money{}, err
+	}
+
+	if policy.TolerancePercent.IsPositive() {
+		tolerance, err = tx.Amount.Percent(policy.TolerancePercent)
+		if err != nil {
+			return money.Money{}, errors.Wrap(err, "unable to calculate percent tolerance")
+		}
+	}
+
+	if !policy.ToleranceFiat.IsPositive() {
+		return tolerance, nil
+	}
+
+	fiatTolerance, err := money.FiatFromFloat64(fiat, policy.ToleranceFiat.InexactFloat64())
+	if err != nil {
+		return money.Money{}, errors.Wrap(err, "unable to make fiat tolerance")
+	}
+
+	conv, err := s.blockchain.FiatToCrypto(ctx, fiatTolerance, tx.Currency)
+	if err != nil {
+		return money.Money{}, errors.Wrap(err, "unable to convert fiat tolerance")
+	}
+
+	if conv.To.GreaterThan(tolerance) {
+		return conv.To, nil
+	}
+
+	return tolerance, nil
+}
+
+// decideUnderpayment picks underpayment decision for the received amount.
+// Returns empty decision when nothing is missing.
+func decideUnderpayment(
+	expected, received, tolerance money.Money,
+	action merchant.UnderpaymentAction,
+) (payment.UnderpaymentDecision, error) {
+	if received.GreaterThanOrEqual(expected) {
+		return "", nil
+	}
+
+	withTolerance, err := received.Add(tolerance)
+	if err != nil {
+		return "", err
+	}
+
+	if withTolerance.GreaterThanOrEqual(expected) {
+		return payment.UnderpaymentWithinTolerance, nil
+	}
+
+	switch action {
+	case merchant.UnderpaymentAcceptPartial:
+		return payment.UnderpaymentAcceptedPartial, nil
+	case merchant.UnderpaymentExpire:
+		return payment.UnderpaymentExpired, nil
+	default:
+		return payment.UnderpaymentAwaitingTopUp, nil
+	}
+}
+
+// recordUnderpaymentDecision stores the decision on the payment. Best-effort:
+// the decision is informational and must not block processing.
+func (s *Service) recordUnderpaymentDecision(ctx context.Context, tx *transaction.Transaction, decision payment.UnderpaymentDecision) {
+	if decision == "" {
+		return
+	}
+
+	if err := s.payments.SetUnderpaymentDecision(ctx, tx.MerchantID, tx.EntityID, decision); err != nil {
+		s.logger.Error().Err(err).
+			Int64("transaction_id", tx.ID).
+			Int64("payment_id", tx.EntityID).
+			Str("decision", decision.String()).
+			Msg("unable to record underpayment decision")
+	}
+}
@@ -0,0 +1,64 @@
+package processing
+
+import (
+	"testing"
+
+	"github.com/cryptolink/cryptolink/internal/money"
+	"github.com/cryptolink/cryptolink/internal/service/merchant"
+	"github.com/cryptolink/cryptolink/internal/service/payment"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecideUnderpayment(t *testing.T) {
+	usdt := func(raw string) money.Money {
+		return money.MustCryptoFromRaw("ETH_USDT", raw, 6)
+	}
+
+	expected := usdt("100000000") // 100 USDT
+
+	for _, tt := range []struct {
+		name      string
+		received  money.Money
+		tolerance money.Money
+		action    merchant.UnderpaymentAction
+		decision  payment.UnderpaymentDecision
+	}{
+		{name: "exact", received: expected, tolerance: usdt("0"), action: merchant.UnderpaymentWait},
+		{name: "overpaid", received: usdt("100500000"), tolerance: usdt("0"), action: merchant.UnderpaymentExpire},
+		{
+			name:      "within tolerance",
+			received:  usdt("99900000"),
+			tolerance: usdt("100000"),
+			action:    merchant.UnderpaymentExpire,
+			decision:  payment.UnderpaymentWithinTolerance,
+		},
+		{
+			name:      "below tolerance waits",
+			received:  usdt("99800000"),
+			tolerance: usdt("100000"),
+			action:    merchant.UnderpaymentWait,
+			decision:  payment.UnderpaymentAwaitingTopUp,
+		},
+		{
+			name:      "below tolerance accepts partial",
+			received:  usdt("50000000"),
+			tolerance: usdt("100000"),
+			action:    merchant.UnderpaymentAcceptPartial,
+			decision:  payment.UnderpaymentAcceptedPartial,
+		},
+		{
+			name:      "below tolerance expires",
+			received:  usdt("50000000"),
+			tolerance: usdt("0"),
+			action:    merchant.UnderpaymentExpire,
+			decision:  payment.UnderpaymentExpired,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			decision, err := decideUnderpayment(expected, tt.received, tt.tolerance, tt.action)
+			require.NoError(t, err)
+			assert.Equal(t, tt.decision, decision)
+		})
+	}
+}
@@ -0,0 +1,249 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cryptolink/cryptolink/internal/service/email"
+	"github.com/cryptolink/cryptolink/internal/service/ledger"
+	"github.com/cryptolink/cryptolink/internal/service/merchant"
+	"github.com/cryptolink/cryptolink/internal/service/payment"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+const (
+	topLinksLimit  = 5
+	balanceTimeout = 10 * time.Second
+)
+
+// build gathers report of merchant's live (non-test) activity within the period.
+func (s *Service) build(ctx context.Context, mt *merchant.Merchant, period Period) (email.SettlementReportParams, error) {
+	settings := mt.Settings()
+
+	params := email.SettlementReportParams{
+		MerchantName: mt.Name,
+		Frequency:    string(period.Frequency),
+		Period:       period.Label(),
+		Timezone:     settings.Timezone(),
+		FiatCode:     settings.FiatCurrency(),
+	}
+
+	if err := s.paymentCounts(ctx, mt.ID, period, &params); err != nil {
+		return params, err
+	}
+
+	r := ledger.Range{MerchantID: mt.ID, From: period.Start.UTC(), To: period.End.UTC()}
+
+	if err := s.volumes(ctx, r, &params); err != nil {
+		return params, err
+	}
+
+	links, err := s.topLinks(ctx, mt.ID, period)
+	if err != nil {
+		return params, err
+	}
+
+	params.TopLinks = links
+	params.Collectors = s.collectorBalances(ctx, mt.ID)
+
+	var csv bytes.Buffer
+	if err := s.ledger.Export(ctx, &csv, r, ledger.ExportJournal, ledger.NewAccountNames(settings.AccountingAccounts())); err != nil {
+		return params, errors.Wrap(err, "unable to export ledger")
+	}
+
+	params.Attachments = []email.Attachment{{
+		Filename:    attachmentName(period),
+		ContentType: "text/csv",
+		Data:        csv.Bytes(),
+	}}
+
+	return params, nil
+}
+
+// paymentCounts counts payments created within the period. Underpaid includes
+// payments that were closed or accepted by underpayment policy.
+func (s *Service) paymentCounts(ctx context.Context, merchantID int64, period Period, params *email.SettlementReportParams) error {
+	err := s.db.QueryRow(ctx, `
+		SELECT
+			count(*),
+			count(*) FILTER (WHERE status = $4),
+			count(*) FILTER (WHERE status = $5),
+			count(*) FILTER (WHERE status = $6 OR metadata->>$8 IN ($9, $10, $11)),
+			count(*) FILTER (WHERE status = $7 AND expires_at IS NOT NULL AND expires_at <= updated_at)
+		FROM payments
+		WHERE merchant_id = $1 AND type = $12 AND is_test = false
+		  AND created_at >= $2 AND created_at < $3
+	`,
+		merchantID,
+		period.Start.UTC(),
+		period.End.UTC(),
+		payment.StatusSuccess.String(),
+		payment.StatusPartial.String(),
+		payment.StatusUnderpaid.String(),
+		payment.StatusFailed.String(),
+		string(payment.MetaUnderpaymentDecision),
+		payment.UnderpaymentExpired.String(),
+		payment.UnderpaymentTopUpElapsed.String(),
+		payment.UnderpaymentAcceptedPartial.String(),
+		payment.TypePayment.String(),
+	).Scan(&params.Payments, &params.Succeeded, &params.Partial, &params.Underpaid, &params.Expired)
+
+	return errors.Wrap(err, "unable to count payments")
+}
+
+// volumes sums realized sales and overpayments per currency. Fiat values
+// recorded in another fiat currency (merchant changed it) are left out.
+func (s *Service) volumes(ctx context.Context, r ledger.Range, params *email.SettlementReportParams) error {
+	totals, err := s.ledger.Totals(ctx, r)
+	if err != nil {
+		return err
+	}
+
+	type volume struct {
+		amount decimal.Decimal
+		fiat   decimal.Decimal
+	}
+
+	byTicker := make(map[string]*volume)
+	gross := decimal.Zero
+
+	for _, t := range totals {
+		if t.EventType != ledger.EventReceipt && t.EventType != ledger.EventOverpayment {
+			continue
+		}
+
+		v, ok := byTicker[t.Ticker]
+		if !ok {
+			v = &volume{}
+			byTicker[t.Ticker] = v
+		}
+
+		v.amount = v.amount.Add(t.Amount)
+
+		if t.FiatCurrency == params.FiatCode {
+			v.fiat = v.fiat.Add(t.FiatAmount)
+			gross = gross.Add(t.FiatAmount)
+		}
+	}
+
+	tickers := make([]string, 0, len(byTicker))
+	for ticker := range byTicker {
+		tickers = append(tickers, ticker)
+	}
+	sort.Strings(tickers)
+
+	for _, ticker := range tickers {
+		params.Volumes = append(params.Volumes, email.ReportVolume{
+			Ticker: ticker,
+			Amount: byTicker[ticker].amount.String(),
+			Fiat:   byTicker[ticker].fiat.StringFixed(2),
+		})
+	}
+
+	params.GrossFiat = gross.StringFixed(2)
+
+	return nil
+}
+
+// topLinks returns payment links with most successful payments within the period.
+func (s *Service) topLinks(ctx context.Context, merchantID int64, period Period) ([]email.ReportLink, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT l.name, count(*), sum(p.price)::text, p.decimals, p.currency
+		FROM payments p
+		JOIN payment_links l ON l.merchant_id = p.merchant_id AND p.metadata->>$4 = l.id::text
+		WHERE p.merchant_id = $1 AND p.type = $5 AND p.is_test = false AND p.status = $6
+		  AND p.created_at >= $2 AND p.created_at < $3
+		GROUP BY l.id, l.name, p.decimals, p.currency
+		ORDER BY count(*) DESC, l.id
+		LIMIT $7
+	`,
+		merchantID,
+		period.Start.UTC(),
+		period.End.UTC(),
+		string(payment.MetaLinkID),
+		payment.TypePayment.String(),
+		payment.StatusSuccess.String(),
+		topLinksLimit,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to list top payment links")
+	}
+	defer rows.Close()
+
+	var links []email.ReportLink
+	for rows.Next() {
+		var (
+			link     email.ReportLink
+			sum      string
+			decimals int32
+			currency string
+		)
+
+		if err := rows.Scan(&link.Name, &link.Payments, &sum, &decimals, &currency); err != nil {
+			return nil, err
+		}
+
+		if v, err := decimal.NewFromString(sum); err == nil {
+			link.Volume = fmt.Sprintf("%s %s", v.Shift(-decimals).StringFixed(decimals), currency)
+		}
+
+		links = append(links, link)
+	}
+
+	return links, rows.Err()
+}
+
+// collectorBalances fetches current on-chain balances of merchant's collectors.
+// Unreachable chains are reported as unavailable rather than failing the report.
+func (s *Service) collectorBalances(ctx context.Context, merchantID int64) []email.ReportCollectorBalance {
+	collectors, err := s.collectors.ListByMerchantID(ctx, merchantID)
+	if err != nil {
+		s.logger.Error().Err(err).Int64("merchant_id", merchantID).Msg("unable to list collectors for report")
+		return nil
+	}
+
+	results := make([]email.ReportCollectorBalance, 0, len(collectors))
+
+	for _, c := range collectors {
+		item := email.ReportCollectorBalance{
+			Blockchain: c.Blockchain,
+			Address:    c.ContractAddress,
+			Balances:   "unavailable",
+		}
+
+		fetchCtx, cancel := context.WithTimeout(ctx, balanceTimeout)
+		balance, err := s.collectors.FetchBalance(fetchCtx, c.Blockchain, c.ContractAddress)
+		cancel()
+
+		if err != nil {
+			s.logger.Warn().Err(err).Str("collector", c.ContractAddress).Msg("unable to fetch collector balance for report")
+		} else {
+			parts := []string{balance.NativeAmount + " " + balance.NativeTicker}
+			for _, token := range balance.Tokens {
+				if v, err := decimal.NewFromString(token.Amount); err == nil && v.IsZero() {
+					continue
+				}
+				parts = append(parts, token.Amount+" "+token.Ticker)
+			}
+			item.Balances = strings.Join(parts, ", ")
+		}
+
+		results = append(results, item)
+	}
+
+	return results
+}
+
+func attachmentName(period Period) string {
+	last := period.End.AddDate(0, 0, -1).Format(periodDateLayout)
+	if period.Frequency == merchant.ReportDaily {
+		return fmt.Sprintf("cryptolink-journal-%s.csv", last)
+	}
+
+	return fmt.Sprintf("cryptolink-journal-%s_%s.csv", period.Start.Format(periodDateLayout), last)
+}
@@ -0,0 +1,44 @@
+package report
+
+import (
+	"time"
+
+	"github.com/cryptolink/cryptolink/internal/service/merchant"
+)
+
+const periodDateLayout = "2006-01-02"
+
+// Period reporting period [Start, End) bounded by midnights in merchant's timezone.
+type Period struct {
+	Frequency merchant.ReportFrequency
+	Start     time.Time
+	End       time.Time
+}
+
+// lastPeriod returns the latest period of given frequency that ended at or
+// before now in loc. Days start at local midnight, weeks start on Monday.
+func lastPeriod(now time.Time, frequency merchant.ReportFrequency, loc *time.Location) (Period, bool) {
+	local := now.In(loc)
+	end := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+
+	switch frequency {
+	case merchant.ReportDaily:
+		return Period{Frequency: frequency, Start: end.AddDate(0, 0, -1), End: end}, true
+	case merchant.ReportWeekly:
+		// Sunday is 0, shift so that Monday is the first day of week
+		end = end.AddDate(0, 0, -((int(end.Weekday()) + 6) % 7))
+		return Period{Frequency: frequency, Start: end.AddDate(0, 0, -7), End: end}, true
+	default:
+		return Period{}, false
+	}
+}
+
+// Label human-readable period, e.g. "2026-06-01" or "2026-06-01 – 2026-06-07".
+func (p Period) Label() string {
+	last := p.End.AddDate(0, 0, -1)
+	if p.Frequency == merchant.ReportDaily {
+		return last.Format(periodDateLayout)
+	}
+
+	return p.Start.Format(periodDateLayout) + " – " + last.Format(periodDateLayout)
+}
@@ -0,0 +1,85 @@
+package report
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cryptolink/cryptolink/internal/service/merchant"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLastPeriod(t *testing.T) {
+	madrid, err := time.LoadLocation("Europe/Madrid")
+	require.NoError(t, err)
+
+	// Wednesday 2026-06-03 00:30 in Madrid, still Tuesday in UTC
+	now := time.Date(2026, 6, 2, 22, 30, 0, 0, time.UTC)
+
+	for _, tt := range []struct {
+		name      string
+		frequency merchant.ReportFrequency
+		loc       *time.Location
+		start     time.Time
+		end       time.Time
+		label     string
+	}{
+		{
+			name:      "daily in merchant's timezone",
+			frequency: merchant.ReportDaily,
+			loc:       madrid,
+			start:     time.Date(2026, 6, 2, 0, 0, 0, 0, madrid),
+			end:       time.Date(2026, 6, 3, 0, 0, 0, 0, madrid),
+			label:     "2026-06-02",
+		},
+		{
+			name:      "daily in UTC",
+			frequency: merchant.ReportDaily,
+			loc:       time.UTC,
+			start:     time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC),
+			end:       time.Date(2026, 6, 2, 0, 0, 0, 0, time.UTC),
+			label:     "2026-06-01",
+		},
+		{
+			name:      "weekly starts on monday",
+			frequency: merchant.ReportWeekly,
+			loc:       madrid,
+			start:     time.Date(2026, 5, 25, 0, 0, 0, 0, madrid),
+			end:       time.Date(2026, 6, 1, 0, 0, 0, 0, madrid),
+			label:     "2026-05-25 – 2026-05-31",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			p, ok := lastPeriod(now, tt.frequency, tt.loc)
+			require.True(t, ok)
+
+			assert.True(t, tt.start.Equal(p.Start), p.Start.String())
+			assert.True(t, tt.end.Equal(p.End), p.End.String())
+			assert.Equal(t, tt.label, p.Label())
+		})
+	}
+
+	t.Run("weekly on monday reports previous week", func(t *testing.T) {
+		monday := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+
+		p, ok := lastPeriod(monday, merchant.ReportWeekly, time.UTC)
+		require.True(t, ok)
+		assert.True(t, monday.Equal(p.End))
+	})
+
+	t.Run("off", func(t *testing.T) {
+		_, ok := lastPeriod(now, merchant.ReportOff, time.UTC)
+		assert.False(t, ok)
+	})
+}
+
+func TestLastPeriod_DST(t *testing.T) {
+	madrid, err := time.LoadLocation("Europe/Madrid")
+	require.NoError(t, err)
+
+	// clocks go forward on 2026-03-29, the day is 23 hours long
+	p, ok := lastPeriod(time.Date(2026, 3, 30, 12, 0, 0, 0, madrid), merchant.ReportDaily, madrid)
+	require.True(t, ok)
+
+	assert.Equal(t, 23*time.Hour, p.End.Sub(p.Start))
+}
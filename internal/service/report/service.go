@@ -0,0 +1,215 @@
+package report
+
+import (
+	"context"
+	"time"
+
+	"github.com/cryptolink/cryptolink/internal/service/email"
+	"github.com/cryptolink/cryptolink/internal/service/evmcollector"
+	"github.com/cryptolink/cryptolink/internal/service/ledger"
+	"github.com/cryptolink/cryptolink/internal/service/merchant"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+// Service sends merchants' scheduled settlement reports.
+type Service struct {
+	db         *pgxpool.Pool
+	merchants  *merchant.Service
+	ledger     *ledger.Service
+	collectors *evmcollector.Service
+	emails     *email.Service
+	logger     *zerolog.Logger
+}
+
+const (
+	// reportDelay gives late confirmations of the period a chance to be posted
+	// before the report is sent.
+	reportDelay = time.Hour
+
+	// maxReportAttempts how many times a failed report is retried.
+	maxReportAttempts = 3
+
+	// staleReportTimeout after which a report stuck in pending state (e.g.
+	// process crashed while sending) can be claimed again.
+	staleReportTimeout = time.Hour
+
+	statusPending = "pending"
+	statusSent    = "sent"
+	statusFailed  = "failed"
+)
+
+func New(
+	db *pgxpool.Pool,
+	merchants *merchant.Service,
+	ledgerService *ledger.Service,
+	collectors *evmcollector.Service,
+	emails *email.Service,
+	logger *zerolog.Logger,
+) *Service {
+	log := logger.With().Str("channel", "report_service").Logger()
+
+	return &Service{
+		db:         db,
+		merchants:  merchants,
+		ledger:     ledgerService,
+		collectors: collectors,
+		emails:     emails,
+		logger:     &log,
+	}
+}
+
+// SendDueReports sends reports of the last completed period to every merchant
+// that opted in. Each merchant's period is computed in its own timezone; a
+// report is sent once per period, failed ones are retried on later runs.
+func (s *Service) SendDueReports(ctx context.Context) error {
+	merchantIDs, err := s.listSubscribedMerchants(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().Add(-reportDelay)
+
+	for _, merchantID := range merchantIDs {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if err := s.sendDueReport(ctx, merchantID, now); err != nil {
+			s.logger.Error().Err(err).Int64("merchant_id", merchantID).Msg("unable to send settlement report")
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) sendDueReport(ctx context.Context, merchantID int64, now time.Time) error {
+	mt, err := s.merchants.GetByID(ctx, merchantID, false)
+	if err != nil {
+		return errors.Wrap(err, "unable to get merchant")
+	}
+
+	settings := mt.Settings()
+
+	period, ok := lastPeriod(now, settings.ReportFrequency(), settings.Location())
+	if !ok {
+		return nil
+	}
+
+	reportID, claimed, err := s.claim(ctx, merchantID, period)
+	if err != nil || !claimed {
+		return err
+	}
+
+	sendErr := s.send(ctx, mt, period)
+
+	if err := s.markReport(ctx, reportID, sendErr); err != nil {
+		return err
+	}
+
+	if sendErr == nil {
+		s.logger.Info().
+			Int64("merchant_id", merchantID).
+			Str("frequency", string(period.Frequency)).
+			Str("period", period.Label()).
+			Msg("sent settlement report")
+	}
+
+	return sendErr
+}
+
+func (s *Service) send(ctx context.Context, mt *merchant.Merchant, period Period) error {
+	to, err := s.emails.GetMerchantEmail(ctx, mt.ID)
+	if err != nil {
+		return err
+	}
+
+	params, err := s.build(ctx, mt, period)
+	if err != nil {
+		return err
+	}
+
+	params.MerchantEmail = to
+
+	return s.emails.SendSettlementReport(ctx, params)
+}
+
+func (s *Service) listSubscribedMerchants(ctx context.Context) ([]int64, error) {
+	rows, err := s.db.Query(ctx, `
+		SELECT id FROM merchants
+		WHERE deleted_at IS NULL AND settings->>$1 IN ($2, $3)
+		ORDER BY id
+	`, merchant.PropertyReportFrequency, string(merchant.ReportDaily), string(merchant.ReportWeekly))
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to list merchants with reports")
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+// claim reserves merchant's report of the period. Returns false if the report
+// was already sent, is being sent by another worker or ran out of attempts.
+func (s *Service) claim(ctx context.Context, merchantID int64, period Period) (int64, bool, error) {
+	now := time.Now().UTC()
+
+	var id int64
+	err := s.db.QueryRow(ctx, `
+		INSERT INTO settlement_reports (merchant_id, frequency, period_start, period_end, status, attempts, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, 1, $6, $6)
+		ON CONFLICT (merchant_id, frequency, period_start) DO UPDATE
+		SET status = $5, attempts = settlement_reports.attempts + 1, updated_at = $6
+		WHERE settlement_reports.attempts < $7 AND (
+			settlement_reports.status = $8 OR
+			(settlement_reports.status = $5 AND settlement_reports.updated_at < $9)
+		)
+		RETURNING id
+	`,
+		merchantID,
+		string(period.Frequency),
+		period.Start.Format(periodDateLayout),
+		period.End.Format(periodDateLayout),
+		statusPending,
+		now,
+		maxReportAttempts,
+		statusFailed,
+		now.Add(-staleReportTimeout),
+	).Scan(&id)
+
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return 0, false, nil
+	case err != nil:
+		return 0, false, errors.Wrap(err, "unable to claim settlement report")
+	}
+
+	return id, true, nil
+}
+
+func (s *Service) markReport(ctx context.Context, id int64, sendErr error) error {
+	now := time.Now().UTC()
+
+	var err error
+	if sendErr == nil {
+		_, err = s.db.Exec(ctx, `
+			UPDATE settlement_reports SET status = $2, error = NULL, sent_at = $3, updated_at = $3 WHERE id = $1
+		`, id, statusSent, now)
+	} else {
+		_, err = s.db.Exec(ctx, `
+			UPDATE settlement_reports SET status = $2, error = $3, updated_at = $4 WHERE id = $1
+		`, id, statusFailed, sendErr.Error(), now)
+	}
+
+	return errors.Wrap(err, "unable to update settlement report")
+}
@@ -690,6 +690,7 @@ func (s *Service) CheckMerchantLimit(ctx context.Context, userID int64, currentC
 //   - derived_addresses: xpub-derived addresses (keys)
 //   - xpub_wallets: HD wallet extended public keys
 //   - evm_collector_wallets: smart contract addresses
+//   - evm_collector_withdrawals: cascades automatically via FK
 //   - usage_tracking: cascades automatically via FK
 func (s *Service) adminCleanMerchantData(ctx context.Context, merchantID int64) error {
 	// Order matters: child tables before parent tables to respect FK constraints
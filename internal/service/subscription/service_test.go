@@ -0,0 +1,56 @@
+package subscription_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cryptolink/cryptolink/internal/test"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_AdminDeleteMerchant(t *testing.T) {
+	tc := test.NewIntegrationTest(t)
+	pool := tc.Database.Conn().Pool
+
+	t.Run("deletes merchant with indexed collector withdrawals", func(t *testing.T) {
+		// ARRANGE
+		// Given a merchant
+		u, _ := tc.Must.CreateSampleUser(t)
+		mt, _ := tc.Must.CreateMerchant(t, u.ID)
+
+		// And its collector with an indexed withdrawal
+		now := time.Now().UTC().Truncate(time.Second)
+
+		var collectorID int64
+		err := pool.QueryRow(tc.Context, `
+			INSERT INTO evm_collector_wallets
+				(uuid, merchant_id, blockchain, chain_id, contract_address, owner_address, factory_address, created_at, updated_at)
+			VALUES ($1, $2, 'ETH', 1, '0x1111111111111111111111111111111111111111',
+				'0x2222222222222222222222222222222222222222', '0x3333333333333333333333333333333333333333', $3, $3)
+			RETURNING id
+		`, uuid.New(), mt.ID, now).Scan(&collectorID)
+		require.NoError(t, err)
+
+		_, err = pool.Exec(tc.Context, `
+			INSERT INTO evm_collector_withdrawals
+				(collector_id, merchant_id, blockchain, ticker, decimals, to_address, amount, tx_hash, log_index, block_number, created_at)
+			VALUES ($1, $2, 'ETH', 'ETH', 18, '0x2222222222222222222222222222222222222222', 1000, $3, 0, 1, $4)
+		`, collectorID, mt.ID, "0x"+uuid.NewString(), now)
+		require.NoError(t, err)
+
+		// ACT
+		err = tc.Services.Subscriptions.AdminDeleteMerchant(tc.Context, mt.ID)
+
+		// ASSERT
+		require.NoError(t, err)
+
+		var withdrawals int
+		err = pool.QueryRow(tc.Context,
+			`SELECT count(*) FROM evm_collector_withdrawals WHERE merchant_id = $1`, mt.ID,
+		).Scan(&withdrawals)
+		require.NoError(t, err)
+		assert.Zero(t, withdrawals)
+	})
+}
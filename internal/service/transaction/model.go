@@ -2,6 +2,7 @@ package transaction
 
 import (
 	"encoding/json"
+	"strconv"
 	"time"
 
 	"github.com/jackc/pgtype"
@@ -51,6 +52,15 @@ func (tx *Transaction) IsInProgress() bool {
 	return tx.Status == StatusInProgress || tx.Status == StatusInProgressInvalid
 }
 
+// Confirmations returns last seen and required confirmations of incoming
+// transaction, zeros when unknown.
+func (tx *Transaction) Confirmations() (confirmations, required int64) {
+	confirmations, _ = strconv.ParseInt(tx.MetaData[MetaConfirmations], 10, 64)
+	required, _ = strconv.ParseInt(tx.MetaData[MetaRequiredConfirmations], 10, 64)
+
+	return confirmations, required
+}
+
 func (tx *Transaction) NetworkID() string {
 	return tx.Currency.ChooseNetwork(tx.IsTest)
 }
@@ -79,6 +89,11 @@ const (
 	MetaComment     wallet.MetaDataKey = "comment"
 	MetaErrorReason wallet.MetaDataKey = "errorReason"
 
+	// MetaConfirmations last seen confirmations of incoming transaction,
+	// MetaRequiredConfirmations confirmations needed to complete it.
+	MetaConfirmations         wallet.MetaDataKey = "confirmations"
+	MetaRequiredConfirmations wallet.MetaDataKey = "requiredConfirmations"
+
 	MetaTransactionID     = "transactionId"
 	MetaRecipientWalletID = "recipientWalletId"
 	MetaMerchantID        = "merchantId"
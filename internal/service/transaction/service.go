@@ -11,6 +11,7 @@ import (
 	"github.com/cryptolink/cryptolink/internal/db/repository"
 	"github.com/cryptolink/cryptolink/internal/money"
 	"github.com/cryptolink/cryptolink/internal/service/blockchain"
+	"github.com/cryptolink/cryptolink/internal/service/ledger"
 	"github.com/cryptolink/cryptolink/internal/service/wallet"
 	"github.com/cryptolink/cryptolink/internal/util"
 	"github.com/pkg/errors"
@@ -21,6 +22,7 @@ type Service struct {
 	store      *repository.Store
 	blockchain blockchain.Resolver
 	wallets    *wallet.Service
+	ledger     *ledger.Service
 	logger     *zerolog.Logger
 }
 
@@ -42,6 +44,7 @@ func New(
 	store *repository.Store,
 	blockchainService blockchain.Resolver,
 	wallets *wallet.Service,
+	ledgerService *ledger.Service,
 	logger *zerolog.Logger,
 ) *Service {
 	log := logger.With().Str("channel", "transaction_service").Logger()
@@ -50,6 +53,7 @@ func New(
 		store:      store,
 		blockchain: blockchainService,
 		wallets:    wallets,
+		ledger:     ledgerService,
 		logger:     &log,
 	}
 }
@@ -78,6 +82,10 @@ type CreateTransaction struct {
 
 	IsTest bool
 
+	// RateSnapshots conversions used to price this transaction. Persisted
+	// atomically with the transaction itself.
+	RateSnapshots []RateSnapshot
+
 	isIncomingUnexpected bool
 }
 
@@ -249,6 +257,10 @@ func (s *Service) Create(
 			return errCreate
 		}
 
+		if errSnap := insertRateSnapshots(ctx, q, entry, params.RateSnapshots); errSnap != nil {
+			return errSnap
+		}
+
 		tx = entry
 
 		return nil
@@ -61,23 +61,47 @@ func (s *Service) RecordFill(
 	senderNS := sql.NullString{String: senderAddress, Valid: senderAddress != ""}
 	blockNS := sql.NullInt64{Int64: blockNumber, Valid: blockNumber > 0}
 
-	row, err := s.store.InsertTransactionFill(ctx, repository.InsertTransactionFillParams{
-		TransactionID:   parentTx.ID,
-		NetworkID:       networkID,
-		TransactionHash: txHash,
-		VoutOrLogIdx:    voutOrLogIdx,
-		Amount:          repository.MoneyToNumeric(amount),
-		SenderAddress:   senderNS,
-		BlockNumber:     blockNS,
-		Confirmations:   confirmations,
-		Status:          status,
-		ObservedAt:      time.Now(),
+	var fill *Fill
+
+	err := s.store.RunTransaction(ctx, func(ctx context.Context, q repository.Querier) error {
+		row, err := q.InsertTransactionFill(ctx, repository.InsertTransactionFillParams{
+			TransactionID:   parentTx.ID,
+			NetworkID:       networkID,
+			TransactionHash: txHash,
+			VoutOrLogIdx:    voutOrLogIdx,
+			Amount:          repository.MoneyToNumeric(amount),
+			SenderAddress:   senderNS,
+			BlockNumber:     blockNS,
+			Confirmations:   confirmations,
+			Status:          status,
+			ObservedAt:      time.Now(),
+		})
+		if err != nil {
+			return errors.Wrap(err, "unable to insert transaction fill")
+		}
+
+		fill = s.fillFromRepo(parentTx, row)
+
+		// Confirmed fills are customer deposits until the invoice is settled.
+		// Ledger journal is keyed by fill id, so repeat detections are absorbed too.
+		if s.ledger == nil || fill.Status != FillStatusConfirmed || parentTx.MerchantID == SystemMerchantID {
+			return nil
+		}
+
+		o := ledgerOrigin(parentTx, fill.ObservedAt)
+		o.TxHash = fill.TransactionHash
+		if err := s.ledger.RecordFill(ctx, q, o, fill.ID, fill.Amount); err != nil {
+			return errors.Wrap(err, "unable to record fill ledger journal")
+		}
+
+		return nil
 	})
+
 	if err != nil {
-		return nil, errors.Wrap(err, "unable to insert transaction fill")
+		return nil, err
 	}
 
-	return s.fillFromRepo(parentTx, row), nil
+	return fill, nil
 }
 
 // SumConfirmedFills returns the cumulative confirmed amount across all fills
@@ -126,9 +150,24 @@ func (s *Service) FillExistsByHashAndRecipient(ctx context.Context, networkID, t
 }
 
 // MarkFillReorged flips a fill to reorged so it stops counting toward the
-// cumulative confirmed sum. Used by the periodic reorg recheck.
-func (s *Service) MarkFillReorged(ctx context.Context, fillID int64) error {
-	return s.store.MarkFillReorged(ctx, fillID)
+// cumulative confirmed sum and reverses its ledger journal. Used by the
+// periodic reorg recheck.
+func (s *Service) MarkFillReorged(ctx context.Context, parentTx *Transaction, fillID int64) error {
+	return s.store.RunTransaction(ctx, func(ctx context.Context, q repository.Querier) error {
+		if err := q.MarkFillReorged(ctx, fillID); err != nil {
+			return err
+		}
+
+		if s.ledger == nil {
+			return nil
+		}
+
+		if err := s.ledger.ReverseFill(ctx, q, parentTx.MerchantID, fillID, time.Now()); err != nil {
+			return errors.Wrap(err, "unable to reverse fill ledger journal")
+		}
+
+		return nil
+	})
 }
 
 // ListPartialPaymentTxIDs returns transaction ids belonging to invoices
@@ -0,0 +1,67 @@
+package transaction
+
+import (
+	"context"
+	"time"
+
+	"github.com/cryptolink/cryptolink/internal/db/repository"
+	"github.com/cryptolink/cryptolink/internal/service/ledger"
+	"github.com/pkg/errors"
+)
+
+// RecordResolvedReceipt books underpaid transaction resolved by the merchant as revenue.
+func (s *Service) RecordResolvedReceipt(ctx context.Context, tx *Transaction) error {
+	if s.ledger == nil || tx.FactAmount == nil {
+		return nil
+	}
+
+	return s.store.RunTransaction(ctx, func(ctx context.Context, q repository.Querier) error {
+		return s.ledger.RecordResolvedReceipt(ctx, q, ledgerOrigin(tx, time.Now()), *tx.FactAmount)
+	})
+}
+
+// recordLedger records ledger journals of the confirmed transaction.
+// Internal transfers and system transactions don't affect merchants' ledgers.
+func (s *Service) recordLedger(ctx context.Context, q repository.Querier, tx *Transaction) error {
+	if s.ledger == nil || tx.MerchantID == SystemMerchantID || tx.FactAmount == nil {
+		return nil
+	}
+
+	o := ledgerOrigin(tx, tx.UpdatedAt)
+
+	var err error
+
+	switch tx.Type {
+	case TypeIncoming:
+		err = s.ledger.RecordIncoming(ctx, q, ledger.Incoming{
+			Origin:     o,
+			Expected:   tx.Amount,
+			Fact:       *tx.FactAmount,
+			ServiceFee: tx.ServiceFee,
+			Underpaid:  tx.Status == StatusCompletedInvalid,
+		})
+	case TypeWithdrawal:
+		err = s.ledger.RecordWithdrawal(ctx, q, o, tx.Amount, tx.ServiceFee)
+	case TypeVirtual:
+		err = s.ledger.RecordTopup(ctx, q, o, *tx.FactAmount)
+	}
+
+	return errors.Wrap(err, "unable to record ledger journal")
+}
+
+func ledgerOrigin(tx *Transaction, at time.Time) ledger.Origin {
+	var txHash string
+	if tx.HashID != nil {
+		txHash = *tx.HashID
+	}
+
+	return ledger.Origin{
+		MerchantID:    tx.MerchantID,
+		TransactionID: tx.ID,
+		PaymentID:     tx.EntityID,
+		IsTest:        tx.IsTest,
+		NonCustodial:  tx.Type == TypeIncoming && tx.RecipientWalletID == nil,
+		TxHash:        txHash,
+		OccurredAt:    at,
+	}
+}
@@ -0,0 +1,196 @@
+package transaction
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"time"
+
+	"github.com/cryptolink/cryptolink/internal/db/repository"
+	"github.com/cryptolink/cryptolink/internal/money"
+	"github.com/cryptolink/cryptolink/internal/service/blockchain"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+// RateSnapshot is an audit record of an exchange-rate conversion that priced
+// a transaction: what was converted, at which rate, from which source and when
+// the quote was taken. Snapshots are written in the same DB transaction as the
+// transaction they belong to and are never updated afterwards.
+type RateSnapshot struct {
+	ID            int64
+	MerchantID    int64
+	PaymentID     int64
+	PaymentUUID   *uuid.UUID
+	TransactionID int64
+
+	Kind         RateSnapshotKind
+	FromCurrency string
+	ToCurrency   string
+	FromAmount   string
+	ToAmount     string
+	Rate         float64
+	Source       string
+	FetchedAt    time.Time
+
+	// FeePercent is set when the merchant's volatility markup was applied on
+	// top of the conversion; ChargedAmount (RateKindPaymentMethod only) is the
+	// crypto amount after the markup and display rounding.
+	FeePercent    *float64
+	ChargedAmount *string
+
+	CreatedAt time.Time
+}
+
+type RateSnapshotKind string
+
+const (
+	// RateKindPaymentMethod invoice fiat price -> selected crypto currency
+	RateKindPaymentMethod RateSnapshotKind = "payment_method"
+
+	// RateKindUSDValuation invoice fiat price -> USD (stored as tx usd_amount)
+	RateKindUSDValuation RateSnapshotKind = "usd_valuation"
+
+	// RateKindCrossCurrency received crypto -> invoice fiat when a payment in
+	// another currency is auto-accepted
+	RateKindCrossCurrency RateSnapshotKind = "cross_currency"
+
+	// RateKindLedgerValuation received or sent crypto -> merchant's fiat currency
+	// at event time, taken by the ledger when no quote of the transaction applies
+	RateKindLedgerValuation RateSnapshotKind = "ledger_valuation"
+)
+
+// SnapshotFromConversion makes a RateSnapshot out of a blockchain.Conversion.
+// Transaction-related ids are filled in by Create.
+func SnapshotFromConversion(kind RateSnapshotKind, conv blockchain.Conversion) RateSnapshot {
+	return RateSnapshot{
+		Kind:         kind,
+		FromCurrency: conv.From.Ticker(),
+		ToCurrency:   conv.To.Ticker(),
+		FromAmount:   conv.From.String(),
+		ToAmount:     conv.To.String(),
+		Rate:         conv.Rate,
+		Source:       string(conv.Source),
+		FetchedAt:    conv.CalculatedAt,
+	}
+}
+
+// WithFeeMarkup records merchant's fee markup and the amount customer is charged
+// after the markup and rounding were applied.
+func (r RateSnapshot) WithFeeMarkup(feePercent float64, charged money.Money) RateSnapshot {
+	if feePercent > 0 {
+		r.FeePercent = &feePercent
+	}
+
+	chargedAmount := charged.String()
+	r.ChargedAmount = &chargedAmount
+
+	return r
+}
+
+// ListRateSnapshots returns all rate snapshots of the payment in creation order.
+func (s *Service) ListRateSnapshots(ctx context.Context, merchantID, paymentID int64) ([]RateSnapshot, error) {
+	rows, err := s.store.ListRateSnapshotsByPayment(ctx, merchantID, paymentID)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to list rate snapshots")
+	}
+
+	return snapshotsFromRepo(rows), nil
+}
+
+// ListRateSnapshotsInRange returns merchant's rate snapshots created within [from, to).
+func (s *Service) ListRateSnapshotsInRange(ctx context.Context, merchantID int64, from, to time.Time) ([]RateSnapshot, error) {
+	if !from.Before(to) {
+		return nil, errors.New("invalid date range")
+	}
+
+	rows, err := s.store.ListRateSnapshotsByMerchant(ctx, repository.ListRateSnapshotsByMerchantParams{
+		MerchantID: merchantID,
+		From:       from,
+		To:         to,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to list rate snapshots")
+	}
+
+	return snapshotsFromRepo(rows), nil
+}
+
+func insertRateSnapshots(ctx context.Context, q repository.Querier, tx repository.Transaction, snapshots []RateSnapshot) error {
+	for _, snap := range snapshots {
+		feePercent := sql.NullString{}
+		if snap.FeePercent != nil {
+			feePercent = repository.StringToNullable(strconv.FormatFloat(*snap.FeePercent, 'f', -1, 64))
+		}
+
+		fetchedAt := snap.FetchedAt
+		if fetchedAt.IsZero() {
+			fetchedAt = tx.CreatedAt
+		}
+
+		_, err := q.InsertRateSnapshot(ctx, repository.InsertRateSnapshotParams{
+			MerchantID:    tx.MerchantID,
+			PaymentID:     tx.EntityID.Int64,
+			TransactionID: tx.ID,
+			Kind:          string(snap.Kind),
+			FromCurrency:  snap.FromCurrency,
+			ToCurrency:    snap.ToCurrency,
+			FromAmount:    snap.FromAmount,
+			ToAmount:      snap.ToAmount,
+			Rate:          strconv.FormatFloat(snap.Rate, 'f', -1, 64),
+			Source:        snap.Source,
+			FeePercent:    feePercent,
+			ChargedAmount: repository.PointerStringToNullable(snap.ChargedAmount),
+			FetchedAt:     fetchedAt,
+			CreatedAt:     tx.CreatedAt,
+		})
+		if err != nil {
+			return errors.Wrapf(err, "unable to insert %s rate snapshot", snap.Kind)
+		}
+	}
+
+	return nil
+}
+
+func snapshotsFromRepo(rows []repository.RateSnapshot) []RateSnapshot {
+	result := make([]RateSnapshot, 0, len(rows))
+
+	for _, row := range rows {
+		rate, _ := strconv.ParseFloat(row.Rate, 64)
+
+		snap := RateSnapshot{
+			ID:            row.ID,
+			MerchantID:    row.MerchantID,
+			PaymentID:     row.PaymentID,
+			TransactionID: row.TransactionID,
+			Kind:          RateSnapshotKind(row.Kind),
+			FromCurrency:  row.FromCurrency,
+			ToCurrency:    row.ToCurrency,
+			FromAmount:    row.FromAmount,
+			ToAmount:      row.ToAmount,
+			Rate:          rate,
+			Source:        row.Source,
+			FetchedAt:     row.FetchedAt,
+			CreatedAt:     row.CreatedAt,
+		}
+
+		if row.PaymentUUID.Valid {
+			snap.PaymentUUID = &row.PaymentUUID.UUID
+		}
+
+		if row.FeePercent.Valid {
+			if fee, err := strconv.ParseFloat(row.FeePercent.String, 64); err == nil {
+				snap.FeePercent = &fee
+			}
+		}
+
+		if row.ChargedAmount.Valid {
+			charged := row.ChargedAmount.String
+			snap.ChargedAmount = &charged
+		}
+
+		result = append(result, snap)
+	}
+
+	return result
+}
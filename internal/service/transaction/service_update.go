@@ -190,6 +190,34 @@ func (s *Service) UpdateTransactionHash(ctx context.Context, merchantID, txID in
 	})
 }
 
+// SetConfirmations records confirmations progress of in-progress transaction.
+// updated_at is left intact as it drives in-progress timeout.
+func (s *Service) SetConfirmations(ctx context.Context, tx *Transaction, confirmations, required int64) error {
+	meta := MetaData{
+		MetaConfirmations:         strconv.FormatInt(confirmations, 10),
+		MetaRequiredConfirmations: strconv.FormatInt(required, 10),
+	}
+
+	err := s.store.MergeTransactionMetadata(ctx, repository.MergeTransactionMetadataParams{
+		Metadata:   meta.toJSONB(),
+		ID:         tx.ID,
+		MerchantID: tx.MerchantID,
+	})
+	if err != nil {
+		return errors.Wrap(err, "unable to set transaction confirmations")
+	}
+
+	if tx.MetaData == nil {
+		tx.MetaData = make(MetaData)
+	}
+
+	for k, v := range meta {
+		tx.MetaData[k] = v
+	}
+
+	return nil
+}
+
 // confirm mark tx as confirmed and updates related balances.
 func (s *Service) confirm(ctx context.Context, q repository.Querier, merchantID, txID int64, params ConfirmTransaction) (*Transaction, error) {
 	// 1. Get transaction
@@ -238,6 +266,11 @@ func (s *Service) confirm(ctx context.Context, q repository.Querier, merchantID,
 		return nil, errors.Wrap(err, "unable to update balances")
 	}
 
+	// 4. Record merchant's ledger journals
+	if err := s.recordLedger(ctx, q, tx); err != nil {
+		return nil, err
+	}
+
 	return tx, nil
 }
 
@@ -246,7 +246,9 @@ func (s *Service) VerifyEmail(ctx context.Context, token string) (*User, error)
 	return entryToUser(entry)
 }
 
-func (s *Service) UpdatePassword(ctx context.Context, id int64, pass string) (*User, error) {
+// UpdatePassword sets a new password and revokes all sessions of the user
+// except keepSessionID (0 revokes all), e.g. except the one that changes it.
+func (s *Service) UpdatePassword(ctx context.Context, id int64, pass string, keepSessionID int64) (*User, error) {
 	if len(pass) < 8 {
 		return nil, errors.New("password should have minimum length of 8")
 	}
@@ -256,15 +258,30 @@ func (s *Service) UpdatePassword(ctx context.Context, id int64, pass string) (*U
 		return nil, err
 	}
 
-	entry, err := s.store.UpdateUserPassword(ctx, repository.UpdateUserPasswordParams{
-		ID:        id,
-		Password:  repository.StringToNullable(hashedPass),
-		UpdatedAt: time.Now(),
+	var entry repository.User
+
+	err = s.store.RunTransaction(ctx, func(ctx context.Context, q repository.Querier) error {
+		now := time.Now()
+
+		entry, err = q.UpdateUserPassword(ctx, repository.UpdateUserPasswordParams{
+			ID:        id,
+			Password:  repository.StringToNullable(hashedPass),
+			UpdatedAt: now,
+		})
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			return ErrNotFound
+		case err != nil:
+			return err
+		}
+
+		if _, err := q.RevokeUserSessions(ctx, id, keepSessionID, now); err != nil {
+			return errors.Wrap(err, "unable to revoke sessions")
+		}
+
+		return nil
 	})
-	switch {
-	case errors.Is(err, pgx.ErrNoRows):
-		return nil, ErrNotFound
-	case err != nil:
+	if err != nil {
 		return nil, err
 	}
 
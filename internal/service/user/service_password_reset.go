@@ -0,0 +1,140 @@
+package user
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"github.com/cryptolink/cryptolink/internal/db/repository"
+	"github.com/jackc/pgx/v4"
+	"github.com/pkg/errors"
+)
+
+const (
+	// PasswordResetTTL how long the emailed reset link is valid.
+	PasswordResetTTL = time.Hour
+
+	// passwordResetsPerHour limits reset emails sent to one account.
+	passwordResetsPerHour = 3
+)
+
+var (
+	ErrInvalidResetToken    = errors.New("invalid or expired password reset token")
+	ErrTooManyResetRequests = errors.New("too many password reset requests")
+)
+
+type ResetPasswordParams struct {
+	Token    string
+	Password string
+	// RevokeAPITokens also deletes user's API tokens and API tokens of
+	// merchants created by the user.
+	RevokeAPITokens bool
+}
+
+// RequestPasswordReset creates a single-use reset token of the user with the
+// email. Returns the user and the token to email. Only token's hash is stored.
+func (s *Service) RequestPasswordReset(ctx context.Context, email, ip string) (*User, string, error) {
+	person, err := s.GetByEmail(ctx, email)
+	if err != nil {
+		return nil, "", err
+	}
+
+	now := time.Now()
+
+	count, err := s.store.CountPasswordResetTokensSince(ctx, person.ID, now.Add(-time.Hour))
+	switch {
+	case err != nil:
+		return nil, "", errors.Wrap(err, "unable to count password reset tokens")
+	case count >= passwordResetsPerHour:
+		return nil, "", ErrTooManyResetRequests
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return nil, "", errors.Wrap(err, "failed to generate random token")
+	}
+
+	token := hex.EncodeToString(b)
+
+	err = s.store.CreatePasswordResetToken(ctx, repository.CreatePasswordResetTokenParams{
+		UserID:    person.ID,
+		TokenHash: hashResetToken(token),
+		IPAddress: ip,
+		ExpiresAt: now.Add(PasswordResetTTL),
+		CreatedAt: now,
+	})
+	if err != nil {
+		return nil, "", errors.Wrap(err, "failed to save password reset token")
+	}
+
+	return person, token, nil
+}
+
+// ResetPassword sets a new password by reset token. All reset tokens and
+// sessions of the user become invalid.
+func (s *Service) ResetPassword(ctx context.Context, params ResetPasswordParams) (*User, error) {
+	if len(params.Password) < 8 {
+		return nil, errors.New("password should have minimum length of 8")
+	}
+
+	hashedPass, err := hashPass(params.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	var entry repository.User
+
+	err = s.store.RunTransaction(ctx, func(ctx context.Context, q repository.Querier) error {
+		now := time.Now()
+
+		userID, err := q.UsePasswordResetToken(ctx, hashResetToken(params.Token), now)
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			return ErrInvalidResetToken
+		case err != nil:
+			return errors.Wrap(err, "unable to use password reset token")
+		}
+
+		entry, err = q.UpdateUserPassword(ctx, repository.UpdateUserPasswordParams{
+			ID:        userID,
+			Password:  repository.StringToNullable(hashedPass),
+			UpdatedAt: now,
+		})
+		if err != nil {
+			return errors.Wrap(err, "unable to update password")
+		}
+
+		if err := q.ExpirePasswordResetTokens(ctx, userID, now); err != nil {
+			return errors.Wrap(err, "unable to expire password reset tokens")
+		}
+
+		if _, err := q.RevokeUserSessions(ctx, userID, 0, now); err != nil {
+			return errors.Wrap(err, "unable to revoke sessions")
+		}
+
+		if params.RevokeAPITokens {
+			deleted, err := q.DeleteAPITokensByUser(ctx, userID)
+			if err != nil {
+				return errors.Wrap(err, "unable to delete api tokens")
+			}
+
+			s.logger.Info().Int64("user_id", userID).Int64("deleted", deleted).Msg("api tokens revoked on password reset")
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info().Int64("user_id", entry.ID).Msg("password reset")
+
+	return entryToUser(entry)
+}
+
+func hashResetToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
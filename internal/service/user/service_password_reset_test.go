@@ -0,0 +1,74 @@
+package user_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cryptolink/cryptolink/internal/auth"
+	"github.com/cryptolink/cryptolink/internal/service/user"
+	"github.com/cryptolink/cryptolink/internal/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_ResetPassword(t *testing.T) {
+	tc := test.NewIntegrationTest(t)
+
+	// ARRANGE
+	// Given a user
+	u, _ := tc.Must.CreateUser(t, auth.GoogleUser{Name: "u1", Email: "reset@gmail.com"})
+
+	t.Run("unknown email", func(t *testing.T) {
+		// ACT
+		_, _, err := tc.Services.Users.RequestPasswordReset(tc.Context, "unknown@gmail.com", "127.0.0.1")
+
+		// ASSERT
+		assert.ErrorIs(t, err, user.ErrNotFound)
+	})
+
+	t.Run("resets password once", func(t *testing.T) {
+		// ARRANGE
+		person, token, err := tc.Services.Users.RequestPasswordReset(tc.Context, u.Email, "127.0.0.1")
+		require.NoError(t, err)
+		require.Equal(t, u.ID, person.ID)
+
+		// And an active session
+		session, err := tc.Services.Users.CreateSession(tc.Context, user.CreateSessionParams{
+			UserID:     u.ID,
+			AuthMethod: user.AuthMethodPassword,
+			TTL:        time.Hour,
+		})
+		require.NoError(t, err)
+
+		// ACT
+		_, err = tc.Services.Users.ResetPassword(tc.Context, user.ResetPasswordParams{
+			Token:    token,
+			Password: "new-password",
+		})
+
+		// ASSERT
+		require.NoError(t, err)
+
+		_, err = tc.Services.Users.GetActiveSession(tc.Context, session.UUID)
+		assert.ErrorIs(t, err, user.ErrSessionNotFound)
+
+		_, err = tc.Services.Users.GetByEmailWithPasswordCheck(tc.Context, u.Email, "new-password")
+		assert.NoError(t, err)
+
+		// token is single-use
+		_, err = tc.Services.Users.ResetPassword(tc.Context, user.ResetPasswordParams{
+			Token:    token,
+			Password: "another-password",
+		})
+		assert.ErrorIs(t, err, user.ErrInvalidResetToken)
+	})
+
+	t.Run("limits requests", func(t *testing.T) {
+		var err error
+		for i := 0; i < 5 && err == nil; i++ {
+			_, _, err = tc.Services.Users.RequestPasswordReset(tc.Context, u.Email, "127.0.0.1")
+		}
+
+		assert.ErrorIs(t, err, user.ErrTooManyResetRequests)
+	})
+}
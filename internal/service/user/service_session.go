@@ -0,0 +1,171 @@
+package user
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/cryptolink/cryptolink/internal/db/repository"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/pkg/errors"
+)
+
+// AuthMethod first factor the session was created with.
+type AuthMethod string
+
+const (
+	AuthMethodPassword AuthMethod = "password"
+	AuthMethodGoogle   AuthMethod = "google"
+)
+
+const (
+	// sessionTouchInterval how often LastSeenAt of an active session is updated.
+	sessionTouchInterval = time.Minute
+
+	userAgentMaxLength = 512
+)
+
+var ErrSessionNotFound = errors.New("session not found")
+
+// Session server-side record of a dashboard session. The session cookie
+// keeps only its UUID.
+type Session struct {
+	ID         int64
+	UUID       uuid.UUID
+	UserID     int64
+	AuthMethod AuthMethod
+	// TwoFactor whether the second factor was provided on login.
+	TwoFactor  bool
+	IP         string
+	UserAgent  string
+	CreatedAt  time.Time
+	LastSeenAt time.Time
+	ExpiresAt  time.Time
+}
+
+type CreateSessionParams struct {
+	UserID     int64
+	AuthMethod AuthMethod
+	TwoFactor  bool
+	IP         string
+	UserAgent  string
+	TTL        time.Duration
+}
+
+func (s *Service) CreateSession(ctx context.Context, params CreateSessionParams) (*Session, error) {
+	now := time.Now()
+
+	entry, err := s.store.CreateUserSession(ctx, repository.CreateUserSessionParams{
+		Uuid:       uuid.New(),
+		UserID:     params.UserID,
+		AuthMethod: string(params.AuthMethod),
+		TwoFactor:  params.TwoFactor,
+		IPAddress:  params.IP,
+		UserAgent:  truncateUserAgent(params.UserAgent),
+		CreatedAt:  now,
+		ExpiresAt:  now.Add(params.TTL),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create session")
+	}
+
+	return entryToSession(entry), nil
+}
+
+// GetActiveSession returns not revoked and not expired session or ErrSessionNotFound.
+func (s *Service) GetActiveSession(ctx context.Context, id uuid.UUID) (*Session, error) {
+	entry, err := s.store.GetActiveUserSession(ctx, id, time.Now())
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return nil, ErrSessionNotFound
+	case err != nil:
+		return nil, errors.Wrap(err, "unable to get session")
+	}
+
+	return entryToSession(entry), nil
+}
+
+// TouchSession updates last seen time and IP of the session. Writes at most
+// once per sessionTouchInterval unless IP changes.
+func (s *Service) TouchSession(ctx context.Context, session *Session, ip string) error {
+	now := time.Now()
+	if session.IP == ip && now.Sub(session.LastSeenAt) < sessionTouchInterval {
+		return nil
+	}
+
+	if err := s.store.TouchUserSession(ctx, session.ID, now, ip); err != nil {
+		return errors.Wrap(err, "unable to touch session")
+	}
+
+	session.LastSeenAt = now
+	session.IP = ip
+
+	return nil
+}
+
+// ListSessions returns active sessions of the user, recently used first.
+func (s *Service) ListSessions(ctx context.Context, userID int64) ([]*Session, error) {
+	entries, err := s.store.ListActiveUserSessions(ctx, userID, time.Now())
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to list sessions")
+	}
+
+	sessions := make([]*Session, len(entries))
+	for i := range entries {
+		sessions[i] = entryToSession(entries[i])
+	}
+
+	return sessions, nil
+}
+
+// RevokeSession revokes session of the user or returns ErrSessionNotFound.
+func (s *Service) RevokeSession(ctx context.Context, userID int64, id uuid.UUID) error {
+	revoked, err := s.store.RevokeUserSession(ctx, userID, id, time.Now())
+	switch {
+	case err != nil:
+		return errors.Wrap(err, "unable to revoke session")
+	case revoked == 0:
+		return ErrSessionNotFound
+	}
+
+	s.logger.Info().Int64("user_id", userID).Str("session_id", id.String()).Msg("session revoked")
+
+	return nil
+}
+
+// RevokeSessions revokes all sessions of the user except keepSessionID
+// (0 revokes all). Returns number of revoked sessions.
+func (s *Service) RevokeSessions(ctx context.Context, userID, keepSessionID int64) (int64, error) {
+	revoked, err := s.store.RevokeUserSessions(ctx, userID, keepSessionID, time.Now())
+	if err != nil {
+		return 0, errors.Wrap(err, "unable to revoke sessions")
+	}
+
+	s.logger.Info().Int64("user_id", userID).Int64("revoked", revoked).Msg("sessions revoked")
+
+	return revoked, nil
+}
+
+func entryToSession(entry repository.UserSession) *Session {
+	return &Session{
+		ID:         entry.ID,
+		UUID:       entry.Uuid,
+		UserID:     entry.UserID,
+		AuthMethod: AuthMethod(entry.AuthMethod),
+		TwoFactor:  entry.TwoFactor,
+		IP:         entry.IPAddress,
+		UserAgent:  entry.UserAgent,
+		CreatedAt:  entry.CreatedAt,
+		LastSeenAt: entry.LastSeenAt,
+		ExpiresAt:  entry.ExpiresAt,
+	}
+}
+
+func truncateUserAgent(ua string) string {
+	if len(ua) <= userAgentMaxLength {
+		return ua
+	}
+
+	return strings.ToValidUTF8(ua[:userAgentMaxLength], "")
+}
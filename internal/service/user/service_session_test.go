@@ -0,0 +1,152 @@
+package user_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cryptolink/cryptolink/internal/auth"
+	"github.com/cryptolink/cryptolink/internal/service/user"
+	"github.com/cryptolink/cryptolink/internal/test"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_Sessions(t *testing.T) {
+	tc := test.NewIntegrationTest(t)
+
+	// ARRANGE
+	// Given users
+	u, _ := tc.Must.CreateUser(t, auth.GoogleUser{Name: "u1", Email: "sessions@gmail.com"})
+	other, _ := tc.Must.CreateUser(t, auth.GoogleUser{Name: "u2", Email: "sessions-other@gmail.com"})
+
+	createSession := func(t *testing.T, userID int64) *user.Session {
+		session, err := tc.Services.Users.CreateSession(tc.Context, user.CreateSessionParams{
+			UserID:     userID,
+			AuthMethod: user.AuthMethodPassword,
+			IP:         "127.0.0.1",
+			UserAgent:  "Mozilla/5.0",
+			TTL:        time.Hour,
+		})
+		require.NoError(t, err)
+
+		return session
+	}
+
+	t.Run("creates and lists sessions", func(t *testing.T) {
+		// ACT
+		session := createSession(t, u.ID)
+
+		// ASSERT
+		active, err := tc.Services.Users.GetActiveSession(tc.Context, session.UUID)
+		require.NoError(t, err)
+		assert.Equal(t, u.ID, active.UserID)
+		assert.Equal(t, user.AuthMethodPassword, active.AuthMethod)
+		assert.Equal(t, "127.0.0.1", active.IP)
+
+		sessions, err := tc.Services.Users.ListSessions(tc.Context, u.ID)
+		require.NoError(t, err)
+		assert.Contains(t, sessionUUIDs(sessions), session.UUID)
+	})
+
+	t.Run("expired session is not active", func(t *testing.T) {
+		// ARRANGE
+		session, err := tc.Services.Users.CreateSession(tc.Context, user.CreateSessionParams{
+			UserID:     u.ID,
+			AuthMethod: user.AuthMethodGoogle,
+			TTL:        -time.Minute,
+		})
+		require.NoError(t, err)
+
+		// ACT
+		_, err = tc.Services.Users.GetActiveSession(tc.Context, session.UUID)
+
+		// ASSERT
+		assert.ErrorIs(t, err, user.ErrSessionNotFound)
+	})
+
+	t.Run("revokes session", func(t *testing.T) {
+		// ARRANGE
+		session := createSession(t, u.ID)
+
+		// ACT
+		// other user can't revoke the session
+		errOther := tc.Services.Users.RevokeSession(tc.Context, other.ID, session.UUID)
+		err := tc.Services.Users.RevokeSession(tc.Context, u.ID, session.UUID)
+
+		// ASSERT
+		assert.ErrorIs(t, errOther, user.ErrSessionNotFound)
+		assert.NoError(t, err)
+
+		_, err = tc.Services.Users.GetActiveSession(tc.Context, session.UUID)
+		assert.ErrorIs(t, err, user.ErrSessionNotFound)
+
+		err = tc.Services.Users.RevokeSession(tc.Context, u.ID, uuid.New())
+		assert.ErrorIs(t, err, user.ErrSessionNotFound)
+	})
+
+	t.Run("revokes other sessions", func(t *testing.T) {
+		// ARRANGE
+		current := createSession(t, u.ID)
+		another := createSession(t, u.ID)
+		otherUsers := createSession(t, other.ID)
+
+		// ACT
+		revoked, err := tc.Services.Users.RevokeSessions(tc.Context, u.ID, current.ID)
+
+		// ASSERT
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, revoked, int64(1))
+
+		sessions, err := tc.Services.Users.ListSessions(tc.Context, u.ID)
+		require.NoError(t, err)
+		assert.Equal(t, []uuid.UUID{current.UUID}, sessionUUIDs(sessions))
+
+		_, err = tc.Services.Users.GetActiveSession(tc.Context, another.UUID)
+		assert.ErrorIs(t, err, user.ErrSessionNotFound)
+
+		_, err = tc.Services.Users.GetActiveSession(tc.Context, otherUsers.UUID)
+		assert.NoError(t, err)
+	})
+
+	t.Run("password change keeps current session only", func(t *testing.T) {
+		// ARRANGE
+		current := createSession(t, u.ID)
+		another := createSession(t, u.ID)
+
+		// ACT
+		_, err := tc.Services.Users.UpdatePassword(tc.Context, u.ID, "new-password", current.ID)
+
+		// ASSERT
+		require.NoError(t, err)
+
+		_, err = tc.Services.Users.GetActiveSession(tc.Context, current.UUID)
+		assert.NoError(t, err)
+
+		_, err = tc.Services.Users.GetActiveSession(tc.Context, another.UUID)
+		assert.ErrorIs(t, err, user.ErrSessionNotFound)
+	})
+
+	t.Run("2FA reset revokes all sessions", func(t *testing.T) {
+		// ARRANGE
+		session := createSession(t, u.ID)
+
+		// ACT
+		err := tc.Services.Users.ResetTwoFactor(tc.Context, u.ID)
+
+		// ASSERT
+		require.NoError(t, err)
+
+		_, err = tc.Services.Users.GetActiveSession(tc.Context, session.UUID)
+		assert.ErrorIs(t, err, user.ErrSessionNotFound)
+	})
+}
+
+func sessionUUIDs(sessions []*user.Session) []uuid.UUID {
+	ids := make([]uuid.UUID, len(sessions))
+	for i, s := range sessions {
+		ids[i] = s.UUID
+	}
+
+	return ids
+}
@@ -0,0 +1,315 @@
+package user
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/cryptolink/cryptolink/internal/db/repository"
+	"github.com/jackc/pgx/v4"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrInvalidCode          = errors.New("invalid two-factor code")
+	ErrTwoFactorEnabled     = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorNotEnabled  = errors.New("two-factor authentication is not enabled")
+	ErrTwoFactorNotEnrolled = errors.New("two-factor enrollment is not started")
+	ErrTwoFactorRequired    = errors.New("two-factor authentication is required")
+	ErrTwoFactorLocked      = errors.New("two-factor authentication is temporarily locked")
+)
+
+const registryRequireTwoFactor = "auth.require_2fa"
+
+const (
+	// maxSecondFactorFailures failed codes in a row lock the second factor of
+	// the user for secondFactorLockout, regardless of the login attempt.
+	maxSecondFactorFailures = 5
+	secondFactorLockout     = 15 * time.Minute
+)
+
+type TwoFactorStatus struct {
+	Enabled bool
+	// Required all users should enable 2FA, see SetTwoFactorRequired.
+	Required          bool
+	RecoveryCodesLeft int64
+}
+
+// TOTPEnrollment pending TOTP secret. URI is shown as a QR code, Secret
+// allows to enter it manually.
+type TOTPEnrollment struct {
+	Secret string
+	URI    string
+}
+
+// IsTwoFactorRequired whether super admin requires 2FA for all users.
+func (s *Service) IsTwoFactorRequired(ctx context.Context) bool {
+	return s.registry.GetBoolSafe(ctx, registryRequireTwoFactor, false)
+}
+
+func (s *Service) SetTwoFactorRequired(ctx context.Context, required bool) error {
+	_, err := s.registry.Set(ctx, registryRequireTwoFactor, strconv.FormatBool(required))
+	return err
+}
+
+func (s *Service) IsTwoFactorEnabled(ctx context.Context, userID int64) (bool, error) {
+	entry, err := s.store.GetUserTotp(ctx, userID)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return false, nil
+	case err != nil:
+		return false, errors.Wrap(err, "unable to get user totp")
+	}
+
+	return entry.EnabledAt.Valid, nil
+}
+
+func (s *Service) GetTwoFactorStatus(ctx context.Context, userID int64) (TwoFactorStatus, error) {
+	enabled, err := s.IsTwoFactorEnabled(ctx, userID)
+	if err != nil {
+		return TwoFactorStatus{}, err
+	}
+
+	status := TwoFactorStatus{Enabled: enabled, Required: s.IsTwoFactorRequired(ctx)}
+
+	if enabled {
+		status.RecoveryCodesLeft, err = s.store.CountUnusedUserRecoveryCodes(ctx, userID)
+		if err != nil {
+			return TwoFactorStatus{}, errors.Wrap(err, "unable to count recovery codes")
+		}
+	}
+
+	return status, nil
+}
+
+// BeginTOTPEnrollment generates a new TOTP secret. It isn't used for login
+// until confirmed with ConfirmTOTPEnrollment.
+func (s *Service) BeginTOTPEnrollment(ctx context.Context, u *User) (*TOTPEnrollment, error) {
+	secret, err := generateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.store.UpsertPendingUserTotp(ctx, u.ID, secret, time.Now())
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return nil, ErrTwoFactorEnabled
+	case err != nil:
+		return nil, errors.Wrap(err, "unable to store totp secret")
+	}
+
+	return &TOTPEnrollment{Secret: secret, URI: totpURI(secret, u.Email)}, nil
+}
+
+// ConfirmTOTPEnrollment enables TOTP if the code matches pending secret.
+// Returns recovery codes that are shown to the user only once.
+func (s *Service) ConfirmTOTPEnrollment(ctx context.Context, userID int64, code string) ([]string, error) {
+	entry, err := s.store.GetUserTotp(ctx, userID)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return nil, ErrTwoFactorNotEnrolled
+	case err != nil:
+		return nil, errors.Wrap(err, "unable to get user totp")
+	case entry.EnabledAt.Valid:
+		return nil, ErrTwoFactorEnabled
+	}
+
+	now := time.Now()
+
+	step, ok := matchTOTP(entry.Secret, code, now)
+	if !ok {
+		return nil, ErrInvalidCode
+	}
+
+	codes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.store.RunTransaction(ctx, func(ctx context.Context, q repository.Querier) error {
+		enabled, err := q.EnableUserTotp(ctx, userID, step, now)
+		switch {
+		case err != nil:
+			return errors.Wrap(err, "unable to enable totp")
+		case !enabled:
+			return ErrTwoFactorEnabled
+		}
+
+		return replaceRecoveryCodes(ctx, q, userID, codes, now)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.logger.Info().Int64("user_id", userID).Msg("two-factor authentication enabled")
+
+	return codes, nil
+}
+
+// VerifySecondFactor checks TOTP code or unused recovery code of the user.
+// Both can be used only once. After maxSecondFactorFailures invalid codes in
+// a row returns ErrTwoFactorLocked until the lockout ends.
+func (s *Service) VerifySecondFactor(ctx context.Context, userID int64, code string) error {
+	entry, err := s.store.GetUserTotp(ctx, userID)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return ErrTwoFactorNotEnabled
+	case err != nil:
+		return errors.Wrap(err, "unable to get user totp")
+	case !entry.EnabledAt.Valid:
+		return ErrTwoFactorNotEnabled
+	}
+
+	// UTC as locked_until is compared here, not in the query
+	now := time.Now().UTC()
+
+	if entry.LockedUntil.Valid && now.Before(entry.LockedUntil.Time) {
+		return ErrTwoFactorLocked
+	}
+
+	err = s.useSecondFactor(ctx, entry, code, now)
+	switch {
+	case errors.Is(err, ErrInvalidCode):
+		return s.failSecondFactor(ctx, userID, now)
+	case err != nil:
+		return err
+	}
+
+	if entry.FailedAttempts > 0 {
+		if err := s.store.ResetUserTotpFailures(ctx, userID); err != nil {
+			return errors.Wrap(err, "unable to reset totp failures")
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) useSecondFactor(ctx context.Context, entry repository.UserTotp, code string, now time.Time) error {
+	if step, ok := matchTOTP(entry.Secret, code, now); ok {
+		used, err := s.store.UseUserTotpStep(ctx, entry.UserID, step, now)
+		switch {
+		case err != nil:
+			return errors.Wrap(err, "unable to use totp step")
+		case !used:
+			return ErrInvalidCode
+		}
+
+		return nil
+	}
+
+	used, err := s.store.UseUserRecoveryCode(ctx, entry.UserID, hashRecoveryCode(code), now)
+	switch {
+	case err != nil:
+		return errors.Wrap(err, "unable to use recovery code")
+	case !used:
+		return ErrInvalidCode
+	}
+
+	left, _ := s.store.CountUnusedUserRecoveryCodes(ctx, entry.UserID)
+	s.logger.Info().Int64("user_id", entry.UserID).Int64("codes_left", left).Msg("recovery code used")
+
+	return nil
+}
+
+// failSecondFactor counts invalid code of the user. Returns ErrInvalidCode or
+// ErrTwoFactorLocked if this attempt locked the second factor.
+func (s *Service) failSecondFactor(ctx context.Context, userID int64, now time.Time) error {
+	entry, err := s.store.FailUserTotp(ctx, userID, maxSecondFactorFailures, now.Add(secondFactorLockout))
+	if err != nil {
+		return errors.Wrap(err, "unable to count totp failure")
+	}
+
+	if entry.LockedUntil.Valid && entry.LockedUntil.Time.After(now) {
+		s.logger.Warn().Int64("user_id", userID).Time("locked_until", entry.LockedUntil.Time).Msg("second factor locked")
+		return ErrTwoFactorLocked
+	}
+
+	return ErrInvalidCode
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes of the user.
+func (s *Service) RegenerateRecoveryCodes(ctx context.Context, userID int64) ([]string, error) {
+	enabled, err := s.IsTwoFactorEnabled(ctx, userID)
+	switch {
+	case err != nil:
+		return nil, err
+	case !enabled:
+		return nil, ErrTwoFactorNotEnabled
+	}
+
+	codes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.store.RunTransaction(ctx, func(ctx context.Context, q repository.Querier) error {
+		return replaceRecoveryCodes(ctx, q, userID, codes, time.Now())
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// DisableTwoFactor disables 2FA of the user unless it's required for everyone.
+func (s *Service) DisableTwoFactor(ctx context.Context, userID int64) error {
+	if s.IsTwoFactorRequired(ctx) {
+		return ErrTwoFactorRequired
+	}
+
+	return s.deleteTwoFactor(ctx, userID, false)
+}
+
+// ResetTwoFactor removes TOTP secret and recovery codes of the user,
+// e.g. when super admin restores access of a user that lost the device.
+// All sessions of the user are revoked as the device may still have one.
+func (s *Service) ResetTwoFactor(ctx context.Context, userID int64) error {
+	return s.deleteTwoFactor(ctx, userID, true)
+}
+
+func (s *Service) deleteTwoFactor(ctx context.Context, userID int64, revokeSessions bool) error {
+	err := s.store.RunTransaction(ctx, func(ctx context.Context, q repository.Querier) error {
+		if err := q.DeleteUserTotp(ctx, userID); err != nil {
+			return errors.Wrap(err, "unable to delete totp")
+		}
+
+		if err := q.DeleteUserRecoveryCodes(ctx, userID); err != nil {
+			return errors.Wrap(err, "unable to delete recovery codes")
+		}
+
+		if !revokeSessions {
+			return nil
+		}
+
+		if _, err := q.RevokeUserSessions(ctx, userID, 0, time.Now()); err != nil {
+			return errors.Wrap(err, "unable to revoke sessions")
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s.logger.Info().Int64("user_id", userID).Msg("two-factor authentication disabled")
+
+	return nil
+}
+
+func replaceRecoveryCodes(ctx context.Context, q repository.Querier, userID int64, codes []string, now time.Time) error {
+	if err := q.DeleteUserRecoveryCodes(ctx, userID); err != nil {
+		return errors.Wrap(err, "unable to delete recovery codes")
+	}
+
+	hashes := make([]string, len(codes))
+	for i, code := range codes {
+		hashes[i] = hashRecoveryCode(code)
+	}
+
+	if err := q.CreateUserRecoveryCodes(ctx, userID, hashes, now); err != nil {
+		return errors.Wrap(err, "unable to create recovery codes")
+	}
+
+	return nil
+}
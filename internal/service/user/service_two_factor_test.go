@@ -0,0 +1,87 @@
+package user_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cryptolink/cryptolink/internal/auth"
+	"github.com/cryptolink/cryptolink/internal/service/user"
+	"github.com/cryptolink/cryptolink/internal/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_VerifySecondFactor(t *testing.T) {
+	tc := test.NewIntegrationTest(t)
+
+	// enableTwoFactor enables TOTP of the user and returns recovery codes.
+	enableTwoFactor := func(t *testing.T, userID int64) []string {
+		now := time.Now()
+
+		_, err := tc.Repository.UpsertPendingUserTotp(tc.Context, userID, "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ", now)
+		require.NoError(t, err)
+
+		enabled, err := tc.Repository.EnableUserTotp(tc.Context, userID, 0, now)
+		require.NoError(t, err)
+		require.True(t, enabled)
+
+		codes, err := tc.Services.Users.RegenerateRecoveryCodes(tc.Context, userID)
+		require.NoError(t, err)
+
+		return codes
+	}
+
+	t.Run("locks second factor after failed attempts", func(t *testing.T) {
+		// ARRANGE
+		// Given a user with 2FA
+		u, _ := tc.Must.CreateUser(t, auth.GoogleUser{Name: "u1", Email: "2fa-lock@gmail.com"})
+		codes := enableTwoFactor(t, u.ID)
+
+		// ACT
+		// Guess codes until the lockout
+		for i := 0; i < 4; i++ {
+			err := tc.Services.Users.VerifySecondFactor(tc.Context, u.ID, "000000")
+			require.ErrorIs(t, err, user.ErrInvalidCode)
+		}
+
+		errLocking := tc.Services.Users.VerifySecondFactor(tc.Context, u.ID, "000000")
+		errLocked := tc.Services.Users.VerifySecondFactor(tc.Context, u.ID, codes[0])
+
+		// ASSERT
+		assert.ErrorIs(t, errLocking, user.ErrTwoFactorLocked)
+		assert.ErrorIs(t, errLocked, user.ErrTwoFactorLocked)
+
+		// And once the lockout ends the valid code is accepted
+		_, err := tc.Database.Conn().Pool.Exec(tc.Context,
+			`UPDATE user_totp SET locked_until = $2 WHERE user_id = $1`, u.ID, time.Now().UTC().Add(-time.Minute),
+		)
+		require.NoError(t, err)
+
+		assert.NoError(t, tc.Services.Users.VerifySecondFactor(tc.Context, u.ID, codes[0]))
+	})
+
+	t.Run("valid code resets failed attempts", func(t *testing.T) {
+		// ARRANGE
+		// Given a user with 2FA and failed attempts
+		u, _ := tc.Must.CreateUser(t, auth.GoogleUser{Name: "u2", Email: "2fa-reset@gmail.com"})
+		codes := enableTwoFactor(t, u.ID)
+
+		for i := 0; i < 4; i++ {
+			require.ErrorIs(t, tc.Services.Users.VerifySecondFactor(tc.Context, u.ID, "000000"), user.ErrInvalidCode)
+		}
+
+		// ACT
+		err := tc.Services.Users.VerifySecondFactor(tc.Context, u.ID, codes[0])
+
+		// ASSERT
+		require.NoError(t, err)
+
+		entry, err := tc.Repository.GetUserTotp(tc.Context, u.ID)
+		require.NoError(t, err)
+		assert.Zero(t, entry.FailedAttempts)
+
+		// next invalid code doesn't lock
+		err = tc.Services.Users.VerifySecondFactor(tc.Context, u.ID, "000000")
+		assert.ErrorIs(t, err, user.ErrInvalidCode)
+	})
+}
@@ -0,0 +1,133 @@
+package user
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1" //nolint:gosec // RFC 6238 default, supported by all authenticator apps
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	totpIssuer = "CryptoLink"
+
+	totpDigits = 6
+	totpPeriod = 30 * time.Second
+
+	// totpSkew number of adjacent time steps accepted to tolerate clock drift.
+	totpSkew = 1
+
+	totpSecretSize = 20
+
+	recoveryCodesCount = 10
+	recoveryCodeLength = 10
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+func generateTOTPSecret() (string, error) {
+	b := make([]byte, totpSecretSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Wrap(err, "unable to generate secret")
+	}
+
+	return totpEncoding.EncodeToString(b), nil
+}
+
+// totpURI returns otpauth:// provisioning URI that authenticator apps read
+// from a QR code.
+func totpURI(secret, account string) string {
+	label := url.PathEscape(totpIssuer + ":" + account)
+
+	q := url.Values{}
+	q.Set("secret", secret)
+	q.Set("issuer", totpIssuer)
+	q.Set("algorithm", "SHA1")
+	q.Set("digits", fmt.Sprintf("%d", totpDigits))
+	q.Set("period", fmt.Sprintf("%d", int(totpPeriod.Seconds())))
+
+	return "otpauth://totp/" + label + "?" + q.Encode()
+}
+
+func totpStep(t time.Time) int64 {
+	return t.Unix() / int64(totpPeriod.Seconds())
+}
+
+// totpCode computes RFC 6238 code of the time step.
+func totpCode(secret string, step int64) (string, error) {
+	key, err := totpEncoding.DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return "", errors.Wrap(err, "invalid secret")
+	}
+
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(step))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	return fmt.Sprintf("%0*d", totpDigits, value%1_000_000), nil
+}
+
+// matchTOTP returns time step of the code if it's valid at the given time.
+func matchTOTP(secret, code string, now time.Time) (int64, bool) {
+	code = strings.ReplaceAll(strings.TrimSpace(code), " ", "")
+	if len(code) != totpDigits {
+		return 0, false
+	}
+
+	current := totpStep(now)
+
+	for step := current - totpSkew; step <= current+totpSkew; step++ {
+		expected, err := totpCode(secret, step)
+		if err != nil {
+			return 0, false
+		}
+
+		if hmac.Equal([]byte(expected), []byte(code)) {
+			return step, true
+		}
+	}
+
+	return 0, false
+}
+
+// generateRecoveryCodes returns codes formatted as "xxxxx-xxxxx".
+func generateRecoveryCodes() ([]string, error) {
+	codes := make([]string, recoveryCodesCount)
+
+	// base32 encodes 5 bits per char
+	buf := make([]byte, (recoveryCodeLength*5+7)/8)
+
+	for i := range codes {
+		if _, err := rand.Read(buf); err != nil {
+			return nil, errors.Wrap(err, "unable to generate recovery code")
+		}
+
+		raw := strings.ToLower(totpEncoding.EncodeToString(buf))[:recoveryCodeLength]
+		codes[i] = raw[:recoveryCodeLength/2] + "-" + raw[recoveryCodeLength/2:]
+	}
+
+	return codes, nil
+}
+
+// hashRecoveryCode normalizes and hashes the code. Codes are random, so
+// a fast hash is enough and allows lookup by hash.
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(strings.TrimSpace(code)))
+	sum := sha256.Sum256([]byte(normalized))
+
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,92 @@
+package user
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// base32 of RFC 6238 SHA1 test key "12345678901234567890"
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCode(t *testing.T) {
+	// RFC 6238 Appendix B, last 6 of 8 digits
+	for unix, expected := range map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1111111111: "050471",
+		1234567890: "005924",
+		2000000000: "279037",
+	} {
+		code, err := totpCode(rfcSecret, totpStep(time.Unix(unix, 0)))
+		require.NoError(t, err)
+		assert.Equal(t, expected, code, unix)
+	}
+}
+
+func TestMatchTOTP(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	step := totpStep(now)
+
+	code, err := totpCode(rfcSecret, step)
+	require.NoError(t, err)
+
+	matched, ok := matchTOTP(rfcSecret, code, now)
+	assert.True(t, ok)
+	assert.Equal(t, step, matched)
+
+	// clock drift
+	matched, ok = matchTOTP(rfcSecret, code[:3]+" "+code[3:], now.Add(totpPeriod))
+	assert.True(t, ok)
+	assert.Equal(t, step, matched)
+
+	_, ok = matchTOTP(rfcSecret, code, now.Add(2*totpPeriod))
+	assert.False(t, ok)
+
+	for _, invalid := range []string{"", "12345", "1234567", "abcdef"} {
+		_, ok = matchTOTP(rfcSecret, invalid, now)
+		assert.False(t, ok, invalid)
+	}
+}
+
+func TestTOTPURI(t *testing.T) {
+	secret, err := generateTOTPSecret()
+	require.NoError(t, err)
+	assert.Len(t, secret, 32)
+
+	u, err := url.Parse(totpURI(secret, "user@example.com"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "otpauth", u.Scheme)
+	assert.Equal(t, "totp", u.Host)
+	assert.Equal(t, "/CryptoLink:user@example.com", u.Path)
+	assert.Equal(t, secret, u.Query().Get("secret"))
+	assert.Equal(t, "CryptoLink", u.Query().Get("issuer"))
+	assert.Equal(t, "6", u.Query().Get("digits"))
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, err := generateRecoveryCodes()
+	require.NoError(t, err)
+	require.Len(t, codes, recoveryCodesCount)
+
+	seen := make(map[string]struct{})
+	for _, code := range codes {
+		assert.Regexp(t, `^[a-z2-7]{5}-[a-z2-7]{5}$`, code)
+
+		hash := hashRecoveryCode(code)
+		assert.Len(t, hash, 64)
+		assert.NotContains(t, hash, code)
+
+		// input is normalized
+		assert.Equal(t, hash, hashRecoveryCode(" "+strings.ToUpper(strings.ReplaceAll(code, "-", ""))+" "))
+
+		seen[hash] = struct{}{}
+	}
+
+	assert.Len(t, seen, recoveryCodesCount)
+}
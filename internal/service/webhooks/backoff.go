@@ -0,0 +1,53 @@
+package webhooks
+
+import (
+	"time"
+)
+
+const (
+	// retryBaseDelay delay after the first failed attempt, doubled after each next one.
+	retryBaseDelay = 30 * time.Second
+
+	// retryMaxDelay caps the delay between attempts.
+	retryMaxDelay = 6 * time.Hour
+
+	// retryMaxAge since the first attempt after which failing delivery is given
+	// up and endpoint is disabled.
+	retryMaxAge = 72 * time.Hour
+
+	// retryJitter spreads retries of many deliveries failing at once by ±20%.
+	retryJitter = 0.2
+)
+
+// retryDelay returns delay after given number of failed attempts.
+// random is a number in [0, 1) used for jitter.
+func retryDelay(attempts int, random float64) time.Duration {
+	delay := retryMaxDelay
+
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	// avoid overflow: 30s << 10 already exceeds the cap
+	if attempts <= 10 {
+		if d := retryBaseDelay << (attempts - 1); d < retryMaxDelay {
+			delay = d
+		}
+	}
+
+	jitter := 1 + retryJitter*(2*random-1)
+
+	return time.Duration(float64(delay) * jitter)
+}
+
+// nextAttemptAt returns time of the next attempt of delivery first attempted
+// at firstAttemptAt after given number of failed attempts. False when delivery
+// should be given up.
+func nextAttemptAt(firstAttemptAt, now time.Time, attempts int, random float64) (time.Time, bool) {
+	next := now.Add(retryDelay(attempts, random))
+	if next.Sub(firstAttemptAt) > retryMaxAge {
+		return time.Time{}, false
+	}
+
+	return next, true
+}
@@ -0,0 +1,52 @@
+package webhooks
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryDelay(t *testing.T) {
+	for attempts, expected := range map[int]time.Duration{
+		1:  30 * time.Second,
+		2:  time.Minute,
+		5:  8 * time.Minute,
+		10: 256 * time.Minute,
+		11: 6 * time.Hour,
+		50: 6 * time.Hour,
+	} {
+		assert.Equal(t, expected, retryDelay(attempts, 0.5), attempts)
+	}
+
+	// jitter
+	assert.Equal(t, 24*time.Second, retryDelay(1, 0))
+	assert.InDelta(t, float64(36*time.Second), float64(retryDelay(1, 0.999999)), float64(time.Millisecond))
+}
+
+func TestNextAttemptAt(t *testing.T) {
+	firstAttemptAt := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	// replay the whole schedule without jitter
+	now, attempts := firstAttemptAt, 0
+	for {
+		attempts++
+
+		next, ok := nextAttemptAt(firstAttemptAt, now, attempts, 0.5)
+		if !ok {
+			break
+		}
+
+		assert.True(t, next.After(now))
+		now = next
+	}
+
+	assert.Equal(t, 21, attempts)
+	assert.True(t, now.Sub(firstAttemptAt) <= retryMaxAge)
+	assert.True(t, now.Sub(firstAttemptAt) > retryMaxAge-retryMaxDelay)
+
+	// delivery held back by older deliveries isn't given up on its first attempt
+	late := firstAttemptAt.Add(2 * retryMaxAge)
+	_, ok := nextAttemptAt(late, late, 1, 0.5)
+	assert.True(t, ok)
+}
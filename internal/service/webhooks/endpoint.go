@@ -0,0 +1,392 @@
+package webhooks
+
+import (
+	"context"
+	"net/url"
+	"time"
+
+	"github.com/cryptolink/cryptolink/internal/db/repository"
+	"github.com/cryptolink/cryptolink/internal/service/payment"
+	"github.com/cryptolink/cryptolink/internal/util"
+	"github.com/cryptolink/cryptolink/internal/webhook"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/pkg/errors"
+	"github.com/samber/lo"
+)
+
+// Event types webhook endpoints can subscribe to.
+const (
+	EventPaymentStatus         = payment.WebhookEventStatusUpdate
+	EventSubscriptionActivated = "subscription.activated"
+	EventSubscriptionRenewed   = "subscription.renewed"
+	EventSubscriptionPastDue   = "subscription.past_due"
+	EventSubscriptionCancelled = "subscription.cancelled"
+
+	// Typed events are sent as webhook.Envelope.
+	EventPaymentCreated        = webhook.EventPaymentCreated
+	EventPaymentMethodSelected = webhook.EventPaymentMethodSelected
+	EventPaymentPartial        = webhook.EventPaymentPartial
+	EventPaymentSucceeded      = webhook.EventPaymentSucceeded
+	EventPaymentExpired        = webhook.EventPaymentExpired
+	EventPaymentUnderpaid      = webhook.EventPaymentUnderpaid
+	EventPaymentLate           = webhook.EventPaymentLate
+	EventCustomerCreated       = webhook.EventCustomerCreated
+	EventPaymentLinkUsedUp     = webhook.EventPaymentLinkUsedUp
+	EventCollectorWithdrawn    = webhook.EventCollectorWithdrawn
+)
+
+// LegacyEventTypes lists event types sent without envelope. Endpoints managed
+// via the legacy merchant webhook settings are subscribed to them only.
+var LegacyEventTypes = []string{
+	EventPaymentStatus,
+	EventSubscriptionActivated,
+	EventSubscriptionRenewed,
+	EventSubscriptionPastDue,
+	EventSubscriptionCancelled,
+}
+
+// EventTypes lists all event types. Endpoint with no event types receives all of them.
+var EventTypes = lo.Flatten([][]string{LegacyEventTypes, webhook.EventTypes})
+
+const (
+	maxEndpoints       = 10
+	endpointSecretSize = 32
+
+	// MaxSecretRotationOverlap limits how long the previous secret stays valid.
+	MaxSecretRotationOverlap = 7 * 24 * time.Hour
+)
+
+var (
+	ErrEndpointNotFound = errors.New("webhook endpoint not found")
+	ErrEndpointLimit    = errors.New("webhook endpoints limit reached")
+	ErrInvalidEndpoint  = errors.New("invalid webhook endpoint")
+)
+
+// Endpoint is merchant's webhook receiver.
+type Endpoint struct {
+	ID          int64
+	UUID        uuid.UUID
+	MerchantID  int64
+	URL         string
+	Secret      string
+	Description string
+	Enabled     bool
+	TestOnly    bool
+	EventTypes  []string
+
+	// DisabledAt is set when endpoint was disabled after persistent delivery failures.
+	DisabledAt *time.Time
+
+	// PreviousSecret is valid until PreviousSecretExpiresAt after rotation.
+	PreviousSecret          string
+	PreviousSecretExpiresAt *time.Time
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Subscribed reports whether endpoint receives events of given type.
+func (e *Endpoint) Subscribed(eventType string) bool {
+	return len(e.EventTypes) == 0 || lo.Contains(e.EventTypes, eventType)
+}
+
+// Secrets returns secrets deliveries are signed with: the current one and the
+// previous one while rotation overlap lasts.
+func (e *Endpoint) Secrets(now time.Time) []string {
+	secrets := []string{e.Secret}
+
+	if e.PreviousSecret != "" && e.PreviousSecretExpiresAt != nil && now.Before(*e.PreviousSecretExpiresAt) {
+		secrets = append(secrets, e.PreviousSecret)
+	}
+
+	return secrets
+}
+
+type EndpointParams struct {
+	URL         string
+	Description string
+	Enabled     bool
+	TestOnly    bool
+	EventTypes  []string
+
+	// Secret is generated when empty.
+	Secret string
+}
+
+func (s *Service) ListEndpoints(ctx context.Context, merchantID int64) ([]*Endpoint, error) {
+	rows, err := s.store.ListWebhookEndpoints(ctx, merchantID)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to list webhook endpoints")
+	}
+
+	return util.MapSlice(rows, endpointFromRepo), nil
+}
+
+func (s *Service) GetEndpoint(ctx context.Context, merchantID int64, id uuid.UUID) (*Endpoint, error) {
+	row, err := s.store.GetWebhookEndpointByUUID(ctx, merchantID, id)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return nil, ErrEndpointNotFound
+	case err != nil:
+		return nil, errors.Wrap(err, "unable to get webhook endpoint")
+	}
+
+	return endpointFromRepo(row), nil
+}
+
+func (s *Service) CreateEndpoint(ctx context.Context, merchantID int64, params EndpointParams) (*Endpoint, error) {
+	if err := params.validate(); err != nil {
+		return nil, err
+	}
+
+	existing, err := s.store.ListWebhookEndpoints(ctx, merchantID)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to list webhook endpoints")
+	}
+
+	if len(existing) >= maxEndpoints {
+		return nil, ErrEndpointLimit
+	}
+
+	if params.Secret == "" {
+		params.Secret = util.Strings.Random(endpointSecretSize)
+	}
+
+	row, err := s.store.CreateWebhookEndpoint(ctx, repository.CreateWebhookEndpointParams{
+		MerchantID:  merchantID,
+		URL:         params.URL,
+		Secret:      params.Secret,
+		Description: params.Description,
+		Enabled:     params.Enabled,
+		TestOnly:    params.TestOnly,
+		EventTypes:  normalizeEventTypes(params.EventTypes),
+		CreatedAt:   time.Now().UTC(),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create webhook endpoint")
+	}
+
+	return endpointFromRepo(row), nil
+}
+
+// UpdateEndpoint replaces endpoint settings. Empty secret keeps the current one.
+// Enabling the endpoint resumes deliveries after it was disabled due to failures.
+func (s *Service) UpdateEndpoint(ctx context.Context, merchantID int64, id uuid.UUID, params EndpointParams) (*Endpoint, error) {
+	if err := params.validate(); err != nil {
+		return nil, err
+	}
+
+	e, err := s.GetEndpoint(ctx, merchantID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.updateEndpoint(ctx, e, params)
+}
+
+func (s *Service) DeleteEndpoint(ctx context.Context, merchantID int64, id uuid.UUID) error {
+	e, err := s.GetEndpoint(ctx, merchantID, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.store.DeleteWebhookEndpoint(ctx, e.ID, time.Now().UTC()); err != nil {
+		return errors.Wrap(err, "unable to delete webhook endpoint")
+	}
+
+	return nil
+}
+
+// RotateEndpointSecret generates new endpoint secret. The current secret stays
+// valid for the overlap so that in-flight deliveries can still be verified;
+// negative overlap means configured default.
+func (s *Service) RotateEndpointSecret(ctx context.Context, merchantID int64, id uuid.UUID, overlap time.Duration) (*Endpoint, error) {
+	if overlap < 0 {
+		overlap = s.config.SecretRotationOverlap
+	}
+
+	if overlap > MaxSecretRotationOverlap {
+		return nil, errors.Wrap(ErrInvalidEndpoint, "overlap is too long")
+	}
+
+	e, err := s.GetEndpoint(ctx, merchantID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+
+	row, err := s.store.RotateWebhookEndpointSecret(ctx, repository.RotateWebhookEndpointSecretParams{
+		ID:                e.ID,
+		Secret:            util.Strings.Random(endpointSecretSize),
+		PreviousExpiresAt: now.Add(overlap),
+		UpdatedAt:         now,
+	})
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return nil, ErrEndpointNotFound
+	case err != nil:
+		return nil, errors.Wrap(err, "unable to rotate webhook endpoint secret")
+	}
+
+	s.logger.Info().
+		Int64("merchant_id", merchantID).
+		Int64("endpoint_id", e.ID).
+		Dur("overlap", overlap).
+		Msg("rotated webhook endpoint secret")
+
+	return endpointFromRepo(row), nil
+}
+
+// PrimaryEndpoint returns merchant's oldest endpoint that backs legacy
+// single-URL webhook settings.
+func (s *Service) PrimaryEndpoint(ctx context.Context, merchantID int64) (*Endpoint, error) {
+	endpoints, err := s.ListEndpoints(ctx, merchantID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(endpoints) == 0 {
+		return nil, ErrEndpointNotFound
+	}
+
+	return endpoints[0], nil
+}
+
+// SetPrimaryEndpoint updates url and secret of the primary endpoint and enables
+// it, or creates one subscribed to legacy events.
+func (s *Service) SetPrimaryEndpoint(ctx context.Context, merchantID int64, endpointURL, secret string) (*Endpoint, error) {
+	e, err := s.PrimaryEndpoint(ctx, merchantID)
+	switch {
+	case errors.Is(err, ErrEndpointNotFound):
+		return s.CreateEndpoint(ctx, merchantID, EndpointParams{
+			URL:        endpointURL,
+			Secret:     secret,
+			Enabled:    true,
+			EventTypes: LegacyEventTypes,
+		})
+	case err != nil:
+		return nil, err
+	}
+
+	params := EndpointParams{
+		URL:         endpointURL,
+		Secret:      secret,
+		Description: e.Description,
+		Enabled:     true,
+		TestOnly:    e.TestOnly,
+		EventTypes:  e.EventTypes,
+	}
+
+	if err := params.validate(); err != nil {
+		return nil, err
+	}
+
+	return s.updateEndpoint(ctx, e, params)
+}
+
+// updateEndpoint updates endpoint settings. A new secret is rotated with the
+// configured overlap, so in-flight deliveries can still be verified.
+func (s *Service) updateEndpoint(ctx context.Context, e *Endpoint, params EndpointParams) (*Endpoint, error) {
+	now := time.Now().UTC()
+	rotate := params.Secret != "" && params.Secret != e.Secret
+
+	var row repository.WebhookEndpoint
+
+	err := s.store.RunTransaction(ctx, func(ctx context.Context, q repository.Querier) error {
+		var err error
+
+		row, err = q.UpdateWebhookEndpoint(ctx, repository.UpdateWebhookEndpointParams{
+			ID:          e.ID,
+			URL:         params.URL,
+			Description: params.Description,
+			Enabled:     params.Enabled,
+			TestOnly:    params.TestOnly,
+			EventTypes:  normalizeEventTypes(params.EventTypes),
+			UpdatedAt:   now,
+		})
+		if err != nil || !rotate {
+			return err
+		}
+
+		row, err = q.RotateWebhookEndpointSecret(ctx, repository.RotateWebhookEndpointSecretParams{
+			ID:                e.ID,
+			Secret:            params.Secret,
+			PreviousExpiresAt: now.Add(s.config.SecretRotationOverlap),
+			UpdatedAt:         now,
+		})
+
+		return err
+	})
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return nil, ErrEndpointNotFound
+	case err != nil:
+		return nil, errors.Wrap(err, "unable to update webhook endpoint")
+	}
+
+	if rotate {
+		s.logger.Info().
+			Int64("merchant_id", e.MerchantID).
+			Int64("endpoint_id", e.ID).
+			Dur("overlap", s.config.SecretRotationOverlap).
+			Msg("rotated webhook endpoint secret")
+	}
+
+	return endpointFromRepo(row), nil
+}
+
+func (p EndpointParams) validate() error {
+	u, err := url.ParseRequestURI(p.URL)
+	if err != nil || u.Hostname() == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return errors.Wrap(ErrInvalidEndpoint, "url is invalid")
+	}
+
+	if len(p.Description) > 256 {
+		return errors.Wrap(ErrInvalidEndpoint, "description should be at most 256 characters")
+	}
+
+	for _, t := range p.EventTypes {
+		if !lo.Contains(EventTypes, t) {
+			return errors.Wrapf(ErrInvalidEndpoint, "unknown event type %q", t)
+		}
+	}
+
+	return nil
+}
+
+func normalizeEventTypes(types []string) []string {
+	if types == nil {
+		return []string{}
+	}
+
+	return lo.Uniq(types)
+}
+
+func endpointFromRepo(row repository.WebhookEndpoint) *Endpoint {
+	e := &Endpoint{
+		ID:          row.ID,
+		UUID:        row.UUID,
+		MerchantID:  row.MerchantID,
+		URL:         row.URL,
+		Secret:      row.Secret,
+		Description: row.Description,
+		Enabled:     row.Enabled,
+		TestOnly:    row.TestOnly,
+		EventTypes:  row.EventTypes,
+		CreatedAt:   row.CreatedAt,
+		UpdatedAt:   row.UpdatedAt,
+	}
+
+	if row.DisabledAt.Valid {
+		e.DisabledAt = &row.DisabledAt.Time
+	}
+
+	if row.PreviousSecret.Valid && row.PreviousSecretExpiresAt.Valid {
+		e.PreviousSecret = row.PreviousSecret.String
+		e.PreviousSecretExpiresAt = &row.PreviousSecretExpiresAt.Time
+	}
+
+	return e
+}
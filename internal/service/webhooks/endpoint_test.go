@@ -0,0 +1,60 @@
+package webhooks
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cryptolink/cryptolink/internal/util"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEndpointParams_Validate(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		params EndpointParams
+		valid  bool
+	}{
+		{name: "all events", params: EndpointParams{URL: "https://example.com/hook"}, valid: true},
+		{
+			name:   "subscribed",
+			params: EndpointParams{URL: "http://example.com", EventTypes: []string{EventPaymentStatus}},
+			valid:  true,
+		},
+		{name: "relative url", params: EndpointParams{URL: "/hook"}},
+		{name: "ftp url", params: EndpointParams{URL: "ftp://example.com"}},
+		{name: "unknown event", params: EndpointParams{URL: "https://example.com", EventTypes: []string{"payment.foo"}}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.params.validate()
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, ErrInvalidEndpoint)
+			}
+		})
+	}
+}
+
+func TestEndpoint_Subscribed(t *testing.T) {
+	all := &Endpoint{}
+	assert.True(t, all.Subscribed(EventPaymentStatus))
+	assert.True(t, all.Subscribed(EventSubscriptionRenewed))
+
+	some := &Endpoint{EventTypes: []string{EventSubscriptionRenewed}}
+	assert.False(t, some.Subscribed(EventPaymentStatus))
+	assert.True(t, some.Subscribed(EventSubscriptionRenewed))
+}
+
+func TestEndpoint_Secrets(t *testing.T) {
+	now := time.Now()
+
+	e := &Endpoint{Secret: "new"}
+	assert.Equal(t, []string{"new"}, e.Secrets(now))
+
+	e.PreviousSecret = "old"
+	e.PreviousSecretExpiresAt = util.Ptr(now.Add(time.Hour))
+	assert.Equal(t, []string{"new", "old"}, e.Secrets(now))
+
+	e.PreviousSecretExpiresAt = util.Ptr(now.Add(-time.Second))
+	assert.Equal(t, []string{"new"}, e.Secrets(now))
+}
@@ -0,0 +1,323 @@
+package webhooks
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/cryptolink/cryptolink/internal/db/repository"
+	"github.com/cryptolink/cryptolink/internal/service/merchant"
+	"github.com/cryptolink/cryptolink/internal/webhook"
+	"github.com/google/uuid"
+	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v4"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrDeliveryNotFound = errors.New("webhook delivery not found")
+	ErrReplayDisabled   = errors.New("webhook endpoint is deleted or disabled")
+)
+
+// purgeBatch limits deliveries deleted by a single statement.
+const purgeBatch = 1000
+
+// Delivery of a single webhook event, see DeliveryStatus.
+type Delivery struct {
+	ID            int64
+	UUID          uuid.UUID
+	PaymentID     int64
+	PaymentUUID   *uuid.UUID
+	EndpointUUID  *uuid.UUID
+	EventID       *uuid.UUID
+	EventType     string
+	PaymentStatus string
+	Status        DeliveryStatus
+	Attempts      int
+	URL           string
+	Payload       json.RawMessage
+	ResponseCode  int
+	LastError     string
+	NextAttemptAt *time.Time
+	LastAttemptAt *time.Time
+	DeliveredAt   *time.Time
+	CreatedAt     time.Time
+
+	// ReplayOf is set when delivery was replayed manually.
+	ReplayOf *int64
+
+	// AttemptLog is filled only by GetDelivery.
+	AttemptLog []*Attempt
+}
+
+// Attempt is a log record of a single POST request to merchant's endpoint.
+type Attempt struct {
+	Attempt        int
+	URL            string
+	RequestHeaders map[string]string
+	RequestBody    string
+	ResponseStatus int
+	ResponseBody   string
+	Latency        time.Duration
+	ErrorClass     string
+	Error          string
+	CreatedAt      time.Time
+}
+
+type ListDeliveriesParams struct {
+	PaymentID  int64
+	EndpointID int64
+	Status     DeliveryStatus
+	BeforeID   int64
+	Limit      int
+}
+
+func (s *Service) ListDeliveries(ctx context.Context, merchantID int64, params ListDeliveriesParams) ([]*Delivery, error) {
+	rows, err := s.store.ListWebhookDeliveries(ctx, repository.ListWebhookDeliveriesParams{
+		MerchantID: merchantID,
+		PaymentID:  params.PaymentID,
+		EndpointID: params.EndpointID,
+		Status:     string(params.Status),
+		BeforeID:   params.BeforeID,
+		Limit:      int32(params.Limit),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to list webhook deliveries")
+	}
+
+	results := make([]*Delivery, 0, len(rows))
+	for _, row := range rows {
+		results = append(results, deliveryFromRepo(row))
+	}
+
+	return results, nil
+}
+
+// GetDelivery returns merchant's delivery with its attempt log.
+func (s *Service) GetDelivery(ctx context.Context, merchantID int64, id uuid.UUID) (*Delivery, error) {
+	row, err := s.store.GetWebhookDeliveryByUUID(ctx, merchantID, id)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return nil, ErrDeliveryNotFound
+	case err != nil:
+		return nil, errors.Wrap(err, "unable to get webhook delivery")
+	}
+
+	attempts, err := s.store.ListWebhookDeliveryAttempts(ctx, row.ID)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to list webhook delivery attempts")
+	}
+
+	d := deliveryFromRepo(row)
+	d.AttemptLog = make([]*Attempt, 0, len(attempts))
+
+	for _, a := range attempts {
+		d.AttemptLog = append(d.AttemptLog, attemptFromRepo(a))
+	}
+
+	return d, nil
+}
+
+// Replay enqueues past delivery again with the same payload and sends it
+// right away. Returns the new delivery.
+func (s *Service) Replay(ctx context.Context, mt *merchant.Merchant, id uuid.UUID) (*Delivery, error) {
+	original, err := s.store.GetWebhookDeliveryByUUID(ctx, mt.ID, id)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return nil, ErrDeliveryNotFound
+	case err != nil:
+		return nil, errors.Wrap(err, "unable to get webhook delivery")
+	case !original.EndpointID.Valid:
+		return nil, ErrReplayDisabled
+	}
+
+	endpoint, err := s.store.GetWebhookEndpointByID(ctx, original.EndpointID.Int64)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return nil, ErrReplayDisabled
+	case err != nil:
+		return nil, errors.Wrap(err, "unable to get webhook endpoint")
+	case !endpoint.Enabled:
+		return nil, ErrReplayDisabled
+	}
+
+	replay, err := s.store.CreateWebhookDeliveryReplay(ctx, original.ID, time.Now().UTC())
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create webhook delivery replay")
+	}
+
+	s.logger.Info().
+		Int64("merchant_id", mt.ID).
+		Int64("delivery_id", original.ID).
+		Int64("replay_id", replay.ID).
+		Msg("replaying webhook delivery")
+
+	return s.GetDelivery(ctx, mt.ID, replay.UUID)
+}
+
+// PurgeExpired deletes finished deliveries and merchant events older than
+// configured retention.
+func (s *Service) PurgeExpired(ctx context.Context) error {
+	if s.config.RetentionDays <= 0 {
+		return nil
+	}
+
+	before := time.Now().UTC().AddDate(0, 0, -s.config.RetentionDays)
+
+	var total int64
+	for {
+		deleted, err := s.store.DeleteWebhookDeliveriesBefore(ctx, before, purgeBatch)
+		if err != nil {
+			return errors.Wrap(err, "unable to delete expired webhook deliveries")
+		}
+
+		total += deleted
+
+		if deleted < purgeBatch || ctx.Err() != nil {
+			break
+		}
+	}
+
+	if total > 0 {
+		s.logger.Info().Int64("deleted", total).Time("before", before).Msg("purged expired webhook deliveries")
+	}
+
+	total = 0
+	for {
+		deleted, err := s.store.DeleteMerchantEventsBefore(ctx, before, purgeBatch)
+		if err != nil {
+			return errors.Wrap(err, "unable to delete expired merchant events")
+		}
+
+		total += deleted
+
+		if deleted < purgeBatch || ctx.Err() != nil {
+			break
+		}
+	}
+
+	if total > 0 {
+		s.logger.Info().Int64("deleted", total).Time("before", before).Msg("purged expired merchant events")
+	}
+
+	return nil
+}
+
+func newAttempt(
+	d repository.WebhookDelivery,
+	attempt int,
+	url string,
+	body []byte,
+	result webhook.Result,
+	errSend error,
+	now time.Time,
+) repository.InsertWebhookDeliveryAttemptParams {
+	headers := pgtype.JSONB{Bytes: []byte("{}"), Status: pgtype.Present}
+	if raw, err := json.Marshal(flattenHeaders(result.RequestHeaders)); err == nil {
+		headers.Bytes = raw
+	}
+
+	params := repository.InsertWebhookDeliveryAttemptParams{
+		DeliveryID:     d.ID,
+		MerchantID:     d.MerchantID,
+		Attempt:        int32(attempt),
+		URL:            url,
+		RequestHeaders: headers,
+		RequestBody:    string(body),
+		LatencyMS:      int32(result.Latency.Milliseconds()),
+		CreatedAt:      now,
+	}
+
+	if result.StatusCode != 0 {
+		params.ResponseStatus = sql.NullInt32{Int32: int32(result.StatusCode), Valid: true}
+		params.ResponseBody = sql.NullString{String: result.ResponseBody, Valid: true}
+	}
+
+	if errSend != nil {
+		params.ErrorClass = repository.StringToNullable(webhook.ErrorClass(errSend))
+		params.Error = repository.StringToNullable(errSend.Error())
+	}
+
+	return params
+}
+
+func flattenHeaders(h http.Header) map[string]string {
+	res := make(map[string]string, len(h))
+	for k := range h {
+		res[k] = h.Get(k)
+	}
+
+	return res
+}
+
+func deliveryFromRepo(row repository.WebhookDelivery) *Delivery {
+	d := &Delivery{
+		ID:            row.ID,
+		UUID:          row.UUID,
+		PaymentID:     row.PaymentID,
+		EventType:     row.EventType,
+		PaymentStatus: row.PaymentStatus,
+		Status:        DeliveryStatus(row.Status),
+		Attempts:      int(row.Attempts),
+		URL:           row.URL.String,
+		ResponseCode:  int(row.ResponseCode.Int32),
+		LastError:     row.LastError.String,
+		CreatedAt:     row.CreatedAt,
+	}
+
+	if row.Payload.Status == pgtype.Present {
+		d.Payload = row.Payload.Bytes
+	}
+
+	if d.Status == DeliveryPending {
+		d.NextAttemptAt = &row.NextAttemptAt
+	}
+
+	if row.LastAttemptAt.Valid {
+		d.LastAttemptAt = &row.LastAttemptAt.Time
+	}
+
+	if row.DeliveredAt.Valid {
+		d.DeliveredAt = &row.DeliveredAt.Time
+	}
+
+	if row.PaymentUUID.Valid {
+		d.PaymentUUID = &row.PaymentUUID.UUID
+	}
+
+	if row.EndpointUUID.Valid {
+		d.EndpointUUID = &row.EndpointUUID.UUID
+	}
+
+	if row.EventID.Valid {
+		d.EventID = &row.EventID.UUID
+	}
+
+	if row.ReplayOf.Valid {
+		d.ReplayOf = &row.ReplayOf.Int64
+	}
+
+	return d
+}
+
+func attemptFromRepo(row repository.WebhookDeliveryAttempt) *Attempt {
+	a := &Attempt{
+		Attempt:        int(row.Attempt),
+		URL:            row.URL,
+		RequestBody:    row.RequestBody,
+		ResponseStatus: int(row.ResponseStatus.Int32),
+		ResponseBody:   row.ResponseBody.String,
+		Latency:        time.Duration(row.LatencyMS) * time.Millisecond,
+		ErrorClass:     row.ErrorClass.String,
+		Error:          row.Error.String,
+		CreatedAt:      row.CreatedAt,
+	}
+
+	if row.RequestHeaders.Status == pgtype.Present {
+		_ = json.Unmarshal(row.RequestHeaders.Bytes, &a.RequestHeaders)
+	}
+
+	return a
+}
@@ -0,0 +1,59 @@
+package webhooks
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/cryptolink/cryptolink/internal/db/repository"
+	"github.com/cryptolink/cryptolink/internal/webhook"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewAttempt(t *testing.T) {
+	d := repository.WebhookDelivery{ID: 7, MerchantID: 3}
+	now := time.Now().UTC()
+	body := []byte(`{"id":"abc"}`)
+
+	t.Run("responded", func(t *testing.T) {
+		result := webhook.Result{
+			RequestHeaders: http.Header{"X-Signature": []string{"sig"}},
+			StatusCode:     http.StatusInternalServerError,
+			ResponseBody:   "oops",
+			Latency:        1500 * time.Millisecond,
+		}
+
+		errSend := errors.Wrap(webhook.ErrInvalidStatusCode, "code: 500")
+
+		a := newAttempt(d, 2, "https://example.com", body, result, errSend, now)
+
+		assert.Equal(t, int64(7), a.DeliveryID)
+		assert.Equal(t, int64(3), a.MerchantID)
+		assert.Equal(t, int32(2), a.Attempt)
+		assert.Equal(t, string(body), a.RequestBody)
+		assert.JSONEq(t, `{"X-Signature":"sig"}`, string(a.RequestHeaders.Bytes))
+		assert.Equal(t, int32(500), a.ResponseStatus.Int32)
+		assert.Equal(t, "oops", a.ResponseBody.String)
+		assert.Equal(t, int32(1500), a.LatencyMS)
+		assert.Equal(t, webhook.ErrorClassHTTPStatus, a.ErrorClass.String)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		errSend := errors.Wrap(webhook.ErrTimeout, "deadline exceeded")
+
+		a := newAttempt(d, 1, "https://example.com", body, webhook.Result{}, errSend, now)
+
+		assert.False(t, a.ResponseStatus.Valid)
+		assert.False(t, a.ResponseBody.Valid)
+		assert.JSONEq(t, `{}`, string(a.RequestHeaders.Bytes))
+		assert.Equal(t, webhook.ErrorClassTimeout, a.ErrorClass.String)
+	})
+
+	t.Run("delivered", func(t *testing.T) {
+		a := newAttempt(d, 1, "https://example.com", body, webhook.Result{StatusCode: http.StatusOK}, nil, now)
+
+		assert.False(t, a.ErrorClass.Valid)
+		assert.False(t, a.Error.Valid)
+	})
+}
@@ -0,0 +1,141 @@
+package webhooks
+
+import (
+	"context"
+	"time"
+
+	"github.com/cryptolink/cryptolink/internal/db/repository"
+	"github.com/cryptolink/cryptolink/internal/service/merchant"
+	"github.com/cryptolink/cryptolink/internal/service/payment"
+	"github.com/cryptolink/cryptolink/internal/util"
+	"github.com/cryptolink/cryptolink/internal/webhook"
+	"github.com/pkg/errors"
+)
+
+type PaymentWebhook struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+
+	CustomerEmail string `json:"customerEmail"`
+
+	SelectedBlockchain string `json:"selectedBlockchain"`
+	SelectedCurrency   string `json:"selectedCurrency"`
+
+	IsTest bool `json:"isTest"`
+
+	LinkID *string `json:"paymentLinkId"`
+
+	// Partial-fill payload — populated only when Status == "partial".
+	// Merchants should treat the `idempotencyKey` as a dedup token: it's
+	// "<network_id>:<tx_hash>:<vout_or_logidx>" of the most recent
+	// confirmed fill, so retried webhook deliveries for the same fill
+	// arrive with the same key.
+	ReceivedAmount  string `json:"receivedAmount,omitempty"`
+	RemainingAmount string `json:"remainingAmount,omitempty"`
+	IdempotencyKey  string `json:"idempotencyKey,omitempty"`
+}
+
+// SubscriptionWebhook is sent on customer subscription status transitions.
+type SubscriptionWebhook struct {
+	Event  string `json:"event"`
+	ID     string `json:"id"`
+	Status string `json:"status"`
+
+	PreviousStatus string `json:"previousStatus"`
+
+	PlanID        string `json:"planId"`
+	CustomerID    string `json:"customerId"`
+	CustomerEmail string `json:"customerEmail"`
+
+	CurrentPeriodStart time.Time `json:"currentPeriodStart"`
+	CurrentPeriodEnd   time.Time `json:"currentPeriodEnd"`
+	CancelAtPeriodEnd  bool      `json:"cancelAtPeriodEnd"`
+
+	IsTest bool `json:"isTest"`
+}
+
+// PaymentEventData is data of typed payment.* events: PaymentWebhook with
+// order and price details.
+type PaymentEventData struct {
+	PaymentWebhook
+
+	OrderID   *string    `json:"orderId"`
+	Price     string     `json:"price"`
+	Currency  string     `json:"currency"`
+	CreatedAt time.Time  `json:"createdAt"`
+	ExpiresAt *time.Time `json:"expiresAt"`
+}
+
+// buildPaymentEvent renders typed payment event envelope of the delivery.
+// Envelope id and time are the same for every endpoint the event is sent to.
+func (s *Service) buildPaymentEvent(ctx context.Context, mt *merchant.Merchant, d repository.WebhookDelivery) ([]byte, error) {
+	wh, err := s.buildPaymentWebhook(ctx, mt, d)
+	if err != nil {
+		return nil, err
+	}
+
+	pt, err := s.payments.GetByID(ctx, mt.ID, d.PaymentID)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to get payment")
+	}
+
+	data := PaymentEventData{
+		PaymentWebhook: wh,
+		OrderID:        pt.MerchantOrderID,
+		Price:          pt.Price.String(),
+		Currency:       pt.Price.Ticker(),
+		CreatedAt:      pt.CreatedAt.UTC(),
+	}
+
+	if pt.ExpiresAt != nil {
+		data.ExpiresAt = util.Ptr(pt.ExpiresAt.UTC())
+	}
+
+	return webhook.MarshalEvent(d.EventID.UUID, d.EventType, d.CreatedAt, data)
+}
+
+// buildPaymentWebhook renders webhook of the payment status recorded in the
+// delivery; other fields reflect the payment at the time of the first attempt.
+func (s *Service) buildPaymentWebhook(ctx context.Context, mt *merchant.Merchant, d repository.WebhookDelivery) (PaymentWebhook, error) {
+	p, err := s.processing.GetDetailedPayment(ctx, mt.ID, d.PaymentID)
+	if err != nil {
+		return PaymentWebhook{}, errors.Wrap(err, "unable to get detailed payment")
+	}
+
+	wh := PaymentWebhook{
+		ID:     p.Payment.MerchantOrderUUID.String(),
+		Status: d.PaymentStatus,
+		IsTest: p.Payment.IsTest,
+	}
+
+	if p.Customer != nil {
+		wh.CustomerEmail = p.Customer.Email
+	}
+
+	if p.PaymentMethod != nil {
+		wh.SelectedBlockchain = p.PaymentMethod.Currency.Blockchain.String()
+		wh.SelectedCurrency = p.PaymentMethod.Currency.Ticker
+	}
+
+	// Partial-fill enrichment: include received/remaining amounts and a
+	// per-fill idempotency key so merchants can dedup retried deliveries.
+	if d.PaymentStatus == payment.StatusPartial.String() && p.PaymentInfo != nil {
+		wh.ReceivedAmount = p.PaymentInfo.ReceivedAmount
+		wh.RemainingAmount = p.PaymentInfo.RemainingAmount
+
+		if key, keyErr := s.processing.LatestFillIdempotencyKey(ctx, mt.ID, d.PaymentID); keyErr == nil {
+			wh.IdempotencyKey = key
+		}
+	}
+
+	if p.Payment.LinkID() != 0 {
+		link, err := s.payments.GetPaymentLinkByID(ctx, mt.ID, p.Payment.LinkID())
+		if err != nil {
+			return PaymentWebhook{}, errors.Wrap(err, "unable to get payment link")
+		}
+
+		wh.LinkID = util.Ptr(link.PublicID.String())
+	}
+
+	return wh, nil
+}
@@ -0,0 +1,382 @@
+package webhooks
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/cryptolink/cryptolink/internal/db/repository"
+	"github.com/cryptolink/cryptolink/internal/service/email"
+	"github.com/cryptolink/cryptolink/internal/service/merchant"
+	"github.com/cryptolink/cryptolink/internal/service/payment"
+	"github.com/cryptolink/cryptolink/internal/service/processing"
+	"github.com/cryptolink/cryptolink/internal/webhook"
+	"github.com/google/uuid"
+	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v4"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+type Config struct {
+	RetentionDays         int           `yaml:"retention_days" env:"OXYGEN_WEBHOOKS_RETENTION_DAYS" env-default:"30" env-description:"How many days webhook deliveries, their attempts and streamed merchant events are kept"`
+	SecretRotationOverlap time.Duration `yaml:"secret_rotation_overlap" env:"OXYGEN_WEBHOOKS_SECRET_ROTATION_OVERLAP" env-default:"24h" env-description:"How long the previous endpoint secret stays valid after rotation by default"`
+}
+
+// Service delivers merchant webhooks from the outbox. Deliveries are written
+// by producers (payment service, collector indexer) in the same transaction
+// as the change that caused the event.
+type Service struct {
+	config     Config
+	store      *repository.Store
+	merchants  *merchant.Service
+	payments   *payment.Service
+	processing *processing.Service
+	emails     *email.Service
+	logger     *zerolog.Logger
+
+	// subscribers of merchant event streams served by this process
+	streamMu          sync.Mutex
+	streamSubscribers map[int64]map[chan struct{}]struct{}
+}
+
+type DeliveryStatus string
+
+const (
+	// DeliveryPending awaits the first attempt or a retry.
+	DeliveryPending DeliveryStatus = "pending"
+
+	// DeliveryDelivered merchant's endpoint responded with 2xx.
+	DeliveryDelivered DeliveryStatus = "delivered"
+
+	// DeliveryFailed all retries failed, the endpoint was disabled.
+	DeliveryFailed DeliveryStatus = "failed"
+
+	// DeliverySkipped webhook endpoint was disabled or deleted before delivery.
+	DeliverySkipped DeliveryStatus = "skipped"
+)
+
+const (
+	// deliveryLease how long claimed delivery is hidden from other workers.
+	// Should exceed webhook.Timeout with a good margin.
+	deliveryLease = 2 * time.Minute
+
+	deliveryBatch       = 50
+	deliveryConcurrency = 8
+)
+
+func New(
+	cfg Config,
+	store *repository.Store,
+	merchants *merchant.Service,
+	payments *payment.Service,
+	processingService *processing.Service,
+	emails *email.Service,
+	logger *zerolog.Logger,
+) *Service {
+	log := logger.With().Str("channel", "webhook_service").Logger()
+
+	return &Service{
+		config:     cfg,
+		store:      store,
+		merchants:  merchants,
+		payments:   payments,
+		processing: processingService,
+		emails:     emails,
+		logger:     &log,
+
+		streamSubscribers: make(map[int64]map[chan struct{}]struct{}),
+	}
+}
+
+// DeliverDue attempts due deliveries. Deliveries of different payments are
+// sent concurrently, deliveries of the same payment one by one in order.
+func (s *Service) DeliverDue(ctx context.Context) error {
+	deliveries, err := s.claim(ctx, deliveryBatch)
+	if err != nil {
+		return err
+	}
+
+	var (
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, deliveryConcurrency)
+	)
+
+	for i := range deliveries {
+		wg.Add(1)
+		sem <- struct{}{}
+
+		go func(d repository.WebhookDelivery) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			if err := s.deliver(ctx, d); err != nil {
+				s.logger.Error().Err(err).Int64("delivery_id", d.ID).Msg("unable to deliver webhook")
+			}
+		}(deliveries[i])
+	}
+
+	wg.Wait()
+
+	return nil
+}
+
+// SendEvent posts event to every enabled endpoint of the merchant subscribed
+// to it. Unlike payment webhooks, such events are sent once, without retries.
+func (s *Service) SendEvent(ctx context.Context, merchantID int64, eventType string, isTest bool, data any) error {
+	endpoints, err := s.store.ListMatchingWebhookEndpoints(ctx, merchantID, eventType, isTest)
+	if err != nil {
+		return errors.Wrap(err, "unable to list webhook endpoints")
+	}
+
+	body, err := json.Marshal(data)
+	if err != nil {
+		return errors.Wrap(err, "unable to marshal webhook")
+	}
+
+	for _, e := range endpoints {
+		logger := s.logger.With().
+			Int64("merchant_id", merchantID).
+			Int64("endpoint_id", e.ID).
+			Str("event", eventType).
+			Logger()
+
+		_, err := webhook.Deliver(ctx, webhook.Request{
+			URL:     e.URL,
+			ID:      uuid.NewString(),
+			Secrets: endpointFromRepo(e).Secrets(time.Now()),
+			Body:    body,
+		})
+		if err != nil {
+			logger.Warn().Err(err).Str("webhook_url", e.URL).Msg("unable to send webhook")
+			continue
+		}
+
+		logger.Info().Msg("sent webhook to merchant")
+	}
+
+	return nil
+}
+
+func (s *Service) claim(ctx context.Context, limit int32) ([]repository.WebhookDelivery, error) {
+	now := time.Now().UTC()
+
+	deliveries, err := s.store.ClaimWebhookDeliveries(ctx, repository.ClaimWebhookDeliveriesParams{
+		Now:        now,
+		LeaseUntil: now.Add(deliveryLease),
+		Limit:      limit,
+	})
+
+	return deliveries, errors.Wrap(err, "unable to claim webhook deliveries")
+}
+
+func (s *Service) deliver(ctx context.Context, d repository.WebhookDelivery) error {
+	mt, err := s.merchants.GetByID(ctx, d.MerchantID, false)
+	switch {
+	case errors.Is(err, merchant.ErrMerchantNotFound):
+		return s.skip(ctx, d, "merchant not found")
+	case err != nil:
+		return errors.Wrap(err, "unable to get merchant")
+	}
+
+	if !d.EndpointID.Valid {
+		return s.skip(ctx, d, "webhook endpoint is not set")
+	}
+
+	endpoint, err := s.store.GetWebhookEndpointByID(ctx, d.EndpointID.Int64)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return s.skip(ctx, d, "webhook endpoint was deleted")
+	case err != nil:
+		return errors.Wrap(err, "unable to get webhook endpoint")
+	case !endpoint.Enabled:
+		return s.skip(ctx, d, "webhook endpoint is disabled")
+	}
+
+	url := endpoint.URL
+
+	var (
+		body     []byte
+		result   webhook.Result
+		errSend  error
+		attempts = int(d.Attempts) + 1
+		payload  = pgtype.JSONB{Status: pgtype.Null}
+	)
+
+	if d.Payload.Status == pgtype.Present {
+		body = d.Payload.Bytes
+	} else {
+		body, errSend = s.render(ctx, mt, d)
+		if errSend == nil {
+			payload = pgtype.JSONB{Bytes: body, Status: pgtype.Present}
+		}
+	}
+
+	if errSend == nil {
+		result, errSend = webhook.Deliver(ctx, webhook.Request{
+			URL:     url,
+			ID:      d.UUID.String(),
+			Secrets: endpointFromRepo(endpoint).Secrets(time.Now()),
+			Body:    body,
+		})
+	}
+
+	now := time.Now().UTC()
+
+	update := repository.UpdateWebhookDeliveryAttemptParams{
+		ID:            d.ID,
+		Status:        string(DeliveryDelivered),
+		Payload:       payload,
+		URL:           url,
+		ResponseCode:  sql.NullInt32{Int32: int32(result.StatusCode), Valid: result.StatusCode != 0},
+		NextAttemptAt: now,
+		AttemptedAt:   now,
+		DeliveredAt:   repository.TimeToNullable(now),
+	}
+
+	giveUp := false
+
+	if errSend != nil {
+		update.LastError = repository.StringToNullable(errSend.Error())
+		update.DeliveredAt = sql.NullTime{}
+
+		// age is counted from the first attempt: a delivery may wait behind
+		// older deliveries of the same payment long after it was created
+		firstAttemptAt := now
+		if d.FirstAttemptAt.Valid {
+			firstAttemptAt = d.FirstAttemptAt.Time
+		}
+
+		//nolint:gosec // jitter doesn't need crypto random
+		next, ok := nextAttemptAt(firstAttemptAt, now, attempts, rand.Float64())
+		if ok {
+			update.Status = string(DeliveryPending)
+			update.NextAttemptAt = next
+		} else {
+			update.Status = string(DeliveryFailed)
+			giveUp = true
+		}
+	}
+
+	attempt := newAttempt(d, attempts, url, body, result, errSend, now)
+
+	err = s.store.RunTransaction(ctx, func(ctx context.Context, q repository.Querier) error {
+		if err := q.UpdateWebhookDeliveryAttempt(ctx, update); err != nil {
+			return errors.Wrap(err, "unable to update webhook delivery")
+		}
+
+		return errors.Wrap(q.InsertWebhookDeliveryAttempt(ctx, attempt), "unable to log webhook delivery attempt")
+	})
+	if err != nil {
+		return err
+	}
+
+	logger := s.logger.With().
+		Int64("delivery_id", d.ID).
+		Int64("merchant_id", d.MerchantID).
+		Int64("payment_id", d.PaymentID).
+		Int64("endpoint_id", endpoint.ID).
+		Str("webhook_url", url).
+		Int("attempt", attempts).
+		Logger()
+
+	switch {
+	case errSend == nil:
+		logger.Info().Msg("sent webhook to merchant")
+	case giveUp:
+		logger.Warn().Err(errSend).Msg("giving up webhook delivery")
+		s.disableEndpoint(ctx, mt, endpoint, d, errSend)
+	default:
+		logger.Warn().Err(errSend).Time("next_attempt_at", update.NextAttemptAt).Msg("unable to send webhook")
+	}
+
+	return nil
+}
+
+// render renders payload of payment events. Payload of other events is
+// rendered when they are enqueued.
+func (s *Service) render(ctx context.Context, mt *merchant.Merchant, d repository.WebhookDelivery) ([]byte, error) {
+	switch {
+	case d.PaymentID == 0:
+		return nil, errors.Errorf("webhook event %q has no payload", d.EventType)
+	case d.EventType == EventPaymentStatus:
+		wh, err := s.buildPaymentWebhook(ctx, mt, d)
+		if err != nil {
+			return nil, err
+		}
+
+		return json.Marshal(wh)
+	case d.EventID.Valid:
+		return s.buildPaymentEvent(ctx, mt, d)
+	default:
+		return nil, errors.Errorf("unknown webhook event type %q", d.EventType)
+	}
+}
+
+func (s *Service) skip(ctx context.Context, d repository.WebhookDelivery, reason string) error {
+	err := s.store.UpdateWebhookDeliveryStatus(
+		ctx,
+		d.ID,
+		string(DeliverySkipped),
+		repository.StringToNullable(reason),
+		time.Now().UTC(),
+	)
+	if err != nil {
+		return errors.Wrap(err, "unable to update webhook delivery")
+	}
+
+	s.logger.Warn().
+		Int64("merchant_id", d.MerchantID).Int64("payment_id", d.PaymentID).
+		Str("reason", reason).
+		Msg("skipping webhook delivery")
+
+	return nil
+}
+
+// disableEndpoint stops deliveries to the endpoint that kept failing for
+// retryMaxAge and lets merchant know by email.
+func (s *Service) disableEndpoint(
+	ctx context.Context,
+	mt *merchant.Merchant,
+	endpoint repository.WebhookEndpoint,
+	d repository.WebhookDelivery,
+	lastErr error,
+) {
+	disabled, err := s.store.DisableWebhookEndpoint(ctx, endpoint.ID, time.Now().UTC())
+	if err != nil {
+		s.logger.Error().Err(err).Int64("endpoint_id", endpoint.ID).Msg("unable to disable webhook endpoint")
+		return
+	}
+
+	if !disabled {
+		return
+	}
+
+	s.logger.Warn().
+		Int64("merchant_id", mt.ID).
+		Int64("endpoint_id", endpoint.ID).
+		Msg("disabled webhook endpoint after persistent failures")
+
+	if s.emails == nil {
+		return
+	}
+
+	to, err := s.emails.GetMerchantEmail(ctx, mt.ID)
+	if err != nil {
+		s.logger.Error().Err(err).Int64("merchant_id", mt.ID).Msg("unable to get merchant email")
+		return
+	}
+
+	s.emails.SendWebhookDisabled(ctx, email.WebhookDisabledParams{
+		MerchantEmail: to,
+		MerchantName:  mt.Name,
+		WebhookURL:    endpoint.URL,
+		LastError:     lastErr.Error(),
+		FailingSince:  d.CreatedAt.UTC().Format("2006-01-02 15:04 UTC"),
+	})
+}
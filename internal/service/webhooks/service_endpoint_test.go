@@ -0,0 +1,38 @@
+package webhooks_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cryptolink/cryptolink/internal/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_SetPrimaryEndpoint(t *testing.T) {
+	tc := test.NewIntegrationTest(t)
+
+	t.Run("new secret keeps the previous one valid for the overlap", func(t *testing.T) {
+		// ARRANGE
+		// Given a merchant with primary endpoint
+		u, _ := tc.Must.CreateSampleUser(t)
+		mt, _ := tc.Must.CreateMerchant(t, u.ID)
+
+		_, err := tc.Services.Webhooks.SetPrimaryEndpoint(tc.Context, mt.ID, "https://example.com/webhook", "old-secret")
+		require.NoError(t, err)
+
+		// ACT
+		// Change the secret in legacy webhook settings
+		e, err := tc.Services.Webhooks.SetPrimaryEndpoint(tc.Context, mt.ID, "https://example.com/webhook", "new-secret")
+
+		// ASSERT
+		require.NoError(t, err)
+		assert.Equal(t, []string{"new-secret", "old-secret"}, e.Secrets(time.Now()))
+		assert.Equal(t, []string{"new-secret"}, e.Secrets(time.Now().Add(2*time.Hour)))
+
+		// And the same secret doesn't rotate again
+		e, err = tc.Services.Webhooks.SetPrimaryEndpoint(tc.Context, mt.ID, "https://example.com/webhook", "new-secret")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"new-secret", "old-secret"}, e.Secrets(time.Now()))
+	})
+}
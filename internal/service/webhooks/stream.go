@@ -0,0 +1,281 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cryptolink/cryptolink/internal/db/repository"
+	"github.com/cryptolink/cryptolink/internal/service/merchant"
+	"github.com/cryptolink/cryptolink/internal/webhook"
+	"github.com/google/uuid"
+	"github.com/jackc/pgtype"
+	"github.com/pkg/errors"
+	"github.com/samber/lo"
+)
+
+const (
+	// streamPollInterval how often WatchStream checks the event log.
+	streamPollInterval = time.Second
+
+	streamWatchBatch = 1000
+
+	// MaxStreamsPerMerchant limits concurrent event streams of a merchant
+	// served by one process.
+	MaxStreamsPerMerchant = 10
+)
+
+var (
+	ErrInvalidCursor  = errors.New("invalid cursor")
+	ErrTooManyStreams = errors.New("too many open event streams")
+)
+
+// StreamEvent is typed event of merchant's event stream. Payload is the same
+// envelope that webhook endpoints receive, Cursor points right after the event.
+type StreamEvent struct {
+	ID      uuid.UUID
+	Type    string
+	Cursor  string
+	Payload json.RawMessage
+}
+
+type StreamParams struct {
+	// EventTypes filter, empty for all typed events.
+	EventTypes []string
+	Cursor     string
+	Limit      int32
+}
+
+// streamCursor is position in merchant_events.
+type streamCursor struct {
+	TxID int64
+	ID   int64
+}
+
+func (c streamCursor) String() string {
+	return fmt.Sprintf("%d-%d", c.TxID, c.ID)
+}
+
+func parseStreamCursor(raw string) (streamCursor, error) {
+	txID, id, ok := strings.Cut(raw, "-")
+	if !ok {
+		return streamCursor{}, ErrInvalidCursor
+	}
+
+	var (
+		c   streamCursor
+		err error
+	)
+
+	if c.TxID, err = strconv.ParseInt(txID, 10, 64); err != nil || c.TxID < 0 {
+		return streamCursor{}, ErrInvalidCursor
+	}
+
+	if c.ID, err = strconv.ParseInt(id, 10, 64); err != nil || c.ID < 0 {
+		return streamCursor{}, ErrInvalidCursor
+	}
+
+	return c, nil
+}
+
+// ValidateStreamParams checks event types and cursor of the stream.
+func ValidateStreamParams(params StreamParams) error {
+	for _, t := range params.EventTypes {
+		if !lo.Contains(webhook.EventTypes, t) {
+			return errors.Wrapf(ErrUnknownEventType, "%q", t)
+		}
+	}
+
+	if params.Cursor != "" {
+		if _, err := parseStreamCursor(params.Cursor); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// StreamHead returns cursor pointing after the last event of the merchant.
+func (s *Service) StreamHead(ctx context.Context, merchantID int64) (string, error) {
+	txID, id, err := s.store.GetMerchantEventsHead(ctx, merchantID)
+	if err != nil {
+		return "", errors.Wrap(err, "unable to get event log head")
+	}
+
+	return streamCursor{TxID: txID, ID: id}.String(), nil
+}
+
+// ListStreamEvents returns merchant's typed events after the cursor in the
+// order they were committed and the cursor after the returned batch. Like
+// webhooks, payload of payment events is rendered once, so a re-read event
+// has the same body; events that can't be rendered are skipped.
+func (s *Service) ListStreamEvents(ctx context.Context, mt *merchant.Merchant, params StreamParams) ([]*StreamEvent, string, error) {
+	cursor, err := parseStreamCursor(params.Cursor)
+	if err != nil {
+		return nil, "", err
+	}
+
+	rows, err := s.store.ListMerchantEvents(ctx, repository.ListMerchantEventsParams{
+		MerchantID: mt.ID,
+		EventTypes: normalizeEventTypes(params.EventTypes),
+		AfterTxID:  cursor.TxID,
+		AfterID:    cursor.ID,
+		Limit:      params.Limit,
+	})
+	if err != nil {
+		return nil, "", errors.Wrap(err, "unable to list merchant events")
+	}
+
+	events := make([]*StreamEvent, 0, len(rows))
+
+	for _, row := range rows {
+		cursor = streamCursor{TxID: row.TxID, ID: row.ID}
+
+		payload, err := s.streamPayload(ctx, mt, row)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, "", err
+			}
+
+			s.logger.Warn().Err(err).
+				Int64("merchant_id", mt.ID).Int64("payment_id", row.PaymentID).
+				Str("event", row.EventType).Str("event_id", row.EventID.String()).
+				Msg("skipping stream event")
+
+			continue
+		}
+
+		events = append(events, &StreamEvent{
+			ID:      row.EventID,
+			Type:    row.EventType,
+			Cursor:  cursor.String(),
+			Payload: payload,
+		})
+	}
+
+	return events, cursor.String(), nil
+}
+
+func (s *Service) streamPayload(ctx context.Context, mt *merchant.Merchant, row repository.MerchantEvent) ([]byte, error) {
+	if row.Payload.Status == pgtype.Present {
+		return row.Payload.Bytes, nil
+	}
+
+	body, err := s.render(ctx, mt, repository.WebhookDelivery{
+		MerchantID:    row.MerchantID,
+		PaymentID:     row.PaymentID,
+		EventType:     row.EventType,
+		EventID:       uuid.NullUUID{UUID: row.EventID, Valid: true},
+		PaymentStatus: row.PaymentStatus,
+		CreatedAt:     row.CreatedAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// another stream could render the event in the meantime
+	stored, err := s.store.UpdateMerchantEventPayload(ctx, row.ID, pgtype.JSONB{Bytes: body, Status: pgtype.Present})
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to store event payload")
+	}
+
+	return stored.Bytes, nil
+}
+
+// SubscribeStream returns a channel that receives a value when new events of
+// the merchant are logged, see WatchStream, and a function to unsubscribe.
+func (s *Service) SubscribeStream(merchantID int64) (<-chan struct{}, func(), error) {
+	s.streamMu.Lock()
+	defer s.streamMu.Unlock()
+
+	if len(s.streamSubscribers[merchantID]) >= MaxStreamsPerMerchant {
+		return nil, nil, ErrTooManyStreams
+	}
+
+	if s.streamSubscribers[merchantID] == nil {
+		s.streamSubscribers[merchantID] = make(map[chan struct{}]struct{})
+	}
+
+	ch := make(chan struct{}, 1)
+	s.streamSubscribers[merchantID][ch] = struct{}{}
+
+	unsubscribe := func() {
+		s.streamMu.Lock()
+		defer s.streamMu.Unlock()
+
+		delete(s.streamSubscribers[merchantID], ch)
+		if len(s.streamSubscribers[merchantID]) == 0 {
+			delete(s.streamSubscribers, merchantID)
+		}
+	}
+
+	return ch, unsubscribe, nil
+}
+
+// WatchStream polls the event log and wakes up stream subscribers of
+// merchants that have new events until ctx is done. Runs in every process
+// that serves streams.
+func (s *Service) WatchStream(ctx context.Context) {
+	ticker := time.NewTicker(streamPollInterval)
+	defer ticker.Stop()
+
+	var (
+		position streamCursor
+		started  bool
+	)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if !started {
+			txID, id, err := s.store.GetMerchantEventsHead(ctx, 0)
+			if err != nil {
+				s.logger.Error().Err(err).Msg("unable to get event log head")
+				continue
+			}
+
+			position, started = streamCursor{TxID: txID, ID: id}, true
+
+			continue
+		}
+
+		rows, err := s.store.ListMerchantEventPositions(ctx, position.TxID, position.ID, streamWatchBatch)
+		if err != nil {
+			if ctx.Err() == nil {
+				s.logger.Error().Err(err).Msg("unable to list merchant events")
+			}
+
+			continue
+		}
+
+		for _, merchantID := range lo.Uniq(lo.Map(rows, func(p repository.MerchantEventPosition, _ int) int64 {
+			return p.MerchantID
+		})) {
+			s.notifyStream(merchantID)
+		}
+
+		if len(rows) > 0 {
+			last := rows[len(rows)-1]
+			position = streamCursor{TxID: last.TxID, ID: last.ID}
+		}
+	}
+}
+
+func (s *Service) notifyStream(merchantID int64) {
+	s.streamMu.Lock()
+	defer s.streamMu.Unlock()
+
+	for ch := range s.streamSubscribers[merchantID] {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
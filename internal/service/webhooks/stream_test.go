@@ -0,0 +1,64 @@
+package webhooks
+
+import (
+	"testing"
+
+	"github.com/cryptolink/cryptolink/internal/webhook"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamCursor(t *testing.T) {
+	c, err := parseStreamCursor(streamCursor{TxID: 1024, ID: 7}.String())
+	require.NoError(t, err)
+	assert.Equal(t, streamCursor{TxID: 1024, ID: 7}, c)
+
+	for _, raw := range []string{"", "1", "1-", "-1", "a-1", "1-b", "1--2"} {
+		_, err := parseStreamCursor(raw)
+		assert.ErrorIs(t, err, ErrInvalidCursor, raw)
+	}
+}
+
+func TestValidateStreamParams(t *testing.T) {
+	assert.NoError(t, ValidateStreamParams(StreamParams{}))
+	assert.NoError(t, ValidateStreamParams(StreamParams{
+		EventTypes: []string{webhook.EventPaymentSucceeded, webhook.EventCustomerCreated},
+		Cursor:     "10-2",
+	}))
+
+	// legacy events are not streamed
+	err := ValidateStreamParams(StreamParams{EventTypes: []string{EventPaymentStatus}})
+	assert.ErrorIs(t, err, ErrUnknownEventType)
+
+	err = ValidateStreamParams(StreamParams{Cursor: "abc"})
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+}
+
+func TestSubscribeStream(t *testing.T) {
+	s := &Service{streamSubscribers: make(map[int64]map[chan struct{}]struct{})}
+
+	unsubscribes := make([]func(), 0, MaxStreamsPerMerchant)
+	for i := 0; i < MaxStreamsPerMerchant; i++ {
+		_, unsubscribe, err := s.SubscribeStream(1)
+		require.NoError(t, err)
+		unsubscribes = append(unsubscribes, unsubscribe)
+	}
+
+	_, _, err := s.SubscribeStream(1)
+	assert.ErrorIs(t, err, ErrTooManyStreams)
+
+	other, unsubscribeOther, err := s.SubscribeStream(2)
+	require.NoError(t, err)
+
+	// pending wake ups are coalesced
+	s.notifyStream(2)
+	s.notifyStream(2)
+	assert.Len(t, other, 1)
+
+	unsubscribeOther()
+	for _, unsubscribe := range unsubscribes {
+		unsubscribe()
+	}
+
+	assert.Empty(t, s.streamSubscribers)
+}
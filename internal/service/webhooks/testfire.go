@@ -0,0 +1,212 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/cryptolink/cryptolink/internal/db/repository"
+	"github.com/cryptolink/cryptolink/internal/service/merchant"
+	"github.com/cryptolink/cryptolink/internal/service/payment"
+	"github.com/cryptolink/cryptolink/internal/util"
+	"github.com/cryptolink/cryptolink/internal/webhook"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/samber/lo"
+)
+
+var (
+	ErrUnknownEventType = errors.New("unknown webhook event type")
+	ErrNotPaymentEvent  = errors.New("webhook event type is not a payment event")
+)
+
+// SendTestEvent delivers synthetic event of given type to the endpoint with
+// the same signing as real deliveries and returns the exchange. Test events
+// carry webhook.HeaderWebhookTest, isTest=true and nil resource ids; they are
+// neither logged nor retried. Disabled endpoints can be tested too.
+func (s *Service) SendTestEvent(ctx context.Context, merchantID int64, endpointID uuid.UUID, eventType string) (*Attempt, error) {
+	e, err := s.GetEndpoint(ctx, merchantID, endpointID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+
+	body, err := samplePayload(eventType, now)
+	if err != nil {
+		return nil, err
+	}
+
+	result, errSend := webhook.Deliver(ctx, webhook.Request{
+		URL:     e.URL,
+		ID:      uuid.NewString(),
+		Secrets: e.Secrets(now),
+		Body:    body,
+		Test:    true,
+	})
+
+	s.logger.Info().
+		Int64("merchant_id", merchantID).
+		Int64("endpoint_id", e.ID).
+		Str("event", eventType).
+		Int("response_status", result.StatusCode).
+		AnErr("send_error", errSend).
+		Msg("sent test webhook")
+
+	a := &Attempt{
+		Attempt:        1,
+		URL:            e.URL,
+		RequestHeaders: flattenHeaders(result.RequestHeaders),
+		RequestBody:    string(body),
+		ResponseStatus: result.StatusCode,
+		ResponseBody:   result.ResponseBody,
+		Latency:        result.Latency,
+		CreatedAt:      now,
+	}
+
+	if errSend != nil {
+		a.ErrorClass = webhook.ErrorClass(errSend)
+		a.Error = errSend.Error()
+	}
+
+	return a, nil
+}
+
+// PreviewPaymentEvent renders payload the payment would be delivered with for
+// given payment event type in its current status, without sending it.
+// Envelope id of typed events is random.
+func (s *Service) PreviewPaymentEvent(ctx context.Context, mt *merchant.Merchant, pt *payment.Payment, eventType string) ([]byte, error) {
+	if !lo.Contains(EventTypes, eventType) {
+		return nil, ErrUnknownEventType
+	}
+
+	if eventType != EventPaymentStatus && !strings.HasPrefix(eventType, "payment.") {
+		return nil, ErrNotPaymentEvent
+	}
+
+	d := repository.WebhookDelivery{
+		MerchantID:    mt.ID,
+		PaymentID:     pt.ID,
+		EventType:     eventType,
+		PaymentStatus: pt.Status.String(),
+		CreatedAt:     time.Now().UTC(),
+	}
+
+	if eventType != EventPaymentStatus {
+		d.EventID = uuid.NullUUID{UUID: uuid.New(), Valid: true}
+	}
+
+	return s.render(ctx, mt, d)
+}
+
+// samplePayload renders synthetic event of given type.
+func samplePayload(eventType string, now time.Time) ([]byte, error) {
+	if !lo.Contains(EventTypes, eventType) {
+		return nil, ErrUnknownEventType
+	}
+
+	nilID := uuid.Nil.String()
+
+	switch {
+	case eventType == EventPaymentStatus:
+		return json.Marshal(samplePaymentWebhook(payment.StatusSuccess))
+	case strings.HasPrefix(eventType, "subscription."):
+		status := strings.TrimPrefix(eventType, "subscription.")
+		if eventType == EventSubscriptionActivated || eventType == EventSubscriptionRenewed {
+			status = "active"
+		}
+
+		return json.Marshal(SubscriptionWebhook{
+			Event:              eventType,
+			ID:                 nilID,
+			Status:             status,
+			PreviousStatus:     "active",
+			PlanID:             nilID,
+			CustomerID:         nilID,
+			CustomerEmail:      "test@example.com",
+			CurrentPeriodStart: now.AddDate(0, -1, 0),
+			CurrentPeriodEnd:   now,
+			IsTest:             true,
+		})
+	}
+
+	var data any
+
+	switch eventType {
+	case EventCustomerCreated:
+		data = webhook.CustomerData{ID: nilID, Email: "test@example.com", CreatedAt: now}
+	case EventPaymentLinkUsedUp:
+		data = webhook.PaymentLinkUsedUpData{
+			ID:            nilID,
+			Name:          "Test link",
+			URL:           "https://example.com/link/test",
+			MaxUses:       10,
+			Uses:          10,
+			LastPaymentID: nilID,
+			IsTest:        true,
+		}
+	case EventCollectorWithdrawn:
+		data = webhook.CollectorWithdrawnData{
+			CollectorID:     nilID,
+			Blockchain:      "ETH",
+			ContractAddress: "0x0000000000000000000000000000000000000000",
+			Ticker:          "ETH",
+			ToAddress:       "0x0000000000000000000000000000000000000000",
+			Amount:          "0.5",
+			TxHash:          "0x0000000000000000000000000000000000000000000000000000000000000000",
+		}
+	default:
+		data = samplePaymentEvent(eventType, now)
+	}
+
+	return webhook.MarshalEvent(uuid.New(), eventType, now, data)
+}
+
+func samplePaymentWebhook(status payment.Status) PaymentWebhook {
+	return PaymentWebhook{
+		ID:                 uuid.Nil.String(),
+		Status:             status.String(),
+		CustomerEmail:      "test@example.com",
+		SelectedBlockchain: "ETH",
+		SelectedCurrency:   "ETH_USDT",
+		IsTest:             true,
+	}
+}
+
+func samplePaymentEvent(eventType string, now time.Time) PaymentEventData {
+	status := map[string]payment.Status{
+		EventPaymentCreated:        payment.StatusPending,
+		EventPaymentMethodSelected: payment.StatusLocked,
+		EventPaymentPartial:        payment.StatusPartial,
+		EventPaymentSucceeded:      payment.StatusSuccess,
+		EventPaymentExpired:        payment.StatusFailed,
+		EventPaymentUnderpaid:      payment.StatusUnderpaid,
+		EventPaymentLate:           payment.StatusSuccess,
+	}[eventType]
+
+	wh := samplePaymentWebhook(status)
+	if status == payment.StatusPending {
+		wh.CustomerEmail, wh.SelectedBlockchain, wh.SelectedCurrency = "", "", ""
+	}
+
+	if status == payment.StatusPartial {
+		wh.ReceivedAmount = "10"
+		wh.RemainingAmount = "19.9"
+		wh.IdempotencyKey = "test"
+	}
+
+	data := PaymentEventData{
+		PaymentWebhook: wh,
+		OrderID:        util.Ptr("test"),
+		Price:          "29.9",
+		Currency:       "USD",
+		CreatedAt:      now.Add(-10 * time.Minute),
+	}
+
+	if status != payment.StatusPending {
+		data.ExpiresAt = util.Ptr(now.Add(10 * time.Minute))
+	}
+
+	return data
+}
@@ -0,0 +1,41 @@
+package webhooks
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/cryptolink/cryptolink/internal/webhook"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSamplePayload(t *testing.T) {
+	now := time.Date(2026, 7, 1, 12, 0, 0, 0, time.UTC)
+
+	for _, eventType := range EventTypes {
+		t.Run(eventType, func(t *testing.T) {
+			body, err := samplePayload(eventType, now)
+			require.NoError(t, err)
+
+			var raw map[string]any
+			require.NoError(t, json.Unmarshal(body, &raw))
+
+			if lo.Contains(LegacyEventTypes, eventType) {
+				assert.Equal(t, true, raw["isTest"])
+				return
+			}
+
+			var envelope webhook.Envelope
+			require.NoError(t, json.Unmarshal(body, &envelope))
+			assert.Equal(t, eventType, envelope.Type)
+			assert.Equal(t, webhook.APIVersion, envelope.APIVersion)
+			assert.Equal(t, now, envelope.Created)
+			assert.NotEmpty(t, envelope.Data)
+		})
+	}
+
+	_, err := samplePayload("payment.foo", now)
+	assert.ErrorIs(t, err, ErrUnknownEventType)
+}
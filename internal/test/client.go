@@ -76,6 +76,11 @@ func (r *Request) WithToken(token string) *Request {
 	return r
 }
 
+func (r *Request) Header(key, value string) *Request {
+	r.headers[key] = value
+	return r
+}
+
 func (r *Request) Body(body []byte) *Request {
 	r.body = body
 	return r
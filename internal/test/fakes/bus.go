@@ -1,9 +1,11 @@
 package fakes
 
 import (
+	"context"
 	"sync"
 
 	"github.com/cryptolink/cryptolink/internal/bus"
+	"github.com/cryptolink/cryptolink/internal/db/repository"
 	"github.com/samber/lo"
 )
 
@@ -21,6 +23,10 @@ func (b *Bus) Publish(topic bus.Topic, message any) error {
 	return nil
 }
 
+func (b *Bus) PublishTx(_ context.Context, _ repository.Querier, topic bus.Topic, message any) error {
+	return b.Publish(topic, message)
+}
+
 func (b *Bus) GetBusCalls() []lo.Tuple2[bus.Topic, any] {
 	b.mu.RLock()
 	defer b.mu.RUnlock()
@@ -22,14 +22,18 @@ import (
 	"github.com/cryptolink/cryptolink/internal/server/http/middleware"
 	"github.com/cryptolink/cryptolink/internal/server/http/paymentapi"
 	"github.com/cryptolink/cryptolink/internal/server/http/webhook"
+	"github.com/cryptolink/cryptolink/internal/service/audit"
 	"github.com/cryptolink/cryptolink/internal/service/blockchain"
+	"github.com/cryptolink/cryptolink/internal/service/ledger"
 	"github.com/cryptolink/cryptolink/internal/service/merchant"
 	"github.com/cryptolink/cryptolink/internal/service/payment"
 	"github.com/cryptolink/cryptolink/internal/service/processing"
 	"github.com/cryptolink/cryptolink/internal/service/registry"
+	"github.com/cryptolink/cryptolink/internal/service/subscription"
 	"github.com/cryptolink/cryptolink/internal/service/transaction"
 	"github.com/cryptolink/cryptolink/internal/service/user"
 	"github.com/cryptolink/cryptolink/internal/service/wallet"
+	"github.com/cryptolink/cryptolink/internal/service/webhooks"
 	"github.com/cryptolink/cryptolink/internal/service/xpub"
 	"github.com/cryptolink/cryptolink/internal/test/fakes"
 	"github.com/cryptolink/cryptolink/internal/util"
@@ -68,6 +72,8 @@ type Services struct {
 	Transaction      *transaction.Service
 	Blockchain       *blockchain.Service
 	Processing       *processing.Service
+	Subscriptions    *subscription.Service
+	Webhooks         *webhooks.Service
 	Registry         *registry.Service
 	Locker           *lock.Locker
 	JobLogger        *log.JobLogger
@@ -129,14 +135,15 @@ func NewIntegrationTest(t *testing.T) *IntegrationTest {
 	locker := lock.New(storage)
 
 	authTokenManager := auth.NewTokenAuth(repo, &logger)
-	merchantsService := merchant.New(repo, blockchainService, &logger)
+	merchantsService := merchant.New(repo, storage, blockchainService, merchant.BrandingConfig{}, &logger)
 	usersService := user.New(storage, globalFaker.Bus, kv, &logger)
 	walletsService := wallet.New(globalFaker.ConvertorProxy, storage, &logger)
-	transactionsService := transaction.New(storage, globalFaker.CurrencyResolver, walletsService, &logger)
+	ledgerService := ledger.New(storage, nil, &logger) // spot valuation is not needed in tests
+	transactionsService := transaction.New(storage, globalFaker.CurrencyResolver, walletsService, ledgerService, &logger)
 	xpubService := xpub.New(storage, &logger)
 
 	paymentsService := payment.New(
-		repo,
+		storage,
 		processingConfig.PaymentFrontendBasePath,
 		transactionsService,
 		merchantsService,
@@ -162,6 +169,20 @@ func NewIntegrationTest(t *testing.T) *IntegrationTest {
 		&logger,
 	)
 
+	webhooksService := webhooks.New(
+		webhooks.Config{SecretRotationOverlap: time.Hour},
+		storage,
+		merchantsService,
+		paymentsService,
+		processingService,
+		nil, // emailService (not needed in tests)
+		&logger,
+	)
+
+	subscriptionService := subscription.New(db.Conn().Pool, &logger)
+
+	auditService := audit.New(repo, merchantsService, nil, &logger)
+
 	jobLogger := log.NewJobLogger(storage)
 
 	googleConfig := auth.GoogleConfig{ClientID: "1", ClientSecret: "2", RedirectCallback: "3"}
@@ -177,6 +198,11 @@ func NewIntegrationTest(t *testing.T) *IntegrationTest {
 		xpubService,
 		nil, // evmCollectorService (not needed in tests)
 		nil, // subscriptionService (not needed in tests)
+		nil, // billingService (not needed in tests)
+		ledgerService,
+		webhooksService,
+		nil, // emailService (not needed in tests)
+		auditService,
 		globalFaker,
 		globalFaker.Bus,
 		&logger,
@@ -241,6 +267,8 @@ func NewIntegrationTest(t *testing.T) *IntegrationTest {
 			Wallet:           walletsService,
 			Payment:          paymentsService,
 			Processing:       processingService,
+			Subscriptions:    subscriptionService,
+			Webhooks:         webhooksService,
 			Transaction:      transactionsService,
 			Blockchain:       blockchainService,
 			Registry:         kv,
@@ -74,7 +74,11 @@ func (m *Must) CreateMerchant(t *testing.T, userID int64) (*merchant.Merchant, s
 }
 
 func (m *Must) CreateMerchantToken(t *testing.T, mt *merchant.Merchant) string {
-	token, err := m.tc.Services.AuthTokenManager.CreateMerchantToken(m.tc.Context, mt.ID, "test")
+	token, err := m.tc.Services.AuthTokenManager.CreateMerchantToken(
+		m.tc.Context,
+		mt.ID,
+		auth.CreateTokenParams{Name: "test"},
+	)
 	require.NoError(t, err)
 
 	return token.Token
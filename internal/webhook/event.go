@@ -0,0 +1,99 @@
+package webhook
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+// APIVersion version of the event envelope and of event data schemas.
+// Bump it on breaking changes of any event schema.
+const APIVersion = "2026-07-01"
+
+// Typed event types sent inside Envelope.
+const (
+	EventPaymentCreated        = "payment.created"
+	EventPaymentMethodSelected = "payment.method_selected"
+	EventPaymentPartial        = "payment.partial"
+	EventPaymentSucceeded      = "payment.succeeded"
+	EventPaymentExpired        = "payment.expired"
+	EventPaymentUnderpaid      = "payment.underpaid"
+	EventPaymentLate           = "payment.late"
+
+	EventCustomerCreated    = "customer.created"
+	EventPaymentLinkUsedUp  = "payment_link.used_up"
+	EventCollectorWithdrawn = "collector.withdrawn"
+)
+
+// EventTypes lists typed event types.
+var EventTypes = []string{
+	EventPaymentCreated,
+	EventPaymentMethodSelected,
+	EventPaymentPartial,
+	EventPaymentSucceeded,
+	EventPaymentExpired,
+	EventPaymentUnderpaid,
+	EventPaymentLate,
+	EventCustomerCreated,
+	EventPaymentLinkUsedUp,
+	EventCollectorWithdrawn,
+}
+
+// Envelope wraps typed event data. ID is the same for every endpoint the
+// event is delivered to, so receivers can use it for deduplication.
+type Envelope struct {
+	ID         string          `json:"id"`
+	Type       string          `json:"type"`
+	Created    time.Time       `json:"created"`
+	APIVersion string          `json:"apiVersion"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// CustomerData is data of customer.created event.
+type CustomerData struct {
+	ID        string    `json:"id"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// PaymentLinkUsedUpData is data of payment_link.used_up event.
+type PaymentLinkUsedUpData struct {
+	ID            string `json:"id"`
+	Name          string `json:"name"`
+	URL           string `json:"url"`
+	MaxUses       int    `json:"maxUses"`
+	Uses          int    `json:"uses"`
+	LastPaymentID string `json:"lastPaymentId"`
+	IsTest        bool   `json:"isTest"`
+}
+
+// CollectorWithdrawnData is data of collector.withdrawn event.
+type CollectorWithdrawnData struct {
+	CollectorID     string  `json:"collectorId"`
+	Blockchain      string  `json:"blockchain"`
+	ContractAddress string  `json:"contractAddress"`
+	Ticker          string  `json:"ticker"`
+	TokenContract   *string `json:"tokenContract"`
+	ToAddress       string  `json:"toAddress"`
+	Amount          string  `json:"amount"`
+	TxHash          string  `json:"txHash"`
+	BlockNumber     int64   `json:"blockNumber"`
+}
+
+// MarshalEvent renders event envelope with given data.
+func MarshalEvent(id uuid.UUID, eventType string, created time.Time, data any) ([]byte, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, errors.Wrap(ErrInvalidInput, err.Error())
+	}
+
+	return json.Marshal(Envelope{
+		ID:         id.String(),
+		Type:       eventType,
+		Created:    created.UTC().Truncate(time.Second),
+		APIVersion: APIVersion,
+		Data:       raw,
+	})
+}
@@ -0,0 +1,51 @@
+package webhook
+
+import (
+	"context"
+	"time"
+
+	"github.com/cryptolink/cryptolink/internal/db/repository"
+	"github.com/google/uuid"
+	"github.com/jackc/pgtype"
+	"github.com/pkg/errors"
+)
+
+// EnqueueEvent renders typed event not related to a payment and writes it to
+// the outbox, one delivery per subscribed endpoint, and to the merchant event
+// log. Should be called within the transaction of the change it describes.
+func EnqueueEvent(ctx context.Context, q repository.Querier, merchantID int64, eventType string, isTest bool, data any) error {
+	var (
+		id  = uuid.New()
+		now = time.Now().UTC()
+	)
+
+	body, err := MarshalEvent(id, eventType, now, data)
+	if err != nil {
+		return errors.Wrapf(err, "unable to render %s webhook", eventType)
+	}
+
+	payload := pgtype.JSONB{Bytes: body, Status: pgtype.Present}
+
+	_, err = q.CreateWebhookEventDeliveries(ctx, repository.CreateWebhookEventDeliveriesParams{
+		MerchantID: merchantID,
+		EventType:  eventType,
+		EventID:    id,
+		IsTest:     isTest,
+		Payload:    payload,
+		CreatedAt:  now,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "unable to enqueue %s webhook", eventType)
+	}
+
+	err = q.CreateMerchantEvent(ctx, repository.CreateMerchantEventParams{
+		MerchantID: merchantID,
+		EventID:    id,
+		EventType:  eventType,
+		IsTest:     isTest,
+		Payload:    payload,
+		CreatedAt:  now,
+	})
+
+	return errors.Wrapf(err, "unable to log %s event", eventType)
+}
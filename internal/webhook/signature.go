@@ -0,0 +1,86 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrInvalidSignature = errors.New("invalid signature")
+	ErrSignatureExpired = errors.New("signature timestamp is outside of tolerance")
+)
+
+// SignatureV1 returns HeaderSignatureV1 value "t=<unix>,v1=<sig>[,v1=<sig>...]"
+// where sig is hex HMAC-SHA256 of "<unix>.<body>", one per secret.
+func SignatureV1(t time.Time, body []byte, secrets ...string) string {
+	ts := strconv.FormatInt(t.Unix(), 10)
+
+	parts := make([]string, 0, len(secrets)+1)
+	parts = append(parts, "t="+ts)
+
+	for _, secret := range secrets {
+		if secret == "" {
+			continue
+		}
+
+		parts = append(parts, "v1="+signV1(ts, body, secret))
+	}
+
+	return strings.Join(parts, ",")
+}
+
+// VerifySignatureV1 checks that header carries a valid signature of the body
+// for the secret and its timestamp is within tolerance of now.
+func VerifySignatureV1(header string, body []byte, secret string, tolerance time.Duration, now time.Time) error {
+	var (
+		ts         string
+		signatures []string
+	)
+
+	for _, part := range strings.Split(header, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+
+		switch key {
+		case "t":
+			ts = value
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+
+	unix, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil || len(signatures) == 0 {
+		return ErrInvalidSignature
+	}
+
+	if d := now.Sub(time.Unix(unix, 0)); d > tolerance || d < -tolerance {
+		return ErrSignatureExpired
+	}
+
+	expected := signV1(ts, body, secret)
+	for _, sig := range signatures {
+		if hmac.Equal([]byte(sig), []byte(expected)) {
+			return nil
+		}
+	}
+
+	return ErrInvalidSignature
+}
+
+func signV1(ts string, body []byte, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
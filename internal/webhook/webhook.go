@@ -7,16 +7,31 @@ import (
 	"crypto/sha512"
 	"encoding/base64"
 	"encoding/json"
+	"io"
+	"net"
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/pkg/errors"
 )
 
 const (
-	Timeout         = time.Second * 5
+	Timeout = time.Second * 5
+
+	// HeaderSignature legacy signature: base64 HMAC-SHA512 of the body.
 	HeaderSignature = "X-Signature"
+
+	// HeaderSignatureV1 timestamped signature, see SignatureV1.
+	HeaderSignatureV1 = "X-Webhook-Signature"
+
+	// HeaderWebhookID unique id of the delivery, same for its retries.
+	HeaderWebhookID = "X-Webhook-Id"
+
+	// HeaderWebhookTest marks synthetic test events sent by merchant's request.
+	HeaderWebhookTest = "X-Webhook-Test"
 )
 
 // client is configured with appropriate timeouts to prevent resource exhaustion
@@ -34,43 +49,143 @@ var client = &http.Client{
 var (
 	ErrInvalidInput      = errors.New("invalid input")
 	ErrInvalidStatusCode = errors.New("invalid status code")
+	ErrRequestFailed     = errors.New("request failed")
+	ErrTimeout           = errors.New("request timed out")
 )
 
 func Send(ctx context.Context, destination, secret string, data any) error {
-	ctx, cancel := context.WithTimeout(ctx, Timeout)
-	defer cancel()
-
-	if err := validateURL(destination); err != nil {
-		return errors.Wrap(ErrInvalidInput, err.Error())
-	}
-
 	body, err := json.Marshal(data)
 	if err != nil {
 		return errors.Wrap(ErrInvalidInput, err.Error())
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, destination, bytes.NewReader(body))
+	_, err = Deliver(ctx, Request{
+		URL:     destination,
+		ID:      uuid.NewString(),
+		Secrets: []string{secret},
+		Body:    body,
+	})
+
+	return err
+}
+
+// Request to merchant's webhook endpoint.
+type Request struct {
+	URL string
+
+	// ID is sent in HeaderWebhookID.
+	ID string
+
+	// Secrets sign the request. The first one is the current secret,
+	// others are previous secrets still valid during rotation.
+	Secrets []string
+
+	Body []byte
+
+	// Test marks synthetic event with HeaderWebhookTest.
+	Test bool
+}
+
+// MaxResponseBody max number of response body bytes kept in Result.
+const MaxResponseBody = 2048
+
+// Result of webhook delivery attempt.
+type Result struct {
+	RequestHeaders http.Header
+	StatusCode     int    // 0 when merchant's server didn't respond
+	ResponseBody   string // truncated to MaxResponseBody
+	Latency        time.Duration
+}
+
+// Error classes of failed delivery attempts, see ErrorClass.
+const (
+	ErrorClassInvalidRequest = "invalid_request"
+	ErrorClassTimeout        = "timeout"
+	ErrorClassConnection     = "connection"
+	ErrorClassHTTPStatus     = "http_status"
+)
+
+// Deliver posts signed JSON body to the endpoint.
+func Deliver(ctx context.Context, r Request) (Result, error) {
+	var result Result
+
+	ctx, cancel := context.WithTimeout(ctx, Timeout)
+	defer cancel()
+
+	if err := validateURL(r.URL); err != nil {
+		return result, errors.Wrap(ErrInvalidInput, err.Error())
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.URL, bytes.NewReader(r.Body))
 	if err != nil {
-		return errors.Wrap(ErrInvalidInput, err.Error())
+		return result, errors.Wrap(ErrInvalidInput, err.Error())
 	}
 
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("User-Agent", "CryptoLink-Webhook/1.0")
-	if errSign := SignRequest(req, body, secret); errSign != nil {
-		return errors.Wrap(ErrInvalidInput, errSign.Error())
+	if r.ID != "" {
+		req.Header.Set(HeaderWebhookID, r.ID)
+	}
+	if r.Test {
+		req.Header.Set(HeaderWebhookTest, "true")
+	}
+
+	var (
+		secret   string
+		previous []string
+	)
+
+	if len(r.Secrets) > 0 {
+		secret, previous = r.Secrets[0], r.Secrets[1:]
+	}
+
+	if errSign := SignRequest(req, r.Body, secret, previous...); errSign != nil {
+		return result, errors.Wrap(ErrInvalidInput, errSign.Error())
 	}
 
+	result.RequestHeaders = req.Header.Clone()
+
+	start := time.Now()
 	res, err := client.Do(req)
+	result.Latency = time.Since(start)
+
 	if err != nil {
-		return errors.Wrap(ErrInvalidInput, err.Error())
+		var netErr net.Error
+		if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+			return result, errors.Wrap(ErrTimeout, err.Error())
+		}
+
+		return result, errors.Wrap(ErrRequestFailed, err.Error())
 	}
 	defer res.Body.Close()
 
+	result.StatusCode = res.StatusCode
+
+	if raw, errRead := io.ReadAll(io.LimitReader(res.Body, MaxResponseBody)); errRead == nil {
+		result.ResponseBody = strings.ToValidUTF8(string(raw), "")
+	}
+
 	if res.StatusCode < 200 || res.StatusCode >= 300 {
-		return errors.Wrapf(ErrInvalidStatusCode, "code: %d %s", res.StatusCode, res.Status)
+		return result, errors.Wrapf(ErrInvalidStatusCode, "code: %d %s", res.StatusCode, res.Status)
 	}
 
-	return nil
+	return result, nil
+}
+
+// ErrorClass classifies error returned by Deliver.
+func ErrorClass(err error) string {
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, ErrInvalidStatusCode):
+		return ErrorClassHTTPStatus
+	case errors.Is(err, ErrTimeout):
+		return ErrorClassTimeout
+	case errors.Is(err, ErrRequestFailed):
+		return ErrorClassConnection
+	default:
+		return ErrorClassInvalidRequest
+	}
 }
 
 func validateURL(u string) error {
@@ -86,18 +201,27 @@ func validateURL(u string) error {
 	return nil
 }
 
-func SignRequest(req *http.Request, body []byte, secret string) error {
+// SignRequest sets HeaderSignatureV1 and legacy HeaderSignature signed with
+// the secret and previous secrets, if any. Legacy header is repeated for every
+// secret, the current one goes first.
+func SignRequest(req *http.Request, body []byte, secret string, previous ...string) error {
 	if secret == "" {
 		return nil
 	}
 
-	mac := hmac.New(sha512.New, []byte(secret))
-	if _, err := mac.Write(body); err != nil {
-		return err
+	secrets := append([]string{secret}, previous...)
+
+	req.Header.Del(HeaderSignature)
+	for _, s := range secrets {
+		mac := hmac.New(sha512.New, []byte(s))
+		if _, err := mac.Write(body); err != nil {
+			return err
+		}
+
+		req.Header.Add(HeaderSignature, base64.StdEncoding.EncodeToString(mac.Sum(nil)))
 	}
 
-	signature := base64.StdEncoding.EncodeToString(mac.Sum(nil))
-	req.Header.Set(HeaderSignature, signature)
+	req.Header.Set(HeaderSignatureV1, SignatureV1(time.Now(), body, secrets...))
 
 	return nil
 }
@@ -6,8 +6,11 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/samber/lo"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -58,6 +61,85 @@ func TestSend(t *testing.T) {
 	})
 }
 
+func TestDeliver(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Returns response", func(t *testing.T) {
+		s := assertServer(t, func(t *testing.T, writer http.ResponseWriter, request *http.Request) {
+			writer.WriteHeader(http.StatusBadRequest)
+			_, _ = writer.Write([]byte(strings.Repeat("x", MaxResponseBody+100)))
+		})
+
+		result, err := Deliver(ctx, Request{URL: s.URL, ID: "abc", Secrets: []string{"secret"}, Body: []byte(`{"id":"1"}`)})
+		assert.ErrorIs(t, err, ErrInvalidStatusCode)
+		assert.Equal(t, ErrorClassHTTPStatus, ErrorClass(err))
+
+		assert.Equal(t, http.StatusBadRequest, result.StatusCode)
+		assert.Len(t, result.ResponseBody, MaxResponseBody)
+		assert.NotEmpty(t, result.RequestHeaders.Get(HeaderSignature))
+		assert.NotEmpty(t, result.RequestHeaders.Get(HeaderSignatureV1))
+		assert.Equal(t, "abc", result.RequestHeaders.Get(HeaderWebhookID))
+		assert.Empty(t, result.RequestHeaders.Get(HeaderWebhookTest))
+		assert.Positive(t, result.Latency)
+	})
+
+	t.Run("Marks test events", func(t *testing.T) {
+		s := assertServer(t, func(t *testing.T, writer http.ResponseWriter, request *http.Request) {
+			assert.Equal(t, "true", request.Header.Get(HeaderWebhookTest))
+			writer.WriteHeader(http.StatusOK)
+		})
+
+		result, err := Deliver(ctx, Request{URL: s.URL, Secrets: []string{"secret"}, Body: []byte("{}"), Test: true})
+		assert.NoError(t, err)
+		assert.Equal(t, "true", result.RequestHeaders.Get(HeaderWebhookTest))
+	})
+
+	t.Run("Signs with previous secret during rotation", func(t *testing.T) {
+		body := []byte(`{"id":"1"}`)
+
+		s := assertServer(t, func(t *testing.T, writer http.ResponseWriter, request *http.Request) {
+			header := request.Header.Get(HeaderSignatureV1)
+
+			assert.NoError(t, VerifySignatureV1(header, body, "new", time.Minute, time.Now()))
+			assert.NoError(t, VerifySignatureV1(header, body, "old", time.Minute, time.Now()))
+			assert.ErrorIs(t, VerifySignatureV1(header, body, "other", time.Minute, time.Now()), ErrInvalidSignature)
+
+			// legacy header is repeated for every secret, the current one first
+			legacy := request.Header.Values(HeaderSignature)
+			require.Len(t, legacy, 2)
+			assert.True(t, ValidateHMAC(body, "new", legacy[0]))
+			assert.True(t, ValidateHMAC(body, "old", legacy[1]))
+
+			writer.WriteHeader(http.StatusOK)
+		})
+
+		_, err := Deliver(ctx, Request{URL: s.URL, ID: "abc", Secrets: []string{"new", "old"}, Body: body})
+		assert.NoError(t, err)
+	})
+
+	t.Run("Classifies errors", func(t *testing.T) {
+		_, err := Deliver(ctx, Request{URL: "not a url"})
+		assert.Equal(t, ErrorClassInvalidRequest, ErrorClass(err))
+
+		s := assertServer(t, func(t *testing.T, writer http.ResponseWriter, request *http.Request) {})
+		s.Close()
+
+		result, err := Deliver(ctx, Request{URL: s.URL, Body: []byte("{}")})
+		assert.Equal(t, ErrorClassConnection, ErrorClass(err))
+		assert.Zero(t, result.StatusCode)
+
+		canceled, cancel := context.WithTimeout(ctx, time.Millisecond)
+		defer cancel()
+
+		slow := assertServer(t, func(t *testing.T, writer http.ResponseWriter, request *http.Request) {
+			time.Sleep(100 * time.Millisecond)
+		})
+
+		_, err = Deliver(canceled, Request{URL: slow.URL, Body: []byte("{}")})
+		assert.Equal(t, ErrorClassTimeout, ErrorClass(err))
+	})
+}
+
 func assertBind(t *testing.T, request *http.Request, v any) {
 	bytes, err := io.ReadAll(request.Body)
 	require.NoError(t, err)
@@ -71,3 +153,39 @@ func assertServer(t *testing.T, handler func(*testing.T, http.ResponseWriter, *h
 
 	return httptest.NewServer(http.HandlerFunc(fn))
 }
+
+func TestVerifySignatureV1(t *testing.T) {
+	body := []byte(`{"id":"1"}`)
+	now := time.Unix(1700000000, 0)
+
+	header := SignatureV1(now, body, "secret")
+	assert.True(t, strings.HasPrefix(header, "t=1700000000,v1="))
+
+	assert.NoError(t, VerifySignatureV1(header, body, "secret", 5*time.Minute, now.Add(time.Minute)))
+	assert.ErrorIs(t, VerifySignatureV1(header, body, "secret", 5*time.Minute, now.Add(10*time.Minute)), ErrSignatureExpired)
+	assert.ErrorIs(t, VerifySignatureV1(header, []byte(`{"id":"2"}`), "secret", 5*time.Minute, now), ErrInvalidSignature)
+	assert.ErrorIs(t, VerifySignatureV1("garbage", body, "secret", 5*time.Minute, now), ErrInvalidSignature)
+}
+
+func TestMarshalEvent(t *testing.T) {
+	var (
+		id      = uuid.MustParse("3f1b9c8e-5d2a-4e7b-9a61-0c4b2e8f7d10")
+		created = time.Date(2026, 7, 1, 12, 30, 15, 500, time.FixedZone("UTC+2", 2*60*60))
+	)
+
+	body, err := MarshalEvent(id, EventCustomerCreated, created, sampleBodyValue)
+	require.NoError(t, err)
+
+	assert.JSONEq(t, `{
+		"id": "3f1b9c8e-5d2a-4e7b-9a61-0c4b2e8f7d10",
+		"type": "customer.created",
+		"created": "2026-07-01T10:30:15Z",
+		"apiVersion": "`+APIVersion+`",
+		"data": {"Message": "Hello, world!"}
+	}`, string(body))
+
+	t.Run("Rejects unsupported data", func(t *testing.T) {
+		_, err := MarshalEvent(id, EventCustomerCreated, created, make(chan int))
+		assert.ErrorIs(t, err, ErrInvalidInput)
+	})
+}
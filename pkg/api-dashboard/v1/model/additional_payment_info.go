@@ -51,6 +51,11 @@ type AdditionalPaymentInfo struct {
 
 	// Crypto currency ticker (e.g. "TRON_USDT")
 	CryptoTicker *string `json:"cryptoTicker,omitempty"`
+
+	// Decision applied by merchant's underpayment policy
+	// Example: withinTolerance
+	// Enum: [withinTolerance awaitingTopUp acceptedPartial expired topUpElapsed]
+	UnderpaymentDecision *string `json:"underpaymentDecision,omitempty"`
 }
 
 // Validate validates this additional payment info
@@ -19,21 +19,39 @@ import (
 // swagger:model apiToken
 type APIToken struct {
 
+	// Allowed client IPs or CIDRs
+	AllowedCidrs []string `json:"allowedCidrs"`
+
 	// CreatedAt
 	// Required: true
 	// Format: datetime
 	CreatedAt strfmt.DateTime `json:"createdAt"`
 
+	// Expiration time
+	// Format: date-time
+	ExpiresAt *strfmt.DateTime `json:"expiresAt"`
+
 	// token UUID
 	// Example: 1eb5fbb5-ece0-475c-9ddd-23c524a33e06
 	// Required: true
 	ID string `json:"id"`
 
+	// Last usage time
+	// Format: date-time
+	LastUsedAt *strfmt.DateTime `json:"lastUsedAt"`
+
+	// Last usage client IP
+	LastUsedIP *string `json:"lastUsedIp"`
+
 	// Name
 	// Example: My Token
 	// Required: true
 	Name string `json:"name"`
 
+	// Granted scopes
+	// Example: ["payments:read","payments:write"]
+	Scopes []string `json:"scopes"`
+
 	// Token
 	// Example: abc123
 	// Required: true
@@ -19,12 +19,24 @@ import (
 // swagger:model createMerchantTokenRequest
 type CreateMerchantTokenRequest struct {
 
+	// Allowed client IPs or CIDRs. Empty allows any IP
+	// Example: ["203.0.113.0/24"]
+	AllowedCidrs []string `json:"allowedCidrs"`
+
+	// Expiration time. Empty means the token never expires
+	// Format: date-time
+	ExpiresAt *strfmt.DateTime `json:"expiresAt,omitempty"`
+
 	// Name
 	// Example: My Token
 	// Required: true
 	// Max Length: 128
 	// Min Length: 2
 	Name string `json:"name"`
+
+	// Granted scopes. Empty grants full access
+	// Example: ["payments:read","payments:write"]
+	Scopes []string `json:"scopes"`
 }
 
 // Validate validates this create merchant token request
@@ -29,6 +29,11 @@ type CreatePaymentLinkRequest struct {
 	// Example: White T-shirt size M
 	Description *string `json:"description"`
 
+	// Optional limit of successful payments, the link stops accepting payments once reached
+	// Example: 100
+	// Minimum: 1
+	MaxUses *int64 `json:"maxUses"`
+
 	// Name
 	// Example: My Link
 	// Required: true
@@ -69,6 +74,10 @@ func (m *CreatePaymentLinkRequest) Validate(formats strfmt.Registry) error {
 		res = append(res, err)
 	}
 
+	if err := m.validateMaxUses(formats); err != nil {
+		res = append(res, err)
+	}
+
 	if err := m.validateName(formats); err != nil {
 		res = append(res, err)
 	}
@@ -134,6 +143,18 @@ func (m *CreatePaymentLinkRequest) validateCurrency(formats strfmt.Registry) err
 	return nil
 }
 
+func (m *CreatePaymentLinkRequest) validateMaxUses(formats strfmt.Registry) error {
+	if swag.IsZero(m.MaxUses) { // not required
+		return nil
+	}
+
+	if err := validate.MinimumInt("maxUses", "body", *m.MaxUses, 1, false); err != nil {
+		return err
+	}
+
+	return nil
+}
+
 func (m *CreatePaymentLinkRequest) validateName(formats strfmt.Registry) error {
 
 	if err := validate.RequiredString("name", "body", m.Name); err != nil {
@@ -28,6 +28,10 @@ type Merchant struct {
 	// Example: My Store
 	Name string `json:"name,omitempty"`
 
+	// role of the current user in the merchant
+	// Example: owner
+	Role string `json:"role,omitempty"`
+
 	// Merchant's preferred fiat currency code (e.g. "USD", "EUR", "GBP")
 	FiatCurrency string `json:"fiatCurrency,omitempty"`
 
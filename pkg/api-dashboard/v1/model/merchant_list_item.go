@@ -25,6 +25,10 @@ type MerchantListItem struct {
 	// Example: My Store
 	Name string `json:"name,omitempty"`
 
+	// role of the current user in the merchant
+	// Example: owner
+	Role string `json:"role,omitempty"`
+
 	// Website URL
 	// Example: https://my-store.com
 	Website string `json:"website,omitempty"`
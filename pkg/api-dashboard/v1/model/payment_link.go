@@ -40,6 +40,10 @@ type PaymentLink struct {
 	// Required: true
 	ID string `json:"id"`
 
+	// Limit of successful payments, null means unlimited
+	// Example: 100
+	MaxUses *int64 `json:"maxUses"`
+
 	// Name
 	// Example: My Link
 	// Required: true
@@ -69,6 +73,10 @@ type PaymentLink struct {
 	// Example: https://cryptolink.cc/p/link/ufaiCu6J
 	// Required: true
 	URL string `json:"url"`
+
+	// Number of successful payments
+	// Example: 3
+	Uses int64 `json:"uses"`
 }
 
 // Validate validates this payment link
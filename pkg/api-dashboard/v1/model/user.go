@@ -53,6 +53,12 @@ type User struct {
 
 	// marketing consent opt-in
 	MarketingConsent bool `json:"marketingConsent"`
+
+	// whether two-factor authentication is enabled
+	TwoFactorEnabled bool `json:"twoFactorEnabled"`
+
+	// whether two-factor authentication is required for all users
+	TwoFactorRequired bool `json:"twoFactorRequired"`
 }
 
 // Validate validates this user
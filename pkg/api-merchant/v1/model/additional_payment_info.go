@@ -45,6 +45,11 @@ type AdditionalPaymentInfo struct {
 
 	// Network fee paid for this transaction
 	NetworkFee *string `json:"networkFee,omitempty"`
+
+	// Decision applied by merchant's underpayment policy
+	// Example: withinTolerance
+	// Enum: [withinTolerance awaitingTopUp acceptedPartial expired topUpElapsed]
+	UnderpaymentDecision *string `json:"underpaymentDecision,omitempty"`
 }
 
 // Validate validates this additional payment info
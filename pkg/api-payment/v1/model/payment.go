@@ -59,6 +59,9 @@ type Payment struct {
 
 	// Merchant's volatility buffer fee percentage (0 if none)
 	FeePercent float64 `json:"feePercent,omitempty"`
+
+	// Merchant's checkout branding (omitted if not configured)
+	Branding *MerchantBranding `json:"branding,omitempty"`
 }
 
 // Validate validates this payment
@@ -37,6 +37,9 @@ type PaymentLink struct {
 	// Example: 39.9
 	// Required: true
 	Price float64 `json:"price"`
+
+	// Merchant's checkout branding (omitted if not configured)
+	Branding *MerchantBranding `json:"branding,omitempty"`
 }
 
 // Validate validates this payment link
@@ -0,0 +1,37 @@
+-- +migrate Up
+-- Exchange-rate audit trail: every conversion that shapes what a customer is
+-- asked to pay (or what a merchant is credited) is persisted together with the
+-- rate, its source (binance, coingecko, stablecoin_composition, ...) and the
+-- quote timestamp, linked to the transaction it priced.
+--
+-- kind:
+--   payment_method — invoice fiat price -> selected crypto (incl. fee markup)
+--   usd_valuation  — invoice fiat price -> USD (transactions.usd_amount)
+--   cross_currency — received crypto -> invoice fiat on auto-accept
+CREATE TABLE IF NOT EXISTS rate_snapshots (
+    id BIGSERIAL PRIMARY KEY,
+    merchant_id BIGINT NOT NULL,
+    payment_id BIGINT NOT NULL,
+    transaction_id BIGINT NOT NULL REFERENCES transactions(id) ON DELETE CASCADE,
+    kind VARCHAR(32) NOT NULL,
+    from_currency VARCHAR(16) NOT NULL,
+    to_currency VARCHAR(16) NOT NULL,
+    from_amount VARCHAR(64) NOT NULL,
+    to_amount VARCHAR(64) NOT NULL,
+    rate NUMERIC NOT NULL,
+    source VARCHAR(32) NOT NULL,
+    fee_percent NUMERIC,
+    charged_amount VARCHAR(64),
+    fetched_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS rate_snapshots_tx ON rate_snapshots (transaction_id);
+CREATE INDEX IF NOT EXISTS rate_snapshots_payment ON rate_snapshots (merchant_id, payment_id);
+CREATE INDEX IF NOT EXISTS rate_snapshots_merchant_created ON rate_snapshots (merchant_id, created_at);
+
+-- +migrate Down
+DROP INDEX IF EXISTS rate_snapshots_merchant_created;
+DROP INDEX IF EXISTS rate_snapshots_payment;
+DROP INDEX IF EXISTS rate_snapshots_tx;
+DROP TABLE IF EXISTS rate_snapshots;
@@ -0,0 +1,73 @@
+-- +migrate Up
+-- Recurring billing for merchants' own customers. A merchant defines plans
+-- (fiat price + interval), subscribes a customer to a plan, and the scheduler
+-- issues a regular payment (invoice) for every billing period.
+CREATE TABLE IF NOT EXISTS billing_plans (
+    id BIGSERIAL PRIMARY KEY,
+    uuid UUID NOT NULL UNIQUE,
+    merchant_id BIGINT NOT NULL REFERENCES merchants(id) ON DELETE CASCADE,
+    name VARCHAR(128) NOT NULL,
+    description TEXT,
+    price NUMERIC(64,0) NOT NULL,          -- fiat minor units (cents)
+    currency VARCHAR(8) NOT NULL,
+    billing_interval VARCHAR(8) NOT NULL,  -- day | week | month | year
+    interval_count INTEGER NOT NULL DEFAULT 1,
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMP NOT NULL DEFAULT now(),
+    updated_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS billing_plans_merchant ON billing_plans (merchant_id);
+
+-- status: pending (first invoice unpaid) | active | past_due | cancelled
+CREATE TABLE IF NOT EXISTS customer_subscriptions (
+    id BIGSERIAL PRIMARY KEY,
+    uuid UUID NOT NULL UNIQUE,
+    merchant_id BIGINT NOT NULL REFERENCES merchants(id) ON DELETE CASCADE,
+    plan_id BIGINT NOT NULL REFERENCES billing_plans(id),
+    customer_id BIGINT NOT NULL REFERENCES customers(id),
+    status VARCHAR(16) NOT NULL,
+    current_period_start TIMESTAMP NOT NULL,
+    current_period_end TIMESTAMP NOT NULL,
+    cancel_at_period_end BOOLEAN NOT NULL DEFAULT false,
+    past_due_since TIMESTAMP,
+    cancelled_at TIMESTAMP,
+    is_test BOOLEAN NOT NULL DEFAULT false,
+    created_at TIMESTAMP NOT NULL DEFAULT now(),
+    updated_at TIMESTAMP NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS customer_subscriptions_merchant ON customer_subscriptions (merchant_id);
+CREATE INDEX IF NOT EXISTS customer_subscriptions_due ON customer_subscriptions (status, current_period_end);
+
+-- One invoice per subscription period. payment_id points at the payment the
+-- customer is asked to pay; it is replaced when an unpaid payment expires and
+-- a reminder re-issues a fresh pay link.
+-- status: open | paid | void
+CREATE TABLE IF NOT EXISTS customer_subscription_invoices (
+    id BIGSERIAL PRIMARY KEY,
+    subscription_id BIGINT NOT NULL REFERENCES customer_subscriptions(id) ON DELETE CASCADE,
+    payment_id BIGINT NOT NULL,
+    period_start TIMESTAMP NOT NULL,
+    period_end TIMESTAMP NOT NULL,
+    status VARCHAR(8) NOT NULL DEFAULT 'open',
+    reminders_sent INTEGER NOT NULL DEFAULT 0,
+    last_notified_at TIMESTAMP NOT NULL DEFAULT now(),
+    paid_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT now(),
+    updated_at TIMESTAMP NOT NULL DEFAULT now(),
+    CONSTRAINT customer_subscription_invoices_period UNIQUE (subscription_id, period_start)
+);
+
+CREATE INDEX IF NOT EXISTS customer_subscription_invoices_payment ON customer_subscription_invoices (payment_id);
+CREATE INDEX IF NOT EXISTS customer_subscription_invoices_open ON customer_subscription_invoices (status, last_notified_at);
+
+-- +migrate Down
+DROP INDEX IF EXISTS customer_subscription_invoices_open;
+DROP INDEX IF EXISTS customer_subscription_invoices_payment;
+DROP TABLE IF EXISTS customer_subscription_invoices;
+DROP INDEX IF EXISTS customer_subscriptions_due;
+DROP INDEX IF EXISTS customer_subscriptions_merchant;
+DROP TABLE IF EXISTS customer_subscriptions;
+DROP INDEX IF EXISTS billing_plans_merchant;
+DROP TABLE IF EXISTS billing_plans;
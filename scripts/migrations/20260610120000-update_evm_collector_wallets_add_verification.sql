@@ -0,0 +1,14 @@
+-- +migrate Up
+
+-- On-chain verification of collector ownership. Collectors registered before
+-- this migration stay 'pending' until the re-verify job checks them.
+ALTER TABLE evm_collector_wallets ADD COLUMN IF NOT EXISTS deployment_tx_hash varchar(128) NULL;
+ALTER TABLE evm_collector_wallets ADD COLUMN IF NOT EXISTS verification_status varchar(16) NOT NULL DEFAULT 'pending';
+ALTER TABLE evm_collector_wallets ADD COLUMN IF NOT EXISTS verification_error text NULL;
+ALTER TABLE evm_collector_wallets ADD COLUMN IF NOT EXISTS verified_at timestamp(0) NULL;
+
+-- +migrate Down
+ALTER TABLE evm_collector_wallets DROP COLUMN IF EXISTS deployment_tx_hash;
+ALTER TABLE evm_collector_wallets DROP COLUMN IF EXISTS verification_status;
+ALTER TABLE evm_collector_wallets DROP COLUMN IF EXISTS verification_error;
+ALTER TABLE evm_collector_wallets DROP COLUMN IF EXISTS verified_at;
@@ -0,0 +1,28 @@
+-- +migrate Up
+
+-- Server-assisted collector deployments. Backend prepares factory deploy(owner)
+-- calldata, merchant signs it in their wallet and the scheduler watches for
+-- CloneCreated(owner, clone) to register the collector. One row per merchant per chain.
+CREATE TABLE IF NOT EXISTS evm_collector_deployments (
+    id               bigserial PRIMARY KEY,
+    uuid             uuid NOT NULL UNIQUE DEFAULT gen_random_uuid(),
+    merchant_id      bigint NOT NULL REFERENCES merchants(id),
+    blockchain       varchar(16) NOT NULL,
+    chain_id         int NOT NULL,
+    factory_address  varchar(64) NOT NULL,
+    owner_address    varchar(64) NOT NULL,
+    status           varchar(16) NOT NULL,
+    search_from      bigint NOT NULL DEFAULT 0,
+    tx_hash          varchar(128) NULL,
+    contract_address varchar(64) NULL,
+    error            text NULL,
+    created_at       timestamp(0) NOT NULL,
+    updated_at       timestamp(0) NOT NULL,
+    expires_at       timestamp(0) NOT NULL,
+    CONSTRAINT evm_collector_deployments_merchant_blockchain UNIQUE (merchant_id, blockchain)
+);
+
+CREATE INDEX IF NOT EXISTS evm_collector_deployments_status ON evm_collector_deployments (status);
+
+-- +migrate Down
+DROP TABLE IF EXISTS evm_collector_deployments;
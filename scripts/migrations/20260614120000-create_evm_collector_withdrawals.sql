@@ -0,0 +1,29 @@
+-- +migrate Up
+
+-- Settlement table: owner withdrawals from collector contracts indexed from
+-- WithdrewNative / WithdrewToken events. Amount is in the asset's smallest units.
+CREATE TABLE IF NOT EXISTS evm_collector_withdrawals (
+    id             bigserial PRIMARY KEY,
+    collector_id   bigint NOT NULL REFERENCES evm_collector_wallets(id) ON DELETE CASCADE,
+    merchant_id    bigint NOT NULL REFERENCES merchants(id),
+    blockchain     varchar(16) NOT NULL,
+    token_contract varchar(64) NULL,
+    ticker         varchar(16) NOT NULL,
+    decimals       int NOT NULL,
+    to_address     varchar(64) NOT NULL,
+    amount         numeric(78,0) NOT NULL,
+    tx_hash        varchar(128) NOT NULL,
+    log_index      int NOT NULL,
+    block_number   bigint NOT NULL,
+    created_at     timestamp(0) NOT NULL,
+    CONSTRAINT evm_collector_withdrawals_tx_log UNIQUE (blockchain, tx_hash, log_index)
+);
+
+CREATE INDEX IF NOT EXISTS evm_collector_withdrawals_collector_id ON evm_collector_withdrawals (collector_id, id);
+
+-- Block number (EVM) or block timestamp in ms (TRON) withdrawals were indexed up to
+ALTER TABLE evm_collector_wallets ADD COLUMN IF NOT EXISTS withdrawals_cursor bigint NOT NULL DEFAULT 0;
+
+-- +migrate Down
+ALTER TABLE evm_collector_wallets DROP COLUMN IF EXISTS withdrawals_cursor;
+DROP TABLE IF EXISTS evm_collector_withdrawals;
@@ -0,0 +1,42 @@
+-- +migrate Up
+
+-- Append-only double-entry ledger per merchant. Every balance-affecting event
+-- (receipt, fill, reorg reversal, refund, overpayment, withdrawal, ...) is a
+-- journal identified by (merchant_id, event_key) with two or more lines whose
+-- debits equal credits. Rows are never updated or deleted: corrections are
+-- posted as reversing journals.
+--
+-- amount is in the asset's smallest units; fiat_amount is the value in the
+-- merchant's fiat currency at the time of the event (NULL when no rate was
+-- available), rate is fiat units per one whole asset unit.
+CREATE TABLE IF NOT EXISTS ledger_entries (
+    id               bigserial PRIMARY KEY,
+    merchant_id      bigint NOT NULL REFERENCES merchants(id),
+    event_key        varchar(128) NOT NULL,
+    event_type       varchar(32) NOT NULL,
+    line             int NOT NULL,
+    account          varchar(64) NOT NULL,
+    side             varchar(8) NOT NULL,
+    ticker           varchar(16) NOT NULL,
+    decimals         int NOT NULL,
+    amount           numeric(78,0) NOT NULL CHECK (amount > 0),
+    fiat_currency    varchar(3) NOT NULL,
+    fiat_amount      numeric(36,2) NULL,
+    rate             numeric NULL,
+    rate_source      varchar(32) NULL,
+    rate_snapshot_id bigint NULL REFERENCES rate_snapshots(id) ON DELETE SET NULL,
+    transaction_id   bigint NULL,
+    payment_id       bigint NULL,
+    reverses_key     varchar(128) NULL,
+    description      text NOT NULL DEFAULT '',
+    is_test          boolean NOT NULL DEFAULT false,
+    occurred_at      timestamp(0) NOT NULL,
+    created_at       timestamp(0) NOT NULL,
+    CONSTRAINT ledger_entries_event_line UNIQUE (merchant_id, event_key, line)
+);
+
+CREATE INDEX IF NOT EXISTS ledger_entries_merchant_occurred ON ledger_entries (merchant_id, is_test, occurred_at);
+CREATE INDEX IF NOT EXISTS ledger_entries_transaction ON ledger_entries (transaction_id) WHERE transaction_id IS NOT NULL;
+
+-- +migrate Down
+DROP TABLE IF EXISTS ledger_entries;
@@ -0,0 +1,8 @@
+-- +migrate Up
+
+-- On-chain hash of the transfer behind the journal (payment, fill, refund or
+-- withdrawal). Used as a reference in accounting exports.
+ALTER TABLE ledger_entries ADD COLUMN IF NOT EXISTS tx_hash varchar(128) NULL;
+
+-- +migrate Down
+ALTER TABLE ledger_entries DROP COLUMN IF EXISTS tx_hash;
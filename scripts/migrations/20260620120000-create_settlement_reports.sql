@@ -0,0 +1,21 @@
+-- +migrate Up
+
+-- Sent settlement report emails. Period dates are local to merchant's timezone,
+-- one row per merchant, frequency and period guarantees a report is sent once.
+CREATE TABLE IF NOT EXISTS settlement_reports (
+    id           bigserial PRIMARY KEY,
+    merchant_id  bigint NOT NULL REFERENCES merchants(id),
+    frequency    varchar(16) NOT NULL,
+    period_start date NOT NULL,
+    period_end   date NOT NULL,
+    status       varchar(16) NOT NULL,
+    attempts     int NOT NULL DEFAULT 0,
+    error        text NULL,
+    sent_at      timestamp(0) NULL,
+    created_at   timestamp(0) NOT NULL,
+    updated_at   timestamp(0) NOT NULL,
+    CONSTRAINT settlement_reports_period UNIQUE (merchant_id, frequency, period_start)
+);
+
+-- +migrate Down
+DROP TABLE IF EXISTS settlement_reports;
@@ -0,0 +1,33 @@
+-- +migrate Up
+
+-- Webhook outbox. Rows are written in the same transaction as payment status
+-- change and delivered by the scheduler with retries. Payload is rendered on
+-- the first attempt so that retries deliver the same body.
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id              bigserial PRIMARY KEY,
+    merchant_id     bigint NOT NULL REFERENCES merchants(id),
+    payment_id      bigint NOT NULL REFERENCES payments(id),
+    event_type      varchar(32) NOT NULL,
+    payment_status  varchar(16) NOT NULL,
+    status          varchar(16) NOT NULL,
+    attempts        int NOT NULL DEFAULT 0,
+    payload         jsonb NULL,
+    url             text NULL,
+    response_code   int NULL,
+    last_error      text NULL,
+    next_attempt_at timestamp NOT NULL,
+    last_attempt_at timestamp NULL,
+    -- retries are given up this long after the first attempt, not creation
+    first_attempt_at timestamp NULL,
+    delivered_at    timestamp NULL,
+    created_at      timestamp NOT NULL,
+    updated_at      timestamp NOT NULL
+);
+
+-- due deliveries lookup; deliveries of a payment are sent in id order
+CREATE INDEX IF NOT EXISTS webhook_deliveries_pending ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS webhook_deliveries_payment_id ON webhook_deliveries (payment_id, id);
+CREATE INDEX IF NOT EXISTS webhook_deliveries_merchant_id ON webhook_deliveries (merchant_id, id);
+
+-- +migrate Down
+DROP TABLE IF EXISTS webhook_deliveries;
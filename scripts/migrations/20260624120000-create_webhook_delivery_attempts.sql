@@ -0,0 +1,30 @@
+-- +migrate Up
+ALTER TABLE webhook_deliveries ADD COLUMN IF NOT EXISTS uuid uuid NOT NULL UNIQUE DEFAULT gen_random_uuid();
+ALTER TABLE webhook_deliveries ADD COLUMN IF NOT EXISTS replay_of bigint NULL REFERENCES webhook_deliveries(id) ON DELETE SET NULL;
+
+-- Log of every webhook delivery attempt, kept for a configurable number of days.
+-- Response body is truncated.
+CREATE TABLE IF NOT EXISTS webhook_delivery_attempts (
+    id              bigserial PRIMARY KEY,
+    delivery_id     bigint NOT NULL REFERENCES webhook_deliveries(id) ON DELETE CASCADE,
+    merchant_id     bigint NOT NULL REFERENCES merchants(id),
+    attempt         int NOT NULL,
+    url             text NOT NULL,
+    request_headers jsonb NOT NULL DEFAULT '{}'::jsonb,
+    request_body    text NOT NULL,
+    response_status int NULL,
+    response_body   text NULL,
+    latency_ms      int NOT NULL DEFAULT 0,
+    error_class     varchar(32) NULL,
+    error           text NULL,
+    created_at      timestamp NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS webhook_delivery_attempts_delivery_id ON webhook_delivery_attempts (delivery_id, id);
+CREATE INDEX IF NOT EXISTS webhook_deliveries_created_at ON webhook_deliveries (created_at);
+
+-- +migrate Down
+DROP INDEX IF EXISTS webhook_deliveries_created_at;
+DROP TABLE IF EXISTS webhook_delivery_attempts;
+ALTER TABLE webhook_deliveries DROP COLUMN IF EXISTS replay_of;
+ALTER TABLE webhook_deliveries DROP COLUMN IF EXISTS uuid;
@@ -0,0 +1,65 @@
+-- +migrate Up
+
+-- Merchant webhook endpoints. Every endpoint has its own secret and receives
+-- events it is subscribed to independently; empty event_types means all events.
+-- test_only endpoints receive only test-mode events. disabled_at is set when
+-- the endpoint was disabled after persistent delivery failures.
+CREATE TABLE IF NOT EXISTS webhook_endpoints (
+    id          bigserial PRIMARY KEY,
+    uuid        uuid NOT NULL UNIQUE DEFAULT gen_random_uuid(),
+    merchant_id bigint NOT NULL REFERENCES merchants(id),
+    url         text NOT NULL,
+    secret      text NOT NULL,
+    description varchar(256) NOT NULL DEFAULT '',
+    enabled     boolean NOT NULL DEFAULT true,
+    test_only   boolean NOT NULL DEFAULT false,
+    event_types text[] NOT NULL DEFAULT '{}',
+    disabled_at timestamp NULL,
+    created_at  timestamp NOT NULL,
+    updated_at  timestamp NOT NULL,
+    deleted_at  timestamp NULL
+);
+
+CREATE INDEX IF NOT EXISTS webhook_endpoints_merchant_id ON webhook_endpoints (merchant_id, id) WHERE deleted_at IS NULL;
+
+ALTER TABLE webhook_deliveries ADD COLUMN IF NOT EXISTS endpoint_id bigint NULL REFERENCES webhook_endpoints(id);
+
+CREATE INDEX IF NOT EXISTS webhook_deliveries_endpoint_id ON webhook_deliveries (endpoint_id, id);
+
+-- Move single webhook url of merchant settings into an endpoint subscribed to all events.
+INSERT INTO webhook_endpoints (merchant_id, url, secret, enabled, disabled_at, created_at, updated_at)
+SELECT
+    m.id,
+    m.settings->>'webhook.url',
+    COALESCE(m.settings->>'webhook.secret', ''),
+    COALESCE(m.settings->>'webhook.disabled_at', '') = '',
+    (NULLIF(m.settings->>'webhook.disabled_at', '')::timestamptz AT TIME ZONE 'UTC'),
+    (NOW() AT TIME ZONE 'UTC'),
+    (NOW() AT TIME ZONE 'UTC')
+FROM merchants m
+WHERE COALESCE(m.settings->>'webhook.url', '') <> ''
+  AND NOT EXISTS (SELECT 1 FROM webhook_endpoints e WHERE e.merchant_id = m.id);
+
+UPDATE webhook_deliveries d SET endpoint_id = e.id
+FROM webhook_endpoints e
+WHERE d.endpoint_id IS NULL AND e.merchant_id = d.merchant_id;
+
+UPDATE merchants SET settings = settings - 'webhook.url' - 'webhook.secret' - 'webhook.disabled_at'
+WHERE settings->>'webhook.url' IS NOT NULL
+   OR settings->>'webhook.secret' IS NOT NULL
+   OR settings->>'webhook.disabled_at' IS NOT NULL;
+
+-- +migrate Down
+UPDATE merchants m
+SET settings = COALESCE(m.settings, '{}'::jsonb) || jsonb_build_object('webhook.url', e.url, 'webhook.secret', e.secret)
+FROM (
+    SELECT DISTINCT ON (merchant_id) merchant_id, url, secret
+    FROM webhook_endpoints
+    WHERE deleted_at IS NULL
+    ORDER BY merchant_id, id
+) e
+WHERE m.id = e.merchant_id;
+
+DROP INDEX IF EXISTS webhook_deliveries_endpoint_id;
+ALTER TABLE webhook_deliveries DROP COLUMN IF EXISTS endpoint_id;
+DROP TABLE IF EXISTS webhook_endpoints;
@@ -0,0 +1,10 @@
+-- +migrate Up
+
+-- Secret replaced by rotation stays valid until previous_secret_expires_at;
+-- deliveries are signed with both secrets meanwhile.
+ALTER TABLE webhook_endpoints ADD COLUMN IF NOT EXISTS previous_secret text NULL;
+ALTER TABLE webhook_endpoints ADD COLUMN IF NOT EXISTS previous_secret_expires_at timestamp NULL;
+
+-- +migrate Down
+ALTER TABLE webhook_endpoints DROP COLUMN IF EXISTS previous_secret_expires_at;
+ALTER TABLE webhook_endpoints DROP COLUMN IF EXISTS previous_secret;
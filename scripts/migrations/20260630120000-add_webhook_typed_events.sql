@@ -0,0 +1,20 @@
+-- +migrate Up
+
+-- Typed events: not every event belongs to a payment. event_id is the
+-- envelope id, shared by deliveries of the same event to different endpoints.
+ALTER TABLE webhook_deliveries ALTER COLUMN payment_id DROP NOT NULL;
+ALTER TABLE webhook_deliveries ALTER COLUMN payment_status SET DEFAULT '';
+ALTER TABLE webhook_deliveries ADD COLUMN IF NOT EXISTS event_id uuid NULL;
+
+-- Existing endpoints subscribed to all events keep receiving the events they
+-- know about instead of new typed events they can't parse.
+UPDATE webhook_endpoints
+SET event_types = ARRAY['payment.status', 'subscription.activated', 'subscription.renewed',
+                        'subscription.past_due', 'subscription.cancelled']
+WHERE cardinality(event_types) = 0;
+
+-- +migrate Down
+DELETE FROM webhook_deliveries WHERE payment_id IS NULL;
+ALTER TABLE webhook_deliveries DROP COLUMN IF EXISTS event_id;
+ALTER TABLE webhook_deliveries ALTER COLUMN payment_status DROP DEFAULT;
+ALTER TABLE webhook_deliveries ALTER COLUMN payment_id SET NOT NULL;
@@ -0,0 +1,10 @@
+-- +migrate Up
+
+-- Optional limit of successful payments of a link; the link stops accepting
+-- payments once uses reaches max_uses.
+ALTER TABLE payment_links ADD COLUMN IF NOT EXISTS max_uses int NULL;
+ALTER TABLE payment_links ADD COLUMN IF NOT EXISTS uses int NOT NULL DEFAULT 0;
+
+-- +migrate Down
+ALTER TABLE payment_links DROP COLUMN IF EXISTS uses;
+ALTER TABLE payment_links DROP COLUMN IF EXISTS max_uses;
@@ -0,0 +1,49 @@
+-- +migrate Up
+
+-- Event bus outbox. Messages are written in the publisher's transaction and
+-- read by consumers in (tx_id, id) order: ids are assigned before commit, so
+-- ordering by id alone would skip messages of transactions that commit late.
+CREATE TABLE IF NOT EXISTS event_bus_messages (
+    id         bigserial PRIMARY KEY,
+    topic      varchar(64) NOT NULL,
+    payload    jsonb NOT NULL,
+    tx_id      bigint NOT NULL DEFAULT txid_current(),
+    created_at timestamp NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS event_bus_messages_topic ON event_bus_messages (topic, tx_id, id);
+CREATE INDEX IF NOT EXISTS event_bus_messages_created_at ON event_bus_messages (created_at);
+
+-- Position of every consumer in its topic. The consumer is leased by one
+-- replica at a time: locked_by holds the process until locked_until, the
+-- lease is extended on every processed message.
+CREATE TABLE IF NOT EXISTS event_bus_offsets (
+    consumer        varchar(255) NOT NULL,
+    topic           varchar(64) NOT NULL,
+    last_tx_id      bigint NOT NULL,
+    last_message_id bigint NOT NULL,
+    locked_by       varchar(64) NULL,
+    locked_until    timestamp NULL,
+    created_at      timestamp NOT NULL,
+    updated_at      timestamp NOT NULL,
+    PRIMARY KEY (consumer, topic)
+);
+
+-- Messages a consumer gave up on after all retries.
+CREATE TABLE IF NOT EXISTS event_bus_dead_letters (
+    id         bigserial PRIMARY KEY,
+    consumer   varchar(255) NOT NULL,
+    topic      varchar(64) NOT NULL,
+    message_id bigint NOT NULL,
+    payload    jsonb NOT NULL,
+    attempts   int NOT NULL,
+    last_error text NOT NULL,
+    created_at timestamp NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS event_bus_dead_letters_consumer ON event_bus_dead_letters (consumer, topic, id);
+
+-- +migrate Down
+DROP TABLE IF EXISTS event_bus_dead_letters;
+DROP TABLE IF EXISTS event_bus_offsets;
+DROP TABLE IF EXISTS event_bus_messages;
@@ -0,0 +1,25 @@
+-- +migrate Up
+
+-- Log of typed merchant events streamed over WebSocket. Rows are written in
+-- the same transaction as webhook deliveries of the event and share their
+-- event_id. Read in (tx_id, id) order like event_bus_messages. Payload of
+-- payment events is rendered when the event is streamed for the first time.
+CREATE TABLE IF NOT EXISTS merchant_events (
+    id             bigserial PRIMARY KEY,
+    merchant_id    bigint NOT NULL REFERENCES merchants(id),
+    event_id       uuid NOT NULL,
+    event_type     varchar(32) NOT NULL,
+    payment_id     bigint NULL REFERENCES payments(id),
+    payment_status varchar(16) NOT NULL DEFAULT '',
+    is_test        boolean NOT NULL DEFAULT false,
+    payload        jsonb NULL,
+    tx_id          bigint NOT NULL DEFAULT txid_current(),
+    created_at     timestamp NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS merchant_events_merchant_id ON merchant_events (merchant_id, tx_id, id);
+CREATE INDEX IF NOT EXISTS merchant_events_tx_id ON merchant_events (tx_id, id);
+CREATE INDEX IF NOT EXISTS merchant_events_created_at ON merchant_events (created_at);
+
+-- +migrate Down
+DROP TABLE IF EXISTS merchant_events;
@@ -0,0 +1,31 @@
+-- +migrate Up
+
+-- TOTP second factor of dashboard users. Secret is stored on enrollment and
+-- becomes active once enabled_at is set by a verified code. last_used_step is
+-- the last accepted RFC 6238 time step, codes can't be replayed. Second factor
+-- is locked until locked_until after too many failed_attempts in a row.
+CREATE TABLE IF NOT EXISTS user_totp (
+    user_id         bigint PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    secret          varchar(64) NOT NULL,
+    enabled_at      timestamp NULL,
+    last_used_step  bigint NOT NULL DEFAULT 0,
+    failed_attempts int NOT NULL DEFAULT 0,
+    locked_until    timestamp NULL,
+    created_at      timestamp NOT NULL,
+    updated_at      timestamp NOT NULL
+);
+
+-- One-time recovery codes, only SHA-256 hashes are stored.
+CREATE TABLE IF NOT EXISTS user_recovery_codes (
+    id         bigserial PRIMARY KEY,
+    user_id    bigint NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    code_hash  varchar(64) NOT NULL,
+    used_at    timestamp NULL,
+    created_at timestamp NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS user_recovery_codes_user_id_code_hash ON user_recovery_codes (user_id, code_hash);
+
+-- +migrate Down
+DROP TABLE IF EXISTS user_recovery_codes;
+DROP TABLE IF EXISTS user_totp;
@@ -0,0 +1,18 @@
+-- +migrate Up
+
+-- Single-use password reset tokens, only SHA-256 hashes are stored.
+CREATE TABLE IF NOT EXISTS password_reset_tokens (
+    id         bigserial PRIMARY KEY,
+    user_id    bigint NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token_hash varchar(64) NOT NULL,
+    ip_address varchar(64) NOT NULL DEFAULT '',
+    expires_at timestamp NOT NULL,
+    used_at    timestamp NULL,
+    created_at timestamp NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS password_reset_tokens_token_hash ON password_reset_tokens (token_hash);
+CREATE INDEX IF NOT EXISTS password_reset_tokens_user_id ON password_reset_tokens (user_id, created_at);
+
+-- +migrate Down
+DROP TABLE IF EXISTS password_reset_tokens;
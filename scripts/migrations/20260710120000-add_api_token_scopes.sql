@@ -0,0 +1,18 @@
+-- +migrate Up
+
+-- Merchant API token restrictions. Existing tokens keep full access ("*").
+-- Empty allowed_cidrs allows any client address.
+ALTER TABLE api_tokens
+    ADD COLUMN IF NOT EXISTS scopes        text[] NOT NULL DEFAULT '{*}',
+    ADD COLUMN IF NOT EXISTS expires_at    timestamp NULL,
+    ADD COLUMN IF NOT EXISTS last_used_at  timestamp NULL,
+    ADD COLUMN IF NOT EXISTS last_used_ip  varchar(64) NULL,
+    ADD COLUMN IF NOT EXISTS allowed_cidrs text[] NOT NULL DEFAULT '{}';
+
+-- +migrate Down
+ALTER TABLE api_tokens
+    DROP COLUMN IF EXISTS scopes,
+    DROP COLUMN IF EXISTS expires_at,
+    DROP COLUMN IF EXISTS last_used_at,
+    DROP COLUMN IF EXISTS last_used_ip,
+    DROP COLUMN IF EXISTS allowed_cidrs;
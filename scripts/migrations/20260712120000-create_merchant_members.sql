@@ -0,0 +1,42 @@
+-- +migrate Up
+
+-- Users with access to a merchant and their role.
+CREATE TABLE IF NOT EXISTS merchant_members (
+    id          bigserial PRIMARY KEY,
+    merchant_id bigint NOT NULL REFERENCES merchants(id) ON DELETE CASCADE,
+    user_id     bigint NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role        varchar(16) NOT NULL,
+    created_at  timestamp NOT NULL,
+    updated_at  timestamp NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS merchant_members_merchant_user ON merchant_members (merchant_id, user_id);
+CREATE INDEX IF NOT EXISTS merchant_members_user_id ON merchant_members (user_id);
+
+-- Merchant creators become owners.
+INSERT INTO merchant_members (merchant_id, user_id, role, created_at, updated_at)
+SELECT m.id, m.creator_id, 'owner', NOW(), NOW() FROM merchants m
+JOIN users u ON u.id = m.creator_id
+ON CONFLICT (merchant_id, user_id) DO NOTHING;
+
+-- Pending invitations by email, only SHA-256 hashes of tokens are stored.
+CREATE TABLE IF NOT EXISTS merchant_invitations (
+    id          bigserial PRIMARY KEY,
+    uuid        uuid NOT NULL,
+    merchant_id bigint NOT NULL REFERENCES merchants(id) ON DELETE CASCADE,
+    email       varchar(255) NOT NULL,
+    role        varchar(16) NOT NULL,
+    token_hash  varchar(64) NOT NULL,
+    invited_by  bigint NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    expires_at  timestamp NOT NULL,
+    accepted_at timestamp NULL,
+    created_at  timestamp NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS merchant_invitations_uuid ON merchant_invitations (uuid);
+CREATE UNIQUE INDEX IF NOT EXISTS merchant_invitations_token_hash ON merchant_invitations (token_hash);
+CREATE INDEX IF NOT EXISTS merchant_invitations_merchant_id ON merchant_invitations (merchant_id, created_at);
+
+-- +migrate Down
+DROP TABLE IF EXISTS merchant_invitations;
+DROP TABLE IF EXISTS merchant_members;
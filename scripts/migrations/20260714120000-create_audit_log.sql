@@ -0,0 +1,42 @@
+-- +migrate Up
+
+-- Append-only security audit log of merchant configuration changes: webhooks,
+-- payment methods, fees, API tokens, xpub wallets, EVM collectors and team
+-- members. Secrets are redacted before insert. Actor and merchant ids have no
+-- foreign keys so that entries outlive deleted users and tokens.
+CREATE TABLE IF NOT EXISTS audit_log (
+    id             bigserial PRIMARY KEY,
+    uuid           uuid NOT NULL,
+    merchant_id    bigint NULL,
+    actor_type     varchar(16) NOT NULL,
+    actor_user_id  bigint NULL,
+    actor_token_id bigint NULL,
+    actor_email    varchar(255) NOT NULL DEFAULT '',
+    ip             varchar(64) NOT NULL DEFAULT '',
+    user_agent     varchar(512) NOT NULL DEFAULT '',
+    action         varchar(64) NOT NULL,
+    resource_id    varchar(64) NOT NULL DEFAULT '',
+    before         jsonb NULL,
+    after          jsonb NULL,
+    created_at     timestamp NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS audit_log_uuid ON audit_log (uuid);
+CREATE INDEX IF NOT EXISTS audit_log_merchant_id ON audit_log (merchant_id, id);
+CREATE INDEX IF NOT EXISTS audit_log_action ON audit_log (action, id);
+
+-- +migrate StatementBegin
+CREATE OR REPLACE FUNCTION audit_log_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+-- +migrate StatementEnd
+
+CREATE TRIGGER audit_log_append_only
+    BEFORE UPDATE OR DELETE ON audit_log
+    FOR EACH ROW EXECUTE FUNCTION audit_log_append_only();
+
+-- +migrate Down
+DROP TABLE IF EXISTS audit_log;
+DROP FUNCTION IF EXISTS audit_log_append_only();
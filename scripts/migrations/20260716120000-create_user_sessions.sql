@@ -0,0 +1,23 @@
+-- +migrate Up
+
+-- Server-side records of dashboard sessions. The session cookie keeps only
+-- uuid of the record, so sessions can be listed and revoked.
+CREATE TABLE IF NOT EXISTS user_sessions (
+    id           bigserial PRIMARY KEY,
+    uuid         uuid NOT NULL,
+    user_id      bigint NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    auth_method  varchar(32) NOT NULL,
+    two_factor   boolean NOT NULL DEFAULT false,
+    ip_address   varchar(64) NOT NULL DEFAULT '',
+    user_agent   varchar(512) NOT NULL DEFAULT '',
+    created_at   timestamp NOT NULL,
+    last_seen_at timestamp NOT NULL,
+    expires_at   timestamp NOT NULL,
+    revoked_at   timestamp NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS user_sessions_uuid ON user_sessions (uuid);
+CREATE INDEX IF NOT EXISTS user_sessions_user_id ON user_sessions (user_id, id);
+
+-- +migrate Down
+DROP TABLE IF EXISTS user_sessions;
@@ -19,8 +19,11 @@ INSERT INTO api_tokens (
     token,
     uuid,
     name,
-    settings
-) VALUES ($1, $2, $3, $4, $5, $6, $7)
+    settings,
+    scopes,
+    expires_at,
+    allowed_cidrs
+) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 RETURNING *;
 
 -- name: DeleteAPITokenByToken :exec
@@ -29,4 +32,15 @@ WHERE token = $1;
 
 -- name: DeleteAPITokenByID :exec
 DELETE from api_tokens
-WHERE id = $1;
\ No newline at end of file
+WHERE id = $1;
+
+-- name: DeleteAPITokensByUser :execrows
+-- DeleteAPITokensByUser deletes user's tokens and tokens of merchants created by the user.
+DELETE from api_tokens
+WHERE (entity_type = 'user' AND entity_id = @user_id)
+   OR (entity_type = 'merchant' AND entity_id IN (SELECT id FROM merchants WHERE creator_id = @user_id));
+
+-- name: UpdateAPITokenLastUsed :exec
+UPDATE api_tokens
+SET last_used_at = $2, last_used_ip = $3
+WHERE id = $1;
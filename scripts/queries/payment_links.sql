@@ -25,10 +25,15 @@ INSERT INTO payment_links (
   success_action,
   redirect_url,
   success_message,
-  is_test
-) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+  is_test,
+  max_uses
+) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
 RETURNING *;
 
 
 -- name: DeletePaymentLinkByPublicID :exec
 delete from payment_links where merchant_id = $1 and uuid = $2;
+
+-- name: IncrementPaymentLinkUses :one
+UPDATE payment_links SET uses = uses + 1, updated_at = $2 WHERE id = $1
+RETURNING *;
@@ -77,6 +77,26 @@ expires_at = (CASE WHEN @set_expires_at::boolean THEN $5 ELSE payments.expires_a
 WHERE id = $1 and merchant_id = $2
 returning *;
 
--- name: UpdatePaymentWebhookInfo :exec
-UPDATE payments set webhook_sent_at = $3, updated_at = $4
-WHERE id = $1 and merchant_id = $2;
+-- name: ExtendPaymentExpiry :one
+-- ExtendPaymentExpiry sets a new expires_at for a payment, hard-capped at
+-- original_expires_at + MaxExtensionMinutes (merchant's top-up window) to
+-- prevent infinite extension via dust top-ups.
+-- Also updates the status (typically to "partial"). Returns the actual
+-- expires_at applied (may be earlier than requested due to the cap).
+UPDATE payments
+SET status = @status,
+    updated_at = @updated_at,
+    expires_at = LEAST(
+        @requested_expires_at::timestamp,
+        COALESCE(original_expires_at, expires_at) + make_interval(mins => @max_extension_minutes::int)
+    )
+WHERE id = @id AND merchant_id = @merchant_id
+RETURNING id, expires_at;
+
+-- name: SetPaymentMetadataValue :exec
+-- SetPaymentMetadataValue sets a single key of payment's metadata leaving
+-- the rest intact.
+UPDATE payments
+SET metadata = COALESCE(metadata, '{}'::jsonb) || jsonb_build_object(@key::text, @value::text),
+    updated_at = @updated_at
+WHERE id = @id AND merchant_id = @merchant_id;
@@ -57,6 +57,10 @@ where id = $1;
 -- name: SetTransactionHash :exec
 update transactions set transaction_hash = $1, updated_at = $2 where id = $3 and merchant_id = $4;
 
+-- name: MergeTransactionMetadata :exec
+update transactions set metadata = coalesce(metadata, '{}'::jsonb) || @metadata::jsonb
+where id = @id and merchant_id = @merchant_id;
+
 -- name: GetTransactionsByFilter :many
 select * from transactions
 where (CASE WHEN @filter_by_recipient_wallet_id::boolean THEN recipient_wallet_id = $1 ELSE true END)